@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/logger"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// cliOptions are the flags the commands running the pipeline in-process share
+type cliOptions struct {
+	devMode bool
+	verbose bool
+}
+
+// openService creates the transcriber service for a command, on the data of the server. The
+// server must not be running, since both would write the event log and the server's child
+// processes would be taken for orphans. The background watchers that start meetings on their
+// own are left off. Logs go to stderr, errors only unless verbose is set, so the output of
+// the command can be piped.
+func openService(opts cliOptions) (*transcriber.TranscriberService, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+	if opts.devMode {
+		cfg.DevMode = true
+	}
+	if conn, err := net.DialTimeout("tcp", cfg.Server.Addr(), 500*time.Millisecond); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a server is running on %s, use its API or stop it first", cfg.Server.Addr())
+	}
+	cfg.Calendar.URL = ""
+	cfg.MeetingApps.Enabled = false
+	cfg.Watch.Folder = ""
+	cfg.Update.Enabled = false
+
+	level := slog.LevelError
+	if opts.verbose {
+		level = slog.LevelDebug
+	}
+	stderr := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: level}))
+	cliLogger := &logger.Logger{
+		Info:  stderr.Info,
+		Error: stderr.Error,
+		Debug: stderr.Debug,
+	}
+
+	return transcriber.NewTranscriberService(cfg, cliLogger)
+}
+
+// waitForMeeting waits until the pipeline is done with a meeting or ctx is done
+func waitForMeeting(ctx context.Context, service *transcriber.TranscriberService, meetingId string) (*types.Meeting, error) {
+	for {
+		meeting, err := service.GetMeetingStatus(meetingId)
+		if err != nil {
+			return nil, err
+		}
+		if settled(meeting.Status) {
+			return meeting, nil
+		}
+		if _, err := service.WaitForStatusChange(ctx, meetingId, meeting.Status); err != nil {
+			return nil, err
+		}
+		if ctx.Err() != nil {
+			return meeting, fmt.Errorf("stopped waiting for meeting %s with status %s, it resumes on the next start", meetingId, meeting.Status)
+		}
+	}
+}
+
+// settled reports whether the pipeline is done with a meeting with the status, including the
+// meetings left waiting for an external transcript or transcription engine
+func settled(status string) bool {
+	switch types.MeetingStatus(status) {
+	case types.MeetingStatusAwaitingTranscript, types.MeetingStatusDeferred:
+		return true
+	}
+	return finalStatus(status)
+}
+
+// meetingResult returns the error of a failed meeting, or describes why a meeting is waiting
+func meetingResult(meeting *types.Meeting) error {
+	switch types.MeetingStatus(meeting.Status) {
+	case types.MeetingStatusFailed:
+		return fmt.Errorf("meeting %s failed: %s", meeting.Id, meeting.Error)
+	case types.MeetingStatusAwaitingTranscript:
+		return fmt.Errorf("meeting %s is waiting for its transcript from the external service", meeting.Id)
+	case types.MeetingStatusDeferred:
+		return fmt.Errorf("meeting %s is deferred until the transcription engine is available", meeting.Id)
+	}
+	return nil
+}
+
+// splitList splits a comma separated flag value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
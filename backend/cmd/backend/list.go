@@ -0,0 +1,53 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// runList prints the meetings matching a filter, newest first unless sorted otherwise
+func runList(args []string) error {
+	flags := flag.NewFlagSet("list", flag.ExitOnError)
+	filterExpr := flags.String("filter", "", `filter expression, e.g. "since:30d tag:standup status:completed"`)
+	sort := flags.String("sort", "", "field to sort by, prefixed with - for descending order")
+	limit := flags.Int("limit", 0, "maximum number of meetings, 0 lists all")
+	asJSON := flags.Bool("json", false, "print the meetings as JSON")
+	var opts cliOptions
+	flags.BoolVar(&opts.verbose, "verbose", false, "log to stderr")
+	flags.Parse(args)
+
+	filter, err := transcriber.ParseMeetingFilter(*filterExpr)
+	if err != nil {
+		return err
+	}
+	if err := transcriber.ValidateSort(*sort); err != nil {
+		return err
+	}
+
+	service, err := openService(opts)
+	if err != nil {
+		return err
+	}
+	defer service.Shutdown()
+
+	meetings, _ := service.ListMeetings(transcriber.ListOptions{Filter: filter, Sort: *sort, Limit: *limit})
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(meetings)
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tCREATED\tDURATION\tSTATUS\tTITLE")
+	for _, meeting := range meetings {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", meeting.Id, meeting.CreatedAt.Local().Format("2006-01-02 15:04"),
+			time.Duration(meeting.Duration)*time.Second, meeting.Status, meeting.Title)
+	}
+	return w.Flush()
+}
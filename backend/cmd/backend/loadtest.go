@@ -0,0 +1,248 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/api"
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/logger"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// loadTestResult is the outcome of a single simulated meeting
+type loadTestResult struct {
+	pipeline time.Duration // From queueing until the meeting reached a final status
+	status   string
+}
+
+// latencies collects API request durations from concurrent clients
+type latencies struct {
+	mu     sync.Mutex
+	values []time.Duration
+	errors atomic.Int64
+}
+
+func (l *latencies) add(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.values = append(l.values, d)
+}
+
+// runLoadTest pushes simulated meetings through the pipeline with the fake transcription
+// engine and summarizer while clients poll the API, then prints throughput and latencies.
+// Everything it writes goes to a temporary directory that is removed afterwards.
+func runLoadTest(args []string) error {
+	flags := flag.NewFlagSet("loadtest", flag.ExitOnError)
+	meetings := flags.Int("meetings", 100, "number of simulated meetings")
+	clients := flags.Int("clients", 8, "number of concurrent API clients")
+	timeout := flags.Duration("timeout", 5*time.Minute, "maximum duration of the run")
+	cpuProfile := flags.String("cpuprofile", "", "write a CPU profile to this file")
+	memProfile := flags.String("memprofile", "", "write a heap profile to this file when done")
+	flags.Parse(args)
+
+	if *meetings < 1 || *clients < 1 {
+		return fmt.Errorf("meetings and clients must be at least 1")
+	}
+
+	// Keep the vault notes, templates and other state out of the user's home directory
+	tempDir, err := osoperations.CreateTempDir("loadtest")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tempDir)
+	os.Setenv("HOME", tempDir)
+	os.Setenv("TRANSCRIBER_DATA_DIR", tempDir)
+
+	cfg := config.Default()
+	cfg.Transcription.Engine = config.TranscriptionEngineFake
+	cfg.Ollama.Fake = true
+
+	// Only errors are logged so the pipeline output doesn't drown the report
+	errorLog := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelError}))
+	quiet := &logger.Logger{
+		Info:  func(string, ...any) {},
+		Error: errorLog.Error,
+		Debug: func(string, ...any) {},
+	}
+
+	service, err := transcriber.NewTranscriberService(cfg, quiet)
+	if err != nil {
+		return err
+	}
+	server, err := api.NewServer(cfg, quiet, service)
+	if err != nil {
+		return err
+	}
+	httpServer := httptest.NewServer(server.Handler())
+	defer httpServer.Close()
+
+	if *cpuProfile != "" {
+		f, err := os.Create(*cpuProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := pprof.StartCPUProfile(f); err != nil {
+			return err
+		}
+		defer pprof.StopCPUProfile()
+	}
+
+	fmt.Printf("Load test: %d meetings, %d API clients\n", *meetings, *clients)
+	start := time.Now()
+	deadline := start.Add(*timeout)
+
+	// Queue all meetings up front so the queue depth matches a burst of stopped recordings
+	queuedAt := make(map[string]time.Time, *meetings)
+	ids := make([]string, 0, *meetings)
+	for i := 0; i < *meetings; i++ {
+		id, err := service.SimulateMeeting(transcriber.RecordingOptions{
+			Title:        fmt.Sprintf("Load test meeting %d", i+1),
+			Participants: []string{"Alice", "Bob", "Carol"},
+			Tags:         []string{"loadtest"},
+		})
+		if err != nil {
+			return err
+		}
+		queuedAt[id] = time.Now()
+		ids = append(ids, id)
+	}
+
+	// API clients poll the meetings until every one of them has finished processing
+	var (
+		requests latencies
+		results  sync.Map // Meeting ID -> loadTestResult
+		done     atomic.Int64
+		wg       sync.WaitGroup
+	)
+	client := &http.Client{Timeout: 10 * time.Second}
+	for c := 0; c < *clients; c++ {
+		wg.Add(1)
+		go func(c int) {
+			defer wg.Done()
+			for i := c; done.Load() < int64(len(ids)) && time.Now().Before(deadline); i++ {
+				if i%10 == 0 {
+					// Regularly fetch the full list, which scales with the store size
+					requestJSON(client, httpServer.URL+"/api/v1/meetings", &requests, nil)
+				}
+
+				id := ids[i%len(ids)]
+				if _, finished := results.Load(id); finished {
+					continue
+				}
+				var meeting types.Meeting
+				if !requestJSON(client, httpServer.URL+"/api/v1/meetings/"+id, &requests, &meeting) {
+					continue
+				}
+				if finalStatus(meeting.Status) {
+					if _, loaded := results.LoadOrStore(id, loadTestResult{pipeline: time.Since(queuedAt[id]), status: meeting.Status}); !loaded {
+						done.Add(1)
+					}
+				}
+			}
+		}(c)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	if *memProfile != "" {
+		f, err := os.Create(*memProfile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		runtime.GC()
+		if err := pprof.WriteHeapProfile(f); err != nil {
+			return err
+		}
+	}
+
+	// ===========================================================================
+	// Report
+	// ===========================================================================
+	var pipeline []time.Duration
+	statuses := make(map[string]int)
+	results.Range(func(_, value any) bool {
+		result := value.(loadTestResult)
+		pipeline = append(pipeline, result.pipeline)
+		statuses[result.status]++
+		return true
+	})
+
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	fmt.Printf("Finished %d/%d meetings in %s (%.1f meetings/s)\n", len(pipeline), len(ids), elapsed.Round(time.Millisecond), float64(len(pipeline))/elapsed.Seconds())
+	for status, count := range statuses {
+		fmt.Printf("  %-16s %d\n", status, count)
+	}
+	printPercentiles("Pipeline latency", pipeline)
+	printPercentiles("API latency", requests.values)
+	fmt.Printf("API requests: %d (%.0f req/s), errors: %d\n", len(requests.values), float64(len(requests.values))/elapsed.Seconds(), requests.errors.Load())
+	fmt.Printf("Heap in use: %.1f MB, goroutines: %d\n", float64(mem.HeapInuse)/(1<<20), runtime.NumGoroutine())
+
+	if len(pipeline) < len(ids) {
+		return fmt.Errorf("%d meetings did not finish within %s", len(ids)-len(pipeline), *timeout)
+	}
+	return nil
+}
+
+// requestJSON performs a GET request, records its latency and decodes the body into out
+func requestJSON(client *http.Client, url string, requests *latencies, out any) bool {
+	start := time.Now()
+	resp, err := client.Get(url)
+	if err != nil {
+		requests.errors.Add(1)
+		return false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		requests.errors.Add(1)
+		return false
+	}
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			requests.errors.Add(1)
+			return false
+		}
+	}
+	requests.add(time.Since(start))
+	return true
+}
+
+// finalStatus reports whether the pipeline is done with a meeting
+func finalStatus(status string) bool {
+	switch types.MeetingStatus(status) {
+	case types.MeetingStatusCompleted, types.MeetingStatusTranscriptOnly, types.MeetingStatusFailed:
+		return true
+	}
+	return false
+}
+
+func printPercentiles(name string, values []time.Duration) {
+	if len(values) == 0 {
+		fmt.Printf("%s: no samples\n", name)
+		return
+	}
+	sorted := slices.Clone(values)
+	slices.Sort(sorted)
+	percentile := func(p float64) time.Duration {
+		return sorted[int(p*float64(len(sorted)-1))].Round(time.Microsecond)
+	}
+	fmt.Printf("%s: p50 %s, p95 %s, p99 %s, max %s\n", name, percentile(0.50), percentile(0.95), percentile(0.99), sorted[len(sorted)-1].Round(time.Microsecond))
+}
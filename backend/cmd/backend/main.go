@@ -1,25 +1,54 @@
 package main
 
 import (
+	"fmt"
 	"log"
 	"os"
-
-	"github.com/martijnspitter/transcriber/internal/api"
-	"github.com/martijnspitter/transcriber/internal/logger"
-	"github.com/martijnspitter/transcriber/internal/transcriber"
+	"strings"
 )
 
-func main() {
-	logger := logger.NewLogger()
-	logger.Info("Starting Transcriber API server...")
+// commands maps the subcommands of the binary to their implementation
+var commands = map[string]func(args []string) error{
+	"serve":       runServe,
+	"record":      runRecord,
+	"transcribe":  runTranscribe,
+	"summarize":   runSummarize,
+	"list":        runList,
+	"loadtest":    runLoadTest,
+	"self-update": runSelfUpdate,
+}
+
+const usage = `Usage: transcriber <command> [flags]
+
+Commands:
+  serve        run the API server (the default)
+  record       record a meeting until interrupted and print its summary
+  transcribe   transcribe an audio file and print the transcript
+  summarize    summarize a transcript file and print the summary
+  list         list the meetings
+  loadtest     push simulated meetings through the pipeline and report latencies
+  self-update  install the latest release
 
-	transcriber := transcriber.NewTranscriberService(logger)
+Run transcriber <command> -h for the flags of a command.
+`
 
-	// Create a new API server
-	server := api.NewServer(logger, transcriber)
+func main() {
+	// Without a command, or with the server flags only, the server starts as before
+	name, args := "serve", os.Args[1:]
+	if len(args) > 0 && !strings.HasPrefix(args[0], "-") {
+		name, args = args[0], args[1:]
+	}
+	if name == "help" {
+		fmt.Print(usage)
+		return
+	}
+	run, ok := commands[name]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown command %q\n\n%s", name, usage)
+		os.Exit(2)
+	}
 
-	// Start the server
-	if err := server.Start(); err != nil {
+	if err := run(args); err != nil {
 		log.Printf("Error: %v", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// runRecord records a meeting until it is interrupted, the duration has passed or the
+// recording stops by itself, then waits for the pipeline and prints the summary
+func runRecord(args []string) error {
+	flags := flag.NewFlagSet("record", flag.ExitOnError)
+	title := flags.String("title", "", "title of the meeting")
+	meetingType := flags.String("type", "", "meeting preset providing the template, vault folder, participants and tags")
+	template := flags.String("template", "", "summarization template")
+	participants := flags.String("participants", "", "comma separated participants")
+	tags := flags.String("tags", "", "comma separated tags")
+	stages := flags.String("stages", "", "comma separated processing stages, e.g. transcribe to skip the summary")
+	duration := flags.Duration("duration", 0, "stop recording after this duration, 0 records until interrupted")
+	var opts cliOptions
+	flags.BoolVar(&opts.devMode, "dev-mode", false, "simulate recording with a sample WAV and generate the transcript")
+	flags.BoolVar(&opts.verbose, "verbose", false, "log the pipeline to stderr")
+	flags.Parse(args)
+
+	service, err := openService(opts)
+	if err != nil {
+		return err
+	}
+	defer service.Shutdown()
+
+	meetingId, err := service.StartRecording(transcriber.RecordingOptions{
+		Title:        *title,
+		Type:         *meetingType,
+		Template:     *template,
+		Participants: splitList(*participants),
+		Tags:         splitList(*tags),
+		Stages:       splitList(*stages),
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Recording meeting %s, press Ctrl-C to stop\n", meetingId)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	recordCtx := ctx
+	if *duration > 0 {
+		var cancel context.CancelFunc
+		recordCtx, cancel = context.WithTimeout(ctx, *duration)
+		defer cancel()
+	}
+	started := time.Now()
+	if _, err := service.WaitForStatusChange(recordCtx, meetingId, string(types.MeetingStatusRecording)); err != nil {
+		stop()
+		return err
+	}
+	stop()
+	if err := service.StopMeeting(meetingId); err != nil && !errors.Is(err, transcriber.ErrAlreadyStopped) {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Stopped after %s, processing the recording, press Ctrl-C to leave it for the next start\n", time.Since(started).Round(time.Second))
+
+	// A second interrupt stops waiting, the shutdown persists the meeting
+	ctx, stop = signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	meeting, err := waitForMeeting(ctx, service, meetingId)
+	if err != nil {
+		return err
+	}
+	if err := meetingResult(meeting); err != nil {
+		return err
+	}
+	if meeting.Summary != "" {
+		fmt.Println(meeting.Summary)
+	} else {
+		fmt.Println(meeting.Transcript)
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/update"
+)
+
+// runSelfUpdate replaces the binary with the latest release of the configured channel
+func runSelfUpdate(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	flags := flag.NewFlagSet("self-update", flag.ExitOnError)
+	channel := flags.String("channel", cfg.Update.Channel, "release channel: stable or prerelease")
+	check := flags.Bool("check", false, "only report whether an update is available")
+	force := flags.Bool("force", false, "install even when the running version is not older, e.g. a development build")
+	flags.Parse(args)
+
+	if *channel != update.ChannelStable && *channel != update.ChannelPrerelease {
+		return fmt.Errorf("unknown channel %q, use %s or %s", *channel, update.ChannelStable, update.ChannelPrerelease)
+	}
+	cfg.Update.Channel = *channel
+	checker := update.NewChecker(cfg.Update)
+
+	release, err := checker.Latest()
+	if err != nil {
+		return err
+	}
+	available := update.Newer(release.Tag, update.Version)
+	fmt.Printf("Running %s, latest %s release is %s\n", update.Version, *channel, release.Tag)
+	if *check {
+		if available {
+			fmt.Printf("Update available: %s\n", release.URL)
+		}
+		return nil
+	}
+	if !available && !*force {
+		fmt.Println("Already up to date")
+		return nil
+	}
+
+	path, err := checker.Install(release)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Installed %s to %s, restart the server to use it\n", release.Tag, path)
+	return nil
+}
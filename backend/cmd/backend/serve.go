@@ -0,0 +1,47 @@
+package main
+
+import (
+	"flag"
+
+	"github.com/martijnspitter/transcriber/internal/api"
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/logger"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// runServe runs the API server until it is interrupted
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	devMode := flags.Bool("dev-mode", false, "simulate recording with a sample WAV and generate transcripts, so the server runs without ffmpeg and whisper")
+	flags.Parse(args)
+
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+
+	logger, err := logger.New(cfg.Log)
+	if err != nil {
+		return err
+	}
+	logger.Info("Starting Transcriber API server...")
+	if *devMode {
+		cfg.DevMode = true
+	}
+
+	transcriber, err := transcriber.NewTranscriberService(cfg, logger)
+	if err != nil {
+		return err
+	}
+
+	// Create a new API server
+	server, err := api.NewServer(cfg, logger, transcriber)
+	if err != nil {
+		return err
+	}
+
+	// Start the server, finishing or persisting the work in flight once it shuts down
+	err = server.Start()
+	transcriber.Shutdown()
+	return err
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/prompts"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// runSummarize summarizes a plain text or markdown transcript with Ollama and prints the
+// summary. Nothing is stored: use the import endpoint to add a transcript to the vault.
+func runSummarize(args []string) error {
+	flags := flag.NewFlagSet("summarize", flag.ExitOnError)
+	title := flags.String("title", "", "title of the meeting, empty uses the file name")
+	template := flags.String("template", prompts.DefaultTemplate, "summarization template")
+	participants := flags.String("participants", "", "comma separated participants")
+	var opts cliOptions
+	flags.BoolVar(&opts.verbose, "verbose", false, "log to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: transcriber summarize [flags] <transcript file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	path := flags.Arg(0)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	service, err := openService(opts)
+	if err != nil {
+		return err
+	}
+	defer service.Shutdown()
+
+	if _, err := service.Prompts().Get(*template); err != nil {
+		return err
+	}
+	if *title == "" {
+		*title = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	now := time.Now()
+	meeting := &types.Meeting{
+		Title:        *title,
+		CreatedAt:    now,
+		Start_time:   now,
+		Participants: splitList(*participants),
+		Template:     *template,
+		Transcript:   string(data),
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	summary, err := service.Summarize(ctx, meeting)
+	if err != nil {
+		return err
+	}
+	fmt.Println(summary)
+	return nil
+}
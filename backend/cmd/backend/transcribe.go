@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// runTranscribe runs an audio file through the pipeline like an upload, so it also ends up
+// in the vault, and prints its transcript. The file itself is left in place.
+func runTranscribe(args []string) error {
+	flags := flag.NewFlagSet("transcribe", flag.ExitOnError)
+	title := flags.String("title", "", "title of the meeting, empty uses the file name")
+	meetingType := flags.String("type", "", "meeting preset providing the template, vault folder, participants and tags")
+	participants := flags.String("participants", "", "comma separated participants")
+	tags := flags.String("tags", "", "comma separated tags")
+	stages := flags.String("stages", "", "comma separated processing stages, e.g. transcribe to skip the summary")
+	asJSON := flags.Bool("json", false, "print the transcript segments as JSON")
+	var opts cliOptions
+	flags.BoolVar(&opts.devMode, "dev-mode", false, "generate the transcript instead of running whisper")
+	flags.BoolVar(&opts.verbose, "verbose", false, "log the pipeline to stderr")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: transcriber transcribe [flags] <audio file>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	service, err := openService(opts)
+	if err != nil {
+		return err
+	}
+	defer service.Shutdown()
+
+	meetingId, err := service.ProcessFile(transcriber.RecordingOptions{
+		Title:        *title,
+		Type:         *meetingType,
+		Participants: splitList(*participants),
+		Tags:         splitList(*tags),
+		Stages:       splitList(*stages),
+	}, flags.Arg(0), true)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	meeting, err := waitForMeeting(ctx, service, meetingId)
+	if err != nil {
+		return err
+	}
+	if len(meeting.Segments) == 0 {
+		if err := meetingResult(meeting); err != nil {
+			return err
+		}
+	}
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(meeting.Segments)
+	}
+	fmt.Println(meeting.Transcript)
+	return nil
+}
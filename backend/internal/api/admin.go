@@ -0,0 +1,237 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// requireAdmin wraps a handler so only admins can call it. Without authentication
+// the server is single-user and every caller is allowed.
+func (s *Server) requireAdmin(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if principal := auth.FromContext(r.Context()); principal != nil && !principal.IsAdmin() {
+			s.respondWithJSON(w, http.StatusForbidden, map[string]string{
+				"error": "Admin role required",
+			})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// requireOwner wraps a handler of a meeting so only its owner and admins can call it. The
+// meetings of other users are reported as not found, so their ids can't be probed.
+func (s *Server) requireOwner(handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.canAccessMeeting(w, r, pathOrQueryId(r)) {
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// canAccessMeeting reports whether the caller may use a meeting, responding not found when
+// not. Handlers of routes carrying the meeting ID in the body check it after decoding.
+func (s *Server) canAccessMeeting(w http.ResponseWriter, r *http.Request, meetingId string) bool {
+	if meeting, err := s.transcriber.GetMeetingStatus(meetingId); err == nil && !auth.FromContext(r.Context()).CanAccess(meeting.Owner) {
+		s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+			"error": fmt.Sprintf("meeting not found with ID: %s", meetingId),
+		})
+		return false
+	}
+	return true
+}
+
+// scopeFilter limits a meeting filter to the meetings of the caller. Admins see every
+// meeting and may filter by owner themselves.
+func scopeFilter(r *http.Request, filter *transcriber.MeetingFilter) {
+	if owner := auth.FromContext(r.Context()).Owner(); owner != "" {
+		filter.Owner = owner
+	}
+}
+
+// requireUserStore responds with an error and returns false when user management is unavailable
+func (s *Server) requireUserStore(w http.ResponseWriter) bool {
+	if s.auth == nil {
+		s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+			"error": "User management requires authentication to be enabled",
+		})
+		return false
+	}
+	return true
+}
+
+// respondWithStoreError maps user store errors to HTTP responses
+func (s *Server) respondWithStoreError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, auth.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	s.respondWithJSON(w, status, map[string]string{
+		"error": err.Error(),
+	})
+}
+
+// handleListUsers returns a handler that lists all users
+func (s *Server) handleListUsers() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireUserStore(w) {
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"users": s.auth.Store().ListUsers(),
+		})
+	}
+}
+
+// handleGetUser returns a handler that returns a single user
+func (s *Server) handleGetUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireUserStore(w) {
+			return
+		}
+		user, err := s.auth.Store().GetUser(r.PathValue("username"))
+		if err != nil {
+			s.respondWithStoreError(w, err)
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, user)
+	}
+}
+
+// handleCreateUser returns a handler that creates a user
+func (s *Server) handleCreateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireUserStore(w) {
+			return
+		}
+
+		var user auth.User
+		if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		user, err := s.auth.Store().CreateUser(user)
+		if err != nil {
+			s.respondWithStoreError(w, err)
+			return
+		}
+		s.logger.Info("User created", "user", user.Username, "role", user.Role, "by", auth.Username(r.Context()))
+		s.respondWithJSON(w, http.StatusCreated, user)
+	}
+}
+
+// handleUpdateUser returns a handler that changes the role or quotas of a user
+func (s *Server) handleUpdateUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireUserStore(w) {
+			return
+		}
+
+		var requestBody struct {
+			Role   *string      `json:"role"`
+			Quotas *auth.Quotas `json:"quotas"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		user, err := s.auth.Store().UpdateUser(r.PathValue("username"), requestBody.Role, requestBody.Quotas)
+		if err != nil {
+			s.respondWithStoreError(w, err)
+			return
+		}
+		s.logger.Info("User updated", "user", user.Username, "role", user.Role, "by", auth.Username(r.Context()))
+		s.respondWithJSON(w, http.StatusOK, user)
+	}
+}
+
+// handleDeleteUser returns a handler that deletes a user and revokes their tokens
+func (s *Server) handleDeleteUser() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireUserStore(w) {
+			return
+		}
+
+		username := r.PathValue("username")
+		if err := s.auth.Store().DeleteUser(username); err != nil {
+			s.respondWithStoreError(w, err)
+			return
+		}
+		s.logger.Info("User deleted", "user", username, "by", auth.Username(r.Context()))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleListTokens returns a handler that lists API tokens, optionally for a single user
+func (s *Server) handleListTokens() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireUserStore(w) {
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"tokens": s.auth.Store().ListTokens(r.URL.Query().Get("user")),
+		})
+	}
+}
+
+// handleCreateToken returns a handler that issues an API token. The secret is only
+// returned in this response.
+func (s *Server) handleCreateToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireUserStore(w) {
+			return
+		}
+
+		var requestBody struct {
+			User  string `json:"user"`
+			Name  string `json:"name"`
+			Scope string `json:"scope,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		token, secret, err := s.auth.Store().CreateToken(requestBody.User, requestBody.Name, requestBody.Scope)
+		if err != nil {
+			s.respondWithStoreError(w, err)
+			return
+		}
+		s.logger.Info("API token created", "tokenId", token.Id, "user", token.User, "scope", token.Scope, "by", auth.Username(r.Context()))
+		s.respondWithJSON(w, http.StatusCreated, map[string]interface{}{
+			"token":  token,
+			"secret": secret,
+		})
+	}
+}
+
+// handleRevokeToken returns a handler that revokes an API token
+func (s *Server) handleRevokeToken() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !s.requireUserStore(w) {
+			return
+		}
+
+		token, err := s.auth.Store().RevokeToken(r.PathValue("id"))
+		if err != nil {
+			s.respondWithStoreError(w, err)
+			return
+		}
+		s.logger.Info("API token revoked", "tokenId", token.Id, "user", token.User, "by", auth.Username(r.Context()))
+		s.respondWithJSON(w, http.StatusOK, token)
+	}
+}
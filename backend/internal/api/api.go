@@ -3,77 +3,265 @@ package api
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
 	"os"
 	"os/signal"
+	"strconv"
+	"strings"
 
 	"syscall"
 	"time"
 
 	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/config"
 	"github.com/martijnspitter/transcriber/internal/logger"
+	"github.com/martijnspitter/transcriber/internal/mcp"
+	"github.com/martijnspitter/transcriber/internal/meetingapps"
+	"github.com/martijnspitter/transcriber/internal/prompts"
 	"github.com/martijnspitter/transcriber/internal/transcriber"
+	"github.com/martijnspitter/transcriber/internal/types"
+	"github.com/martijnspitter/transcriber/internal/webui"
 )
 
+// maxStatusWait caps how long a meeting status request waits for the status to change
+const maxStatusWait = 60 * time.Second
+
 // Server represents the API server
 type Server struct {
 	router      *http.ServeMux
 	server      *http.Server
+	config      *config.Config
 	logger      *logger.Logger
 	transcriber *transcriber.TranscriberService
+	mcp         *mcp.Server
+	auth        *auth.Authenticator // Nil when authentication is disabled
+	idempotency *idempotencyStore   // Nil when idempotency keys are disabled
+	shareKey    []byte              // Signs the links meetings are shared with
+	revoked     *shareRevocations   // Share links revoked before they expire
+	shutdown    chan struct{}       // Closed when the server shuts down, ending open streams
 }
 
 // NewServer creates a new API server instance
-func NewServer(logger *logger.Logger, transcriber *transcriber.TranscriberService) *Server {
+func NewServer(cfg *config.Config, logger *logger.Logger, transcriber *transcriber.TranscriberService) (*Server, error) {
+	authenticator, err := auth.New(cfg.Auth, logger)
+	if err != nil {
+		return nil, fmt.Errorf("invalid auth config: %w", err)
+	}
+	if cfg.Server.Pprof && authenticator == nil {
+		return nil, fmt.Errorf("server.pprof requires authentication to be enabled")
+	}
+	if cfg.Server.DebugAddr != "" {
+		if err := checkLoopback(cfg.Server.DebugAddr); err != nil {
+			return nil, err
+		}
+	}
+
+	shareKey, err := loadShareKey(cfg.Server.Sharing)
+	if err != nil {
+		return nil, err
+	}
+	revoked, err := loadShareRevocations(cfg.Server.Sharing.RevokedFile)
+	if err != nil {
+		return nil, err
+	}
+
 	s := &Server{
 		router:      http.NewServeMux(),
+		config:      cfg,
 		logger:      logger,
 		transcriber: transcriber,
+		mcp:         mcp.NewServer(transcriber, logger),
+		auth:        authenticator,
+		idempotency: newIdempotencyStore(cfg.Server.IdempotencyTTL),
+		shareKey:    shareKey,
+		revoked:     revoked,
+		shutdown:    make(chan struct{}),
 	}
 
 	// Register all available routes
 	s.registerRoutes()
 
-	return s
+	return s, nil
 }
 
 // registerRoutes sets up all the API endpoints
 func (s *Server) registerRoutes() {
 	// Health check endpoint
-	s.router.HandleFunc("/health", s.handleHealth())
+	s.handle("GET /health", s.handleHealth())
+
+	// Authentication endpoints
+	s.handle("GET /auth/login", s.handleLogin())
+	s.handle("GET /auth/callback", s.handleLoginCallback())
+	s.handle("GET /auth/session", s.handleSession())
+	s.handle("POST /auth/logout", s.handleLogout())
+
+	s.handle("GET /capabilities", s.handleCapabilities())
+	s.handle("GET /setup/status", s.handleSetupStatus())
+	s.handle("POST /setup/step", s.requireAdmin(s.handleSetupStep()))
+	s.handle("GET /me/usage", s.handleUsage())
 
 	// Recording endpoints
-	s.router.HandleFunc("/start-recording", s.handleStartRecording())
-	s.router.HandleFunc("/stop-recording", s.handleStopRecording())
+	s.handle("POST /recordings", s.handleStartRecording())
+	s.handle("POST /recordings/{id}/stop", s.handleStopRecording())
+	s.handle("POST /recordings/{id}/bookmarks", s.handleAddBookmark())
+	s.handle("POST /recordings/arm", s.handleArmRecording())
+	s.handle("GET /recordings/arm", s.handleGetArmedRecording())
+	s.handle("DELETE /recordings/arm", s.handleDisarmRecording())
+	s.handle("GET /now", s.handleNow())
+	s.handle("GET /status", s.handleStatus())
+	s.handle("GET /status/stream", s.handleStatusStream())
+	s.handle("POST /quick-start", s.handleQuickStart())
+	s.handle("POST /import", s.handleImport())
+	s.handle("POST /batch", s.requireAdmin(s.handleBatch()))
+	s.handle("POST /transcribe-url", s.handleTranscribeURL())
+	s.handle("GET /imports/voice-memos", s.handleListVoiceMemos())
+	s.handle("POST /imports/voice-memos", s.handleImportVoiceMemos())
+
+	// Meeting endpoints
+	s.handle("GET /meetings", s.handleGetAllMeetings())
+	s.handle("GET /meetings/{id}", s.handleGetMeetingStatus())
+	s.handle("POST /meetings/merge", s.handleMergeMeetings())
+	s.handle("PATCH /meetings/{id}", s.handleEditMeeting())
+	s.handle("POST /meetings/{id}/undo", s.handleUndoEdit())
+	s.handle("POST /meetings/{id}/archive", s.handleArchiveMeeting())
+	s.handle("POST /meetings/{id}/trash", s.handleTrashMeeting())
+	s.handle("POST /meetings/{id}/restore", s.handleRestoreMeeting())
+	s.handle("GET /meetings/{id}/export", s.handleDownloadExport())
+	s.handle("GET /meetings/{id}/highlights", s.handleDownloadHighlights())
+	s.handle("POST /meetings/{id}/share", s.handleShareMeeting())
+	s.handle("DELETE /meetings/{id}/share/{token}", s.handleRevokeShare())
+	s.handle("GET /shared/{token}", s.handleSharedMeeting())
+	s.handle("POST /meetings/{id}/marker", s.handleAddBookmark())
+	s.handle("POST /meetings/{id}/notes", s.handleAddNote())
+	s.handle("GET /meetings/{id}/segments", s.handleMeetingSegments())
+	s.handle("GET /meetings/{id}/timeline", s.handleMeetingTimeline())
+	s.handle("GET /meetings/{id}/audio", s.handleMeetingAudio())
+	s.handle("GET /meetings/{id}/waveform", s.handleMeetingWaveform())
+	s.handle("GET /meetings/{id}/stats", s.handleMeetingStats())
+	s.handle("GET /stats", s.handleAggregateStats())
+	s.handle("GET /series/{id}", s.handleGetSeries())
+	s.handle("GET /meetings/{id}/events", s.handleMeetingEvents())
+	s.handle("GET /meetings/{id}/events/{seq}", s.handleReplayMeeting())
+	s.handle("GET /events", s.handleEvents())
+	s.handle("POST /meetings/{id}/participants/rename", s.handleRenameParticipant())
+	s.handle("POST /participants/rename", s.requireAdmin(s.handleRenameParticipantGlobally()))
+
+	s.handle("GET /audio-devices", s.handleListAudioDevices())
+	s.handle("GET /device-preferences", s.handleListDevicePreferences())
+	s.handle("DELETE /device-preferences/{series}", s.handleForgetDevicePreference())
+	s.handle("POST /test-recording", s.handleTestRecording())
+	s.handle("GET /test-recording/{id}/audio", s.handleTestRecordingAudio())
+
+	s.handle("POST /meetings/{id}/summarize", s.handleSummarize())
+	s.handle("GET /meetings/{id}/summarize/{job}", s.handleGetSummaryJob())
+
+	s.handle("GET /presets", s.handleListPresets())
+	s.handle("GET /calendar/events", s.handleCalendarEvents())
+	s.handle("GET /meeting-apps", s.handleMeetingApps())
+
+	// Summarization template endpoints
+	s.handle("GET /templates", s.handleListTemplates())
+	s.handle("GET /templates/{name}", s.handleGetTemplate())
+	s.handle("PUT /templates/{name}", s.requireAdmin(s.handleSaveTemplate()))
+	s.handle("DELETE /templates/{name}", s.requireAdmin(s.handleDeleteTemplate()))
 
-	// Meeting status endpoints
-	s.router.HandleFunc("/meeting-status", s.handleGetMeetingStatus())
-	s.router.HandleFunc("/meetings", s.handleGetAllMeetings())
+	// People directory endpoints
+	s.handle("GET /people", s.handleListPeople())
+	s.handle("GET /people/{name}", s.handleGetPerson())
+	s.handle("PUT /people/{name}", s.requireAdmin(s.handleSavePerson()))
+	s.handle("DELETE /people/{name}", s.requireAdmin(s.handleDeletePerson()))
 
-	s.router.HandleFunc("/list-audio-devices", s.handleListAudioDevices())
+	// Summarizer A/B testing endpoints
+	s.handle("GET /meetings/{id}/compare", s.handleCompare())
+	s.handle("POST /meetings/{id}/compare/feedback", s.handleCompareFeedback())
+
+	// Question answering over transcripts
+	s.handle("POST /meetings/{id}/ask", s.handleAskMeeting())
+	s.handle("POST /ask", s.handleAsk())
+
+	// Model Context Protocol endpoint for local AI agents
+	s.handle("POST /mcp", s.mcp.ServeHTTP)
+
+	// Webhook inbox for external transcription services
+	s.handle("POST /webhooks/transcripts/{id}", s.handleTranscriptWebhook())
+
+	// Admin endpoints
+	s.handle("POST /admin/resummarize", s.requireAdmin(s.handleResummarize()))
+	s.handle("GET /admin/resummarize/{id}", s.requireAdmin(s.handleGetResummarizeBatch()))
+	s.handle("GET /admin/users", s.requireAdmin(s.handleListUsers()))
+	s.handle("POST /admin/users", s.requireAdmin(s.handleCreateUser()))
+	s.handle("GET /admin/users/{username}", s.requireAdmin(s.handleGetUser()))
+	s.handle("PATCH /admin/users/{username}", s.requireAdmin(s.handleUpdateUser()))
+	s.handle("DELETE /admin/users/{username}", s.requireAdmin(s.handleDeleteUser()))
+	s.handle("GET /admin/tokens", s.requireAdmin(s.handleListTokens()))
+	s.handle("POST /admin/tokens", s.requireAdmin(s.handleCreateToken()))
+	s.handle("DELETE /admin/tokens/{id}", s.requireAdmin(s.handleRevokeToken()))
+	s.handle("POST /admin/preview-paths", s.requireAdmin(s.handlePreviewPaths()))
+	s.handle("GET /admin/log-level", s.requireAdmin(s.handleGetLogLevel()))
+	s.handle("PUT /admin/log-level", s.requireAdmin(s.handleSetLogLevel()))
+	s.handle("GET /admin/diagnostics", s.requireAdmin(s.handleDiagnostics()))
+
+	// Profiling endpoints
+	if s.config.Server.Pprof {
+		s.registerPprof()
+	}
+	if s.config.Server.Pprof || s.config.Server.DebugAddr != "" {
+		s.publishRuntimeStats()
+	}
+
+	// Legacy unversioned endpoints, kept as aliases during the deprecation period
+	s.handleLegacy("GET /health", "/health", s.handleHealth())
+	s.handleLegacy("POST /start-recording", "/recordings", s.handleStartRecording())
+	s.handleLegacy("POST /stop-recording", "/recordings/{id}/stop", s.handleStopRecording())
+	s.handleLegacy("GET /meeting-status", "/meetings/{id}", s.handleGetMeetingStatus())
+	s.handleLegacy("GET /meetings", "/meetings", s.handleGetAllMeetings())
+	s.handleLegacy("GET /list-audio-devices", "/audio-devices", s.handleListAudioDevices())
+	s.handleLegacy("GET /compare", "/meetings/{id}/compare", s.handleCompare())
+	s.handleLegacy("POST /compare/feedback", "/meetings/{id}/compare/feedback", s.handleCompareFeedback())
+	s.handleLegacy("POST /admin/resummarize", "/admin/resummarize", s.requireAdmin(s.handleResummarize()))
+	s.handleLegacy("GET /admin/resummarize", "/admin/resummarize/{id}", s.requireAdmin(s.handleGetResummarizeBatch()))
 
 	// Root endpoint
-	s.router.HandleFunc("/", s.handleRoot())
+	s.router.HandleFunc("GET /{$}", s.handleRoot())
+	if s.config.Server.WebUI {
+		s.router.Handle("GET "+uiPrefix, http.StripPrefix(strings.TrimSuffix(uiPrefix, "/"), http.FileServerFS(webui.Files())))
+	}
 }
 
 // handleHealth returns a handler for health check requests
 func (s *Server) handleHealth() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		response := map[string]string{"status": "ok", "timestamp": time.Now().Format(time.RFC3339)}
+		// Public, so it tells whether an update is available but not which version runs or
+		// which is newer
+		response := map[string]interface{}{"status": "ok", "timestamp": time.Now().Format(time.RFC3339), "api_version": APIVersion}
+		if updates := s.transcriber.Updates(); updates != nil {
+			response["update_available"] = updates.Status().Available
+		}
 		s.respondWithJSON(w, http.StatusOK, response)
 	}
 }
 
+// handleCapabilities returns a handler that reports which features work with the installed dependencies
+func (s *Server) handleCapabilities() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respondWithJSON(w, http.StatusOK, s.transcriber.Capabilities())
+	}
+}
+
 // handleRoot returns a handler for the root endpoint
 func (s *Server) handleRoot() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" {
-			http.NotFound(w, r)
+		// Browsers get the bundled web UI, API clients a description of the API
+		if s.config.Server.WebUI && strings.Contains(r.Header.Get("Accept"), "text/html") {
+			http.ServeFileFS(w, r, webui.Files(), "index.html")
 			return
 		}
-
-		response := map[string]string{"message": "Transcriber API Server"}
+		response := map[string]string{"message": "Transcriber API Server", "api_version": APIVersion, "api_base": apiPrefix}
 		s.respondWithJSON(w, http.StatusOK, response)
 	}
 }
@@ -81,15 +269,28 @@ func (s *Server) handleRoot() http.HandlerFunc {
 // handleStartRecording returns a handler for starting recording requests
 func (s *Server) handleStartRecording() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Only allow POST method
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-
 		var requestBody struct {
-			Title        string   `json:"title"`
-			Participants []string `json:"participants,omitempty"`
+			Title        string            `json:"title"`
+			Participants []string          `json:"participants,omitempty"`
+			Tags         []string          `json:"tags,omitempty"`
+			Metadata     map[string]string `json:"metadata,omitempty"`
+			Template     string            `json:"template,omitempty"`
+			Type         string            `json:"type,omitempty"`
+			Series       string            `json:"series,omitempty"`
+			Stages       []string          `json:"stages,omitempty"`
+			MicDevice    string            `json:"mic_device,omitempty"`
+			SystemDevice string            `json:"system_device,omitempty"`
+			App          string            `json:"app,omitempty"`
+			MicGain      float64           `json:"mic_gain,omitempty"`
+			SystemGain   float64           `json:"system_gain,omitempty"`
+			ExtraDevices []struct {
+				Device string  `json:"device"`
+				Gain   float64 `json:"gain,omitempty"`
+			} `json:"extra_devices,omitempty"`
+			InputVolume    float64 `json:"input_volume,omitempty"`
+			NoiseReduction *bool   `json:"noise_reduction,omitempty"`
+			Loudnorm       *bool   `json:"loudnorm,omitempty"`
+			MixMode        string  `json:"mix_mode,omitempty"`
 		}
 
 		// Parse the request body for participants
@@ -100,12 +301,32 @@ func (s *Server) handleStartRecording() http.HandlerFunc {
 			return
 		}
 
-		meetingId, err := s.transcriber.StartRecording(requestBody.Title, requestBody.Participants)
-		if err != nil {
-			s.logger.Error("Failed to list audio devices", "error", err)
-			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
-				"error": fmt.Sprintf("Failed to list audio devices: %v", err),
-			})
+		var extraDevices []audiocapture.ExtraDevice
+		for _, extra := range requestBody.ExtraDevices {
+			extraDevices = append(extraDevices, audiocapture.ExtraDevice{Device: extra.Device, Gain: extra.Gain})
+		}
+
+		meetingId, ok := s.startRecording(w, r, transcriber.RecordingOptions{
+			Title:          requestBody.Title,
+			Participants:   requestBody.Participants,
+			Tags:           requestBody.Tags,
+			Metadata:       requestBody.Metadata,
+			Template:       requestBody.Template,
+			Type:           requestBody.Type,
+			Series:         requestBody.Series,
+			Stages:         requestBody.Stages,
+			MicDevice:      requestBody.MicDevice,
+			SystemDevice:   requestBody.SystemDevice,
+			App:            requestBody.App,
+			MicGain:        requestBody.MicGain,
+			SystemGain:     requestBody.SystemGain,
+			ExtraDevices:   extraDevices,
+			InputVolume:    requestBody.InputVolume,
+			NoiseReduction: requestBody.NoiseReduction,
+			Loudnorm:       requestBody.Loudnorm,
+			MixMode:        requestBody.MixMode,
+		})
+		if !ok {
 			return
 		}
 
@@ -115,29 +336,94 @@ func (s *Server) handleStartRecording() http.HandlerFunc {
 	}
 }
 
+// startRecording starts a recording for the caller after checking their storage quota. Errors
+// are responded to, in which case ok is false.
+func (s *Server) startRecording(w http.ResponseWriter, r *http.Request, opts transcriber.RecordingOptions) (string, bool) {
+	if err := s.checkStorageQuota(r); err != nil {
+		s.respondWithJSON(w, http.StatusInsufficientStorage, map[string]string{
+			"error": err.Error(),
+		})
+		return "", false
+	}
+
+	opts.Owner = auth.Username(r.Context())
+	meetingId, err := s.transcriber.StartRecording(opts)
+	if err != nil {
+		s.respondStartError(w, err)
+		return "", false
+	}
+	return meetingId, true
+}
+
+// respondStartError responds to an error starting a recording
+func (s *Server) respondStartError(w http.ResponseWriter, err error) {
+	if errors.Is(err, transcriber.ErrFFmpegMissing) || errors.Is(err, transcriber.ErrAppCaptureUnavailable) || errors.Is(err, transcriber.ErrShuttingDown) {
+		s.respondWithJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, transcriber.ErrInsufficientDiskSpace) {
+		s.respondWithJSON(w, http.StatusInsufficientStorage, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, transcriber.ErrRecordingInProgress) || errors.Is(err, meetingapps.ErrAppNotRunning) {
+		s.respondWithJSON(w, http.StatusConflict, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	if errors.Is(err, prompts.ErrNotFound) || errors.Is(err, transcriber.ErrUnknownPreset) || errors.Is(err, transcriber.ErrInvalidStages) || errors.Is(err, meetingapps.ErrBrowserApp) || errors.Is(err, transcriber.ErrInvalidDevices) || errors.Is(err, transcriber.ErrUnknownMixMode) ||
+		errors.Is(err, audiocapture.ErrDeviceNotFound) || errors.Is(err, audiocapture.ErrAmbiguousDevice) {
+		s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+			"error": err.Error(),
+		})
+		return
+	}
+	s.logger.Error("Failed to start recording", "error", err)
+	s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+		"error": fmt.Sprintf("Failed to start recording: %v", err),
+	})
+}
+
 // handleStopRecording returns a handler for stopping recording requests
 func (s *Server) handleStopRecording() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Only allow POST method
-		if r.Method != http.MethodPost {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
+		var requestBody struct {
+			MeetingId string `json:"meeting_id"`
 		}
 
-		var requestBody struct {
-			MeetingId string `json:"meeting_id"` // in seconds
+		// The versioned route carries the meeting ID in the path, the legacy route in the body
+		requestBody.MeetingId = r.PathValue("id")
+		if requestBody.MeetingId == "" {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				s.logger.Error("Failed to decode request body", "error", err)
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": "Invalid request body",
+				})
+				return
+			}
+			if !s.canAccessMeeting(w, r, requestBody.MeetingId) {
+				return
+			}
 		}
 
-		// Parse the request body for meeting ID
-		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
-			s.logger.Error("Failed to decode request body", "error", err)
-			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
-				"error": "Invalid request body",
+		err := s.transcriber.StopMeeting(requestBody.MeetingId)
+		if errors.Is(err, transcriber.ErrAlreadyStopped) {
+			// Retried stops succeed without stopping anything
+			s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+				"message": "Meeting already stopped",
+			})
+			return
+		}
+		if errors.Is(err, transcriber.ErrNotRecording) {
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
 			})
 			return
 		}
-
-		err := s.transcriber.StopMeeting(requestBody.MeetingId)
 		if err != nil {
 			s.logger.Error("Failed to stop meeting", "error", err, "meetingId", requestBody.MeetingId)
 			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
@@ -153,17 +439,12 @@ func (s *Server) handleStopRecording() http.HandlerFunc {
 }
 
 // handleCaptureAndMergeAudio returns a handler for capturing and merging audio in one operation
-// handleGetMeetingStatus returns a handler for getting meeting status by ID
+// handleGetMeetingStatus returns a handler for getting meeting status by ID. With wait, the
+// response is held until the status differs from status, or the current one, or wait passes.
 func (s *Server) handleGetMeetingStatus() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Only allow GET method
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
-			return
-		}
-
-		// Get meeting ID from query parameter
-		meetingId := r.URL.Query().Get("id")
+		// Get meeting ID from the path or query parameter
+		meetingId := pathOrQueryId(r)
 		if meetingId == "" {
 			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
 				"error": "Missing meeting ID parameter",
@@ -181,41 +462,262 @@ func (s *Server) handleGetMeetingStatus() http.HandlerFunc {
 			return
 		}
 
+		// Long polling: wait for the status to differ from the one the client last saw
+		if value := r.URL.Query().Get("wait"); value != "" {
+			wait, err := parseStatusWait(value)
+			if err != nil {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": err.Error(),
+				})
+				return
+			}
+			status := r.URL.Query().Get("status")
+			if status == "" {
+				status = meeting.Status
+			}
+
+			// The wait outlasts the write timeout of the server
+			if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second)); err != nil {
+				s.logger.Debug("Failed to extend write deadline for long poll", "error", err)
+			}
+			ctx, cancel := context.WithTimeout(r.Context(), wait)
+			defer cancel()
+			if meeting, err = s.transcriber.WaitForStatusChange(ctx, meetingId, status); err != nil {
+				s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+					"error": fmt.Sprintf("Failed to get meeting status: %v", err),
+				})
+				return
+			}
+		}
+
+		meeting = s.transcriber.Snapshot(meeting)
+		w.Header().Set("ETag", etag(meeting))
 		s.respondWithJSON(w, http.StatusOK, meeting)
 	}
 }
 
+// etag returns the entity tag of a meeting, its version, for If-Match on edits
+func etag(meeting *types.Meeting) string {
+	return fmt.Sprintf(`"%d"`, meeting.Version)
+}
+
+// ifMatchVersion returns the meeting version a change was made to from the If-Match header,
+// holding the ETag of the meeting, or nil without the header
+func ifMatchVersion(r *http.Request) (*int64, error) {
+	value := r.Header.Get("If-Match")
+	if value == "" {
+		return nil, nil
+	}
+	version, err := strconv.ParseInt(strings.Trim(strings.TrimPrefix(value, "W/"), `"`), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid If-Match header %q, expected a meeting version", value)
+	}
+	return &version, nil
+}
+
+// parseStatusWait parses how long a status request waits for a change, as a duration such
+// as 30s or a number of seconds, capped at maxStatusWait
+func parseStatusWait(value string) (time.Duration, error) {
+	wait, err := time.ParseDuration(value)
+	if err != nil {
+		seconds, convErr := strconv.Atoi(value)
+		if convErr != nil {
+			return 0, fmt.Errorf("invalid wait parameter %q, use a duration such as 30s", value)
+		}
+		wait = time.Duration(seconds) * time.Second
+	}
+	if wait < 0 {
+		return 0, fmt.Errorf("invalid wait parameter %q, use a duration such as 30s", value)
+	}
+	return min(wait, maxStatusWait), nil
+}
+
 // handleGetAllMeetings returns a handler for getting all meetings
+// Supports limit/offset pagination, sort=created_at|-created_at|title|duration|status and the
+// status, from, to, participant, title and tag filters.
 func (s *Server) handleGetAllMeetings() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Only allow GET method
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		opts, err := parseListOptions(r)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
 			return
 		}
 
-		// Get all meetings
-		meetings := s.transcriber.GetAllMeetings()
+		scopeFilter(r, &opts.Filter)
+		meetings, total := s.transcriber.ListMeetings(opts)
+		items := make([]meetingListItem, 0, len(meetings))
+		for _, meeting := range meetings {
+			items = append(items, newMeetingListItem(meeting))
+		}
 
-		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+		response := map[string]interface{}{
 			"status":   "success",
-			"meetings": meetings,
-		})
+			"meetings": items,
+			"total":    total,
+			"offset":   opts.Offset,
+			"limit":    opts.Limit,
+		}
+		if next := opts.Offset + len(meetings); opts.Limit > 0 && next < total {
+			response["next_offset"] = next
+		}
+
+		s.respondWithJSON(w, http.StatusOK, response)
 	}
 }
 
-// handleListAudioDevices returns a handler that lists available audio devices
-func (s *Server) handleListAudioDevices() http.HandlerFunc {
+// meetingListItem is a meeting as listed, without the transcript, summary and other details
+// that are fetched per meeting
+type meetingListItem struct {
+	Id         string     `json:"id"`
+	Title      string     `json:"title"`
+	Status     string     `json:"status"`
+	Duration   int        `json:"duration"` // in seconds
+	CreatedAt  time.Time  `json:"created_at"`
+	Tags       []string   `json:"tags,omitempty"`
+	Owner      string     `json:"owner,omitempty"`
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+	TrashedAt  *time.Time `json:"trashed_at,omitempty"`
+}
+
+// newMeetingListItem returns the list item of a meeting
+func newMeetingListItem(meeting *types.Meeting) meetingListItem {
+	return meetingListItem{
+		Id:         meeting.Id,
+		Title:      meeting.Title,
+		Status:     meeting.Status,
+		Duration:   meeting.Duration,
+		CreatedAt:  meeting.CreatedAt,
+		Tags:       meeting.Tags,
+		Owner:      meeting.Owner,
+		ArchivedAt: meeting.ArchivedAt,
+		TrashedAt:  meeting.TrashedAt,
+	}
+}
+
+// parseListOptions reads pagination, sorting and filter parameters from the query string
+func parseListOptions(r *http.Request) (transcriber.ListOptions, error) {
+	query := r.URL.Query()
+	opts := transcriber.ListOptions{Sort: query.Get("sort")}
+
+	if err := transcriber.ValidateSort(opts.Sort); err != nil {
+		return opts, err
+	}
+
+	for _, param := range []string{"limit", "offset"} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return opts, fmt.Errorf("invalid %s parameter %q", param, value)
+		}
+		if param == "limit" {
+			opts.Limit = n
+		} else {
+			opts.Offset = n
+		}
+	}
+
+	for _, key := range []string{"status", "from", "to", "participant", "title", "tag", "owner", "type", "archived", "trash"} {
+		if value := query.Get(key); value != "" {
+			if err := opts.Filter.Set(key, value); err != nil {
+				return opts, err
+			}
+		}
+	}
+
+	// Metadata filters are passed as meta.<key>=<value>
+	for key, values := range query {
+		if strings.HasPrefix(key, transcriber.MetadataFilterPrefix) && len(values) > 0 {
+			if err := opts.Filter.Set(key, values[0]); err != nil {
+				return opts, err
+			}
+		}
+	}
+
+	return opts, nil
+}
+
+// handleEditMeeting returns a handler that applies manual corrections to a meeting
+func (s *Server) handleEditMeeting() http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		// Only allow GET method
-		if r.Method != http.MethodGet {
-			w.WriteHeader(http.StatusMethodNotAllowed)
+		meetingId := pathOrQueryId(r)
+
+		var edit transcriber.MeetingEdit
+		if err := json.NewDecoder(r.Body).Decode(&edit); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+		// The version can also be passed as the ETag of the meeting in If-Match
+		if edit.Version == nil {
+			version, err := ifMatchVersion(r)
+			if err != nil {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": err.Error(),
+				})
+				return
+			}
+			edit.Version = version
+		}
+
+		if _, err := s.transcriber.GetMeetingStatus(meetingId); err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		meeting, err := s.transcriber.EditMeeting(meetingId, edit)
+		switch {
+		case errors.Is(err, transcriber.ErrStaleVersion):
+			s.respondWithJSON(w, http.StatusPreconditionFailed, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, transcriber.ErrMeetingBusy):
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case err != nil && meeting == nil:
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case err != nil:
+			// The edit was applied but the vault note couldn't be written
+			s.logger.Error("Failed to update vault note after edit", "error", err, "meetingId", meetingId)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
 			return
 		}
 
+		w.Header().Set("ETag", etag(meeting))
+		s.respondWithJSON(w, http.StatusOK, meeting)
+	}
+}
+
+// handleListPresets returns a handler that lists the meeting presets
+func (s *Server) handleListPresets() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"presets": s.config.Presets,
+		})
+	}
+}
+
+// handleListAudioDevices returns a handler that lists available audio devices
+func (s *Server) handleListAudioDevices() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
 		s.logger.Info("Listing audio devices")
 
-		devices, err := audiocapture.ListAudioDevices()
+		devices, err := s.transcriber.ListAudioDevices()
 		if err != nil {
 			s.logger.Error("Failed to list audio devices", "error", err)
 			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
@@ -231,6 +733,167 @@ func (s *Server) handleListAudioDevices() http.HandlerFunc {
 	}
 }
 
+// handleCompare returns a handler that shows the summarizer variants of a meeting side by side
+func (s *Server) handleCompare() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := pathOrQueryId(r)
+		if meetingId == "" {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Missing meeting ID parameter",
+			})
+			return
+		}
+
+		meeting, err := s.transcriber.GetMeetingStatus(meetingId)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": fmt.Sprintf("Failed to get meeting: %v", err),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"meeting_id": meeting.Id,
+			"title":      meeting.Title,
+			"variants":   meeting.SummaryVariants,
+			"stats":      s.transcriber.VariantStats(),
+		})
+	}
+}
+
+// handleCompareFeedback returns a handler that records a thumbs-up for a summarizer variant
+func (s *Server) handleCompareFeedback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			MeetingId string `json:"meeting_id"`
+			Variant   string `json:"variant"`
+		}
+
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+		if id := r.PathValue("id"); id != "" {
+			requestBody.MeetingId = id
+		} else if !s.canAccessMeeting(w, r, requestBody.MeetingId) {
+			return
+		}
+
+		if err := s.transcriber.RecordVariantFeedback(requestBody.MeetingId, requestBody.Variant); err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"message": "Feedback recorded",
+			"stats":   s.transcriber.VariantStats(),
+		})
+	}
+}
+
+// handleSummarize returns a handler that regenerates a meeting summary with an optional
+// custom prompt and model
+func (s *Server) handleSummarize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := pathOrQueryId(r)
+		if _, err := s.transcriber.GetMeetingStatus(meetingId); err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		// The body is optional, an empty one regenerates with the defaults
+		var requestBody struct {
+			Model        string `json:"model,omitempty"`
+			Template     string `json:"template,omitempty"`
+			SystemPrompt string `json:"system_prompt,omitempty"`
+			Instructions string `json:"instructions,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil && err != io.EOF {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		job, err := s.transcriber.QueueSummary(meetingId, transcriber.SummarizeOptions{
+			Model:        requestBody.Model,
+			Template:     requestBody.Template,
+			SystemPrompt: requestBody.SystemPrompt,
+			Instructions: requestBody.Instructions,
+		})
+		if err != nil {
+			s.respondWithJSON(w, http.StatusUnprocessableEntity, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		w.Header().Set("Location", fmt.Sprintf("/api/%s/meetings/%s/summarize/%s", APIVersion, meetingId, job.Id))
+		s.respondWithJSON(w, http.StatusAccepted, job.Snapshot())
+	}
+}
+
+// handleGetSummaryJob returns a handler that reports the progress of a queued summary
+func (s *Server) handleGetSummaryJob() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		job, err := s.transcriber.GetSummaryJob(r.PathValue("id"), r.PathValue("job"))
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, job.Snapshot())
+	}
+}
+
+// handleResummarize returns a handler that queues re-summarization of matching meetings
+func (s *Server) handleResummarize() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter := r.URL.Query().Get("filter")
+
+		batch, err := s.transcriber.Resummarize(filter)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Invalid filter: %v", err),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusAccepted, batch.Snapshot())
+	}
+}
+
+// handleGetResummarizeBatch returns a handler that reports the progress of a re-summarization batch
+func (s *Server) handleGetResummarizeBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		batchId := pathOrQueryId(r)
+		if batchId == "" {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Missing batch ID parameter",
+			})
+			return
+		}
+
+		batch, err := s.transcriber.GetResummarizeBatch(batchId)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, batch.Snapshot())
+	}
+}
+
 // respondWithJSON sends a JSON response
 func (s *Server) respondWithJSON(w http.ResponseWriter, status int, payload interface{}) {
 	response, err := json.Marshal(payload)
@@ -245,27 +908,72 @@ func (s *Server) respondWithJSON(w http.ResponseWriter, status int, payload inte
 	w.Write(response)
 }
 
+// Handler returns the router wrapped in the CORS, version and auth middleware
+func (s *Server) Handler() http.Handler {
+	return corsMiddleware(s.config.CORS, versionMiddleware(authMiddleware(s.auth, idempotencyMiddleware(s.idempotency, s.router))))
+}
+
 // Start initializes the server and starts listening for requests
 func (s *Server) Start() error {
-	addr := ":8000"
+	addr := s.config.Server.Addr()
 
 	// Create the HTTP server
 	s.server = &http.Server{
 		Addr:         addr,
-		Handler:      s.router,
+		Handler:      s.Handler(),
 		ReadTimeout:  10 * time.Second,
 		WriteTimeout: 10 * time.Second,
 		IdleTimeout:  120 * time.Second,
 	}
+	s.server.RegisterOnShutdown(func() { close(s.shutdown) })
+
+	if s.config.Server.TLS.Enabled() {
+		tlsConfig, err := loadTLSConfig(s.config.Server.TLS)
+		if err != nil {
+			return err
+		}
+		s.server.TLSConfig = tlsConfig
+	}
+
+	listeners, err := s.listen()
+	if err != nil {
+		return err
+	}
+
+	if s.config.Server.DebugAddr != "" {
+		debugListener, err := net.Listen("tcp", s.config.Server.DebugAddr)
+		if err != nil {
+			for _, listener := range listeners {
+				listener.Close()
+			}
+			return fmt.Errorf("failed to listen on %s: %w", s.config.Server.DebugAddr, err)
+		}
+		debugServer := &http.Server{Handler: debugHandler(), ReadHeaderTimeout: 10 * time.Second}
+		defer debugServer.Close()
+		go func() {
+			s.logger.Info("Debug server listening", "addr", debugListener.Addr().String())
+			if err := debugServer.Serve(debugListener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+				s.logger.Error("Debug server error", "error", err)
+			}
+		}()
+	}
 
 	// Channel to listen for errors coming from the server
-	serverErrors := make(chan error, 1)
+	serverErrors := make(chan error, len(listeners))
 
-	// Start the server in a goroutine
-	go func() {
-		s.logger.Info("API server listening on %s", "addr", addr)
-		serverErrors <- s.server.ListenAndServe()
-	}()
+	// Start serving every listener in its own goroutine
+	for _, listener := range listeners {
+		go func(listener net.Listener) {
+			// The Unix socket is local-only, so TLS is only applied to the TCP listener
+			useTLS := s.server.TLSConfig != nil && listener.Addr().Network() == "tcp"
+			s.logger.Info("API server listening", "network", listener.Addr().Network(), "addr", listener.Addr().String(), "tls", useTLS)
+			if useTLS {
+				serverErrors <- s.server.ServeTLS(listener, "", "")
+				return
+			}
+			serverErrors <- s.server.Serve(listener)
+		}(listener)
+	}
 
 	// Channel to listen for an interrupt or terminate signal from the OS
 	shutdown := make(chan os.Signal, 1)
@@ -275,6 +983,7 @@ func (s *Server) Start() error {
 	select {
 	case err := <-serverErrors:
 		s.logger.Error("Server error:", "error", err)
+		s.server.Close()
 		return fmt.Errorf("server error: %w", err)
 
 	case <-shutdown:
@@ -297,3 +1006,36 @@ func (s *Server) Start() error {
 
 	return nil
 }
+
+// listen opens the TCP listener and, when configured, the Unix domain socket listener
+func (s *Server) listen() ([]net.Listener, error) {
+	tcpListener, err := net.Listen("tcp", s.config.Server.Addr())
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen on %s: %w", s.config.Server.Addr(), err)
+	}
+	listeners := []net.Listener{tcpListener}
+
+	if socketPath := s.config.Server.UnixSocket; socketPath != "" {
+		// Remove a stale socket left behind by a previous run
+		if err := os.Remove(socketPath); err != nil && !os.IsNotExist(err) {
+			tcpListener.Close()
+			return nil, fmt.Errorf("failed to remove stale socket %s: %w", socketPath, err)
+		}
+
+		unixListener, err := net.Listen("unix", socketPath)
+		if err != nil {
+			tcpListener.Close()
+			return nil, fmt.Errorf("failed to listen on socket %s: %w", socketPath, err)
+		}
+
+		// Restrict the socket to the current user
+		if err := os.Chmod(socketPath, 0600); err != nil {
+			tcpListener.Close()
+			unixListener.Close()
+			return nil, fmt.Errorf("failed to set socket permissions: %w", err)
+		}
+		listeners = append(listeners, unixListener)
+	}
+
+	return listeners, nil
+}
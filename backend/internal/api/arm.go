@@ -0,0 +1,106 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleArmRecording returns a handler that starts capturing ahead of the next recording
+func (s *Server) handleArmRecording() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Title        string `json:"title,omitempty"`
+			Series       string `json:"series,omitempty"`
+			MicDevice    string `json:"mic_device,omitempty"`
+			SystemDevice string `json:"system_device,omitempty"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": "Invalid request body",
+				})
+				return
+			}
+		}
+
+		armed, err := s.transcriber.ArmRecording(transcriber.ArmOptions{
+			Title:        requestBody.Title,
+			Series:       requestBody.Series,
+			MicDevice:    requestBody.MicDevice,
+			SystemDevice: requestBody.SystemDevice,
+			Owner:        auth.Username(r.Context()),
+		})
+		if errors.Is(err, transcriber.ErrFFmpegMissing) {
+			s.respondWithJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, transcriber.ErrInsufficientDiskSpace) {
+			s.respondWithJSON(w, http.StatusInsufficientStorage, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, audiocapture.ErrDeviceNotFound) || errors.Is(err, audiocapture.ErrAmbiguousDevice) {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, transcriber.ErrRecordingInProgress) {
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to arm recording", "error", err)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, armed)
+	}
+}
+
+// handleGetArmedRecording returns a handler that reports the armed capture
+func (s *Server) handleGetArmedRecording() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		armed, ok := s.transcriber.ArmedRecording()
+		if !ok {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": transcriber.ErrNotArmed.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, armed)
+	}
+}
+
+// handleDisarmRecording returns a handler that discards the armed capture
+func (s *Server) handleDisarmRecording() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := s.transcriber.Disarm()
+		if errors.Is(err, transcriber.ErrNotArmed) {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to disarm recording", "error", err)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
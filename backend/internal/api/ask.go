@@ -0,0 +1,110 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// askRequest is the body of a question about meeting transcripts
+type askRequest struct {
+	Question string `json:"question"`
+	Filter   string `json:"filter"` // Only used when asking across meetings
+}
+
+// askTimeout is how long answering a question may take, including embedding the transcripts
+// it searches that weren't embedded yet
+const askTimeout = 5 * time.Minute
+
+// askContext extends the write deadline of the response past the write timeout of the server,
+// and returns the context the question is answered under
+func (s *Server) askContext(w http.ResponseWriter, r *http.Request) (context.Context, context.CancelFunc) {
+	if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(askTimeout + 10*time.Second)); err != nil {
+		s.logger.Debug("Failed to extend write deadline for question", "error", err)
+	}
+	return context.WithTimeout(r.Context(), askTimeout)
+}
+
+// decodeAskRequest reads the question from the request body
+func (s *Server) decodeAskRequest(w http.ResponseWriter, r *http.Request) (askRequest, bool) {
+	var req askRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Invalid request body",
+		})
+		return req, false
+	}
+	if req.Question == "" {
+		s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+			"error": "Missing question",
+		})
+		return req, false
+	}
+	return req, true
+}
+
+// handleAskMeeting returns a handler that answers a question about a single meeting
+func (s *Server) handleAskMeeting() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := pathOrQueryId(r)
+		if _, err := s.transcriber.GetMeetingStatus(meetingId); err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		req, ok := s.decodeAskRequest(w, r)
+		if !ok {
+			return
+		}
+
+		ctx, cancel := s.askContext(w, r)
+		defer cancel()
+		answer, err := s.transcriber.AskMeeting(ctx, meetingId, req.Question)
+		if err != nil {
+			s.logger.Error("Failed to answer question", "error", err, "meetingId", meetingId)
+			s.respondWithJSON(w, http.StatusUnprocessableEntity, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, answer)
+	}
+}
+
+// handleAsk returns a handler that answers a question across all matching meetings
+func (s *Server) handleAsk() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		req, ok := s.decodeAskRequest(w, r)
+		if !ok {
+			return
+		}
+
+		filter, err := transcriber.ParseMeetingFilter(req.Filter)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		scopeFilter(r, &filter)
+
+		ctx, cancel := s.askContext(w, r)
+		defer cancel()
+		answer, err := s.transcriber.Ask(ctx, req.Question, filter)
+		if err != nil {
+			s.logger.Error("Failed to answer question", "error", err)
+			s.respondWithJSON(w, http.StatusUnprocessableEntity, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, answer)
+	}
+}
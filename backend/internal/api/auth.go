@@ -0,0 +1,110 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+)
+
+// handleLogin returns a handler that redirects the browser to the OIDC provider
+func (s *Server) handleLogin() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil || !s.auth.OIDCEnabled() {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": "OIDC login is not configured",
+			})
+			return
+		}
+
+		loginURL, err := s.auth.LoginURL()
+		if err != nil {
+			s.logger.Error("Failed to start OIDC login", "error", err)
+			s.respondWithJSON(w, http.StatusBadGateway, map[string]string{
+				"error": "Failed to reach the identity provider",
+			})
+			return
+		}
+
+		http.Redirect(w, r, loginURL, http.StatusFound)
+	}
+}
+
+// handleLoginCallback returns a handler that completes the OIDC login, sets the session
+// cookie and redirects the browser back to the app
+func (s *Server) handleLoginCallback() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.auth == nil || !s.auth.OIDCEnabled() {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": "OIDC login is not configured",
+			})
+			return
+		}
+
+		query := r.URL.Query()
+		if providerErr := query.Get("error"); providerErr != "" {
+			s.respondWithJSON(w, http.StatusUnauthorized, map[string]string{
+				"error": "Login failed: " + providerErr + " " + query.Get("error_description"),
+			})
+			return
+		}
+
+		token, principal, err := s.auth.CompleteLogin(query.Get("state"), query.Get("code"))
+		if err != nil {
+			s.logger.Error("Failed to complete OIDC login", "error", err)
+			s.respondWithJSON(w, http.StatusUnauthorized, map[string]string{
+				"error": "Login failed",
+			})
+			return
+		}
+
+		cookie := s.sessionCookie(r, token)
+		cookie.Expires = *principal.Expires
+		http.SetCookie(w, cookie)
+		http.Redirect(w, r, s.auth.PostLoginRedirect(), http.StatusFound)
+	}
+}
+
+// handleSession returns a handler that reports the authenticated user
+func (s *Server) handleSession() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.FromContext(r.Context())
+		if principal == nil {
+			s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+				"auth_enabled": false,
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"auth_enabled": true,
+			"user":         principal,
+		})
+	}
+}
+
+// handleLogout returns a handler that revokes the session token of the request
+func (s *Server) handleLogout() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if s.auth != nil {
+			s.auth.EndSession(r)
+		}
+
+		cookie := s.sessionCookie(r, "")
+		cookie.MaxAge = -1
+		http.SetCookie(w, cookie)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// sessionCookie returns the session cookie holding a token. It is Secure on TLS requests or
+// when auth.secure_cookies is set, for servers behind a proxy that terminates TLS.
+func (s *Server) sessionCookie(r *http.Request, token string) *http.Cookie {
+	return &http.Cookie{
+		Name:     auth.SessionCookie,
+		Value:    token,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   r.TLS != nil || s.config.Auth.SecureCookies,
+		SameSite: http.SameSiteLaxMode,
+	}
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/prompts"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleBatch returns a handler that queues the audio files in a directory on the server
+// for the full pipeline, each as a meeting titled after the file
+func (s *Server) handleBatch() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Dir          string   `json:"dir"`
+			Type         string   `json:"type,omitempty"`
+			Participants []string `json:"participants,omitempty"`
+			Tags         []string `json:"tags,omitempty"`
+			Template     string   `json:"template,omitempty"`
+			Series       string   `json:"series,omitempty"`
+			Stages       []string `json:"stages,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || requestBody.Dir == "" {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body, expected a dir",
+			})
+			return
+		}
+
+		if err := s.checkStorageQuota(r); err != nil {
+			s.respondWithJSON(w, http.StatusInsufficientStorage, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		meetingIds, err := s.transcriber.ProcessDirectory(transcriber.RecordingOptions{
+			Participants: requestBody.Participants,
+			Tags:         requestBody.Tags,
+			Owner:        auth.Username(r.Context()),
+			Template:     requestBody.Template,
+			Type:         requestBody.Type,
+			Series:       requestBody.Series,
+			Stages:       requestBody.Stages,
+		}, requestBody.Dir)
+		if err != nil {
+			status := http.StatusInternalServerError
+			if errors.Is(err, transcriber.ErrInvalidBatch) || errors.Is(err, transcriber.ErrUnknownPreset) || errors.Is(err, transcriber.ErrInvalidStages) || errors.Is(err, prompts.ErrNotFound) {
+				status = http.StatusBadRequest
+			} else if errors.Is(err, transcriber.ErrShuttingDown) {
+				status = http.StatusServiceUnavailable
+			}
+			s.logger.Error("Failed to queue batch", "error", err, "dir", requestBody.Dir, "queued", len(meetingIds))
+			s.respondWithJSON(w, status, map[string]interface{}{
+				"error":       err.Error(),
+				"meeting_ids": meetingIds, // Queued before the failure
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+			"meeting_ids": meetingIds,
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// handleCalendarEvents returns a handler that lists the calendar events of the coming hours
+// (24 by default) and the event happening now
+func (s *Server) handleCalendarEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		feed := s.transcriber.Calendar()
+		if feed == nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": "no calendar configured, set calendar.url",
+			})
+			return
+		}
+
+		hours := 24
+		if value := r.URL.Query().Get("hours"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed < 1 || parsed > 24*31 {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": "hours must be a number between 1 and 744",
+				})
+				return
+			}
+			hours = parsed
+		}
+
+		now := time.Now()
+		response := map[string]interface{}{
+			"events":     feed.Between(now, now.Add(time.Duration(hours)*time.Hour)),
+			"fetched_at": feed.FetchedAt(),
+		}
+		if current, ok := feed.Current(now); ok {
+			response["current"] = current
+		}
+		s.respondWithJSON(w, http.StatusOK, response)
+	}
+}
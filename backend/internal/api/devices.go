@@ -0,0 +1,38 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/devices"
+)
+
+// handleListDevicePreferences returns a handler that lists the devices remembered per meeting series
+func (s *Server) handleListDevicePreferences() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"preferences": s.transcriber.DevicePreferences().List(),
+		})
+	}
+}
+
+// handleForgetDevicePreference returns a handler that forgets the devices of a meeting series
+func (s *Server) handleForgetDevicePreference() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		err := s.transcriber.DevicePreferences().Forget(r.PathValue("series"))
+		if errors.Is(err, devices.ErrNotFound) {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to forget device preference", "error", err)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
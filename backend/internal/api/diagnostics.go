@@ -0,0 +1,40 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// diagnosticsTimeout is how long gathering a diagnostic bundle may take, beyond the server's
+// write timeout
+const diagnosticsTimeout = 30 * time.Second
+
+// handleDiagnostics returns a handler that downloads a diagnostic bundle to attach to bug
+// reports
+func (s *Server) handleDiagnostics() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(diagnosticsTimeout + 10*time.Second)); err != nil {
+			s.logger.Debug("Failed to extend write deadline for diagnostic bundle", "error", err)
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), diagnosticsTimeout)
+		defer cancel()
+
+		// Built in memory so a failure can still be reported as an error response
+		var bundle bytes.Buffer
+		if err := s.transcriber.WriteDiagnostics(ctx, &bundle); err != nil {
+			s.logger.Error("Failed to create diagnostic bundle", "error", err)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": "Failed to create diagnostic bundle",
+			})
+			return
+		}
+
+		name := fmt.Sprintf("transcriber-diagnostics-%s.zip", time.Now().Format("20060102-150405"))
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", name))
+		w.Write(bundle.Bytes())
+	}
+}
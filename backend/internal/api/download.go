@@ -0,0 +1,72 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/prompts"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleTranscribeURL returns a handler that downloads a recording from a URL, such as a
+// webinar or podcast episode, and queues it for the full pipeline
+func (s *Server) handleTranscribeURL() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			URL          string            `json:"url"`
+			Title        string            `json:"title,omitempty"`
+			Type         string            `json:"type,omitempty"`
+			Participants []string          `json:"participants,omitempty"`
+			Tags         []string          `json:"tags,omitempty"`
+			Metadata     map[string]string `json:"metadata,omitempty"`
+			Template     string            `json:"template,omitempty"`
+			Series       string            `json:"series,omitempty"`
+			Stages       []string          `json:"stages,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		if err := s.checkStorageQuota(r); err != nil {
+			s.respondWithJSON(w, http.StatusInsufficientStorage, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		meetingId, err := s.transcriber.TranscribeURL(transcriber.RecordingOptions{
+			Title:        requestBody.Title,
+			Participants: requestBody.Participants,
+			Tags:         requestBody.Tags,
+			Metadata:     requestBody.Metadata,
+			Owner:        auth.Username(r.Context()),
+			Template:     requestBody.Template,
+			Type:         requestBody.Type,
+			Series:       requestBody.Series,
+			Stages:       requestBody.Stages,
+		}, requestBody.URL)
+		if errors.Is(err, transcriber.ErrInvalidURL) || errors.Is(err, transcriber.ErrUnknownPreset) || errors.Is(err, transcriber.ErrInvalidStages) || errors.Is(err, prompts.ErrNotFound) {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to transcribe URL", "error", err, "url", requestBody.URL)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to transcribe URL: %v", err),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+			"meeting_id": meetingId,
+		})
+	}
+}
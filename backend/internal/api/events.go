@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// parseEventRange reads the since and limit parameters of the event endpoints
+func parseEventRange(r *http.Request) (int64, int, error) {
+	query := r.URL.Query()
+
+	var since int64
+	if value := query.Get("since"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid since parameter %q", value)
+		}
+		since = n
+	}
+
+	var limit int
+	if value := query.Get("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("invalid limit parameter %q", value)
+		}
+		limit = n
+	}
+	return since, limit, nil
+}
+
+// handleEvents returns a handler that lists the events of all meetings after a sequence number
+func (s *Server) handleEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		since, limit, err := parseEventRange(r)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"events": s.transcriber.Events(since, limit, auth.FromContext(r.Context()).Owner()),
+		})
+	}
+}
+
+// handleMeetingEvents returns a handler that lists the events of a meeting
+func (s *Server) handleMeetingEvents() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		since, limit, err := parseEventRange(r)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		meetingEvents, err := s.transcriber.MeetingEvents(pathOrQueryId(r), since, limit)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"events": meetingEvents,
+		})
+	}
+}
+
+// handleReplayMeeting returns a handler that returns an event of a meeting together with
+// the meeting as it was right after the event
+func (s *Server) handleReplayMeeting() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		seq, err := strconv.ParseInt(r.PathValue("seq"), 10, 64)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("invalid event sequence number %q", r.PathValue("seq")),
+			})
+			return
+		}
+
+		event, meeting, err := s.transcriber.MeetingAt(pathOrQueryId(r), seq)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": fmt.Sprintf("event %d not found for meeting", seq),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"event":   event,
+			"meeting": meeting,
+		})
+	}
+}
+
+// handleUndoEdit returns a handler that reverts the most recent edit of a meeting
+func (s *Server) handleUndoEdit() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := pathOrQueryId(r)
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if _, err := s.transcriber.GetMeetingStatus(meetingId); err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		meeting, err := s.transcriber.UndoEdit(meetingId, version)
+		switch {
+		case errors.Is(err, transcriber.ErrStaleVersion):
+			s.respondWithJSON(w, http.StatusPreconditionFailed, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, transcriber.ErrMeetingBusy), errors.Is(err, events.ErrNothingToUndo):
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case err != nil && meeting == nil:
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case err != nil:
+			// The edit was undone but the vault note couldn't be written
+			s.logger.Error("Failed to update vault note after undo", "error", err, "meetingId", meetingId)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		w.Header().Set("ETag", etag(meeting))
+		s.respondWithJSON(w, http.StatusOK, meeting)
+	}
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/martijnspitter/transcriber/internal/encryption"
+)
+
+// handleDownloadExport returns a handler that downloads the export zip of a meeting
+func (s *Server) handleDownloadExport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := r.PathValue("id")
+		meeting, err := s.transcriber.GetMeetingStatus(meetingId)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": fmt.Sprintf("Failed to get meeting: %v", err),
+			})
+			return
+		}
+		if meeting.ExportPath == "" {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": "meeting has no export, enable audio.export before recording",
+			})
+			return
+		}
+		if _, err := os.Stat(meeting.ExportPath); err != nil {
+			s.respondWithJSON(w, http.StatusGone, map[string]string{
+				"error": "export file no longer exists",
+			})
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/zip")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(meeting.ExportPath)))
+		s.serveStoredFile(w, r, meeting.ExportPath)
+	}
+}
+
+// serveStoredFile serves an archived recording, export or highlight reel, decrypted when
+// it is stored encrypted. Files stored as is are streamed from disk.
+func (s *Server) serveStoredFile(w http.ResponseWriter, r *http.Request, path string) {
+	file, err := os.Open(path)
+	if err != nil {
+		s.respondWithJSON(w, http.StatusGone, map[string]string{
+			"error": "file no longer exists",
+		})
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		s.respondWithJSON(w, http.StatusGone, map[string]string{
+			"error": "file no longer exists",
+		})
+		return
+	}
+	header := make([]byte, 16)
+	n, _ := io.ReadFull(file, header)
+	if !encryption.IsEncrypted(header[:n]) {
+		if _, err := file.Seek(0, io.SeekStart); err == nil {
+			http.ServeContent(w, r, filepath.Base(path), info.ModTime(), file)
+			return
+		}
+	}
+
+	data, err := s.transcriber.ReadStoredFile(path)
+	if err != nil {
+		s.logger.Error("Failed to read stored file", "error", err, "path", path)
+		s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+			"error": "failed to read file",
+		})
+		return
+	}
+	http.ServeContent(w, r, filepath.Base(path), info.ModTime(), bytes.NewReader(data))
+}
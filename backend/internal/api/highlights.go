@@ -0,0 +1,69 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// handleAddBookmark returns a handler that marks the current moment of a recording, with an
+// optional note or label shown in the transcript
+func (s *Server) handleAddBookmark() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Note  string `json:"note,omitempty"`
+			Label string `json:"label,omitempty"` // Alias of note
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": "Invalid request body",
+				})
+				return
+			}
+		}
+
+		if requestBody.Note == "" {
+			requestBody.Note = requestBody.Label
+		}
+		bookmark, err := s.transcriber.AddBookmark(r.PathValue("id"), requestBody.Note)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusCreated, bookmark)
+	}
+}
+
+// handleDownloadHighlights returns a handler that downloads the highlight reel of a meeting
+func (s *Server) handleDownloadHighlights() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := r.PathValue("id")
+		meeting, err := s.transcriber.GetMeetingStatus(meetingId)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": fmt.Sprintf("Failed to get meeting: %v", err),
+			})
+			return
+		}
+		if meeting.HighlightsPath == "" {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": "meeting has no highlights, enable audio.highlights before recording",
+			})
+			return
+		}
+		if _, err := os.Stat(meeting.HighlightsPath); err != nil {
+			s.respondWithJSON(w, http.StatusGone, map[string]string{
+				"error": "highlights file no longer exists",
+			})
+			return
+		}
+
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filepath.Base(meeting.HighlightsPath)))
+		s.serveStoredFile(w, r, meeting.HighlightsPath)
+	}
+}
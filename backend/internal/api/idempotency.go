@@ -0,0 +1,174 @@
+package api
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+)
+
+// idempotencyHeader carries a client-chosen key that makes retrying a POST request safe
+const idempotencyHeader = "Idempotency-Key"
+
+// maxIdempotentBody is the largest request body that is read to be compared with retries
+const maxIdempotentBody = 64 << 20
+
+// idempotentResponse is the response to the first request with an idempotency key
+type idempotentResponse struct {
+	done    chan struct{} // Closed once the first request has been answered
+	hash    [sha256.Size]byte
+	status  int
+	header  http.Header
+	body    []byte
+	expires time.Time // Zero while the first request is in progress
+}
+
+// idempotencyStore remembers the responses to requests with an idempotency key for a window
+type idempotencyStore struct {
+	mu        sync.Mutex
+	window    time.Duration
+	responses map[string]*idempotentResponse
+}
+
+// newIdempotencyStore returns a store keeping responses for window seconds, or nil when
+// window is not positive
+func newIdempotencyStore(window int) *idempotencyStore {
+	if window <= 0 {
+		return nil
+	}
+	return &idempotencyStore{
+		window:    time.Duration(window) * time.Second,
+		responses: make(map[string]*idempotentResponse),
+	}
+}
+
+// begin returns the response stored for the key, or stores a pending one and reports that the
+// request is the first with the key
+func (s *idempotencyStore) begin(key string, hash [sha256.Size]byte) (*idempotentResponse, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for k, response := range s.responses {
+		if !response.expires.IsZero() && now.After(response.expires) {
+			delete(s.responses, k)
+		}
+	}
+	if response, ok := s.responses[key]; ok {
+		return response, false
+	}
+	response := &idempotentResponse{done: make(chan struct{}), hash: hash}
+	s.responses[key] = response
+	return response, true
+}
+
+// finish stores the response to the first request with the key. Server errors are not kept,
+// so a later retry runs the request again.
+func (s *idempotencyStore) finish(key string, response *idempotentResponse, recorder *responseRecorder) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	response.status = recorder.status
+	if response.status == 0 {
+		response.status = http.StatusInternalServerError // The handler panicked
+	}
+	response.header = recorder.Header().Clone()
+	response.body = recorder.body.Bytes()
+	response.expires = time.Now().Add(s.window)
+	if response.status >= http.StatusInternalServerError {
+		delete(s.responses, key)
+	}
+	close(response.done)
+}
+
+// responseRecorder passes a response through while keeping a copy of it
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (r *responseRecorder) WriteHeader(status int) {
+	if r.status == 0 {
+		r.status = status
+	}
+	r.ResponseWriter.WriteHeader(status)
+}
+
+func (r *responseRecorder) Write(b []byte) (int, error) {
+	if r.status == 0 {
+		r.status = http.StatusOK
+	}
+	r.body.Write(b)
+	return r.ResponseWriter.Write(b)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (r *responseRecorder) Unwrap() http.ResponseWriter {
+	return r.ResponseWriter
+}
+
+// idempotencyMiddleware answers retried POST requests carrying the Idempotency-Key header
+// with the response to the first request instead of running them again. Keys are scoped to
+// the user and path; a retry that arrives while the first request is still running waits for
+// its response, and reusing a key for a different body is rejected. A nil store disables it.
+func idempotencyMiddleware(store *idempotencyStore, next http.Handler) http.Handler {
+	if store == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		idempotencyKey := r.Header.Get(idempotencyHeader)
+		if r.Method != http.MethodPost || idempotencyKey == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxIdempotentBody))
+		if err != nil {
+			var maxBytesError *http.MaxBytesError
+			status := http.StatusBadRequest
+			if errors.As(err, &maxBytesError) {
+				status = http.StatusRequestEntityTooLarge
+			}
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(status)
+			w.Write([]byte(`{"error":"Failed to read request body"}`))
+			return
+		}
+		r.Body = io.NopCloser(bytes.NewReader(body))
+
+		key := auth.Username(r.Context()) + "\x00" + r.URL.Path + "\x00" + idempotencyKey
+		hash := sha256.Sum256(body)
+		response, first := store.begin(key, hash)
+		if first {
+			recorder := &responseRecorder{ResponseWriter: w}
+			defer store.finish(key, response, recorder)
+			next.ServeHTTP(recorder, r)
+			return
+		}
+
+		if response.hash != hash {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(`{"error":"Idempotency-Key was already used for a different request"}`))
+			return
+		}
+		select {
+		case <-response.done:
+		case <-r.Context().Done():
+			return
+		}
+		for name, values := range response.header {
+			w.Header()[name] = values
+		}
+		w.Header().Set("Idempotent-Replayed", "true")
+		w.WriteHeader(response.status)
+		w.Write(response.body)
+	})
+}
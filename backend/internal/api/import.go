@@ -0,0 +1,117 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/prompts"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// maxImportSize limits uploaded transcript files
+const maxImportSize = 50 << 20
+
+// handleImport returns a handler that creates a meeting from an uploaded SRT, WebVTT, Zoom,
+// Teams or JSON transcript and queues its summary and vault note. The multipart form has
+// the transcript as file, and optionally a title, format, date, comma separated
+// participants and tags, series, template and type.
+func (s *Server) handleImport() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+		file, header, err := r.FormFile("file")
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body, expected a transcript file in the file field",
+			})
+			return
+		}
+		defer file.Close()
+		data, err := io.ReadAll(file)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		var date time.Time
+		if value := r.FormValue("date"); value != "" {
+			if date, err = parseImportDate(value); err != nil {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": err.Error(),
+				})
+				return
+			}
+		}
+
+		if err := s.checkStorageQuota(r); err != nil {
+			s.respondWithJSON(w, http.StatusInsufficientStorage, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		meeting, err := s.transcriber.ImportTranscript(transcriber.ImportOptions{
+			Meeting: transcriber.RecordingOptions{
+				Title:        r.FormValue("title"),
+				Participants: splitList(r.FormValue("participants")),
+				Tags:         splitList(r.FormValue("tags")),
+				Owner:        auth.Username(r.Context()),
+				Template:     r.FormValue("template"),
+				Type:         r.FormValue("type"),
+				Series:       r.FormValue("series"),
+				Stages:       splitList(r.FormValue("stages")),
+			},
+			Format:   strings.ToLower(r.FormValue("format")),
+			FileName: header.Filename,
+			Date:     date,
+		}, data)
+		if errors.Is(err, transcriber.ErrInvalidImport) || errors.Is(err, transcriber.ErrUnknownPreset) || errors.Is(err, transcriber.ErrInvalidStages) || errors.Is(err, prompts.ErrNotFound) {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to import transcript", "error", err)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": fmt.Sprintf("Failed to import transcript: %v", err),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+			"meeting_id": meeting.Id,
+			"format":     meeting.ImportedFrom,
+			"segments":   len(meeting.Segments),
+		})
+	}
+}
+
+// parseImportDate parses the date of an imported meeting, as a date or an RFC 3339 time
+func parseImportDate(value string) (time.Time, error) {
+	if date, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return date, nil
+	}
+	date, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, use YYYY-MM-DD or RFC 3339", value)
+	}
+	return date, nil
+}
+
+// splitList splits a comma separated form value, dropping empty items
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
@@ -0,0 +1,61 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/logger"
+)
+
+// handleGetLogLevel returns a handler that reports the log level
+func (s *Server) handleGetLogLevel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respondWithJSON(w, http.StatusOK, s.logger.Level())
+	}
+}
+
+// handleSetLogLevel returns a handler that changes the log level until the duration has
+// passed, or until the next change or restart without one
+func (s *Server) handleSetLogLevel() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Level    string `json:"level"`
+			Duration string `json:"duration"` // e.g. 30m, empty keeps the level
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		level, err := logger.ParseLevel(requestBody.Level)
+		if err != nil || requestBody.Level == "" {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "level must be debug, info, warn or error",
+			})
+			return
+		}
+		var duration time.Duration
+		if requestBody.Duration != "" {
+			if duration, err = time.ParseDuration(requestBody.Duration); err != nil || duration <= 0 {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": "duration must be a positive duration such as 30m",
+				})
+				return
+			}
+		}
+
+		previous := s.logger.Level()
+		if err := s.logger.SetLevel(level, duration); err != nil {
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.logger.Info("Log level changed", "from", previous.Level, "to", requestBody.Level, "duration", duration, "user", auth.Username(r.Context()))
+		s.respondWithJSON(w, http.StatusOK, s.logger.Level())
+	}
+}
@@ -0,0 +1,24 @@
+package api
+
+import (
+	"net/http"
+)
+
+// handleMeetingApps returns a handler that lists the calls in progress in meeting apps, so
+// clients can prompt for a recording
+func (s *Server) handleMeetingApps() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		detector := s.transcriber.MeetingApps()
+		if detector == nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": "meeting app detection is disabled, set meeting_apps.enabled",
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"calls":      detector.Calls(),
+			"checked_at": detector.CheckedAt(),
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleMergeMeetings returns a handler that joins two meetings into a new one and archives
+// them, e.g. a recording that was stopped by accident and its continuation
+func (s *Server) handleMergeMeetings() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			MeetingIds []string `json:"meeting_ids"`
+			Versions   []int64  `json:"versions,omitempty"` // Versions of the meetings, in the order of meeting_ids
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || len(requestBody.MeetingIds) != 2 {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body, expected the meeting_ids of two meetings",
+			})
+			return
+		}
+		var versions [2]*int64
+		switch len(requestBody.Versions) {
+		case 0:
+		case 2:
+			versions[0], versions[1] = &requestBody.Versions[0], &requestBody.Versions[1]
+		default:
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body, expected the versions of both meetings",
+			})
+			return
+		}
+
+		for _, meetingId := range requestBody.MeetingIds {
+			meeting, err := s.transcriber.GetMeetingStatus(meetingId)
+			if err == nil && !auth.FromContext(r.Context()).CanAccess(meeting.Owner) {
+				err = fmt.Errorf("meeting not found with ID: %s", meetingId)
+			}
+			if err != nil {
+				s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+					"error": err.Error(),
+				})
+				return
+			}
+		}
+
+		meeting, err := s.transcriber.MergeMeetings(requestBody.MeetingIds[0], requestBody.MeetingIds[1], versions[0], versions[1])
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, transcriber.ErrInvalidMerge) || errors.Is(err, transcriber.ErrNoTranscript):
+				status = http.StatusBadRequest
+			case errors.Is(err, transcriber.ErrMeetingBusy) || errors.Is(err, transcriber.ErrInTrash):
+				status = http.StatusConflict
+			case errors.Is(err, transcriber.ErrStaleVersion):
+				status = http.StatusPreconditionFailed
+			default:
+				s.logger.Error("Failed to merge meetings", "error", err, "meetingIds", requestBody.MeetingIds)
+			}
+			s.respondWithJSON(w, status, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusAccepted, meeting)
+	}
+}
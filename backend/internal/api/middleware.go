@@ -0,0 +1,102 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/config"
+)
+
+// corsMiddleware adds CORS headers for allowed origins and answers preflight requests
+func corsMiddleware(cfg config.CORSConfig, next http.Handler) http.Handler {
+	allowAll := slices.Contains(cfg.AllowedOrigins, "*")
+	methods := strings.Join(cfg.AllowedMethods, ", ")
+	headers := strings.Join(cfg.AllowedHeaders, ", ")
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		w.Header().Add("Vary", "Origin")
+		if !allowAll && !slices.Contains(cfg.AllowedOrigins, origin) {
+			// Unknown origin: serve the request without CORS headers so the browser blocks it
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// Wildcards can't be combined with credentials, so echo the origin instead
+		if allowAll && !cfg.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Origin", "*")
+		} else {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+		}
+		if cfg.AllowCredentials {
+			w.Header().Set("Access-Control-Allow-Credentials", "true")
+		}
+
+		// Handle preflight requests
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			w.Header().Add("Vary", "Access-Control-Request-Method")
+			w.Header().Add("Vary", "Access-Control-Request-Headers")
+			w.Header().Set("Access-Control-Allow-Methods", methods)
+			if headers != "" {
+				w.Header().Set("Access-Control-Allow-Headers", headers)
+			} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+				w.Header().Set("Access-Control-Allow-Headers", requested)
+			}
+			if cfg.MaxAge > 0 {
+				w.Header().Set("Access-Control-Max-Age", strconv.Itoa(cfg.MaxAge))
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// publicPaths are served without authentication. Webhooks check their own shared secret,
+// shared meetings their signed token, and the web UI signs in through the API.
+var publicPaths = []string{
+	"/",
+	"/health",
+	apiPrefix + "/health",
+	apiPrefix + "/auth/login",
+	apiPrefix + "/auth/callback",
+}
+
+func isPublicPath(path string) bool {
+	return slices.Contains(publicPaths, path) || strings.HasPrefix(path, apiPrefix+"/webhooks/") || strings.HasPrefix(path, apiPrefix+"/shared/") || strings.HasPrefix(path, uiPrefix)
+}
+
+// authMiddleware rejects unauthenticated requests and stores the principal in the
+// request context. A nil authenticator disables authentication.
+func authMiddleware(authenticator *auth.Authenticator, next http.Handler) http.Handler {
+	if authenticator == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isPublicPath(r.URL.Path) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		principal, ok := authenticator.Authenticate(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="transcriber"`)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusUnauthorized)
+			w.Write([]byte(`{"error":"Authentication required"}`))
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(auth.WithPrincipal(r.Context(), principal)))
+	})
+}
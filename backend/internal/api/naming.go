@@ -0,0 +1,47 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handlePreviewPaths returns a handler that shows the note and recording names the naming
+// templates generate for a sample meeting
+func (s *Server) handlePreviewPaths() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Title        string               `json:"title"`
+			Type         string               `json:"type"`
+			Participants []string             `json:"participants"`
+			Tags         []string             `json:"tags"`
+			CreatedAt    time.Time            `json:"created_at"`
+			Templates    *config.NamingConfig `json:"templates"` // Try templates without changing the config
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		preview, err := s.transcriber.PreviewPaths(transcriber.PathPreviewOptions{
+			Title:        requestBody.Title,
+			Type:         requestBody.Type,
+			Participants: requestBody.Participants,
+			Tags:         requestBody.Tags,
+			CreatedAt:    requestBody.CreatedAt,
+			Naming:       requestBody.Templates,
+		})
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, preview)
+	}
+}
@@ -0,0 +1,48 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleAddNote returns a handler that adds a timestamped note to the meeting being recorded
+func (s *Server) handleAddNote() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Text string `json:"text"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		note, err := s.transcriber.AddNote(r.PathValue("id"), requestBody.Text, version)
+		if err != nil {
+			status := http.StatusConflict
+			switch {
+			case errors.Is(err, transcriber.ErrEmptyNote):
+				status = http.StatusBadRequest
+			case errors.Is(err, transcriber.ErrStaleVersion):
+				status = http.StatusPreconditionFailed
+			}
+			s.respondWithJSON(w, status, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusCreated, note)
+	}
+}
@@ -0,0 +1,91 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// renameParticipantRequest is the body of the participant rename endpoints
+type renameParticipantRequest struct {
+	From     string `json:"from"`
+	To       string `json:"to"`
+	FreeText bool   `json:"free_text"` // Also rename whole-word mentions in the text
+}
+
+// handleRenameParticipant returns a handler that renames a participant within a single meeting
+func (s *Server) handleRenameParticipant() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := pathOrQueryId(r)
+
+		var req renameParticipantRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if _, err := s.transcriber.GetMeetingStatus(meetingId); err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		result, err := s.transcriber.RenameParticipant(meetingId, req.From, req.To, req.FreeText, version)
+		if errors.Is(err, transcriber.ErrStaleVersion) {
+			s.respondWithJSON(w, http.StatusPreconditionFailed, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, transcriber.ErrMeetingBusy) {
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, result)
+	}
+}
+
+// handleRenameParticipantGlobally returns a handler that renames a participant across all meetings
+func (s *Server) handleRenameParticipantGlobally() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req renameParticipantRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		result, err := s.transcriber.RenameParticipant("", req.From, req.To, req.FreeText, nil)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/people"
+)
+
+// respondWithPeopleError maps people directory errors to HTTP responses
+func (s *Server) respondWithPeopleError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	switch {
+	case errors.Is(err, people.ErrNotFound):
+		status = http.StatusNotFound
+	case errors.Is(err, people.ErrConflict):
+		status = http.StatusConflict
+	}
+	s.respondWithJSON(w, status, map[string]string{
+		"error": err.Error(),
+	})
+}
+
+// handleListPeople returns a handler that lists the known people
+func (s *Server) handleListPeople() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"people": s.transcriber.People().List(),
+		})
+	}
+}
+
+// handleGetPerson returns a handler that returns a person by name or alias
+func (s *Server) handleGetPerson() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		person, ok := s.transcriber.People().Resolve(r.PathValue("name"))
+		if !ok {
+			s.respondWithPeopleError(w, people.ErrNotFound)
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, person)
+	}
+}
+
+// handleSavePerson returns a handler that creates or replaces a person
+func (s *Server) handleSavePerson() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Aliases  []string `json:"aliases"`
+			NotePath string   `json:"note_path"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		person, err := s.transcriber.People().Save(people.Person{
+			Name:     r.PathValue("name"),
+			Aliases:  requestBody.Aliases,
+			NotePath: requestBody.NotePath,
+		})
+		if err != nil {
+			s.respondWithPeopleError(w, err)
+			return
+		}
+		s.logger.Info("Person saved", "name", person.Name, "by", auth.Username(r.Context()))
+		s.respondWithJSON(w, http.StatusOK, person)
+	}
+}
+
+// handleDeletePerson returns a handler that removes a person from the directory
+func (s *Server) handleDeletePerson() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := s.transcriber.People().Delete(name); err != nil {
+			s.respondWithPeopleError(w, err)
+			return
+		}
+		s.logger.Info("Person deleted", "name", name, "by", auth.Username(r.Context()))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
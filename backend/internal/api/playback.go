@@ -0,0 +1,79 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// audioContentTypes are the content types of the recordings players are served, by extension
+var audioContentTypes = map[string]string{
+	".wav":  "audio/wav",
+	".ogg":  "audio/ogg",
+	".m4a":  "audio/mp4",
+	".flac": "audio/flac",
+}
+
+// playbackWait is how long a request waits for a recording to be transcoded for playback
+// before it is asked to come back
+const playbackWait = time.Minute
+
+// handleMeetingAudio returns a handler that serves the recording of a meeting to an audio
+// player, with range requests so it can seek without downloading the whole file. The
+// format parameter transcodes the recording to opus, aac or flac; while that takes longer than
+// playbackWait, the request is answered 202 with Retry-After.
+func (s *Server) handleMeetingAudio() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := r.PathValue("id")
+		if _, err := s.transcriber.GetMeetingStatus(meetingId); err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		// The transcode may outlast the write timeout of the server
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Now().Add(playbackWait + 10*time.Second)); err != nil {
+			s.logger.Debug("Failed to extend write deadline for playback", "error", err)
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), playbackWait)
+		defer cancel()
+
+		path, err := s.transcriber.PlaybackAudio(ctx, meetingId, r.URL.Query().Get("format"))
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, context.DeadlineExceeded) && r.Context().Err() == nil:
+				w.Header().Set("Retry-After", "10")
+				s.respondWithJSON(w, http.StatusAccepted, map[string]string{
+					"message": "Recording is being transcoded for playback",
+				})
+				return
+			case errors.Is(err, transcriber.ErrInvalidCodec):
+				status = http.StatusBadRequest
+			case errors.Is(err, transcriber.ErrMeetingBusy):
+				status = http.StatusConflict
+			case errors.Is(err, transcriber.ErrNoRecording):
+				status = http.StatusGone
+			case r.Context().Err() != nil:
+				return // The player went away while the recording was transcoded
+			default:
+				s.logger.Error("Failed to prepare recording for playback", "error", err, "meetingId", meetingId)
+			}
+			s.respondWithJSON(w, status, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if contentType, ok := audioContentTypes[filepath.Ext(path)]; ok {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.Header().Set("Cache-Control", "private, max-age=3600")
+		s.serveStoredFile(w, r, path)
+	}
+}
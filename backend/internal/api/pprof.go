@@ -0,0 +1,106 @@
+package api
+
+import (
+	"expvar"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// runtimeStatsVar is the expvar holding the runtime stats of the server
+const runtimeStatsVar = "transcriber"
+
+// runtimeStats is published under runtimeStatsVar next to the memstats and cmdline expvars
+type runtimeStats struct {
+	transcriber.RuntimeStats
+	Goroutines    int     `json:"goroutines"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+}
+
+// registerPprof exposes the runtime profiles under /api/v1/debug/pprof and the expvars under
+// /api/v1/debug/vars for admins. CPU profiles and traces must be shorter than the server's
+// write timeout, e.g. ?seconds=5.
+func (s *Server) registerPprof() {
+	s.handle("GET /debug/pprof/", s.requireAdmin(pprof.Index))
+	s.handle("GET /debug/pprof/cmdline", s.requireAdmin(pprof.Cmdline))
+	s.handle("GET /debug/pprof/profile", s.requireAdmin(pprof.Profile))
+	s.handle("GET /debug/pprof/symbol", s.requireAdmin(pprof.Symbol))
+	s.handle("POST /debug/pprof/symbol", s.requireAdmin(pprof.Symbol))
+	s.handle("GET /debug/pprof/trace", s.requireAdmin(pprof.Trace))
+	s.handle("GET /debug/pprof/{profile}", s.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(r.PathValue("profile")).ServeHTTP(w, r)
+	}))
+	s.handle("GET /debug/vars", s.requireAdmin(expvar.Handler().ServeHTTP))
+}
+
+// debugHandler serves the runtime profiles and expvars on the debug address, where they
+// need no authentication and long profiles aren't cut off by a write timeout. Requests for
+// any other host than localhost are refused, so a web page can't reach the address through
+// DNS rebinding.
+func debugHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !isLoopbackHost(r.Host) {
+			http.Error(w, "debug endpoints only answer requests for localhost", http.StatusForbidden)
+			return
+		}
+		mux.ServeHTTP(w, r)
+	})
+}
+
+// isLoopbackHost reports whether the Host of a request names this machine, localhost or a
+// loopback address, with or without a port
+func isLoopbackHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.Trim(host, "[]")
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// publishRuntimeStats publishes the runtime stats of the server as an expvar
+func (s *Server) publishRuntimeStats() {
+	if expvar.Get(runtimeStatsVar) != nil {
+		return // Published by an earlier server of this process
+	}
+	started := time.Now()
+	expvar.Publish(runtimeStatsVar, expvar.Func(func() any {
+		stats := runtimeStats{
+			Goroutines:    runtime.NumGoroutine(),
+			UptimeSeconds: time.Since(started).Seconds(),
+		}
+		if s.transcriber != nil {
+			stats.RuntimeStats = s.transcriber.RuntimeStats()
+		}
+		return stats
+	}))
+}
+
+// checkLoopback checks that the debug address only accepts local connections
+func checkLoopback(addr string) error {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid server.debug_addr %q: %w", addr, err)
+	}
+	if ip := net.ParseIP(host); host != "localhost" && (ip == nil || !ip.IsLoopback()) {
+		return fmt.Errorf("server.debug_addr must be a loopback address such as localhost:6060, got %q", addr)
+	}
+	return nil
+}
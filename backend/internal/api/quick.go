@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// nowResponse is the recording state polled by launcher scripts, kept small as it is
+// fetched every second
+type nowResponse struct {
+	Recording bool   `json:"recording"`
+	MeetingId string `json:"meeting_id,omitempty"`
+	Title     string `json:"title,omitempty"`
+	Elapsed   int    `json:"elapsed,omitempty"` // Seconds since the recording started
+}
+
+// now returns the recording state as the caller may see it: the recording of another user
+// is reported without its id and title
+func (s *Server) now(r *http.Request) nowResponse {
+	meeting, ok := s.transcriber.ActiveRecording()
+	if !ok {
+		return nowResponse{}
+	}
+	if !auth.FromContext(r.Context()).CanAccess(meeting.Owner) {
+		return nowResponse{Recording: true}
+	}
+	return nowResponse{
+		Recording: true,
+		MeetingId: meeting.Id,
+		Title:     meeting.Title,
+		Elapsed:   int(time.Since(meeting.Start_time).Seconds()),
+	}
+}
+
+// handleNow returns a handler that reports whether a meeting is being recorded
+func (s *Server) handleNow() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respondWithJSON(w, http.StatusOK, s.now(r))
+	}
+}
+
+// handleQuickStart returns a handler that starts a recording with the defaults, titled after
+// the current calendar event if any, without a request body. While a meeting is being
+// recorded nothing is started, so a launcher can fire it repeatedly; the service decides
+// this, so two quick starts at once start one recording.
+func (s *Server) handleQuickStart() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if err := s.checkStorageQuota(r); err != nil {
+			s.respondWithJSON(w, http.StatusInsufficientStorage, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		_, started, err := s.transcriber.QuickStart(transcriber.RecordingOptions{Owner: auth.Username(r.Context())})
+		if err != nil {
+			s.respondStartError(w, err)
+			return
+		}
+		status := http.StatusOK
+		if started {
+			status = http.StatusAccepted
+		}
+		s.respondWithJSON(w, status, s.now(r))
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+)
+
+// APIVersion is the current version of the HTTP API, returned in the
+// X-API-Version header of every response
+const APIVersion = "v1"
+
+// apiPrefix is the path prefix of the versioned API
+const apiPrefix = "/api/" + APIVersion
+
+// uiPrefix is the path prefix of the files of the bundled web UI
+const uiPrefix = "/ui/"
+
+// handle registers a handler under the versioned API prefix. The pattern uses the
+// Go 1.22 method syntax, e.g. "POST /recordings" is served at POST /api/v1/recordings.
+// Routes of a single meeting are limited to its owner.
+func (s *Server) handle(pattern string, handler http.HandlerFunc) {
+	method, path, _ := strings.Cut(pattern, " ")
+	if isMeetingPath(path) {
+		handler = s.requireOwner(handler)
+	}
+	s.router.HandleFunc(method+" "+apiPrefix+path, s.requireScope(pattern, handler))
+}
+
+// scopeRoutes are the routes API keys with a limited scope may call, by scope
+var scopeRoutes = map[string][]string{
+	auth.ScopeRecorder: {
+		"POST /recordings",
+		"POST /recordings/{id}/stop",
+		"POST /recordings/{id}/bookmarks",
+		"POST /recordings/arm",
+		"GET /recordings/arm",
+		"DELETE /recordings/arm",
+		"GET /now",
+		"GET /status",
+		"GET /status/stream",
+		"POST /quick-start",
+		"GET /audio-devices",
+		"GET /capabilities",
+		"GET /auth/session",
+	},
+	auth.ScopeReader: {
+		"GET /meetings",
+		"GET /meetings/{id}/stats",
+		"GET /stats",
+		"GET /series/{id}",
+		"GET /presets",
+		"GET /now",
+		"GET /status",
+		"GET /status/stream",
+		"GET /me/usage",
+		"GET /capabilities",
+		"GET /auth/session",
+	},
+}
+
+// requireScope wraps the handler of a route so API keys with a limited scope are refused
+// the routes outside their scope
+func (s *Server) requireScope(pattern string, handler http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		principal := auth.FromContext(r.Context())
+		if principal != nil && principal.Scope != "" && !slices.Contains(scopeRoutes[principal.Scope], pattern) {
+			s.respondWithJSON(w, http.StatusForbidden, map[string]string{
+				"error": fmt.Sprintf("API key scope %s doesn't allow this request", principal.Scope),
+			})
+			return
+		}
+		handler(w, r)
+	}
+}
+
+// isMeetingPath reports whether a route pattern addresses a single meeting by its id
+func isMeetingPath(path string) bool {
+	return strings.HasPrefix(path, "/meetings/{id}") || strings.HasPrefix(path, "/recordings/{id}")
+}
+
+// handleLegacy registers a deprecated unversioned alias for a versioned endpoint.
+// Responses carry Deprecation and Link headers pointing clients at the successor.
+func (s *Server) handleLegacy(pattern string, successor string, handler http.HandlerFunc) {
+	if isMeetingPath(successor) {
+		handler = s.requireOwner(handler)
+	}
+	method, _, _ := strings.Cut(pattern, " ")
+	handler = s.requireScope(method+" "+successor, handler)
+	s.router.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Deprecation", "true")
+		w.Header().Set("Link", "<"+apiPrefix+successor+`>; rel="successor-version"`)
+		handler(w, r)
+	})
+}
+
+// versionMiddleware adds the API version header to every response
+func versionMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-API-Version", APIVersion)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// pathOrQueryId returns the {id} path value of versioned routes, falling back to
+// the id query parameter used by the legacy routes
+func pathOrQueryId(r *http.Request) string {
+	if id := r.PathValue("id"); id != "" {
+		return id
+	}
+	return r.URL.Query().Get("id")
+}
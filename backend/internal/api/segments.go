@@ -0,0 +1,41 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleMeetingSegments returns a handler that lists the transcript segments of a meeting
+// with its chapters, or the segments of one chapter with ?chapter=<index>
+func (s *Server) handleMeetingSegments() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		chapter := -1
+		if value := r.URL.Query().Get("chapter"); value != "" {
+			n, err := strconv.Atoi(value)
+			if err != nil || n < 0 {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": fmt.Sprintf("invalid chapter parameter %q", value),
+				})
+				return
+			}
+			chapter = n
+		}
+
+		segments, err := s.transcriber.MeetingSegments(r.PathValue("id"), chapter)
+		if err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, transcriber.ErrInvalidChapter) {
+				status = http.StatusBadRequest
+			}
+			s.respondWithJSON(w, status, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, segments)
+	}
+}
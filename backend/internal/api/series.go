@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+)
+
+// handleGetSeries returns a handler that lists the meetings of a recurring meeting series
+// with their action items
+func (s *Server) handleGetSeries() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		series, err := s.transcriber.GetSeries(r.PathValue("id"), auth.FromContext(r.Context()).Owner())
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, series)
+	}
+}
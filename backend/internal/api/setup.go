@@ -0,0 +1,63 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleSetupStatus returns a handler that reports the progress of the first-run setup
+func (s *Server) handleSetupStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respondWithJSON(w, http.StatusOK, s.transcriber.SetupStatus())
+	}
+}
+
+// handleSetupStep returns a handler that completes a step of the first-run setup
+func (s *Server) handleSetupStep() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody transcriber.SetupStepRequest
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		result, err := s.transcriber.SetupStep(r.Context(), requestBody)
+		switch {
+		case errors.Is(err, transcriber.ErrInvalidSetupStep):
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, transcriber.ErrFFmpegMissing):
+			s.respondWithJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case errors.Is(err, transcriber.ErrRecordingInProgress):
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		case err != nil:
+			s.logger.Error("Failed to complete setup step", "error", err, "step", requestBody.Step)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		if result.Soundcheck != nil {
+			s.respondWithJSON(w, http.StatusOK, struct {
+				*transcriber.SetupStepResult
+				PlaybackURL string `json:"playback_url"`
+			}{result, apiPrefix + "/test-recording/" + result.Soundcheck.Id + "/audio"})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, result)
+	}
+}
@@ -0,0 +1,355 @@
+package api
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Errors of share tokens
+var (
+	errInvalidShareToken = errors.New("invalid share token")
+	errShareTokenExpired = errors.New("share link expired")
+	errShareTokenRevoked = errors.New("share link revoked")
+)
+
+// Validity of share links when the config leaves it out, in hours
+const (
+	defaultShareTTLHours    = 7 * 24
+	defaultShareMaxTTLHours = 30 * 24
+)
+
+// loadShareKey returns the key share links are signed with: the configured secret, or a key
+// generated into the secret file on first use so links survive restarts
+func loadShareKey(cfg config.SharingConfig) ([]byte, error) {
+	if cfg.Secret != "" {
+		return []byte(cfg.Secret), nil
+	}
+	key, err := os.ReadFile(cfg.SecretFile)
+	if err == nil {
+		return key, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read share secret: %w", err)
+	}
+
+	random := make([]byte, 32)
+	if _, err := rand.Read(random); err != nil {
+		return nil, err
+	}
+	key = []byte(hex.EncodeToString(random))
+	if err := os.MkdirAll(filepath.Dir(cfg.SecretFile), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create share secret directory: %w", err)
+	}
+	if err := os.WriteFile(cfg.SecretFile, key, 0600); err != nil {
+		return nil, fmt.Errorf("failed to write share secret: %w", err)
+	}
+	return key, nil
+}
+
+// signShareToken returns a token granting read access to a meeting until it expires. The
+// token is the meeting id and expiry with their HMAC-SHA256, so no state is kept per link
+// except for the links revoked early; changing the secret revokes every link.
+func (s *Server) signShareToken(meetingId string, expires time.Time) string {
+	payload := meetingId + "|" + strconv.FormatInt(expires.Unix(), 10)
+	return base64.RawURLEncoding.EncodeToString([]byte(payload)) + "." + base64.RawURLEncoding.EncodeToString(s.shareMAC(payload))
+}
+
+// verifyShareToken returns the id of the meeting a token grants access to
+func (s *Server) verifyShareToken(token string) (string, error) {
+	meetingId, expires, err := s.parseShareToken(token)
+	if err != nil {
+		return "", err
+	}
+	if time.Now().After(expires) {
+		return "", errShareTokenExpired
+	}
+	if s.revoked.isRevoked(token) {
+		return "", errShareTokenRevoked
+	}
+	return meetingId, nil
+}
+
+// parseShareToken checks the signature of a token and returns the meeting it is for and when
+// it expires
+func (s *Server) parseShareToken(token string) (string, time.Time, error) {
+	encodedPayload, encodedMAC, ok := strings.Cut(token, ".")
+	if !ok {
+		return "", time.Time{}, errInvalidShareToken
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encodedPayload)
+	if err != nil {
+		return "", time.Time{}, errInvalidShareToken
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(encodedMAC)
+	if err != nil || !hmac.Equal(mac, s.shareMAC(string(payload))) {
+		return "", time.Time{}, errInvalidShareToken
+	}
+
+	meetingId, expires, ok := strings.Cut(string(payload), "|")
+	if !ok {
+		return "", time.Time{}, errInvalidShareToken
+	}
+	unix, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil {
+		return "", time.Time{}, errInvalidShareToken
+	}
+	return meetingId, time.Unix(unix, 0), nil
+}
+
+func (s *Server) shareMAC(payload string) []byte {
+	mac := hmac.New(sha256.New, s.shareKey)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)
+}
+
+// shareBaseURL returns the URL share links start with, from the config or the request
+func (s *Server) shareBaseURL(r *http.Request) string {
+	if base := s.config.Server.Sharing.BaseURL; base != "" {
+		return strings.TrimSuffix(base, "/")
+	}
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host
+}
+
+// handleShareMeeting returns a handler that creates an expiring link to a read-only view of a
+// meeting's summary and transcript
+func (s *Server) handleShareMeeting() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			ExpiresInHours int `json:"expires_in_hours,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil && err != io.EOF {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		ttl, maxTTL := s.shareTTL()
+		hours := requestBody.ExpiresInHours
+		if hours == 0 {
+			hours = ttl
+		}
+		if hours < 0 || hours > maxTTL {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("expires_in_hours must be between 1 and %d", maxTTL),
+			})
+			return
+		}
+
+		meetingId := r.PathValue("id")
+		meeting, err := s.transcriber.GetMeetingStatus(meetingId)
+		if err != nil || meeting.TrashedAt != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": fmt.Sprintf("meeting not found with ID: %s", meetingId),
+			})
+			return
+		}
+		if meeting.Summary == "" && meeting.Transcript == "" {
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": "Meeting has no summary or transcript to share yet",
+			})
+			return
+		}
+
+		expires := time.Now().Add(time.Duration(hours) * time.Hour).Truncate(time.Second)
+		token := s.signShareToken(meetingId, expires)
+		s.logger.Info("Shared meeting", "meetingId", meetingId, "expires", expires)
+		s.respondWithJSON(w, http.StatusCreated, map[string]string{
+			"url":        s.shareBaseURL(r) + apiPrefix + "/shared/" + token,
+			"token":      token,
+			"expires_at": expires.UTC().Format(time.RFC3339),
+		})
+	}
+}
+
+// shareTTL returns how many hours links are valid by default and at most, falling back to
+// the defaults for values the config leaves out
+func (s *Server) shareTTL() (int, int) {
+	sharing := s.config.Server.Sharing
+	ttl, maxTTL := sharing.TTLHours, sharing.MaxTTLHours
+	if maxTTL <= 0 {
+		maxTTL = defaultShareMaxTTLHours
+	}
+	if ttl <= 0 {
+		ttl = defaultShareTTLHours
+	}
+	return min(ttl, maxTTL), maxTTL
+}
+
+// handleRevokeShare returns a handler that revokes a share link of a meeting before it expires
+func (s *Server) handleRevokeShare() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.PathValue("token")
+		meetingId, expires, err := s.parseShareToken(token)
+		if err != nil || meetingId != r.PathValue("id") {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": "Share link not found for this meeting",
+			})
+			return
+		}
+
+		if time.Now().Before(expires) {
+			if err := s.revoked.revoke(token, expires); err != nil {
+				s.logger.Error("Failed to revoke share link", "error", err, "meetingId", meetingId)
+				s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+					"error": "Failed to revoke share link",
+				})
+				return
+			}
+			s.logger.Info("Revoked share link", "meetingId", meetingId, "expires", expires)
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// handleSharedMeeting returns a handler that serves the read-only view of a shared meeting to
+// anyone with a valid link
+func (s *Server) handleSharedMeeting() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// Links are private: keep them out of caches, search engines and referrers
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("X-Robots-Tag", "noindex")
+		w.Header().Set("Referrer-Policy", "no-referrer")
+		w.Header().Set("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+
+		meetingId, err := s.verifyShareToken(r.PathValue("token"))
+		if errors.Is(err, errShareTokenExpired) {
+			http.Error(w, "This link has expired.", http.StatusGone)
+			return
+		}
+		if errors.Is(err, errShareTokenRevoked) {
+			http.Error(w, "This link has been revoked.", http.StatusGone)
+			return
+		}
+		if err != nil {
+			http.Error(w, "This link is not valid.", http.StatusNotFound)
+			return
+		}
+		meeting, err := s.transcriber.GetMeetingStatus(meetingId)
+		if err != nil || meeting.TrashedAt != nil {
+			http.Error(w, "This meeting is no longer available.", http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		if err := sharedMeetingPage.Execute(w, meeting); err != nil {
+			s.logger.Error("Failed to render shared meeting", "error", err, "meetingId", meetingId)
+		}
+	}
+}
+
+// shareRevocations holds the share links revoked before they expire, in a file so they stay
+// revoked across restarts. Links are forgotten once they have expired.
+type shareRevocations struct {
+	mu      sync.Mutex
+	path    string
+	revoked map[string]time.Time // Token to when it expires
+}
+
+// loadShareRevocations reads the revoked links from the file at path
+func loadShareRevocations(path string) (*shareRevocations, error) {
+	r := &shareRevocations{path: path, revoked: make(map[string]time.Time)}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return r, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read revoked share links: %w", err)
+	}
+	if err := json.Unmarshal(data, &r.revoked); err != nil {
+		return nil, fmt.Errorf("failed to read revoked share links: %w", err)
+	}
+	return r, nil
+}
+
+// revoke revokes a link until it expires
+func (r *shareRevocations) revoke(token string, expires time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	for revoked, until := range r.revoked {
+		if now.After(until) {
+			delete(r.revoked, revoked)
+		}
+	}
+	r.revoked[token] = expires
+
+	data, err := json.Marshal(r.revoked)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(r.path), 0700); err != nil {
+		return err
+	}
+	tmpPath := r.path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, r.path)
+}
+
+// isRevoked reports whether a link was revoked
+func (r *shareRevocations) isRevoked(token string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	_, ok := r.revoked[token]
+	return ok
+}
+
+// sharedMeetingPage is the read-only view of a shared meeting
+var sharedMeetingPage = template.Must(template.New("shared").Funcs(template.FuncMap{
+	"date":    func(t time.Time) string { return t.Format("Monday 2 January 2006, 15:04") },
+	"minutes": func(m *types.Meeting) int { return (m.Duration + 59) / 60 },
+	"join":    strings.Join,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<meta name="robots" content="noindex">
+<title>{{if .Title}}{{.Title}}{{else}}Meeting{{end}}</title>
+<style>
+body { font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", sans-serif; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.5; color: #222; }
+header p { color: #666; margin: 0.25rem 0; }
+.text { white-space: pre-wrap; }
+details { margin-top: 2rem; }
+summary { cursor: pointer; font-weight: 600; }
+</style>
+</head>
+<body>
+<header>
+<h1>{{if .Title}}{{.Title}}{{else}}Meeting{{end}}</h1>
+<p>{{date .Start_time}}{{if .Duration}} · {{minutes .}} min{{end}}</p>
+{{if .Participants}}<p>{{join .Participants ", "}}</p>{{end}}
+</header>
+{{if .Summary}}<section class="text">{{.Summary}}</section>{{end}}
+{{if .Transcript}}<details{{if not .Summary}} open{{end}}>
+<summary>Transcript</summary>
+<div class="text">{{.Transcript}}</div>
+</details>{{end}}
+</body>
+</html>
+`))
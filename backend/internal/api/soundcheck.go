@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleTestRecording returns a handler that records a few seconds from the selected devices
+// and reports their levels, as a soundcheck before a meeting
+func (s *Server) handleTestRecording() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Title        string `json:"title,omitempty"`
+			Series       string `json:"series,omitempty"`
+			MicDevice    string `json:"mic_device,omitempty"`
+			SystemDevice string `json:"system_device,omitempty"`
+		}
+		if r.ContentLength != 0 {
+			if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+				s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+					"error": "Invalid request body",
+				})
+				return
+			}
+		}
+
+		result, err := s.transcriber.Soundcheck(r.Context(), transcriber.SoundcheckOptions{
+			Title:        requestBody.Title,
+			Series:       requestBody.Series,
+			MicDevice:    requestBody.MicDevice,
+			SystemDevice: requestBody.SystemDevice,
+		})
+		if errors.Is(err, transcriber.ErrFFmpegMissing) {
+			s.respondWithJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, audiocapture.ErrDeviceNotFound) || errors.Is(err, audiocapture.ErrAmbiguousDevice) {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if errors.Is(err, transcriber.ErrRecordingInProgress) {
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to record soundcheck", "error", err)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusOK, struct {
+			*transcriber.SoundcheckResult
+			PlaybackURL string `json:"playback_url"`
+		}{result, apiPrefix + "/test-recording/" + result.Id + "/audio"})
+	}
+}
+
+// handleTestRecordingAudio returns a handler that serves the mixed audio of a soundcheck
+func (s *Server) handleTestRecordingAudio() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		path, err := s.transcriber.SoundcheckAudio(r.PathValue("id"))
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		w.Header().Set("Content-Type", "audio/wav")
+		http.ServeFile(w, r, path)
+	}
+}
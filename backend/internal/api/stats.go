@@ -0,0 +1,43 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleMeetingStats returns a handler that reports the talk time, pace, filler words and
+// longest monologue of a meeting
+func (s *Server) handleMeetingStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingStats, err := s.transcriber.MeetingStats(r.PathValue("id"))
+		if err != nil {
+			status := http.StatusNotFound
+			if errors.Is(err, transcriber.ErrNoTranscript) {
+				status = http.StatusConflict
+			}
+			s.respondWithJSON(w, status, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, meetingStats)
+	}
+}
+
+// handleAggregateStats returns a handler that reports the stats of the transcribed meetings
+// matching the list filters together, e.g. from and to for a date range
+func (s *Server) handleAggregateStats() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		opts, err := parseListOptions(r)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		scopeFilter(r, &opts.Filter)
+		s.respondWithJSON(w, http.StatusOK, s.transcriber.AggregateStats(opts.Filter))
+	}
+}
@@ -0,0 +1,115 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/update"
+)
+
+// Status stream timing: changes are pushed at most once per statusInterval, and a comment
+// keeps idle connections open through proxies
+const (
+	statusInterval  = time.Second
+	statusKeepAlive = 30 * time.Second
+)
+
+// status returns the state shown by a menu bar companion: the recording as the caller may see
+// it, the number of queued jobs, the last failure of the caller's meetings, the running
+// version and the newer release found, if any
+func (s *Server) status(r *http.Request) map[string]interface{} {
+	now := s.now(r)
+	status := map[string]interface{}{
+		"recording": now.Recording,
+		"queued":    s.transcriber.RuntimeStats().QueuedJobs,
+		"version":   update.Version,
+	}
+	if updates := s.transcriber.Updates(); updates != nil {
+		if release := updates.Status(); release.Available {
+			status["update_available"] = release.Latest
+		}
+	}
+	if now.MeetingId != "" {
+		status["meeting_id"] = now.MeetingId
+		status["title"] = now.Title
+		status["elapsed"] = now.Elapsed
+	}
+	if failure, ok := s.transcriber.LastFailure(); ok && auth.FromContext(r.Context()).CanAccess(failure.Owner()) {
+		status["last_error"] = failure
+	}
+	return status
+}
+
+// handleStatus returns a handler that reports the menu bar status once
+func (s *Server) handleStatus() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		s.respondWithJSON(w, http.StatusOK, s.status(r))
+	}
+}
+
+// handleStatusStream returns a handler that streams the menu bar status as server-sent
+// events. The first event holds the full status, later ones only the fields that changed,
+// with null for fields that are gone, at most once per second.
+func (s *Server) handleStatusStream() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		// The stream outlives the write timeout of the server
+		rc := http.NewResponseController(w)
+		if err := rc.SetWriteDeadline(time.Time{}); err != nil {
+			s.logger.Error("Failed to clear write deadline of status stream", "error", err)
+		}
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		ticker := time.NewTicker(statusInterval)
+		defer ticker.Stop()
+		sent := make(map[string]string) // Fields sent last, as JSON
+		lastWrite := time.Now()
+		for {
+			delta := make(map[string]interface{})
+			status := s.status(r)
+			for field, value := range status {
+				encoded, _ := json.Marshal(value)
+				if sent[field] != string(encoded) {
+					delta[field] = value
+					sent[field] = string(encoded)
+				}
+			}
+			for field := range sent {
+				if _, ok := status[field]; !ok {
+					delta[field] = nil
+					delete(sent, field)
+				}
+			}
+
+			event := ""
+			if len(delta) > 0 {
+				data, _ := json.Marshal(delta)
+				event = fmt.Sprintf("data: %s\n\n", data)
+			} else if time.Since(lastWrite) >= statusKeepAlive {
+				event = ": keep-alive\n\n"
+			}
+			if event != "" {
+				if _, err := fmt.Fprint(w, event); err != nil {
+					return
+				}
+				if err := rc.Flush(); err != nil {
+					return
+				}
+				lastWrite = time.Now()
+			}
+
+			select {
+			case <-r.Context().Done():
+				return
+			case <-s.shutdown:
+				return
+			case <-ticker.C:
+			}
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/prompts"
+)
+
+// respondWithTemplateError maps template store errors to HTTP responses
+func (s *Server) respondWithTemplateError(w http.ResponseWriter, err error) {
+	status := http.StatusBadRequest
+	if errors.Is(err, prompts.ErrNotFound) {
+		status = http.StatusNotFound
+	}
+	s.respondWithJSON(w, status, map[string]string{
+		"error": err.Error(),
+	})
+}
+
+// handleListTemplates returns a handler that lists the summarization templates
+func (s *Server) handleListTemplates() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		templates, err := s.transcriber.Prompts().List()
+		if err != nil {
+			s.logger.Error("Failed to list templates", "error", err)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, map[string]interface{}{
+			"templates": templates,
+		})
+	}
+}
+
+// handleGetTemplate returns a handler that returns a single summarization template
+func (s *Server) handleGetTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		template, err := s.transcriber.Prompts().Get(r.PathValue("name"))
+		if err != nil {
+			s.respondWithTemplateError(w, err)
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, template)
+	}
+}
+
+// handleSaveTemplate returns a handler that creates or replaces a summarization template
+func (s *Server) handleSaveTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Prompt string `json:"prompt"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		template, err := s.transcriber.Prompts().Save(r.PathValue("name"), requestBody.Prompt)
+		if err != nil {
+			s.respondWithTemplateError(w, err)
+			return
+		}
+		s.logger.Info("Template saved", "template", template.Name, "by", auth.Username(r.Context()))
+		s.respondWithJSON(w, http.StatusOK, template)
+	}
+}
+
+// handleDeleteTemplate returns a handler that deletes a summarization template.
+// Deleting an override of a built-in template restores the built-in.
+func (s *Server) handleDeleteTemplate() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("name")
+		if err := s.transcriber.Prompts().Delete(name); err != nil {
+			s.respondWithTemplateError(w, err)
+			return
+		}
+		s.logger.Info("Template deleted", "template", name, "by", auth.Username(r.Context()))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
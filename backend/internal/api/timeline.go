@@ -0,0 +1,20 @@
+package api
+
+import (
+	"net/http"
+)
+
+// handleMeetingTimeline returns a handler that lists the segments, markers, chapters and
+// notes of a meeting in the order they occur in the audio
+func (s *Server) handleMeetingTimeline() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		timeline, err := s.transcriber.MeetingTimeline(r.PathValue("id"))
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, timeline)
+	}
+}
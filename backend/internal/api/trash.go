@@ -0,0 +1,57 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// handleArchiveMeeting returns a handler that hides a meeting from the meetings list
+func (s *Server) handleArchiveMeeting() http.HandlerFunc {
+	return s.handleMoveMeeting(s.transcriber.ArchiveMeeting)
+}
+
+// handleTrashMeeting returns a handler that moves a meeting to the trash
+func (s *Server) handleTrashMeeting() http.HandlerFunc {
+	return s.handleMoveMeeting(s.transcriber.TrashMeeting)
+}
+
+// handleRestoreMeeting returns a handler that takes a meeting out of the trash or archive
+func (s *Server) handleRestoreMeeting() http.HandlerFunc {
+	return s.handleMoveMeeting(s.transcriber.RestoreMeeting)
+}
+
+// handleMoveMeeting returns a handler that moves a meeting in or out of the archive or trash
+// with move and responds with the meeting. An If-Match header holding the meeting's ETag
+// rejects the move when the meeting changed since.
+func (s *Server) handleMoveMeeting(move func(meetingId string, version *int64) (*types.Meeting, error)) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		version, err := ifMatchVersion(r)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		meeting, err := move(r.PathValue("id"), version)
+		if err != nil {
+			status := http.StatusNotFound
+			switch {
+			case errors.Is(err, transcriber.ErrMeetingBusy) || errors.Is(err, transcriber.ErrInTrash):
+				status = http.StatusConflict
+			case errors.Is(err, transcriber.ErrStaleVersion):
+				status = http.StatusPreconditionFailed
+			}
+			s.respondWithJSON(w, status, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		w.Header().Set("ETag", etag(meeting))
+		s.respondWithJSON(w, http.StatusOK, meeting)
+	}
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+)
+
+const bytesPerMB = 1 << 20
+
+// storageQuotaMB returns the storage quota of the user in megabytes, 0 meaning unlimited
+func (s *Server) storageQuotaMB(username string) int {
+	if s.auth == nil {
+		return 0
+	}
+	if user, err := s.auth.Store().GetUser(username); err == nil && user.Quotas.MaxStorageMB > 0 {
+		return user.Quotas.MaxStorageMB
+	}
+	return s.config.Auth.DefaultStorageQuotaMB
+}
+
+// checkStorageQuota returns an error when the user's meetings use up their storage quota
+func (s *Server) checkStorageQuota(r *http.Request) error {
+	username := auth.Username(r.Context())
+	quota := s.storageQuotaMB(username)
+	if quota <= 0 {
+		return nil
+	}
+
+	usage := s.transcriber.StorageUsage(username)
+	if usage.TotalBytes >= int64(quota)*bytesPerMB {
+		return fmt.Errorf("storage quota exceeded: %.1f MB used of %d MB, delete old meetings to free up space",
+			float64(usage.TotalBytes)/bytesPerMB, quota)
+	}
+	return nil
+}
+
+// handleUsage returns a handler that reports the storage used by the authenticated user
+func (s *Server) handleUsage() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		username := auth.Username(r.Context())
+		usage := s.transcriber.StorageUsage(username)
+
+		response := map[string]interface{}{
+			"user":  username,
+			"usage": usage,
+		}
+		if quota := s.storageQuotaMB(username); quota > 0 {
+			quotaBytes := int64(quota) * bytesPerMB
+			response["quota_bytes"] = quotaBytes
+			response["remaining_bytes"] = max(quotaBytes-usage.TotalBytes, 0)
+		}
+
+		s.respondWithJSON(w, http.StatusOK, response)
+	}
+}
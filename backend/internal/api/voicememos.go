@@ -0,0 +1,88 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/prompts"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+	"github.com/martijnspitter/transcriber/internal/voicememos"
+)
+
+// handleListVoiceMemos returns a handler that lists the Voice Memos recordings that weren't
+// imported yet, or all of them with all=true
+func (s *Server) handleListVoiceMemos() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		memos, err := s.transcriber.VoiceMemos(r.URL.Query().Get("all") == "true")
+		if err != nil {
+			s.logger.Error("Failed to list voice memos", "error", err)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		s.respondWithJSON(w, http.StatusOK, memos)
+	}
+}
+
+// handleImportVoiceMemos returns a handler that queues the picked Voice Memos recordings for
+// the full pipeline, keeping the dates they were recorded
+func (s *Server) handleImportVoiceMemos() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var requestBody struct {
+			Ids          []string `json:"ids"`
+			Type         string   `json:"type,omitempty"`
+			Participants []string `json:"participants,omitempty"`
+			Tags         []string `json:"tags,omitempty"`
+			Template     string   `json:"template,omitempty"`
+			Series       string   `json:"series,omitempty"`
+			Stages       []string `json:"stages,omitempty"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&requestBody); err != nil || len(requestBody.Ids) == 0 {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body, expected the ids of the memos to import",
+			})
+			return
+		}
+
+		if err := s.checkStorageQuota(r); err != nil {
+			s.respondWithJSON(w, http.StatusInsufficientStorage, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		meetingIds, err := s.transcriber.ImportVoiceMemos(transcriber.RecordingOptions{
+			Participants: requestBody.Participants,
+			Tags:         requestBody.Tags,
+			Owner:        auth.Username(r.Context()),
+			Template:     requestBody.Template,
+			Type:         requestBody.Type,
+			Series:       requestBody.Series,
+			Stages:       requestBody.Stages,
+		}, requestBody.Ids)
+		if err != nil {
+			status := http.StatusInternalServerError
+			switch {
+			case errors.Is(err, voicememos.ErrNotFound):
+				status = http.StatusNotFound
+			case errors.Is(err, transcriber.ErrUnknownPreset) || errors.Is(err, transcriber.ErrInvalidStages) || errors.Is(err, prompts.ErrNotFound):
+				status = http.StatusBadRequest
+			case errors.Is(err, transcriber.ErrShuttingDown):
+				status = http.StatusServiceUnavailable
+			}
+			s.logger.Error("Failed to import voice memos", "error", err, "queued", len(meetingIds))
+			s.respondWithJSON(w, status, map[string]interface{}{
+				"error":       err.Error(),
+				"meeting_ids": meetingIds, // Queued before the failure
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+			"meeting_ids": meetingIds,
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// handleMeetingWaveform returns a handler that returns the waveform of a meeting's recording
+func (s *Server) handleMeetingWaveform() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		meetingId := r.PathValue("id")
+		if _, err := s.transcriber.GetMeetingStatus(meetingId); err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		waveform, err := s.transcriber.MeetingWaveform(meetingId)
+		if errors.Is(err, transcriber.ErrNoWaveform) {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		if err != nil {
+			s.logger.Error("Failed to read waveform", "error", err, "meetingId", meetingId)
+			s.respondWithJSON(w, http.StatusInternalServerError, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+		// The waveform doesn't change once computed
+		w.Header().Set("Cache-Control", "private, max-age=86400")
+		s.respondWithJSON(w, http.StatusOK, waveform)
+	}
+}
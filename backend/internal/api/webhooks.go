@@ -0,0 +1,77 @@
+package api
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// maxWebhookBodySize limits transcript callback payloads
+const maxWebhookBodySize = 50 << 20
+
+// handleTranscriptWebhook returns a handler that accepts completed transcripts from
+// external transcription services for a meeting
+func (s *Server) handleTranscriptWebhook() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		secret := s.config.Transcription.WebhookSecret
+		if secret == "" {
+			s.respondWithJSON(w, http.StatusServiceUnavailable, map[string]string{
+				"error": "Webhook inbox disabled: no webhook secret configured",
+			})
+			return
+		}
+
+		// Services that can't set headers pass the secret in the callback URL instead
+		provided := r.Header.Get("X-Webhook-Secret")
+		if provided == "" {
+			provided = r.URL.Query().Get("token")
+		}
+		if subtle.ConstantTimeCompare([]byte(provided), []byte(secret)) != 1 {
+			s.respondWithJSON(w, http.StatusUnauthorized, map[string]string{
+				"error": "Invalid webhook secret",
+			})
+			return
+		}
+
+		meetingId := r.PathValue("id")
+		meeting, err := s.transcriber.GetMeetingStatus(meetingId)
+		if err != nil {
+			s.respondWithJSON(w, http.StatusNotFound, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		payload, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodySize))
+		if err != nil {
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": "Invalid request body",
+			})
+			return
+		}
+
+		segments, err := s.transcriber.ParseExternalTranscript(r.Context(), payload)
+		if err != nil {
+			s.logger.Error("Failed to parse external transcript", "error", err, "meetingId", meetingId)
+			s.respondWithJSON(w, http.StatusBadRequest, map[string]string{
+				"error": fmt.Sprintf("Failed to parse transcript: %v", err),
+			})
+			return
+		}
+
+		if err := s.transcriber.AcceptExternalTranscript(meetingId, segments); err != nil {
+			s.logger.Error("Failed to accept external transcript", "error", err, "meetingId", meetingId)
+			s.respondWithJSON(w, http.StatusConflict, map[string]string{
+				"error": err.Error(),
+			})
+			return
+		}
+
+		s.respondWithJSON(w, http.StatusAccepted, map[string]interface{}{
+			"message":  "Transcript accepted, summarization queued",
+			"segments": len(segments),
+			"metadata": meeting.Metadata,
+		})
+	}
+}
@@ -0,0 +1,167 @@
+// Package artifacts owns the working directories of the pipeline, such as whisper output and
+// downloads. Every meeting gets a directory holding a directory per stage; a stage directory
+// lives from Create until Release, files that must outlive it are moved out with Promote, and
+// GC removes what crashed runs left behind without touching directories still in use.
+package artifacts
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// sharedOwner holds the working directories of work that doesn't belong to a meeting
+const sharedOwner = "shared"
+
+// Manager hands out the working directories under its root
+type Manager struct {
+	root  string
+	mu    sync.Mutex
+	inUse map[string]int // Stage directories created and not yet released, per owner
+}
+
+// New returns a manager of the working directories under root, creating it
+func New(root string) (*Manager, error) {
+	// Release compares the parent of directories with the root, so it must be clean
+	root = filepath.Clean(root)
+	if err := os.MkdirAll(root, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create artifacts directory: %w", err)
+	}
+	return &Manager{root: root, inUse: make(map[string]int)}, nil
+}
+
+// Create returns a new, empty directory for a stage of the meeting's work, e.g. "whisper".
+// An empty owner is used for work that doesn't belong to a meeting. The directory is kept
+// until it is released, however long the stage runs.
+func (m *Manager) Create(owner string, stage string) (string, error) {
+	if owner == "" {
+		owner = sharedOwner
+	}
+	if filepath.Base(owner) != owner || owner == "." || owner == ".." {
+		return "", fmt.Errorf("invalid artifacts owner %q", owner)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ownerDir := filepath.Join(m.root, owner)
+	if err := os.MkdirAll(ownerDir, 0700); err != nil {
+		return "", err
+	}
+	dir, err := os.MkdirTemp(ownerDir, stage+"-")
+	if err != nil {
+		return "", err
+	}
+	m.inUse[owner]++
+	return dir, nil
+}
+
+// Release removes a stage directory with its files, and the meeting's directory once it has
+// no stages left
+func (m *Manager) Release(dir string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ownerDir := filepath.Dir(dir)
+	owner := filepath.Base(ownerDir)
+	if filepath.Dir(ownerDir) != m.root {
+		return fmt.Errorf("%s is not an artifacts directory", dir)
+	}
+	if m.inUse[owner] > 0 {
+		m.inUse[owner]--
+	}
+	if m.inUse[owner] == 0 {
+		delete(m.inUse, owner)
+	}
+	if err := os.RemoveAll(dir); err != nil {
+		return err
+	}
+	os.Remove(ownerDir) // Only succeeds once it is empty
+	return nil
+}
+
+// Remove removes all working directories of a meeting, e.g. when it is purged. Stages still
+// running keep their directories.
+func (m *Manager) Remove(owner string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if owner == "" || m.inUse[owner] > 0 {
+		return nil
+	}
+	return os.RemoveAll(filepath.Join(m.root, owner))
+}
+
+// GC removes the working directories of meetings without running stages that haven't
+// changed for maxAge, as left behind by crashes, and returns the removed paths. With a
+// maxAge of 0, on startup, everything not in use is removed.
+func (m *Manager) GC(maxAge time.Duration) ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entries, err := os.ReadDir(m.root)
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, entry := range entries {
+		if m.inUse[entry.Name()] > 0 {
+			continue
+		}
+		dir := filepath.Join(m.root, entry.Name())
+		if maxAge > 0 && time.Since(lastModified(dir)) < maxAge {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+		removed = append(removed, dir)
+	}
+	return removed, nil
+}
+
+// lastModified returns the latest modification time of a directory and everything in it
+func lastModified(dir string) time.Time {
+	var latest time.Time
+	filepath.WalkDir(dir, func(path string, entry os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if info, err := entry.Info(); err == nil && info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+		return nil
+	})
+	return latest
+}
+
+// Promote moves a file to its permanent location, e.g. a download into the recordings
+// directory, so it survives the release of its stage directory. Files are copied when the
+// locations are on different file systems.
+func Promote(path string, dest string) error {
+	if err := os.Rename(path, dest); err == nil {
+		return nil
+	}
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dest)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		os.Remove(dest)
+		return err
+	}
+	if err := out.Close(); err != nil {
+		os.Remove(dest)
+		return err
+	}
+	in.Close()
+	return os.Remove(path)
+}
@@ -0,0 +1,61 @@
+package audiocapture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// tapExitTimeout is how long the system track waits for the tap helper to report its exit
+// after ffmpeg reached the end of its stream
+const tapExitTimeout = time.Second
+
+// AppTap records the audio of a single app instead of the system output device. A helper
+// taps the processes of the app, through Core Audio process taps or ScreenCaptureKit, and
+// streams their audio as raw PCM to stdout, which ffmpeg records as the system track.
+type AppTap struct {
+	App        string   // Name of the app, for logs and the meeting's devices
+	Command    []string // Helper and its arguments
+	Format     string   // ffmpeg raw sample format of the stream, e.g. s16le or f32le
+	SampleRate int
+	Channels   int
+}
+
+// inputArgs returns the ffmpeg input options reading the helper's stream from stdin
+func (tap *AppTap) inputArgs() []string {
+	return []string{
+		"-f", tap.Format,
+		"-ar", fmt.Sprintf("%d", tap.SampleRate),
+		"-ac", fmt.Sprintf("%d", tap.Channels),
+		"-i", "pipe:0",
+	}
+}
+
+// start starts the helper with its stdout connected to the stdin of ffmpeg, before ffmpeg is
+// started. The helper is interrupted when the context is done.
+func (tap *AppTap) start(ctx context.Context, ffmpeg *exec.Cmd) (*exec.Cmd, error) {
+	if len(tap.Command) == 0 {
+		return nil, fmt.Errorf("no app tap helper configured")
+	}
+	cmd := exec.CommandContext(ctx, tap.Command[0], tap.Command[1:]...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = ffmpegWaitDelay
+	cmd.Stderr = ffmpeg.Stderr
+
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	ffmpeg.Stdin = stdout
+	if err := procs.Start(cmd); err != nil {
+		return nil, fmt.Errorf("failed to start app tap helper %s: %w", filepath.Base(tap.Command[0]), err)
+	}
+	return cmd, nil
+}
@@ -0,0 +1,94 @@
+package audiocapture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// Begin marks the start of the recording of an armed capture, one started ahead of the
+// recording to skip ffmpeg's startup latency. The audio captured so far is cut from the
+// recording except for the last preRoll. The offsets are measured per track, as the
+// tracks start capturing at different moments.
+func (ca *CombinedAudio) Begin(preRoll time.Duration) error {
+	micCaptured, err := TrackDuration(ca.inputAudio.outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to measure mic track: %w", err)
+	}
+	systemCaptured, err := TrackDuration(ca.outputAudio.outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to measure system track: %w", err)
+	}
+	ca.micOffset = max(0, micCaptured-preRoll)
+	ca.systemOffset = max(0, systemCaptured-preRoll)
+	for i, extra := range ca.extraAudio {
+		captured, err := TrackDuration(extra.outputPath)
+		if err != nil {
+			return fmt.Errorf("failed to measure %s track: %w", ExtraTrackSource(i), err)
+		}
+		ca.extraOffsets[i] = max(0, captured-preRoll)
+	}
+	return nil
+}
+
+// Cancel stops an armed capture and removes its tracks without mixing them
+func (ca *CombinedAudio) Cancel() error {
+	ca.cancelled = true
+	return ca.Stop()
+}
+
+// SetOutputPath changes where the tracks are mixed to, for armed captures started before
+// the recording was named
+func (ca *CombinedAudio) SetOutputPath(outputPath string) {
+	ca.outputPath = outputPath
+}
+
+// TrackDuration returns the length of the audio in a PCM WAV file, which may still be written,
+// or of the segments of a track recorded in segments
+func TrackDuration(path string) (time.Duration, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) && len(trackSegments(path)) > 0 {
+		return segmentedDuration(path)
+	}
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	format, dataOffset, err := readWavHeader(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+	seconds := float64(info.Size()-dataOffset) / float64(format.byteRate)
+	return time.Duration(seconds * float64(time.Second)), nil
+}
+
+// seekArgs returns the ffmpeg input option skipping the offset, if any
+func seekArgs(offset time.Duration) []string {
+	if offset <= 0 {
+		return nil
+	}
+	return []string{"-ss", fmt.Sprintf("%.3f", offset.Seconds())}
+}
+
+// trimTrack cuts the offset from the start of a track in place
+func trimTrack(ctx context.Context, path string, offset time.Duration) error {
+	if offset <= 0 {
+		return nil
+	}
+	trimmed := strings.TrimSuffix(path, filepath.Ext(path)) + "_trimmed" + filepath.Ext(path)
+	args := append(seekArgs(offset), "-i", path, "-c", "copy", "-y", trimmed)
+	if output, err := procs.CombinedOutput(ffmpegCommand(ctx, args...)); err != nil {
+		return fmt.Errorf("ffmpeg trim failed: %w\nOutput: %s", err, string(output))
+	}
+	return os.Rename(trimmed, path)
+}
@@ -0,0 +1,43 @@
+package audiocapture
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ExtractClips cuts the clips out of a recording and joins them, in order, into one file
+// encoded with the codec at its default bitrate
+func ExtractClips(ctx context.Context, inputPath string, outputPath string, name string, clips []types.Highlight) error {
+	c, ok := codecs[name]
+	if !ok {
+		return fmt.Errorf("unknown codec %q, use %s, %s or %s", name, CodecOpus, CodecAAC, CodecFLAC)
+	}
+	if len(clips) == 0 {
+		return fmt.Errorf("no clips to extract")
+	}
+
+	var filter strings.Builder
+	for i, clip := range clips {
+		fmt.Fprintf(&filter, "[0:a]atrim=start=%.3f:end=%.3f,asetpts=PTS-STARTPTS[c%d];", clip.Start, clip.End, i)
+	}
+	for i := range clips {
+		fmt.Fprintf(&filter, "[c%d]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1[out]", len(clips))
+
+	args := []string{"-y", "-i", inputPath, "-filter_complex", filter.String(), "-map", "[out]", "-c:a", c.encoder}
+	if c.defaultBitrate != "" {
+		args = append(args, "-b:a", c.defaultBitrate)
+	}
+	args = append(args, outputPath)
+
+	cmd := ffmpegCommand(ctx, args...)
+	if output, err := procs.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("ffmpeg failed to extract clips: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
@@ -1,71 +1,198 @@
 package audiocapture
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"regexp"
 	"strings"
 	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Devices captured when none are selected
+const (
+	DefaultMicDevice    = "2"
+	DefaultSystemDevice = "1"
 )
 
+// CaptureDevices selects the avfoundation devices to record, by index or name
+type CaptureDevices struct {
+	Mic        string
+	System     string
+	SystemApp  *AppTap       // Records the audio of an app as the system track instead of the System device
+	MicGain    float64       // Gain of the mic track in the mix, 0 leaves it as recorded
+	SystemGain float64       // Gain of the system track in the mix, 0 leaves it as recorded
+	Extra      []ExtraDevice // More input devices, e.g. a USB interface, each recorded as its own track
+}
+
+// ExtraDevice is an input device recorded next to the mic and system audio
+type ExtraDevice struct {
+	Device string
+	Gain   float64 // Gain of the track in the mix, 0 leaves it as recorded
+}
+
+// MaxExtraDevices is how many extra devices a capture records at most
+const MaxExtraDevices = 6
+
+// ExtraTrackSource returns the source of the track of the extra device at the index: input1,
+// input2 and so on
+func ExtraTrackSource(index int) string {
+	return fmt.Sprintf("input%d", index+1)
+}
+
+// extraTrackPath returns where the track of the extra device at the index is recorded
+func extraTrackPath(basePath string, index int) string {
+	return basePath + "_" + ExtraTrackSource(index) + ".wav"
+}
+
+// trackGain returns the gain a track is mixed with
+func trackGain(gain float64) float64 {
+	if gain == 0 {
+		return 1
+	}
+	return gain
+}
+
+// EstimateRecordingSize returns the disk space a recording of the duration in the format
+// needs: the mic and system tracks are held twice while their segments are joined, which
+// outweighs the mix written afterwards
+func EstimateRecordingSize(duration time.Duration, format AudioFormat) uint64 {
+	return uint64(duration.Seconds() * float64(2*2*format.BytesPerSecond()))
+}
+
 type CombinedAudio struct {
 	inputAudio  *InputAudio
 	outputAudio *OutputAudio
+	extraAudio  []*InputAudio // Tracks of the extra devices, in order
+	micParts    []micPart     // Parts of the mic track recorded after switching the mic
+	started     time.Time     // When the capture started, to place the parts of the mic track
+	gains       []float64     // Mix gain of each track: mic, system, then the extra devices
 	duration    int
 	stopChan    chan struct{}
 	outputPath  string
+	mixOptions  MixOptions
+
+	// Set on armed captures: the audio captured before the recording began is cut from the
+	// start of the tracks, and cancelled captures are discarded instead of mixed
+	micOffset    time.Duration
+	systemOffset time.Duration
+	extraOffsets []time.Duration
+	cancelled    bool
+
+	done      chan struct{} // Closed once the tracks of a started capture are mixed or discarded
+	err       error         // Why the stopped capture couldn't be mixed, set before done is closed
+	trackErrs []error       // Problems with the tracks the capture was mixed despite, set before done is closed
 }
 
-func NewCombinedAudio(outputPath string) *CombinedAudio {
+func NewCombinedAudio(outputPath string, devices CaptureDevices, mixOptions MixOptions) *CombinedAudio {
+	// Record the individual tracks next to the mixed output file
+	basePath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
 	inputOptions := InputOptions{
-		OutputPath: "input.wav",
-		Duration:   0,
+		OutputPath:  basePath + "_mic.wav",
+		Duration:    0,
+		Device:      devices.Mic,
+		SegmentTime: mixOptions.SegmentTime,
+		Volume:      mixOptions.InputVolume,
+		Format:      mixOptions.Format,
 	}
 	outputOptions := OutputAudioOptions{
-		OutputPath: "output.wav",
-		Duration:   0,
+		OutputPath:  basePath + "_system.wav",
+		Duration:    0,
+		Device:      devices.System,
+		SegmentTime: mixOptions.SegmentTime,
+		App:         devices.SystemApp,
+		Format:      mixOptions.Format,
+	}
+
+	gains := []float64{trackGain(devices.MicGain), trackGain(devices.SystemGain)}
+	var extraAudio []*InputAudio
+	for i, extra := range devices.Extra {
+		extraAudio = append(extraAudio, NewInputAudio(InputOptions{
+			OutputPath:  extraTrackPath(basePath, i),
+			Device:      extra.Device,
+			SegmentTime: mixOptions.SegmentTime,
+			Volume:      mixOptions.InputVolume,
+			Format:      mixOptions.Format,
+		}))
+		gains = append(gains, trackGain(extra.Gain))
 	}
 
 	InputAudio := NewInputAudio(inputOptions)
 	OutputAudio := NewOutputAudio(outputOptions)
 
 	return &CombinedAudio{
-		inputAudio:  InputAudio,
-		outputAudio: OutputAudio,
-		duration:    0,
-		stopChan:    make(chan struct{}),
-		outputPath:  outputPath,
+		inputAudio:   InputAudio,
+		outputAudio:  OutputAudio,
+		extraAudio:   extraAudio,
+		gains:        gains,
+		extraOffsets: make([]time.Duration, len(extraAudio)),
+		duration:     0,
+		stopChan:     make(chan struct{}),
+		outputPath:   outputPath,
+		mixOptions:   mixOptions,
 	}
 }
 
-// ListAudioDevices lists available audio devices
+// captureTrack is a track of the capture and how it goes into the mix
+type captureTrack struct {
+	source string
+	path   string
+	err    error         // Why the ffmpeg recording the track exited, if it failed
+	offset time.Duration // Audio cut from the start of the track
+	gain   float64
+}
+
+// tracks returns the tracks of the capture in mix order: mic, system, then the extra devices
+func (ca *CombinedAudio) tracks() []captureTrack {
+	tracks := []captureTrack{
+		{types.TrackSourceMic, ca.inputAudio.outputPath, ca.mic().Err(), ca.micOffset, ca.gains[0]},
+		{types.TrackSourceSystem, ca.outputAudio.outputPath, ca.outputAudio.Err(), ca.systemOffset, ca.gains[1]},
+	}
+	for i, extra := range ca.extraAudio {
+		tracks = append(tracks, captureTrack{ExtraTrackSource(i), extra.outputPath, extra.Err(), ca.extraOffsets[i], ca.gains[i+2]})
+	}
+	return tracks
+}
+
+// deviceLine matches a device ffmpeg lists, e.g. "[AVFoundation indev @ 0x7f8] [1] MacBook Pro Microphone"
+var deviceLine = regexp.MustCompile(`^\[AVFoundation[^\]]*\] \[(\d+)\] (.+)$`)
+
+// ListAudioDevices lists the avfoundation audio input devices by name, in the order of
+// their indices
 func ListAudioDevices() ([]string, error) {
 	cmd := exec.Command("ffmpeg", "-f", "avfoundation", "-list_devices", "true", "-i", "dummy")
+	// ffmpeg exits with an error after listing, as "dummy" is no input
 	output, err := cmd.CombinedOutput()
-	if err != nil {
-		return nil, fmt.Errorf("failed to list audio devices: %w", err)
-	}
-	lines := strings.Split(string(output), "\n")
 	var devices []string
-	for _, line := range lines {
+	audio := false
+	for _, line := range strings.Split(string(output), "\n") {
 		line = strings.TrimSpace(line)
-		if strings.HasPrefix(line, "[AVFoundation]") && strings.Contains(line, "audio") {
-			// Extract device name from the line
-			// Assuming the format is like "[AVFoundation input device 'Built-in Microphone']"
-			start := strings.Index(line, "'")
-			end := strings.LastIndex(line, "'")
-			if start != -1 && end != -1 && start < end {
-				deviceName := line[start+1 : end]
-				devices = append(devices, deviceName)
+		switch {
+		case strings.Contains(line, "AVFoundation audio devices"):
+			audio = true
+		case strings.Contains(line, "AVFoundation video devices"):
+			audio = false
+		case audio:
+			if match := deviceLine.FindStringSubmatch(line); match != nil {
+				devices = append(devices, match[2])
 			}
 		}
 	}
+	if len(devices) == 0 && err != nil {
+		return nil, fmt.Errorf("failed to list audio devices: %w", err)
+	}
 	return devices, nil
 }
 
-// Start begins the combined audio capture process
-func (ca *CombinedAudio) Start() error {
-	if ca.inputAudio.isRecording || ca.outputAudio.isRecording {
+// Start begins the combined audio capture process. The capture stops, and the tracks are no
+// longer mixed, when the context is done.
+func (ca *CombinedAudio) Start(ctx context.Context) error {
+	if ca.IsRecording() {
 		return fmt.Errorf("recording already in progress")
 	}
 
@@ -83,19 +210,33 @@ func (ca *CombinedAudio) Start() error {
 	outputDone := make(chan error, 1)
 
 	// Start mic recording
+	ca.started = time.Now()
 	go func() {
-		err := ca.inputAudio.Start()
+		err := ca.inputAudio.Start(ctx)
 		micDone <- err
 	}()
 
 	// Start system audio recording
 	go func() {
-		err := ca.outputAudio.Start()
+		err := ca.outputAudio.Start(ctx)
 		outputDone <- err
 	}()
 
-	// Set up merge process to run after both recordings finish
-	go func() {
+	// Start the recordings of the extra devices
+	extraDone := make([]chan error, len(ca.extraAudio))
+	for i, extra := range ca.extraAudio {
+		extraDone[i] = make(chan error, 1)
+		go func(extra *InputAudio, done chan error) {
+			done <- extra.Start(ctx)
+		}(extra, extraDone[i])
+	}
+
+	// Set up merge process to run after both recordings finish. Stop replaces stopChan for
+	// the next recording, this one waits on the current channel.
+	ca.done = make(chan struct{})
+	go func(done, stop chan struct{}) {
+		defer close(done)
+
 		// Create wait channel for duration-based recording
 		var waitChan <-chan time.Time
 		if ca.duration > 0 {
@@ -103,59 +244,133 @@ func (ca *CombinedAudio) Start() error {
 			<-waitChan
 		} else {
 			// For manual stopping, wait for the stop signal
-			<-ca.stopChan
+			<-stop
 		}
 
-		// Wait for both recordings to complete
+		// Wait for all recordings to complete
 		err1 := <-micDone
 		err2 := <-outputDone
+		extraStarted := false
+		for _, done := range extraDone {
+			extraStarted = <-done == nil || extraStarted
+		}
 
 		// Check for errors
-		if err1 != nil && err2 != nil {
+		if err1 != nil && err2 != nil && !extraStarted {
 			fmt.Printf("Error recording audio: mic error: %v, output error: %v\n", err1, err2)
+			ca.err = fmt.Errorf("failed to start recording: mic: %v, system: %v", err1, err2)
 			return
 		}
 
-		// Now mix the two audio files together
-		mixArgs := []string{
-			"-i", ca.inputAudio.outputPath,
-			"-i", ca.outputAudio.outputPath,
-			"-filter_complex", "amix=inputs=2:duration=longest:dropout_transition=2", // Mix the audio streams
-			"-ac", "2", // Output stereo
-			"-ar", fmt.Sprintf("%d", ca.inputAudio.options.SampleRate),
-			"-c:a", "pcm_s16le", // Output as PCM
-			"-y", // Overwrite existing file
-			ca.outputPath,
+		if ca.cancelled {
+			for _, track := range ca.tracks() {
+				for _, path := range append([]string{track.path}, trackParts(track.path)...) {
+					os.Remove(path)
+					for _, segment := range trackSegments(path) {
+						os.Remove(segment)
+					}
+				}
+			}
+			return
 		}
 
-		fmt.Printf("Running audio mix command: ffmpeg %s\n", strings.Join(mixArgs, " "))
-
-		// Execute the mix command
-		mixCmd := exec.Command("ffmpeg", mixArgs...)
-		mixCmd.Stderr = os.Stderr
-		err := mixCmd.Run()
-
-		if err != nil {
+		// Let ffmpeg finish writing the tracks before they are joined and mixed
+		for i := 0; i < 50 && ca.IsRecording(); i++ {
+			time.Sleep(200 * time.Millisecond)
+		}
+		ca.trackErrs = ca.checkTracks()
+		if err := ca.mix(ctx); err != nil {
 			fmt.Printf("Error mixing audio: %v\n", err)
+			ca.err = fmt.Errorf("failed to mix tracks: %w", err)
 		} else {
-			// Clean up temp files if successful
-			os.Remove(ca.inputAudio.outputPath)
-			os.Remove(ca.outputAudio.outputPath)
 			fmt.Printf("Successfully mixed audio to %s\n", ca.outputPath)
 		}
-	}()
+	}(ca.done, ca.stopChan)
+
+	return nil
+}
 
+// checkTracks returns why the ffmpeg of each track exited with an error, and confirms the
+// tracks that stopped cleanly were finalized
+func (ca *CombinedAudio) checkTracks() []error {
+	var problems []error
+	for _, track := range ca.tracks() {
+		// Repair the header either way, a failed ffmpeg may have left audio behind
+		finalizeErr := finalizeTrack(track.path)
+		if track.err != nil {
+			problems = append(problems, &TrackError{Source: track.source, Err: track.err})
+		} else if finalizeErr != nil {
+			problems = append(problems, &TrackError{Source: track.source, Err: finalizeErr})
+		}
+	}
+	return problems
+}
+
+// mix joins the segments of the tracks, if recorded in segments, and mixes the tracks into
+// the output path
+func (ca *CombinedAudio) mix(ctx context.Context) error {
+	tracks := ca.tracks()
+	for _, track := range tracks {
+		if err := joinSegments(ctx, track.path); err != nil {
+			return fmt.Errorf("failed to join %s segments: %w", track.source, err)
+		}
+		var starts []time.Duration
+		if track.source == types.TrackSourceMic {
+			starts = ca.micPartStarts()
+		}
+		if err := joinParts(ctx, track.path, starts, ca.mixOptions.Format); err != nil {
+			return fmt.Errorf("failed to join %s parts: %w", track.source, err)
+		}
+	}
+
+	// Now mix the audio files together
+	var mixArgs []string
+	for _, track := range tracks {
+		mixArgs = append(mixArgs, seekArgs(track.offset)...)
+		mixArgs = append(mixArgs, "-i", track.path)
+	}
+	mixArgs = append(mixArgs,
+		"-filter_complex", buildTracksFilter(ca.mixOptions, ca.gains), // Mix the audio streams
+	)
+	mixArgs = append(mixArgs, ca.mixOptions.Format.outputArgs()...)
+	mixArgs = append(mixArgs,
+		"-y", // Overwrite existing file
+		ca.outputPath,
+	)
+
+	fmt.Printf("Running audio mix command: ffmpeg %s\n", strings.Join(mixArgs, " "))
+
+	// Execute the mix command
+	mixCmd := ffmpegCommand(ctx, mixArgs...)
+	mixCmd.Stderr = os.Stderr
+	if err := procs.Run(mixCmd); err != nil {
+		return err
+	}
+
+	// Clean up temp files if successful, unless the tracks are needed afterwards
+	if !ca.mixOptions.KeepTracks {
+		for _, track := range tracks {
+			os.Remove(track.path)
+		}
+		return nil
+	}
+	// Kept tracks must line up with the mix
+	for _, track := range tracks {
+		if err := trimTrack(ctx, track.path, track.offset); err != nil {
+			fmt.Printf("Error trimming %s track: %v\n", track.source, err)
+		}
+	}
 	return nil
 }
 
 // Stop stops the ongoing recording
 func (ca *CombinedAudio) Stop() error {
-	if !ca.inputAudio.isRecording && !ca.outputAudio.isRecording {
+	if !ca.IsRecording() {
 		return fmt.Errorf("no recording in progress")
 	}
 
 	// Stop input audio recording
-	if err := ca.inputAudio.Stop(); err != nil {
+	if err := ca.mic().Stop(); err != nil {
 		return fmt.Errorf("failed to stop input audio: %w", err)
 	}
 
@@ -164,6 +379,16 @@ func (ca *CombinedAudio) Stop() error {
 		return fmt.Errorf("failed to stop output audio: %w", err)
 	}
 
+	// Stop the recordings of the extra devices, those that failed already stopped
+	for i, extra := range ca.extraAudio {
+		if !extra.IsRecording() {
+			continue
+		}
+		if err := extra.Stop(); err != nil {
+			return fmt.Errorf("failed to stop %s audio: %w", ExtraTrackSource(i), err)
+		}
+	}
+
 	// Send stop signal
 	close(ca.stopChan)
 
@@ -173,12 +398,63 @@ func (ca *CombinedAudio) Stop() error {
 	return nil
 }
 
+// Wait waits up to the timeout for a stopped capture to be mixed and reports whether it was
+func (ca *CombinedAudio) Wait(timeout time.Duration) bool {
+	if ca.done == nil {
+		return true
+	}
+	select {
+	case <-ca.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// Err returns why a stopped capture couldn't be mixed, once Wait reported it was done
+func (ca *CombinedAudio) Err() error {
+	return ca.err
+}
+
+// TrackErrors returns the problems with the tracks: while recording, the tracks whose ffmpeg
+// exited with an error, and once Wait reported the capture was done, also the tracks whose
+// header had to be repaired. Each problem is a *TrackError.
+func (ca *CombinedAudio) TrackErrors() []error {
+	if ca.done != nil {
+		select {
+		case <-ca.done:
+			return ca.trackErrs
+		default:
+		}
+	}
+	var problems []error
+	for _, track := range ca.tracks() {
+		if track.err != nil {
+			problems = append(problems, &TrackError{Source: track.source, Err: track.err})
+		}
+	}
+	return problems
+}
+
 // GetOutputPath returns the path to the recorded file
 func (ca *CombinedAudio) GetOutputPath() string {
 	return ca.outputPath
 }
 
+// GetTracks returns the individual source tracks of the recording
+func (ca *CombinedAudio) GetTracks() []types.AudioTrack {
+	var tracks []types.AudioTrack
+	for _, track := range ca.tracks() {
+		tracks = append(tracks, types.AudioTrack{Source: track.source, Path: track.path})
+	}
+	return tracks
+}
+
 // IsRecording returns whether a recording is currently in progress
 func (ca *CombinedAudio) IsRecording() bool {
-	return ca.inputAudio.IsRecording() || ca.outputAudio.IsRecording()
+	recording := ca.mic().IsRecording() || ca.outputAudio.IsRecording()
+	for _, extra := range ca.extraAudio {
+		recording = recording || extra.IsRecording()
+	}
+	return recording
 }
@@ -0,0 +1,61 @@
+package audiocapture
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// Codecs recordings can be compressed to
+const (
+	CodecOpus = "opus"
+	CodecAAC  = "aac"
+	CodecFLAC = "flac"
+)
+
+// codec describes how ffmpeg encodes a codec and the extension of the resulting file
+type codec struct {
+	encoder        string
+	extension      string
+	defaultBitrate string // Empty for lossless codecs
+}
+
+var codecs = map[string]codec{
+	CodecOpus: {encoder: "libopus", extension: ".ogg", defaultBitrate: "32k"},
+	CodecAAC:  {encoder: "aac", extension: ".m4a", defaultBitrate: "64k"},
+	CodecFLAC: {encoder: "flac", extension: ".flac"},
+}
+
+// CodecExtension returns the file extension of a codec
+func CodecExtension(name string) (string, error) {
+	c, ok := codecs[name]
+	if !ok {
+		return "", fmt.Errorf("unknown codec %q, use %s, %s or %s", name, CodecOpus, CodecAAC, CodecFLAC)
+	}
+	return c.extension, nil
+}
+
+// Compress encodes a recording with the codec at the bitrate, using the codec's default
+// bitrate when it is empty. The bitrate is ignored by lossless codecs.
+func Compress(ctx context.Context, inputPath string, outputPath string, name string, bitrate string) error {
+	c, ok := codecs[name]
+	if !ok {
+		return fmt.Errorf("unknown codec %q, use %s, %s or %s", name, CodecOpus, CodecAAC, CodecFLAC)
+	}
+
+	args := []string{"-y", "-i", inputPath, "-c:a", c.encoder}
+	if c.defaultBitrate != "" {
+		if bitrate == "" {
+			bitrate = c.defaultBitrate
+		}
+		args = append(args, "-b:a", bitrate)
+	}
+	args = append(args, outputPath)
+
+	cmd := ffmpegCommand(ctx, args...)
+	if output, err := procs.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("ffmpeg failed to compress recording to %s: %w\nOutput: %s", name, err, string(output))
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+package audiocapture
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// Concat joins recordings, in order, into one WAV file. The recordings may differ in format,
+// e.g. a WAV recording and a compressed archive.
+func Concat(ctx context.Context, inputPaths []string, outputPath string) error {
+	if len(inputPaths) < 2 {
+		return fmt.Errorf("at least two recordings are needed to concatenate, got %d", len(inputPaths))
+	}
+
+	args := []string{"-y"}
+	var filter strings.Builder
+	for i, path := range inputPaths {
+		args = append(args, "-i", path)
+		fmt.Fprintf(&filter, "[%d:a]", i)
+	}
+	fmt.Fprintf(&filter, "concat=n=%d:v=0:a=1[out]", len(inputPaths))
+	args = append(args, "-filter_complex", filter.String(), "-map", "[out]", "-c:a", "pcm_s16le", outputPath)
+
+	cmd := ffmpegCommand(ctx, args...)
+	if output, err := procs.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("ffmpeg failed to concatenate recordings: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
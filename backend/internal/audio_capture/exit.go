@@ -0,0 +1,212 @@
+package audiocapture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Causes of a failed capture, matched with errors.Is on the error of a track
+var (
+	ErrDeviceBusy       = errors.New("audio device is busy")
+	ErrDeviceNotFound   = errors.New("audio device not found")
+	ErrPermissionDenied = errors.New("permission to record was denied")
+	ErrDiskFull         = errors.New("disk is full")
+	ErrNotFinalized     = errors.New("WAV header was not finalized")
+)
+
+// exitCauses map what ffmpeg writes to stderr to the cause of its exit, in order of precedence
+var exitCauses = []struct {
+	pattern *regexp.Regexp
+	cause   error
+}{
+	{regexp.MustCompile(`(?i)no space left on device|disk quota exceeded`), ErrDiskFull},
+	{regexp.MustCompile(`(?i)permission denied|not authori[sz]ed|operation not permitted`), ErrPermissionDenied},
+	{regexp.MustCompile(`(?i)device or resource busy|device is busy|already in use`), ErrDeviceBusy},
+	{regexp.MustCompile(`(?i)invalid device index|device not found|no such device|could not find audio (only )?device`), ErrDeviceNotFound},
+}
+
+// ffmpegStoppedNormally is what ffmpeg writes when it stopped on a signal after finalizing
+// its output; it still exits with status 255
+const ffmpegStoppedNormally = "Exiting normally, received signal"
+
+// stderrTail is how much of ffmpeg's stderr is kept to classify its exit
+const stderrTail = 8 * 1024
+
+// ExitError is a capture process, ffmpeg or an app tap helper, that exited with an error
+type ExitError struct {
+	Process string // Name of the process
+	Cause   error  // One of the causes above, nil when it isn't recognized
+	Err     error  // The exit error of the process
+	Output  string // The end of what the process wrote to stderr
+}
+
+func (e *ExitError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%v (%s %v)", e.Cause, e.Process, e.Err)
+	}
+	if line := lastLine(e.Output); line != "" {
+		return fmt.Sprintf("%s %v: %s", e.Process, e.Err, line)
+	}
+	return fmt.Sprintf("%s %v", e.Process, e.Err)
+}
+
+func (e *ExitError) Unwrap() []error {
+	if e.Cause == nil {
+		return []error{e.Err}
+	}
+	return []error{e.Cause, e.Err}
+}
+
+// exitError classifies how a capture process exited by its stderr. It returns nil when it
+// stopped cleanly, which includes ffmpeg stopping on an interrupt.
+func exitError(process string, err error, stderr string) error {
+	if err == nil || strings.Contains(stderr, ffmpegStoppedNormally) {
+		return nil
+	}
+	exitErr := &ExitError{Process: process, Err: err, Output: stderr}
+	for _, c := range exitCauses {
+		if c.pattern.MatchString(stderr) {
+			exitErr.Cause = c.cause
+			break
+		}
+	}
+	return exitErr
+}
+
+// lastLine returns the last non-empty line of the output
+func lastLine(output string) string {
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	return strings.TrimSpace(lines[len(lines)-1])
+}
+
+// tailBuffer keeps the last bytes written to it
+type tailBuffer struct {
+	mu   sync.Mutex
+	data []byte
+}
+
+func (b *tailBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.data = append(b.data, p...)
+	if len(b.data) > stderrTail {
+		b.data = b.data[len(b.data)-stderrTail:]
+	}
+	return len(p), nil
+}
+
+func (b *tailBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.data)
+}
+
+// TrackError is a problem with one of the tracks of a capture
+type TrackError struct {
+	Source string // types.TrackSourceMic or types.TrackSourceSystem
+	Err    error
+}
+
+func (e *TrackError) Error() string {
+	return fmt.Sprintf("%s track: %v", e.Source, e.Err)
+}
+
+func (e *TrackError) Unwrap() error {
+	return e.Err
+}
+
+// finalizeTrack checks that ffmpeg finalized the WAV header of a track, or of each of its
+// segments. ffmpeg writes the sizes in the header when it closes the file, so a header that
+// doesn't match the file means ffmpeg didn't get to close it; such headers are repaired so
+// the audio can still be read. The returned error wraps ErrNotFinalized.
+func finalizeTrack(path string) error {
+	files := trackSegments(path)
+	if _, err := os.Stat(path); err == nil {
+		files = append(files, path)
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("%s was not written", filepath.Base(path))
+	}
+	for _, file := range files {
+		finalized, err := wavFinalized(file)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrNotFinalized, err)
+		}
+		if finalized {
+			continue
+		}
+		if err := repairWavHeader(file); err != nil {
+			return fmt.Errorf("%w in %s and repairing it failed: %v", ErrNotFinalized, filepath.Base(file), err)
+		}
+		return fmt.Errorf("%w in %s, the header was repaired", ErrNotFinalized, filepath.Base(file))
+	}
+	return nil
+}
+
+// wavFinalized reports whether the RIFF and data sizes in the header of a WAV file match
+// the size of the file
+func wavFinalized(path string) (bool, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	_, dataOffset, err := readWavHeader(file)
+	if err != nil {
+		return false, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return false, err
+	}
+	sizes := make([]byte, 4)
+	if _, err := file.ReadAt(sizes, 4); err != nil {
+		return false, err
+	}
+	riffSize := int64(binary.LittleEndian.Uint32(sizes))
+	if _, err := file.ReadAt(sizes, dataOffset-4); err != nil {
+		return false, err
+	}
+	dataSize := int64(binary.LittleEndian.Uint32(sizes))
+
+	fileSize := info.Size()
+	return riffSize == fileSize-8 && (dataSize == fileSize-dataOffset || dataSize == fileSize-dataOffset-1), nil
+}
+
+// repairWavHeader writes the RIFF and data sizes of a WAV file ffmpeg didn't finalize
+func repairWavHeader(path string) error {
+	file, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	format, dataOffset, err := readWavHeader(file)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return err
+	}
+	block := int64(format.blockAlign)
+	dataSize := (info.Size() - dataOffset) / block * block
+
+	size := make([]byte, 4)
+	binary.LittleEndian.PutUint32(size, uint32(info.Size()-8))
+	if _, err := file.WriteAt(size, 4); err != nil {
+		return err
+	}
+	binary.LittleEndian.PutUint32(size, uint32(dataSize))
+	if _, err := file.WriteAt(size, dataOffset-4); err != nil {
+		return err
+	}
+	return file.Sync()
+}
@@ -0,0 +1,23 @@
+package audiocapture
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// ffmpegWaitDelay is how long ffmpeg gets to finalize its output after the context is done,
+// before it is killed
+const ffmpegWaitDelay = 10 * time.Second
+
+// ffmpegCommand returns an ffmpeg command bound to the context. When the context is done
+// ffmpeg is interrupted rather than killed, so the file it writes stays readable.
+func ffmpegCommand(ctx context.Context, args ...string) *exec.Cmd {
+	cmd := exec.CommandContext(ctx, "ffmpeg", args...)
+	cmd.Cancel = func() error {
+		return cmd.Process.Signal(os.Interrupt)
+	}
+	cmd.WaitDelay = ffmpegWaitDelay
+	return cmd
+}
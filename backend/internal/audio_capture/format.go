@@ -0,0 +1,67 @@
+package audiocapture
+
+import (
+	"fmt"
+	"slices"
+)
+
+// AudioFormat is the sample format the tracks are recorded in and the recording is mixed to
+type AudioFormat struct {
+	SampleRate int // Samples per second
+	BitDepth   int // Bits per sample: 16, 24 or 32
+	Channels   int // 1 for mono, 2 for stereo
+}
+
+// DefaultFormat is used when no format is set: 48 kHz, the rate macOS runs most devices at,
+// so capturing doesn't resample, in 24-bit stereo
+var DefaultFormat = AudioFormat{SampleRate: 48000, BitDepth: 24, Channels: 2}
+
+// Sample rates and bit depths a format can have
+var (
+	sampleRates = []int{8000, 11025, 16000, 22050, 32000, 44100, 48000, 88200, 96000}
+	bitDepths   = []int{16, 24, 32}
+)
+
+// Validate checks that ffmpeg can record and mix in the format
+func (f AudioFormat) Validate() error {
+	if !slices.Contains(sampleRates, f.SampleRate) {
+		return fmt.Errorf("unsupported sample rate %d, use one of %v", f.SampleRate, sampleRates)
+	}
+	if !slices.Contains(bitDepths, f.BitDepth) {
+		return fmt.Errorf("unsupported bit depth %d, use one of %v", f.BitDepth, bitDepths)
+	}
+	if f.Channels != 1 && f.Channels != 2 {
+		return fmt.Errorf("unsupported channel count %d, use 1 or 2", f.Channels)
+	}
+	return nil
+}
+
+// orDefault returns the format with the fields that aren't set taken from DefaultFormat
+func (f AudioFormat) orDefault() AudioFormat {
+	if f.SampleRate <= 0 {
+		f.SampleRate = DefaultFormat.SampleRate
+	}
+	if f.BitDepth <= 0 {
+		f.BitDepth = DefaultFormat.BitDepth
+	}
+	if f.Channels <= 0 {
+		f.Channels = DefaultFormat.Channels
+	}
+	return f
+}
+
+// outputArgs returns the ffmpeg output options writing PCM WAV in the format
+func (f AudioFormat) outputArgs() []string {
+	f = f.orDefault()
+	return []string{
+		"-ac", fmt.Sprintf("%d", f.Channels),
+		"-ar", fmt.Sprintf("%d", f.SampleRate),
+		"-c:a", fmt.Sprintf("pcm_s%dle", f.BitDepth),
+	}
+}
+
+// BytesPerSecond returns how much PCM audio in the format takes per second
+func (f AudioFormat) BytesPerSecond() int {
+	f = f.orDefault()
+	return f.SampleRate * f.BitDepth / 8 * f.Channels
+}
@@ -0,0 +1,188 @@
+package audiocapture
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// micStopTimeout bounds how long switching the mic waits for the ffmpeg of the previous
+// device to finalize its part
+const micStopTimeout = 5 * time.Second
+
+// micPart is a part of the mic track recorded after the mic was switched to another device
+type micPart struct {
+	audio *InputAudio
+	start time.Duration // When the part started, since the capture started
+}
+
+// mic returns the recording of the device the mic track is currently recorded from
+func (ca *CombinedAudio) mic() *InputAudio {
+	if len(ca.micParts) > 0 {
+		return ca.micParts[len(ca.micParts)-1].audio
+	}
+	return ca.inputAudio
+}
+
+// SwitchMic continues the mic track on another device, e.g. when the device disappeared or
+// headphones became the default input. The device being recorded is stopped and the new
+// device is recorded to a new part of the track, which is joined to the earlier parts in
+// line with the other tracks when the capture is mixed.
+func (ca *CombinedAudio) SwitchMic(ctx context.Context, device string) error {
+	if !ca.IsRecording() || ca.started.IsZero() {
+		return fmt.Errorf("no recording in progress")
+	}
+
+	current := ca.mic()
+	if current.IsRecording() {
+		if err := current.Stop(); err != nil {
+			return fmt.Errorf("failed to stop mic: %w", err)
+		}
+		if current.exited != nil {
+			select {
+			case <-current.exited:
+			case <-time.After(micStopTimeout):
+			}
+		}
+	}
+
+	part := NewInputAudio(InputOptions{
+		OutputPath:  trackPartPath(ca.inputAudio.outputPath, len(ca.micParts)+2),
+		Device:      device,
+		SegmentTime: ca.mixOptions.SegmentTime,
+		Volume:      ca.mixOptions.InputVolume,
+		Format:      ca.mixOptions.Format,
+	})
+	start := time.Since(ca.started)
+	if err := part.Start(ctx); err != nil {
+		return fmt.Errorf("failed to record %s: %w", device, err)
+	}
+	ca.micParts = append(ca.micParts, micPart{audio: part, start: start})
+	return nil
+}
+
+// micPartStarts returns when each part of the mic track started
+func (ca *CombinedAudio) micPartStarts() []time.Duration {
+	var starts []time.Duration
+	for _, part := range ca.micParts {
+		starts = append(starts, part.start)
+	}
+	return starts
+}
+
+// trackPartPath returns the path of a part of a track: the track itself is part 1, the
+// parts after a device switch are numbered from 2
+func trackPartPath(path string, part int) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + fmt.Sprintf("_part%d", part) + filepath.Ext(path)
+}
+
+// trackParts returns the parts of a track recorded after a device switch, in order
+func trackParts(path string) []string {
+	var parts []string
+	for n := 2; trackExists(trackPartPath(path, n)); n++ {
+		parts = append(parts, trackPartPath(path, n))
+	}
+	return parts
+}
+
+// joinParts joins the parts a track was continued in after a device switch onto the track,
+// which must be joined from its segments already. Each part is placed at its start, cutting
+// or padding what was recorded before, so the track stays in line with the other tracks.
+// Without the starts, as for a recording interrupted by a crash, the parts are appended.
+func joinParts(ctx context.Context, path string, starts []time.Duration, format AudioFormat) error {
+	parts := trackParts(path)
+	if len(parts) == 0 {
+		return nil
+	}
+	for _, part := range parts {
+		if err := joinSegments(ctx, part); err != nil {
+			return err
+		}
+	}
+
+	inputs := append([]string{path}, parts...)
+	aligned := len(starts) == len(parts)
+	begin := func(i int) time.Duration {
+		if i == 0 {
+			return 0
+		}
+		return starts[i-1]
+	}
+
+	var args []string
+	var graph strings.Builder
+	for i, input := range inputs {
+		args = append(args, "-i", input)
+		if aligned && i < len(parts) {
+			seconds := max(0, begin(i+1)-begin(i)).Seconds()
+			fmt.Fprintf(&graph, "[%d:a]atrim=end=%.3f,apad=whole_dur=%.3f[p%d];", i, seconds, seconds, i)
+		} else {
+			fmt.Fprintf(&graph, "[%d:a]anull[p%d];", i, i)
+		}
+	}
+	for i := range inputs {
+		fmt.Fprintf(&graph, "[p%d]", i)
+	}
+	fmt.Fprintf(&graph, "concat=n=%d:v=0:a=1", len(inputs))
+
+	joined := strings.TrimSuffix(path, filepath.Ext(path)) + "_joined" + filepath.Ext(path)
+	args = append(args, "-filter_complex", graph.String())
+	args = append(args, format.outputArgs()...)
+	args = append(args, "-y", joined)
+	if output, err := procs.CombinedOutput(ffmpegCommand(ctx, args...)); err != nil {
+		return fmt.Errorf("ffmpeg failed to join %d parts: %w\nOutput: %s", len(inputs), err, string(output))
+	}
+	if err := os.Rename(joined, path); err != nil {
+		return err
+	}
+	for _, part := range parts {
+		os.Remove(part)
+	}
+	return nil
+}
+
+// TrackProgress returns the file a track is being written to and its size, to tell whether
+// ffmpeg still writes the track
+func TrackProgress(path string) (string, int64, error) {
+	live := liveTrack(path)
+	info, err := os.Stat(live)
+	if err != nil {
+		return "", 0, err
+	}
+	return live, info.Size(), nil
+}
+
+// DefaultInputDevice returns the name of the default input device of macOS, which changes
+// when e.g. AirPods connect
+func DefaultInputDevice() (string, error) {
+	output, err := exec.Command("system_profiler", "SPAudioDataType", "-json").Output()
+	if err != nil {
+		return "", fmt.Errorf("failed to list audio devices: %w", err)
+	}
+	var profile struct {
+		Audio []struct {
+			Items []struct {
+				Name         string `json:"_name"`
+				DefaultInput string `json:"coreaudio_default_audio_input_device"`
+			} `json:"_items"`
+		} `json:"SPAudioDataType"`
+	}
+	if err := json.Unmarshal(output, &profile); err != nil {
+		return "", fmt.Errorf("failed to parse audio devices: %w", err)
+	}
+	for _, audio := range profile.Audio {
+		for _, item := range audio.Items {
+			if item.DefaultInput == "spaudio_yes" {
+				return item.Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("no default input device")
+}
@@ -1,17 +1,24 @@
 package audiocapture
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
 )
 
 // InputOptions defines the options for audio capture
 type InputOptions struct {
-	OutputPath string // Where to save the WAV file (if empty, a default path will be used)
-	Duration   int    // Duration in seconds (0 means until Stop() is called)
-	SampleRate int    // Sample rate in Hz (default: 44100)
+	OutputPath  string      // Where to save the WAV file (if empty, a default path will be used)
+	Duration    int         // Duration in seconds (0 means until Stop() is called)
+	Format      AudioFormat // Sample format of the track (default: DefaultFormat)
+	Device      string      // avfoundation audio device index or name (default: 2)
+	SegmentTime int         // Seconds per segment file, 0 records a single file
+	Volume      float64     // Volume the device is captured at (default: 1.5)
 }
 
 // InputAudio manages audio capture operations
@@ -21,13 +28,20 @@ type InputAudio struct {
 	outputPath  string
 	isRecording bool
 	stopChan    chan struct{}
+	stderr      *tailBuffer   // End of ffmpeg's stderr, to tell why it exited
+	exited      chan struct{} // Closed once ffmpeg exited
+	err         error         // Why ffmpeg exited, nil when it stopped cleanly
 }
 
 // NewInputAudio creates a new audio capture instance
 func NewInputAudio(options InputOptions) *InputAudio {
 	// Set defaults if not provided
-	if options.SampleRate <= 0 {
-		options.SampleRate = 44100
+	options.Format = options.Format.orDefault()
+	if options.Device == "" {
+		options.Device = DefaultMicDevice
+	}
+	if options.Volume <= 0 {
+		options.Volume = defaultInputVolume
 	}
 
 	outputPath := options.OutputPath
@@ -39,8 +53,8 @@ func NewInputAudio(options InputOptions) *InputAudio {
 	}
 }
 
-// Start begins the audio capture process
-func (ac *InputAudio) Start() error {
+// Start begins the audio capture process. The capture stops when the context is done.
+func (ac *InputAudio) Start(ctx context.Context) error {
 	if ac.isRecording {
 		return fmt.Errorf("recording already in progress")
 	}
@@ -50,14 +64,13 @@ func (ac *InputAudio) Start() error {
 	// Construct ffmpeg command - always use microphone which will pick up system audio too
 	args = []string{
 		"-f", "avfoundation",
-		"-i", ":2", // Use MacBook Pro Microphone (index 2 from device list)
-		"-ac", "2", // Stereo audio
-		"-ar", "44100", // Standard sample rate
+		"-i", ":" + ac.options.Device, // The MacBook Pro Microphone is index 2 in the device list
 		// Simple audio enhancement filters
-		"-af", "volume=1.5",
+		"-af", fmt.Sprintf("volume=%g", ac.options.Volume),
 		"-y", // Overwrite output file if it exists
-		ac.outputPath,
 	}
+	args = append(args, ac.options.Format.outputArgs()...)
+	args = append(args, segmentArgs(ac.outputPath, ac.options.SegmentTime)...)
 
 	// Add duration limit if specified
 	if ac.options.Duration > 0 {
@@ -65,16 +78,18 @@ func (ac *InputAudio) Start() error {
 	}
 
 	// Create the command
-	ac.cmd = exec.Command("ffmpeg", args...)
+	ac.cmd = ffmpegCommand(ctx, args...)
 
 	// Print the command for debugging
 	fmt.Printf("Running command: ffmpeg %s\n", strings.Join(args, " "))
 
-	// Redirect stderr for debugging (ffmpeg outputs progress to stderr)
-	ac.cmd.Stderr = os.Stderr
+	// Redirect stderr for debugging (ffmpeg outputs progress to stderr), keeping its end
+	// to tell why ffmpeg exited
+	ac.stderr = &tailBuffer{}
+	ac.cmd.Stderr = io.MultiWriter(os.Stderr, ac.stderr)
 
 	// Start the ffmpeg process
-	err := ac.cmd.Start()
+	err := procs.Start(ac.cmd)
 	if err != nil {
 		return fmt.Errorf("failed to start ffmpeg: %w", err)
 	}
@@ -83,20 +98,23 @@ func (ac *InputAudio) Start() error {
 
 	// If no duration limit is set, we need to handle stopping manually
 	if ac.options.Duration <= 0 {
-		go func() {
-			<-ac.stopChan
+		go func(stop chan struct{}) {
+			<-stop
 			// Signal received to stop recording
 			if ac.cmd.Process != nil {
 				ac.cmd.Process.Signal(os.Interrupt)
 			}
-		}()
+		}(ac.stopChan) // Stop replaces stopChan for the next recording
 	}
 
 	// Wait for the command to complete in a goroutine
-	go func() {
-		ac.cmd.Wait()
+	ac.exited = make(chan struct{})
+	go func(exited chan struct{}) {
+		err := procs.Wait(ac.cmd)
+		ac.err = exitError("ffmpeg", err, ac.stderr.String())
 		ac.isRecording = false
-	}()
+		close(exited)
+	}(ac.exited)
 
 	return nil
 }
@@ -125,3 +143,14 @@ func (ac *InputAudio) GetOutputPath() string {
 func (ac *InputAudio) IsRecording() bool {
 	return ac.isRecording
 }
+
+// Err returns why ffmpeg exited, classified as an *ExitError. It is nil while recording and
+// when ffmpeg stopped cleanly.
+func (ac *InputAudio) Err() error {
+	select {
+	case <-ac.exited:
+		return ac.err
+	default:
+		return nil
+	}
+}
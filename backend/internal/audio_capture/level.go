@@ -0,0 +1,159 @@
+package audiocapture
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"time"
+)
+
+// wavFormat is the part of a WAV fmt chunk needed to read PCM samples
+type wavFormat struct {
+	audioFormat   uint16
+	byteRate      uint32
+	blockAlign    uint16
+	bitsPerSample uint16
+}
+
+// Audio formats of the WAV fmt chunk
+const (
+	wavFormatPCM        = 1
+	wavFormatExtensible = 0xFFFE
+)
+
+// TrackLevel returns the RMS level in dBFS of the last window of a PCM WAV file, or -Inf
+// when the window holds no signal. The file may still be written by ffmpeg, so the data
+// is read up to the end of the file instead of the size in the header. Tracks recorded in
+// segments are measured on the segment being written.
+func TrackLevel(path string, window time.Duration) (float64, error) {
+	file, err := os.Open(liveTrack(path))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	format, dataOffset, err := readWavHeader(file)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return 0, err
+	}
+
+	block := int64(format.blockAlign)
+	windowBytes := int64(window.Seconds()*float64(format.byteRate)) / block * block
+	start := max(dataOffset, info.Size()-windowBytes)
+	start = dataOffset + (start-dataOffset)/block*block
+	data := make([]byte, (info.Size()-start)/block*block)
+	if _, err := file.ReadAt(data, start); err != nil && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	bytesPerSample := int(format.bitsPerSample / 8)
+	fullScale := math.Pow(2, float64(format.bitsPerSample-1))
+	var sum float64
+	samples := 0
+	for i := 0; i+bytesPerSample <= len(data); i += bytesPerSample {
+		sample := float64(decodeSample(data[i:i+bytesPerSample])) / fullScale
+		sum += sample * sample
+		samples++
+	}
+	if samples == 0 || sum == 0 {
+		return math.Inf(-1), nil
+	}
+	return 10 * math.Log10(sum/float64(samples)), nil
+}
+
+// readWavHeader returns the format of a WAV file and the offset of its sample data
+func readWavHeader(file *os.File) (wavFormat, int64, error) {
+	var format wavFormat
+	riff := make([]byte, 12)
+	if _, err := io.ReadFull(file, riff); err != nil {
+		return format, 0, err
+	}
+	if string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return format, 0, fmt.Errorf("not a WAV file")
+	}
+
+	offset := int64(12)
+	header := make([]byte, 8)
+	for {
+		if _, err := file.ReadAt(header, offset); err != nil {
+			return format, 0, fmt.Errorf("no data chunk: %w", err)
+		}
+		id := string(header[0:4])
+		size := int64(binary.LittleEndian.Uint32(header[4:8]))
+		offset += 8
+
+		switch id {
+		case "fmt ":
+			chunk := make([]byte, 16)
+			if _, err := file.ReadAt(chunk, offset); err != nil {
+				return format, 0, err
+			}
+			format = wavFormat{
+				audioFormat:   binary.LittleEndian.Uint16(chunk[0:2]),
+				byteRate:      binary.LittleEndian.Uint32(chunk[8:12]),
+				blockAlign:    binary.LittleEndian.Uint16(chunk[12:14]),
+				bitsPerSample: binary.LittleEndian.Uint16(chunk[14:16]),
+			}
+		case "data":
+			if format.blockAlign == 0 {
+				return format, 0, fmt.Errorf("data chunk before fmt chunk")
+			}
+			if format.audioFormat != wavFormatPCM && format.audioFormat != wavFormatExtensible {
+				return format, 0, fmt.Errorf("unsupported audio format %d", format.audioFormat)
+			}
+			switch format.bitsPerSample {
+			case 16, 24, 32:
+			default:
+				return format, 0, fmt.Errorf("unsupported sample size of %d bits", format.bitsPerSample)
+			}
+			return format, offset, nil
+		}
+		offset += size + size%2 // Chunks are padded to an even size
+	}
+}
+
+// decodeSample decodes a little-endian signed integer sample of 2, 3 or 4 bytes
+func decodeSample(b []byte) int32 {
+	switch len(b) {
+	case 2:
+		return int32(int16(binary.LittleEndian.Uint16(b)))
+	case 3:
+		return int32(uint32(b[0])<<8|uint32(b[1])<<16|uint32(b[2])<<24) >> 8
+	default:
+		return int32(binary.LittleEndian.Uint32(b))
+	}
+}
+
+// WriteSilence writes a 16 kHz mono PCM WAV file of silence
+func WriteSilence(path string, duration time.Duration) error {
+	const sampleRate = 16000
+	dataSize := uint32(duration.Seconds()*sampleRate) * 2
+	header := []any{
+		[4]byte{'R', 'I', 'F', 'F'}, 36 + dataSize, [4]byte{'W', 'A', 'V', 'E'},
+		[4]byte{'f', 'm', 't', ' '}, uint32(16), uint16(wavFormatPCM), uint16(1),
+		uint32(sampleRate), uint32(sampleRate * 2), uint16(2), uint16(16),
+		[4]byte{'d', 'a', 't', 'a'}, dataSize,
+	}
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	for _, field := range header {
+		if err := binary.Write(file, binary.LittleEndian, field); err != nil {
+			return err
+		}
+	}
+	if _, err := file.Write(make([]byte, dataSize)); err != nil {
+		return err
+	}
+	return file.Close()
+}
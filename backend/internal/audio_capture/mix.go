@@ -0,0 +1,255 @@
+package audiocapture
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// MixOptions controls how the mic and system audio tracks are combined
+type MixOptions struct {
+	// EchoCancellation removes system audio picked up by the microphone before
+	// mixing, using the system track as the echo reference
+	EchoCancellation bool
+	EchoFilterOrder  int     // Adaptive filter length in samples, covers the echo tail
+	EchoStepSize     float64 // Adaptation speed of the filter (0-2)
+
+	// KeepTracks keeps the individual mic and system tracks after mixing
+	KeepTracks bool
+
+	// SegmentTime records the tracks in files of this many seconds, joined before mixing,
+	// so a crash loses at most the last segment. 0 records each track in a single file.
+	SegmentTime int
+
+	// Format is the sample format the tracks are recorded in and mixed to, zero uses DefaultFormat
+	Format AudioFormat
+
+	// InputVolume is the volume the input devices are captured at, 0 uses 1.5
+	InputVolume float64
+
+	// NoiseReduction removes steady background noise from the mic track before mixing
+	NoiseReduction bool
+	NoiseFloor     float64 // Noise floor in dB (-80 to -20)
+
+	// StereoSplit puts the mic, with the extra devices, on the left channel and the system
+	// audio on the right instead of blending them, keeping who said what apart
+	StereoSplit bool
+
+	// Loudnorm normalizes the loudness of the mix to EBU R128
+	Loudnorm         bool
+	LoudnessTarget   float64 // Integrated loudness in LUFS
+	LoudnessTruePeak float64 // Maximum true peak in dBTP
+	LoudnessRange    float64 // Loudness range in LU
+}
+
+// defaultInputVolume is the volume input devices are captured at when none is set
+const defaultInputVolume = 1.5
+
+// inputVolume returns the volume filter the input devices are captured with
+func (opts MixOptions) inputVolume() string {
+	volume := opts.InputVolume
+	if volume <= 0 {
+		volume = defaultInputVolume
+	}
+	return fmt.Sprintf("volume=%g", volume)
+}
+
+// noiseFilter returns the filter removing background noise from the mic track, if enabled
+func (opts MixOptions) noiseFilter() string {
+	if !opts.NoiseReduction {
+		return ""
+	}
+	floor := opts.NoiseFloor
+	if floor == 0 {
+		floor = -50
+	}
+	return fmt.Sprintf("afftdn=nf=%g", floor)
+}
+
+// loudnormFilter returns the filter normalizing the loudness of the mix, if enabled
+func (opts MixOptions) loudnormFilter() string {
+	if !opts.Loudnorm {
+		return ""
+	}
+	target, truePeak, lra := opts.LoudnessTarget, opts.LoudnessTruePeak, opts.LoudnessRange
+	if target == 0 {
+		target = -16
+	}
+	if truePeak == 0 {
+		truePeak = -1.5
+	}
+	if lra == 0 {
+		lra = 11
+	}
+	return fmt.Sprintf("loudnorm=I=%g:TP=%g:LRA=%g", target, truePeak, lra)
+}
+
+// AppliedFilters returns the filters a capture of the devices applies with the options, per
+// track and to the mix, in the order they are applied
+func AppliedFilters(devices CaptureDevices, opts MixOptions) []types.AudioFilter {
+	gains := []float64{trackGain(devices.MicGain), trackGain(devices.SystemGain)}
+	for _, extra := range devices.Extra {
+		gains = append(gains, trackGain(extra.Gain))
+	}
+	sources := []string{types.TrackSourceMic, types.TrackSourceSystem}
+	for i := range devices.Extra {
+		sources = append(sources, ExtraTrackSource(i))
+	}
+
+	filters := []types.AudioFilter{{Track: types.TrackSourceMic, Filter: opts.inputVolume()}}
+	for i := range devices.Extra {
+		filters = append(filters, types.AudioFilter{Track: ExtraTrackSource(i), Filter: opts.inputVolume()})
+	}
+	if opts.EchoCancellation {
+		order, stepSize := opts.echoFilter()
+		filters = append(filters, types.AudioFilter{Track: types.TrackSourceMic, Filter: fmt.Sprintf("anlms=order=%d:mu=%g", order, stepSize)})
+	}
+	if noise := opts.noiseFilter(); noise != "" {
+		filters = append(filters, types.AudioFilter{Track: types.TrackSourceMic, Filter: noise})
+	}
+	for i, gain := range gains {
+		if gain != 1 {
+			filters = append(filters, types.AudioFilter{Track: sources[i], Filter: fmt.Sprintf("volume=%g", gain)})
+		}
+	}
+	if opts.StereoSplit {
+		for i, source := range sources {
+			filters = append(filters, types.AudioFilter{Track: source, Filter: splitFilter(i)})
+		}
+	}
+	if loudnorm := opts.loudnormFilter(); loudnorm != "" {
+		filters = append(filters, types.AudioFilter{Track: types.AudioFilterMix, Filter: loudnorm})
+	}
+	return filters
+}
+
+// RecordedMixOptions returns opts with the filters a recording was captured with, as returned
+// by AppliedFilters, so its tracks are mixed again the way they were recorded. Without
+// filters, as for recordings made before they were stored, opts is returned as it is.
+func RecordedMixOptions(opts MixOptions, filters []types.AudioFilter, stereoSplit bool) MixOptions {
+	if len(filters) == 0 {
+		return opts
+	}
+	opts.EchoCancellation, opts.NoiseReduction, opts.Loudnorm = false, false, false
+	opts.StereoSplit = stereoSplit
+	inputVolume := false
+	for _, filter := range filters {
+		switch {
+		case filter.Track == types.TrackSourceMic && !inputVolume && strings.HasPrefix(filter.Filter, "volume="):
+			// The input volume comes first, later volume filters are the track gains
+			inputVolume = true
+			fmt.Sscanf(filter.Filter, "volume=%g", &opts.InputVolume)
+		case strings.HasPrefix(filter.Filter, "anlms="):
+			opts.EchoCancellation = true
+			fmt.Sscanf(filter.Filter, "anlms=order=%d:mu=%g", &opts.EchoFilterOrder, &opts.EchoStepSize)
+		case strings.HasPrefix(filter.Filter, "afftdn="):
+			opts.NoiseReduction = true
+			fmt.Sscanf(filter.Filter, "afftdn=nf=%g", &opts.NoiseFloor)
+		case strings.HasPrefix(filter.Filter, "loudnorm="):
+			opts.Loudnorm = true
+			fmt.Sscanf(filter.Filter, "loudnorm=I=%g:TP=%g:LRA=%g", &opts.LoudnessTarget, &opts.LoudnessTruePeak, &opts.LoudnessRange)
+		}
+	}
+	return opts
+}
+
+// Pan filters of the stereo split: the system audio goes right, the other tracks left
+const (
+	panLeft  = "pan=stereo|c0=c0|c1=0*c0"
+	panRight = "pan=stereo|c0=0*c0|c1=c0"
+)
+
+// splitFilter returns the filter placing input i on its channel of the stereo split
+func splitFilter(i int) string {
+	if i == 1 {
+		return "aformat=channel_layouts=mono," + panRight
+	}
+	return "aformat=channel_layouts=mono," + panLeft
+}
+
+// echoSampleRate is the rate both tracks are resampled to before echo cancellation
+const echoSampleRate = 48000
+
+// echoFilter returns the filter length and step size of the echo cancellation
+func (opts MixOptions) echoFilter() (int, float64) {
+	order := opts.EchoFilterOrder
+	if order <= 0 {
+		order = 4096
+	}
+	stepSize := opts.EchoStepSize
+	if stepSize <= 0 {
+		stepSize = 0.5
+	}
+	return order, stepSize
+}
+
+// buildMixFilter returns the ffmpeg filter graph mixing input 0 (mic) and input 1 (system audio)
+func buildMixFilter(opts MixOptions) string {
+	if !opts.EchoCancellation {
+		return "amix=inputs=2:duration=longest:dropout_transition=2"
+	}
+
+	order, stepSize := opts.echoFilter()
+
+	// anlms adapts a filter predicting the mic signal (desired, second input) from the
+	// system track (first input); out_mode=e outputs the prediction error, which is
+	// the mic signal with the echoed system audio removed
+	return fmt.Sprintf(
+		"[0:a]aformat=sample_rates=%[1]d:channel_layouts=mono[mic];"+
+			"[1:a]aformat=sample_rates=%[1]d:channel_layouts=mono,asplit=2[ref][sys];"+
+			"[ref][mic]anlms=order=%[2]d:mu=%[3]g:eps=1:out_mode=e[clean];"+
+			"[clean][sys]amix=inputs=2:duration=longest:dropout_transition=2",
+		echoSampleRate, order, stepSize,
+	)
+}
+
+// buildTracksFilter returns the ffmpeg filter graph mixing the tracks of a capture: input 0
+// (mic), input 1 (system audio) and the inputs of the extra devices, each scaled by its gain.
+// Echo cancellation and noise reduction only clean the mic track, the extra devices are mixed
+// as recorded. With the stereo split the tracks are panned before they are added up. Loudness
+// normalization applies to the mix.
+func buildTracksFilter(opts MixOptions, gains []float64) string {
+	unity := true
+	for _, gain := range gains {
+		unity = unity && gain == 1
+	}
+	if len(gains) == 2 && unity && !opts.StereoSplit && opts.noiseFilter() == "" && opts.loudnormFilter() == "" {
+		return buildMixFilter(opts)
+	}
+
+	var graph strings.Builder
+	labels := make([]string, len(gains))
+	for i := range gains {
+		labels[i] = fmt.Sprintf("%d:a", i)
+	}
+	if opts.EchoCancellation {
+		// The echo-cancelled mic and the system audio continue as [clean] and [sys]
+		echo := buildMixFilter(opts)
+		graph.WriteString(echo[:strings.LastIndex(echo, ";")+1])
+		labels[0], labels[1] = "clean", "sys"
+	}
+	for i, gain := range gains {
+		fmt.Fprintf(&graph, "[%s]", labels[i])
+		if noise := opts.noiseFilter(); i == 0 && noise != "" {
+			graph.WriteString(noise + ",")
+		}
+		fmt.Fprintf(&graph, "volume=%g", gain)
+		if opts.StereoSplit {
+			graph.WriteString("," + splitFilter(i))
+		}
+		fmt.Fprintf(&graph, "[t%d];", i)
+	}
+	for i := range gains {
+		fmt.Fprintf(&graph, "[t%d]", i)
+	}
+	fmt.Fprintf(&graph, "amix=inputs=%d:duration=longest:dropout_transition=2", len(gains))
+	if opts.StereoSplit {
+		// The channels don't overlap, so the panned tracks are added up instead of averaged
+		graph.WriteString(":normalize=0")
+	}
+	if loudnorm := opts.loudnormFilter(); loudnorm != "" {
+		graph.WriteString("," + loudnorm)
+	}
+	return graph.String()
+}
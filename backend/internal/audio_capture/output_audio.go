@@ -1,15 +1,25 @@
 package audiocapture
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
 )
 
 type OutputAudioOptions struct {
-	OutputPath string // Where to save the recording
-	Duration   int    // Duration in seconds (0 means until Stop() is called)
+	OutputPath  string      // Where to save the recording
+	Duration    int         // Duration in seconds (0 means until Stop() is called)
+	Device      string      // avfoundation audio device index or name (default: 1)
+	SegmentTime int         // Seconds per segment file, 0 records a single file
+	App         *AppTap     // Records the audio of an app instead of the device, nil records the device
+	Format      AudioFormat // Sample format of the track (default: DefaultFormat)
 }
 
 // OutputAudio manages system audio recording
@@ -19,6 +29,11 @@ type OutputAudio struct {
 	outputPath  string
 	isRecording bool
 	stopChan    chan struct{}
+	tap         *exec.Cmd     // App tap helper feeding ffmpeg, nil when recording the device
+	stopped     bool          // Set once the recording was asked to stop
+	stderr      *tailBuffer   // End of ffmpeg's stderr, to tell why it exited
+	exited      chan struct{} // Closed once ffmpeg exited
+	err         error         // Why ffmpeg exited, nil when it stopped cleanly
 }
 
 func NewOutputAudio(options OutputAudioOptions) *OutputAudio {
@@ -31,26 +46,33 @@ func NewOutputAudio(options OutputAudioOptions) *OutputAudio {
 	}
 }
 
-// Start begins the system audio recording process
-func (sr *OutputAudio) Start() error {
+// Start begins the system audio recording process. The recording stops when the context is
+// done.
+func (sr *OutputAudio) Start(ctx context.Context) error {
 	if sr.isRecording {
 		return fmt.Errorf("recording already in progress")
 	}
 
-	deviceIndex := 1
+	device := sr.options.Device
+	if device == "" {
+		device = DefaultSystemDevice
+	}
 
 	// Use ffmpeg to capture desktop audio
 	// This uses the avfoundation input for system audio
 	// For audio-only capture in avfoundation, use "none:deviceIndex" format
-	args := []string{
+	input := []string{
 		"-f", "avfoundation",
-		"-i", fmt.Sprintf("none:%d", deviceIndex), // Using the specified device index for system audio
-		"-ac", "2", // Stereo
-		"-ar", "48000", // 44.1 kHz sample rate (standard for audio)
+		"-i", "none:" + device, // Using the specified device for system audio
+	}
+	if sr.options.App != nil {
+		input = sr.options.App.inputArgs()
+	}
+	args := append(input,
 		"-thread_queue_size", "4096", // Increase buffer size to prevent buffer underruns
 		"-max_delay", "500000", // 0.5 second maximum delay
 		"-buffer_size", "1024k", // Larger buffer size
-	}
+	)
 
 	// Add duration if specified
 	if sr.options.Duration > 0 {
@@ -58,24 +80,42 @@ func (sr *OutputAudio) Start() error {
 	}
 
 	// Add output format and path with better quality settings
+	args = append(args, sr.options.Format.outputArgs()...)
 	args = append(args,
-		"-c:a", "pcm_s24le", // Use high quality PCM audio codec
 		"-af", "aresample=resampler=soxr:precision=28:osf=s32", // High quality resampler
 		"-y", // Overwrite existing file
-		sr.outputPath,
 	)
+	args = append(args, segmentArgs(sr.outputPath, sr.options.SegmentTime)...)
 
 	// Print the command for debugging
 	fmt.Printf("Running system audio capture command: ffmpeg %s\n", strings.Join(args, " "))
 
 	// Create the command
-	sr.cmd = exec.Command("ffmpeg", args...)
-
-	// Redirect stderr for logging
-	sr.cmd.Stderr = os.Stderr
+	sr.cmd = ffmpegCommand(ctx, args...)
+
+	// Redirect stderr for logging, keeping its end to tell why ffmpeg exited
+	sr.stderr = &tailBuffer{}
+	sr.cmd.Stderr = io.MultiWriter(os.Stderr, sr.stderr)
+
+	// Start the app tap helper feeding ffmpeg, and the tap's exit is watched separately
+	var tapDone chan error
+	if sr.options.App != nil {
+		tap, err := sr.options.App.start(ctx, sr.cmd)
+		if err != nil {
+			return err
+		}
+		sr.tap = tap
+		tapDone = make(chan error, 1)
+		go func() {
+			tapDone <- procs.Wait(tap)
+		}()
+	}
 
 	// Start the recording
-	if err := sr.cmd.Start(); err != nil {
+	if err := procs.Start(sr.cmd); err != nil {
+		if sr.tap != nil {
+			sr.tap.Process.Kill()
+		}
 		return fmt.Errorf("failed to start system audio recording: %w", err)
 	}
 
@@ -83,23 +123,50 @@ func (sr *OutputAudio) Start() error {
 
 	// If no duration limit is set, we need to handle stopping manually
 	if sr.options.Duration <= 0 {
-		go func() {
-			<-sr.stopChan
+		go func(stop chan struct{}) {
+			<-stop
+			sr.stopped = true
+			// Stopping the helper ends ffmpeg's stream, ffmpeg is interrupted in case it doesn't
+			if sr.tap != nil {
+				sr.tap.Process.Signal(os.Interrupt)
+			}
 			if sr.cmd.Process != nil {
 				sr.cmd.Process.Signal(os.Interrupt)
 			}
-		}()
+		}(sr.stopChan) // Stop replaces stopChan for the next recording
 	}
 
 	// Wait for the command to complete in a goroutine
-	go func() {
-		sr.cmd.Wait()
+	sr.exited = make(chan struct{})
+	go func(exited chan struct{}) {
+		err := procs.Wait(sr.cmd)
+		sr.err = exitError("ffmpeg", err, sr.stderr.String())
+		if tapDone != nil {
+			sr.err = sr.waitTap(tapDone, sr.err)
+		}
 		sr.isRecording = false
-	}()
+		close(exited)
+	}(sr.exited)
 
 	return nil
 }
 
+// waitTap stops the app tap helper once ffmpeg exited and returns the error of the track.
+// A helper that exited with an error before the recording was stopped ended ffmpeg's stream,
+// so it is the cause of the exit.
+func (sr *OutputAudio) waitTap(tapDone chan error, ffmpegErr error) error {
+	select {
+	case err := <-tapDone:
+		if ffmpegErr == nil && !sr.stopped {
+			return exitError(filepath.Base(sr.options.App.Command[0]), err, sr.stderr.String())
+		}
+	case <-time.After(tapExitTimeout):
+		sr.tap.Process.Kill()
+		<-tapDone
+	}
+	return ffmpegErr
+}
+
 // Stop stops the ongoing system audio recording
 func (sr *OutputAudio) Stop() error {
 	if !sr.isRecording {
@@ -124,3 +191,14 @@ func (sr *OutputAudio) GetOutputPath() string {
 func (sr *OutputAudio) IsRecording() bool {
 	return sr.isRecording
 }
+
+// Err returns why ffmpeg exited, classified as an *ExitError. It is nil while recording and
+// when ffmpeg stopped cleanly.
+func (sr *OutputAudio) Err() error {
+	select {
+	case <-sr.exited:
+		return sr.err
+	default:
+		return nil
+	}
+}
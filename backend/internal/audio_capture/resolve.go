@@ -0,0 +1,101 @@
+package audiocapture
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// ErrAmbiguousDevice is returned when a device name matches more than one device
+var ErrAmbiguousDevice = errors.New("audio device name is ambiguous")
+
+// ResolveDevice matches a device name, e.g. "MacBook Pro Microphone" or "blackhole", against
+// the names of the available devices, so recordings can select devices by name instead of by
+// index, which shifts between reboots. Names match exactly, then ignoring case and
+// punctuation, then by part of the name, and finally allowing a typo or two. Indices are
+// returned as they are. The error lists the available devices when nothing matches.
+func ResolveDevice(name string, available []string) (string, error) {
+	if name == "" || strings.Trim(name, "0123456789") == "" {
+		return name, nil
+	}
+	for _, device := range available {
+		if device == name {
+			return device, nil
+		}
+	}
+
+	wanted := normalizeDeviceName(name)
+	var matches []string
+	for _, device := range available {
+		if normalizeDeviceName(device) == wanted {
+			return device, nil
+		}
+		if strings.Contains(normalizeDeviceName(device), wanted) {
+			matches = append(matches, device)
+		}
+	}
+
+	if len(matches) == 0 {
+		best := max(1, len(wanted)/5) + 1
+		for _, device := range available {
+			distance := editDistance(wanted, normalizeDeviceName(device))
+			if distance < best {
+				best, matches = distance, []string{device}
+			} else if distance == best && len(matches) > 0 {
+				matches = append(matches, device)
+			}
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		return "", fmt.Errorf("%w: no device matches %q, available devices: %s", ErrDeviceNotFound, name, quoteDevices(available))
+	case 1:
+		return matches[0], nil
+	default:
+		return "", fmt.Errorf("%w: %q matches %s", ErrAmbiguousDevice, name, quoteDevices(matches))
+	}
+}
+
+// normalizeDeviceName lowercases a device name and reduces punctuation to single spaces
+func normalizeDeviceName(name string) string {
+	fields := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(fields, " ")
+}
+
+// editDistance returns the Levenshtein distance between two strings
+func editDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	previous := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			current[j] = min(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(rb)]
+}
+
+// quoteDevices lists device names for an error
+func quoteDevices(devices []string) string {
+	if len(devices) == 0 {
+		return "none"
+	}
+	quoted := make([]string, len(devices))
+	for i, device := range devices {
+		quoted[i] = fmt.Sprintf("%q", device)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,232 @@
+package audiocapture
+
+import (
+	"context"
+	_ "embed"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// sampleWAV is the recording the sample recorder produces: 3 seconds of tones, loud enough
+// to pass the silence checks
+//
+//go:embed sample.wav
+var sampleWAV []byte
+
+// Devices the sample capturer lists
+const (
+	SampleMicDevice    = "Sample Microphone"
+	SampleSystemDevice = "Sample System Audio"
+)
+
+// Sample simulates recording and conversions without ffmpeg, for developing and testing on
+// machines without it: every recording, track and soundcheck is a copy of a bundled sample
+// WAV, and conversions copy their input.
+type Sample struct{}
+
+var (
+	_ Capturer   = Sample{}
+	_ Transcoder = Sample{}
+)
+
+func (Sample) NewRecorder(outputPath string, devices CaptureDevices, mixOptions MixOptions) Recorder {
+	basePath := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	tracks := []types.AudioTrack{
+		{Source: types.TrackSourceMic, Path: basePath + "_mic.wav"},
+		{Source: types.TrackSourceSystem, Path: basePath + "_system.wav"},
+	}
+	for i := range devices.Extra {
+		tracks = append(tracks, types.AudioTrack{Source: ExtraTrackSource(i), Path: extraTrackPath(basePath, i)})
+	}
+	return &sampleRecorder{
+		outputPath: outputPath,
+		tracks:     tracks,
+		keepTracks: mixOptions.KeepTracks,
+		stopChan:   make(chan struct{}),
+	}
+}
+
+func (Sample) RecordSoundcheck(ctx context.Context, dir string, devices CaptureDevices, duration time.Duration, format AudioFormat) (*Soundcheck, error) {
+	check := &Soundcheck{
+		MicPath:    filepath.Join(dir, "mic.wav"),
+		SystemPath: filepath.Join(dir, "system.wav"),
+		MixPath:    filepath.Join(dir, "mix.wav"),
+	}
+	select {
+	case <-time.After(duration):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	for _, path := range []string{check.MicPath, check.SystemPath, check.MixPath} {
+		if err := writeSample(path); err != nil {
+			return nil, err
+		}
+	}
+	return check, nil
+}
+
+func (Sample) ListAudioDevices() ([]string, error) {
+	return []string{SampleMicDevice, SampleSystemDevice}, nil
+}
+
+func (Sample) DefaultInputDevice() (string, error) {
+	return SampleMicDevice, nil
+}
+
+func (Sample) Compress(ctx context.Context, inputPath string, outputPath string, codec string, bitrate string) error {
+	if _, err := CodecExtension(codec); err != nil {
+		return err
+	}
+	return copyFile(inputPath, outputPath)
+}
+
+func (Sample) ExtractClips(ctx context.Context, inputPath string, outputPath string, codec string, clips []types.Highlight) error {
+	if len(clips) == 0 {
+		return fmt.Errorf("no clips to extract")
+	}
+	return copyFile(inputPath, outputPath)
+}
+
+func (Sample) RecoverRecording(ctx context.Context, outputPath string, mixOptions MixOptions) error {
+	if !HasTracks(outputPath) {
+		return fmt.Errorf("no tracks to recover for %s", outputPath)
+	}
+	return writeSample(outputPath)
+}
+
+func (Sample) JoinSegments(ctx context.Context, path string) error {
+	return nil
+}
+
+func (Sample) Concat(ctx context.Context, inputPaths []string, outputPath string) error {
+	if len(inputPaths) < 2 {
+		return fmt.Errorf("at least two recordings are needed to concatenate, got %d", len(inputPaths))
+	}
+	return copyFile(inputPaths[0], outputPath)
+}
+
+// sampleRecorder writes the sample to its tracks when started and to its output path when
+// stopped
+type sampleRecorder struct {
+	outputPath  string
+	tracks      []types.AudioTrack
+	keepTracks  bool
+	isRecording bool
+	cancelled   bool
+	stopChan    chan struct{}
+	done        chan struct{} // Closed once the output is written or the capture discarded
+	err         error
+}
+
+func (sr *sampleRecorder) Start(ctx context.Context) error {
+	if sr.isRecording {
+		return fmt.Errorf("recording already in progress")
+	}
+	for _, track := range sr.tracks {
+		if err := writeSample(track.Path); err != nil {
+			return err
+		}
+	}
+	sr.isRecording = true
+
+	// Stop replaces stopChan for the next recording, this one waits on the current channel
+	sr.done = make(chan struct{})
+	go func(done, stop chan struct{}) {
+		defer close(done)
+		select {
+		case <-stop:
+		case <-ctx.Done():
+		}
+		sr.isRecording = false
+
+		if !sr.cancelled {
+			sr.err = writeSample(sr.outputPath)
+		}
+		if sr.cancelled || !sr.keepTracks {
+			for _, track := range sr.tracks {
+				os.Remove(track.Path)
+			}
+		}
+	}(sr.done, sr.stopChan)
+	return nil
+}
+
+func (sr *sampleRecorder) Stop() error {
+	if !sr.isRecording {
+		return fmt.Errorf("no recording in progress")
+	}
+	close(sr.stopChan)
+	sr.stopChan = make(chan struct{})
+	return nil
+}
+
+func (sr *sampleRecorder) Wait(timeout time.Duration) bool {
+	if sr.done == nil {
+		return true
+	}
+	select {
+	case <-sr.done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+func (sr *sampleRecorder) Err() error {
+	return sr.err
+}
+
+func (sr *sampleRecorder) TrackErrors() []error {
+	return nil
+}
+
+func (sr *sampleRecorder) IsRecording() bool {
+	return sr.isRecording
+}
+
+func (sr *sampleRecorder) GetOutputPath() string {
+	return sr.outputPath
+}
+
+func (sr *sampleRecorder) GetTracks() []types.AudioTrack {
+	return sr.tracks
+}
+
+func (sr *sampleRecorder) Begin(preRoll time.Duration) error {
+	return nil
+}
+
+func (sr *sampleRecorder) Cancel() error {
+	sr.cancelled = true
+	return sr.Stop()
+}
+
+func (sr *sampleRecorder) SetOutputPath(outputPath string) {
+	sr.outputPath = outputPath
+}
+
+func (sr *sampleRecorder) SwitchMic(ctx context.Context, device string) error {
+	if !sr.isRecording {
+		return fmt.Errorf("no recording in progress")
+	}
+	return nil
+}
+
+// writeSample writes the sample WAV to the path
+func writeSample(path string) error {
+	return os.WriteFile(path, sampleWAV, 0644)
+}
+
+// copyFile copies a file, standing in for a conversion
+func copyFile(src string, dst string) error {
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(dst, data, 0644)
+}
@@ -0,0 +1,163 @@
+package audiocapture
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// segmentArgs returns the ffmpeg output options writing the track to numbered files of
+// segmentTime seconds next to its path, or the path itself when segmentTime is 0. Every
+// finished segment is a complete WAV file, so an ffmpeg or server crash loses at most the
+// segment being written.
+func segmentArgs(path string, segmentTime int) []string {
+	if segmentTime <= 0 {
+		return []string{path}
+	}
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	return []string{
+		"-f", "segment",
+		"-segment_time", fmt.Sprintf("%d", segmentTime),
+		"-segment_format", "wav",
+		"-reset_timestamps", "1",
+		base + "_%05d" + filepath.Ext(path),
+	}
+}
+
+// trackSegments returns the segments of a track recorded in segments, in order
+func trackSegments(path string) []string {
+	base := strings.TrimSuffix(path, filepath.Ext(path))
+	segments, _ := filepath.Glob(base + "_[0-9][0-9][0-9][0-9][0-9]" + filepath.Ext(path))
+	sort.Strings(segments)
+	return segments
+}
+
+// joinSegments concatenates the segments of a track into its path and removes them. Tracks
+// that weren't recorded in segments are left as they are.
+func joinSegments(ctx context.Context, path string) error {
+	segments := trackSegments(path)
+	switch len(segments) {
+	case 0:
+		return nil
+	case 1:
+		return os.Rename(segments[0], path)
+	}
+
+	listPath := strings.TrimSuffix(path, filepath.Ext(path)) + "_segments.txt"
+	var list strings.Builder
+	for _, segment := range segments {
+		fmt.Fprintf(&list, "file '%s'\n", strings.ReplaceAll(segment, "'", `'\''`))
+	}
+	if err := os.WriteFile(listPath, []byte(list.String()), 0600); err != nil {
+		return err
+	}
+	defer os.Remove(listPath)
+
+	args := []string{"-f", "concat", "-safe", "0", "-i", listPath, "-c", "copy", "-y", path}
+	if output, err := procs.CombinedOutput(ffmpegCommand(ctx, args...)); err != nil {
+		return fmt.Errorf("ffmpeg failed to join %d segments: %w\nOutput: %s", len(segments), err, string(output))
+	}
+	for _, segment := range segments {
+		os.Remove(segment)
+	}
+	return nil
+}
+
+// liveTrack returns the file a track is being written to: its path, or its last segment,
+// of the last part when the device was switched
+func liveTrack(path string) string {
+	if parts := trackParts(path); len(parts) > 0 {
+		path = parts[len(parts)-1]
+	}
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+	if segments := trackSegments(path); len(segments) > 0 {
+		return segments[len(segments)-1]
+	}
+	return path
+}
+
+// segmentedDuration returns the length of the audio of a track recorded in segments
+func segmentedDuration(path string) (time.Duration, error) {
+	segments := trackSegments(path)
+	if len(segments) == 0 {
+		return 0, fmt.Errorf("no recording found at %s", path)
+	}
+	var total time.Duration
+	for _, segment := range segments {
+		duration, err := TrackDuration(segment)
+		if err != nil {
+			return 0, err
+		}
+		total += duration
+	}
+	return total, nil
+}
+
+// JoinSegments joins the segments of a track left behind by a crash into its path, so the
+// audio can be recovered when no recording claims it
+func JoinSegments(ctx context.Context, path string) error {
+	return joinSegments(ctx, path)
+}
+
+// SegmentedTracks returns the paths of the tracks in a directory that have segments on disk
+func SegmentedTracks(dir string) []string {
+	segments, _ := filepath.Glob(filepath.Join(dir, "*_[0-9][0-9][0-9][0-9][0-9].wav"))
+	var tracks []string
+	for _, segment := range segments {
+		track := segment[:len(segment)-len("_00000.wav")] + ".wav"
+		if len(tracks) == 0 || tracks[len(tracks)-1] != track {
+			tracks = append(tracks, track)
+		}
+	}
+	return tracks
+}
+
+// HasTracks reports whether a track of a recording exists, as a file or as segments, as left
+// behind by a recording interrupted by a crash
+func HasTracks(outputPath string) bool {
+	if outputPath == "" {
+		return false
+	}
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	for _, track := range []string{base + "_mic.wav", base + "_system.wav"} {
+		if trackExists(track) {
+			return true
+		}
+	}
+	return false
+}
+
+// trackExists reports whether a track exists as a file or as segments
+func trackExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil || len(trackSegments(path)) > 0
+}
+
+// recordedExtraDevices returns as many extra devices as a recording left tracks behind for,
+// mixed as recorded
+func recordedExtraDevices(outputPath string) []ExtraDevice {
+	base := strings.TrimSuffix(outputPath, filepath.Ext(outputPath))
+	var extra []ExtraDevice
+	for len(extra) < MaxExtraDevices && trackExists(extraTrackPath(base, len(extra))) {
+		extra = append(extra, ExtraDevice{})
+	}
+	return extra
+}
+
+// RecoverRecording joins the segments of the tracks of a recording interrupted by a crash and
+// mixes them into the output path, so what was captured can still be processed
+func RecoverRecording(ctx context.Context, outputPath string, mixOptions MixOptions) error {
+	if !HasTracks(outputPath) {
+		return fmt.Errorf("no tracks found for %s", outputPath)
+	}
+	devices := CaptureDevices{Extra: recordedExtraDevices(outputPath)}
+	return NewCombinedAudio(outputPath, devices, mixOptions).mix(ctx)
+}
@@ -0,0 +1,54 @@
+package audiocapture
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// Soundcheck holds the files of a short test recording
+type Soundcheck struct {
+	MicPath    string
+	SystemPath string
+	MixPath    string // Both tracks mixed, for playback
+}
+
+// RecordSoundcheck records both devices for the duration into dir in the format with a
+// single ffmpeg process and blocks until the recording is done
+func RecordSoundcheck(ctx context.Context, dir string, devices CaptureDevices, duration time.Duration, format AudioFormat) (*Soundcheck, error) {
+	if devices.Mic == "" {
+		devices.Mic = DefaultMicDevice
+	}
+	if devices.System == "" {
+		devices.System = DefaultSystemDevice
+	}
+	check := &Soundcheck{
+		MicPath:    filepath.Join(dir, "mic.wav"),
+		SystemPath: filepath.Join(dir, "system.wav"),
+		MixPath:    filepath.Join(dir, "mix.wav"),
+	}
+
+	seconds := fmt.Sprintf("%.1f", duration.Seconds())
+	args := []string{
+		"-y",
+		"-f", "avfoundation", "-t", seconds, "-i", ":" + devices.Mic,
+		"-f", "avfoundation", "-t", seconds, "-i", "none:" + devices.System,
+		"-filter_complex", "[0:a][1:a]" + buildMixFilter(MixOptions{}) + "[mix]",
+	}
+	for _, output := range []struct{ stream, path string }{
+		{"0:a", check.MicPath},
+		{"1:a", check.SystemPath},
+		{"[mix]", check.MixPath},
+	} {
+		args = append(args, "-map", output.stream)
+		args = append(args, format.outputArgs()...)
+		args = append(args, output.path)
+	}
+	if output, err := procs.CombinedOutput(ffmpegCommand(ctx, args...)); err != nil {
+		return nil, fmt.Errorf("soundcheck recording failed: %w\nOutput: %s", err, string(output))
+	}
+	return check, nil
+}
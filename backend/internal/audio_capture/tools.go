@@ -0,0 +1,87 @@
+package audiocapture
+
+import (
+	"context"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Recorder captures the mic and system audio of a meeting and mixes them into a recording.
+// Armed recorders are started ahead of the recording, then begun or cancelled.
+type Recorder interface {
+	Start(ctx context.Context) error
+	Stop() error
+	Wait(timeout time.Duration) bool
+	Err() error
+	TrackErrors() []error
+	IsRecording() bool
+	GetOutputPath() string
+	GetTracks() []types.AudioTrack
+	Begin(preRoll time.Duration) error
+	Cancel() error
+	SetOutputPath(outputPath string)
+	SwitchMic(ctx context.Context, device string) error
+}
+
+// Capturer creates recorders and records from the audio devices
+type Capturer interface {
+	NewRecorder(outputPath string, devices CaptureDevices, mixOptions MixOptions) Recorder
+	RecordSoundcheck(ctx context.Context, dir string, devices CaptureDevices, duration time.Duration, format AudioFormat) (*Soundcheck, error)
+	ListAudioDevices() ([]string, error)
+	DefaultInputDevice() (string, error)
+}
+
+// Transcoder converts recordings after they were captured
+type Transcoder interface {
+	Compress(ctx context.Context, inputPath string, outputPath string, codec string, bitrate string) error
+	ExtractClips(ctx context.Context, inputPath string, outputPath string, codec string, clips []types.Highlight) error
+	RecoverRecording(ctx context.Context, outputPath string, mixOptions MixOptions) error
+	JoinSegments(ctx context.Context, path string) error
+	Concat(ctx context.Context, inputPaths []string, outputPath string) error
+}
+
+// FFmpeg records and converts audio with ffmpeg
+type FFmpeg struct{}
+
+var (
+	_ Recorder   = (*CombinedAudio)(nil)
+	_ Capturer   = FFmpeg{}
+	_ Transcoder = FFmpeg{}
+)
+
+func (FFmpeg) NewRecorder(outputPath string, devices CaptureDevices, mixOptions MixOptions) Recorder {
+	return NewCombinedAudio(outputPath, devices, mixOptions)
+}
+
+func (FFmpeg) RecordSoundcheck(ctx context.Context, dir string, devices CaptureDevices, duration time.Duration, format AudioFormat) (*Soundcheck, error) {
+	return RecordSoundcheck(ctx, dir, devices, duration, format)
+}
+
+func (FFmpeg) ListAudioDevices() ([]string, error) {
+	return ListAudioDevices()
+}
+
+func (FFmpeg) DefaultInputDevice() (string, error) {
+	return DefaultInputDevice()
+}
+
+func (FFmpeg) Compress(ctx context.Context, inputPath string, outputPath string, codec string, bitrate string) error {
+	return Compress(ctx, inputPath, outputPath, codec, bitrate)
+}
+
+func (FFmpeg) ExtractClips(ctx context.Context, inputPath string, outputPath string, codec string, clips []types.Highlight) error {
+	return ExtractClips(ctx, inputPath, outputPath, codec, clips)
+}
+
+func (FFmpeg) RecoverRecording(ctx context.Context, outputPath string, mixOptions MixOptions) error {
+	return RecoverRecording(ctx, outputPath, mixOptions)
+}
+
+func (FFmpeg) JoinSegments(ctx context.Context, path string) error {
+	return JoinSegments(ctx, path)
+}
+
+func (FFmpeg) Concat(ctx context.Context, inputPaths []string, outputPath string) error {
+	return Concat(ctx, inputPaths, outputPath)
+}
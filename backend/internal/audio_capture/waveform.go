@@ -0,0 +1,65 @@
+package audiocapture
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// Waveform returns the RMS level of each of buckets equal stretches of a PCM WAV file, from
+// 0 for silence to 1 for a full-scale signal, and the duration of the file in seconds. Files
+// shorter than buckets frames get a bucket per frame.
+func Waveform(path string, buckets int) ([]float64, float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer file.Close()
+
+	format, dataOffset, err := readWavHeader(file)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		return nil, 0, err
+	}
+	block := int64(format.blockAlign)
+	frames := (info.Size() - dataOffset) / block
+	duration := float64(frames*block) / float64(format.byteRate)
+	if frames <= 0 || buckets <= 0 {
+		return []float64{}, duration, nil
+	}
+	buckets = int(min(int64(buckets), frames))
+
+	if _, err := file.Seek(dataOffset, io.SeekStart); err != nil {
+		return nil, 0, err
+	}
+	reader := bufio.NewReaderSize(file, 64*1024)
+	bytesPerSample := int(format.bitsPerSample / 8)
+	fullScale := math.Pow(2, float64(format.bitsPerSample-1))
+	frame := make([]byte, block)
+	levels := make([]float64, buckets)
+	var frameIndex int64
+	for bucket := range levels {
+		end := (int64(bucket) + 1) * frames / int64(buckets)
+		var sum float64
+		samples := 0
+		for ; frameIndex < end; frameIndex++ {
+			if _, err := io.ReadFull(reader, frame); err != nil {
+				return nil, 0, fmt.Errorf("failed to read %s: %w", path, err)
+			}
+			for i := 0; i+bytesPerSample <= len(frame); i += bytesPerSample {
+				sample := float64(decodeSample(frame[i:i+bytesPerSample])) / fullScale
+				sum += sample * sample
+				samples++
+			}
+		}
+		if samples > 0 {
+			levels[bucket] = math.Sqrt(sum / float64(samples))
+		}
+	}
+	return levels, duration, nil
+}
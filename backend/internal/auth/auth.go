@@ -0,0 +1,245 @@
+// Package auth authenticates API requests with static API keys or OIDC session tokens.
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/logger"
+)
+
+// SessionCookie holds the session token of browser logins
+const SessionCookie = "transcriber_session"
+
+// Authentication methods
+const (
+	MethodAPIKey  = "api_key"
+	MethodSession = "session"
+)
+
+// Principal is the authenticated user of a request
+type Principal struct {
+	Username string     `json:"username"`
+	Role     string     `json:"role"`
+	Method   string     `json:"method"`
+	Scope    string     `json:"scope,omitempty"`   // Scope of the API key, empty for the full scope
+	Expires  *time.Time `json:"expires,omitempty"` // Set for session logins
+}
+
+type contextKey struct{}
+
+// WithPrincipal returns a context carrying the principal
+func WithPrincipal(ctx context.Context, principal *Principal) context.Context {
+	return context.WithValue(ctx, contextKey{}, principal)
+}
+
+// FromContext returns the principal of the request, or nil when authentication is disabled
+func FromContext(ctx context.Context) *Principal {
+	principal, _ := ctx.Value(contextKey{}).(*Principal)
+	return principal
+}
+
+// Username returns the authenticated username of the request, or "" when authentication is disabled
+func Username(ctx context.Context) string {
+	if principal := FromContext(ctx); principal != nil {
+		return principal.Username
+	}
+	return ""
+}
+
+// IsAdmin reports whether the principal may use the admin API
+func (p *Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+// Owner returns the user whose meetings the principal is limited to. Admins, and requests
+// when authentication is disabled (a nil principal), see every meeting and get "".
+func (p *Principal) Owner() string {
+	if p == nil || p.IsAdmin() {
+		return ""
+	}
+	return p.Username
+}
+
+// CanAccess reports whether the principal may read and change a meeting started by owner
+func (p *Principal) CanAccess(owner string) bool {
+	return p.Owner() == "" || p.Owner() == owner
+}
+
+// Authenticator validates API keys and session tokens and runs the OIDC login flow
+type Authenticator struct {
+	apiKeys    []config.APIKey
+	store      *Store
+	sessionTTL time.Duration
+	oidc       *oidcProvider // Nil when OIDC is not configured
+	logger     *logger.Logger
+
+	mu       sync.Mutex
+	sessions map[string]*Principal // Session token -> principal
+	logins   map[string]*pendingLogin
+}
+
+// New creates an authenticator, or returns nil when authentication is disabled
+func New(cfg config.AuthConfig, logger *logger.Logger) (*Authenticator, error) {
+	if !cfg.Enabled() {
+		return nil, nil
+	}
+
+	store, err := loadStore(cfg.UsersFile)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &Authenticator{
+		apiKeys:    cfg.APIKeys,
+		store:      store,
+		sessionTTL: time.Duration(cfg.SessionTTL) * time.Second,
+		logger:     logger,
+		sessions:   make(map[string]*Principal),
+		logins:     make(map[string]*pendingLogin),
+	}
+	for _, key := range cfg.APIKeys {
+		if key.Key == "" || key.User == "" {
+			return nil, fmt.Errorf("api keys require both a key and a user")
+		}
+		if key.Role != "" && !validRole(key.Role) {
+			return nil, fmt.Errorf("invalid role %q for api key of %s", key.Role, key.User)
+		}
+		if !validScope(key.Scope) {
+			return nil, fmt.Errorf("invalid scope %q for api key of %s", key.Scope, key.User)
+		}
+	}
+
+	if cfg.OIDC.Enabled() {
+		provider, err := newOIDCProvider(cfg.OIDC)
+		if err != nil {
+			return nil, err
+		}
+		a.oidc = provider
+	}
+
+	return a, nil
+}
+
+// Store returns the user and token store
+func (a *Authenticator) Store() *Store {
+	return a.store
+}
+
+// OIDCEnabled reports whether users can log in with the OIDC provider
+func (a *Authenticator) OIDCEnabled() bool {
+	return a.oidc != nil
+}
+
+// Authenticate returns the principal for the request's API key, API token or session token.
+// Credentials are read from the Authorization bearer token, the X-API-Key header or the session cookie.
+func (a *Authenticator) Authenticate(r *http.Request) (*Principal, bool) {
+	token := requestToken(r)
+	if token == "" {
+		return nil, false
+	}
+
+	for _, key := range a.apiKeys {
+		if subtle.ConstantTimeCompare([]byte(token), []byte(key.Key)) == 1 {
+			role := key.Role
+			if role == "" {
+				role = RoleMember
+			}
+			return &Principal{Username: key.User, Role: role, Method: MethodAPIKey, Scope: limitedScope(key.Scope)}, true
+		}
+	}
+
+	if user, scope, ok := a.store.lookupToken(token); ok {
+		return &Principal{Username: user.Username, Role: user.Role, Method: MethodAPIKey, Scope: scope}, true
+	}
+
+	return a.lookupSession(token)
+}
+
+// limitedScope returns the scope of a principal: empty for the full scope
+func limitedScope(scope string) string {
+	if scope == ScopeFull {
+		return ""
+	}
+	return scope
+}
+
+func requestToken(r *http.Request) string {
+	if bearer, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok {
+		return strings.TrimSpace(bearer)
+	}
+	if key := r.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if cookie, err := r.Cookie(SessionCookie); err == nil {
+		return cookie.Value
+	}
+	return ""
+}
+
+// CreateSession issues a session token for the user
+func (a *Authenticator) CreateSession(username string, role string) (string, *Principal) {
+	token := randomToken()
+	expires := time.Now().Add(a.sessionTTL)
+	principal := &Principal{
+		Username: username,
+		Role:     role,
+		Method:   MethodSession,
+		Expires:  &expires,
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.sessions[token] = principal
+	return token, principal
+}
+
+// EndSession revokes the session token of the request, if any
+func (a *Authenticator) EndSession(r *http.Request) {
+	token := requestToken(r)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.sessions, token)
+}
+
+func (a *Authenticator) lookupSession(token string) (*Principal, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	principal, ok := a.sessions[token]
+	if !ok {
+		return nil, false
+	}
+	if time.Now().After(*principal.Expires) {
+		delete(a.sessions, token)
+		return nil, false
+	}
+
+	// Pick up role changes and deleted accounts without requiring a new login
+	user, err := a.store.GetUser(principal.Username)
+	if err != nil {
+		delete(a.sessions, token)
+		return nil, false
+	}
+	principal.Role = user.Role
+
+	current := *principal
+	return &current, true
+}
+
+// randomToken returns a URL-safe random token with 256 bits of entropy
+func randomToken() string {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		panic(fmt.Sprintf("failed to read random bytes: %v", err))
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
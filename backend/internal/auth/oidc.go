@@ -0,0 +1,353 @@
+package auth
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+)
+
+// loginTimeout is how long a user has to complete the login at the provider
+const loginTimeout = 10 * time.Minute
+
+var oidcHTTPClient = &http.Client{Timeout: 15 * time.Second}
+
+// pendingLogin is an authorization request waiting for the provider callback
+type pendingLogin struct {
+	verifier string // PKCE code verifier
+	nonce    string
+	expires  time.Time
+}
+
+// oidcProvider runs the authorization code flow against an OpenID Connect provider
+type oidcProvider struct {
+	cfg config.OIDCConfig
+
+	mu        sync.Mutex
+	discovery *oidcDiscovery
+	keys      map[string]*rsa.PublicKey // Key ID -> signing key
+}
+
+type oidcDiscovery struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+func newOIDCProvider(cfg config.OIDCConfig) (*oidcProvider, error) {
+	if cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc requires auth.oidc.redirect_url")
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &oidcProvider{cfg: cfg}, nil
+}
+
+// metadata fetches the provider configuration on first use so the server can start
+// while the provider is unreachable
+func (p *oidcProvider) metadata() (*oidcDiscovery, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	var discovery oidcDiscovery
+	if err := getJSON(p.cfg.Issuer+"/.well-known/openid-configuration", &discovery); err != nil {
+		return nil, fmt.Errorf("failed to discover OIDC provider: %w", err)
+	}
+	if strings.TrimSuffix(discovery.Issuer, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("OIDC provider reports issuer %q, expected %q", discovery.Issuer, p.cfg.Issuer)
+	}
+	p.discovery = &discovery
+	return p.discovery, nil
+}
+
+// LoginURL starts a login and returns the provider URL to send the browser to
+func (a *Authenticator) LoginURL() (string, error) {
+	if a.oidc == nil {
+		return "", fmt.Errorf("OIDC login is not configured")
+	}
+	discovery, err := a.oidc.metadata()
+	if err != nil {
+		return "", err
+	}
+
+	state := randomToken()
+	login := &pendingLogin{
+		verifier: randomToken(),
+		nonce:    randomToken(),
+		expires:  time.Now().Add(loginTimeout),
+	}
+
+	a.mu.Lock()
+	for key, pending := range a.logins {
+		if time.Now().After(pending.expires) {
+			delete(a.logins, key)
+		}
+	}
+	a.logins[state] = login
+	a.mu.Unlock()
+
+	challenge := sha256.Sum256([]byte(login.verifier))
+	query := url.Values{
+		"response_type":         {"code"},
+		"client_id":             {a.oidc.cfg.ClientID},
+		"redirect_uri":          {a.oidc.cfg.RedirectURL},
+		"scope":                 {strings.Join(a.oidc.cfg.Scopes, " ")},
+		"state":                 {state},
+		"nonce":                 {login.nonce},
+		"code_challenge":        {base64.RawURLEncoding.EncodeToString(challenge[:])},
+		"code_challenge_method": {"S256"},
+	}
+	return discovery.AuthorizationEndpoint + "?" + query.Encode(), nil
+}
+
+// CompleteLogin exchanges the authorization code from the provider callback for an
+// ID token and issues a session for the user
+func (a *Authenticator) CompleteLogin(state, code string) (string, *Principal, error) {
+	if a.oidc == nil {
+		return "", nil, fmt.Errorf("OIDC login is not configured")
+	}
+
+	a.mu.Lock()
+	login, ok := a.logins[state]
+	delete(a.logins, state)
+	a.mu.Unlock()
+	if !ok || time.Now().After(login.expires) {
+		return "", nil, fmt.Errorf("unknown or expired login state")
+	}
+
+	claims, err := a.oidc.exchange(code, login)
+	if err != nil {
+		return "", nil, err
+	}
+
+	username := a.oidc.username(claims)
+	if username == "" {
+		return "", nil, fmt.Errorf("ID token has no %s, email or sub claim", a.oidc.cfg.UsernameClaim)
+	}
+
+	// OIDC users are provisioned as members on first login
+	user, err := a.store.ensureUser(username)
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to provision user %q: %w", username, err)
+	}
+
+	token, principal := a.CreateSession(user.Username, user.Role)
+	a.logger.Info("User logged in with OIDC", "user", username)
+	return token, principal, nil
+}
+
+// PostLoginRedirect is where the browser is sent after a successful login
+func (a *Authenticator) PostLoginRedirect() string {
+	if a.oidc == nil || a.oidc.cfg.PostLoginRedirect == "" {
+		return "/"
+	}
+	return a.oidc.cfg.PostLoginRedirect
+}
+
+// username maps the ID token claims to a username, preferring the configured claim
+func (p *oidcProvider) username(claims map[string]interface{}) string {
+	for _, claim := range []string{p.cfg.UsernameClaim, "email", "sub"} {
+		if value, ok := claims[claim].(string); ok && value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// exchange redeems the authorization code and returns the verified ID token claims
+func (p *oidcProvider) exchange(code string, login *pendingLogin) (map[string]interface{}, error) {
+	discovery, err := p.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {login.verifier},
+	}
+	req, err := http.NewRequest(http.MethodPost, discovery.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := oidcHTTPClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem authorization code: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var tokens struct {
+		IdToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tokens); err != nil {
+		return nil, fmt.Errorf("invalid token response: %w", err)
+	}
+	if tokens.Error != "" {
+		return nil, fmt.Errorf("token endpoint returned %s: %s", tokens.Error, tokens.ErrorDescription)
+	}
+	if tokens.IdToken == "" {
+		return nil, fmt.Errorf("token response has no id_token")
+	}
+
+	return p.verifyIDToken(tokens.IdToken, login.nonce)
+}
+
+// verifyIDToken checks the signature and standard claims of an RS256 ID token
+func (p *oidcProvider) verifyIDToken(idToken string, nonce string) (map[string]interface{}, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("malformed ID token")
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, fmt.Errorf("invalid ID token header: %w", err)
+	}
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("unsupported ID token algorithm %q", header.Alg)
+	}
+
+	key, err := p.signingKey(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid ID token signature encoding: %w", err)
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return nil, fmt.Errorf("invalid ID token signature")
+	}
+
+	var claims map[string]interface{}
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return nil, fmt.Errorf("invalid ID token claims: %w", err)
+	}
+
+	if iss, _ := claims["iss"].(string); strings.TrimSuffix(iss, "/") != p.cfg.Issuer {
+		return nil, fmt.Errorf("ID token issued by %q", iss)
+	}
+	if !audienceContains(claims["aud"], p.cfg.ClientID) {
+		return nil, fmt.Errorf("ID token not issued for this client")
+	}
+	if exp, ok := claims["exp"].(float64); !ok || time.Now().After(time.Unix(int64(exp), 0)) {
+		return nil, fmt.Errorf("ID token expired")
+	}
+	if claimNonce, _ := claims["nonce"].(string); claimNonce != nonce {
+		return nil, fmt.Errorf("ID token nonce mismatch")
+	}
+
+	return claims, nil
+}
+
+// signingKey returns the provider key with the given ID, refreshing the key set when
+// the key is unknown to pick up rotations
+func (p *oidcProvider) signingKey(kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	p.mu.Unlock()
+	if ok {
+		return key, nil
+	}
+
+	discovery, err := p.metadata()
+	if err != nil {
+		return nil, err
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			Use string `json:"use"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(discovery.JWKSURI, &jwks); err != nil {
+		return nil, fmt.Errorf("failed to fetch OIDC signing keys: %w", err)
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, jwk := range jwks.Keys {
+		if jwk.Kty != "RSA" || (jwk.Use != "" && jwk.Use != "sig") {
+			continue
+		}
+		n, errN := base64.RawURLEncoding.DecodeString(jwk.N)
+		e, errE := base64.RawURLEncoding.DecodeString(jwk.E)
+		if errN != nil || errE != nil {
+			continue
+		}
+		keys[jwk.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.mu.Unlock()
+
+	if key, ok := keys[kid]; ok {
+		return key, nil
+	}
+	return nil, fmt.Errorf("unknown ID token signing key %q", kid)
+}
+
+func audienceContains(aud interface{}, clientId string) bool {
+	switch aud := aud.(type) {
+	case string:
+		return aud == clientId
+	case []interface{}:
+		for _, value := range aud {
+			if value == clientId {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func decodeSegment(segment string, v interface{}) error {
+	data, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, v)
+}
+
+func getJSON(url string, v interface{}) error {
+	resp, err := oidcHTTPClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("GET %s failed with status %d", url, resp.StatusCode)
+	}
+	return json.NewDecoder(resp.Body).Decode(v)
+}
@@ -0,0 +1,307 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Roles
+const (
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+)
+
+// Scopes limit what an API key or token can do, whatever the role of its user
+const (
+	ScopeFull     = "full"     // Everything the role allows, the default
+	ScopeRecorder = "recorder" // Only start and stop recordings, e.g. for a kiosk or stream deck
+	ScopeReader   = "reader"   // Only list meetings and their stats, never transcripts, e.g. for a dashboard
+)
+
+// ErrNotFound is returned when a user or token doesn't exist
+var ErrNotFound = errors.New("not found")
+
+// User is an account of the multi-user model
+type User struct {
+	Username  string    `json:"username"`
+	Role      string    `json:"role"`
+	Quotas    Quotas    `json:"quotas"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Quotas limit what a user can do on a shared server. Zero values mean unlimited.
+type Quotas struct {
+	MaxStorageMB int `json:"max_storage_mb"` // Audio and transcripts, overrides auth.default_storage_quota_mb
+}
+
+// Token is an API token issued through the admin API. Only a hash of the secret is stored.
+type Token struct {
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	User      string     `json:"user"`
+	Scope     string     `json:"scope,omitempty"` // Empty is the full scope
+	Hash      string     `json:"hash,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// Store persists users and API tokens in a JSON file
+type Store struct {
+	mu     sync.RWMutex
+	path   string
+	Users  map[string]*User  `json:"users"`
+	Tokens map[string]*Token `json:"tokens"` // Token ID -> token
+}
+
+// loadStore reads the store file, starting empty when it doesn't exist yet
+func loadStore(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		Users:  make(map[string]*User),
+		Tokens: make(map[string]*Token),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read users file: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse users file: %w", err)
+	}
+	return s, nil
+}
+
+// save writes the store atomically; callers must hold the write lock
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func validRole(role string) bool {
+	return role == RoleAdmin || role == RoleMember
+}
+
+func validScope(scope string) bool {
+	return scope == "" || scope == ScopeFull || scope == ScopeRecorder || scope == ScopeReader
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// ListUsers returns all users sorted by username
+func (s *Store) ListUsers() []User {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	users := make([]User, 0, len(s.Users))
+	for _, user := range s.Users {
+		users = append(users, *user)
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
+
+// GetUser returns a user by username
+func (s *Store) GetUser(username string) (User, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	user, ok := s.Users[username]
+	if !ok {
+		return User{}, fmt.Errorf("user %q %w", username, ErrNotFound)
+	}
+	return *user, nil
+}
+
+// CreateUser adds a user, defaulting to the member role
+func (s *Store) CreateUser(user User) (User, error) {
+	if user.Username == "" {
+		return User{}, fmt.Errorf("username cannot be empty")
+	}
+	if user.Role == "" {
+		user.Role = RoleMember
+	}
+	if !validRole(user.Role) {
+		return User{}, fmt.Errorf("invalid role %q", user.Role)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.Users[user.Username]; exists {
+		return User{}, fmt.Errorf("user %q already exists", user.Username)
+	}
+
+	user.CreatedAt = time.Now()
+	s.Users[user.Username] = &user
+	return user, s.save()
+}
+
+// ensureUser returns the user, creating a member account on first login
+func (s *Store) ensureUser(username string) (User, error) {
+	if user, err := s.GetUser(username); err == nil {
+		return user, nil
+	}
+	user, err := s.CreateUser(User{Username: username})
+	if err != nil {
+		// Lost a race with a concurrent login
+		return s.GetUser(username)
+	}
+	return user, nil
+}
+
+// UpdateUser changes the role and quotas of a user. Nil fields are left unchanged.
+func (s *Store) UpdateUser(username string, role *string, quotas *Quotas) (User, error) {
+	if role != nil && !validRole(*role) {
+		return User{}, fmt.Errorf("invalid role %q", *role)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.Users[username]
+	if !ok {
+		return User{}, fmt.Errorf("user %q %w", username, ErrNotFound)
+	}
+	if role != nil {
+		user.Role = *role
+	}
+	if quotas != nil {
+		user.Quotas = *quotas
+	}
+	return *user, s.save()
+}
+
+// DeleteUser removes a user and revokes all of their tokens
+func (s *Store) DeleteUser(username string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Users[username]; !ok {
+		return fmt.Errorf("user %q %w", username, ErrNotFound)
+	}
+
+	delete(s.Users, username)
+	now := time.Now()
+	for _, token := range s.Tokens {
+		if token.User == username && token.RevokedAt == nil {
+			token.RevokedAt = &now
+		}
+	}
+	return s.save()
+}
+
+// ListTokens returns the tokens of a user, or of all users when username is empty, newest first
+func (s *Store) ListTokens(username string) []Token {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	tokens := []Token{}
+	for _, token := range s.Tokens {
+		if username == "" || token.User == username {
+			listed := *token
+			listed.Hash = ""
+			tokens = append(tokens, listed)
+		}
+	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.After(tokens[j].CreatedAt) })
+	return tokens
+}
+
+// CreateToken issues a token for an existing user and returns its secret, which is not stored.
+// The scope limits what the token can do; empty is the full scope.
+func (s *Store) CreateToken(username string, name string, scope string) (Token, string, error) {
+	if !validScope(scope) {
+		return Token{}, "", fmt.Errorf("invalid scope %q, expected %s, %s or %s", scope, ScopeFull, ScopeRecorder, ScopeReader)
+	}
+	if scope == ScopeFull {
+		scope = ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.Users[username]; !ok {
+		return Token{}, "", fmt.Errorf("user %q %w", username, ErrNotFound)
+	}
+
+	secret := "tr_" + randomToken()
+	token := &Token{
+		Id:        uuid.NewString(),
+		Name:      name,
+		User:      username,
+		Scope:     scope,
+		Hash:      hashToken(secret),
+		CreatedAt: time.Now(),
+	}
+	s.Tokens[token.Id] = token
+	if err := s.save(); err != nil {
+		delete(s.Tokens, token.Id)
+		return Token{}, "", err
+	}
+
+	issued := *token
+	issued.Hash = ""
+	return issued, secret, nil
+}
+
+// RevokeToken revokes a token by ID
+func (s *Store) RevokeToken(id string) (Token, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	token, ok := s.Tokens[id]
+	if !ok {
+		return Token{}, fmt.Errorf("token %q %w", id, ErrNotFound)
+	}
+	if token.RevokedAt == nil {
+		now := time.Now()
+		token.RevokedAt = &now
+		if err := s.save(); err != nil {
+			return Token{}, err
+		}
+	}
+
+	revoked := *token
+	revoked.Hash = ""
+	return revoked, nil
+}
+
+// lookupToken returns the user and scope of an active token secret
+func (s *Store) lookupToken(secret string) (User, string, bool) {
+	hash := hashToken(secret)
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, token := range s.Tokens {
+		if token.Hash == hash && token.RevokedAt == nil {
+			user, ok := s.Users[token.User]
+			if !ok {
+				return User{}, "", false
+			}
+			return *user, token.Scope, true
+		}
+	}
+	return User{}, "", false
+}
@@ -0,0 +1,265 @@
+// Package calendar reads an iCalendar feed, such as the ICS export of a CalDAV, Google or
+// Outlook calendar, so recordings can be titled after and started for calendar events
+package calendar
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+)
+
+// Event is an occurrence of a calendar event
+type Event struct {
+	UID         string    `json:"uid"`
+	Summary     string    `json:"summary"`
+	Description string    `json:"description,omitempty"`
+	Location    string    `json:"location,omitempty"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	AllDay      bool      `json:"all_day"`
+	Attendees   []string  `json:"attendees,omitempty"` // Names, or email addresses of attendees without one
+	Organizer   string    `json:"organizer,omitempty"`
+	Categories  []string  `json:"categories,omitempty"`
+	Cancelled   bool      `json:"-"`
+}
+
+// HasTag reports whether the tag, e.g. #record, appears in the summary, description or
+// categories of the event, ignoring case
+func (e Event) HasTag(tag string) bool {
+	if tag == "" {
+		return false
+	}
+	tag = strings.ToLower(tag)
+	if strings.Contains(strings.ToLower(e.Summary), tag) || strings.Contains(strings.ToLower(e.Description), tag) {
+		return true
+	}
+	return slices.ContainsFunc(e.Categories, func(category string) bool {
+		return strings.EqualFold(category, strings.TrimPrefix(tag, "#"))
+	})
+}
+
+// Title returns the summary without the tag
+func (e Event) Title(tag string) string {
+	if tag == "" {
+		return strings.TrimSpace(e.Summary)
+	}
+	index := strings.Index(strings.ToLower(e.Summary), strings.ToLower(tag))
+	if index < 0 {
+		return strings.TrimSpace(e.Summary)
+	}
+	return strings.Join(strings.Fields(e.Summary[:index]+e.Summary[index+len(tag):]), " ")
+}
+
+// maxOccurrences bounds the expansion of a single recurring event
+const maxOccurrences = 5000
+
+// Between returns the occurrences overlapping the period, ordered by start. Cancelled
+// events are left out.
+func (c *Calendar) Between(from, to time.Time) []Event {
+	// Overrides replace the occurrence of the recurring event they were moved from
+	overridden := make(map[string]map[int64]bool)
+	for _, e := range c.entries {
+		if !e.recurrenceID.IsZero() {
+			if overridden[e.UID] == nil {
+				overridden[e.UID] = make(map[int64]bool)
+			}
+			overridden[e.UID][e.recurrenceID.Unix()] = true
+		}
+	}
+
+	var events []Event
+	for _, e := range c.entries {
+		for _, occurrence := range e.occurrences(to) {
+			if !e.recurrenceID.IsZero() || !overridden[e.UID][occurrence.Start.Unix()] {
+				if !occurrence.Cancelled && occurrence.End.After(from) && occurrence.Start.Before(to) {
+					events = append(events, occurrence)
+				}
+			}
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Start.Before(events[j].Start) })
+	return events
+}
+
+// occurrences expands the event up to the end of the period
+func (e entry) occurrences(to time.Time) []Event {
+	freq := e.rrule["FREQ"]
+	if freq != "DAILY" && freq != "WEEKLY" && freq != "MONTHLY" {
+		return []Event{e.Event}
+	}
+
+	interval, err := strconv.Atoi(e.rrule["INTERVAL"])
+	if err != nil || interval < 1 {
+		interval = 1
+	}
+	count, _ := strconv.Atoi(e.rrule["COUNT"])
+	var until time.Time
+	if value := e.rrule["UNTIL"]; value != "" {
+		until, _, _ = parseTime(property{value: value})
+		if len(value) == 8 {
+			until = until.AddDate(0, 0, 1) // A date includes the whole day
+		}
+	}
+	duration := e.End.Sub(e.Start)
+
+	// Candidate starts of a period of the rule, e.g. the BYDAY days of a week
+	weekdays := byDay(e.rrule["BYDAY"])
+	period := func(n int) []time.Time {
+		switch freq {
+		case "DAILY":
+			return []time.Time{e.Start.AddDate(0, 0, n*interval)}
+		case "MONTHLY":
+			return []time.Time{e.Start.AddDate(0, n*interval, 0)}
+		}
+		if len(weekdays) == 0 {
+			return []time.Time{e.Start.AddDate(0, 0, 7*n*interval)}
+		}
+		// Days of the week starting on the Monday of the start's week
+		monday := e.Start.AddDate(0, 0, -((int(e.Start.Weekday())+6)%7)+7*n*interval)
+		starts := make([]time.Time, 0, len(weekdays))
+		for _, weekday := range weekdays {
+			starts = append(starts, monday.AddDate(0, 0, (int(weekday)+6)%7))
+		}
+		return starts
+	}
+
+	var occurrences []Event
+	generated := 0
+	for n := 0; generated < maxOccurrences; n++ {
+		for _, start := range period(n) {
+			if start.Before(e.Start) {
+				continue
+			}
+			if start.After(to) || (!until.IsZero() && !start.Before(until)) || (count > 0 && generated >= count) {
+				return occurrences
+			}
+			generated++
+			if e.exdates[start.Unix()] {
+				continue
+			}
+			occurrence := e.Event
+			occurrence.Start = start
+			occurrence.End = start.Add(duration)
+			occurrences = append(occurrences, occurrence)
+		}
+	}
+	return occurrences
+}
+
+// byDay parses the weekdays of a BYDAY rule, ignoring ordinal prefixes
+func byDay(value string) []time.Weekday {
+	names := map[string]time.Weekday{"SU": time.Sunday, "MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday, "FR": time.Friday, "SA": time.Saturday}
+	var weekdays []time.Weekday
+	for _, day := range strings.Split(value, ",") {
+		if len(day) >= 2 {
+			if weekday, ok := names[day[len(day)-2:]]; ok {
+				weekdays = append(weekdays, weekday)
+			}
+		}
+	}
+	sort.Slice(weekdays, func(i, j int) bool { return (weekdays[i]+6)%7 < (weekdays[j]+6)%7 })
+	return weekdays
+}
+
+// Feed fetches the calendar and caches it between refreshes
+type Feed struct {
+	mu        sync.RWMutex
+	cfg       config.CalendarConfig
+	client    *http.Client
+	calendar  *Calendar
+	fetchedAt time.Time
+}
+
+// NewFeed returns a feed for the configured URL or file
+func NewFeed(cfg config.CalendarConfig) *Feed {
+	return &Feed{cfg: cfg, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+// Refresh fetches and parses the calendar
+func (f *Feed) Refresh() error {
+	data, err := f.fetch()
+	if err != nil {
+		return err
+	}
+	cal, err := Parse(data)
+	if err != nil {
+		return fmt.Errorf("failed to parse calendar: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calendar = cal
+	f.fetchedAt = time.Now()
+	return nil
+}
+
+// fetch reads the feed from its URL, webcal:// being fetched over https, or from a file
+func (f *Feed) fetch() ([]byte, error) {
+	url := f.cfg.URL
+	if rest, ok := strings.CutPrefix(url, "webcal://"); ok {
+		url = "https://" + rest
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return os.ReadFile(url)
+	}
+
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if f.cfg.Username != "" {
+		req.SetBasicAuth(f.cfg.Username, f.cfg.Password)
+	}
+	resp, err := f.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch calendar: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch calendar: %s", resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// Between returns the events overlapping the period from the last fetched calendar
+func (f *Feed) Between(from, to time.Time) []Event {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	if f.calendar == nil {
+		return nil
+	}
+	return f.calendar.Between(from, to)
+}
+
+// FetchedAt returns when the calendar was last fetched
+func (f *Feed) FetchedAt() time.Time {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.fetchedAt
+}
+
+// Current returns the timed event happening at the moment, preferring the one that started
+// last when events overlap
+func (f *Feed) Current(now time.Time) (Event, bool) {
+	var current Event
+	found := false
+	for _, event := range f.Between(now, now.Add(time.Second)) {
+		if event.AllDay || event.Start.After(now) {
+			continue
+		}
+		if !found || event.Start.After(current.Start) {
+			current = event
+			found = true
+		}
+	}
+	return current, found
+}
@@ -0,0 +1,246 @@
+package calendar
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// property is a content line of an iCalendar file, e.g. DTSTART;TZID=Europe/Amsterdam:20240501T090000
+type property struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+// entry is a parsed VEVENT with its recurrence, which Between expands into occurrences
+type entry struct {
+	Event
+	rrule        map[string]string
+	exdates      map[int64]bool // Unix times of excluded occurrences
+	recurrenceID time.Time      // Set on an override of one occurrence of a recurring event
+}
+
+// Calendar is a parsed iCalendar feed
+type Calendar struct {
+	entries []entry
+}
+
+// Parse reads the events of an iCalendar (RFC 5545) feed. Recurring events support daily,
+// weekly and monthly rules with INTERVAL, COUNT, UNTIL, weekly BYDAY and EXDATE.
+func Parse(data []byte) (*Calendar, error) {
+	lines, err := unfold(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(lines) == 0 || !strings.EqualFold(strings.TrimSpace(lines[0]), "BEGIN:VCALENDAR") {
+		return nil, fmt.Errorf("not an iCalendar feed")
+	}
+
+	cal := &Calendar{}
+	var current *entry
+	depth := 0 // Nesting inside the event, e.g. VALARM
+	for _, line := range lines {
+		prop, ok := parseProperty(line)
+		if !ok {
+			continue
+		}
+		switch {
+		case prop.name == "BEGIN" && strings.EqualFold(prop.value, "VEVENT"):
+			current = &entry{exdates: make(map[int64]bool)}
+			depth = 0
+		case current == nil:
+			continue
+		case prop.name == "BEGIN":
+			depth++
+		case prop.name == "END" && depth > 0:
+			depth--
+		case prop.name == "END" && strings.EqualFold(prop.value, "VEVENT"):
+			if err := current.finish(); err == nil {
+				cal.entries = append(cal.entries, *current)
+			}
+			current = nil
+		case depth == 0:
+			current.apply(prop)
+		}
+	}
+	return cal, nil
+}
+
+// unfold joins the continuation lines of the feed
+func unfold(data []byte) ([]string, error) {
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return lines, scanner.Err()
+}
+
+// parseProperty splits a content line into its name, parameters and value
+func parseProperty(line string) (property, bool) {
+	quoted := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			quoted = !quoted
+		} else if r == ':' && !quoted {
+			colon = i
+			break
+		}
+	}
+	if colon < 0 {
+		return property{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := property{
+		name:   strings.ToUpper(parts[0]),
+		params: make(map[string]string),
+		value:  line[colon+1:],
+	}
+	for _, param := range parts[1:] {
+		key, value, _ := strings.Cut(param, "=")
+		prop.params[strings.ToUpper(key)] = strings.Trim(value, `"`)
+	}
+	return prop, true
+}
+
+// apply sets the event field of a property
+func (e *entry) apply(prop property) {
+	switch prop.name {
+	case "UID":
+		e.UID = prop.value
+	case "SUMMARY":
+		e.Summary = unescape(prop.value)
+	case "DESCRIPTION":
+		e.Description = unescape(prop.value)
+	case "LOCATION":
+		e.Location = unescape(prop.value)
+	case "CATEGORIES":
+		for _, category := range strings.Split(prop.value, ",") {
+			if category = strings.TrimSpace(unescape(category)); category != "" {
+				e.Categories = append(e.Categories, category)
+			}
+		}
+	case "DTSTART":
+		e.Start, e.AllDay, _ = parseTime(prop)
+	case "DTEND":
+		e.End, _, _ = parseTime(prop)
+	case "DURATION":
+		if duration, err := parseDuration(prop.value); err == nil && !e.Start.IsZero() {
+			e.End = e.Start.Add(duration)
+		}
+	case "ATTENDEE":
+		e.Attendees = append(e.Attendees, personName(prop))
+	case "ORGANIZER":
+		e.Organizer = personName(prop)
+	case "RRULE":
+		e.rrule = make(map[string]string)
+		for _, part := range strings.Split(prop.value, ";") {
+			key, value, _ := strings.Cut(part, "=")
+			e.rrule[strings.ToUpper(key)] = strings.ToUpper(value)
+		}
+	case "EXDATE":
+		for _, value := range strings.Split(prop.value, ",") {
+			if t, _, err := parseTime(property{params: prop.params, value: value}); err == nil {
+				e.exdates[t.Unix()] = true
+			}
+		}
+	case "RECURRENCE-ID":
+		e.recurrenceID, _, _ = parseTime(prop)
+	case "STATUS":
+		e.Cancelled = strings.EqualFold(prop.value, "CANCELLED")
+	}
+}
+
+// finish fills in a missing end and rejects events without a start
+func (e *entry) finish() error {
+	if e.Start.IsZero() {
+		return fmt.Errorf("event %q has no start", e.UID)
+	}
+	if e.End.IsZero() || e.End.Before(e.Start) {
+		if e.AllDay {
+			e.End = e.Start.AddDate(0, 0, 1)
+		} else {
+			e.End = e.Start.Add(time.Hour)
+		}
+	}
+	return nil
+}
+
+// parseTime parses a DATE or DATE-TIME value in UTC, its TZID or local time. Time zones
+// Go doesn't know, such as Windows names, fall back to local time.
+func parseTime(prop property) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.value)
+	if prop.params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+	location := time.Local
+	if tzid := prop.params["TZID"]; tzid != "" {
+		if loaded, err := time.LoadLocation(tzid); err == nil {
+			location = loaded
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, location)
+	return t, false, err
+}
+
+// parseDuration parses the weeks, days, hours, minutes and seconds of an iCalendar duration, e.g. PT1H30M
+func parseDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.ToUpper(value), "+")
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("invalid duration %q", value)
+	}
+	units := map[byte]time.Duration{'W': 7 * 24 * time.Hour, 'D': 24 * time.Hour, 'H': time.Hour, 'M': time.Minute, 'S': time.Second}
+	var total time.Duration
+	number := ""
+	for i := 1; i < len(value); i++ {
+		c := value[i]
+		switch {
+		case c == 'T':
+		case c >= '0' && c <= '9':
+			number += string(c)
+		default:
+			n, err := strconv.Atoi(number)
+			unit, ok := units[c]
+			if err != nil || !ok {
+				return 0, fmt.Errorf("invalid duration %q", value)
+			}
+			total += time.Duration(n) * unit
+			number = ""
+		}
+	}
+	return total, nil
+}
+
+// personName returns the common name of an attendee or organizer, or their email address
+func personName(prop property) string {
+	if name := prop.params["CN"]; name != "" {
+		return name
+	}
+	value := prop.value
+	if len(value) > 7 && strings.EqualFold(value[:7], "mailto:") {
+		value = value[7:]
+	}
+	return value
+}
+
+// unescape decodes the escaped characters of a TEXT value
+func unescape(value string) string {
+	return strings.NewReplacer(`\n`, "\n", `\N`, "\n", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(value)
+}
@@ -0,0 +1,365 @@
+// Package chapters splits long transcripts into chapters at topic shifts and renders them
+// as collapsible sections of the meeting note
+package chapters
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Segmentation methods: keywords compares the words used before and after a pause,
+// embeddings compares their meaning
+const (
+	SegmentationKeywords   = "keywords"
+	SegmentationEmbeddings = "embeddings"
+)
+
+// Note transcript styles
+const (
+	StyleFlat     = "flat"     // One line per segment, no chapters
+	StyleHeadings = "headings" // A heading per chapter
+	StyleDetails  = "details"  // A collapsed <details> block per chapter
+	StyleCallout  = "callout"  // A folded Obsidian callout per chapter
+)
+
+// flatLines renders the transcript without chapters; every style falls back to it for
+// transcripts that weren't split
+const flatLines = `{{range .Lines}}{{.}}
+{{end}}`
+
+var styles = map[string]string{
+	StyleFlat: flatLines,
+	StyleHeadings: `{{if .Chapters}}{{range .Chapters}}### {{.Start}} {{.Title}}
+
+{{range .Lines}}{{.}}
+{{end}}
+{{end}}{{else}}` + flatLines + `{{end}}`,
+	StyleDetails: `{{if .Chapters}}{{range .Chapters}}<details>
+<summary>{{.Start}} {{.Title}}</summary>
+
+{{range .Lines}}{{.}}
+{{end}}
+</details>
+
+{{end}}{{else}}` + flatLines + `{{end}}`,
+	StyleCallout: `{{if .Chapters}}{{range .Chapters}}> [!note]- {{.Start}} {{.Title}}
+{{range .Lines}}> {{.}}
+{{end}}
+{{end}}{{else}}` + flatLines + `{{end}}`,
+}
+
+// Data is what transcript templates can refer to
+type Data struct {
+	Chapters []ChapterData // Empty when the transcript wasn't split
+	Lines    []string      // Every line of the transcript
+}
+
+// ChapterData is a chapter as seen by transcript templates
+type ChapterData struct {
+	Title string
+	Start string // 00:12:30
+	End   string
+	Lines []string
+}
+
+// Renderer renders transcripts with the configured style or template
+type Renderer struct {
+	template *template.Template
+}
+
+// New parses the transcript template of the config, or the template of its style
+func New(cfg config.TranscriptConfig) (*Renderer, error) {
+	if cfg.Segmentation != "" && cfg.Segmentation != SegmentationKeywords && cfg.Segmentation != SegmentationEmbeddings {
+		return nil, fmt.Errorf("unknown segmentation %q, use %s or %s", cfg.Segmentation, SegmentationKeywords, SegmentationEmbeddings)
+	}
+	text := cfg.Template
+	if text == "" {
+		style, ok := styles[cfg.Style]
+		if !ok {
+			return nil, fmt.Errorf("unknown transcript style %q, use %s, %s, %s or %s", cfg.Style, StyleFlat, StyleHeadings, StyleDetails, StyleCallout)
+		}
+		text = style
+	}
+	tmpl, err := template.New("transcript").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid transcript template: %w", err)
+	}
+	r := &Renderer{template: tmpl}
+
+	// Catch references to unknown fields now rather than when a meeting is saved
+	sample := []types.Segment{{Start: 0, End: 1, Text: "sample"}}
+	if _, err := r.Render(sample, []types.Chapter{{Title: "Sample", End: 1}}, func(s types.Segment) string { return s.Text }); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Render renders the segments, grouped by the chapters when there are any. line formats a
+// single segment.
+func (r *Renderer) Render(segments []types.Segment, chapters []types.Chapter, line func(types.Segment) string) (string, error) {
+	data := Data{Lines: make([]string, 0, len(segments))}
+	for _, segment := range segments {
+		data.Lines = append(data.Lines, line(segment))
+	}
+
+	ranges := Ranges(segments, chapters)
+	for i, chapter := range chapters {
+		data.Chapters = append(data.Chapters, ChapterData{
+			Title: chapter.Title,
+			Start: clock(chapter.Start),
+			End:   clock(chapter.End),
+			Lines: data.Lines[ranges[i][0]:ranges[i][1]],
+		})
+	}
+
+	var buf bytes.Buffer
+	if err := r.template.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render transcript: %w", err)
+	}
+	return buf.String(), nil
+}
+
+// Ranges returns the segments of every chapter as [first, end) indices. Segments belong to
+// the chapter they start in; the last chapter takes the rest.
+func Ranges(segments []types.Segment, chapters []types.Chapter) [][2]int {
+	ranges := make([][2]int, len(chapters))
+	next := 0
+	for i, chapter := range chapters {
+		first := next
+		for next < len(segments) && (i == len(chapters)-1 || segments[next].Start < chapter.End) {
+			next++
+		}
+		ranges[i] = [2]int{first, next}
+	}
+	return ranges
+}
+
+// Segmentation parameters
+const (
+	windowSegments = 10 // Segments compared on either side of a candidate boundary
+	titleKeywords  = 3
+)
+
+// Split divides the segments into chapters at the largest topic shifts, found by comparing
+// the words used before and after every gap between segments (TextTiling). Chapters are at
+// least minLength seconds long. Titles are the chapters' most frequent keywords.
+func Split(segments []types.Segment, minLength float64) []types.Chapter {
+	if len(segments) < 2*windowSegments {
+		return nil
+	}
+
+	words := make([]map[string]int, len(segments))
+	for i, segment := range segments {
+		words[i] = CountWords(segment.Text)
+	}
+
+	// Similarity of the windows around the gap after every segment
+	similarity := make([]float64, len(segments)-1)
+	for gap := range similarity {
+		before := sumCounts(words[max(0, gap+1-windowSegments) : gap+1])
+		after := sumCounts(words[gap+1 : min(len(words), gap+1+windowSegments)])
+		similarity[gap] = cosine(before, after)
+	}
+	return splitAt(segments, similarity, minLength)
+}
+
+// SplitEmbedded divides the segments into chapters like Split, but compares the summed
+// embeddings of the segments before and after every gap, one vector per segment, so topic
+// shifts are found when the topics share words and stay together when a topic is discussed
+// in other words.
+func SplitEmbedded(segments []types.Segment, vectors [][]float64, minLength float64) []types.Chapter {
+	if len(segments) < 2*windowSegments || len(vectors) != len(segments) {
+		return nil
+	}
+
+	similarity := make([]float64, len(segments)-1)
+	for gap := range similarity {
+		before := sumVectors(vectors[max(0, gap+1-windowSegments) : gap+1])
+		after := sumVectors(vectors[gap+1 : min(len(vectors), gap+1+windowSegments)])
+		similarity[gap] = cosineVectors(before, after)
+	}
+	return splitAt(segments, similarity, minLength)
+}
+
+// splitAt divides the segments into chapters at the gaps where the similarity of the
+// windows around them drops deepest
+func splitAt(segments []types.Segment, similarity []float64, minLength float64) []types.Chapter {
+
+	// Depth of every gap: how far the similarity drops below the peaks on either side
+	type candidate struct {
+		gap   int
+		depth float64
+	}
+	candidates := make([]candidate, 0, len(similarity))
+	var sum, sumSquares float64
+	for gap, value := range similarity {
+		left, right := value, value
+		for i := gap - 1; i >= 0 && similarity[i] >= left; i-- {
+			left = similarity[i]
+		}
+		for i := gap + 1; i < len(similarity) && similarity[i] >= right; i++ {
+			right = similarity[i]
+		}
+		depth := (left - value) + (right - value)
+		candidates = append(candidates, candidate{gap: gap, depth: depth})
+		sum += depth
+		sumSquares += depth * depth
+	}
+	mean := sum / float64(len(candidates))
+	cutoff := mean + math.Sqrt(math.Max(0, sumSquares/float64(len(candidates))-mean*mean))/2
+
+	// Take the deepest gaps first, keeping every chapter at least minLength long
+	sort.Slice(candidates, func(i, j int) bool { return candidates[i].depth > candidates[j].depth })
+	start, end := segments[0].Start, segments[len(segments)-1].End
+	var boundaries []int
+	for _, c := range candidates {
+		if c.depth <= cutoff || c.depth == 0 {
+			break
+		}
+		at := segments[c.gap+1].Start
+		if at-start < minLength || end-at < minLength {
+			continue
+		}
+		tooClose := false
+		for _, b := range boundaries {
+			if math.Abs(segments[b+1].Start-at) < minLength {
+				tooClose = true
+				break
+			}
+		}
+		if !tooClose {
+			boundaries = append(boundaries, c.gap)
+		}
+	}
+	if len(boundaries) == 0 {
+		return nil
+	}
+	sort.Ints(boundaries)
+
+	chapters := make([]types.Chapter, 0, len(boundaries)+1)
+	first := 0
+	for _, last := range append(boundaries, len(segments)-1) {
+		chapterSegments := segments[first : last+1]
+		chapters = append(chapters, types.Chapter{
+			Title: title(Keywords(chapterSegments, titleKeywords)),
+			Start: chapterSegments[0].Start,
+			End:   chapterSegments[len(chapterSegments)-1].End,
+		})
+		first = last + 1
+	}
+	return chapters
+}
+
+// Keywords returns the most frequent content words of the segments
+func Keywords(segments []types.Segment, n int) []string {
+	counts := make(map[string]int)
+	for _, segment := range segments {
+		for word, count := range CountWords(segment.Text) {
+			counts[word] += count
+		}
+	}
+	keywords := make([]string, 0, len(counts))
+	for word := range counts {
+		keywords = append(keywords, word)
+	}
+	sort.Slice(keywords, func(i, j int) bool {
+		if counts[keywords[i]] != counts[keywords[j]] {
+			return counts[keywords[i]] > counts[keywords[j]]
+		}
+		return keywords[i] < keywords[j]
+	})
+	return keywords[:min(n, len(keywords))]
+}
+
+// title joins keywords into a chapter title
+func title(keywords []string) string {
+	if len(keywords) == 0 {
+		return "Discussion"
+	}
+	joined := strings.Join(keywords, ", ")
+	return strings.ToUpper(joined[:1]) + joined[1:]
+}
+
+// CountWords counts the content words of a text, ignoring case and stopwords
+func CountWords(text string) map[string]int {
+	counts := make(map[string]int)
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && r != '\''
+	}) {
+		word = strings.Trim(word, "'")
+		if len([]rune(word)) < 3 || stopwords[word] {
+			continue
+		}
+		counts[word]++
+	}
+	return counts
+}
+
+// sumCounts adds up word counts
+func sumCounts(counts []map[string]int) map[string]int {
+	total := make(map[string]int)
+	for _, c := range counts {
+		for word, n := range c {
+			total[word] += n
+		}
+	}
+	return total
+}
+
+// sumVectors adds up embeddings
+func sumVectors(vectors [][]float64) []float64 {
+	var total []float64
+	for _, vector := range vectors {
+		if total == nil {
+			total = make([]float64, len(vector))
+		}
+		for i := range min(len(total), len(vector)) {
+			total[i] += vector[i]
+		}
+	}
+	return total
+}
+
+// cosineVectors returns the cosine similarity of two embeddings
+func cosineVectors(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range min(len(a), len(b)) {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// cosine returns the cosine similarity of two word counts
+func cosine(a, b map[string]int) float64 {
+	var dot, normA, normB float64
+	for word, n := range a {
+		normA += float64(n * n)
+		dot += float64(n * b[word])
+	}
+	for _, n := range b {
+		normB += float64(n * n)
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / math.Sqrt(normA*normB)
+}
+
+// clock formats seconds as HH:MM:SS
+func clock(seconds float64) string {
+	s := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
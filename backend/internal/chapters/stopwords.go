@@ -0,0 +1,32 @@
+package chapters
+
+import "strings"
+
+// stopwords are left out of topic comparisons and titles: common English and Dutch words,
+// and the filler words of spoken language
+var stopwords = func() map[string]bool {
+	words := strings.Fields(`
+		about above after again against all also and any are aren't because been before being
+		below between both but can can't cannot could couldn't did didn't does doesn't doing don't
+		down during each few for from further get gets getting going gonna got had hadn't has hasn't
+		have haven't having her here hers herself him himself his how i'd i'll i'm i've into isn't
+		it's its itself just know let's like maybe mean more most much mustn't myself nor not now
+		off okay once only other ought our ours ourselves out over own really right same say see
+		shan't she she'd she'll she's should shouldn't some something such than that that's the
+		their theirs them themselves then there there's these they they'd they'll they're they've
+		thing things think this those through too under until very want was wasn't way we'd we'll
+		we're we've well were weren't what what's when when's where where's which while who who's
+		whom why why's will with won't would wouldn't yeah yes you you'd you'll you're you've your
+		yours yourself yourselves actually basically kind sort stuff
+
+		aan als bij dan dat die dit doen door een eens geen had heb hebben heeft hem het hier hij
+		hoe hun iets ik jij jou jouw kan kon maar meer men met mij mijn moet naar niet niets nog
+		nou dus ook over tot uit van veel voor wat wel werd wie wij wil worden zal zei zich zij
+		zijn zo zou gewoon eigenlijk even echt heel weet ja nee oke
+	`)
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}()
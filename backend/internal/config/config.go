@@ -0,0 +1,1086 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// Config holds the runtime configuration of the backend
+type Config struct {
+	Server        ServerConfig        `json:"server"`
+	CORS          CORSConfig          `json:"cors"`
+	Processing    ProcessingConfig    `json:"processing"`
+	ABTest        ABTestConfig        `json:"ab_test"`
+	Transcription TranscriptionConfig `json:"transcription"`
+	Audio         AudioConfig         `json:"audio"`
+	Ollama        OllamaConfig        `json:"ollama"`
+	CRM           CRMConfig           `json:"crm"`
+	Publish       PublishConfig       `json:"publish"`
+	Auth          AuthConfig          `json:"auth"`
+	Storage       StorageConfig       `json:"storage"`
+	People        PeopleConfig        `json:"people"`
+	Naming        NamingConfig        `json:"naming"`
+	Frontmatter   []FrontmatterField  `json:"frontmatter"` // Frontmatter of meeting notes, in order
+	Vault         VaultConfig         `json:"vault"`
+	Calendar      CalendarConfig      `json:"calendar"`
+	MeetingApps   MeetingAppsConfig   `json:"meeting_apps"`
+	Watch         WatchConfig         `json:"watch"`
+	Download      DownloadConfig      `json:"download"`
+	VoiceMemos    VoiceMemosConfig    `json:"voice_memos"`
+	Update        UpdateConfig        `json:"update"`
+	Setup         SetupConfig         `json:"setup"`
+	Cleanup       CleanupConfig       `json:"cleanup"`
+	Log           LogConfig           `json:"log"`
+	Hooks         HooksConfig         `json:"hooks"`
+	Presets       map[string]Preset   `json:"presets"` // Meeting types selectable when starting a recording
+	DevMode       bool                `json:"-"`       // Simulate recording and transcription, set with --dev-mode
+}
+
+// Preset bundles the defaults of a meeting type
+type Preset struct {
+	Template     string   `json:"template"`     // Summarization template
+	VaultFolder  string   `json:"vault_folder"` // Folder inside the vault, relative to the vault root
+	Participants []string `json:"participants"` // Added to the participants of the meeting
+	Tags         []string `json:"tags"`         // Added to the tags of the meeting
+	Keywords     []string `json:"keywords"`     // Title keywords that select the preset when detecting meeting types
+	Stages       []string `json:"stages"`       // Processing stages of the meeting type, empty uses processing.stages
+}
+
+// CalendarConfig reads an iCalendar feed, used to title recordings started without a title
+// after the event happening now and to record tagged events automatically
+type CalendarConfig struct {
+	URL            string `json:"url"`      // https:// or webcal:// feed, or a local .ics file; empty disables the calendar
+	Username       string `json:"username"` // Basic auth, e.g. for the export URL of a CalDAV calendar
+	Password       string `json:"password"`
+	RefreshMinutes int    `json:"refresh_minutes"`
+	AutoStart      bool   `json:"auto_start"` // Record events carrying the tag from their start to their end
+	Tag            string `json:"tag"`
+}
+
+// MeetingAppsConfig watches for calls in meeting apps such as Zoom, Teams and Google Meet,
+// to prompt for or start a recording as soon as a call begins
+type MeetingAppsConfig struct {
+	Enabled   bool `json:"enabled"`
+	Interval  int  `json:"interval"`   // Seconds between checks
+	Notify    bool `json:"notify"`     // Show a notification when a call starts without a recording
+	AutoStart bool `json:"auto_start"` // Record calls from their start until the app leaves the call
+}
+
+// WatchConfig processes audio files dropped into a folder through the full pipeline, titled
+// after the file name
+type WatchConfig struct {
+	Folder     string   `json:"folder"`     // Watched folder, empty disables watching
+	Interval   int      `json:"interval"`   // Seconds between scans
+	Extensions []string `json:"extensions"` // Audio files picked up, other files are left alone
+	Type       string   `json:"type"`       // Meeting preset of the picked up meetings
+	Tags       []string `json:"tags"`       // Added to the picked up meetings
+}
+
+// DownloadConfig controls downloading recordings from URLs to transcribe them, such as
+// webinars and podcast episodes
+type DownloadConfig struct {
+	YtDlp          string `json:"yt_dlp"`          // yt-dlp command used for pages of video platforms
+	MaxMB          int    `json:"max_mb"`          // Larger downloads are aborted
+	TimeoutMinutes int    `json:"timeout_minutes"` // 0 disables the timeout
+}
+
+// VoiceMemosConfig locates the Apple Voice Memos recordings that can be imported
+type VoiceMemosConfig struct {
+	Folder string `json:"folder"` // Folder of exported memos, empty uses the storage location of the Voice Memos app
+}
+
+// UpdateConfig checks GitHub releases for newer versions of the transcriber
+type UpdateConfig struct {
+	Enabled    bool   `json:"enabled"`
+	Channel    string `json:"channel"`    // stable, or prerelease to include prereleases
+	Repository string `json:"repository"` // GitHub owner/name the releases are published in
+	CheckHours int    `json:"check_hours"`
+	Notify     bool   `json:"notify"`     // Show a notification when a newer version is found
+	PublicKey  string `json:"public_key"` // Base64 Ed25519 key release checksums are signed with, overriding the built-in one
+}
+
+// SetupConfig records the progress of the first-run setup
+type SetupConfig struct {
+	CompletedSteps []string `json:"completed_steps"`
+}
+
+// CleanupConfig removes what failed runs leave behind: stale temp directories and child
+// processes such as ffmpeg that outlived a crash
+type CleanupConfig struct {
+	IntervalMinutes int    `json:"interval_minutes"`   // Minutes between sweeps of stale temp directories, 0 only sweeps on startup
+	TempMaxAgeHours int    `json:"temp_max_age_hours"` // Temp directories unchanged for longer are stale
+	ProcessesFile   string `json:"processes_file"`     // PIDs of running child processes, killed on the next start after a crash
+}
+
+// HooksConfig runs shell commands when a meeting reaches a stage of its lifecycle, e.g. to
+// push notes to a git repository. Empty commands are skipped.
+type HooksConfig struct {
+	OnRecordingStopped string `json:"on_recording_stopped"`
+	OnTranscriptReady  string `json:"on_transcript_ready"`
+	OnSummaryReady     string `json:"on_summary_ready"`
+	TimeoutSeconds     int    `json:"timeout_seconds"` // Hooks running longer are killed, 0 doesn't limit them
+}
+
+// Log outputs
+const (
+	LogOutputStdout = "stdout"
+	LogOutputFile   = "file"
+)
+
+// LogConfig controls the level and destination of the server log
+type LogConfig struct {
+	Level      string `json:"level"`       // debug, info, warn or error, changed at runtime through PUT /admin/log-level
+	Output     string `json:"output"`      // stdout or file
+	File       string `json:"file"`        // Log file written when output is file
+	MaxSizeMB  int    `json:"max_size_mb"` // The log file is rotated once it grows past this size, 0 never rotates
+	MaxBackups int    `json:"max_backups"` // Rotated log files kept next to it, 0 drops the log file on rotation
+}
+
+// StorageConfig controls where meetings are persisted
+type StorageConfig struct {
+	EventsFile      string `json:"events_file"`      // Append-only log of meeting events the meetings are rebuilt from
+	RecordingsDir   string `json:"recordings_dir"`   // Recordings being captured or processed, kept across restarts
+	ArtifactsDir    string `json:"artifacts_dir"`    // Working directories of the pipeline, such as whisper output and downloads
+	MinFreeMB       int    `json:"min_free_mb"`      // Recordings are refused and transcriptions deferred below this much free space
+	ExpectedMinutes int    `json:"expected_minutes"` // Recording length the free space should fit, a warning is raised when it doesn't
+	TrashDays       int    `json:"trash_days"`       // Days meetings stay in the trash before they are purged, 0 keeps them
+
+	Encryption    EncryptionConfig    `json:"encryption"`
+	ObjectStorage ObjectStorageConfig `json:"object_storage"`
+}
+
+// ObjectStorageConfig uploads the archived recording and the transcript of processed
+// meetings to S3-compatible storage, such as AWS S3, MinIO or Backblaze B2
+type ObjectStorageConfig struct {
+	Endpoint        string `json:"endpoint"` // e.g. https://s3.eu-west-1.amazonaws.com; empty disables uploads
+	Region          string `json:"region"`
+	Bucket          string `json:"bucket"`
+	Prefix          string `json:"prefix"` // Objects are stored under <prefix>/<yyyy>/<mm>/<meeting id>/
+	AccessKeyID     string `json:"access_key_id"`
+	SecretAccessKey string `json:"secret_access_key"` // Set from TRANSCRIBER_S3_SECRET_ACCESS_KEY
+	PathStyle       bool   `json:"path_style"`        // Bucket in the path instead of the host name, as MinIO expects
+	StorageClass    string `json:"storage_class"`     // e.g. STANDARD_IA, empty uses the bucket default
+	RetentionDays   int    `json:"retention_days"`    // Stored as retain-until metadata for lifecycle rules, 0 keeps objects
+	DeleteLocal     bool   `json:"delete_local"`      // Remove the local archive once it is uploaded
+}
+
+// EncryptionConfig encrypts the meeting event log, with the transcripts, and the archived
+// recordings, exports and highlight reels at rest with AES-256-GCM. Vault notes and
+// attachments stay readable for Obsidian.
+type EncryptionConfig struct {
+	Enabled         bool   `json:"enabled"`
+	Key             string `json:"key"`              // Base64 32 byte key, set from TRANSCRIBER_ENCRYPTION_KEY; empty uses the Keychain
+	KeychainService string `json:"keychain_service"` // Keychain item holding the key, created on first use
+	KeychainAccount string `json:"keychain_account"`
+}
+
+// PeopleConfig controls the directory of known people used to normalize participant names
+type PeopleConfig struct {
+	File        string `json:"file"`
+	CreateNotes bool   `json:"create_notes"` // Add new participants to the directory and create a vault note for them
+	NotesFolder string `json:"notes_folder"` // Vault folder of created person notes
+}
+
+// NamingConfig holds the text/template patterns that name meeting notes and recordings,
+// e.g. "{{.Date}} {{.Title}}" for date-first or "{{.Title}} {{.Date}}" for title-first notes
+type NamingConfig struct {
+	Note      string `json:"note"`      // Meeting note name inside the vault folder, without .md
+	Recording string `json:"recording"` // Recording file name, without .wav
+}
+
+// VaultConfig controls the notes maintained in the Obsidian vault besides the meeting notes
+type VaultConfig struct {
+	Path       string           `json:"path"` // Vault directory, empty uses ~/obsidian-vault
+	Index      IndexConfig      `json:"index"`
+	Transcript TranscriptConfig `json:"transcript"`
+}
+
+// TranscriptConfig controls how transcripts are written to meeting notes. Long transcripts
+// are split into chapters at topic shifts, rendered by the style or a text/template
+// ranging over .Chapters (Title, Start, End, Lines) or .Lines.
+type TranscriptConfig struct {
+	Style          string `json:"style"`            // flat, headings, details or callout
+	Template       string `json:"template"`         // Overrides the style
+	InSummaryNotes bool   `json:"in_summary_notes"` // Append the transcript to summarized notes too
+	ChapterAfter   int    `json:"chapter_after"`    // Minutes; shorter transcripts aren't split, 0 never splits
+	MinChapter     int    `json:"min_chapter"`      // Minimum chapter length in minutes
+	LLMTitles      bool   `json:"llm_titles"`       // Let Ollama title the chapters instead of using keywords
+	Segmentation   string `json:"segmentation"`     // "keywords" compares the words around pauses, "embeddings" their Ollama embeddings
+}
+
+// IndexConfig maintains a note linking every published meeting, grouped by month
+type IndexConfig struct {
+	Enabled bool   `json:"enabled"`
+	Path    string `json:"path"` // Relative to the vault root
+}
+
+// FrontmatterField maps a meeting field, or a literal value, to a frontmatter key of the
+// meeting notes. Fields are id, title, type, date, datetime, updated, duration (minutes),
+// duration_seconds, participants, tags, status, owner, template, project (the project
+// metadata entry), action_item_count and meta.<key>.
+type FrontmatterField struct {
+	Key   string `json:"key"`
+	Field string `json:"field,omitempty"`
+	Value string `json:"value,omitempty"`
+}
+
+// AuthConfig protects the API on shared deployments. Authentication is disabled
+// when neither API keys nor an OIDC provider are configured.
+type AuthConfig struct {
+	APIKeys    []APIKey   `json:"api_keys"`
+	OIDC       OIDCConfig `json:"oidc"`
+	SessionTTL int        `json:"session_ttl"` // Lifetime of OIDC session tokens in seconds
+	UsersFile  string     `json:"users_file"`  // Users and API tokens managed through the admin API
+
+	// SecureCookies marks the session cookie Secure on plain HTTP requests too, for servers
+	// behind a proxy that terminates TLS
+	SecureCookies bool `json:"secure_cookies"`
+
+	// AutoOwner owns the meetings started without a request: by the watch folder, the calendar
+	// or call detection. Empty leaves them to admins.
+	AutoOwner string `json:"auto_owner"`
+
+	// DefaultStorageQuotaMB limits the storage of users without their own quota, 0 is unlimited
+	DefaultStorageQuotaMB int `json:"default_storage_quota_mb"`
+}
+
+// APIKey is a static key that authenticates requests as the given user
+type APIKey struct {
+	Key   string `json:"key"`
+	User  string `json:"user"`
+	Role  string `json:"role"`  // "admin" or "member" (default)
+	Scope string `json:"scope"` // "full" (default), "recorder" to only start and stop recordings or "reader" to only list meetings
+}
+
+// OIDCConfig enables single sign-on with an OpenID Connect provider
+type OIDCConfig struct {
+	Issuer            string   `json:"issuer"`
+	ClientID          string   `json:"client_id"`
+	ClientSecret      string   `json:"client_secret"`
+	RedirectURL       string   `json:"redirect_url"` // Public URL of /api/v1/auth/callback
+	Scopes            []string `json:"scopes"`
+	UsernameClaim     string   `json:"username_claim"`      // ID token claim used as the username
+	PostLoginRedirect string   `json:"post_login_redirect"` // Where the browser is sent after logging in
+}
+
+// Enabled reports whether an OIDC provider is configured
+func (o OIDCConfig) Enabled() bool {
+	return o.Issuer != "" && o.ClientID != ""
+}
+
+// Enabled reports whether requests must be authenticated
+func (a AuthConfig) Enabled() bool {
+	return len(a.APIKeys) > 0 || a.OIDC.Enabled()
+}
+
+// CRM providers
+const (
+	CRMProviderHubSpot    = "hubspot"
+	CRMProviderSalesforce = "salesforce"
+)
+
+// CRMConfig attaches meeting summaries to CRM contacts matched by email address
+type CRMConfig struct {
+	Provider              string `json:"provider"` // Empty disables the integration
+	HubSpotToken          string `json:"hubspot_token"`
+	SalesforceInstanceURL string `json:"salesforce_instance_url"`
+	SalesforceAccessToken string `json:"salesforce_access_token"`
+	EmailMetadataKey      string `json:"email_metadata_key"` // Metadata key holding comma separated contact emails
+}
+
+// PublishConfig creates pages for meetings outside the vault, in the save-notion and
+// save-confluence stages
+type PublishConfig struct {
+	Notion     NotionConfig     `json:"notion"`
+	Confluence ConfluenceConfig `json:"confluence"`
+}
+
+// NotionConfig creates a page per meeting in a Notion database shared with the integration
+type NotionConfig struct {
+	Token         string `json:"token"`          // Internal integration secret, set from NOTION_TOKEN
+	DatabaseID    string `json:"database_id"`    // Empty disables Notion
+	TitleProperty string `json:"title_property"` // Title property of the database
+}
+
+// Enabled reports whether a Notion database is configured
+func (n NotionConfig) Enabled() bool {
+	return n.DatabaseID != ""
+}
+
+// ConfluenceConfig creates a page per meeting in a Confluence space
+type ConfluenceConfig struct {
+	BaseURL  string `json:"base_url"` // e.g. https://example.atlassian.net/wiki
+	Email    string `json:"email"`
+	APIToken string `json:"api_token"` // Set from CONFLUENCE_API_TOKEN
+	SpaceKey string `json:"space_key"` // Empty disables Confluence
+	ParentID string `json:"parent_id"` // Page the meeting pages are created under, empty uses the space root
+}
+
+// Enabled reports whether a Confluence space is configured
+func (c ConfluenceConfig) Enabled() bool {
+	return c.SpaceKey != ""
+}
+
+// OllamaConfig selects the local models used for summaries, questions and embeddings
+type OllamaConfig struct {
+	Model          string `json:"model"`
+	EmbeddingModel string `json:"embedding_model"`
+	Fake           bool   `json:"fake"` // Answer with canned summaries and embeddings instead of calling Ollama, for load tests
+	// Citations has summaries cite the moment of the transcript each key point and decision
+	// was discussed, e.g. [00:14:32]
+	Citations bool `json:"citations"`
+	// Review has the model check every summary against its transcript
+	Review SummaryReviewConfig `json:"review"`
+}
+
+// SummaryReviewConfig checks summaries in a second pass of the model for names not in the
+// transcript, missing action items and a broken template structure. Summaries scoring
+// below MinScore are written again with the problems found, up to Retries times.
+type SummaryReviewConfig struct {
+	Enabled  bool   `json:"enabled"`
+	Model    string `json:"model"`     // Model of the review, empty uses ollama.model
+	MinScore int    `json:"min_score"` // Out of 100
+	Retries  int    `json:"retries"`   // Once used up the best scoring summary is kept
+}
+
+// AudioConfig controls audio capture and mixing
+type AudioConfig struct {
+	EchoCancellation EchoCancellationConfig `json:"echo_cancellation"`
+	Filters          AudioFiltersConfig     `json:"filters"`
+	VaultAttachment  VaultAttachmentConfig  `json:"vault_attachment"`
+	Archive          ArchiveConfig          `json:"archive"`
+	SilenceDetection SilenceDetectionConfig `json:"silence_detection"`
+	AutoStop         AutoStopConfig         `json:"auto_stop"`
+	HotSwap          HotSwapConfig          `json:"hot_swap"`
+	Arm              ArmConfig              `json:"arm"`
+	Export           ExportConfig           `json:"export"`
+	Highlights       HighlightsConfig       `json:"highlights"`
+	Playback         PlaybackConfig         `json:"playback"`
+	Waveform         WaveformConfig         `json:"waveform"`
+
+	// DevicePreferencesFile remembers the devices used per meeting series
+	DevicePreferencesFile string `json:"device_preferences_file"`
+
+	// Devices recorded when none are requested and the series has no preference, by
+	// avfoundation index or name; empty uses the built-in defaults
+	MicDevice    string `json:"mic_device"`
+	SystemDevice string `json:"system_device"`
+
+	// SegmentMinutes records the tracks in files of this many minutes, joined when the
+	// recording stops, so a crash loses at most the last segment. 0 records single files.
+	SegmentMinutes int `json:"segment_minutes"`
+
+	// Format is the sample format the tracks are recorded in and the recording is mixed to
+	Format AudioFormatConfig `json:"format"`
+
+	// MixMode is how the tracks are mixed: "blend" (default) mixes them together, "split"
+	// puts the mic on the left channel and the system audio on the right
+	MixMode string `json:"mix_mode"`
+
+	// AppCapture records the audio of a single app instead of the system output device
+	AppCapture AppCaptureConfig `json:"app_capture"`
+}
+
+// AudioFormatConfig sets the sample format of every track and the mix, so the devices are
+// captured and mixed alike
+type AudioFormatConfig struct {
+	SampleRate int `json:"sample_rate"` // Samples per second, e.g. 44100 or 48000
+	BitDepth   int `json:"bit_depth"`   // 16, 24 or 32
+	Channels   int `json:"channels"`    // 1 for mono, 2 for stereo; the split mix mode needs stereo
+}
+
+// AudioFiltersConfig sets the ffmpeg filters applied to the tracks while capturing and
+// mixing. Recordings can override them when they are started.
+type AudioFiltersConfig struct {
+	InputVolume    float64              `json:"input_volume"` // Volume input devices are captured at, 1 captures as is
+	MicGain        float64              `json:"mic_gain"`     // Gain of the mic track in the mix
+	SystemGain     float64              `json:"system_gain"`  // Gain of the system track in the mix
+	NoiseReduction NoiseReductionConfig `json:"noise_reduction"`
+	Loudnorm       LoudnormConfig       `json:"loudnorm"`
+}
+
+// NoiseReductionConfig removes steady background noise, such as fans and hum, from the mic
+// track before mixing (ffmpeg afftdn)
+type NoiseReductionConfig struct {
+	Enabled    bool    `json:"enabled"`
+	NoiseFloor float64 `json:"noise_floor"` // Noise floor in dB, -80 to -20
+}
+
+// LoudnormConfig normalizes the loudness of the mix to EBU R128 (ffmpeg loudnorm), so
+// quiet and loud meetings play back and transcribe alike
+type LoudnormConfig struct {
+	Enabled  bool    `json:"enabled"`
+	Target   float64 `json:"target"`    // Integrated loudness in LUFS, -70 to -5
+	TruePeak float64 `json:"true_peak"` // Maximum true peak in dBTP, -9 to 0
+	Range    float64 `json:"range"`     // Loudness range in LU, 1 to 50
+}
+
+// AppCaptureConfig records the system track from a single app, e.g. Zoom, so music and
+// notifications of other apps stay out of the recording. A helper taps the processes of the
+// app, with Core Audio process taps on macOS 14.2+, and streams raw PCM to stdout.
+type AppCaptureConfig struct {
+	Helper      string   `json:"helper"`       // Tap helper executable, e.g. audiotee
+	Args        []string `json:"args"`         // Helper arguments; {pids} is replaced by the PIDs of the app
+	Format      string   `json:"format"`       // Sample format the helper writes, as an ffmpeg raw format such as s16le or f32le
+	SampleRate  int      `json:"sample_rate"`  // Sample rate the helper writes
+	Channels    int      `json:"channels"`     // Channels the helper writes
+	MeetingApps bool     `json:"meeting_apps"` // Record only the app of calls recorded automatically by meeting app detection
+}
+
+// HotSwapConfig watches the mic while recording, for when it disappears, stops delivering
+// audio or headphones such as AirPods become the default input
+type HotSwapConfig struct {
+	Enabled       bool   `json:"enabled"`
+	Mode          string `json:"mode"`           // "follow" switches to the default input, "warn" only warns
+	CheckInterval int    `json:"check_interval"` // Seconds between checks
+}
+
+// SilenceDetectionConfig warns when a capture track is near-silent after the recording
+// starts, e.g. because the system audio loopback device isn't configured
+type SilenceDetectionConfig struct {
+	Enabled     bool    `json:"enabled"`
+	After       int     `json:"after"`        // Seconds of audio checked once the recording starts
+	ThresholdDB float64 `json:"threshold_db"` // Tracks with an RMS level below this, in dBFS, are silent
+}
+
+// AutoStopConfig stops forgotten recordings and hands them to processing, so they don't
+// fill the disk. Silence is judged with the silence detection threshold.
+type AutoStopConfig struct {
+	MaxDuration    int `json:"max_duration"`    // Minutes, 0 disables the limit
+	SilenceMinutes int `json:"silence_minutes"` // Minutes of silence on both tracks, 0 disables the check
+}
+
+// ArmConfig controls armed recordings, captures started ahead of a recording so it begins
+// without waiting for ffmpeg to start
+type ArmConfig struct {
+	Timeout int     `json:"timeout"`  // Minutes an armed capture waits for a recording before it is discarded
+	PreRoll float64 `json:"pre_roll"` // Seconds captured before the start that are kept in the recording
+}
+
+// ExportConfig writes a zip with the separate tracks, the mixed recording, an SRT transcript
+// and the note of every processed meeting. Enabling it keeps the tracks after mixing.
+type ExportConfig struct {
+	Enabled bool   `json:"enabled"`
+	Dir     string `json:"dir"`
+}
+
+// HighlightsConfig cuts the most information-dense minutes of every processed meeting,
+// judged by keywords, decisions and bookmarks, into a short highlight reel
+type HighlightsConfig struct {
+	Enabled bool   `json:"enabled"`
+	Minutes int    `json:"minutes"` // Number of one-minute clips in the reel
+	Codec   string `json:"codec"`   // opus, aac or flac
+	Dir     string `json:"dir"`
+}
+
+// WaveformConfig computes the waveform of every recording after mixing, so players can
+// draw it without decoding the audio
+type WaveformConfig struct {
+	Enabled bool   `json:"enabled"`
+	Buckets int    `json:"buckets"` // Number of levels the recording is divided in
+	Dir     string `json:"dir"`
+}
+
+// PlaybackConfig transcodes recordings for the audio player of the API. Transcoded copies
+// are cached in Dir, until their meeting is purged or they weren't played for
+// cleanup.temp_max_age_hours.
+type PlaybackConfig struct {
+	Codec string `json:"codec"` // opus, aac or flac, played when the recording isn't kept as is
+	Dir   string `json:"dir"`
+}
+
+// ArchiveConfig keeps a compressed copy of every recording. The WAV file is only used
+// for transcription and removed afterwards.
+type ArchiveConfig struct {
+	Codec   string `json:"codec"`   // opus, aac or flac; empty disables the archive
+	Bitrate string `json:"bitrate"` // e.g. 32k, empty uses the codec default; ignored by flac
+	Dir     string `json:"dir"`
+}
+
+// Ways of attaching the recording to the meeting note
+const (
+	AttachmentModeCopy     = "copy"     // Copy the WAV file into the vault
+	AttachmentModeCompress = "compress" // Encode the recording to AAC with ffmpeg
+	AttachmentModeSymlink  = "symlink"  // Link to the archived recording, or a WAV file kept in KeepDir
+)
+
+// VaultAttachmentConfig adds the recording to the vault and embeds it in the meeting note
+type VaultAttachmentConfig struct {
+	Mode    string `json:"mode"`     // Empty disables attachments
+	Folder  string `json:"folder"`   // Vault folder of the attachments
+	Bitrate string `json:"bitrate"`  // AAC bitrate when compressing, e.g. 64k
+	KeepDir string `json:"keep_dir"` // Where symlinked recordings are kept
+}
+
+// EchoCancellationConfig removes speaker audio re-captured by the microphone
+// before the mic and system tracks are mixed (requires ffmpeg 5.1+)
+type EchoCancellationConfig struct {
+	Enabled     bool    `json:"enabled"`
+	FilterOrder int     `json:"filter_order"` // Echo tail length in samples at 48kHz
+	StepSize    float64 `json:"step_size"`
+}
+
+// ServerConfig defines where and how the API server listens
+type ServerConfig struct {
+	Host            string        `json:"host"`
+	Port            int           `json:"port"`
+	UnixSocket      string        `json:"unix_socket"` // Additionally listen on this Unix domain socket when set
+	TLS             TLSConfig     `json:"tls"`
+	Pprof           bool          `json:"pprof"`            // Expose admin-only profiling endpoints under /api/v1/debug/pprof
+	DebugAddr       string        `json:"debug_addr"`       // Serve the profiles and expvars without authentication on this loopback address, e.g. localhost:6060
+	ShutdownTimeout int           `json:"shutdown_timeout"` // Seconds a shutdown waits for the recording to be mixed and the running job to finish
+	IdempotencyTTL  int           `json:"idempotency_ttl"`  // Seconds responses to POST requests with an Idempotency-Key are replayed to retries, 0 disables
+	WebUI           bool          `json:"web_ui"`           // Serve the bundled web UI to browsers at /
+	Sharing         SharingConfig `json:"sharing"`
+}
+
+// SharingConfig controls the signed links that share a read-only view of a meeting
+type SharingConfig struct {
+	Secret      string `json:"secret"`        // Signs the links, set from TRANSCRIBER_SHARE_SECRET; empty uses a key generated into secret_file
+	SecretFile  string `json:"secret_file"`   // Key generated on first use when no secret is set
+	RevokedFile string `json:"revoked_file"`  // Links revoked before they expire
+	TTLHours    int    `json:"ttl_hours"`     // How long links are valid when the request doesn't say
+	MaxTTLHours int    `json:"max_ttl_hours"` // Longest validity a link may be given
+	BaseURL     string `json:"base_url"`      // URL the server is reached at, e.g. https://notes.example.com; empty uses the host of the request
+}
+
+// TLSConfig enables HTTPS on the TCP listener
+type TLSConfig struct {
+	CertFile   string `json:"cert_file"`
+	KeyFile    string `json:"key_file"`
+	SelfSigned bool   `json:"self_signed"` // Generate an in-memory certificate for localhost
+}
+
+// Enabled reports whether the server should serve HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.SelfSigned || (t.CertFile != "" && t.KeyFile != "")
+}
+
+// Addr returns the TCP address the server listens on
+func (s ServerConfig) Addr() string {
+	return net.JoinHostPort(s.Host, strconv.Itoa(s.Port))
+}
+
+// CORSConfig defines which browser origins may call the API
+type CORSConfig struct {
+	AllowedOrigins   []string `json:"allowed_origins"` // "*" allows any origin
+	AllowedMethods   []string `json:"allowed_methods"`
+	AllowedHeaders   []string `json:"allowed_headers"`
+	AllowCredentials bool     `json:"allow_credentials"`
+	MaxAge           int      `json:"max_age"` // Preflight cache duration in seconds
+}
+
+// ProcessingConfig controls how recorded meetings are processed
+type ProcessingConfig struct {
+	// TagPriorities maps meeting tags to a queue priority. Higher values are
+	// processed first; meetings with equal priority are processed in FIFO order.
+	TagPriorities map[string]int `json:"tag_priorities"`
+
+	// TemplatesDir holds the summarization prompt templates, one <name>.md file each
+	TemplatesDir string `json:"templates_dir"`
+
+	// Minutes the transcription and the summary of a meeting may take before it fails,
+	// 0 doesn't limit the stage
+	TranscribeTimeout int `json:"transcribe_timeout"`
+	SummarizeTimeout  int `json:"summarize_timeout"`
+
+	// Stages run after a recording, in order; transcribe comes first. Meetings and presets
+	// can override them, e.g. with just transcribe to skip Ollama.
+	Stages     []string `json:"stages"`
+	WebhookURL string   `json:"webhook_url"` // Receives the processed meeting as JSON in the webhook stage
+
+	DetectType DetectTypeConfig `json:"detect_type"`
+}
+
+// Processing stages
+const (
+	StageTranscribe     = "transcribe"      // Transcribe the recording
+	StageDiarize        = "diarize"         // Keep the speakers the engine tells apart
+	StageSummarize      = "summarize"       // Type detection, chapters and the summary, with Ollama
+	StageExtractActions = "extract-actions" // Action items of the summary
+	StageSaveVault      = "save-vault"      // Note, person notes and attachment in the vault
+	StageSaveNotion     = "save-notion"     // Page in the publish.notion database
+	StageSaveConfluence = "save-confluence" // Page in the publish.confluence space
+	StageWebhook        = "webhook"         // POST the meeting to processing.webhook_url
+)
+
+// DetectTypeConfig classifies meetings started without a type so the matching preset's
+// template and tags are applied
+type DetectTypeConfig struct {
+	Enabled             bool `json:"enabled"`
+	LLM                 bool `json:"llm"`                     // Ask Ollama when the title and participants don't decide
+	AllHandsFrom        int  `json:"all_hands_from"`          // Participant count from which a meeting is an all-hands
+	OneOnOneMaxDuration int  `json:"one_on_one_max_duration"` // Longest two-person meeting in minutes treated as a 1:1, 0 is unlimited
+}
+
+// Transcription engines
+const (
+	TranscriptionEngineWhisper    = "whisper"    // Local whisper CLI
+	TranscriptionEngineDeepgram   = "deepgram"   // Deepgram live streaming API
+	TranscriptionEngineAssemblyAI = "assemblyai" // AssemblyAI universal streaming API
+	TranscriptionEngineExternal   = "external"   // Transcripts are delivered through the webhook inbox
+	TranscriptionEngineFake       = "fake"       // Generated transcripts without audio, for load tests
+)
+
+// TranscriptionConfig selects how recordings are transcribed
+type TranscriptionConfig struct {
+	Engine        string `json:"engine"`
+	WebhookSecret string `json:"webhook_secret"` // Shared secret required by the transcript webhook inbox
+	DeepgramKey   string `json:"deepgram_api_key"`
+	AssemblyAIKey string `json:"assemblyai_api_key"` // Also used to fetch transcripts announced by AssemblyAI webhooks
+
+	WhisperModel string `json:"whisper_model"` // tiny, base, small, medium, large or turbo
+	Language     string `json:"language"`      // Spoken language passed to whisper, empty lets whisper detect it
+
+	// CodeSwitching transcribes meetings that mix languages mid-sentence with a multilingual
+	// model and picks the best fitting language per segment instead of forcing Language
+	CodeSwitching CodeSwitchingConfig `json:"code_switching"`
+
+	// DedupeTracks transcribes the mic and system tracks separately and drops mic
+	// segments that duplicate overlapping system audio, as an alternative to echo cancellation
+	DedupeTracks bool `json:"dedupe_tracks"`
+}
+
+// CodeSwitchingConfig lists the languages spoken in code-switched meetings
+type CodeSwitchingConfig struct {
+	Enabled   bool     `json:"enabled"`
+	Languages []string `json:"languages"` // Whisper language codes, e.g. ["nl", "en"]
+}
+
+// ABTestConfig runs several summarizer variants on the same transcript so their
+// output can be compared
+type ABTestConfig struct {
+	Enabled  bool                `json:"enabled"`
+	Variants []SummarizerVariant `json:"variants"`
+}
+
+// SummarizerVariant is a model and prompt combination used for A/B testing.
+// The first variant's output becomes the meeting summary.
+type SummarizerVariant struct {
+	Name         string `json:"name"`
+	Model        string `json:"model"`
+	SystemPrompt string `json:"system_prompt,omitempty"` // Empty uses the default template
+}
+
+// Default returns the configuration used when no config file is present
+func Default() *Config {
+	return &Config{
+		Server: ServerConfig{
+			Port:            8000,
+			ShutdownTimeout: 30,
+			IdempotencyTTL:  600,
+			WebUI:           true,
+			Sharing: SharingConfig{
+				SecretFile:  filepath.Join(DataDir(), "share_secret"),
+				RevokedFile: filepath.Join(DataDir(), "share_revoked.json"),
+				TTLHours:    7 * 24,
+				MaxTTLHours: 30 * 24,
+			},
+		},
+		CORS: CORSConfig{
+			AllowedOrigins: []string{"http://localhost:5173", "http://127.0.0.1:5173"},
+			AllowedMethods: []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+			AllowedHeaders: []string{"Content-Type", "Authorization", "X-API-Key", "If-Match", "Idempotency-Key"},
+			MaxAge:         600,
+		},
+		Processing: ProcessingConfig{
+			TagPriorities:     map[string]int{},
+			TemplatesDir:      filepath.Join(DataDir(), "templates"),
+			TranscribeTimeout: 240,
+			SummarizeTimeout:  30,
+			Stages:            []string{StageTranscribe, StageDiarize, StageSummarize, StageExtractActions, StageSaveVault},
+			DetectType: DetectTypeConfig{
+				AllHandsFrom:        10,
+				OneOnOneMaxDuration: 60,
+			},
+		},
+		Transcription: TranscriptionConfig{
+			Engine:       TranscriptionEngineWhisper,
+			WhisperModel: "medium",
+			Language:     "en",
+			CodeSwitching: CodeSwitchingConfig{
+				Languages: []string{"nl", "en"},
+			},
+		},
+		Presets: map[string]Preset{
+			"standup": {
+				Template:    "standup",
+				VaultFolder: "meetings/standups",
+				Tags:        []string{"standup"},
+				Keywords:    []string{"standup", "stand-up", "daily", "scrum"},
+			},
+			"retrospective": {
+				Template:    "retro",
+				VaultFolder: "meetings/retrospectives",
+				Tags:        []string{"retro"},
+				Keywords:    []string{"retro", "retrospective"},
+			},
+			"client-call": {
+				Template:    "client-call",
+				VaultFolder: "meetings/clients",
+				Tags:        []string{"client"},
+				Keywords:    []string{"client", "customer", "sales", "demo"},
+			},
+			"interview": {
+				Template:    "interview",
+				VaultFolder: "meetings/interviews",
+				Tags:        []string{"interview"},
+				Keywords:    []string{"interview", "candidate", "hiring"},
+			},
+			"one-on-one": {
+				Template:    "one-on-one",
+				VaultFolder: "meetings/one-on-ones",
+				Tags:        []string{"1:1"},
+				Keywords:    []string{"1:1", "1-on-1", "one-on-one", "1on1"},
+			},
+			"all-hands": {
+				Template:    "default",
+				VaultFolder: "meetings/all-hands",
+				Tags:        []string{"all-hands"},
+				Keywords:    []string{"all-hands", "all hands", "town hall", "townhall"},
+			},
+		},
+		Auth: AuthConfig{
+			SessionTTL: 7 * 24 * 60 * 60,
+			UsersFile:  filepath.Join(DataDir(), "users.json"),
+			OIDC: OIDCConfig{
+				Scopes:            []string{"openid", "profile", "email"},
+				UsernameClaim:     "preferred_username",
+				PostLoginRedirect: "/",
+			},
+		},
+		CRM: CRMConfig{
+			EmailMetadataKey: "contact_emails",
+		},
+		Publish: PublishConfig{
+			Notion: NotionConfig{
+				TitleProperty: "Name",
+			},
+		},
+		Storage: StorageConfig{
+			EventsFile:      filepath.Join(DataDir(), "events.jsonl"),
+			RecordingsDir:   filepath.Join(DataDir(), "in-progress"),
+			ArtifactsDir:    filepath.Join(DataDir(), "artifacts"),
+			MinFreeMB:       500,
+			TrashDays:       30,
+			ExpectedMinutes: 60,
+			Encryption: EncryptionConfig{
+				KeychainService: "transcriber",
+				KeychainAccount: "encryption-key",
+			},
+			ObjectStorage: ObjectStorageConfig{
+				Region:    "us-east-1",
+				Prefix:    "transcriber",
+				PathStyle: true,
+			},
+		},
+		People: PeopleConfig{
+			File:        filepath.Join(DataDir(), "people.json"),
+			NotesFolder: "people",
+		},
+		Naming: NamingConfig{
+			Note:      "meeting_{{.Timestamp}}",
+			Recording: "recording_{{.Timestamp}}",
+		},
+		Calendar: CalendarConfig{
+			RefreshMinutes: 5,
+			Tag:            "#record",
+		},
+		MeetingApps: MeetingAppsConfig{
+			Interval: 10,
+			Notify:   true,
+		},
+		Watch: WatchConfig{
+			Interval:   10,
+			Extensions: []string{".wav", ".mp3", ".m4a", ".aac", ".flac", ".ogg", ".opus", ".webm", ".mp4", ".mov"},
+		},
+		Download: DownloadConfig{
+			YtDlp:          "yt-dlp",
+			MaxMB:          2048,
+			TimeoutMinutes: 30,
+		},
+		Update: UpdateConfig{
+			Channel:    "stable",
+			Repository: "martijnspitter/transcriber",
+			CheckHours: 24,
+			Notify:     true,
+		},
+		Cleanup: CleanupConfig{
+			IntervalMinutes: 60,
+			TempMaxAgeHours: 24,
+			ProcessesFile:   filepath.Join(DataDir(), "processes.json"),
+		},
+		Hooks: HooksConfig{
+			TimeoutSeconds: 60,
+		},
+		Log: LogConfig{
+			Level:      "info",
+			Output:     LogOutputStdout,
+			File:       filepath.Join(DataDir(), "logs", "transcriber.log"),
+			MaxSizeMB:  10,
+			MaxBackups: 5,
+		},
+		Vault: VaultConfig{
+			Index: IndexConfig{
+				Enabled: true,
+				Path:    "Meetings Index.md",
+			},
+			Transcript: TranscriptConfig{
+				Style:        "details",
+				ChapterAfter: 20,
+				MinChapter:   3,
+				Segmentation: "keywords",
+				LLMTitles:    true,
+			},
+		},
+		Frontmatter: []FrontmatterField{
+			{Key: "meeting_id", Field: "id"},
+			{Key: "title", Field: "title"},
+			{Key: "type", Value: "meeting"},
+			{Key: "created", Field: "date"},
+			{Key: "updated", Field: "updated"},
+			{Key: "duration", Field: "duration"},
+			{Key: "duration_seconds", Field: "duration_seconds"},
+			{Key: "participants", Field: "participants"},
+			{Key: "tags", Field: "tags"},
+			{Key: "project", Field: "project"},
+			{Key: "action_item_count", Field: "action_item_count"},
+			{Key: "status", Field: "status"},
+		},
+		Ollama: OllamaConfig{
+			Model:          "mistral",
+			EmbeddingModel: "nomic-embed-text",
+			Citations:      true,
+			Review: SummaryReviewConfig{
+				MinScore: 70,
+				Retries:  2,
+			},
+		},
+		Audio: AudioConfig{
+			EchoCancellation: EchoCancellationConfig{
+				FilterOrder: 4096,
+				StepSize:    0.5,
+			},
+			Filters: AudioFiltersConfig{
+				InputVolume: 1.5,
+				MicGain:     1,
+				SystemGain:  1,
+				NoiseReduction: NoiseReductionConfig{
+					NoiseFloor: -50,
+				},
+				Loudnorm: LoudnormConfig{
+					Target:   -16,
+					TruePeak: -1.5,
+					Range:    11,
+				},
+			},
+			DevicePreferencesFile: filepath.Join(DataDir(), "devices.json"),
+			SegmentMinutes:        5,
+			Format: AudioFormatConfig{
+				SampleRate: 48000,
+				BitDepth:   24,
+				Channels:   2,
+			},
+			MixMode: "blend",
+			AppCapture: AppCaptureConfig{
+				Helper:     "audiotee",
+				Args:       []string{"--include-processes", "{pids}", "--sample-rate", "48000"},
+				Format:     "s16le",
+				SampleRate: 48000,
+				Channels:   1,
+			},
+			Archive: ArchiveConfig{
+				Dir: filepath.Join(DataDir(), "archive"),
+			},
+			SilenceDetection: SilenceDetectionConfig{
+				Enabled:     true,
+				After:       30,
+				ThresholdDB: -60,
+			},
+			AutoStop: AutoStopConfig{
+				MaxDuration:    240,
+				SilenceMinutes: 15,
+			},
+			HotSwap: HotSwapConfig{
+				Enabled:       true,
+				Mode:          "warn",
+				CheckInterval: 5,
+			},
+			Export: ExportConfig{
+				Dir: filepath.Join(DataDir(), "exports"),
+			},
+			Highlights: HighlightsConfig{
+				Minutes: 3,
+				Codec:   "aac",
+				Dir:     filepath.Join(DataDir(), "highlights"),
+			},
+			Playback: PlaybackConfig{
+				Codec: "aac",
+				Dir:   filepath.Join(DataDir(), "playback"),
+			},
+			Waveform: WaveformConfig{
+				Enabled: true,
+				Buckets: 1000,
+				Dir:     filepath.Join(DataDir(), "waveforms"),
+			},
+			Arm: ArmConfig{
+				Timeout: 15,
+				PreRoll: 2,
+			},
+			VaultAttachment: VaultAttachmentConfig{
+				Folder:  "attachments",
+				Bitrate: "64k",
+				KeepDir: filepath.Join(DataDir(), "recordings"),
+			},
+		},
+	}
+}
+
+// Path returns the location of the config file. It can be overridden with
+// the TRANSCRIBER_CONFIG environment variable.
+func Path() string {
+	if path := os.Getenv("TRANSCRIBER_CONFIG"); path != "" {
+		return path
+	}
+
+	return filepath.Join(DataDir(), "config.json")
+}
+
+// DataDir returns the directory holding the config file and other server state,
+// ~/.transcriber by default. It can be overridden with TRANSCRIBER_DATA_DIR.
+func DataDir() string {
+	if dir := os.Getenv("TRANSCRIBER_DATA_DIR"); dir != "" {
+		return dir
+	}
+
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "."
+	}
+	return filepath.Join(homeDir, ".transcriber")
+}
+
+// Load reads the config file on top of the defaults and applies environment
+// overrides. A missing config file is not an error.
+func Load() (*Config, error) {
+	cfg, err := loadFile()
+	if err != nil {
+		return nil, err
+	}
+
+	applyEnv(cfg)
+
+	return cfg, nil
+}
+
+// loadFile reads the config file on top of the defaults
+func loadFile() (*Config, error) {
+	cfg := Default()
+
+	data, err := os.ReadFile(Path())
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	if err == nil {
+		if err := json.Unmarshal(data, cfg); err != nil {
+			return nil, fmt.Errorf("failed to parse config file: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// Update applies a change to the config file, creating it when it doesn't exist yet.
+// Environment overrides are left out, so secrets passed in the environment aren't written.
+func Update(change func(cfg *Config)) error {
+	cfg, err := loadFile()
+	if err != nil {
+		return err
+	}
+	change(cfg)
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+	path := Path()
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return err
+	}
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to write config file: %w", err)
+	}
+	return os.Rename(tmpPath, path)
+}
+
+// applyEnv overrides config values with environment variables when set
+func applyEnv(cfg *Config) {
+	if host := os.Getenv("TRANSCRIBER_HOST"); host != "" {
+		cfg.Server.Host = host
+	}
+	if port, err := strconv.Atoi(os.Getenv("TRANSCRIBER_PORT")); err == nil && port > 0 {
+		cfg.Server.Port = port
+	}
+	if socket := os.Getenv("TRANSCRIBER_UNIX_SOCKET"); socket != "" {
+		cfg.Server.UnixSocket = socket
+	}
+	if secret := os.Getenv("TRANSCRIBER_WEBHOOK_SECRET"); secret != "" {
+		cfg.Transcription.WebhookSecret = secret
+	}
+	if secret := os.Getenv("TRANSCRIBER_SHARE_SECRET"); secret != "" {
+		cfg.Server.Sharing.Secret = secret
+	}
+	if key := os.Getenv("DEEPGRAM_API_KEY"); key != "" {
+		cfg.Transcription.DeepgramKey = key
+	}
+	if key := os.Getenv("ASSEMBLYAI_API_KEY"); key != "" {
+		cfg.Transcription.AssemblyAIKey = key
+	}
+	if token := os.Getenv("HUBSPOT_TOKEN"); token != "" {
+		cfg.CRM.HubSpotToken = token
+	}
+	if token := os.Getenv("SALESFORCE_ACCESS_TOKEN"); token != "" {
+		cfg.CRM.SalesforceAccessToken = token
+	}
+	if token := os.Getenv("NOTION_TOKEN"); token != "" {
+		cfg.Publish.Notion.Token = token
+	}
+	if token := os.Getenv("CONFLUENCE_API_TOKEN"); token != "" {
+		cfg.Publish.Confluence.APIToken = token
+	}
+	if key := os.Getenv("TRANSCRIBER_S3_SECRET_ACCESS_KEY"); key != "" {
+		cfg.Storage.ObjectStorage.SecretAccessKey = key
+	}
+	if key := os.Getenv("TRANSCRIBER_ENCRYPTION_KEY"); key != "" {
+		cfg.Storage.Encryption.Key = key
+	}
+	if secret := os.Getenv("TRANSCRIBER_OIDC_CLIENT_SECRET"); secret != "" {
+		cfg.Auth.OIDC.ClientSecret = secret
+	}
+	if origins := os.Getenv("TRANSCRIBER_CORS_ORIGINS"); origins != "" {
+		cfg.CORS.AllowedOrigins = splitList(origins)
+	}
+	if level := os.Getenv("TRANSCRIBER_LOG_LEVEL"); level != "" {
+		cfg.Log.Level = level
+	}
+	if devMode, err := strconv.ParseBool(os.Getenv("TRANSCRIBER_DEV_MODE")); err == nil {
+		cfg.DevMode = devMode
+	}
+}
+
+// splitList splits a comma separated list, dropping empty entries
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
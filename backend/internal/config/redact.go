@@ -0,0 +1,48 @@
+package config
+
+import (
+	"net/url"
+	"slices"
+)
+
+// redacted replaces secrets in a redacted config
+const redacted = "[redacted]"
+
+// Redacted returns a copy of the config without its secrets, to be shared in bug reports.
+// Secrets that are set are replaced, so it still shows which ones are configured. Private
+// calendar feeds carry a token in their URL, so only the host of the calendar is kept.
+func (c *Config) Redacted() *Config {
+	cfg := *c
+	redact(&cfg.Transcription.WebhookSecret)
+	redact(&cfg.Server.Sharing.Secret)
+	redact(&cfg.Transcription.DeepgramKey)
+	redact(&cfg.Transcription.AssemblyAIKey)
+	redact(&cfg.CRM.HubSpotToken)
+	redact(&cfg.CRM.SalesforceAccessToken)
+	redact(&cfg.Publish.Notion.Token)
+	redact(&cfg.Publish.Confluence.APIToken)
+	redact(&cfg.Storage.Encryption.Key)
+	redact(&cfg.Storage.ObjectStorage.SecretAccessKey)
+	redact(&cfg.Auth.OIDC.ClientSecret)
+	redact(&cfg.Calendar.Password)
+	cfg.Auth.APIKeys = slices.Clone(c.Auth.APIKeys)
+	for i := range cfg.Auth.APIKeys {
+		redact(&cfg.Auth.APIKeys[i].Key)
+	}
+	redactURL(&cfg.Calendar.URL)
+	redactURL(&cfg.Processing.WebhookURL)
+	return &cfg
+}
+
+// redactURL keeps only the host of a URL, since its path and query can hold a token
+func redactURL(value *string) {
+	if u, err := url.Parse(*value); err == nil && u.Host != "" {
+		*value = u.Scheme + "://" + u.Host + "/" + redacted
+	}
+}
+
+func redact(value *string) {
+	if *value != "" {
+		*value = redacted
+	}
+}
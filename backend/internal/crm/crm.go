@@ -0,0 +1,115 @@
+package crm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Client attaches meeting summaries to contacts in a CRM
+type Client interface {
+	Name() string
+	// AttachSummary logs the meeting as an activity on the contact with the given email.
+	// It returns ErrContactNotFound when the CRM has no such contact.
+	AttachSummary(ctx context.Context, email string, meeting *types.Meeting) error
+}
+
+// ErrContactNotFound is returned when no CRM contact matches an email address
+var ErrContactNotFound = fmt.Errorf("contact not found")
+
+// New creates the CRM client selected in the config, or nil when the integration is disabled
+func New(cfg config.CRMConfig) (Client, error) {
+	switch cfg.Provider {
+	case "":
+		return nil, nil
+	case config.CRMProviderHubSpot:
+		if cfg.HubSpotToken == "" {
+			return nil, fmt.Errorf("hubspot CRM requires crm.hubspot_token")
+		}
+		return &hubSpotClient{token: cfg.HubSpotToken}, nil
+	case config.CRMProviderSalesforce:
+		if cfg.SalesforceInstanceURL == "" || cfg.SalesforceAccessToken == "" {
+			return nil, fmt.Errorf("salesforce CRM requires crm.salesforce_instance_url and crm.salesforce_access_token")
+		}
+		return &salesforceClient{
+			instanceURL: strings.TrimSuffix(cfg.SalesforceInstanceURL, "/"),
+			token:       cfg.SalesforceAccessToken,
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown CRM provider %q", cfg.Provider)
+	}
+}
+
+// ContactEmails returns the email addresses to match against CRM contacts: the comma
+// separated list in the metadata key plus any participant that is an email address
+func ContactEmails(meeting *types.Meeting, metadataKey string) []string {
+	seen := make(map[string]bool)
+	var emails []string
+	add := func(value string) {
+		email := strings.ToLower(strings.TrimSpace(value))
+		if !strings.Contains(email, "@") || seen[email] {
+			return
+		}
+		seen[email] = true
+		emails = append(emails, email)
+	}
+
+	if metadataKey != "" {
+		for _, value := range strings.Split(meeting.Metadata[metadataKey], ",") {
+			add(value)
+		}
+	}
+	for _, participant := range meeting.Participants {
+		add(participant)
+	}
+	return emails
+}
+
+// activitySubject is the title of the CRM activity for a meeting
+func activitySubject(meeting *types.Meeting) string {
+	return fmt.Sprintf("Meeting: %s", meeting.Title)
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// doJSON sends a JSON request and decodes the JSON response into out when it is not nil
+func doJSON(ctx context.Context, method, url, token string, body interface{}, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		js, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(js)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s failed with status %d: %s", method, url, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
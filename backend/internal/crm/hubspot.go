@@ -0,0 +1,80 @@
+package crm
+
+import (
+	"context"
+	"html"
+	"net/http"
+	"strconv"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+const (
+	hubSpotAPIURL = "https://api.hubapi.com/crm/v3/objects"
+
+	// hubSpotNoteToContact is HubSpot's built-in association type from a note to a contact
+	hubSpotNoteToContact = 202
+)
+
+// hubSpotClient logs meetings as notes on HubSpot contacts
+type hubSpotClient struct {
+	token string
+}
+
+func (c *hubSpotClient) Name() string {
+	return config.CRMProviderHubSpot
+}
+
+func (c *hubSpotClient) AttachSummary(ctx context.Context, email string, meeting *types.Meeting) error {
+	contactId, err := c.findContact(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	note := map[string]interface{}{
+		"properties": map[string]string{
+			"hs_timestamp": strconv.FormatInt(meeting.Start_time.UnixMilli(), 10),
+			"hs_note_body": "<h3>" + html.EscapeString(activitySubject(meeting)) + "</h3><pre>" + html.EscapeString(meeting.Summary) + "</pre>",
+		},
+		"associations": []interface{}{
+			map[string]interface{}{
+				"to": map[string]string{"id": contactId},
+				"types": []interface{}{
+					map[string]interface{}{
+						"associationCategory": "HUBSPOT_DEFINED",
+						"associationTypeId":   hubSpotNoteToContact,
+					},
+				},
+			},
+		},
+	}
+	return doJSON(ctx, http.MethodPost, hubSpotAPIURL+"/notes", c.token, note, nil)
+}
+
+// findContact returns the ID of the contact with the given email
+func (c *hubSpotClient) findContact(ctx context.Context, email string) (string, error) {
+	search := map[string]interface{}{
+		"filterGroups": []interface{}{
+			map[string]interface{}{
+				"filters": []interface{}{
+					map[string]string{"propertyName": "email", "operator": "EQ", "value": email},
+				},
+			},
+		},
+		"limit": 1,
+	}
+
+	var result struct {
+		Results []struct {
+			Id string `json:"id"`
+		} `json:"results"`
+	}
+	if err := doJSON(ctx, http.MethodPost, hubSpotAPIURL+"/contacts/search", c.token, search, &result); err != nil {
+		return "", err
+	}
+	if len(result.Results) == 0 {
+		return "", ErrContactNotFound
+	}
+	return result.Results[0].Id, nil
+}
@@ -0,0 +1,64 @@
+package crm
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+const salesforceAPIVersion = "v59.0"
+
+// salesforceClient logs meetings as completed tasks on Salesforce contacts
+type salesforceClient struct {
+	instanceURL string
+	token       string
+}
+
+func (c *salesforceClient) Name() string {
+	return config.CRMProviderSalesforce
+}
+
+func (c *salesforceClient) dataURL(path string) string {
+	return c.instanceURL + "/services/data/" + salesforceAPIVersion + path
+}
+
+func (c *salesforceClient) AttachSummary(ctx context.Context, email string, meeting *types.Meeting) error {
+	contactId, err := c.findContact(ctx, email)
+	if err != nil {
+		return err
+	}
+
+	task := map[string]string{
+		"WhoId":        contactId,
+		"Subject":      activitySubject(meeting),
+		"Description":  meeting.Summary,
+		"Status":       "Completed",
+		"TaskSubtype":  "Call",
+		"ActivityDate": meeting.Start_time.Format("2006-01-02"),
+	}
+	return doJSON(ctx, http.MethodPost, c.dataURL("/sobjects/Task"), c.token, task, nil)
+}
+
+// findContact returns the ID of the contact with the given email
+func (c *salesforceClient) findContact(ctx context.Context, email string) (string, error) {
+	// Escape the value for the SOQL string literal
+	escaped := strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(email)
+	query := "SELECT Id FROM Contact WHERE Email = '" + escaped + "' LIMIT 1"
+
+	var result struct {
+		Records []struct {
+			Id string `json:"Id"`
+		} `json:"records"`
+	}
+	if err := doJSON(ctx, http.MethodGet, c.dataURL("/query?q="+url.QueryEscape(query)), c.token, nil, &result); err != nil {
+		return "", err
+	}
+	if len(result.Records) == 0 {
+		return "", ErrContactNotFound
+	}
+	return result.Records[0].Id, nil
+}
@@ -0,0 +1,140 @@
+// Package devices remembers the capture devices used for recurring meetings so the next
+// recording of the same series starts with them
+package devices
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no preference exists for a series
+var ErrNotFound = errors.New("device preference not found")
+
+// datePattern matches dates and times in meeting titles, which differ per occurrence of a series
+var datePattern = regexp.MustCompile(`\b\d{4}-\d{1,2}-\d{1,2}\b|\b\d{1,2}[/.-]\d{1,2}([/.-]\d{2,4})?\b|\b\d{1,2}:\d{2}\b`)
+
+// Preference is the pair of devices last used for a series
+type Preference struct {
+	Series    string    `json:"series"`
+	Mic       string    `json:"mic"`
+	System    string    `json:"system"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Store persists device preferences in a JSON file
+type Store struct {
+	mu          sync.RWMutex
+	path        string
+	Preferences map[string]*Preference `json:"preferences"` // Series key -> preference
+}
+
+// Open reads the preferences file, starting empty when it doesn't exist yet
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:        path,
+		Preferences: make(map[string]*Preference),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read device preferences file: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse device preferences file: %w", err)
+	}
+	return s, nil
+}
+
+// save writes the store atomically; callers must hold the write lock
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// SeriesKey returns the key meetings of the same series share: the explicit series when given,
+// otherwise the title without dates and times, ignoring case
+func SeriesKey(series string, title string) string {
+	if series == "" {
+		series = datePattern.ReplaceAllString(title, "")
+	}
+	return strings.Join(strings.Fields(strings.ToLower(series)), " ")
+}
+
+// Get returns the preference of a series
+func (s *Store) Get(key string) (Preference, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preference, ok := s.Preferences[key]
+	if !ok {
+		return Preference{}, false
+	}
+	return *preference, true
+}
+
+// List returns all preferences, most recently used first
+func (s *Store) List() []Preference {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	preferences := make([]Preference, 0, len(s.Preferences))
+	for _, preference := range s.Preferences {
+		preferences = append(preferences, *preference)
+	}
+	sort.Slice(preferences, func(i, j int) bool {
+		return preferences[i].UpdatedAt.After(preferences[j].UpdatedAt)
+	})
+	return preferences
+}
+
+// Remember stores the devices used for a series
+func (s *Store) Remember(key string, mic string, system string) error {
+	if key == "" {
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.Preferences[key] = &Preference{
+		Series:    key,
+		Mic:       mic,
+		System:    system,
+		UpdatedAt: time.Now(),
+	}
+	return s.save()
+}
+
+// Forget removes the preference of a series
+func (s *Store) Forget(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.Preferences[key]; !ok {
+		return ErrNotFound
+	}
+	delete(s.Preferences, key)
+	return s.save()
+}
@@ -0,0 +1,207 @@
+// Package encryption encrypts recordings and transcripts at rest with AES-256-GCM, with the
+// key taken from the environment or the macOS Keychain
+package encryption
+
+import (
+	"bytes"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+)
+
+// KeySize is the length of encryption keys in bytes, for AES-256
+const KeySize = 32
+
+// magic starts encrypted data, so data stored before encryption was enabled is read as is
+var magic = []byte("TRENC1")
+
+// ErrNoKey is returned when encrypted data is read without an encryption key
+var ErrNoKey = errors.New("data is encrypted but no encryption key is configured")
+
+// Cipher encrypts and decrypts data with a key. A nil Cipher leaves data as it is, so
+// callers don't have to check whether encryption is enabled.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// New returns a cipher for a 32 byte key
+func New(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, fmt.Errorf("encryption key must be %d bytes, got %d", KeySize, len(key))
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, err
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Load returns the cipher of the config, or nil when encryption is disabled. The key is the
+// base64 key of the config, set from TRANSCRIBER_ENCRYPTION_KEY, or the one stored in the
+// Keychain; a new key is generated and stored in the Keychain on first use. The Keychain is
+// only there on macOS, elsewhere the key must be set.
+func Load(cfg config.EncryptionConfig) (*Cipher, error) {
+	if !cfg.Enabled {
+		return nil, nil
+	}
+	encoded := cfg.Key
+	if encoded == "" {
+		if runtime.GOOS != "darwin" {
+			return nil, fmt.Errorf("encryption is enabled but no key is set: set storage.encryption.key or TRANSCRIBER_ENCRYPTION_KEY to a base64 encoded %d byte key, the Keychain is only used on macOS", KeySize)
+		}
+		var err error
+		if encoded, err = keychainKey(cfg.KeychainService, cfg.KeychainAccount); err != nil {
+			return nil, err
+		}
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil {
+		return nil, fmt.Errorf("encryption key must be base64: %w", err)
+	}
+	return New(key)
+}
+
+// keychainKey reads the key from the Keychain, generating and storing one when missing. The
+// new key is passed to security on stdin, so it doesn't show in the process list.
+func keychainKey(service string, account string) (string, error) {
+	output, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err == nil {
+		return strings.TrimSpace(string(output)), nil
+	}
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return "", fmt.Errorf("failed to read encryption key from the Keychain: %w", err)
+	}
+
+	key := make([]byte, KeySize)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	encoded := base64.StdEncoding.EncodeToString(key)
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -w %s\n",
+		quoteCommand(service), quoteCommand(account), encoded))
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return "", fmt.Errorf("failed to store encryption key in the Keychain: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+	return encoded, nil
+}
+
+// quoteCommand quotes an argument of a command read by security -i
+func quoteCommand(arg string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+}
+
+// Seal encrypts data
+func (c *Cipher) Seal(plaintext []byte) ([]byte, error) {
+	if c == nil {
+		return plaintext, nil
+	}
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+	sealed := append(bytes.Clone(magic), nonce...)
+	return c.aead.Seal(sealed, nonce, plaintext, magic), nil
+}
+
+// Open decrypts data sealed by Seal. Data that isn't encrypted is returned as it is.
+func (c *Cipher) Open(data []byte) ([]byte, error) {
+	if !IsEncrypted(data) {
+		return data, nil
+	}
+	if c == nil {
+		return nil, ErrNoKey
+	}
+	data = data[len(magic):]
+	if len(data) < c.aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data is truncated")
+	}
+	nonce, sealed := data[:c.aead.NonceSize()], data[c.aead.NonceSize():]
+	plaintext, err := c.aead.Open(nil, nonce, sealed, magic)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt, the encryption key may have changed: %w", err)
+	}
+	return plaintext, nil
+}
+
+// IsEncrypted reports whether data was sealed by a cipher
+func IsEncrypted(data []byte) bool {
+	return bytes.HasPrefix(data, magic)
+}
+
+// EncryptFile encrypts a file in place. Files that are encrypted already are left alone.
+func (c *Cipher) EncryptFile(path string) error {
+	if c == nil {
+		return nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if IsEncrypted(data) {
+		return nil
+	}
+	sealed, err := c.Seal(data)
+	if err != nil {
+		return err
+	}
+	return replaceFile(path, sealed)
+}
+
+// DecryptFile decrypts a file in place. Files that aren't encrypted are left alone.
+func (c *Cipher) DecryptFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	if !IsEncrypted(data) {
+		return nil
+	}
+	plaintext, err := c.Open(data)
+	if err != nil {
+		return err
+	}
+	return replaceFile(path, plaintext)
+}
+
+// replaceFile replaces the contents of a file through a temporary file, so it is never left
+// half written
+func replaceFile(path string, data []byte) error {
+	temp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(temp.Name())
+	if _, err := temp.Write(data); err != nil {
+		temp.Close()
+		return err
+	}
+	if err := temp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(temp.Name(), path)
+}
+
+// ReadFile reads a file, decrypting it when it is encrypted
+func (c *Cipher) ReadFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return c.Open(data)
+}
@@ -0,0 +1,591 @@
+package events
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/encryption"
+)
+
+// Event types
+const (
+	TypeCreated          = "created"
+	TypeDeviceSelected   = "device_selected"
+	TypeDeviceLost       = "device_lost"
+	TypeStopped          = "stopped"
+	TypeStatusChanged    = "status_changed"
+	TypeTranscribed      = "transcribed"
+	TypeSummarized       = "summarized"
+	TypeEdited           = "edited"
+	TypeEditUndone       = "edit_undone"
+	TypeFeedbackRecorded = "feedback_recorded"
+	TypeArchived         = "recording_archived"
+	TypeWarning          = "warning_raised"
+	TypeExported         = "export_created"
+	TypeBookmarked       = "bookmark_added"
+	TypeNoteAdded        = "note_added"
+	TypeHighlighted      = "highlights_created"
+	TypeMeetingArchived  = "meeting_archived"
+	TypeTrashed          = "trashed"
+	TypeRestored         = "restored"
+)
+
+// itemsKey holds, in the changes of an event, the elements that changed of array fields that
+// kept their length, by field and index, so editing one segment doesn't store them all
+const itemsKey = "$items"
+
+// derivedFields are the fields of a meeting derived from its latest event, its version and
+// update time, which aren't stored
+var derivedFields = map[string]bool{"version": true, "updated_at": true}
+
+// ErrNothingToUndo is returned when a meeting has no edit left to undo
+var ErrNothingToUndo = errors.New("no edit to undo")
+
+// ErrNotFound is returned for unknown meetings or events
+var ErrNotFound = errors.New("not found")
+
+// Event is a change to a meeting. Changes holds the top-level meeting fields that changed,
+// with null for fields that were removed, and under "$items" the changed elements of array
+// fields that kept their length; folding the changes in order yields the meeting.
+type Event struct {
+	Seq       int64           `json:"seq"`
+	MeetingId string          `json:"meeting_id"`
+	Type      string          `json:"type"`
+	Time      time.Time       `json:"time"`
+	Undoes    int64           `json:"undoes,omitempty"` // Seq of the edit reverted by an edit_undone event
+	Changes   json.RawMessage `json:"changes"`
+}
+
+// state is a meeting as its top-level JSON fields
+type state map[string]json.RawMessage
+
+// Log is an append-only event stream stored as one JSON event per line
+type Log struct {
+	mu        sync.RWMutex
+	file      *os.File
+	cipher    *encryption.Cipher // Encrypts the changes of appended events, nil stores them as JSON
+	events    []Event
+	byMeeting map[string][]int // Meeting ID -> indexes into events
+	states    map[string]state // Current state of every meeting
+	order     []string         // Meeting IDs in creation order
+	seq       int64            // Highest sequence number, kept when the events holding it are removed
+	appended  chan struct{}    // Closed and replaced whenever an event is appended
+	skipped   []string         // Corrupt lines skipped while opening
+}
+
+// Open reads the event log at path, creating it when it doesn't exist yet. A partially
+// written last line, left by a crash, is discarded, and other lines that aren't valid events
+// are skipped and reported by Skipped. With a cipher, the changes of appended events are
+// stored encrypted; events written without one are read as they are.
+func Open(path string, cipher *encryption.Cipher) (*Log, error) {
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, fmt.Errorf("failed to create events directory: %w", err)
+	}
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open events file: %w", err)
+	}
+
+	l := &Log{
+		file:      file,
+		cipher:    cipher,
+		byMeeting: make(map[string][]int),
+		states:    make(map[string]state),
+		appended:  make(chan struct{}),
+	}
+
+	reader := bufio.NewReader(file)
+	var offset int64
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err == io.EOF {
+			if len(bytes.TrimSpace(line)) > 0 {
+				// Drop the incomplete event so the next append starts on a fresh line
+				if err := file.Truncate(offset); err != nil {
+					file.Close()
+					return nil, fmt.Errorf("failed to truncate events file: %w", err)
+				}
+			}
+			break
+		}
+		if err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read events file: %w", err)
+		}
+
+		var event Event
+		if err := json.Unmarshal(line, &event); err != nil {
+			l.skipped = append(l.skipped, fmt.Sprintf("line at offset %d: %v", offset, err))
+			offset += int64(len(line))
+			continue
+		}
+		// A changes that can't be decrypted is a wrong key rather than a corrupt line
+		if event.Changes, err = l.openChanges(event.Changes); err != nil {
+			file.Close()
+			return nil, fmt.Errorf("failed to read event %d: %w", event.Seq, err)
+		}
+		if err := l.apply(event); err != nil {
+			l.skipped = append(l.skipped, fmt.Sprintf("line at offset %d: %v", offset, err))
+		}
+		offset += int64(len(line))
+	}
+
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, err
+	}
+	return l, nil
+}
+
+// Skipped describes the corrupt lines skipped when the log was opened. They are dropped
+// from the file when it is rewritten.
+func (l *Log) Skipped() []string {
+	return l.skipped
+}
+
+// Close closes the events file
+func (l *Log) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
+
+// Record stores the changes between the last recorded state of the meeting and current,
+// a value or its JSON. Nothing is recorded when nothing changed, except for created events.
+// The derived fields of current are left out.
+func (l *Log) Record(meetingId string, eventType string, current any) (*Event, error) {
+	data, ok := current.([]byte)
+	if !ok {
+		var err error
+		if data, err = json.Marshal(current); err != nil {
+			return nil, err
+		}
+	}
+	var next state
+	if err := json.Unmarshal(data, &next); err != nil {
+		return nil, fmt.Errorf("meeting state must be a JSON object: %w", err)
+	}
+	for key, value := range next {
+		if string(value) == "null" || derivedFields[key] {
+			delete(next, key) // Null fields are stored as absent
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	changes := diff(l.states[meetingId], next)
+	if len(changes) == 0 && eventType != TypeCreated {
+		return nil, nil
+	}
+	return l.append(meetingId, eventType, 0, changes)
+}
+
+// Undo appends an edit_undone event that restores the fields changed by the most recent
+// edit of the meeting that wasn't undone yet
+func (l *Log) Undo(meetingId string) (*Event, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	indexes, ok := l.byMeeting[meetingId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+
+	undone := make(map[int64]bool)
+	for i := len(indexes) - 1; i >= 0; i-- {
+		event := l.events[indexes[i]]
+		switch {
+		case event.Type == TypeEditUndone:
+			undone[event.Undoes] = true
+		case event.Type == TypeEdited && !undone[event.Seq]:
+			var edited state
+			if err := json.Unmarshal(event.Changes, &edited); err != nil {
+				return nil, err
+			}
+			before := l.fold(meetingId, event.Seq-1)
+
+			changes := make(state, len(edited))
+			for key := range edited {
+				changes[key] = before[key] // Nil when the field didn't exist before the edit
+			}
+			if items, ok := edited[itemsKey]; ok {
+				delete(changes, itemsKey)
+				var fields map[string]json.RawMessage
+				if err := json.Unmarshal(items, &fields); err != nil {
+					return nil, err
+				}
+				for key := range fields {
+					changes[key] = before[key]
+				}
+			}
+			return l.append(meetingId, TypeEditUndone, event.Seq, changes)
+		}
+	}
+	return nil, ErrNothingToUndo
+}
+
+// State returns the current state of the meeting as JSON
+func (l *Log) State(meetingId string) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	current, ok := l.states[meetingId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	return json.Marshal(current)
+}
+
+// StateAt replays the events of the meeting up to and including seq and returns the
+// state it had at that point as JSON
+func (l *Log) StateAt(meetingId string, seq int64) ([]byte, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	indexes, ok := l.byMeeting[meetingId]
+	if !ok || l.events[indexes[0]].Seq > seq {
+		return nil, ErrNotFound
+	}
+	return json.Marshal(l.fold(meetingId, seq))
+}
+
+// Meetings returns the IDs of all meetings in the log in creation order
+func (l *Log) Meetings() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]string(nil), l.order...)
+}
+
+// Events returns up to limit events with a sequence number above since, for a single
+// meeting or for all meetings when meetingId is empty. A limit of 0 returns all of them.
+func (l *Log) Events(meetingId string, since int64, limit int) []Event {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	result := []Event{}
+	add := func(event Event) bool {
+		if event.Seq <= since {
+			return true
+		}
+		result = append(result, event)
+		return limit <= 0 || len(result) < limit
+	}
+
+	if meetingId == "" {
+		for _, event := range l.events {
+			if !add(event) {
+				break
+			}
+		}
+		return result
+	}
+	for _, i := range l.byMeeting[meetingId] {
+		if !add(l.events[i]) {
+			break
+		}
+	}
+	return result
+}
+
+// Latest returns the most recent event of a meeting
+func (l *Log) Latest(meetingId string) (*Event, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	indexes, ok := l.byMeeting[meetingId]
+	if !ok {
+		return nil, ErrNotFound
+	}
+	event := l.events[indexes[len(indexes)-1]]
+	return &event, nil
+}
+
+// Event returns a single event of a meeting
+func (l *Log) Event(meetingId string, seq int64) (*Event, error) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, i := range l.byMeeting[meetingId] {
+		if l.events[i].Seq == seq {
+			event := l.events[i]
+			return &event, nil
+		}
+	}
+	return nil, ErrNotFound
+}
+
+// append writes a new event and applies it; callers must hold the write lock
+func (l *Log) append(meetingId string, eventType string, undoes int64, changes state) (*Event, error) {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return nil, err
+	}
+
+	event := Event{
+		Seq:       l.seq + 1,
+		MeetingId: meetingId,
+		Type:      eventType,
+		Time:      time.Now(),
+		Undoes:    undoes,
+		Changes:   data,
+	}
+
+	line, err := l.encode(event)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := l.file.Write(line); err != nil {
+		return nil, fmt.Errorf("failed to write event: %w", err)
+	}
+	if err := l.file.Sync(); err != nil {
+		return nil, fmt.Errorf("failed to write event: %w", err)
+	}
+
+	if err := l.apply(event); err != nil {
+		return nil, err
+	}
+	close(l.appended)
+	l.appended = make(chan struct{})
+	return &event, nil
+}
+
+// Remove deletes all events of a meeting for good, rewriting the events file without them
+func (l *Log) Remove(meetingId string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.byMeeting[meetingId]; !ok {
+		return ErrNotFound
+	}
+	var kept []Event
+	for _, event := range l.events {
+		if event.MeetingId != meetingId {
+			kept = append(kept, event)
+		}
+	}
+
+	// The rewritten file replaces the old one at once, so a crash leaves either of them
+	path := l.file.Name()
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to create events file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+	writer := bufio.NewWriter(tmp)
+	for _, event := range kept {
+		line, err := l.encode(event)
+		if err != nil {
+			tmp.Close()
+			return err
+		}
+		writer.Write(line)
+	}
+	if err := writer.Flush(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write events file: %w", err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write events file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write events file: %w", err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace events file: %w", err)
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf("failed to open events file: %w", err)
+	}
+	if _, err := file.Seek(0, io.SeekEnd); err != nil {
+		file.Close()
+		return err
+	}
+	l.file.Close()
+	l.file = file
+
+	l.events = nil
+	l.byMeeting = make(map[string][]int)
+	l.states = make(map[string]state)
+	l.order = nil
+	for _, event := range kept {
+		if err := l.apply(event); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encode returns the line an event is stored as, with its changes sealed
+func (l *Log) encode(event Event) ([]byte, error) {
+	var err error
+	if event.Changes, err = l.sealChanges(event.Changes); err != nil {
+		return nil, err
+	}
+	line, err := json.Marshal(event)
+	if err != nil {
+		return nil, err
+	}
+	return append(line, '\n'), nil
+}
+
+// Appended returns a channel that is closed when the next event is appended, to wait for
+// changes without polling
+func (l *Log) Appended() <-chan struct{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.appended
+}
+
+// sealChanges encrypts the changes of an event into a base64 JSON string, when the log has
+// a cipher
+func (l *Log) sealChanges(changes json.RawMessage) (json.RawMessage, error) {
+	if l.cipher == nil {
+		return changes, nil
+	}
+	sealed, err := l.cipher.Seal(changes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt event: %w", err)
+	}
+	return json.Marshal(base64.StdEncoding.EncodeToString(sealed))
+}
+
+// openChanges decrypts the changes of an event stored encrypted, which are a JSON string
+// instead of an object
+func (l *Log) openChanges(changes json.RawMessage) (json.RawMessage, error) {
+	var encoded string
+	if err := json.Unmarshal(changes, &encoded); err != nil {
+		return changes, nil
+	}
+	sealed, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, err
+	}
+	return l.cipher.Open(sealed)
+}
+
+// apply adds an event to the in-memory indexes and folds it into the meeting state
+func (l *Log) apply(event Event) error {
+	var changes state
+	if err := json.Unmarshal(event.Changes, &changes); err != nil {
+		return fmt.Errorf("invalid changes in event %d: %w", event.Seq, err)
+	}
+
+	current, ok := l.states[event.MeetingId]
+	if !ok {
+		current = make(state)
+		l.states[event.MeetingId] = current
+		l.order = append(l.order, event.MeetingId)
+	}
+	merge(current, changes)
+
+	l.byMeeting[event.MeetingId] = append(l.byMeeting[event.MeetingId], len(l.events))
+	l.events = append(l.events, event)
+	l.seq = max(l.seq, event.Seq)
+	return nil
+}
+
+// fold replays the events of a meeting up to and including seq; callers must hold the lock
+func (l *Log) fold(meetingId string, seq int64) state {
+	result := make(state)
+	for _, i := range l.byMeeting[meetingId] {
+		event := l.events[i]
+		if event.Seq > seq {
+			break
+		}
+		var changes state
+		if err := json.Unmarshal(event.Changes, &changes); err == nil {
+			merge(result, changes)
+		}
+	}
+	return result
+}
+
+// diff returns the fields of next that differ from previous, with nil for removed fields.
+// Arrays that kept their length and of which less than half the elements changed are
+// stored as their changed elements under itemsKey.
+func diff(previous, next state) state {
+	changes := make(state)
+	items := make(map[string]map[int]json.RawMessage)
+	for key, value := range next {
+		old, ok := previous[key]
+		if ok && bytes.Equal(old, value) {
+			continue
+		}
+		if changed, ok := changedItems(old, value); ok {
+			items[key] = changed
+			continue
+		}
+		changes[key] = value
+	}
+	for key := range previous {
+		if _, ok := next[key]; !ok {
+			changes[key] = nil
+		}
+	}
+	if len(items) > 0 {
+		changes[itemsKey], _ = json.Marshal(items)
+	}
+	return changes
+}
+
+// changedItems returns the elements of the array next that differ from those of the array
+// previous by index, when both have the same length and less than half of them changed
+func changedItems(previous, next json.RawMessage) (map[int]json.RawMessage, bool) {
+	if len(previous) == 0 || previous[0] != '[' || len(next) == 0 || next[0] != '[' {
+		return nil, false
+	}
+	var before, after []json.RawMessage
+	if json.Unmarshal(previous, &before) != nil || json.Unmarshal(next, &after) != nil || len(before) != len(after) {
+		return nil, false
+	}
+	changed := make(map[int]json.RawMessage)
+	for i := range after {
+		if !bytes.Equal(before[i], after[i]) {
+			changed[i] = after[i]
+		}
+	}
+	return changed, len(changed)*2 < len(after)
+}
+
+// merge applies changes to a state, removing fields that changed to null
+func merge(target, changes state) {
+	for key, value := range changes {
+		if key == itemsKey {
+			continue
+		}
+		if value == nil || string(value) == "null" {
+			delete(target, key)
+			continue
+		}
+		target[key] = value
+	}
+	var items map[string]map[int]json.RawMessage
+	if json.Unmarshal(changes[itemsKey], &items) != nil {
+		return
+	}
+	for key, changed := range items {
+		var elements []json.RawMessage
+		if json.Unmarshal(target[key], &elements) != nil {
+			continue
+		}
+		for i, value := range changed {
+			if i >= 0 && i < len(elements) {
+				elements[i] = value
+			}
+		}
+		target[key], _ = json.Marshal(elements)
+	}
+}
@@ -0,0 +1,273 @@
+// Package frontmatter renders the YAML frontmatter of meeting notes from the meeting fields,
+// so Dataview queries don't depend on what the summarizer writes
+package frontmatter
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Meeting fields that can be mapped to frontmatter keys, besides meta.<key>
+const (
+	FieldID              = "id"
+	FieldTitle           = "title"
+	FieldType            = "type"
+	FieldDate            = "date"     // 2006-01-02
+	FieldDateTime        = "datetime" // 2006-01-02T15:04:05
+	FieldUpdated         = "updated"  // Date of the last edit, or the meeting date
+	FieldDuration        = "duration" // Minutes
+	FieldDurationSeconds = "duration_seconds"
+	FieldParticipants    = "participants" // Wikilinks
+	FieldTags            = "tags"
+	FieldStatus          = "status"
+	FieldOwner           = "owner"
+	FieldTemplate        = "template"
+	FieldProject         = "project"           // The project metadata entry
+	FieldActionItemCount = "action_item_count" // Items under the Action Items heading of the summary
+)
+
+// metaPrefix maps a metadata entry, e.g. meta.customer_id
+const metaPrefix = "meta."
+
+var fields = []string{
+	FieldID, FieldTitle, FieldType, FieldDate, FieldDateTime, FieldUpdated, FieldDuration,
+	FieldDurationSeconds, FieldParticipants, FieldTags, FieldStatus, FieldOwner, FieldTemplate,
+	FieldProject, FieldActionItemCount,
+}
+
+// Validate checks that every mapping has a key and either a known field or a literal value
+func Validate(mapping []config.FrontmatterField) error {
+	seen := make(map[string]bool)
+	for _, field := range mapping {
+		if strings.TrimSpace(field.Key) == "" {
+			return fmt.Errorf("frontmatter key cannot be empty")
+		}
+		if seen[field.Key] {
+			return fmt.Errorf("frontmatter key %q is mapped twice", field.Key)
+		}
+		seen[field.Key] = true
+
+		switch {
+		case field.Field == "" && field.Value == "":
+			return fmt.Errorf("frontmatter key %q needs a field or a value", field.Key)
+		case field.Field != "" && field.Value != "":
+			return fmt.Errorf("frontmatter key %q can't have both a field and a value", field.Key)
+		case field.Field != "" && !slices.Contains(fields, field.Field) && !strings.HasPrefix(field.Field, metaPrefix):
+			return fmt.Errorf("frontmatter key %q maps unknown field %q, use one of %s or meta.<key>", field.Key, field.Field, strings.Join(fields, ", "))
+		}
+	}
+	return nil
+}
+
+// Render returns the frontmatter block of a meeting note. Metadata entries that aren't
+// mapped explicitly are added after the mapped keys. link formats participant names as
+// wikilinks. Nothing is returned when there is nothing to write.
+func Render(meeting *types.Meeting, mapping []config.FrontmatterField, link func(name string) string) string {
+	var b strings.Builder
+	mapped := make(map[string]bool)
+	for _, field := range mapping {
+		mapped[field.Key] = true
+		if field.Value != "" {
+			writeScalar(&b, field.Key, field.Value)
+			continue
+		}
+		if key, ok := strings.CutPrefix(field.Field, metaPrefix); ok {
+			if value, ok := meeting.Metadata[key]; ok {
+				writeScalar(&b, field.Key, value)
+			}
+			continue
+		}
+
+		switch field.Field {
+		case FieldID:
+			writeScalar(&b, field.Key, meeting.Id)
+		case FieldTitle:
+			writeScalar(&b, field.Key, meeting.Title)
+		case FieldType:
+			if meeting.Type != "" {
+				writeScalar(&b, field.Key, meeting.Type)
+			}
+		case FieldDate:
+			b.WriteString(fmt.Sprintf("%s: %s\n", quoteKey(field.Key), meeting.CreatedAt.Format("2006-01-02")))
+		case FieldDateTime:
+			b.WriteString(fmt.Sprintf("%s: %s\n", quoteKey(field.Key), meeting.CreatedAt.Format("2006-01-02T15:04:05")))
+		case FieldUpdated:
+			updated := meeting.CreatedAt
+			if meeting.EditedAt != nil {
+				updated = *meeting.EditedAt
+			}
+			b.WriteString(fmt.Sprintf("%s: %s\n", quoteKey(field.Key), updated.Format("2006-01-02")))
+		case FieldDuration:
+			b.WriteString(fmt.Sprintf("%s: %d\n", quoteKey(field.Key), int(math.Round(float64(duration(meeting))/60))))
+		case FieldDurationSeconds:
+			b.WriteString(fmt.Sprintf("%s: %d\n", quoteKey(field.Key), duration(meeting)))
+		case FieldParticipants:
+			links := make([]string, 0, len(meeting.Participants))
+			for _, participant := range meeting.Participants {
+				links = append(links, link(participant))
+			}
+			writeList(&b, field.Key, links)
+		case FieldTags:
+			writeList(&b, field.Key, meeting.Tags)
+		case FieldStatus:
+			writeScalar(&b, field.Key, meeting.Status)
+		case FieldOwner:
+			if meeting.Owner != "" {
+				writeScalar(&b, field.Key, meeting.Owner)
+			}
+		case FieldTemplate:
+			if meeting.Template != "" {
+				writeScalar(&b, field.Key, meeting.Template)
+			}
+		case FieldProject:
+			if project := meeting.Metadata[FieldProject]; project != "" {
+				writeScalar(&b, field.Key, project)
+			}
+		case FieldActionItemCount:
+			b.WriteString(fmt.Sprintf("%s: %d\n", quoteKey(field.Key), CountActionItems(meeting.Summary)))
+		}
+	}
+
+	keys := make([]string, 0, len(meeting.Metadata))
+	for key := range meeting.Metadata {
+		if !mapped[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		writeScalar(&b, key, meeting.Metadata[key])
+	}
+
+	if b.Len() == 0 {
+		return ""
+	}
+	return "---\n" + b.String() + "---\n\n"
+}
+
+// Strip removes a leading frontmatter block, which summarizers sometimes add despite the prompt
+func Strip(body string) string {
+	if !strings.HasPrefix(body, "---\n") {
+		return body
+	}
+	end := strings.Index(body[4:], "\n---")
+	if end < 0 {
+		return body
+	}
+	rest := body[4+end+4:]
+	if rest != "" && !strings.HasPrefix(rest, "\n") {
+		return body // The closing line is not a delimiter on its own
+	}
+	return strings.TrimLeft(rest, "\n")
+}
+
+// CountActionItems counts the list items under the Action Items heading of a summary,
+// ignoring the placeholder written when there are none
+func CountActionItems(summary string) int {
+	return len(ActionItems(summary))
+}
+
+// ActionItems returns the list items under the Action Items heading of a summary, ignoring
+// the placeholder written when there are none
+func ActionItems(summary string) []string {
+	var items []string
+	inSection := false
+	for _, line := range strings.Split(summary, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "#") {
+			heading := strings.ToLower(strings.TrimSpace(strings.TrimLeft(trimmed, "#")))
+			inSection = heading == "action items"
+			continue
+		}
+		if !inSection {
+			continue
+		}
+		item, ok := strings.CutPrefix(trimmed, "- ")
+		if !ok {
+			item, ok = strings.CutPrefix(trimmed, "* ")
+		}
+		item = strings.TrimSpace(item)
+		if ok && !strings.EqualFold(strings.TrimSuffix(item, "."), "None identified") {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// duration returns the meeting length in seconds, estimated from the transcript segments
+// when the recording length is unknown
+func duration(meeting *types.Meeting) int {
+	if meeting.Duration > 0 || len(meeting.Segments) == 0 {
+		return meeting.Duration
+	}
+	return int(math.Round(meeting.Segments[len(meeting.Segments)-1].End))
+}
+
+// writeScalar writes a quoted string value
+func writeScalar(b *strings.Builder, key, value string) {
+	b.WriteString(quoteKey(key) + ": " + quote(value) + "\n")
+}
+
+// writeList writes a block list of quoted strings, or an empty list
+func writeList(b *strings.Builder, key string, values []string) {
+	if len(values) == 0 {
+		b.WriteString(quoteKey(key) + ": []\n")
+		return
+	}
+	b.WriteString(quoteKey(key) + ":\n")
+	for _, value := range values {
+		b.WriteString("  - " + quote(value) + "\n")
+	}
+}
+
+// quoteKey quotes keys that aren't plain YAML identifiers
+func quoteKey(key string) string {
+	for _, r := range key {
+		if !(r == '_' || r == '-' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9') {
+			return quote(key)
+		}
+	}
+	return key
+}
+
+// quote writes a value as a YAML double-quoted string. Unlike Go quoting, it only uses the
+// escapes YAML defines and keeps printable characters as they are.
+func quote(value string) string {
+	var b strings.Builder
+	b.WriteByte('"')
+	for _, r := range strings.ToValidUTF8(value, "\uFFFD") {
+		switch r {
+		case '"':
+			b.WriteString(`\"`)
+		case '\\':
+			b.WriteString(`\\`)
+		case '\n':
+			b.WriteString(`\n`)
+		case '\r':
+			b.WriteString(`\r`)
+		case '\t':
+			b.WriteString(`\t`)
+		case 0x85:
+			b.WriteString(`\N`)
+		case 0x2028:
+			b.WriteString(`\L`)
+		case 0x2029:
+			b.WriteString(`\P`)
+		default:
+			// YAML doesn't allow control characters or the byte order mark unescaped
+			if r < 0x20 || r >= 0x7f && r <= 0x9f || r == 0xfeff || r == 0xfffe || r == 0xffff {
+				fmt.Fprintf(&b, `\u%04X`, r)
+				continue
+			}
+			b.WriteRune(r)
+		}
+	}
+	b.WriteByte('"')
+	return b.String()
+}
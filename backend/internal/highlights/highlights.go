@@ -0,0 +1,95 @@
+// Package highlights picks the most information-dense minutes of a meeting for its
+// highlight reel
+package highlights
+
+import (
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/chapters"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Scoring parameters
+const (
+	windowLength    = 60.0 // Seconds per candidate clip
+	meetingKeywords = 25   // Keywords of the whole meeting that make a minute dense
+	minWindowWords  = 10   // Minutes with fewer content words have no keyword density
+
+	keywordWeight  = 10.0 // Per unit of keyword density, the share of words that are keywords
+	decisionWeight = 3.0  // Per decision phrase
+	bookmarkWeight = 5.0  // Per bookmark
+)
+
+// decisionPhrase matches phrases that announce decisions and action items, in English and Dutch
+var decisionPhrase = regexp.MustCompile(`\b(we decided|decided to|decision|agreed|agree on|let's go with|we will|we'll|action item|next step|deadline|besloten|afgesproken|we gaan|actiepunt|volgende stap)\b`)
+
+// Select scores every minute of the transcript by the density of the meeting's keywords,
+// the decisions made and the bookmarks set in it, and returns the best minutes in order.
+// Adjacent minutes are merged into a single clip.
+func Select(segments []types.Segment, bookmarks []types.Bookmark, minutes int) []types.Highlight {
+	if len(segments) == 0 || minutes <= 0 {
+		return nil
+	}
+	end := segments[len(segments)-1].End
+	windows := int(math.Ceil(end / windowLength))
+	if windows == 0 {
+		return nil
+	}
+
+	keywords := make(map[string]bool)
+	for _, keyword := range chapters.Keywords(segments, meetingKeywords) {
+		keywords[keyword] = true
+	}
+
+	// Segments belong to the minute they start in
+	scores := make([]float64, windows)
+	words := make([]int, windows)
+	hits := make([]int, windows)
+	for _, segment := range segments {
+		window := min(int(segment.Start/windowLength), windows-1)
+		for word, count := range chapters.CountWords(segment.Text) {
+			words[window] += count
+			if keywords[word] {
+				hits[window] += count
+			}
+		}
+		scores[window] += decisionWeight * float64(len(decisionPhrase.FindAllString(strings.ToLower(segment.Text), -1)))
+	}
+	for window := range scores {
+		if words[window] >= minWindowWords {
+			scores[window] += keywordWeight * float64(hits[window]) / float64(words[window])
+		}
+	}
+	for _, bookmark := range bookmarks {
+		if bookmark.At >= 0 && bookmark.At < end {
+			scores[min(int(bookmark.At/windowLength), windows-1)] += bookmarkWeight
+		}
+	}
+
+	// Keep the best minutes, preferring earlier ones on equal scores
+	ranked := make([]int, 0, windows)
+	for window, score := range scores {
+		if score > 0 {
+			ranked = append(ranked, window)
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool { return scores[ranked[i]] > scores[ranked[j]] })
+	ranked = ranked[:min(minutes, len(ranked))]
+	sort.Ints(ranked)
+
+	var selected []types.Highlight
+	for _, window := range ranked {
+		start := float64(window) * windowLength
+		clipEnd := math.Min(start+windowLength, end)
+		if last := len(selected) - 1; last >= 0 && selected[last].End == start {
+			selected[last].End = clipEnd
+			selected[last].Score = math.Max(selected[last].Score, scores[window])
+			continue
+		}
+		selected = append(selected, types.Highlight{Start: start, End: clipEnd, Score: scores[window]})
+	}
+	return selected
+}
@@ -1,19 +1,58 @@
 package logger
 
 import (
+	"fmt"
+	"io"
 	"log/slog"
 	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
 )
 
 type Logger struct {
 	Info  func(msg string, args ...any)
 	Error func(msg string, args ...any)
 	Debug func(msg string, args ...any)
+
+	// Set for loggers made with New, whose level can be changed at runtime
+	level      *slog.LevelVar
+	configured slog.Level // Level the logger returns to after a temporary change
+	mu         sync.Mutex
+	revert     *time.Timer
+	until      time.Time // When a temporary level ends, zero when the level is permanent
+	recent     *recentLog
 }
 
-func NewLogger() *Logger {
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
-		Level: slog.LevelDebug,
+// New creates a logger writing JSON lines at the configured level to stdout or to a log
+// file rotated by size. The last lines are also kept in memory for diagnostics.
+func New(cfg config.LogConfig) (*Logger, error) {
+	level, err := ParseLevel(cfg.Level)
+	if err != nil {
+		return nil, err
+	}
+
+	var output io.Writer
+	switch cfg.Output {
+	case "", config.LogOutputStdout:
+		output = os.Stdout
+	case config.LogOutputFile:
+		file, err := openRotatingFile(cfg.File, int64(cfg.MaxSizeMB)<<20, cfg.MaxBackups)
+		if err != nil {
+			return nil, fmt.Errorf("failed to open log file: %w", err)
+		}
+		output = file
+	default:
+		return nil, fmt.Errorf("unknown log output %q, use %s or %s", cfg.Output, config.LogOutputStdout, config.LogOutputFile)
+	}
+
+	levelVar := &slog.LevelVar{}
+	levelVar.Set(level)
+	recent := &recentLog{}
+	logger := slog.New(slog.NewJSONHandler(io.MultiWriter(output, recent), &slog.HandlerOptions{
+		Level: levelVar,
 	}))
 
 	return &Logger{
@@ -26,6 +65,91 @@ func NewLogger() *Logger {
 		Debug: func(msg string, args ...any) {
 			logger.Debug(msg, args...)
 		},
+		level:      levelVar,
+		configured: level,
+		recent:     recent,
+	}, nil
+}
+
+// ParseLevel parses a level name: debug, info, warn or error. Empty is info.
+func ParseLevel(name string) (slog.Level, error) {
+	switch strings.ToLower(name) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "", "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q, use debug, info, warn or error", name)
+}
+
+// LevelState describes the level of a logger
+type LevelState struct {
+	Level      string     `json:"level"`
+	Configured string     `json:"configured"`      // Level set in the config
+	Until      *time.Time `json:"until,omitempty"` // When the level returns to the configured one
+}
+
+// Level returns the current level of the logger
+func (l *Logger) Level() LevelState {
+	if l.level == nil {
+		return LevelState{}
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state := LevelState{
+		Level:      strings.ToLower(l.level.Level().String()),
+		Configured: strings.ToLower(l.configured.String()),
+	}
+	if !l.until.IsZero() {
+		until := l.until
+		state.Until = &until
+	}
+	return state
+}
+
+// SetLevel changes the level of the logger, e.g. to debug while diagnosing a problem. With a
+// positive duration the logger returns to the configured level once it has passed; otherwise
+// the level stays until the next change or restart.
+func (l *Logger) SetLevel(level slog.Level, duration time.Duration) error {
+	if l.level == nil {
+		return fmt.Errorf("the level of this logger can't be changed")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.revert != nil {
+		l.revert.Stop()
+		l.revert = nil
 	}
+	l.until = time.Time{}
+	l.level.Set(level)
+	if duration > 0 {
+		l.until = time.Now().Add(duration)
+		var revert *time.Timer
+		revert = time.AfterFunc(duration, func() {
+			l.mu.Lock()
+			defer l.mu.Unlock()
+			if l.revert != revert {
+				return // Replaced by a later change
+			}
+			l.level.Set(l.configured)
+			l.revert = nil
+			l.until = time.Time{}
+		})
+		l.revert = revert
+	}
+	return nil
+}
 
+// Recent returns the last log lines, oldest first, or nil for loggers not made with New
+func (l *Logger) Recent() []byte {
+	if l.recent == nil {
+		return nil
+	}
+	return l.recent.bytes()
 }
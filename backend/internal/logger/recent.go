@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"bytes"
+	"sync"
+)
+
+// recentLines is the number of log lines kept in memory for diagnostics
+const recentLines = 2000
+
+// recentLog keeps the last log lines, whatever the output, so they can be attached to a
+// bug report. The handler writes every record with a single call.
+type recentLog struct {
+	mu    sync.Mutex
+	lines [][]byte
+	next  int // Position of the oldest line once the ring is full
+}
+
+func (r *recentLog) Write(p []byte) (int, error) {
+	line := bytes.Clone(p)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if len(r.lines) < recentLines {
+		r.lines = append(r.lines, line)
+	} else {
+		r.lines[r.next] = line
+		r.next = (r.next + 1) % recentLines
+	}
+	return len(p), nil
+}
+
+// bytes returns the kept lines, oldest first
+func (r *recentLog) bytes() []byte {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var b bytes.Buffer
+	for i := range r.lines {
+		b.Write(r.lines[(r.next+i)%len(r.lines)])
+	}
+	return b.Bytes()
+}
@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// rotatingFile is a log file that is moved to path.1 once it grows past maxSize, shifting
+// the older backups to path.2 and up and dropping those beyond maxBackups
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64 // 0 never rotates
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openRotatingFile opens the log file for appending, creating it and its directory
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	if path == "" {
+		return nil, fmt.Errorf("log.file is required when logging to a file")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		return nil, err
+	}
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		if err := f.rotate(); err != nil {
+			// Keep logging to the full file rather than losing the line
+			fmt.Fprintf(os.Stderr, "Failed to rotate log file: %v\n", err)
+		}
+	}
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+	return n, err
+}
+
+// rotate moves the log file to the first backup and starts a new one
+func (f *rotatingFile) rotate() error {
+	if err := f.file.Close(); err != nil {
+		return err
+	}
+	if f.maxBackups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", f.path, f.maxBackups))
+		for i := f.maxBackups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", f.path, i), fmt.Sprintf("%s.%d", f.path, i+1))
+		}
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			f.open()
+			return err
+		}
+	} else if err := os.Remove(f.path); err != nil {
+		f.open()
+		return err
+	}
+	return f.open()
+}
@@ -0,0 +1,162 @@
+// Package mcp exposes the meeting store as a Model Context Protocol server over the
+// streamable HTTP transport, so local AI agents can list, read and search meetings.
+package mcp
+
+import (
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/logger"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+)
+
+// protocolVersion is the newest MCP revision this server implements
+const protocolVersion = "2025-03-26"
+
+// supportedVersions are the protocol revisions accepted during initialization
+var supportedVersions = map[string]bool{
+	"2024-11-05": true,
+	"2025-03-26": true,
+	"2025-06-18": true,
+}
+
+// maxRequestSize limits JSON-RPC request bodies
+const maxRequestSize = 1 << 20
+
+// JSON-RPC error codes
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+type request struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type response struct {
+	JSONRPC string          `json:"jsonrpc"`
+	Id      json.RawMessage `json:"id"`
+	Result  interface{}     `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Server answers MCP requests from the meetings held by the transcriber service
+type Server struct {
+	transcriber *transcriber.TranscriberService
+	logger      *logger.Logger
+	tools       []tool
+}
+
+// NewServer creates an MCP server backed by the transcriber service
+func NewServer(transcriber *transcriber.TranscriberService, logger *logger.Logger) *Server {
+	s := &Server{transcriber: transcriber, logger: logger}
+	s.tools = s.registerTools()
+	return s
+}
+
+// ServeHTTP handles a single JSON-RPC message posted to the MCP endpoint. Responses are
+// always plain JSON; the server never opens an SSE stream.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Requiring JSON forces a CORS preflight, so arbitrary web pages can't post to the server
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxRequestSize))
+	if err != nil {
+		s.write(w, response{Error: &rpcError{Code: codeParseError, Message: "failed to read request"}})
+		return
+	}
+
+	var req request
+	if err := json.Unmarshal(body, &req); err != nil {
+		s.write(w, response{Error: &rpcError{Code: codeParseError, Message: "invalid JSON"}})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		s.write(w, response{Id: req.Id, Error: &rpcError{Code: codeInvalidRequest, Message: "invalid JSON-RPC request"}})
+		return
+	}
+
+	// Notifications don't get a response
+	if len(req.Id) == 0 {
+		w.WriteHeader(http.StatusAccepted)
+		return
+	}
+
+	result, rpcErr := s.dispatch(auth.FromContext(r.Context()), req)
+	s.write(w, response{Id: req.Id, Result: result, Error: rpcErr})
+}
+
+// dispatch routes a request of the principal to its method handler
+func (s *Server) dispatch(principal *auth.Principal, req request) (interface{}, *rpcError) {
+	switch req.Method {
+	case "initialize":
+		return s.initialize(req.Params)
+	case "ping":
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]interface{}{"tools": s.tools}, nil
+	case "tools/call":
+		return s.callTool(principal, req.Params)
+	default:
+		return nil, &rpcError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+func (s *Server) initialize(params json.RawMessage) (interface{}, *rpcError) {
+	var init struct {
+		ProtocolVersion string `json:"protocolVersion"`
+		ClientInfo      struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"clientInfo"`
+	}
+	if err := json.Unmarshal(params, &init); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid initialize params"}
+	}
+	s.logger.Info("MCP client connected", "client", init.ClientInfo.Name, "version", init.ClientInfo.Version)
+
+	// Agree on the client's version when we support it, otherwise offer ours
+	version := protocolVersion
+	if supportedVersions[init.ProtocolVersion] {
+		version = init.ProtocolVersion
+	}
+
+	return map[string]interface{}{
+		"protocolVersion": version,
+		"capabilities": map[string]interface{}{
+			"tools": map[string]interface{}{},
+		},
+		"serverInfo": map[string]string{
+			"name":    "transcriber",
+			"version": "1.0.0",
+		},
+		"instructions": "Meetings recorded and transcribed on this machine. Use search_meetings or list_meetings to find a meeting, then get_meeting for its summary and transcript.",
+	}, nil
+}
+
+func (s *Server) write(w http.ResponseWriter, resp response) {
+	resp.JSONRPC = "2.0"
+	if resp.Id == nil {
+		resp.Id = json.RawMessage("null")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(resp); err != nil {
+		s.logger.Error("Failed to write MCP response", "error", err)
+	}
+}
@@ -0,0 +1,215 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/auth"
+	"github.com/martijnspitter/transcriber/internal/transcriber"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// tool is an MCP tool definition with its implementation
+type tool struct {
+	Name        string                 `json:"name"`
+	Description string                 `json:"description"`
+	InputSchema map[string]interface{} `json:"inputSchema"`
+
+	// The principal is the authenticated caller, nil when authentication is disabled
+	call func(principal *auth.Principal, args json.RawMessage) (string, error)
+}
+
+// object returns a JSON schema for an object with the given properties
+func object(properties map[string]interface{}, required ...string) map[string]interface{} {
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+func property(kind, description string) map[string]interface{} {
+	return map[string]interface{}{"type": kind, "description": description}
+}
+
+func (s *Server) registerTools() []tool {
+	return []tool{
+		{
+			Name:        "list_meetings",
+			Description: "List recorded meetings, newest first, with their status, participants and tags.",
+			InputSchema: object(map[string]interface{}{
+				"filter": property("string", `Optional filter expression of key:value terms, e.g. "since:30d tag:standup participant:alice"`),
+				"limit":  property("integer", "Maximum number of meetings to return (default 20)"),
+			}),
+			call: s.listMeetings,
+		},
+		{
+			Name:        "get_meeting",
+			Description: "Get the summary and full timestamped transcript of a meeting.",
+			InputSchema: object(map[string]interface{}{
+				"meeting_id": property("string", "ID of the meeting"),
+			}, "meeting_id"),
+			call: s.getMeeting,
+		},
+		{
+			Name:        "search_meetings",
+			Description: "Search meeting titles, summaries and transcripts for a phrase and return the matching transcript lines.",
+			InputSchema: object(map[string]interface{}{
+				"query": property("string", "Phrase to search for (case-insensitive)"),
+				"limit": property("integer", "Maximum number of meetings to return (default 10)"),
+			}, "query"),
+			call: s.searchMeetings,
+		},
+	}
+}
+
+// callTool runs a tool. Tool failures are reported in the result so the agent can see them.
+func (s *Server) callTool(principal *auth.Principal, params json.RawMessage) (interface{}, *rpcError) {
+	var call struct {
+		Name      string          `json:"name"`
+		Arguments json.RawMessage `json:"arguments"`
+	}
+	if err := json.Unmarshal(params, &call); err != nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: "invalid tools/call params"}
+	}
+	if len(call.Arguments) == 0 {
+		call.Arguments = json.RawMessage("{}")
+	}
+
+	for _, t := range s.tools {
+		if t.Name != call.Name {
+			continue
+		}
+
+		text, err := t.call(principal, call.Arguments)
+		isError := err != nil
+		if isError {
+			text = err.Error()
+		}
+		return map[string]interface{}{
+			"content": []map[string]string{{"type": "text", "text": text}},
+			"isError": isError,
+		}, nil
+	}
+
+	return nil, &rpcError{Code: codeInvalidParams, Message: "unknown tool: " + call.Name}
+}
+
+func (s *Server) listMeetings(principal *auth.Principal, args json.RawMessage) (string, error) {
+	var input struct {
+		Filter string `json:"filter"`
+		Limit  int    `json:"limit"`
+	}
+	if err := json.Unmarshal(args, &input); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if input.Limit <= 0 {
+		input.Limit = 20
+	}
+
+	filter, err := transcriber.ParseMeetingFilter(input.Filter)
+	if err != nil {
+		return "", err
+	}
+	if owner := principal.Owner(); owner != "" {
+		filter.Owner = owner
+	}
+	meetings, total := s.transcriber.ListMeetings(transcriber.ListOptions{Filter: filter, Limit: input.Limit})
+	if total == 0 {
+		return "No meetings found.", nil
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%d of %d meetings:\n", len(meetings), total)
+	for _, meeting := range meetings {
+		fmt.Fprintf(&b, "- %s (id: %s, %s, %s, status: %s)", meeting.Title, meeting.Id,
+			meeting.CreatedAt.Format(time.DateTime), time.Duration(meeting.Duration)*time.Second, meeting.Status)
+		if len(meeting.Participants) > 0 {
+			fmt.Fprintf(&b, " participants: %s", strings.Join(meeting.Participants, ", "))
+		}
+		if len(meeting.Tags) > 0 {
+			fmt.Fprintf(&b, " tags: %s", strings.Join(meeting.Tags, ", "))
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+func (s *Server) getMeeting(principal *auth.Principal, args json.RawMessage) (string, error) {
+	var input struct {
+		MeetingId string `json:"meeting_id"`
+	}
+	if err := json.Unmarshal(args, &input); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	meeting, err := s.transcriber.GetMeetingStatus(input.MeetingId)
+	if err != nil {
+		return "", err
+	}
+	if !principal.CanAccess(meeting.Owner) {
+		return "", fmt.Errorf("meeting not found with ID: %s", input.MeetingId)
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\nDate: %s\nStatus: %s\n", meeting.Title, meeting.CreatedAt.Format(time.DateTime), meeting.Status)
+	if len(meeting.Participants) > 0 {
+		fmt.Fprintf(&b, "Participants: %s\n", strings.Join(meeting.Participants, ", "))
+	}
+	if meeting.Summary != "" {
+		fmt.Fprintf(&b, "\n## Summary\n\n%s\n", meeting.Summary)
+	}
+	switch {
+	case len(meeting.Segments) > 0:
+		b.WriteString("\n## Transcript\n\n")
+		for _, segment := range meeting.Segments {
+			writeSegment(&b, segment)
+		}
+	case meeting.Transcript != "":
+		fmt.Fprintf(&b, "\n## Transcript\n\n%s\n", meeting.Transcript)
+	default:
+		b.WriteString("\nNo transcript available yet.\n")
+	}
+	return b.String(), nil
+}
+
+func (s *Server) searchMeetings(principal *auth.Principal, args json.RawMessage) (string, error) {
+	var input struct {
+		Query string `json:"query"`
+		Limit int    `json:"limit"`
+	}
+	if err := json.Unmarshal(args, &input); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(input.Query) == "" {
+		return "", fmt.Errorf("query cannot be empty")
+	}
+	if input.Limit <= 0 {
+		input.Limit = 10
+	}
+
+	results := s.transcriber.SearchMeetings(input.Query, input.Limit, principal.Owner())
+	if len(results) == 0 {
+		return fmt.Sprintf("No meetings mention %q.", input.Query), nil
+	}
+
+	var b strings.Builder
+	for _, result := range results {
+		fmt.Fprintf(&b, "## %s (id: %s, %s)\n", result.Title, result.MeetingId, result.CreatedAt.Format(time.DateTime))
+		for _, segment := range result.Matches {
+			writeSegment(&b, segment)
+		}
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
+
+func writeSegment(b *strings.Builder, segment types.Segment) {
+	fmt.Fprintf(b, "[%s]", transcriber.FormatTimestamp(segment.Start))
+	if segment.Speaker != "" {
+		fmt.Fprintf(b, " %s:", segment.Speaker)
+	}
+	fmt.Fprintf(b, " %s\n", segment.Text)
+}
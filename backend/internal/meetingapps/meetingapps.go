@@ -0,0 +1,230 @@
+// Package meetingapps detects calls in meeting apps such as Zoom, Teams and Google Meet by
+// inspecting the running processes and whether CoreAudio has a microphone open
+package meetingapps
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// App describes how a call in a meeting app is recognized
+type App struct {
+	Name string
+	// CallProcesses only run during a call, e.g. Zoom's meeting host
+	CallProcesses []string
+	// Processes of the app; the app is in a call when one runs while the microphone is in use
+	Processes []string
+	// BrowserURL is part of the URL of a browser tab in a call while the microphone is in use
+	BrowserURL string
+	// AudioProcesses play the call audio when it isn't one of the Processes, e.g. zoom.us
+	AudioProcesses []string
+}
+
+// Apps are the meeting apps detected
+var Apps = []App{
+	{Name: "Zoom", CallProcesses: []string{"CptHost", "aomhost"}, AudioProcesses: []string{"zoom.us"}},
+	{Name: "Microsoft Teams", Processes: []string{"MSTeams", "Microsoft Teams", "Microsoft Teams (work or school)"}},
+	{Name: "Webex", Processes: []string{"Webex", "Cisco Webex Meetings"}},
+	{Name: "Google Meet", BrowserURL: "meet.google.com/"},
+}
+
+// browsers are the scriptable browsers whose tabs are checked for browser-based meetings
+var browsers = []string{"Google Chrome", "Safari", "Arc", "Microsoft Edge", "Brave Browser"}
+
+// micAssertion matches the power assertions coreaudiod holds for an input device while it
+// is running, e.g. com.apple.audio.BuiltInMicrophoneDevice.context.preventuseridlesleep
+var micAssertion = regexp.MustCompile(`(?i)com\.apple\.audio\.\S*(microphone|input)\S*\.context\.`)
+
+// Call is a call in progress in a meeting app
+type Call struct {
+	App   string    `json:"app"`
+	Since time.Time `json:"since"`
+}
+
+// Detector remembers the calls in progress between checks
+type Detector struct {
+	mu        sync.RWMutex
+	calls     map[string]time.Time // App name -> when the call was first seen
+	checkedAt time.Time
+}
+
+// NewDetector returns a detector without calls in progress
+func NewDetector() *Detector {
+	return &Detector{calls: make(map[string]time.Time)}
+}
+
+// Check inspects the running processes and returns the apps whose call started or ended
+// since the previous check
+func (d *Detector) Check() (started []string, ended []string, err error) {
+	active, err := activeApps()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	for _, app := range active {
+		if _, ok := d.calls[app]; !ok {
+			d.calls[app] = now
+			started = append(started, app)
+		}
+	}
+	for app := range d.calls {
+		if !slices.Contains(active, app) {
+			delete(d.calls, app)
+			ended = append(ended, app)
+		}
+	}
+	d.checkedAt = now
+	sort.Strings(ended)
+	return started, ended, nil
+}
+
+// Calls returns the calls in progress at the last check, longest running first
+func (d *Detector) Calls() []Call {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	calls := make([]Call, 0, len(d.calls))
+	for app, since := range d.calls {
+		calls = append(calls, Call{App: app, Since: since})
+	}
+	sort.Slice(calls, func(i, j int) bool { return calls[i].Since.Before(calls[j].Since) })
+	return calls
+}
+
+// CheckedAt returns when the processes were last inspected
+func (d *Detector) CheckedAt() time.Time {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.checkedAt
+}
+
+// activeApps returns the names of the apps in a call
+func activeApps() ([]string, error) {
+	processes, err := runningProcesses()
+	if err != nil {
+		return nil, err
+	}
+	running := func(names []string) bool {
+		return slices.ContainsFunc(names, func(name string) bool { return processes[name] })
+	}
+
+	var active []string
+	micChecked, micOpen := false, false
+	for _, app := range Apps {
+		if running(app.CallProcesses) {
+			active = append(active, app.Name)
+			continue
+		}
+		if !running(app.Processes) && app.BrowserURL == "" {
+			continue
+		}
+		// The microphone is only checked once it matters
+		if !micChecked {
+			micOpen = micInUse()
+			micChecked = true
+		}
+		if !micOpen {
+			continue
+		}
+		if running(app.Processes) || browserTabOpen(processes, app.BrowserURL) {
+			active = append(active, app.Name)
+		}
+	}
+	return active, nil
+}
+
+// Errors returned when the audio of an app can't be recorded
+var (
+	ErrAppNotRunning = errors.New("app is not running")
+	ErrBrowserApp    = errors.New("app runs in the browser, record the browser instead")
+)
+
+// ProcessIDs returns the PIDs of the processes playing the audio of an app, to record only
+// that app. The app is the name of one of the Apps, or the executable name of any other app,
+// e.g. Slack.
+func ProcessIDs(app string) ([]int, error) {
+	names := []string{app}
+	for _, known := range Apps {
+		if !strings.EqualFold(known.Name, app) {
+			continue
+		}
+		names = slices.Concat(known.AudioProcesses, known.Processes, known.CallProcesses)
+		if len(names) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrBrowserApp, known.Name)
+		}
+	}
+
+	output, err := exec.Command("ps", "-axco", "pid=,command=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	var pids []int
+	for _, line := range strings.Split(string(output), "\n") {
+		pid, name, ok := strings.Cut(strings.TrimSpace(line), " ")
+		matches := func(n string) bool { return strings.EqualFold(n, strings.TrimSpace(name)) }
+		if !ok || !slices.ContainsFunc(names, matches) {
+			continue
+		}
+		if id, err := strconv.Atoi(pid); err == nil {
+			pids = append(pids, id)
+		}
+	}
+	if len(pids) == 0 {
+		return nil, fmt.Errorf("%w: %s", ErrAppNotRunning, app)
+	}
+	return pids, nil
+}
+
+// runningProcesses returns the executable names of the running processes
+func runningProcesses() (map[string]bool, error) {
+	output, err := exec.Command("ps", "-axco", "command=").Output()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list processes: %w", err)
+	}
+	processes := make(map[string]bool)
+	for _, line := range strings.Split(string(output), "\n") {
+		if name := strings.TrimSpace(line); name != "" {
+			processes[name] = true
+		}
+	}
+	return processes, nil
+}
+
+// micInUse reports whether an input device is running, judged by the power assertions
+// coreaudiod holds for running devices
+func micInUse() bool {
+	output, err := exec.Command("pmset", "-g", "assertions").Output()
+	if err != nil {
+		return false
+	}
+	return micAssertion.Match(output)
+}
+
+// browserTabOpen reports whether a running browser has a tab open whose URL contains the
+// text. Only running browsers are asked, so none is launched.
+func browserTabOpen(processes map[string]bool, url string) bool {
+	if url == "" {
+		return false
+	}
+	for _, browser := range browsers {
+		if !processes[browser] {
+			continue
+		}
+		script := fmt.Sprintf("tell application %q to get URL of every tab of every window", browser)
+		output, err := exec.Command("osascript", "-e", script).Output()
+		if err == nil && strings.Contains(string(output), url) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+// Package naming renders the configurable names of meeting notes and recordings
+package naming
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// invalidChars can't be used in file names or Obsidian note names and are replaced with a dash
+const invalidChars = `[]|#^\/:*?"<>`
+
+// Data is what the naming templates can refer to
+type Data struct {
+	ID           string
+	Title        string
+	Type         string
+	Date         string // 2006-01-02
+	Time         string // 15-04
+	Timestamp    string // 20060102_150405
+	CreatedAt    time.Time
+	Participants []string
+	Tags         []string
+}
+
+// NewData returns the template data of a meeting
+func NewData(meeting *types.Meeting) Data {
+	return Data{
+		ID:           meeting.Id,
+		Title:        meeting.Title,
+		Type:         meeting.Type,
+		Date:         meeting.CreatedAt.Format("2006-01-02"),
+		Time:         meeting.CreatedAt.Format("15-04"),
+		Timestamp:    meeting.CreatedAt.Format("20060102_150405"),
+		CreatedAt:    meeting.CreatedAt,
+		Participants: meeting.Participants,
+		Tags:         meeting.Tags,
+	}
+}
+
+var funcs = template.FuncMap{
+	"slug":  Slug,
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"join":  strings.Join,
+	"date": func(layout string, t time.Time) string {
+		return t.Format(layout)
+	},
+}
+
+// Templates holds the parsed naming templates
+type Templates struct {
+	note      *template.Template
+	recording *template.Template
+}
+
+// New parses the naming templates of the config
+func New(cfg config.NamingConfig) (*Templates, error) {
+	note, err := template.New("note").Funcs(funcs).Option("missingkey=error").Parse(cfg.Note)
+	if err != nil {
+		return nil, fmt.Errorf("invalid note name template: %w", err)
+	}
+	recording, err := template.New("recording").Funcs(funcs).Option("missingkey=error").Parse(cfg.Recording)
+	if err != nil {
+		return nil, fmt.Errorf("invalid recording name template: %w", err)
+	}
+	t := &Templates{note: note, recording: recording}
+
+	// Catch references to unknown fields now rather than when a meeting is saved
+	sample := &types.Meeting{Id: "sample", Title: "Sample", Type: "sample", CreatedAt: time.Now()}
+	if _, err := t.NoteName(sample); err != nil {
+		return nil, err
+	}
+	if _, err := t.RecordingName(sample); err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
+// NoteName returns the name of the meeting note, without the .md extension
+func (t *Templates) NoteName(meeting *types.Meeting) (string, error) {
+	return render(t.note, meeting)
+}
+
+// RecordingName returns the file name of the meeting recording, without the .wav extension
+func (t *Templates) RecordingName(meeting *types.Meeting) (string, error) {
+	return render(t.recording, meeting)
+}
+
+// render executes a naming template and makes the result safe to use as a file name
+func render(tmpl *template.Template, meeting *types.Meeting) (string, error) {
+	var b bytes.Buffer
+	if err := tmpl.Execute(&b, NewData(meeting)); err != nil {
+		return "", fmt.Errorf("failed to render %s name: %w", tmpl.Name(), err)
+	}
+	name := Sanitize(b.String())
+	if name == "" {
+		return "", fmt.Errorf("%s name template rendered an empty name", tmpl.Name())
+	}
+	return name, nil
+}
+
+// Sanitize replaces characters that aren't allowed in file and note names and trims
+// the spaces and dots Obsidian and most file systems choke on
+func Sanitize(name string) string {
+	name = strings.Map(func(r rune) rune {
+		if strings.ContainsRune(invalidChars, r) || unicode.IsControl(r) {
+			return '-'
+		}
+		return r
+	}, name)
+	return strings.Trim(name, " .")
+}
+
+// Slug lowercases a value and joins its words with dashes
+func Slug(value string) string {
+	words := strings.FieldsFunc(strings.ToLower(value), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	return strings.Join(words, "-")
+}
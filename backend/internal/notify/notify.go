@@ -0,0 +1,27 @@
+// Package notify shows desktop notifications, e.g. to prompt for a recording when a call
+// starts: through osascript on macOS and notify-send on Linux
+package notify
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+)
+
+// Send shows a notification with the title and message
+func Send(title string, message string) error {
+	var cmd *exec.Cmd
+	switch runtime.GOOS {
+	case "darwin":
+		script := fmt.Sprintf("display notification %q with title %q", message, title)
+		cmd = exec.Command("osascript", "-e", script)
+	case "linux":
+		cmd = exec.Command("notify-send", "--app-name=Transcriber", title, message)
+	default:
+		return fmt.Errorf("notifications aren't supported on %s", runtime.GOOS)
+	}
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("failed to show notification: %w\nOutput: %s", err, string(output))
+	}
+	return nil
+}
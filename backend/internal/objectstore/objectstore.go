@@ -0,0 +1,209 @@
+// Package objectstore stores files in S3-compatible object storage, such as AWS S3, MinIO or
+// Backblaze B2. Requests are signed with AWS Signature Version 4.
+package objectstore
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+)
+
+// emptyHash is the SHA-256 of an empty payload, signed for requests without a body
+const emptyHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+var httpClient = &http.Client{Timeout: 10 * time.Minute}
+
+// Client uploads, downloads and deletes the objects of a bucket
+type Client struct {
+	endpoint *url.URL
+	cfg      config.ObjectStorageConfig
+}
+
+// New creates a client for the configured bucket, or nil when object storage is disabled
+func New(cfg config.ObjectStorageConfig) (*Client, error) {
+	if cfg.Endpoint == "" {
+		return nil, nil
+	}
+	endpoint, err := url.Parse(strings.TrimSuffix(cfg.Endpoint, "/"))
+	if err != nil || (endpoint.Scheme != "https" && endpoint.Scheme != "http") || endpoint.Host == "" {
+		return nil, fmt.Errorf("object storage endpoint must be an http or https URL, got %q", cfg.Endpoint)
+	}
+	if cfg.Bucket == "" || cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("object storage requires storage.object_storage.bucket, access_key_id and secret_access_key")
+	}
+	if cfg.Region == "" {
+		cfg.Region = "us-east-1"
+	}
+	return &Client{endpoint: endpoint, cfg: cfg}, nil
+}
+
+// Key returns the key of a file of a meeting: <prefix>/<yyyy>/<mm>/<meeting id>/<name>, so
+// lifecycle rules can match objects by their age through the prefix
+func (c *Client) Key(meetingId string, recordedAt time.Time, name string) string {
+	parts := []string{recordedAt.UTC().Format("2006"), recordedAt.UTC().Format("01"), meetingId, name}
+	if prefix := strings.Trim(c.cfg.Prefix, "/"); prefix != "" {
+		parts = append([]string{prefix}, parts...)
+	}
+	return strings.Join(parts, "/")
+}
+
+// URL returns the URL of the object with the key
+func (c *Client) URL(key string) string {
+	u := *c.endpoint
+	if c.cfg.PathStyle {
+		key = c.cfg.Bucket + "/" + key
+	} else {
+		u.Host = c.cfg.Bucket + "." + u.Host
+	}
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	u.RawPath = u.EscapedPath() + "/" + strings.Join(segments, "/")
+	u.Path = u.Path + "/" + key
+	return u.String()
+}
+
+// Put uploads a file as the object with the key and returns its URL. The metadata is stored
+// as x-amz-meta-* headers; its keys and values must be ASCII.
+func (c *Client) Put(ctx context.Context, key string, path string, contentType string, metadata map[string]string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	// The payload is hashed up front, as the signature covers it
+	hash := sha256.New()
+	size, err := io.Copy(hash, file)
+	if err != nil {
+		return "", err
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	objectURL := c.URL(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, file)
+	if err != nil {
+		return "", err
+	}
+	req.ContentLength = size
+	req.Header.Set("Content-Type", contentType)
+	for name, value := range metadata {
+		req.Header.Set("x-amz-meta-"+name, value)
+	}
+	if c.cfg.StorageClass != "" {
+		req.Header.Set("x-amz-storage-class", c.cfg.StorageClass)
+	}
+	if _, err := c.do(req, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return "", err
+	}
+	return objectURL, nil
+}
+
+// Get downloads the object at a URL returned by Put
+func (c *Client) Get(ctx context.Context, objectURL string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, objectURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req, emptyHash)
+}
+
+// Delete removes the object at a URL returned by Put. Objects that don't exist are ignored.
+func (c *Client) Delete(ctx context.Context, objectURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, objectURL, nil)
+	if err != nil {
+		return err
+	}
+	_, err = c.do(req, emptyHash)
+	return err
+}
+
+// do signs and sends a request, returning the response body
+func (c *Client) do(req *http.Request, payloadHash string) ([]byte, error) {
+	c.sign(req, payloadHash, time.Now())
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		if req.Method == http.MethodDelete && resp.StatusCode == http.StatusNotFound {
+			return nil, nil
+		}
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return nil, fmt.Errorf("%s %s failed with status %d: %s", req.Method, req.URL.Path, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// sign adds the AWS Signature Version 4 headers to a request. The host, content type and
+// x-amz-* headers are signed.
+func (c *Client) sign(req *http.Request, payloadHash string, now time.Time) {
+	now = now.UTC()
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	req.Header.Set("x-amz-date", amzDate)
+	req.Header.Set("x-amz-content-sha256", payloadHash)
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		name = strings.ToLower(name)
+		if name == "content-type" || strings.HasPrefix(name, "x-amz-") {
+			headers[name] = strings.TrimSpace(strings.Join(values, ","))
+		}
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + c.cfg.Region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex([]byte(canonicalRequest))
+
+	key := hmacSHA256([]byte("AWS4"+c.cfg.SecretAccessKey), date)
+	for _, part := range []string{c.cfg.Region, "s3", "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", c.cfg.AccessKeyID, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
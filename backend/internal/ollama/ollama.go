@@ -2,7 +2,9 @@ package ollama
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"fmt"
 	"net/http"
 	"time"
 )
@@ -32,10 +34,15 @@ type Response struct {
 }
 
 const ollamaAPIURL = "http://localhost:11434/api/chat"
-const model = "mistral"
+const DefaultModel = "mistral"
 const stream = false
 
-func TalkToOllama(msgs []Message) (*Response, error) {
+// TalkToOllama sends the chat messages to the given model; an empty model uses DefaultModel
+func TalkToOllama(ctx context.Context, model string, msgs []Message) (*Response, error) {
+	if model == "" {
+		model = DefaultModel
+	}
+
 	req := Request{
 		Model:    model,
 		Stream:   stream,
@@ -48,7 +55,7 @@ func TalkToOllama(msgs []Message) (*Response, error) {
 	}
 
 	client := http.Client{}
-	httpReq, err := http.NewRequest(http.MethodPost, ollamaAPIURL, bytes.NewReader(js))
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, ollamaAPIURL, bytes.NewReader(js))
 	if err != nil {
 		return nil, err
 	}
@@ -64,3 +71,153 @@ func TalkToOllama(msgs []Message) (*Response, error) {
 
 	return &ollamaResp, err
 }
+
+const ollamaEmbedURL = "http://localhost:11434/api/embed"
+const DefaultEmbeddingModel = "nomic-embed-text"
+
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type EmbedResponse struct {
+	Model      string      `json:"model"`
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// Embed returns an embedding vector for every input; an empty model uses DefaultEmbeddingModel
+func Embed(ctx context.Context, model string, inputs []string) ([][]float64, error) {
+	if model == "" {
+		model = DefaultEmbeddingModel
+	}
+
+	js, err := json.Marshal(&EmbedRequest{Model: model, Input: inputs})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, ollamaEmbedURL, bytes.NewReader(js))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama embed request failed with status %d", httpResp.StatusCode)
+	}
+
+	embedResp := EmbedResponse{}
+	if err := json.NewDecoder(httpResp.Body).Decode(&embedResp); err != nil {
+		return nil, err
+	}
+	if len(embedResp.Embeddings) != len(inputs) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d inputs", len(embedResp.Embeddings), len(inputs))
+	}
+
+	return embedResp.Embeddings, nil
+}
+
+const ollamaTagsURL = "http://localhost:11434/api/tags"
+
+// Available reports whether the Ollama server is running and reachable
+func Available() bool {
+	client := http.Client{Timeout: 2 * time.Second}
+	httpResp, err := client.Get(ollamaTagsURL)
+	if err != nil {
+		return false
+	}
+	defer httpResp.Body.Close()
+	return httpResp.StatusCode == http.StatusOK
+}
+
+// Models returns the names of the models pulled into Ollama, e.g. mistral:latest
+func Models() ([]string, error) {
+	client := http.Client{Timeout: 5 * time.Second}
+	httpResp, err := client.Get(ollamaTagsURL)
+	if err != nil {
+		return nil, err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama tags request failed with status %d", httpResp.StatusCode)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&tags); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(tags.Models))
+	for _, model := range tags.Models {
+		models = append(models, model.Name)
+	}
+	return models, nil
+}
+
+const ollamaVersionURL = "http://localhost:11434/api/version"
+
+// Version returns the version of the running Ollama server
+func Version() (string, error) {
+	client := http.Client{Timeout: 2 * time.Second}
+	httpResp, err := client.Get(ollamaVersionURL)
+	if err != nil {
+		return "", err
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ollama version request failed with status %d", httpResp.StatusCode)
+	}
+
+	var version struct {
+		Version string `json:"version"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&version); err != nil {
+		return "", err
+	}
+	return version.Version, nil
+}
+
+const ollamaPullURL = "http://localhost:11434/api/pull"
+
+// Pull downloads a model into Ollama, returning once the download is complete
+func Pull(ctx context.Context, model string) error {
+	js, err := json.Marshal(map[string]any{"model": model, "stream": false})
+	if err != nil {
+		return err
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, ollamaPullURL, bytes.NewReader(js))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+
+	httpResp, err := http.DefaultClient.Do(httpReq)
+	if err != nil {
+		return err
+	}
+	defer httpResp.Body.Close()
+
+	var pullResp struct {
+		Status string `json:"status"`
+		Error  string `json:"error"`
+	}
+	if err := json.NewDecoder(httpResp.Body).Decode(&pullResp); err != nil {
+		return err
+	}
+	if pullResp.Error != "" {
+		return fmt.Errorf("ollama failed to pull %s: %s", model, pullResp.Error)
+	}
+	if httpResp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ollama pull request failed with status %d", httpResp.StatusCode)
+	}
+	return nil
+}
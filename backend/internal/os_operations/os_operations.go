@@ -1,10 +1,15 @@
 package osoperations
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"syscall"
 	"time"
 
+	"github.com/martijnspitter/transcriber/internal/frontmatter"
 	"github.com/martijnspitter/transcriber/internal/types"
 )
 
@@ -15,18 +20,36 @@ func FormatFileName(domain string, timestamp time.Time, extension string) string
 	return domain + "_" + timestampStr + extension
 }
 
-func CreateTempDirectory(prefix string) (string, error) {
-	// Create a temporary directory with the specified prefix
-	tempDir, err := os.MkdirTemp("", prefix)
-	if err != nil {
-		return "", err
-	}
-	return tempDir, nil
+// TempDirPrefix starts the name of every directory the transcriber creates in the system temp
+// directory, so stale ones can be removed without touching those of other programs
+const TempDirPrefix = "transcriber-"
+
+// CreateTempDir creates a new directory for name in the system temp directory, named with
+// TempDirPrefix
+func CreateTempDir(name string) (string, error) {
+	return os.MkdirTemp("", TempDirPrefix+name+"-")
 }
 
-func RemoveTempDirectory(dirName string) error {
-	// Remove the temporary directory and its contents
-	return os.RemoveAll(dirName)
+// RemoveStaleTempDirectories removes the directories of the transcriber in the system temp
+// directory that haven't changed for maxAge, as left behind by failed runs, and returns the
+// removed paths
+func RemoveStaleTempDirectories(maxAge time.Duration) ([]string, error) {
+	dirs, err := filepath.Glob(filepath.Join(os.TempDir(), TempDirPrefix+"*"))
+	if err != nil {
+		return nil, err
+	}
+	var removed []string
+	for _, dir := range dirs {
+		info, err := os.Lstat(dir)
+		if err != nil || !info.IsDir() || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if err := os.RemoveAll(dir); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", dir, err)
+		}
+		removed = append(removed, dir)
+	}
+	return removed, nil
 }
 
 func CreateFilePath(dirName, fileName string) string {
@@ -52,24 +75,195 @@ func GetFileNameWithoutExtension(filePath string) string {
 	return baseName[:len(baseName)-len(ext)]
 }
 
-func SaveMeetingToVault(meeting *types.Meeting) error {
-	dirName := "obsidian-vault"
-	folderName := "meetings"
-	fileName := FormatFileName("meeting", meeting.CreatedAt, ".md")
+// vaultDir is the configured vault directory, empty uses ~/obsidian-vault
+var vaultDir atomic.Value
 
-	// The obsidian vault directory should exist in the user's home directory
+// SetVaultDir changes the vault directory; a path starting with ~ is relative to the home
+// directory and an empty path restores the default
+func SetVaultDir(dir string) error {
+	if rest, ok := strings.CutPrefix(dir, "~"); ok {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return err
+		}
+		dir = filepath.Join(homeDir, rest)
+	}
+	vaultDir.Store(dir)
+	return nil
+}
+
+// VaultDir returns the Obsidian vault directory, ~/obsidian-vault unless configured otherwise
+func VaultDir() (string, error) {
+	if dir, _ := vaultDir.Load().(string); dir != "" {
+		return dir, nil
+	}
 	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(homeDir, "obsidian-vault"), nil
+}
+
+// CreateVaultNote writes a note at a path relative to the vault root unless it already
+// exists, and reports whether it was created
+func CreateVaultNote(notePath string, content string) (bool, error) {
+	notePath = filepath.Clean(notePath)
+	if !filepath.IsLocal(notePath) {
+		return false, fmt.Errorf("note path %q must be a relative path inside the vault", notePath)
+	}
+	vaultDir, err := VaultDir()
+	if err != nil {
+		return false, err
+	}
+
+	fullPath := filepath.Join(vaultDir, notePath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return false, err
+	}
+	file, err := os.OpenFile(fullPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if os.IsExist(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString(content)
+	return err == nil, err
+}
+
+// WriteVaultNote writes a note at a path relative to the vault root, replacing it
+func WriteVaultNote(notePath string, content string) error {
+	notePath = filepath.Clean(notePath)
+	if !filepath.IsLocal(notePath) {
+		return fmt.Errorf("note path %q must be a relative path inside the vault", notePath)
+	}
+	vaultDir, err := VaultDir()
 	if err != nil {
 		return err
 	}
 
-	dirName = filepath.Join(homeDir, dirName, folderName)
+	fullPath := filepath.Join(vaultDir, notePath)
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath, []byte(content), 0644)
+}
+
+// RemoveVaultNote removes a note at a path relative to the vault root. A note that doesn't
+// exist is no error.
+func RemoveVaultNote(notePath string) error {
+	notePath = filepath.Clean(notePath)
+	if !filepath.IsLocal(notePath) {
+		return fmt.Errorf("note path %q must be a relative path inside the vault", notePath)
+	}
+	vaultDir, err := VaultDir()
+	if err != nil {
+		return err
+	}
+	if err := os.Remove(filepath.Join(vaultDir, notePath)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// MeetingNotePath returns the path of a meeting note relative to the vault root
+func MeetingNotePath(meeting *types.Meeting, noteName string) (string, error) {
+	folderName := "meetings"
+	if meeting.VaultFolder != "" {
+		folderName = filepath.Clean(meeting.VaultFolder)
+		if !filepath.IsLocal(folderName) {
+			return "", fmt.Errorf("vault folder %q must be a relative path inside the vault", meeting.VaultFolder)
+		}
+	}
+	return filepath.Join(folderName, noteName+".md"), nil
+}
+
+// SaveMeetingToVault writes the meeting note, replacing any frontmatter the summary starts with.
+// The transcript is the note of meetings without a summary and is appended to the summary
+// otherwise; it can be empty.
+func SaveMeetingToVault(meeting *types.Meeting, noteName string, frontmatterBlock string, transcript string) error {
+	notePath, err := MeetingNotePath(meeting, noteName)
+	if err != nil {
+		return err
+	}
+
+	vaultDir, err := VaultDir()
+	if err != nil {
+		return err
+	}
+
+	dirName := filepath.Join(vaultDir, filepath.Dir(notePath))
 	// Create the directory if it doesn't exist
 	err = os.MkdirAll(dirName, 0755)
 	if err != nil {
 		return err
 	}
 
-	err = CreateFile(dirName, fileName, []byte(meeting.Summary))
+	// Meetings saved without a summary get their transcript as the note, followed by the
+	// chapters and the notes taken while recording; summaries are followed by the chapters
+	// and notes, then the transcript
+	sections := appendSection(chapterList(meeting.Chapters), myNotes(meeting.Notes))
+	body := frontmatter.Strip(meeting.Summary)
+	if body == "" {
+		body = appendSection(transcript, sections)
+	} else {
+		body = appendSection(appendSection(body, sections), transcript)
+	}
+
+	if meeting.AudioAttachment != "" {
+		body = strings.TrimRight(body, "\n") + "\n\n## Recording\n\n![[" + filepath.ToSlash(meeting.AudioAttachment) + "]]\n"
+	}
+
+	err = CreateFile(dirName, filepath.Base(notePath), []byte(frontmatterBlock+body))
 	return err
 }
+
+// appendSection appends a section to the body of a note, either may be empty
+func appendSection(body string, section string) string {
+	if body == "" || section == "" {
+		return body + section
+	}
+	return strings.TrimRight(body, "\n") + "\n\n" + section
+}
+
+// myNotes returns the "My Notes" section of a meeting note, or nothing without notes
+func myNotes(notes []types.Note) string {
+	if len(notes) == 0 {
+		return ""
+	}
+	section := "## My Notes\n\n"
+	for _, note := range notes {
+		section += fmt.Sprintf("- `%s` %s\n", clock(note.At), note.Text)
+	}
+	return section
+}
+
+// chapterList returns the "Chapters" section of a meeting note listing the topics of the
+// transcript with their time ranges, or nothing for transcripts without chapters
+func chapterList(chapters []types.Chapter) string {
+	if len(chapters) == 0 {
+		return ""
+	}
+	section := "## Chapters\n\n"
+	for _, chapter := range chapters {
+		section += fmt.Sprintf("- `%s`–`%s` %s\n", clock(chapter.Start), clock(chapter.End), chapter.Title)
+	}
+	return section
+}
+
+// clock formats seconds as HH:MM:SS
+func clock(seconds float64) string {
+	s := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// FreeSpace returns the number of bytes available on the volume holding the path
+func FreeSpace(path string) (uint64, error) {
+	var stat syscall.Statfs_t
+	if err := syscall.Statfs(path, &stat); err != nil {
+		return 0, err
+	}
+	return stat.Bavail * uint64(stat.Bsize), nil
+}
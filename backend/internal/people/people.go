@@ -0,0 +1,228 @@
+// Package people keeps a directory of known people so participant names are spelled and
+// wikilinked the same way in every meeting note
+package people
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no person exists with the given name
+var ErrNotFound = errors.New("person not found")
+
+// ErrConflict is returned when a name or alias already belongs to someone else
+var ErrConflict = errors.New("name or alias already belongs to another person")
+
+// invalidNameChars can't be used in Obsidian note names or wikilinks
+const invalidNameChars = `[]|#^\/:*?"<>`
+
+// Person is a known meeting participant
+type Person struct {
+	Name      string    `json:"name"`
+	Aliases   []string  `json:"aliases,omitempty"`   // Other spellings that resolve to this person
+	NotePath  string    `json:"note_path,omitempty"` // Person note relative to the vault root, e.g. people/John Smith.md
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Link returns the wikilink to the person, pointing at the note when its file name differs from the name
+func (p Person) Link() string {
+	return "[[" + p.LinkTarget() + "]]"
+}
+
+// LinkTarget returns the wikilink target of the person
+func (p Person) LinkTarget() string {
+	if p.NotePath == "" {
+		return p.Name
+	}
+	target := strings.TrimSuffix(filepath.ToSlash(p.NotePath), ".md")
+	if filepath.Base(target) == p.Name {
+		return p.Name
+	}
+	return target + "|" + p.Name
+}
+
+// names returns the name and aliases of the person
+func (p Person) names() []string {
+	return append([]string{p.Name}, p.Aliases...)
+}
+
+// Store persists the people directory in a JSON file
+type Store struct {
+	mu     sync.RWMutex
+	path   string
+	People map[string]*Person `json:"people"` // Name -> person
+}
+
+// Open reads the people file, starting empty when it doesn't exist yet
+func Open(path string) (*Store, error) {
+	s := &Store{
+		path:   path,
+		People: make(map[string]*Person),
+	}
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read people file: %w", err)
+	}
+	if err := json.Unmarshal(data, s); err != nil {
+		return nil, fmt.Errorf("failed to parse people file: %w", err)
+	}
+	return s, nil
+}
+
+// save writes the store atomically; callers must hold the write lock
+func (s *Store) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0700); err != nil {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+// ValidateName checks that a name can be used as a note name and inside a wikilink
+func ValidateName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("name cannot be empty")
+	}
+	if name != strings.TrimSpace(name) {
+		return fmt.Errorf("name %q cannot start or end with spaces", name)
+	}
+	if strings.ContainsAny(name, invalidNameChars) {
+		return fmt.Errorf("name %q cannot contain any of %s", name, invalidNameChars)
+	}
+	return nil
+}
+
+// List returns all people sorted by name
+func (s *Store) List() []Person {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	people := make([]Person, 0, len(s.People))
+	for _, person := range s.People {
+		people = append(people, *person)
+	}
+	sort.Slice(people, func(i, j int) bool {
+		return strings.ToLower(people[i].Name) < strings.ToLower(people[j].Name)
+	})
+	return people
+}
+
+// Get returns the person with the given name
+func (s *Store) Get(name string) (Person, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	person, ok := s.People[name]
+	if !ok {
+		return Person{}, ErrNotFound
+	}
+	return *person, nil
+}
+
+// Resolve finds the person a name or alias refers to, ignoring case
+func (s *Store) Resolve(name string) (Person, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	person := s.resolve(name)
+	if person == nil {
+		return Person{}, false
+	}
+	return *person, true
+}
+
+// resolve finds a person by name or alias; callers must hold the lock
+func (s *Store) resolve(name string) *Person {
+	name = strings.TrimSpace(name)
+	if person, ok := s.People[name]; ok {
+		return person
+	}
+	for _, person := range s.People {
+		for _, known := range person.names() {
+			if strings.EqualFold(known, name) {
+				return person
+			}
+		}
+	}
+	return nil
+}
+
+// Save creates or replaces the person with the given name
+func (s *Store) Save(person Person) (Person, error) {
+	if err := ValidateName(person.Name); err != nil {
+		return Person{}, err
+	}
+	aliases := make([]string, 0, len(person.Aliases))
+	for _, alias := range person.Aliases {
+		alias = strings.TrimSpace(alias)
+		if err := ValidateName(alias); err != nil {
+			return Person{}, fmt.Errorf("invalid alias: %w", err)
+		}
+		if !strings.EqualFold(alias, person.Name) {
+			aliases = append(aliases, alias)
+		}
+	}
+	person.Aliases = aliases
+	if person.NotePath != "" {
+		notePath := filepath.Clean(person.NotePath)
+		if !filepath.IsLocal(notePath) {
+			return Person{}, fmt.Errorf("note path %q must be a relative path inside the vault", person.NotePath)
+		}
+		if filepath.Ext(notePath) != ".md" {
+			notePath += ".md"
+		}
+		person.NotePath = notePath
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, name := range person.names() {
+		if other := s.resolve(name); other != nil && other.Name != person.Name {
+			return Person{}, fmt.Errorf("%w: %q is %s", ErrConflict, name, other.Name)
+		}
+	}
+
+	now := time.Now()
+	person.CreatedAt, person.UpdatedAt = now, now
+	if existing, ok := s.People[person.Name]; ok {
+		person.CreatedAt = existing.CreatedAt
+	}
+	s.People[person.Name] = &person
+	if err := s.save(); err != nil {
+		return Person{}, err
+	}
+	return person, nil
+}
+
+// Delete removes a person from the directory; their vault note is left alone
+func (s *Store) Delete(name string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.People[name]; !ok {
+		return ErrNotFound
+	}
+	delete(s.People, name)
+	return s.save()
+}
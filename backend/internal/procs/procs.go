@@ -0,0 +1,160 @@
+// Package procs keeps track of the child processes the transcriber starts, such as ffmpeg and
+// whisper, so they are stopped on shutdown and those left behind by a crash are killed on the
+// next start
+package procs
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	mu       sync.Mutex
+	running  = make(map[int]string) // PID -> executable name
+	pidsFile string                 // Where the running PIDs are persisted, empty keeps them in memory
+)
+
+// SetFile sets the file the PIDs of the running children are written to
+func SetFile(path string) {
+	mu.Lock()
+	defer mu.Unlock()
+	pidsFile = path
+}
+
+// Start starts the command and tracks its process until Wait returns
+func Start(cmd *exec.Cmd) error {
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	running[cmd.Process.Pid] = filepath.Base(cmd.Path)
+	save()
+	return nil
+}
+
+// Wait waits for a command started with Start to exit, reaping it, and stops tracking it
+func Wait(cmd *exec.Cmd) error {
+	err := cmd.Wait()
+	mu.Lock()
+	defer mu.Unlock()
+	delete(running, cmd.Process.Pid)
+	save()
+	return err
+}
+
+// Run starts the command and waits for it to exit
+func Run(cmd *exec.Cmd) error {
+	if err := Start(cmd); err != nil {
+		return err
+	}
+	return Wait(cmd)
+}
+
+// CombinedOutput runs the command and returns its combined stdout and stderr
+func CombinedOutput(cmd *exec.Cmd) ([]byte, error) {
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err := Run(cmd)
+	return output.Bytes(), err
+}
+
+// Running returns the number of tracked children
+func Running() int {
+	mu.Lock()
+	defer mu.Unlock()
+	return len(running)
+}
+
+// Stop interrupts the running children, so recordings are finalized, and kills those that
+// haven't exited after the timeout. It returns the number of children killed.
+func Stop(timeout time.Duration) int {
+	signalAll(os.Interrupt)
+	deadline := time.Now().Add(timeout)
+	for Running() > 0 && time.Now().Before(deadline) {
+		time.Sleep(100 * time.Millisecond)
+	}
+	return signalAll(os.Kill)
+}
+
+// signalAll sends the signal to the tracked children and returns how many there were
+func signalAll(signal os.Signal) int {
+	mu.Lock()
+	defer mu.Unlock()
+	for pid := range running {
+		if process, err := os.FindProcess(pid); err == nil {
+			process.Signal(signal)
+		}
+	}
+	return len(running)
+}
+
+// KillOrphans kills the children a previous run recorded in the PID file that are still
+// running, and returns their number. A PID is only killed while it still belongs to the same
+// executable, as it may have been reused since.
+func KillOrphans() (int, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	if pidsFile == "" {
+		return 0, nil
+	}
+	data, err := os.ReadFile(pidsFile)
+	if os.IsNotExist(err) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read %s: %w", pidsFile, err)
+	}
+	var orphans map[int]string
+	if err := json.Unmarshal(data, &orphans); err != nil {
+		return 0, fmt.Errorf("failed to parse %s: %w", pidsFile, err)
+	}
+
+	killed := 0
+	for pid, name := range orphans {
+		if _, ok := running[pid]; ok || executable(pid) != name {
+			continue
+		}
+		if process, err := os.FindProcess(pid); err == nil && process.Kill() == nil {
+			killed++
+		}
+	}
+	save()
+	return killed, nil
+}
+
+// executable returns the executable name of a running process, or "" when it isn't running
+func executable(pid int) string {
+	output, err := exec.Command("ps", "-o", "comm=", "-p", strconv.Itoa(pid)).Output()
+	if err != nil {
+		return ""
+	}
+	return filepath.Base(strings.TrimSpace(string(output)))
+}
+
+// save writes the running PIDs to the PID file; the caller holds mu
+func save() {
+	if pidsFile == "" {
+		return
+	}
+	data, err := json.Marshal(running)
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(pidsFile), 0755); err != nil {
+		return
+	}
+	tmp := pidsFile + ".tmp"
+	if err := os.WriteFile(tmp, data, 0600); err == nil {
+		os.Rename(tmp, pidsFile)
+	}
+}
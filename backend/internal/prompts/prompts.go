@@ -0,0 +1,166 @@
+// Package prompts manages the named summarization templates. Templates are stored as
+// markdown files in a directory; built-in templates are used when no file overrides them.
+package prompts
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned when no template exists with the given name
+var ErrNotFound = errors.New("template not found")
+
+// validName keeps template names safe to use as file names
+var validName = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+const templateExt = ".md"
+
+// Template is a named system prompt used to summarize meetings
+type Template struct {
+	Name       string    `json:"name"`
+	Prompt     string    `json:"prompt"`
+	BuiltIn    bool      `json:"built_in"`             // A built-in template exists with this name
+	Customized bool      `json:"customized"`           // The template is stored on disk
+	UpdatedAt  time.Time `json:"updated_at,omitempty"` // Modification time of the stored template
+}
+
+// Store reads and writes templates in a directory
+type Store struct {
+	mu  sync.RWMutex
+	dir string
+}
+
+// NewStore creates a template store in the directory, creating it if needed
+func NewStore(dir string) (*Store, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create templates directory: %w", err)
+	}
+	return &Store{dir: dir}, nil
+}
+
+// ValidateName checks that a template name can be stored
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid template name %q: use lowercase letters, digits, - and _", name)
+	}
+	return nil
+}
+
+func (s *Store) path(name string) string {
+	return filepath.Join(s.dir, name+templateExt)
+}
+
+// Get returns the template with the given name, preferring a stored override over the built-in
+func (s *Store) Get(name string) (Template, error) {
+	if err := ValidateName(name); err != nil {
+		return Template{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.get(name)
+}
+
+func (s *Store) get(name string) (Template, error) {
+	builtin, isBuiltin := builtins[name]
+	template := Template{Name: name, BuiltIn: isBuiltin}
+
+	data, err := os.ReadFile(s.path(name))
+	switch {
+	case err == nil:
+		template.Prompt = string(data)
+		template.Customized = true
+		if info, err := os.Stat(s.path(name)); err == nil {
+			template.UpdatedAt = info.ModTime()
+		}
+	case os.IsNotExist(err) && isBuiltin:
+		template.Prompt = builtin
+	case os.IsNotExist(err):
+		return Template{}, fmt.Errorf("%w: %s", ErrNotFound, name)
+	default:
+		return Template{}, fmt.Errorf("failed to read template %s: %w", name, err)
+	}
+	return template, nil
+}
+
+// List returns all built-in and stored templates sorted by name
+func (s *Store) List() ([]Template, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	names := make(map[string]bool)
+	for name := range builtins {
+		names[name] = true
+	}
+
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read templates directory: %w", err)
+	}
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), templateExt)
+		if ok && !entry.IsDir() && ValidateName(name) == nil {
+			names[name] = true
+		}
+	}
+
+	templates := make([]Template, 0, len(names))
+	for name := range names {
+		template, err := s.get(name)
+		if err != nil {
+			return nil, err
+		}
+		templates = append(templates, template)
+	}
+	sort.Slice(templates, func(i, j int) bool { return templates[i].Name < templates[j].Name })
+	return templates, nil
+}
+
+// Save creates or replaces a template
+func (s *Store) Save(name string, prompt string) (Template, error) {
+	if err := ValidateName(name); err != nil {
+		return Template{}, err
+	}
+	if strings.TrimSpace(prompt) == "" {
+		return Template{}, fmt.Errorf("prompt cannot be empty")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	tmp := s.path(name) + ".tmp"
+	if err := os.WriteFile(tmp, []byte(prompt), 0644); err != nil {
+		return Template{}, fmt.Errorf("failed to write template %s: %w", name, err)
+	}
+	if err := os.Rename(tmp, s.path(name)); err != nil {
+		return Template{}, fmt.Errorf("failed to write template %s: %w", name, err)
+	}
+	return s.get(name)
+}
+
+// Delete removes a stored template. Deleting an override restores the built-in template;
+// built-in templates themselves can't be deleted.
+func (s *Store) Delete(name string) error {
+	if err := ValidateName(name); err != nil {
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err := os.Remove(s.path(name))
+	if os.IsNotExist(err) {
+		if _, isBuiltin := builtins[name]; isBuiltin {
+			return fmt.Errorf("built-in template %s can't be deleted", name)
+		}
+		return fmt.Errorf("%w: %s", ErrNotFound, name)
+	}
+	return err
+}
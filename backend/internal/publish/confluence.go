@@ -0,0 +1,86 @@
+package publish
+
+import (
+	"context"
+	"encoding/base64"
+	"html"
+	"net/http"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// confluenceClient creates meeting pages in a Confluence space
+type confluenceClient struct {
+	cfg config.ConfluenceConfig
+}
+
+func (c *confluenceClient) Name() string {
+	return "confluence"
+}
+
+func (c *confluenceClient) Publish(ctx context.Context, meeting *types.Meeting) (string, error) {
+	page := map[string]any{
+		"type":  "page",
+		"title": pageTitle(meeting),
+		"space": map[string]string{"key": c.cfg.SpaceKey},
+		"body": map[string]any{
+			"storage": map[string]string{
+				"value":          confluenceStorage(parseMarkdown(pageContent(meeting))),
+				"representation": "storage",
+			},
+		},
+	}
+	if c.cfg.ParentID != "" {
+		page["ancestors"] = []map[string]string{{"id": c.cfg.ParentID}}
+	}
+
+	credentials := base64.StdEncoding.EncodeToString([]byte(c.cfg.Email + ":" + c.cfg.APIToken))
+	var created struct {
+		Links struct {
+			Base  string `json:"base"`
+			WebUI string `json:"webui"`
+		} `json:"_links"`
+	}
+	headers := map[string]string{"Authorization": "Basic " + credentials}
+	if err := doJSON(ctx, http.MethodPost, c.cfg.BaseURL+"/rest/api/content", headers, page, &created); err != nil {
+		return "", err
+	}
+	return created.Links.Base + created.Links.WebUI, nil
+}
+
+// confluenceStorage converts Markdown blocks to the XHTML storage format of Confluence
+func confluenceStorage(blocks []block) string {
+	var b strings.Builder
+	list := "" // Tag of the list being written
+	for _, block := range blocks {
+		tag := map[string]string{blockBullet: "ul", blockNumbered: "ol"}[block.kind]
+		if tag != list {
+			if list != "" {
+				b.WriteString("</" + list + ">")
+			}
+			if tag != "" {
+				b.WriteString("<" + tag + ">")
+			}
+			list = tag
+		}
+		text := html.EscapeString(block.text)
+		switch block.kind {
+		case blockHeading1:
+			b.WriteString("<h1>" + text + "</h1>")
+		case blockHeading2:
+			b.WriteString("<h2>" + text + "</h2>")
+		case blockHeading3:
+			b.WriteString("<h3>" + text + "</h3>")
+		case blockBullet, blockNumbered:
+			b.WriteString("<li>" + text + "</li>")
+		default:
+			b.WriteString("<p>" + text + "</p>")
+		}
+	}
+	if list != "" {
+		b.WriteString("</" + list + ">")
+	}
+	return b.String()
+}
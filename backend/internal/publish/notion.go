@@ -0,0 +1,88 @@
+package publish
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+const (
+	notionAPIURL  = "https://api.notion.com/v1"
+	notionVersion = "2022-06-28"
+
+	// Limits of the Notion API on the blocks of a request and the text of a rich text object
+	notionMaxBlocks = 100
+	notionMaxText   = 2000
+)
+
+// notionClient creates meeting pages in a Notion database
+type notionClient struct {
+	cfg config.NotionConfig
+}
+
+func (c *notionClient) Name() string {
+	return "notion"
+}
+
+func (c *notionClient) Publish(ctx context.Context, meeting *types.Meeting) (string, error) {
+	children := notionBlocks(parseMarkdown(pageContent(meeting)))
+	first := children[:min(len(children), notionMaxBlocks)]
+
+	page := map[string]any{
+		"parent": map[string]string{"database_id": c.cfg.DatabaseID},
+		"properties": map[string]any{
+			c.cfg.TitleProperty: map[string]any{"title": notionText(pageTitle(meeting))},
+		},
+		"children": first,
+	}
+	var created struct {
+		Id  string `json:"id"`
+		URL string `json:"url"`
+	}
+	if err := doJSON(ctx, http.MethodPost, notionAPIURL+"/pages", c.headers(), page, &created); err != nil {
+		return "", err
+	}
+
+	// Blocks past the limit are appended to the page
+	for rest := children[len(first):]; len(rest) > 0; rest = rest[min(len(rest), notionMaxBlocks):] {
+		batch := map[string]any{"children": rest[:min(len(rest), notionMaxBlocks)]}
+		if err := doJSON(ctx, http.MethodPatch, notionAPIURL+"/blocks/"+created.Id+"/children", c.headers(), batch, nil); err != nil {
+			return created.URL, err
+		}
+	}
+	return created.URL, nil
+}
+
+func (c *notionClient) headers() map[string]string {
+	return map[string]string{
+		"Authorization":  "Bearer " + c.cfg.Token,
+		"Notion-Version": notionVersion,
+	}
+}
+
+// notionBlocks converts Markdown blocks to Notion blocks, whose kinds have the same names
+func notionBlocks(blocks []block) []map[string]any {
+	converted := make([]map[string]any, 0, len(blocks))
+	for _, b := range blocks {
+		converted = append(converted, map[string]any{
+			"object": "block",
+			"type":   b.kind,
+			b.kind:   map[string]any{"rich_text": notionText(b.text)},
+		})
+	}
+	return converted
+}
+
+// notionText returns the rich text of a string, split into objects within the length limit
+func notionText(text string) []map[string]any {
+	var parts []map[string]any
+	runes := []rune(text)
+	for len(runes) > 0 {
+		n := min(len(runes), notionMaxText)
+		parts = append(parts, map[string]any{"type": "text", "text": map[string]string{"content": string(runes[:n])}})
+		runes = runes[n:]
+	}
+	return parts
+}
@@ -0,0 +1,153 @@
+// Package publish creates pages for meetings outside the Obsidian vault, in Notion and
+// Confluence
+package publish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Destination creates pages for meetings
+type Destination interface {
+	Name() string
+	// Publish creates a page with the summary of the meeting, or its transcript when it wasn't
+	// summarized, and returns the URL of the page
+	Publish(ctx context.Context, meeting *types.Meeting) (string, error)
+}
+
+// New creates the configured destinations by the stage that publishes to them
+func New(cfg config.PublishConfig) (map[string]Destination, error) {
+	destinations := make(map[string]Destination)
+	if cfg.Notion.Enabled() {
+		if cfg.Notion.Token == "" {
+			return nil, fmt.Errorf("notion requires publish.notion.token")
+		}
+		destinations[config.StageSaveNotion] = &notionClient{cfg: cfg.Notion}
+	}
+	if cfg.Confluence.Enabled() {
+		if cfg.Confluence.BaseURL == "" || cfg.Confluence.Email == "" || cfg.Confluence.APIToken == "" {
+			return nil, fmt.Errorf("confluence requires publish.confluence.base_url, email and api_token")
+		}
+		c := cfg.Confluence
+		c.BaseURL = strings.TrimSuffix(c.BaseURL, "/")
+		destinations[config.StageSaveConfluence] = &confluenceClient{cfg: c}
+	}
+	return destinations, nil
+}
+
+// pageTitle is the title of the page of a meeting; Confluence needs titles to be unique
+// within a space, so it includes the start time
+func pageTitle(meeting *types.Meeting) string {
+	title := meeting.Title
+	if title == "" {
+		title = "Meeting"
+	}
+	return fmt.Sprintf("%s (%s)", title, meeting.Start_time.Format("2006-01-02 15:04"))
+}
+
+// pageContent is the Markdown the page of a meeting is made of
+func pageContent(meeting *types.Meeting) string {
+	if meeting.Summary != "" {
+		return meeting.Summary
+	}
+	return meeting.Transcript
+}
+
+// Kinds of Markdown blocks
+const (
+	blockHeading1  = "heading_1"
+	blockHeading2  = "heading_2"
+	blockHeading3  = "heading_3"
+	blockBullet    = "bulleted_list_item"
+	blockNumbered  = "numbered_list_item"
+	blockParagraph = "paragraph"
+)
+
+// block is a line of Markdown with its kind
+type block struct {
+	kind string
+	text string
+}
+
+var (
+	numberedItem = regexp.MustCompile(`^\d+[.)]\s+`)
+	wikiLink     = regexp.MustCompile(`\[\[([^\]|]+)(\|([^\]]+))?\]\]`)
+)
+
+// parseMarkdown splits the Markdown of a summary into headings, list items and paragraphs.
+// Obsidian links and emphasis are reduced to their text.
+func parseMarkdown(markdown string) []block {
+	var blocks []block
+	for _, line := range strings.Split(markdown, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" {
+			continue
+		}
+		b := block{kind: blockParagraph, text: line}
+		switch {
+		case strings.HasPrefix(line, "### "):
+			b = block{kind: blockHeading3, text: line[4:]}
+		case strings.HasPrefix(line, "## "):
+			b = block{kind: blockHeading2, text: line[3:]}
+		case strings.HasPrefix(line, "# "):
+			b = block{kind: blockHeading1, text: line[2:]}
+		case strings.HasPrefix(line, "- "), strings.HasPrefix(line, "* "):
+			b = block{kind: blockBullet, text: line[2:]}
+		case numberedItem.MatchString(line):
+			b = block{kind: blockNumbered, text: numberedItem.ReplaceAllString(line, "")}
+		}
+		b.text = wikiLink.ReplaceAllStringFunc(b.text, func(link string) string {
+			match := wikiLink.FindStringSubmatch(link)
+			if match[3] != "" {
+				return match[3]
+			}
+			return match[1]
+		})
+		b.text = strings.NewReplacer("**", "", "__", "").Replace(b.text)
+		blocks = append(blocks, b)
+	}
+	return blocks
+}
+
+var httpClient = &http.Client{Timeout: 30 * time.Second}
+
+// doJSON sends a JSON request with the headers and decodes the JSON response into out
+func doJSON(ctx context.Context, method string, url string, headers map[string]string, body any, out any) error {
+	js, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(js))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for name, value := range headers {
+		req.Header.Set(name, value)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s %s failed with status %d: %s", method, url, resp.StatusCode, strings.TrimSpace(string(detail)))
+	}
+	if out == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(out)
+}
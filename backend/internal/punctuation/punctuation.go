@@ -0,0 +1,129 @@
+package punctuation
+
+import (
+	"strings"
+	"unicode"
+)
+
+// questionStarters are words that, when opening a sentence, usually indicate a question
+var questionStarters = map[string]bool{
+	"who": true, "what": true, "when": true, "where": true, "why": true, "how": true,
+	"is": true, "are": true, "was": true, "were": true, "do": true, "does": true, "did": true,
+	"can": true, "could": true, "would": true, "should": true, "will": true, "shall": true,
+	"have": true, "has": true, "am": true,
+}
+
+// introWords are discourse markers that get a trailing comma when they open a sentence
+var introWords = map[string]bool{
+	"so": true, "well": true, "okay": true, "ok": true, "yeah": true, "yes": true,
+	"no": true, "right": true, "alright": true, "anyway": true, "actually": true,
+}
+
+// Punctuator applies a rule-based punctuation and casing pass to a stream of text
+// chunks. It keeps track of sentence boundaries between chunks so that partial
+// transcripts can be fed to it incrementally.
+type Punctuator struct {
+	sentenceOpen bool // Whether the previous chunk ended mid-sentence
+}
+
+// NewPunctuator creates a new punctuator starting at a sentence boundary
+func NewPunctuator() *Punctuator {
+	return &Punctuator{}
+}
+
+// Feed punctuates a chunk of streaming text. When final is true the chunk is
+// treated as the end of a sentence and terminal punctuation is added if missing.
+// Chunks that are punctuated already, as Whisper writes them, are kept as they are:
+// the rules are meant for unpunctuated text and would change what it says.
+func (p *Punctuator) Feed(chunk string, final bool) string {
+	words := strings.Fields(chunk)
+	if len(words) == 0 {
+		return ""
+	}
+	if punctuated(words) {
+		p.sentenceOpen = !endsSentence(words[len(words)-1])
+		return strings.Join(words, " ")
+	}
+
+	sentenceStart := !p.sentenceOpen
+	firstWord := ""
+	for i, word := range words {
+		bare := strings.ToLower(strings.TrimRight(word, ",.?!;:"))
+
+		// Always capitalize the pronoun "I" and its contractions
+		if bare == "i" || strings.HasPrefix(bare, "i'") {
+			word = "I" + word[1:]
+		}
+
+		if sentenceStart {
+			firstWord = bare
+			word = capitalize(word)
+			if introWords[bare] && i < len(words)-1 && !hasPunctuation(word) {
+				word += ","
+			}
+			sentenceStart = false
+		}
+
+		if endsSentence(word) {
+			sentenceStart = true
+		}
+
+		words[i] = word
+	}
+
+	// Once a question-like sentence was started in a previous chunk we cannot look back,
+	// so the question heuristic only applies to sentences opened in this chunk
+	last := words[len(words)-1]
+	if final && !endsSentence(last) {
+		last = strings.TrimRight(last, ",;:")
+		if questionStarters[firstWord] {
+			last += "?"
+		} else {
+			last += "."
+		}
+		words[len(words)-1] = last
+		sentenceStart = true
+	}
+
+	p.sentenceOpen = !sentenceStart
+	return strings.Join(words, " ")
+}
+
+// Reset forgets any sentence state carried over from previous chunks
+func (p *Punctuator) Reset() {
+	p.sentenceOpen = false
+}
+
+// Punctuate is a convenience helper that punctuates a single complete sentence
+func Punctuate(text string) string {
+	return NewPunctuator().Feed(text, true)
+}
+
+func capitalize(word string) string {
+	runes := []rune(word)
+	for i, r := range runes {
+		if unicode.IsLetter(r) {
+			runes[i] = unicode.ToUpper(r)
+			break
+		}
+	}
+	return string(runes)
+}
+
+func endsSentence(word string) bool {
+	return strings.HasSuffix(word, ".") || strings.HasSuffix(word, "?") || strings.HasSuffix(word, "!")
+}
+
+// punctuated reports whether any of the words ends in punctuation
+func punctuated(words []string) bool {
+	for _, word := range words {
+		if hasPunctuation(word) {
+			return true
+		}
+	}
+	return false
+}
+
+func hasPunctuation(word string) bool {
+	return strings.ContainsAny(word[len(word)-1:], ",.?!;:")
+}
@@ -0,0 +1,70 @@
+package punctuation
+
+import "testing"
+
+func TestPunctuate(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"empty", "", ""},
+		{"statement", "we ship it on friday", "We ship it on friday."},
+		{"question", "what time is it", "What time is it?"},
+		{"intro word", "so we ship it", "So, we ship it."},
+		{"single intro word", "okay", "Okay."},
+		{"pronoun", "i think i'm done", "I think I'm done."},
+		{"punctuated no", "No problem.", "No problem."},
+		{"punctuated well", "Well done, team.", "Well done, team."},
+		{"punctuated right", "Right now we ship it.", "Right now we ship it."},
+		{"punctuated without terminal", "Well done, team", "Well done, team"},
+		{"extra whitespace", "  yes   that works ", "Yes, that works."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Punctuate(tt.text); got != tt.want {
+				t.Errorf("Punctuate(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPunctuatorFeed(t *testing.T) {
+	tests := []struct {
+		name   string
+		chunks []string
+		want   []string
+	}{
+		{
+			name:   "sentence across chunks",
+			chunks: []string{"we should", "ship it"},
+			want:   []string{"We should", "ship it."},
+		},
+		{
+			name:   "question opened in the last chunk",
+			chunks: []string{"that works.", "how about friday"},
+			want:   []string{"that works.", "How about friday?"},
+		},
+		{
+			name:   "punctuated chunk ends a sentence",
+			chunks: []string{"No problem.", "next item"},
+			want:   []string{"No problem.", "Next item."},
+		},
+		{
+			name:   "punctuated chunk leaves a sentence open",
+			chunks: []string{"Well done, team", "let's go"},
+			want:   []string{"Well done, team", "let's go."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			punctuator := NewPunctuator()
+			for i, chunk := range tt.chunks {
+				got := punctuator.Feed(chunk, i == len(tt.chunks)-1)
+				if got != tt.want[i] {
+					t.Errorf("Feed(%q) = %q, want %q", chunk, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,213 @@
+// Package stats computes how a meeting was spoken from its transcript segments: talk time,
+// pace, filler words and monologues
+package stats
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// maxMonologuePause is the longest pause, in seconds, a speaker can take without ending
+// their monologue
+const maxMonologuePause = 3.0
+
+// fillers are the filler words and phrases counted, in English and Dutch
+var fillers = []string{"um", "uh", "uhm", "erm", "er", "ah", "hmm", "eh", "ehm", "euh", "you know", "i mean", "kind of", "sort of", "weet je", "zeg maar"}
+
+// Stats describes how a meeting was spoken
+type Stats struct {
+	Duration         float64        `json:"duration"`         // Seconds recorded
+	SpeechTime       float64        `json:"speech_time"`      // Seconds someone was speaking
+	SilenceTime      float64        `json:"silence_time"`     // Seconds nobody was speaking
+	Words            int            `json:"words"`            // Words spoken
+	WordsPerMinute   float64        `json:"words_per_minute"` // Words per minute of speech
+	Fillers          map[string]int `json:"fillers"`          // Filler word or phrase -> times used
+	FillerWords      int            `json:"filler_words"`     // Filler words and phrases used in total
+	LongestMonologue *Monologue     `json:"longest_monologue,omitempty"`
+	Speakers         []Speaker      `json:"speakers,omitempty"` // By talk time, when the transcript has speakers or sources
+}
+
+// Speaker is how much one speaker said
+type Speaker struct {
+	Name           string  `json:"name"`
+	TalkTime       float64 `json:"talk_time"` // Seconds
+	Share          float64 `json:"share"`     // Part of the speech time, 0 to 1
+	Words          int     `json:"words"`
+	WordsPerMinute float64 `json:"words_per_minute"`
+	FillerWords    int     `json:"filler_words"`
+}
+
+// Monologue is the longest stretch one speaker talked without pausing for long
+type Monologue struct {
+	MeetingId string  `json:"meeting_id,omitempty"` // Set in aggregates
+	Speaker   string  `json:"speaker,omitempty"`
+	Start     float64 `json:"start"` // Seconds
+	End       float64 `json:"end"`
+	Duration  float64 `json:"duration"`
+}
+
+// Compute returns the stats of a meeting of the duration, in seconds, from its segments.
+// Without a duration the end of the last segment is used.
+func Compute(segments []types.Segment, duration float64) Stats {
+	stats := Stats{Fillers: make(map[string]int)}
+	if len(segments) == 0 {
+		stats.Duration = duration
+		stats.SilenceTime = duration
+		return stats
+	}
+
+	speakers := make(map[string]*Speaker)
+	for _, segment := range segments {
+		words := splitWords(segment.Text)
+		stats.Words += len(words)
+		filler := countFillers(words, stats.Fillers)
+		stats.FillerWords += filler
+
+		if name := speakerName(segment); name != "" {
+			speaker, ok := speakers[name]
+			if !ok {
+				speaker = &Speaker{Name: name}
+				speakers[name] = speaker
+			}
+			speaker.TalkTime += segment.End - segment.Start
+			speaker.Words += len(words)
+			speaker.FillerWords += filler
+		}
+	}
+
+	stats.SpeechTime = speechTime(segments)
+	stats.Duration = max(duration, segments[len(segments)-1].End)
+	stats.SilenceTime = max(stats.Duration-stats.SpeechTime, 0)
+	stats.WordsPerMinute = perMinute(stats.Words, stats.SpeechTime)
+	stats.LongestMonologue = longestMonologue(segments)
+
+	for _, speaker := range speakers {
+		speaker.WordsPerMinute = perMinute(speaker.Words, speaker.TalkTime)
+		if stats.SpeechTime > 0 {
+			speaker.Share = min(speaker.TalkTime/stats.SpeechTime, 1)
+		}
+		stats.Speakers = append(stats.Speakers, *speaker)
+	}
+	sort.Slice(stats.Speakers, func(i, j int) bool {
+		return stats.Speakers[i].TalkTime > stats.Speakers[j].TalkTime
+	})
+	return stats
+}
+
+// Aggregate is the stats of several meetings together
+type Aggregate struct {
+	Meetings         int            `json:"meetings"`
+	Duration         float64        `json:"duration"`
+	SpeechTime       float64        `json:"speech_time"`
+	SilenceTime      float64        `json:"silence_time"`
+	Words            int            `json:"words"`
+	WordsPerMinute   float64        `json:"words_per_minute"`
+	Fillers          map[string]int `json:"fillers"`
+	FillerWords      int            `json:"filler_words"`
+	LongestMonologue *Monologue     `json:"longest_monologue,omitempty"`
+}
+
+// Add adds the stats of a meeting to the aggregate
+func (a *Aggregate) Add(meetingId string, stats Stats) {
+	if a.Fillers == nil {
+		a.Fillers = make(map[string]int)
+	}
+	a.Meetings++
+	a.Duration += stats.Duration
+	a.SpeechTime += stats.SpeechTime
+	a.SilenceTime += stats.SilenceTime
+	a.Words += stats.Words
+	a.WordsPerMinute = perMinute(a.Words, a.SpeechTime)
+	for filler, count := range stats.Fillers {
+		a.Fillers[filler] += count
+	}
+	a.FillerWords += stats.FillerWords
+	if monologue := stats.LongestMonologue; monologue != nil && (a.LongestMonologue == nil || monologue.Duration > a.LongestMonologue.Duration) {
+		longest := *monologue
+		longest.MeetingId = meetingId
+		a.LongestMonologue = &longest
+	}
+}
+
+// speakerName returns who spoke a segment: its speaker, or the track it was transcribed from
+func speakerName(segment types.Segment) string {
+	if segment.Speaker != "" {
+		return segment.Speaker
+	}
+	return segment.Source
+}
+
+// speechTime returns the seconds covered by at least one segment, counting overlapping
+// segments, as transcribed per track, once
+func speechTime(segments []types.Segment) float64 {
+	sorted := make([]types.Segment, len(segments))
+	copy(sorted, segments)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Start < sorted[j].Start })
+
+	var total, start, end float64
+	for i, segment := range sorted {
+		if i == 0 || segment.Start > end {
+			total += end - start
+			start, end = segment.Start, segment.End
+			continue
+		}
+		end = max(end, segment.End)
+	}
+	return total + end - start
+}
+
+// longestMonologue returns the longest run of segments of one speaker without a long pause.
+// Transcripts without speakers are treated as a single speaker.
+func longestMonologue(segments []types.Segment) *Monologue {
+	var longest, current *Monologue
+	for _, segment := range segments {
+		speaker := speakerName(segment)
+		if current == nil || current.Speaker != speaker || segment.Start-current.End > maxMonologuePause {
+			current = &Monologue{Speaker: speaker, Start: segment.Start}
+		}
+		current.End = max(current.End, segment.End)
+		current.Duration = current.End - current.Start
+		if longest == nil || current.Duration > longest.Duration {
+			monologue := *current
+			longest = &monologue
+		}
+	}
+	return longest
+}
+
+// countFillers counts the filler words and phrases in the words into counts and returns how
+// many were found
+func countFillers(words []string, counts map[string]int) int {
+	found := 0
+	for i := range words {
+		for _, filler := range fillers {
+			parts := strings.Fields(filler)
+			if i+len(parts) > len(words) {
+				continue
+			}
+			if strings.Join(words[i:i+len(parts)], " ") == filler {
+				counts[filler]++
+				found++
+			}
+		}
+	}
+	return found
+}
+
+// splitWords returns the lowercased words of a text
+func splitWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// perMinute returns the words per minute spoken in the seconds
+func perMinute(words int, seconds float64) float64 {
+	if seconds <= 0 {
+		return 0
+	}
+	return float64(words) / seconds * 60
+}
@@ -0,0 +1,57 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/meetingapps"
+)
+
+// ErrAppCaptureUnavailable is returned when the audio of an app is recorded without the tap
+// helper installed
+var ErrAppCaptureUnavailable = errors.New("recording the audio of an app requires the app tap helper")
+
+// appTap returns the tap recording the audio of the app, by the name of a meeting app or the
+// executable name of any other app. The PIDs are resolved now, so the app must be running.
+func (t *TranscriberService) appTap(app string) (*audiocapture.AppTap, error) {
+	cfg := t.config.Audio.AppCapture
+	if t.config.DevMode {
+		return &audiocapture.AppTap{App: app}, nil // The sample recorder doesn't run the helper
+	}
+	if cfg.Helper == "" {
+		return nil, fmt.Errorf("%w: set audio.app_capture.helper", ErrAppCaptureUnavailable)
+	}
+	helper, err := exec.LookPath(cfg.Helper)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s was not found on PATH", ErrAppCaptureUnavailable, cfg.Helper)
+	}
+	pids, err := meetingapps.ProcessIDs(app)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(pids))
+	for i, pid := range pids {
+		ids[i] = strconv.Itoa(pid)
+	}
+	// A {pids} argument becomes an argument per PID, inside another argument they are joined
+	command := []string{helper}
+	for _, arg := range cfg.Args {
+		if arg == "{pids}" {
+			command = append(command, ids...)
+			continue
+		}
+		command = append(command, strings.ReplaceAll(arg, "{pids}", strings.Join(ids, ",")))
+	}
+	return &audiocapture.AppTap{
+		App:        app,
+		Command:    command,
+		Format:     cfg.Format,
+		SampleRate: cfg.SampleRate,
+		Channels:   cfg.Channels,
+	}, nil
+}
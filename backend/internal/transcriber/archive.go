@@ -0,0 +1,147 @@
+package transcriber
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// archiveRecording keeps a compressed copy of the mixed recording, when enabled, and of the
+// track of each device when more than two were recorded. The WAV file stays in place for
+// transcription. Failures are logged and the meeting is processed anyway.
+func (t *TranscriberService) archiveRecording(ctx context.Context, meeting *types.Meeting) {
+	cfg := t.config.Audio.Archive
+	if cfg.Codec == "" || meeting.ArchivePath != "" {
+		return
+	}
+	if info, err := os.Stat(meeting.Transcript_path); err != nil || info.Size() == 0 {
+		return
+	}
+
+	extension, err := audiocapture.CodecExtension(cfg.Codec)
+	if err != nil {
+		t.logger.Error("Invalid archive codec", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		t.logger.Error("Failed to create archive directory", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	archivePath := filepath.Join(cfg.Dir, filepath.Base(t.recordingFileName(meeting)))
+	archivePath = freePath(meeting, archivePath[:len(archivePath)-len(filepath.Ext(archivePath))]+extension, "")
+
+	if err := t.transcoder.Compress(ctx, meeting.Transcript_path, archivePath, cfg.Codec, cfg.Bitrate); err != nil {
+		t.logger.Error("Failed to archive recording", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	if err := t.cipher.EncryptFile(archivePath); err != nil {
+		os.Remove(archivePath)
+		t.logger.Error("Failed to encrypt archived recording", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	meeting.ArchivePath = archivePath
+	meeting.ArchiveCodec = cfg.Codec
+	meeting.ArchiveTracks = t.archiveTracks(ctx, meeting, archivePath, extension)
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeArchived)
+	t.logger.Info("Archived recording", "meetingId", meeting.Id, "file", archivePath, "codec", cfg.Codec)
+}
+
+// storedFileCacheSize bounds the decrypted files kept in memory, so a player seeking in an
+// encrypted recording with range requests doesn't have it decrypted for every range
+const storedFileCacheSize = 256 << 20
+
+// storedFileCache keeps the most recently read decrypted files
+type storedFileCache struct {
+	mu    sync.Mutex
+	files []storedFile // Least recently read first
+	size  int
+}
+
+// storedFile is a decrypted file, valid while the file keeps its size and modification time
+type storedFile struct {
+	path    string
+	size    int64
+	modTime time.Time
+	data    []byte
+}
+
+// ReadStoredFile reads an archived recording, export or highlight reel of a meeting,
+// decrypting it when it is stored encrypted. Decrypted files are cached; the data must not
+// be modified.
+func (t *TranscriberService) ReadStoredFile(path string) ([]byte, error) {
+	if t.cipher == nil {
+		return os.ReadFile(path)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	cache := &t.storedFiles
+	cache.mu.Lock()
+	for i, file := range cache.files {
+		if file.path == path && file.size == info.Size() && file.modTime.Equal(info.ModTime()) {
+			cache.files = append(append(cache.files[:i:i], cache.files[i+1:]...), file)
+			cache.mu.Unlock()
+			return file.data, nil
+		}
+	}
+	cache.mu.Unlock()
+
+	data, err := t.cipher.ReadFile(path)
+	if err != nil || len(data) > storedFileCacheSize {
+		return data, err
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	cache.files = slices.DeleteFunc(cache.files, func(file storedFile) bool {
+		if file.path == path {
+			cache.size -= len(file.data)
+			return true
+		}
+		return false
+	})
+	for cache.size+len(data) > storedFileCacheSize {
+		cache.size -= len(cache.files[0].data)
+		cache.files = cache.files[1:]
+	}
+	cache.files = append(cache.files, storedFile{path: path, size: info.Size(), modTime: info.ModTime(), data: data})
+	cache.size += len(data)
+	return data, nil
+}
+
+// archiveTracks compresses the track of each device of a recording of more than two devices
+// next to its archived mix, so the devices can be mixed again later
+func (t *TranscriberService) archiveTracks(ctx context.Context, meeting *types.Meeting, archivePath string, extension string) []types.AudioTrack {
+	if len(meeting.Tracks) <= 2 {
+		return nil
+	}
+	cfg := t.config.Audio.Archive
+	base := archivePath[:len(archivePath)-len(extension)]
+	var archived []types.AudioTrack
+	for _, track := range meeting.Tracks {
+		if _, err := os.Stat(track.Path); err != nil {
+			continue
+		}
+		trackPath := base + "_" + track.Source + extension
+		if err := t.transcoder.Compress(ctx, track.Path, trackPath, cfg.Codec, cfg.Bitrate); err != nil {
+			t.logger.Error("Failed to archive track", "error", err, "meetingId", meeting.Id, "track", track.Source)
+			continue
+		}
+		if err := t.cipher.EncryptFile(trackPath); err != nil {
+			os.Remove(trackPath)
+			t.logger.Error("Failed to encrypt archived track", "error", err, "meetingId", meeting.Id, "track", track.Source)
+			continue
+		}
+		archived = append(archived, types.AudioTrack{Source: track.Source, Path: trackPath})
+	}
+	return archived
+}
@@ -0,0 +1,172 @@
+package transcriber
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/devices"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+)
+
+// defaultArmTimeout is used when no arm timeout is configured
+const defaultArmTimeout = 15 * time.Minute
+
+// ErrNotArmed is returned when disarming without an armed recording
+var ErrNotArmed = errors.New("no recording is armed")
+
+// ArmOptions selects the devices to arm, like the options of a recording
+type ArmOptions struct {
+	Series       string
+	Title        string
+	MicDevice    string
+	SystemDevice string
+	Owner        string // User arming the capture, owning the recording that takes it over
+}
+
+// ArmedRecording is a capture started ahead of a recording, so the recording begins as soon
+// as it is started instead of after ffmpeg's startup latency
+type ArmedRecording struct {
+	MicDevice    string    `json:"mic_device"`
+	SystemDevice string    `json:"system_device"`
+	ArmedAt      time.Time `json:"armed_at"`
+	ExpiresAt    time.Time `json:"expires_at"` // The capture is discarded when no recording starts before
+	devices      audiocapture.CaptureDevices
+	mixOptions   audiocapture.MixOptions
+	capture      audiocapture.Recorder
+	timer        *time.Timer
+	owner        string
+}
+
+// ArmRecording starts capturing from the devices a recording with the options would use.
+// The next recording with the same devices takes over the capture. An earlier armed
+// capture is discarded.
+func (t *TranscriberService) ArmRecording(opts ArmOptions) (*ArmedRecording, error) {
+	if !t.Capabilities().Recording {
+		return nil, ErrFFmpegMissing
+	}
+	if _, err := t.checkRecordingSpace(); err != nil {
+		return nil, err
+	}
+	if t.recordingActive() {
+		return nil, ErrRecordingInProgress
+	}
+	if err := t.Disarm(); err != nil && !errors.Is(err, ErrNotArmed) {
+		t.logger.Error("Failed to discard armed recording", "error", err)
+	}
+
+	captureDevices := t.resolveDevices(devices.SeriesKey(opts.Series, opts.Title), opts.MicDevice, opts.SystemDevice)
+	if err := t.matchDevices(&captureDevices); err != nil {
+		return nil, err
+	}
+	path := osoperations.CreateFilePath(t.recordDir, "armed_"+uuid.NewString()+".wav")
+	mixOptions := t.mixOptions()
+	capture := t.capturer.NewRecorder(path, captureDevices, mixOptions)
+	if err := capture.Start(t.ctx); err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(t.config.Audio.Arm.Timeout) * time.Minute
+	if timeout <= 0 {
+		timeout = defaultArmTimeout
+	}
+	armed := &ArmedRecording{
+		MicDevice:    captureDevices.Mic,
+		SystemDevice: captureDevices.System,
+		ArmedAt:      time.Now(),
+		ExpiresAt:    time.Now().Add(timeout),
+		devices:      captureDevices,
+		mixOptions:   mixOptions,
+		capture:      capture,
+		owner:        opts.Owner,
+	}
+	armed.timer = time.AfterFunc(timeout, func() {
+		t.mu.Lock()
+		expired := t.armed == armed
+		if expired {
+			t.armed = nil
+		}
+		t.mu.Unlock()
+		if expired {
+			t.logger.Info("Armed recording expired", "mic", armed.MicDevice, "system", armed.SystemDevice)
+			armed.capture.Cancel()
+		}
+	})
+
+	t.mu.Lock()
+	t.armed = armed
+	t.mu.Unlock()
+	t.logger.Info("Armed recording", "mic", captureDevices.Mic, "system", captureDevices.System, "expiresAt", armed.ExpiresAt)
+	return armed, nil
+}
+
+// records reports whether the armed capture records the selected devices the way the
+// recording would
+func (a *ArmedRecording) records(selected audiocapture.CaptureDevices, mixOptions audiocapture.MixOptions) bool {
+	return a.devices.Mic == selected.Mic && a.devices.System == selected.System &&
+		a.devices.MicGain == selected.MicGain && a.devices.SystemGain == selected.SystemGain &&
+		selected.SystemApp == nil && len(selected.Extra) == 0 && a.mixOptions == mixOptions
+}
+
+// ArmedRecording returns the armed capture, if any
+func (t *TranscriberService) ArmedRecording() (*ArmedRecording, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return t.armed, t.armed != nil
+}
+
+// Disarm discards the armed capture
+func (t *TranscriberService) Disarm() error {
+	armed := t.takeArmed()
+	if armed == nil {
+		return ErrNotArmed
+	}
+	t.logger.Info("Disarmed recording", "mic", armed.MicDevice, "system", armed.SystemDevice)
+	return armed.capture.Cancel()
+}
+
+// autoOwner returns the owner of a recording started without a user: the user who armed the
+// capture it takes over, or else auth.auto_owner
+func (t *TranscriberService) autoOwner() string {
+	if armed, ok := t.ArmedRecording(); ok && armed.owner != "" {
+		return armed.owner
+	}
+	return t.config.Auth.AutoOwner
+}
+
+// takeArmed removes the armed capture so a recording can use it
+func (t *TranscriberService) takeArmed() *ArmedRecording {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	armed := t.armed
+	if armed != nil {
+		armed.timer.Stop()
+		t.armed = nil
+	}
+	return armed
+}
+
+// armedCapture returns the armed capture when it records the selected devices with the
+// same filters, with the recording begun now. A capture of other devices or with other
+// filters is discarded.
+func (t *TranscriberService) armedCapture(selected audiocapture.CaptureDevices, mixOptions audiocapture.MixOptions) audiocapture.Recorder {
+	armed := t.takeArmed()
+	if armed == nil {
+		return nil
+	}
+	if !armed.records(selected, mixOptions) {
+		t.logger.Info("Armed recording uses other devices or filters, discarding it", "mic", armed.MicDevice, "system", armed.SystemDevice)
+		armed.capture.Cancel()
+		return nil
+	}
+
+	preRoll := time.Duration(t.config.Audio.Arm.PreRoll * float64(time.Second))
+	if err := armed.capture.Begin(preRoll); err != nil {
+		t.logger.Error("Failed to begin armed recording, starting a new capture", "error", err)
+		armed.capture.Cancel()
+		return nil
+	}
+	return armed.capture
+}
@@ -0,0 +1,215 @@
+package transcriber
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/martijnspitter/transcriber/internal/ollama"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// askTopK is the number of transcript segments retrieved as context for a question
+const askTopK = 8
+
+const askSystemPrompt = `You answer questions about meetings using only the numbered transcript excerpts provided.
+Cite the excerpts that support your answer with their number in square brackets, e.g. [2] or [1][4].
+If the excerpts do not contain the answer, say that the meetings don't cover it. Do not make up facts.`
+
+var citationPattern = regexp.MustCompile(`\[(\d+)\]`)
+
+// Citation is a transcript segment referenced by an answer
+type Citation struct {
+	Number    int           `json:"number"`
+	MeetingId string        `json:"meeting_id"`
+	Title     string        `json:"title"`
+	Segment   types.Segment `json:"segment"`
+	Score     float64       `json:"score"`
+}
+
+// Answer is the response to a question about one or more meetings
+type Answer struct {
+	Question  string     `json:"question"`
+	Answer    string     `json:"answer"`
+	Citations []Citation `json:"citations"`
+}
+
+// embeddingIndex caches segment embeddings per meeting
+type embeddingIndex struct {
+	mu      sync.Mutex
+	vectors map[string]segmentEmbeddings // Meeting ID -> embeddings of its segments
+}
+
+// segmentEmbeddings are the embeddings of the segments of a meeting, one vector per segment,
+// with the hash of the segment texts they were computed from
+type segmentEmbeddings struct {
+	hash    [sha256.Size]byte
+	vectors [][]float64
+}
+
+// segmentVectors returns the embeddings of the meeting segments, computing them when missing
+// or when a segment text changed since they were computed
+func (t *TranscriberService) segmentVectors(ctx context.Context, meeting *types.Meeting) ([][]float64, error) {
+	texts := make([]string, len(meeting.Segments))
+	hash := sha256.New()
+	for i, segment := range meeting.Segments {
+		texts[i] = segment.Text
+		hash.Write([]byte(segment.Text))
+		hash.Write([]byte{0})
+	}
+	var sum [sha256.Size]byte
+	hash.Sum(sum[:0])
+
+	t.embeddings.mu.Lock()
+	defer t.embeddings.mu.Unlock()
+
+	if cached, ok := t.embeddings.vectors[meeting.Id]; ok && cached.hash == sum {
+		return cached.vectors, nil
+	}
+
+	vectors, err := t.embed(ctx, texts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed transcript segments: %w", err)
+	}
+	t.embeddings.vectors[meeting.Id] = segmentEmbeddings{hash: sum, vectors: vectors}
+	return vectors, nil
+}
+
+// invalidateEmbeddings drops the cached segment embeddings of a meeting after its transcript changed
+func (t *TranscriberService) invalidateEmbeddings(meetingId string) {
+	t.embeddings.mu.Lock()
+	defer t.embeddings.mu.Unlock()
+	delete(t.embeddings.vectors, meetingId)
+}
+
+// AskMeeting answers a question about a single meeting
+func (t *TranscriberService) AskMeeting(ctx context.Context, meetingId string, question string) (*Answer, error) {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return nil, err
+	}
+	if len(meeting.Segments) == 0 {
+		return nil, fmt.Errorf("meeting %s has no transcript to ask about", meetingId)
+	}
+	return t.ask(ctx, []*types.Meeting{meeting}, question)
+}
+
+// Ask answers a question across all transcribed meetings matching the filter
+func (t *TranscriberService) Ask(ctx context.Context, question string, filter MeetingFilter) (*Answer, error) {
+	var meetings []*types.Meeting
+	for _, meeting := range t.GetAllMeetings() {
+		if len(meeting.Segments) > 0 && filter.Matches(meeting) {
+			meetings = append(meetings, meeting)
+		}
+	}
+	if len(meetings) == 0 {
+		return nil, fmt.Errorf("no transcribed meetings match the filter")
+	}
+	return t.ask(ctx, meetings, question)
+}
+
+// ask retrieves the segments most similar to the question and lets the LLM answer from them
+func (t *TranscriberService) ask(ctx context.Context, meetings []*types.Meeting, question string) (*Answer, error) {
+	question = strings.TrimSpace(question)
+	if question == "" {
+		return nil, fmt.Errorf("question cannot be empty")
+	}
+
+	questionVectors, err := t.embed(ctx, []string{question})
+	if err != nil {
+		return nil, fmt.Errorf("failed to embed question: %w", err)
+	}
+
+	// Score every segment of every meeting against the question
+	var candidates []Citation
+	for _, meeting := range meetings {
+		vectors, err := t.segmentVectors(ctx, meeting)
+		if err != nil {
+			return nil, err
+		}
+		for i, vector := range vectors {
+			candidates = append(candidates, Citation{
+				MeetingId: meeting.Id,
+				Title:     meeting.Title,
+				Segment:   meeting.Segments[i],
+				Score:     cosineSimilarity(questionVectors[0], vector),
+			})
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return candidates[i].Score > candidates[j].Score
+	})
+	if len(candidates) > askTopK {
+		candidates = candidates[:askTopK]
+	}
+
+	// Build the numbered context excerpts
+	var context strings.Builder
+	for i := range candidates {
+		candidates[i].Number = i + 1
+		c := candidates[i]
+		context.WriteString(fmt.Sprintf("[%d] %s, %s: %s\n", c.Number, c.Title, FormatTimestamp(c.Segment.Start), c.Segment.Text))
+	}
+
+	msgs := []ollama.Message{
+		{Role: "system", Content: askSystemPrompt},
+		{Role: "user", Content: fmt.Sprintf("Transcript excerpts:\n%s\nQuestion: %s", context.String(), question)},
+	}
+
+	res, err := t.chat(ctx, t.config.Ollama.Model, msgs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to talk to Ollama: %w", err)
+	}
+
+	return &Answer{
+		Question:  question,
+		Answer:    res.Message.Content,
+		Citations: citedExcerpts(res.Message.Content, candidates),
+	}, nil
+}
+
+// citedExcerpts returns the excerpts referenced in the answer, or all of them when the
+// model didn't cite anything
+func citedExcerpts(answer string, excerpts []Citation) []Citation {
+	cited := make(map[int]bool)
+	for _, match := range citationPattern.FindAllStringSubmatch(answer, -1) {
+		if n, err := strconv.Atoi(match[1]); err == nil {
+			cited[n] = true
+		}
+	}
+	if len(cited) == 0 {
+		return excerpts
+	}
+
+	var citations []Citation
+	for _, excerpt := range excerpts {
+		if cited[excerpt.Number] {
+			citations = append(citations, excerpt)
+		}
+	}
+	return citations
+}
+
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) != len(b) || len(a) == 0 {
+		return 0
+	}
+
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
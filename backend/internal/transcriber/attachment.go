@@ -0,0 +1,102 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/config"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// attachRecording adds the recording to the vault attachments folder, when enabled, so the
+// note can embed it. Failures are logged and the note is saved without the recording.
+func (t *TranscriberService) attachRecording(ctx context.Context, meeting *types.Meeting) {
+	cfg := t.config.Audio.VaultAttachment
+	if cfg.Mode == "" || meeting.AudioAttachment != "" {
+		return
+	}
+	if info, err := os.Stat(meeting.Transcript_path); err != nil || info.Size() == 0 {
+		t.logger.Info("No recording to attach", "meetingId", meeting.Id)
+		return
+	}
+
+	attachment, err := t.createAttachment(ctx, meeting, cfg)
+	if err != nil {
+		t.logger.Error("Failed to attach recording to vault note", "error", err, "meetingId", meeting.Id, "mode", cfg.Mode)
+		return
+	}
+	meeting.AudioAttachment = attachment
+	t.setMeeting(meeting)
+	t.logger.Info("Attached recording to vault note", "meetingId", meeting.Id, "attachment", attachment)
+}
+
+// createAttachment writes the recording into the vault and returns its path relative to the vault root
+func (t *TranscriberService) createAttachment(ctx context.Context, meeting *types.Meeting, cfg config.VaultAttachmentConfig) (string, error) {
+	folder := filepath.Clean(cfg.Folder)
+	if !filepath.IsLocal(folder) {
+		return "", fmt.Errorf("attachment folder %q must be a relative path inside the vault", cfg.Folder)
+	}
+	name, err := t.noteName(meeting)
+	if err != nil {
+		return "", err
+	}
+	vaultDir, err := osoperations.VaultDir()
+	if err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(filepath.Join(vaultDir, folder), 0755); err != nil {
+		return "", err
+	}
+
+	switch cfg.Mode {
+	case config.AttachmentModeCopy:
+		attachment := filepath.Join(folder, name+".wav")
+		return attachment, copyFile(meeting.Transcript_path, filepath.Join(vaultDir, attachment))
+	case config.AttachmentModeCompress:
+		attachment := filepath.Join(folder, name+".m4a")
+		return attachment, t.transcoder.Compress(ctx, meeting.Transcript_path, filepath.Join(vaultDir, attachment), audiocapture.CodecAAC, cfg.Bitrate)
+	case config.AttachmentModeSymlink:
+		// The WAV file is removed after processing, so link to the archived recording or a kept
+		// copy; an encrypted archive can't be played from the vault
+		kept := meeting.ArchivePath
+		if kept == "" || t.cipher != nil {
+			if err := os.MkdirAll(cfg.KeepDir, 0700); err != nil {
+				return "", err
+			}
+			kept = filepath.Join(cfg.KeepDir, meeting.Id+".wav")
+			if err := copyFile(meeting.Transcript_path, kept); err != nil {
+				return "", err
+			}
+		}
+		attachment := filepath.Join(folder, name+filepath.Ext(kept))
+		linkPath := filepath.Join(vaultDir, attachment)
+		os.Remove(linkPath)
+		return attachment, os.Symlink(kept, linkPath)
+	default:
+		return "", fmt.Errorf("unknown attachment mode %q", cfg.Mode)
+	}
+}
+
+// copyFile copies src to dst, replacing dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,78 @@
+package transcriber
+
+import (
+	"fmt"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// autoStopInterval is how often a running recording is checked, and the length of audio
+// measured for silence on every check
+const autoStopInterval = 30 * time.Second
+
+// watchAutoStop stops the recording once it reaches the maximum duration or both tracks
+// have been silent for the configured number of minutes. The reason is added to the
+// meeting's warnings.
+func (t *TranscriberService) watchAutoStop(meeting *types.Meeting, tracks []types.AudioTrack) {
+	cfg := t.config.Audio.AutoStop
+	if cfg.MaxDuration <= 0 && cfg.SilenceMinutes <= 0 {
+		return
+	}
+	maxDuration := time.Duration(cfg.MaxDuration) * time.Minute
+	silenceLimit := time.Duration(cfg.SilenceMinutes) * time.Minute
+
+	var silentSince time.Time
+	ticker := time.NewTicker(autoStopInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !t.isRecording(meeting) {
+			return
+		}
+
+		var reason string
+		if maxDuration > 0 && time.Since(meeting.Start_time) >= maxDuration {
+			reason = fmt.Sprintf("recording stopped automatically after reaching the maximum duration of %s", maxDuration)
+		} else if silenceLimit > 0 {
+			if !t.tracksSilent(meeting, tracks) {
+				silentSince = time.Time{}
+			} else if silentSince.IsZero() {
+				silentSince = time.Now().Add(-autoStopInterval)
+			}
+			if !silentSince.IsZero() && time.Since(silentSince) >= silenceLimit {
+				reason = fmt.Sprintf("recording stopped automatically after %s of silence", silenceLimit)
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		t.logger.Info("Stopping recording automatically", "meetingId", meeting.Id, "reason", reason)
+		t.addWarning(meeting, events.TypeWarning, reason)
+		if err := t.StopMeeting(meeting.Id); err != nil {
+			t.logger.Error("Failed to stop recording automatically", "error", err, "meetingId", meeting.Id)
+		}
+		return
+	}
+}
+
+// tracksSilent reports whether every track was below the silence threshold during the last
+// check interval. Tracks that can't be measured count as not silent.
+func (t *TranscriberService) tracksSilent(meeting *types.Meeting, tracks []types.AudioTrack) bool {
+	if len(tracks) == 0 {
+		return false
+	}
+	for _, track := range tracks {
+		level, err := audiocapture.TrackLevel(track.Path, autoStopInterval)
+		if err != nil {
+			t.logger.Error("Failed to measure track level", "error", err, "meetingId", meeting.Id, "track", track.Source)
+			return false
+		}
+		if level >= t.config.Audio.SilenceDetection.ThresholdDB {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,214 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/martijnspitter/transcriber/internal/artifacts"
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrInvalidBatch is returned for batch directories that can't be processed
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// ProcessFile queues an audio file recorded elsewhere for the full pipeline, as if it was
+// just recorded. The meeting is titled after the file unless opts has a title and dated
+// when the file was last modified. The file is moved into the recordings directory, or
+// copied when keep is set, since processed recordings are removed.
+func (t *TranscriberService) ProcessFile(opts RecordingOptions, path string, keep bool) (string, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return "", err
+	}
+	if opts.Title == "" {
+		opts.Title = osoperations.GetFileNameWithoutExtension(path)
+	}
+	return t.processFile(opts, path, keep, info.ModTime())
+}
+
+// processFile queues an audio file recorded at the time for the full pipeline
+func (t *TranscriberService) processFile(opts RecordingOptions, path string, keep bool, recordedAt time.Time) (string, error) {
+	if t.stopping.Load() {
+		return "", ErrShuttingDown
+	}
+	meeting, err := t.fileMeeting(opts, recordedAt)
+	if err != nil {
+		return "", err
+	}
+	if err := t.takeFile(meeting, path, keep); err != nil {
+		return "", err
+	}
+
+	t.setStatus(meeting, types.MeetingStatusProcessing)
+	t.recordEvent(meeting, events.TypeCreated)
+	t.queueFile(meeting, path)
+	return meeting.Id, nil
+}
+
+// fileMeeting returns a new meeting for audio recorded elsewhere at the time
+func (t *TranscriberService) fileMeeting(opts RecordingOptions, createdAt time.Time) (*types.Meeting, error) {
+	opts.Tags = cleanTags(opts.Tags)
+	vaultFolder, err := t.applyPreset(&opts)
+	if err != nil {
+		return nil, err
+	}
+	return &types.Meeting{
+		Id:            uuid.NewString(),
+		Title:         opts.Title,
+		CreatedAt:     createdAt,
+		Start_time:    createdAt,
+		Participants:  opts.Participants,
+		Tags:          opts.Tags,
+		Metadata:      opts.Metadata,
+		Owner:         opts.Owner,
+		Template:      opts.Template,
+		Type:          opts.Type,
+		Series:        opts.Series,
+		VaultFolder:   vaultFolder,
+		Stages:        opts.Stages,
+		Audio_devices: []types.AudioDevice{},
+	}, nil
+}
+
+// takeFile moves or, when keep is set, copies an audio file into the recordings directory
+// as the recording of the meeting
+func (t *TranscriberService) takeFile(meeting *types.Meeting, path string, keep bool) error {
+	fileName := strings.TrimSuffix(t.recordingFileName(meeting), ".wav") + strings.ToLower(filepath.Ext(path))
+	meeting.Transcript_path = freePath(meeting, osoperations.CreateFilePath(t.recordDir, fileName), "")
+
+	var err error
+	if keep {
+		err = copyFile(path, meeting.Transcript_path)
+	} else {
+		err = artifacts.Promote(path, meeting.Transcript_path)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to take %s: %w", path, err)
+	}
+	// The length of other formats is estimated from the transcript
+	if duration, err := audiocapture.TrackDuration(meeting.Transcript_path); err == nil {
+		meeting.Duration = int(duration.Seconds())
+	}
+	return nil
+}
+
+// freePath returns path, or path with the meeting ID prefixed to its name when another file is
+// there already and it isn't current, so meetings with the same name, such as a merged
+// meeting and its first part, don't overwrite each other's files
+func freePath(meeting *types.Meeting, path string, current string) string {
+	if _, err := os.Stat(path); err != nil || path == current {
+		return path
+	}
+	return filepath.Join(filepath.Dir(path), meeting.Id+"-"+filepath.Base(path))
+}
+
+// queueFile queues the recording of a meeting taken from a file for processing
+func (t *TranscriberService) queueFile(meeting *types.Meeting, source string) {
+	t.logger.Info("Queueing audio file for processing", "meetingId", meeting.Id, "file", source)
+	t.queue.Enqueue(meeting.Id, tagPriority(t.config.Processing.TagPriorities, meeting.Tags), func() {
+		t.processMeeting(t.ctx, meeting)
+	})
+}
+
+// ProcessDirectory queues the audio files in a directory for the full pipeline, each as
+// its own meeting titled after the file. The files are copied, so the directory is left
+// as it is. It returns the IDs of the queued meetings.
+func (t *TranscriberService) ProcessDirectory(opts RecordingOptions, dir string) ([]string, error) {
+	files, err := t.audioFiles(dir)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBatch, err)
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("%w: no audio files in %s", ErrInvalidBatch, dir)
+	}
+
+	var meetingIds []string
+	for _, path := range files {
+		meetingId, err := t.ProcessFile(opts, path, true)
+		if err != nil {
+			return meetingIds, err
+		}
+		meetingIds = append(meetingIds, meetingId)
+	}
+	return meetingIds, nil
+}
+
+// watchFolder periodically scans the watch folder and processes the audio files dropped into
+// it. Files are picked up once their size stops changing, so files still being copied in
+// aren't processed half.
+func (t *TranscriberService) watchFolder() {
+	cfg := t.config.Watch
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	if err := os.MkdirAll(cfg.Folder, 0755); err != nil {
+		t.logger.Error("Failed to create watch folder", "error", err, "folder", cfg.Folder)
+		return
+	}
+	t.logger.Info("Watching folder for audio files", "folder", cfg.Folder)
+
+	sizes := make(map[string]int64) // Sizes seen on the previous scan, by path
+	failed := make(map[string]bool) // Files that couldn't be taken, left in the folder
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for ; t.ctx.Err() == nil; t.nextTick(ticker) {
+		files, err := t.audioFiles(cfg.Folder)
+		if err != nil {
+			t.logger.Error("Failed to scan watch folder", "error", err, "folder", cfg.Folder)
+			continue
+		}
+
+		seen := make(map[string]int64, len(files))
+		for _, path := range files {
+			info, err := os.Stat(path)
+			if err != nil || failed[path] {
+				continue
+			}
+			if previous, ok := sizes[path]; !ok || previous != info.Size() {
+				seen[path] = info.Size()
+				continue
+			}
+
+			meetingId, err := t.ProcessFile(RecordingOptions{Type: cfg.Type, Tags: cfg.Tags, Owner: t.config.Auth.AutoOwner}, path, false)
+			if err != nil {
+				t.logger.Error("Failed to process dropped audio file", "error", err, "file", path)
+				failed[path] = true
+				continue
+			}
+			t.logger.Info("Picked up audio file from watch folder", "meetingId", meetingId, "file", path)
+		}
+		sizes = seen
+	}
+}
+
+// audioFiles returns the audio files directly inside a directory, by the configured
+// extensions and skipping hidden files
+func (t *TranscriberService) audioFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var files []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") {
+			continue
+		}
+		if slices.ContainsFunc(t.config.Watch.Extensions, func(ext string) bool {
+			return strings.EqualFold(ext, filepath.Ext(name))
+		}) {
+			files = append(files, filepath.Join(dir, name))
+		}
+	}
+	return files, nil
+}
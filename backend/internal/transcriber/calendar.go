@@ -0,0 +1,103 @@
+package transcriber
+
+import (
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/calendar"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// calendarCheckInterval is how often tagged events are checked for starting or stopping
+const calendarCheckInterval = 30 * time.Second
+
+// calendarMetadataKey stores the UID of the calendar event a meeting was recorded for
+const calendarMetadataKey = "calendar_uid"
+
+// Calendar returns the calendar feed, or nil when no calendar is configured
+func (t *TranscriberService) Calendar() *calendar.Feed {
+	return t.calendar
+}
+
+// applyCalendarEvent titles a recording started without a title after the calendar event
+// happening now and adds its attendees as participants
+func (t *TranscriberService) applyCalendarEvent(opts *RecordingOptions) {
+	if t.calendar == nil || opts.Title != "" {
+		return
+	}
+	event, ok := t.calendar.Current(time.Now())
+	if !ok {
+		return
+	}
+
+	opts.Title = event.Title(t.config.Calendar.Tag)
+	opts.Participants = mergeUnique(opts.Participants, event.Attendees)
+	if opts.Metadata == nil {
+		opts.Metadata = make(map[string]string)
+	}
+	if _, ok := opts.Metadata[calendarMetadataKey]; !ok && event.UID != "" {
+		opts.Metadata[calendarMetadataKey] = event.UID
+	}
+	t.logger.Info("Titled recording after calendar event", "title", opts.Title, "attendees", len(event.Attendees))
+}
+
+// watchCalendar refreshes the calendar feed and, when enabled, records tagged events from
+// their start to their end. An event is started once, so stopping its recording by hand
+// isn't undone.
+func (t *TranscriberService) watchCalendar() {
+	cfg := t.config.Calendar
+	refresh := time.Duration(cfg.RefreshMinutes) * time.Minute
+	if refresh <= 0 {
+		refresh = 5 * time.Minute
+	}
+
+	started := make(map[string]bool) // Occurrences recorded, by UID and start
+	var recording *types.Meeting     // Meeting started for the current event
+	var recordingEnd time.Time
+
+	ticker := time.NewTicker(calendarCheckInterval)
+	defer ticker.Stop()
+	for ; t.ctx.Err() == nil; t.nextTick(ticker) {
+		now := time.Now()
+		if time.Since(t.calendar.FetchedAt()) >= refresh {
+			if err := t.calendar.Refresh(); err != nil {
+				t.logger.Error("Failed to refresh calendar", "error", err)
+			}
+		}
+		if !cfg.AutoStart {
+			continue
+		}
+
+		if recording != nil && !now.Before(recordingEnd) {
+			if t.isRecording(recording) {
+				t.logger.Info("Calendar event ended, stopping recording", "meetingId", recording.Id)
+				if err := t.StopMeeting(recording.Id); err != nil {
+					t.logger.Error("Failed to stop recording of calendar event", "error", err, "meetingId", recording.Id)
+				}
+			}
+			recording = nil
+		}
+
+		event, ok := t.calendar.Current(now)
+		if !ok || !event.HasTag(cfg.Tag) {
+			continue
+		}
+		key := event.UID + "@" + event.Start.Format(time.RFC3339)
+		if started[key] || t.recordingActive() {
+			continue
+		}
+		started[key] = true
+
+		meetingId, err := t.StartRecording(RecordingOptions{
+			Title:        event.Title(cfg.Tag),
+			Participants: event.Attendees,
+			Metadata:     map[string]string{calendarMetadataKey: event.UID},
+		})
+		if err != nil {
+			t.logger.Error("Failed to start recording of calendar event", "error", err, "event", event.Summary)
+			continue
+		}
+		recording, _ = t.GetMeetingStatus(meetingId)
+		recordingEnd = event.End
+		t.logger.Info("Started recording of calendar event", "meetingId", meetingId, "event", event.Summary, "end", event.End)
+	}
+}
@@ -0,0 +1,144 @@
+package transcriber
+
+import (
+	"errors"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/ollama"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// capabilitiesTTL is how long dependency checks are cached
+const capabilitiesTTL = 30 * time.Second
+
+// deferredCheckInterval is how often deferred meetings are retried
+const deferredCheckInterval = time.Minute
+
+// ErrFFmpegMissing is returned when a recording is started without ffmpeg installed
+var ErrFFmpegMissing = errors.New("ffmpeg is required to record meetings but was not found on PATH; install it with `brew install ffmpeg`")
+
+// Dependency is the state of an optional external dependency
+type Dependency struct {
+	Available bool   `json:"available"`
+	Needed    bool   `json:"needed"`            // The current configuration uses the dependency
+	Impact    string `json:"impact,omitempty"`  // What happens while it is missing
+	Install   string `json:"install,omitempty"` // How to install it
+}
+
+// Capabilities describes which features work with the installed dependencies
+type Capabilities struct {
+	Dependencies map[string]Dependency `json:"dependencies"`
+	Recording    bool                  `json:"recording"`     // Meetings can be recorded
+	Transcribe   bool                  `json:"transcription"` // Recordings are transcribed right away instead of deferred
+	Summarize    bool                  `json:"summarization"` // Transcripts are summarized instead of stored transcript-only
+	CheckedAt    time.Time             `json:"checked_at"`
+}
+
+// capabilityCache holds the last dependency check
+type capabilityCache struct {
+	mu   sync.Mutex
+	caps *Capabilities
+}
+
+// Capabilities returns the current dependency state, re-checking at most every capabilitiesTTL.
+// The check asks Ollama over HTTP, so it runs without the lock to not hold up other callers.
+func (t *TranscriberService) Capabilities() Capabilities {
+	t.capabilities.mu.Lock()
+	if caps := t.capabilities.caps; caps != nil && time.Since(caps.CheckedAt) < capabilitiesTTL {
+		t.capabilities.mu.Unlock()
+		return *caps
+	}
+	t.capabilities.mu.Unlock()
+
+	caps := t.detectCapabilities()
+	t.capabilities.mu.Lock()
+	t.capabilities.caps = &caps
+	t.capabilities.mu.Unlock()
+	return caps
+}
+
+func (t *TranscriberService) detectCapabilities() Capabilities {
+	_, ffmpegErr := exec.LookPath("ffmpeg")
+	_, whisperErr := exec.LookPath("whisper")
+	_, ytDlpErr := exec.LookPath(t.config.Download.YtDlp)
+	engine := t.engine.Name()
+
+	// Dev mode records a sample instead of running ffmpeg
+	ffmpeg := Dependency{
+		Available: ffmpegErr == nil,
+		Needed:    !t.config.DevMode,
+		Impact:    "Recording is refused",
+		Install:   "brew install ffmpeg",
+	}
+	whisper := Dependency{
+		Available: whisperErr == nil,
+		Needed:    engine == config.TranscriptionEngineWhisper,
+		Impact:    "Meetings are recorded and their processing is deferred until whisper is installed",
+		Install:   "pip install openai-whisper",
+	}
+	ollamaDep := Dependency{
+		Available: t.config.Ollama.Fake || ollama.Available(),
+		Needed:    true,
+		Impact:    "Meetings are saved transcript-only without a summary",
+		Install:   "Install Ollama from https://ollama.com, run `ollama serve` and pull the configured model",
+	}
+	ytDlp := Dependency{
+		Available: ytDlpErr == nil,
+		Impact:    "Only direct audio and video links and podcast feeds can be transcribed from URLs",
+		Install:   "brew install yt-dlp",
+	}
+
+	return Capabilities{
+		Dependencies: map[string]Dependency{
+			"ffmpeg":  ffmpeg,
+			"whisper": whisper,
+			"ollama":  ollamaDep,
+			"yt-dlp":  ytDlp,
+		},
+		Recording:  !ffmpeg.Needed || ffmpeg.Available,
+		Transcribe: !whisper.Needed || whisper.Available,
+		Summarize:  ollamaDep.Available,
+		CheckedAt:  time.Now(),
+	}
+}
+
+// watchDeferred periodically queues deferred meetings once transcription is possible again
+// and there is enough disk space to transcribe them, until shutdown
+func (t *TranscriberService) watchDeferred() {
+	ticker := time.NewTicker(deferredCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var deferred []*types.Meeting
+		for _, meeting := range t.GetAllMeetings() {
+			if meeting.Status == string(types.MeetingStatusDeferred) {
+				deferred = append(deferred, meeting)
+			}
+		}
+		if len(deferred) == 0 || !t.Capabilities().Transcribe {
+			continue
+		}
+
+		for _, meeting := range deferred {
+			if t.checkTranscriptionSpace(meeting) != nil {
+				continue
+			}
+			t.logger.Info("Transcription available again, processing deferred meeting", "meetingId", meeting.Id)
+			t.setStatus(meeting, types.MeetingStatusProcessing)
+			t.recordEvent(meeting, events.TypeStatusChanged)
+			t.queue.Enqueue(meeting.Id, tagPriority(t.config.Processing.TagPriorities, meeting.Tags), func() {
+				t.processMeeting(t.ctx, meeting)
+			})
+		}
+	}
+}
@@ -0,0 +1,88 @@
+package transcriber
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// captureCheckInterval is how often a running recording is checked for failed tracks
+const captureCheckInterval = 5 * time.Second
+
+// captureWaitTimeout bounds how long processing waits for a stopped capture to be mixed
+const captureWaitTimeout = 10 * time.Minute
+
+// watchCapture adds a warning to the meeting as soon as the ffmpeg of a track exits with an
+// error, e.g. because the device was unplugged or the disk is full. When every track
+// failed nothing is being recorded anymore, so the recording is stopped.
+func (t *TranscriberService) watchCapture(meeting *types.Meeting, recorder audiocapture.Recorder) {
+	ticker := time.NewTicker(captureCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !t.isRecording(meeting) {
+			return
+		}
+		problems := recorder.TrackErrors()
+		if !t.addCaptureWarnings(meeting, problems) {
+			continue
+		}
+		if len(problems) < len(recorder.GetTracks()) {
+			continue
+		}
+
+		t.logger.Info("Stopping recording, all tracks failed", "meetingId", meeting.Id)
+		if err := t.StopMeeting(meeting.Id); err != nil {
+			t.logger.Error("Failed to stop recording after its tracks failed", "error", err, "meetingId", meeting.Id)
+		}
+		return
+	}
+}
+
+// checkCapture waits for the stopped capture to be mixed, confirming ffmpeg finalized the
+// tracks, and surfaces its problems on the meeting. It reports whether the recording can be
+// processed; the meeting is marked as failed when it can't.
+func (t *TranscriberService) checkCapture(meeting *types.Meeting, recorder audiocapture.Recorder) bool {
+	if recorder == nil {
+		return true
+	}
+	if !recorder.Wait(captureWaitTimeout) {
+		t.logger.Info("Capture wasn't mixed in time, processing what was written", "meetingId", meeting.Id)
+		return true
+	}
+
+	problems := recorder.TrackErrors()
+	if err := recorder.Err(); err != nil {
+		// The track problems explain why the capture failed better than the mix does
+		reason := err.Error()
+		if len(problems) > 0 {
+			var causes []string
+			for _, problem := range problems {
+				causes = append(causes, problem.Error())
+			}
+			reason = strings.Join(causes, "; ")
+		}
+		t.failMeeting(meeting, fmt.Sprintf("recording failed: %s", reason), err)
+		return false
+	}
+	t.addCaptureWarnings(meeting, problems)
+	dropMixedTracks(meeting)
+	return true
+}
+
+// addCaptureWarnings adds the track problems the meeting has no warning for yet, and reports
+// whether there were any
+func (t *TranscriberService) addCaptureWarnings(meeting *types.Meeting, problems []error) bool {
+	var warnings []string
+	for _, problem := range problems {
+		warnings = append(warnings, problem.Error())
+	}
+	if !t.addWarning(meeting, events.TypeWarning, warnings...) {
+		return false
+	}
+	t.logger.Info("Capture track failed", "meetingId", meeting.Id, "warnings", warnings)
+	return true
+}
@@ -0,0 +1,176 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/chapters"
+	"github.com/martijnspitter/transcriber/internal/ollama"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrInvalidChapter is returned for chapters a meeting doesn't have
+var ErrInvalidChapter = errors.New("invalid chapter")
+
+// chapterExcerptLength limits how much of every chapter is sent to Ollama for its title
+const chapterExcerptLength = 1500
+
+// listMarker matches the numbering or bullet models put before list items
+var listMarker = regexp.MustCompile(`^\s*(\d+[.)]|[-*•])\s*`)
+
+// chapterTranscript splits long transcripts into chapters at topic shifts, titled by Ollama
+// when enabled and by their keywords otherwise
+func (t *TranscriberService) chapterTranscript(ctx context.Context, meeting *types.Meeting) {
+	cfg := t.config.Vault.Transcript
+	if cfg.ChapterAfter <= 0 || meetingDuration(meeting) < cfg.ChapterAfter*60 {
+		return
+	}
+
+	split := t.splitChapters(ctx, meeting)
+	if len(split) == 0 {
+		t.logger.Info("No topic shifts found in transcript", "meetingId", meeting.Id)
+		return
+	}
+	if cfg.LLMTitles && t.usesOllama(meeting) {
+		t.titleChapters(ctx, meeting, split)
+	}
+	meeting.Chapters = split
+	t.setMeeting(meeting)
+	t.logger.Info("Split transcript into chapters", "meetingId", meeting.Id, "chapters", len(split))
+}
+
+// splitChapters finds the topic shifts of the transcript by the configured segmentation,
+// falling back to keywords when the segments can't be embedded
+func (t *TranscriberService) splitChapters(ctx context.Context, meeting *types.Meeting) []types.Chapter {
+	cfg := t.config.Vault.Transcript
+	minLength := float64(cfg.MinChapter * 60)
+	if cfg.Segmentation == chapters.SegmentationEmbeddings && t.usesOllama(meeting) {
+		vectors, err := t.segmentVectors(ctx, meeting)
+		if err == nil {
+			return chapters.SplitEmbedded(meeting.Segments, vectors, minLength)
+		}
+		t.logger.Error("Failed to embed transcript for chapters, using keywords", "error", err, "meetingId", meeting.Id)
+	}
+	return chapters.Split(meeting.Segments, minLength)
+}
+
+// titleChapters asks Ollama for a short title per chapter, keeping the keyword titles when
+// the answer doesn't have one title per chapter
+func (t *TranscriberService) titleChapters(ctx context.Context, meeting *types.Meeting, split []types.Chapter) {
+	var excerpts strings.Builder
+	next := 0
+	for i, chapter := range split {
+		var text strings.Builder
+		for ; next < len(meeting.Segments) && (i == len(split)-1 || meeting.Segments[next].Start < chapter.End); next++ {
+			text.WriteString(meeting.Segments[next].Text + " ")
+		}
+		excerpt := text.String()
+		if len(excerpt) > chapterExcerptLength {
+			excerpt = excerpt[:chapterExcerptLength]
+		}
+		fmt.Fprintf(&excerpts, "Part %d:\n%s\n\n", i+1, strings.TrimSpace(excerpt))
+	}
+
+	msgs := []ollama.Message{
+		{
+			Role: "system",
+			Content: fmt.Sprintf("You title the parts of a meeting transcript. Answer with exactly %d lines, one title of at most six words per part, in order, without numbering or quotes.",
+				len(split)),
+		},
+		{
+			Role:    "user",
+			Content: excerpts.String(),
+		},
+	}
+	res, err := t.chat(ctx, t.config.Ollama.Model, msgs)
+	if err != nil {
+		t.logger.Error("Failed to title chapters", "error", err, "meetingId", meeting.Id)
+		return
+	}
+
+	var titles []string
+	for _, line := range strings.Split(res.Message.Content, "\n") {
+		line = strings.Trim(listMarker.ReplaceAllString(strings.TrimSpace(line), ""), "\"'`*# ")
+		if line != "" {
+			titles = append(titles, line)
+		}
+	}
+	if len(titles) != len(split) {
+		t.logger.Info("Chapter titles don't match the chapters, keeping keywords", "meetingId", meeting.Id, "titles", len(titles), "chapters", len(split))
+		return
+	}
+	for i := range split {
+		split[i].Title = titles[i]
+	}
+}
+
+// noteTranscript returns the transcript section of the meeting note: the whole note for
+// meetings without a summary, or a section appended to the summary when enabled
+func (t *TranscriberService) noteTranscript(meeting *types.Meeting) string {
+	withSummary := meeting.Summary != ""
+	if withSummary && !t.config.Vault.Transcript.InSummaryNotes {
+		return ""
+	}
+	if len(meeting.Segments) == 0 {
+		if withSummary {
+			return ""
+		}
+		return meeting.Transcript
+	}
+
+	rendered, err := t.transcripts.Render(meeting.Segments, meeting.Chapters, formatLine)
+	if err != nil {
+		t.logger.Error("Failed to render note transcript, using the plain transcript", "error", err, "meetingId", meeting.Id)
+		return FormatTranscript(meeting, meeting.Segments)
+	}
+	if withSummary {
+		return "## Transcript\n\n" + rendered
+	}
+	return transcriptHeader(meeting) + rendered
+}
+
+// ChapterSegments is a chapter of a transcript with the segments it covers, for navigation
+type ChapterSegments struct {
+	types.Chapter
+	Index        int `json:"index"`
+	FirstSegment int `json:"first_segment"` // Index of the first segment of the chapter
+	Segments     int `json:"segments"`      // Number of segments in the chapter
+}
+
+// TranscriptSegments is the transcript of a meeting as segments with its chapters
+type TranscriptSegments struct {
+	Segments []types.Segment   `json:"segments"`
+	Chapters []ChapterSegments `json:"chapters"`
+}
+
+// MeetingSegments returns the transcript segments of a meeting with the chapters pointing
+// into them, or only the segments of one chapter when chapter isn't negative
+func (t *TranscriberService) MeetingSegments(meetingId string, chapter int) (TranscriptSegments, error) {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return TranscriptSegments{}, err
+	}
+	if chapter >= len(meeting.Chapters) {
+		return TranscriptSegments{}, fmt.Errorf("%w: chapter %d out of range, meeting has %d chapters", ErrInvalidChapter, chapter, len(meeting.Chapters))
+	}
+
+	result := TranscriptSegments{Segments: meeting.Segments, Chapters: []ChapterSegments{}}
+	if result.Segments == nil {
+		result.Segments = []types.Segment{}
+	}
+	for i, r := range chapters.Ranges(meeting.Segments, meeting.Chapters) {
+		result.Chapters = append(result.Chapters, ChapterSegments{
+			Chapter:      meeting.Chapters[i],
+			Index:        i,
+			FirstSegment: r[0],
+			Segments:     r[1] - r[0],
+		})
+		if i == chapter {
+			result.Segments = meeting.Segments[r[0]:r[1]]
+		}
+	}
+	return result, nil
+}
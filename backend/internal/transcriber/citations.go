@@ -0,0 +1,86 @@
+package transcriber
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// citationsPrompt asks the summarizer to cite the moments of the transcript it summarizes
+const citationsPrompt = `
+
+Every line of the transcript starts with the moment it was said, e.g. [00:14:32]. End each key point, decision and action item of the summary with the moment it was discussed, copied from the transcript line it is based on, e.g. "Decided to ship on Friday [00:14:32]". Only cite moments that appear in the transcript.`
+
+// momentPattern matches a cited moment, [HH:MM:SS] or [MM:SS], with the space before it
+var momentPattern = regexp.MustCompile(` ?\[(?:(\d{1,2}):)?(\d{1,2}):(\d{2})\]`)
+
+// citationTolerance is how far a cited moment may be from the start of a segment to be
+// taken as a citation of it, in seconds
+const citationTolerance = 5
+
+// citationTranscript renders the segments as lines starting with the second they start at,
+// for the summarizer to cite
+func citationTranscript(segments []types.Segment) string {
+	var transcript strings.Builder
+	for _, segment := range segments {
+		transcript.WriteString("[" + formatCitation(segment.Start) + "] ")
+		if segment.Speaker != "" {
+			transcript.WriteString(segment.Speaker + ": ")
+		}
+		transcript.WriteString(strings.TrimSpace(segment.Text) + "\n")
+	}
+	return transcript.String()
+}
+
+// formatCitation formats an offset in seconds as a cited moment (00:14:32)
+func formatCitation(seconds float64) string {
+	s := int(seconds)
+	return fmt.Sprintf("%02d:%02d:%02d", s/3600, s/60%60, s%60)
+}
+
+// checkCitations verifies the moments cited in a summary against the segments. Moments
+// spoken during a segment are kept, those close to the start of one are moved to it, and
+// the others are removed, so every citation points at something said. It returns the
+// summary and the number of removed citations.
+func checkCitations(summary string, segments []types.Segment) (string, int) {
+	starts := make([]float64, len(segments))
+	for i, segment := range segments {
+		starts[i] = math.Floor(segment.Start)
+	}
+
+	removed := 0
+	summary = momentPattern.ReplaceAllStringFunc(summary, func(match string) string {
+		parts := momentPattern.FindStringSubmatch(match)
+		hours, _ := strconv.Atoi(parts[1])
+		minutes, _ := strconv.Atoi(parts[2])
+		seconds, _ := strconv.Atoi(parts[3])
+		at := float64(hours*3600 + minutes*60 + seconds)
+		space := ""
+		if strings.HasPrefix(match, " ") {
+			space = " "
+		}
+
+		// The last segment starting at or before the moment, and the one after it
+		i := sort.Search(len(starts), func(i int) bool { return starts[i] > at }) - 1
+		for _, candidate := range []int{i, i + 1} {
+			if candidate < 0 || candidate >= len(segments) {
+				continue
+			}
+			segment := segments[candidate]
+			if at >= starts[candidate] && at <= math.Ceil(segment.End) {
+				return space + "[" + formatCitation(at) + "]"
+			}
+			if math.Abs(at-starts[candidate]) <= citationTolerance {
+				return space + "[" + formatCitation(segment.Start) + "]"
+			}
+		}
+		removed++
+		return ""
+	})
+	return summary, removed
+}
@@ -0,0 +1,60 @@
+package transcriber
+
+import (
+	"time"
+
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// killOrphans kills the child processes a crashed previous run left running
+func (t *TranscriberService) killOrphans() {
+	procs.SetFile(t.config.Cleanup.ProcessesFile)
+	killed, err := procs.KillOrphans()
+	if err != nil {
+		t.logger.Error("Failed to kill orphaned processes", "error", err)
+		return
+	}
+	if killed > 0 {
+		t.logger.Info("Killed orphaned processes of a previous run", "processes", killed)
+	}
+}
+
+// watchTempDirs removes stale working directories on startup and every configured interval.
+// On startup no stage is running yet, so every working directory is left over from a crash.
+func (t *TranscriberService) watchTempDirs() {
+	cfg := t.config.Cleanup
+	maxAge := time.Duration(cfg.TempMaxAgeHours) * time.Hour
+	if maxAge <= 0 {
+		maxAge = 24 * time.Hour
+	}
+
+	artifactsAge := time.Duration(0)
+	sweep := func() {
+		removed, err := osoperations.RemoveStaleTempDirectories(maxAge)
+		if err != nil {
+			t.logger.Error("Failed to remove stale temp directories", "error", err)
+		}
+		collected, err := t.artifacts.GC(artifactsAge)
+		if err != nil {
+			t.logger.Error("Failed to remove stale working directories", "error", err)
+		}
+		artifactsAge = maxAge
+		if len(removed)+len(collected) > 0 {
+			t.logger.Info("Removed stale temp directories", "directories", len(removed)+len(collected))
+		}
+		if played := t.removeStalePlayback(maxAge); played > 0 {
+			t.logger.Info("Removed transcoded recordings that weren't played", "files", played)
+		}
+	}
+	if cfg.IntervalMinutes <= 0 {
+		sweep()
+		return
+	}
+
+	ticker := time.NewTicker(time.Duration(cfg.IntervalMinutes) * time.Minute)
+	defer ticker.Stop()
+	for ; t.ctx.Err() == nil; t.nextTick(ticker) {
+		sweep()
+	}
+}
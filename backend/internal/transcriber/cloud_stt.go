@@ -0,0 +1,283 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os/exec"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/logger"
+	"github.com/martijnspitter/transcriber/internal/procs"
+	"github.com/martijnspitter/transcriber/internal/types"
+	"github.com/martijnspitter/transcriber/internal/websocket"
+)
+
+const (
+	deepgramStreamURL   = "wss://api.deepgram.com/v1/listen?encoding=linear16&sample_rate=16000&channels=1&punctuate=true&smart_format=true&interim_results=true&diarize=true"
+	assemblyAIStreamURL = "wss://streaming.assemblyai.com/v3/ws?sample_rate=16000&encoding=pcm_s16le&format_turns=true"
+
+	// streamChunkSize is 250ms of 16kHz mono 16-bit PCM
+	streamChunkSize = 8000
+)
+
+// streamPCM decodes the audio file to 16kHz mono PCM with ffmpeg and hands it to send in
+// chunks. Recordings are only transcribed once finished, so there is no live audio to pace
+// to and the chunks are sent as fast as the connection takes them.
+func streamPCM(ctx context.Context, audioPath string, send func(chunk []byte) error) error {
+	cmd := exec.CommandContext(ctx, "ffmpeg",
+		"-i", audioPath,
+		"-ac", "1",
+		"-ar", "16000",
+		"-f", "s16le",
+		"-loglevel", "error",
+		"-",
+	)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := procs.Start(cmd); err != nil {
+		return fmt.Errorf("failed to start ffmpeg: %w", err)
+	}
+
+	buffer := make([]byte, streamChunkSize)
+	for {
+		n, readErr := io.ReadFull(stdout, buffer)
+		if n > 0 {
+			if ctx.Err() != nil {
+				cmd.Process.Kill()
+				procs.Wait(cmd)
+				return ctx.Err()
+			}
+			if err := send(buffer[:n]); err != nil {
+				cmd.Process.Kill()
+				procs.Wait(cmd)
+				return err
+			}
+		}
+		if readErr == io.EOF || readErr == io.ErrUnexpectedEOF {
+			break
+		}
+		if readErr != nil {
+			cmd.Process.Kill()
+			procs.Wait(cmd)
+			return readErr
+		}
+	}
+
+	if err := procs.Wait(cmd); err != nil {
+		return fmt.Errorf("ffmpeg failed to decode audio: %w", err)
+	}
+	return nil
+}
+
+// streamTranscription streams the recording over the websocket while handle processes
+// incoming messages. finish is sent once all audio has been written. The connection is
+// closed when the context is done or the audio can't be sent, since the service would
+// otherwise keep waiting for it.
+func streamTranscription(ctx context.Context, conn *websocket.Conn, audioPath string, finish []byte, handle func(message []byte) error) error {
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	sendErr := make(chan error, 1)
+	go func() {
+		err := streamPCM(ctx, audioPath, func(chunk []byte) error {
+			return conn.WriteMessage(websocket.OpBinary, chunk)
+		})
+		if err == nil {
+			err = conn.WriteMessage(websocket.OpText, finish)
+		}
+		sendErr <- err
+		if err != nil {
+			conn.Close()
+		}
+	}()
+
+	for {
+		_, message, err := conn.ReadMessage()
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			// The service closes the connection after the final results
+			closed := errors.Is(err, websocket.ErrClosed) || errors.Is(err, io.EOF)
+			// A failed send closed the connection, report why
+			select {
+			case sendErr := <-sendErr:
+				if sendErr != nil || closed {
+					return sendErr
+				}
+			default:
+			}
+			if closed {
+				break
+			}
+			return fmt.Errorf("failed to read transcription results: %w", err)
+		}
+		if err := handle(message); err != nil {
+			return err
+		}
+	}
+
+	return <-sendErr
+}
+
+// joinPartial renders final segments plus the in-flight interim text
+func joinPartial(segments []types.Segment, interim string) string {
+	var parts []string
+	for _, segment := range segments {
+		parts = append(parts, segment.Text)
+	}
+	if interim != "" {
+		parts = append(parts, interim)
+	}
+	return strings.Join(parts, " ")
+}
+
+// deepgramEngine transcribes recordings with Deepgram's live streaming API
+type deepgramEngine struct {
+	apiKey string
+	logger *logger.Logger
+}
+
+func (e *deepgramEngine) Name() string {
+	return config.TranscriptionEngineDeepgram
+}
+
+type deepgramResult struct {
+	Type     string  `json:"type"`
+	Start    float64 `json:"start"`
+	Duration float64 `json:"duration"`
+	IsFinal  bool    `json:"is_final"`
+	Channel  struct {
+		Alternatives []struct {
+			Transcript string `json:"transcript"`
+			Words      []struct {
+				Speaker *int `json:"speaker"`
+			} `json:"words"`
+		} `json:"alternatives"`
+	} `json:"channel"`
+}
+
+func (e *deepgramEngine) Transcribe(ctx context.Context, audioPath string, workDir string, partial func(text string)) ([]types.Segment, error) {
+	e.logger.Info("Streaming recording to Deepgram", "file", audioPath)
+
+	conn, err := websocket.Dial(ctx, deepgramStreamURL, http.Header{"Authorization": {"Token " + e.apiKey}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to Deepgram: %w", err)
+	}
+	defer conn.Close()
+
+	var segments []types.Segment
+	err = streamTranscription(ctx, conn, audioPath, []byte(`{"type":"CloseStream"}`), func(message []byte) error {
+		var result deepgramResult
+		if err := json.Unmarshal(message, &result); err != nil {
+			return fmt.Errorf("invalid Deepgram message: %w", err)
+		}
+		if result.Type != "Results" || len(result.Channel.Alternatives) == 0 {
+			return nil
+		}
+
+		alternative := result.Channel.Alternatives[0]
+		if !result.IsFinal {
+			partial(joinPartial(segments, alternative.Transcript))
+			return nil
+		}
+		if alternative.Transcript == "" {
+			return nil
+		}
+
+		segment := types.Segment{
+			Start: result.Start,
+			End:   result.Start + result.Duration,
+			Text:  alternative.Transcript,
+		}
+		if len(alternative.Words) > 0 && alternative.Words[0].Speaker != nil {
+			segment.Speaker = fmt.Sprintf("Speaker %d", *alternative.Words[0].Speaker)
+		}
+		segments = append(segments, segment)
+		partial(joinPartial(segments, ""))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.Info("Deepgram transcription completed", "file", audioPath, "segments", len(segments))
+	return segments, nil
+}
+
+// assemblyAIEngine transcribes recordings with AssemblyAI's universal streaming API
+type assemblyAIEngine struct {
+	apiKey string
+	logger *logger.Logger
+}
+
+func (e *assemblyAIEngine) Name() string {
+	return config.TranscriptionEngineAssemblyAI
+}
+
+type assemblyAITurn struct {
+	Type            string `json:"type"`
+	Transcript      string `json:"transcript"`
+	EndOfTurn       bool   `json:"end_of_turn"`
+	TurnIsFormatted bool   `json:"turn_is_formatted"`
+	Words           []struct {
+		Start int64 `json:"start"` // Milliseconds
+		End   int64 `json:"end"`
+	} `json:"words"`
+	Error string `json:"error"`
+}
+
+func (e *assemblyAIEngine) Transcribe(ctx context.Context, audioPath string, workDir string, partial func(text string)) ([]types.Segment, error) {
+	e.logger.Info("Streaming recording to AssemblyAI", "file", audioPath)
+
+	conn, err := websocket.Dial(ctx, assemblyAIStreamURL, http.Header{"Authorization": {e.apiKey}})
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to AssemblyAI: %w", err)
+	}
+	defer conn.Close()
+
+	var segments []types.Segment
+	err = streamTranscription(ctx, conn, audioPath, []byte(`{"type":"Terminate"}`), func(message []byte) error {
+		var turn assemblyAITurn
+		if err := json.Unmarshal(message, &turn); err != nil {
+			return fmt.Errorf("invalid AssemblyAI message: %w", err)
+		}
+		if turn.Error != "" {
+			return fmt.Errorf("AssemblyAI error: %s", turn.Error)
+		}
+		if turn.Type != "Turn" {
+			return nil
+		}
+
+		// Only the formatted end-of-turn message is final, everything else is interim
+		if !turn.EndOfTurn || !turn.TurnIsFormatted {
+			partial(joinPartial(segments, turn.Transcript))
+			return nil
+		}
+		if turn.Transcript == "" {
+			return nil
+		}
+
+		segment := types.Segment{Text: turn.Transcript}
+		if len(turn.Words) > 0 {
+			segment.Start = float64(turn.Words[0].Start) / 1000
+			segment.End = float64(turn.Words[len(turn.Words)-1].End) / 1000
+		}
+		segments = append(segments, segment)
+		partial(joinPartial(segments, ""))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.Info("AssemblyAI transcription completed", "file", audioPath, "segments", len(segments))
+	return segments, nil
+}
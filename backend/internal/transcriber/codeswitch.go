@@ -0,0 +1,163 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/procs"
+	"github.com/martijnspitter/transcriber/internal/punctuation"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// codeSwitchMargin is how much better, in average log probability per token, a segment must
+// be in another language before it replaces the segment of the detected language
+const codeSwitchMargin = 0.05
+
+// whisperResult is the JSON output of the whisper CLI
+type whisperResult struct {
+	Language string           `json:"language"`
+	Segments []whisperSegment `json:"segments"`
+}
+
+type whisperSegment struct {
+	Start      float64 `json:"start"`
+	End        float64 `json:"end"`
+	Text       string  `json:"text"`
+	AvgLogprob float64 `json:"avg_logprob"`
+}
+
+// TranscribeCodeSwitched transcribes a meeting that mixes languages. Whisper first runs without
+// a language flag, then once per other candidate language. Every segment of the first pass
+// is replaced by the overlapping segments of the language the model is most confident in.
+func (s *Transcriber) TranscribeCodeSwitched(ctx context.Context, languages []string) ([]types.Segment, error) {
+	s.logger.Info("Starting code-switched transcription using OpenAI Whisper", "file", s.audioFilePath, "languages", languages)
+
+	detected, err := s.runWhisperJSON(ctx, "")
+	if err != nil {
+		return nil, err
+	}
+	s.logger.Info("Whisper detected language", "language", detected.Language, "segments", len(detected.Segments))
+
+	type pass struct {
+		language string
+		segments [][]whisperSegment // Segments of the pass grouped by the detected segment they fall in
+	}
+	var passes []pass
+	for _, language := range languages {
+		if language == detected.Language {
+			continue
+		}
+		result, err := s.runWhisperJSON(ctx, language)
+		if err != nil {
+			// The detected pass is still a usable transcript
+			s.logger.Error("Whisper pass failed, skipping language", "error", err, "language", language)
+			continue
+		}
+		passes = append(passes, pass{language: language, segments: groupByMidpoint(result.Segments, detected.Segments)})
+	}
+
+	segments := make([]types.Segment, 0, len(detected.Segments))
+	switched := 0
+	for i, base := range detected.Segments {
+		segment := types.Segment{
+			Start:    base.Start,
+			End:      base.End,
+			Text:     strings.TrimSpace(base.Text),
+			Language: detected.Language,
+		}
+		bestScore := base.AvgLogprob
+		for _, p := range passes {
+			candidates := p.segments[i]
+			if len(candidates) == 0 {
+				continue
+			}
+			if score := weightedLogprob(candidates); score > bestScore+codeSwitchMargin {
+				segment.Text = joinSegmentText(candidates)
+				segment.Language = p.language
+				bestScore = score
+			}
+		}
+		if segment.Language != detected.Language {
+			switched++
+		}
+		segments = append(segments, segment)
+	}
+	s.logger.Info("Selected segment languages", "segments", len(segments), "switched", switched)
+
+	// Segments are fed as one stream so sentences spanning segments keep their casing
+	punctuator := punctuation.NewPunctuator()
+	for i := range segments {
+		segments[i].Text = punctuator.Feed(segments[i].Text, i == len(segments)-1)
+	}
+	return segments, nil
+}
+
+// runWhisperJSON runs whisper with JSON output, forcing the language when one is given
+func (s *Transcriber) runWhisperJSON(ctx context.Context, language string) (*whisperResult, error) {
+	tempDir, err := s.outputDir()
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, "whisper", s.whisperArgs(tempDir, "json", language)...)
+	s.logger.Info("Running Whisper command", "command", cmd.String())
+	if output, err := procs.CombinedOutput(cmd); err != nil {
+		return nil, fmt.Errorf("whisper transcription failed: %w\nOutput: %s", err, string(output))
+	}
+
+	outputFile := filepath.Join(tempDir, osoperations.GetFileNameWithoutExtension(s.audioFilePath)+".json")
+	data, err := os.ReadFile(outputFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read whisper output: %w", err)
+	}
+	var result whisperResult
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, fmt.Errorf("failed to parse whisper output: %w", err)
+	}
+	return &result, nil
+}
+
+// groupByMidpoint assigns every segment to the base segment its midpoint falls in, so each
+// segment is used at most once. Segments between base segments are dropped.
+func groupByMidpoint(segments []whisperSegment, base []whisperSegment) [][]whisperSegment {
+	groups := make([][]whisperSegment, len(base))
+	for _, segment := range segments {
+		mid := (segment.Start + segment.End) / 2
+		for i, b := range base {
+			if mid >= b.Start && mid < b.End {
+				groups[i] = append(groups[i], segment)
+				break
+			}
+		}
+	}
+	return groups
+}
+
+// weightedLogprob returns the average log probability of segments weighted by their duration
+func weightedLogprob(segments []whisperSegment) float64 {
+	var total, weight float64
+	for _, segment := range segments {
+		duration := segment.End - segment.Start
+		total += segment.AvgLogprob * duration
+		weight += duration
+	}
+	if weight == 0 {
+		return segments[0].AvgLogprob
+	}
+	return total / weight
+}
+
+// joinSegmentText joins the trimmed text of segments with spaces
+func joinSegmentText(segments []whisperSegment) string {
+	texts := make([]string, 0, len(segments))
+	for _, segment := range segments {
+		texts = append(texts, strings.TrimSpace(segment.Text))
+	}
+	return strings.Join(texts, " ")
+}
@@ -0,0 +1,105 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// abTestEnabled reports whether the A/B testing harness should run
+func (t *TranscriberService) abTestEnabled() bool {
+	return t.config.ABTest.Enabled && len(t.config.ABTest.Variants) >= 2
+}
+
+// summarizeVariants runs every configured summarizer variant on the meeting transcript.
+// A failing variant is recorded with its error so the others can still be compared.
+func (t *TranscriberService) summarizeVariants(ctx context.Context, meeting *types.Meeting) []types.SummaryVariant {
+	variants := make([]types.SummaryVariant, 0, len(t.config.ABTest.Variants))
+
+	for _, variant := range t.config.ABTest.Variants {
+		systemPrompt := variant.SystemPrompt
+		if systemPrompt == "" {
+			systemPrompt = t.systemPrompt(meeting)
+		}
+
+		t.logger.Info("Summarizing meeting with variant", "meetingId", meeting.Id, "variant", variant.Name, "model", variant.Model)
+		result := types.SummaryVariant{
+			Name:  variant.Name,
+			Model: variant.Model,
+		}
+
+		summary, err := t.summarizeWith(ctx, meeting, variant.Model, systemPrompt)
+		if err != nil {
+			t.logger.Error("Summarizer variant failed", "error", err, "meetingId", meeting.Id, "variant", variant.Name)
+			result.Error = err.Error()
+		}
+		result.Summary = summary
+
+		variants = append(variants, result)
+	}
+
+	return variants
+}
+
+// summarizeForPipeline produces the meeting summary. When A/B testing is enabled all
+// variants are stored on the meeting and the first successful one becomes the summary.
+func (t *TranscriberService) summarizeForPipeline(ctx context.Context, meeting *types.Meeting) (string, error) {
+	if !t.abTestEnabled() {
+		return t.Summarize(ctx, meeting)
+	}
+
+	meeting.SummaryVariants = t.summarizeVariants(ctx, meeting)
+	for _, variant := range meeting.SummaryVariants {
+		if variant.Error == "" {
+			return variant.Summary, nil
+		}
+	}
+
+	return "", fmt.Errorf("all summarizer variants failed")
+}
+
+// RecordVariantFeedback marks a summary variant of a meeting as preferred
+func (t *TranscriberService) RecordVariantFeedback(meetingId string, variantName string) error {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	found := false
+	for i := range meeting.SummaryVariants {
+		preferred := meeting.SummaryVariants[i].Name == variantName
+		meeting.SummaryVariants[i].Preferred = preferred
+		found = found || preferred
+	}
+	t.mu.Unlock()
+	if !found {
+		return fmt.Errorf("variant %q not found for meeting %s", variantName, meetingId)
+	}
+
+	t.recordEvent(meeting, events.TypeFeedbackRecorded)
+	t.logger.Info("Recorded summarizer feedback", "meetingId", meetingId, "variant", variantName)
+	return nil
+}
+
+// VariantStats counts how often each variant was preferred across all meetings
+func (t *TranscriberService) VariantStats() map[string]int {
+	stats := make(map[string]int)
+	for _, variant := range t.config.ABTest.Variants {
+		stats[variant.Name] = 0
+	}
+
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	for _, meeting := range t.meetings {
+		for _, variant := range meeting.SummaryVariants {
+			if variant.Preferred {
+				stats[variant.Name]++
+			}
+		}
+	}
+
+	return stats
+}
@@ -0,0 +1,35 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/crm"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// crmTimeout is how long attaching a summary to the CRM contacts of a meeting may take
+const crmTimeout = 2 * time.Minute
+
+// attachToCRM logs the meeting summary on the CRM contact of every participant email
+func (t *TranscriberService) attachToCRM(ctx context.Context, meeting *types.Meeting) {
+	if t.crm == nil {
+		return
+	}
+	ctx, cancel := context.WithTimeout(ctx, crmTimeout)
+	defer cancel()
+
+	emails := crm.ContactEmails(meeting, t.config.CRM.EmailMetadataKey)
+	for _, email := range emails {
+		err := t.crm.AttachSummary(ctx, email, meeting)
+		switch {
+		case errors.Is(err, crm.ErrContactNotFound):
+			t.logger.Debug("No CRM contact for participant", "meetingId", meeting.Id, "crm", t.crm.Name(), "email", email)
+		case err != nil:
+			t.logger.Error("Failed to attach summary to CRM contact", "error", err, "meetingId", meeting.Id, "crm", t.crm.Name(), "email", email)
+		default:
+			t.logger.Info("Attached summary to CRM contact", "meetingId", meeting.Id, "crm", t.crm.Name(), "email", email)
+		}
+	}
+}
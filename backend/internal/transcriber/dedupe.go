@@ -0,0 +1,95 @@
+package transcriber
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+const (
+	// dedupeTimeTolerance is how far apart (in seconds) two segments may be and still overlap,
+	// covering the offset between the separately started mic and system captures
+	dedupeTimeTolerance = 2.0
+
+	// dedupeSimilarity is the minimum word overlap for two segments to count as duplicates
+	dedupeSimilarity = 0.6
+)
+
+// mergeTrackSegments merges the per-track transcripts of the mic and system audio. Mic
+// segments that repeat an overlapping system segment are the speakers re-captured by the
+// microphone and are dropped; the system track holds the clean version of that speech.
+func mergeTrackSegments(micSegments, systemSegments []types.Segment) ([]types.Segment, int) {
+	merged := make([]types.Segment, 0, len(micSegments)+len(systemSegments))
+	for _, segment := range systemSegments {
+		segment.Source = types.TrackSourceSystem
+		merged = append(merged, segment)
+	}
+
+	dropped := 0
+	for _, segment := range micSegments {
+		if isDuplicate(segment, systemSegments) {
+			dropped++
+			continue
+		}
+		segment.Source = types.TrackSourceMic
+		merged = append(merged, segment)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return merged[i].Start < merged[j].Start
+	})
+
+	return merged, dropped
+}
+
+// isDuplicate reports whether the segment repeats any time-overlapping candidate
+func isDuplicate(segment types.Segment, candidates []types.Segment) bool {
+	words := normalizedWords(segment.Text)
+	if len(words) == 0 {
+		return false
+	}
+
+	for _, candidate := range candidates {
+		if candidate.End+dedupeTimeTolerance < segment.Start || candidate.Start-dedupeTimeTolerance > segment.End {
+			continue
+		}
+		if wordSimilarity(words, normalizedWords(candidate.Text)) >= dedupeSimilarity {
+			return true
+		}
+	}
+	return false
+}
+
+// normalizedWords lowercases the text and splits it into words without punctuation
+func normalizedWords(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+}
+
+// wordSimilarity returns the share of words in a that also occur in b. Using the
+// smaller side as the base lets a partial echo match a longer clean segment.
+func wordSimilarity(a, b []string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+
+	counts := make(map[string]int, len(b))
+	for _, word := range b {
+		counts[word]++
+	}
+
+	shared := 0
+	for _, word := range a {
+		if counts[word] > 0 {
+			counts[word]--
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a))
+}
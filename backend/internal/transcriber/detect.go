@@ -0,0 +1,153 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/ollama"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Presets picked by the participant heuristics
+const (
+	presetOneOnOne = "one-on-one"
+	presetAllHands = "all-hands"
+)
+
+// detectTypePromptLength limits how much of the transcript is sent to the classifier
+const detectTypePromptLength = 4000
+
+// detectMeetingType classifies a meeting started without a type and applies the matching
+// preset's tags, and its template and stages when none were chosen. The title keywords of
+// the presets are tried first, then the participant count and finally Ollama when enabled.
+func (t *TranscriberService) detectMeetingType(ctx context.Context, meeting *types.Meeting) {
+	cfg := t.config.Processing.DetectType
+	if !cfg.Enabled || meeting.Type != "" {
+		return
+	}
+
+	presetName, reason := t.classifyByTitle(meeting.Title)
+	if presetName == "" {
+		presetName, reason = t.classifyByParticipants(meeting)
+	}
+	if presetName == "" && cfg.LLM && t.usesOllama(meeting) {
+		presetName, reason = t.classifyWithLLM(ctx, meeting)
+	}
+	if presetName == "" {
+		t.logger.Info("Meeting type not detected", "meetingId", meeting.Id)
+		return
+	}
+
+	preset := t.config.Presets[presetName]
+	meeting.Type = presetName
+	meeting.TypeDetected = true
+	meeting.Tags = mergeUnique(meeting.Tags, preset.Tags)
+	if meeting.Template == "" {
+		meeting.Template = preset.Template
+	}
+	if len(meeting.Stages) == 0 {
+		meeting.Stages = preset.Stages
+	}
+	t.setMeeting(meeting)
+	t.logger.Info("Detected meeting type", "meetingId", meeting.Id, "type", presetName, "reason", reason)
+}
+
+// presetNames returns the configured preset names in a stable order
+func (t *TranscriberService) presetNames() []string {
+	names := make([]string, 0, len(t.config.Presets))
+	for name := range t.config.Presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// classifyByTitle returns the preset with a keyword in the title
+func (t *TranscriberService) classifyByTitle(title string) (string, string) {
+	title = strings.ToLower(title)
+	for _, name := range t.presetNames() {
+		for _, keyword := range t.config.Presets[name].Keywords {
+			if containsWord(title, strings.ToLower(keyword)) {
+				return name, "title"
+			}
+		}
+	}
+	return "", ""
+}
+
+// classifyByParticipants recognizes short two-person meetings as 1:1s and large meetings
+// as all-hands, when those presets exist
+func (t *TranscriberService) classifyByParticipants(meeting *types.Meeting) (string, string) {
+	cfg := t.config.Processing.DetectType
+	participants := len(meeting.Participants)
+	if speakers := countSpeakers(meeting.Segments); speakers > participants {
+		participants = speakers
+	}
+
+	if _, ok := t.config.Presets[presetAllHands]; ok && cfg.AllHandsFrom > 0 && participants >= cfg.AllHandsFrom {
+		return presetAllHands, "participants"
+	}
+	if _, ok := t.config.Presets[presetOneOnOne]; ok && participants == 2 {
+		minutes := meetingDuration(meeting) / 60
+		if cfg.OneOnOneMaxDuration == 0 || minutes <= cfg.OneOnOneMaxDuration {
+			return presetOneOnOne, "participants"
+		}
+	}
+	return "", ""
+}
+
+// classifyWithLLM asks Ollama which preset fits the transcript
+func (t *TranscriberService) classifyWithLLM(ctx context.Context, meeting *types.Meeting) (string, string) {
+	names := t.presetNames()
+	transcript := meeting.Transcript
+	if len(transcript) > detectTypePromptLength {
+		transcript = transcript[:detectTypePromptLength]
+	}
+
+	msgs := []ollama.Message{
+		{
+			Role: "system",
+			Content: fmt.Sprintf("You classify meeting transcripts. Answer with exactly one of these meeting types and nothing else: %s. Answer \"none\" when no type fits.",
+				strings.Join(names, ", ")),
+		},
+		{
+			Role:    "user",
+			Content: fmt.Sprintf("Title: %s\nParticipants: %s\n\n%s", meeting.Title, strings.Join(meeting.Participants, ", "), transcript),
+		},
+	}
+	res, err := t.chat(ctx, t.config.Ollama.Model, msgs)
+	if err != nil {
+		t.logger.Error("Failed to classify meeting type", "error", err, "meetingId", meeting.Id)
+		return "", ""
+	}
+
+	answer := strings.Trim(strings.ToLower(strings.TrimSpace(res.Message.Content)), ".\"'`")
+	for _, name := range names {
+		if answer == strings.ToLower(name) {
+			return name, "llm"
+		}
+	}
+	return "", ""
+}
+
+// countSpeakers returns the number of distinct speakers in the segments
+func countSpeakers(segments []types.Segment) int {
+	speakers := make(map[string]bool)
+	for _, segment := range segments {
+		if segment.Speaker != "" {
+			speakers[segment.Speaker] = true
+		}
+	}
+	return len(speakers)
+}
+
+// meetingDuration returns the meeting length in seconds, estimated from the transcript
+// segments when the recording length is unknown
+func meetingDuration(meeting *types.Meeting) int {
+	if meeting.Duration > 0 || len(meeting.Segments) == 0 {
+		return meeting.Duration
+	}
+	return int(meeting.Segments[len(meeting.Segments)-1].End)
+}
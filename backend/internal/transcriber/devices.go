@@ -0,0 +1,148 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/devices"
+)
+
+// selectDevices returns the devices to record with: the requested ones, falling back to the
+// devices last used for the meeting's series and then the configured or default devices,
+// matched by name against the available devices. The choice is remembered for the next
+// meeting of the series.
+func (t *TranscriberService) selectDevices(opts RecordingOptions, appTap *audiocapture.AppTap) (audiocapture.CaptureDevices, error) {
+	key := devices.SeriesKey(opts.Series, opts.Title)
+	selected := t.resolveDevices(key, opts.MicDevice, opts.SystemDevice)
+	selected.SystemApp = appTap
+	if opts.MicGain > 0 {
+		selected.MicGain = opts.MicGain
+	}
+	if opts.SystemGain > 0 {
+		selected.SystemGain = opts.SystemGain
+	}
+	selected.Extra = slices.Clone(opts.ExtraDevices)
+	if err := t.matchDevices(&selected); err != nil {
+		return selected, err
+	}
+
+	if err := t.devices.Remember(key, selected.Mic, selected.System); err != nil {
+		t.logger.Error("Failed to remember devices", "error", err, "series", key)
+	}
+	return selected, nil
+}
+
+// matchDevices resolves the devices given by name against the devices ffmpeg lists, so a
+// name with different case or a typo still selects the device. The devices are used as
+// given when they can't be listed.
+func (t *TranscriberService) matchDevices(selected *audiocapture.CaptureDevices) error {
+	available, err := t.capturer.ListAudioDevices()
+	if err != nil || len(available) == 0 {
+		t.logger.Debug("Can't list audio devices, using the devices as given", "error", err)
+		return nil
+	}
+
+	names := []*string{&selected.Mic}
+	if selected.SystemApp == nil {
+		names = append(names, &selected.System)
+	}
+	for i := range selected.Extra {
+		names = append(names, &selected.Extra[i].Device)
+	}
+	for _, name := range names {
+		device, err := audiocapture.ResolveDevice(*name, available)
+		if err != nil {
+			return err
+		}
+		if device != *name {
+			t.logger.Info("Matched audio device by name", "requested", *name, "device", device)
+		}
+		*name = device
+	}
+	return nil
+}
+
+// ErrInvalidDevices is returned when a recording is started with devices that can't be recorded
+var ErrInvalidDevices = errors.New("invalid capture devices")
+
+// maxTrackGain bounds the gain a track is mixed with, 10 amplifies by 20 dB
+const maxTrackGain = 10
+
+// validateDevices checks the extra devices and the gains of a recording
+func validateDevices(opts RecordingOptions) error {
+	if len(opts.ExtraDevices) > audiocapture.MaxExtraDevices {
+		return fmt.Errorf("%w: at most %d extra devices can be recorded", ErrInvalidDevices, audiocapture.MaxExtraDevices)
+	}
+	if err := validateGain("mic", opts.MicGain); err != nil {
+		return err
+	}
+	if err := validateGain("system", opts.SystemGain); err != nil {
+		return err
+	}
+	if opts.InputVolume < 0 || opts.InputVolume > maxTrackGain {
+		return fmt.Errorf("%w: input volume must be between 0 and %d", ErrInvalidDevices, maxTrackGain)
+	}
+	for i, extra := range opts.ExtraDevices {
+		if extra.Device == "" {
+			return fmt.Errorf("%w: extra device %d has no device", ErrInvalidDevices, i+1)
+		}
+		if err := validateGain(extra.Device, extra.Gain); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateGain checks the gain of the track of a device, 0 leaves the track as recorded
+func validateGain(device string, gain float64) error {
+	if gain < 0 || gain > maxTrackGain {
+		return fmt.Errorf("%w: gain of %s must be between 0 and %d", ErrInvalidDevices, device, maxTrackGain)
+	}
+	return nil
+}
+
+// resolveDevices fills in the devices that weren't requested from the preference of the
+// series, then the configured devices and then the defaults. The tracks get the configured
+// gains.
+func (t *TranscriberService) resolveDevices(key string, mic string, system string) audiocapture.CaptureDevices {
+	selected := audiocapture.CaptureDevices{
+		Mic:        mic,
+		System:     system,
+		MicGain:    t.config.Audio.Filters.MicGain,
+		SystemGain: t.config.Audio.Filters.SystemGain,
+	}
+	if preference, ok := t.devices.Get(key); ok && (selected.Mic == "" || selected.System == "") {
+		if selected.Mic == "" {
+			selected.Mic = preference.Mic
+		}
+		if selected.System == "" {
+			selected.System = preference.System
+		}
+		t.logger.Info("Using devices from previous meeting of the series", "series", key, "mic", selected.Mic, "system", selected.System)
+	}
+	if selected.Mic == "" {
+		selected.Mic = t.config.Audio.MicDevice
+	}
+	if selected.System == "" {
+		selected.System = t.config.Audio.SystemDevice
+	}
+	if selected.Mic == "" {
+		selected.Mic = audiocapture.DefaultMicDevice
+	}
+	if selected.System == "" {
+		selected.System = audiocapture.DefaultSystemDevice
+	}
+	return selected
+}
+
+// DevicePreferences returns the store of devices used per meeting series
+func (t *TranscriberService) DevicePreferences() *devices.Store {
+	return t.devices
+}
+
+// ListAudioDevices lists the audio devices recordings can capture from
+func (t *TranscriberService) ListAudioDevices() ([]string, error) {
+	return t.capturer.ListAudioDevices()
+}
@@ -0,0 +1,224 @@
+package transcriber
+
+import (
+	"archive/zip"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/ollama"
+	"github.com/martijnspitter/transcriber/internal/types"
+	"github.com/martijnspitter/transcriber/internal/update"
+)
+
+// diagnosticMeetings is the number of recent meetings described in a diagnostic bundle
+const diagnosticMeetings = 20
+
+// versionTimeout is how long a dependency gets to report its version
+const versionTimeout = 5 * time.Second
+
+// diagnosticMeeting is the state of a meeting in a diagnostic bundle. The title,
+// participants and content are left out, since bundles are attached to bug reports.
+type diagnosticMeeting struct {
+	Id            string              `json:"id"`
+	Status        string              `json:"status"`
+	Type          string              `json:"type,omitempty"`
+	Template      string              `json:"template,omitempty"`
+	CreatedAt     time.Time           `json:"created_at"`
+	UpdatedAt     time.Time           `json:"updated_at"`
+	Duration      int                 `json:"duration"`
+	ImportedFrom  string              `json:"imported_from,omitempty"`
+	AudioDevices  []types.AudioDevice `json:"audio_devices"`
+	Tracks        []types.AudioTrack  `json:"tracks,omitempty"`
+	AudioFilters  []types.AudioFilter `json:"audio_filters,omitempty"`
+	StereoSplit   bool                `json:"stereo_split,omitempty"`
+	Segments      int                 `json:"segments"`
+	Summarized    bool                `json:"summarized"`
+	Error         string              `json:"error,omitempty"`
+	Warnings      []string            `json:"warnings,omitempty"`
+	Version       int64               `json:"version"`
+	Events        []diagnosticEvent   `json:"events"` // Without their changes
+	RecordingSize int64               `json:"recording_size,omitempty"`
+}
+
+// diagnosticEvent is an event of a meeting in a diagnostic bundle
+type diagnosticEvent struct {
+	Seq  int64     `json:"seq"`
+	Type string    `json:"type"`
+	Time time.Time `json:"time"`
+}
+
+// WriteDiagnostics writes a zip for bug reports to w: the versions of the server and its
+// dependencies, the audio devices, the config without its secrets, the state of the recent
+// meetings and the last log lines. The contents are gathered in parallel, since probing the
+// dependencies and devices can take seconds each.
+func (t *TranscriberService) WriteDiagnostics(ctx context.Context, w io.Writer) error {
+	archive := zip.NewWriter(w)
+
+	files := []struct {
+		name    string
+		content func() (any, error)
+	}{
+		{"versions.json", func() (any, error) { return t.versions(ctx), nil }},
+		{"capabilities.json", func() (any, error) { return t.Capabilities(), nil }},
+		{"devices.json", func() (any, error) { return t.diagnosticDevices(), nil }},
+		{"config.json", func() (any, error) { return t.config.Redacted(), nil }},
+		{"meetings.json", func() (any, error) { return t.diagnosticMeetings(), nil }},
+	}
+	contents := make([]any, len(files))
+	errs := make([]error, len(files))
+	var wg sync.WaitGroup
+	for i, file := range files {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			contents[i], errs[i] = file.content()
+		}()
+	}
+	wg.Wait()
+
+	for i, file := range files {
+		if errs[i] != nil {
+			return errs[i]
+		}
+		data, err := json.MarshalIndent(contents[i], "", "  ")
+		if err != nil {
+			return fmt.Errorf("failed to encode %s: %w", file.name, err)
+		}
+		if err := addContent(archive, file.name, string(data)); err != nil {
+			return err
+		}
+	}
+	if err := addContent(archive, "logs.jsonl", string(t.logger.Recent())); err != nil {
+		return err
+	}
+	return archive.Close()
+}
+
+// versions reports the version of the server, the platform and each dependency, or why it
+// couldn't be determined
+func (t *TranscriberService) versions(ctx context.Context) map[string]string {
+	versions := map[string]string{
+		"transcriber": update.Version,
+		"go":          runtime.Version(),
+		"platform":    runtime.GOOS + "/" + runtime.GOARCH,
+		"engine":      t.engine.Name(),
+	}
+	probes := map[string]func() string{
+		"ffmpeg":  func() string { return commandVersion(ctx, "ffmpeg", "-version") },
+		"yt-dlp":  func() string { return commandVersion(ctx, t.config.Download.YtDlp, "--version") },
+		"whisper": func() string { return whisperVersion(ctx) },
+		"ollama": func() string {
+			version, err := ollama.Version()
+			if err != nil {
+				return "unavailable: " + err.Error()
+			}
+			return version
+		},
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for name, probe := range probes {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			version := probe()
+			mu.Lock()
+			versions[name] = version
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return versions
+}
+
+// commandVersion returns the first line a command prints with its version flag
+func commandVersion(ctx context.Context, name string, args ...string) string {
+	ctx, cancel := context.WithTimeout(ctx, versionTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, name, args...).Output()
+	if err != nil {
+		return "unavailable: " + err.Error()
+	}
+	line, _, _ := strings.Cut(strings.TrimSpace(string(out)), "\n")
+	return line
+}
+
+// whisperVersion returns the version of the openai-whisper package the whisper command runs
+// with, asking the Python interpreter of its script since the command has no version flag
+func whisperVersion(ctx context.Context) string {
+	path, err := exec.LookPath("whisper")
+	if err != nil {
+		return "unavailable: " + err.Error()
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return "unavailable: " + err.Error()
+	}
+	shebang, _ := bufio.NewReader(file).ReadString('\n')
+	file.Close()
+	interpreter := strings.Fields(strings.TrimPrefix(strings.TrimSpace(shebang), "#!"))
+	if !strings.HasPrefix(shebang, "#!") || len(interpreter) == 0 {
+		return "installed at " + path + ", version unknown"
+	}
+	args := append(interpreter[1:], "-c", "import importlib.metadata; print(importlib.metadata.version('openai-whisper'))")
+	return commandVersion(ctx, interpreter[0], args...)
+}
+
+// diagnosticDevices lists the audio devices, or the error listing them
+func (t *TranscriberService) diagnosticDevices() map[string]any {
+	devices, err := t.ListAudioDevices()
+	if err != nil {
+		return map[string]any{"error": err.Error()}
+	}
+	return map[string]any{"devices": devices}
+}
+
+// diagnosticMeetings describes the most recent meetings, including archived ones
+func (t *TranscriberService) diagnosticMeetings() []diagnosticMeeting {
+	meetings, _ := t.ListMeetings(ListOptions{Filter: MeetingFilter{Archived: true}, Limit: diagnosticMeetings})
+	described := make([]diagnosticMeeting, 0, len(meetings))
+	for _, meeting := range meetings {
+		described = append(described, t.diagnosticMeeting(meeting))
+	}
+	return described
+}
+
+func (t *TranscriberService) diagnosticMeeting(meeting *types.Meeting) diagnosticMeeting {
+	described := diagnosticMeeting{
+		Id:           meeting.Id,
+		Status:       meeting.Status,
+		Type:         meeting.Type,
+		Template:     meeting.Template,
+		CreatedAt:    meeting.CreatedAt,
+		UpdatedAt:    meeting.UpdatedAt,
+		Duration:     meeting.Duration,
+		ImportedFrom: meeting.ImportedFrom,
+		AudioDevices: meeting.Audio_devices,
+		Tracks:       meeting.Tracks,
+		AudioFilters: meeting.AudioFilters,
+		StereoSplit:  meeting.StereoSplit,
+		Segments:     len(meeting.Segments),
+		Summarized:   meeting.Summary != "",
+		Error:        meeting.Error,
+		Warnings:     meeting.Warnings,
+		Version:      meeting.Version,
+		Events:       []diagnosticEvent{},
+	}
+	for _, event := range t.events.Events(meeting.Id, 0, 0) {
+		described.Events = append(described.Events, diagnosticEvent{Seq: event.Seq, Type: event.Type, Time: event.Time})
+	}
+	if info, err := os.Stat(meeting.Transcript_path); err == nil {
+		described.RecordingSize = info.Size()
+	}
+	return described
+}
@@ -0,0 +1,103 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/config"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrInsufficientDiskSpace is returned when a recording is started with less free space
+// than storage.min_free_mb
+var ErrInsufficientDiskSpace = errors.New("not enough free disk space")
+
+// whisperModelSizes are the download sizes of the whisper models in MB
+var whisperModelSizes = map[string]uint64{
+	"tiny": 75, "tiny.en": 75, "base": 142, "base.en": 142, "small": 466, "small.en": 466,
+	"medium": 1500, "medium.en": 1500, "large": 2900, "turbo": 1500,
+}
+
+// checkRecordingSpace refuses a recording when the recordings directory has less free space
+// than the minimum, so ffmpeg doesn't write truncated WAVs. It returns a warning when a
+// recording of the expected length won't fit.
+func (t *TranscriberService) checkRecordingSpace() (string, error) {
+	cfg := t.config.Storage
+	free, err := osoperations.FreeSpace(t.recordDir)
+	if err != nil {
+		t.logger.Error("Failed to check free disk space", "error", err, "dir", t.recordDir)
+		return "", nil
+	}
+	if minFree := megabytes(cfg.MinFreeMB); free < minFree {
+		return "", fmt.Errorf("%w: %s free in %s, recording needs at least %s", ErrInsufficientDiskSpace, formatBytes(free), t.recordDir, formatBytes(minFree))
+	}
+
+	format := t.mixOptions().Format
+	expected := time.Duration(cfg.ExpectedMinutes) * time.Minute
+	if expected <= 0 || free >= audiocapture.EstimateRecordingSize(expected, format) {
+		return "", nil
+	}
+	minutes := free / audiocapture.EstimateRecordingSize(time.Minute, format)
+	return fmt.Sprintf("low disk space: %s free, enough for about %d minutes of recording", formatBytes(free), minutes), nil
+}
+
+// checkTranscriptionSpace checks that transcribing the meeting won't fill the disk: whisper
+// works on copies of the recording in the artifacts directory, and downloads its model first when
+// it isn't cached. Both must fit on top of the minimum free space.
+func (t *TranscriberService) checkTranscriptionSpace(meeting *types.Meeting) error {
+	minFree := megabytes(t.config.Storage.MinFreeMB)
+
+	needed := minFree
+	if info, err := os.Stat(meeting.Transcript_path); err == nil {
+		needed += uint64(info.Size())
+	}
+	workDir := t.config.Storage.ArtifactsDir
+	if free, err := osoperations.FreeSpace(workDir); err != nil {
+		t.logger.Error("Failed to check free disk space", "error", err, "dir", workDir)
+	} else if free < needed {
+		return fmt.Errorf("%w to transcribe: %s free in %s, needs %s", ErrInsufficientDiskSpace, formatBytes(free), workDir, formatBytes(needed))
+	}
+
+	if t.engine.Name() != config.TranscriptionEngineWhisper {
+		return nil
+	}
+	model := t.config.Transcription.WhisperModel
+	if cached, err := whisperModelCached(model); err != nil || cached {
+		return nil
+	}
+	cacheDir, err := whisperCacheDir()
+	if err != nil {
+		return nil
+	}
+	// The cache directory may not exist before the first download
+	for ; ; cacheDir = filepath.Dir(cacheDir) {
+		if _, err := os.Stat(cacheDir); err == nil || cacheDir == filepath.Dir(cacheDir) {
+			break
+		}
+	}
+	needed = minFree + megabytes(int(whisperModelSizes[model]))
+	if free, err := osoperations.FreeSpace(cacheDir); err != nil {
+		t.logger.Error("Failed to check free disk space", "error", err, "dir", cacheDir)
+	} else if free < needed {
+		return fmt.Errorf("%w to download the whisper %s model: %s free in %s, needs %s", ErrInsufficientDiskSpace, model, formatBytes(free), cacheDir, formatBytes(needed))
+	}
+	return nil
+}
+
+// megabytes converts a size in MB to bytes
+func megabytes(mb int) uint64 {
+	return uint64(max(mb, 0)) << 20
+}
+
+// formatBytes formats a size in MB below a GB and in GB above
+func formatBytes(bytes uint64) string {
+	if bytes < 1<<30 {
+		return fmt.Sprintf("%d MB", bytes>>20)
+	}
+	return fmt.Sprintf("%.1f GB", float64(bytes)/(1<<30))
+}
@@ -0,0 +1,262 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/procs"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// sourceURLMetadataKey stores the URL a meeting was downloaded from
+const sourceURLMetadataKey = "source_url"
+
+// ErrInvalidURL is returned for URLs that can't be downloaded
+var ErrInvalidURL = errors.New("invalid URL")
+
+// downloadClient fetches recordings from URLs given through the API. It only connects to
+// public addresses, checked after DNS resolution and on every redirect, so a URL can't reach
+// the machine itself or the network it is on. It doesn't use a proxy, which would make the
+// connection on its behalf.
+var downloadClient = &http.Client{
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 30 * time.Second,
+			Control: dialPublicOnly,
+		}).DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: time.Minute,
+	},
+}
+
+// dialPublicOnly refuses connections to loopback, private, link-local and unspecified
+// addresses
+func dialPublicOnly(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); ip == nil || !isPublicIP(ip) {
+		return fmt.Errorf("%w: %s is not a public address", ErrInvalidURL, host)
+	}
+	return nil
+}
+
+// isPublicIP reports whether an address is reachable from the internet rather than local
+func isPublicIP(ip net.IP) bool {
+	return !ip.IsLoopback() && !ip.IsPrivate() && !ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() && !ip.IsUnspecified()
+}
+
+// podcastFeed is the part of an RSS podcast feed needed to find its latest episode
+type podcastFeed struct {
+	Items []struct {
+		Title     string `xml:"title"`
+		Enclosure struct {
+			URL string `xml:"url,attr"`
+		} `xml:"enclosure"`
+	} `xml:"channel>item"`
+}
+
+// TranscribeURL downloads a recording from a URL and runs it through the full pipeline, e.g.
+// a recorded webinar or a podcast episode. Audio and video files are downloaded directly,
+// podcast feeds give their latest episode and other pages are downloaded with yt-dlp. The
+// meeting is created right away with the downloading status, and titled after the
+// download unless opts has a title.
+func (t *TranscriberService) TranscribeURL(opts RecordingOptions, rawURL string) (string, error) {
+	if err := checkDownloadURL(rawURL); err != nil {
+		return "", err
+	}
+	meeting, err := t.fileMeeting(opts, time.Now())
+	if err != nil {
+		return "", err
+	}
+	meeting.Status = string(types.MeetingStatusDownloading)
+	meeting.Metadata = maps.Clone(meeting.Metadata)
+	if meeting.Metadata == nil {
+		meeting.Metadata = make(map[string]string)
+	}
+	meeting.Metadata[sourceURLMetadataKey] = rawURL
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeCreated)
+
+	go t.downloadMeeting(meeting, rawURL, opts.Title == "")
+	return meeting.Id, nil
+}
+
+// downloadMeeting downloads the recording of a meeting and queues it for processing
+func (t *TranscriberService) downloadMeeting(meeting *types.Meeting, rawURL string, retitle bool) {
+	t.logger.Info("Downloading recording", "meetingId", meeting.Id, "url", rawURL)
+	ctx, cancel := stageContext(t.ctx, t.config.Download.TimeoutMinutes)
+	defer cancel()
+
+	dir, err := t.artifacts.Create(meeting.Id, "download")
+	if err != nil {
+		t.failMeeting(meeting, "failed to create download directory", err)
+		return
+	}
+	defer t.artifacts.Release(dir)
+
+	path, title, err := t.fetchRecording(ctx, rawURL, dir)
+	if err != nil {
+		err = stageError(ctx, err)
+		t.failMeeting(meeting, fmt.Sprintf("failed to download %s: %v", rawURL, err), err)
+		return
+	}
+	if retitle && title != "" {
+		meeting.Title = title
+	}
+	if err := t.takeFile(meeting, path, false); err != nil {
+		t.failMeeting(meeting, "failed to store the downloaded recording", err)
+		return
+	}
+
+	t.setStatus(meeting, types.MeetingStatusProcessing)
+	t.recordEvent(meeting, events.TypeStatusChanged)
+	t.queueFile(meeting, rawURL)
+}
+
+// fetchRecording downloads the recording at a URL into the directory and returns its path and
+// title
+func (t *TranscriberService) fetchRecording(ctx context.Context, rawURL string, dir string) (string, string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return "", "", err
+	}
+	resp, err := downloadClient.Do(req)
+	if err != nil {
+		return "", "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", "", fmt.Errorf("server responded with %s", resp.Status)
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	switch {
+	case strings.HasPrefix(mediaType, "audio/") || strings.HasPrefix(mediaType, "video/") || mediaType == "application/octet-stream":
+		return t.saveDownload(resp, mediaType, dir)
+	case strings.Contains(mediaType, "xml"):
+		var feed podcastFeed
+		if err := xml.NewDecoder(io.LimitReader(resp.Body, 10<<20)).Decode(&feed); err != nil {
+			return "", "", fmt.Errorf("failed to read podcast feed: %w", err)
+		}
+		if len(feed.Items) == 0 || feed.Items[0].Enclosure.URL == "" {
+			return "", "", fmt.Errorf("podcast feed has no episodes")
+		}
+		episode := feed.Items[0]
+		if err := checkDownloadURL(episode.Enclosure.URL); err != nil {
+			return "", "", err
+		}
+		path, title, err := t.fetchRecording(ctx, episode.Enclosure.URL, dir)
+		if episode.Title != "" {
+			title = strings.TrimSpace(episode.Title)
+		}
+		return path, title, err
+	default:
+		return t.ytDlp(ctx, rawURL, dir)
+	}
+}
+
+// saveDownload writes the audio or video of a response into the directory, named after the
+// URL it was served from
+func (t *TranscriberService) saveDownload(resp *http.Response, mediaType string, dir string) (string, string, error) {
+	maxBytes := int64(t.config.Download.MaxMB) << 20
+	if maxBytes > 0 && resp.ContentLength > maxBytes {
+		return "", "", fmt.Errorf("download of %d MB exceeds the limit of %d MB", resp.ContentLength>>20, t.config.Download.MaxMB)
+	}
+
+	name := path.Base(resp.Request.URL.Path) // After redirects
+	title := strings.TrimSuffix(name, path.Ext(name))
+	if title == "" || title == "." || title == "/" {
+		title = "download"
+	}
+	ext := strings.ToLower(path.Ext(name))
+	if ext == "" {
+		ext = ".mp3"
+		if extensions, _ := mime.ExtensionsByType(mediaType); len(extensions) > 0 {
+			ext = extensions[0]
+		}
+	}
+
+	filePath := filepath.Join(dir, "download"+ext)
+	file, err := os.Create(filePath)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+	body := io.Reader(resp.Body)
+	if maxBytes > 0 {
+		body = io.LimitReader(resp.Body, maxBytes+1)
+	}
+	written, err := io.Copy(file, body)
+	if err != nil {
+		return "", "", err
+	}
+	if maxBytes > 0 && written > maxBytes {
+		return "", "", fmt.Errorf("download exceeds the limit of %d MB", t.config.Download.MaxMB)
+	}
+	return filePath, title, file.Close()
+}
+
+// ytDlp downloads the audio of a page of a video platform with yt-dlp into the directory
+func (t *TranscriberService) ytDlp(ctx context.Context, rawURL string, dir string) (string, string, error) {
+	command := t.config.Download.YtDlp
+	if _, err := exec.LookPath(command); err != nil {
+		return "", "", fmt.Errorf("%s is not audio or a podcast feed and %s is not installed to download it; install it with `brew install yt-dlp`", rawURL, command)
+	}
+
+	args := []string{
+		"--format", "bestaudio/best",
+		"--no-playlist",
+		"--output", filepath.Join(dir, "%(title).200B.%(ext)s"),
+		"--print", "after_move:filepath",
+	}
+	if t.config.Download.MaxMB > 0 {
+		args = append(args, "--max-filesize", fmt.Sprintf("%dM", t.config.Download.MaxMB))
+	}
+	// The URL can't be taken for an option, even when it starts with a dash
+	cmd := exec.CommandContext(ctx, command, append(args, "--", rawURL)...)
+	output, err := procs.CombinedOutput(cmd)
+	if err != nil {
+		return "", "", fmt.Errorf("yt-dlp failed: %w: %s", err, strings.TrimSpace(string(output)))
+	}
+
+	// The path is printed last, after any warnings
+	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
+	filePath := strings.TrimSpace(lines[len(lines)-1])
+	if _, err := os.Stat(filePath); err != nil {
+		return "", "", fmt.Errorf("yt-dlp did not download any audio: %s", strings.TrimSpace(string(output)))
+	}
+	return filePath, osoperations.GetFileNameWithoutExtension(filePath), nil
+}
+
+// checkDownloadURL checks that a URL is an http or https URL and doesn't name a local
+// address. Host names are checked once resolved, when the download connects.
+func checkDownloadURL(rawURL string) error {
+	parsed, err := url.Parse(rawURL)
+	if err != nil || (parsed.Scheme != "http" && parsed.Scheme != "https") || parsed.Host == "" {
+		return fmt.Errorf("%w: %q, expected an http or https URL", ErrInvalidURL, rawURL)
+	}
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && !isPublicIP(ip) {
+		return fmt.Errorf("%w: %q, %s is not a public address", ErrInvalidURL, rawURL, ip)
+	}
+	return nil
+}
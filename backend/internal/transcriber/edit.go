@@ -0,0 +1,157 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrMeetingBusy is returned when a meeting can't be changed while it is recorded or processed
+var ErrMeetingBusy = errors.New("meeting is still being recorded or processed")
+
+// ErrStaleVersion is returned for edits made to an older version of a meeting than the current
+var ErrStaleVersion = errors.New("meeting was changed since this version")
+
+// SegmentEdit changes the text or speaker of a transcript segment by index
+type SegmentEdit struct {
+	Index   int     `json:"index"`
+	Text    *string `json:"text,omitempty"`
+	Speaker *string `json:"speaker,omitempty"`
+}
+
+// MeetingEdit holds manual corrections to a meeting. Nil fields are left unchanged.
+type MeetingEdit struct {
+	Title        *string            `json:"title,omitempty"`
+	Participants *[]string          `json:"participants,omitempty"`
+	Segments     []SegmentEdit      `json:"segments,omitempty"`
+	Summary      *string            `json:"summary,omitempty"`
+	Tags         *[]string          `json:"tags,omitempty"`
+	Metadata     *map[string]string `json:"metadata,omitempty"` // Replaces all metadata
+	Series       *string            `json:"series,omitempty"`   // Links the meeting into a series, empty groups it by title
+	Version      *int64             `json:"version,omitempty"`  // Version the edit was made to, rejected when the meeting changed since
+}
+
+// checkVersion rejects a change made to another version of the meeting than the current one,
+// when the version it was made to is given
+func checkVersion(meeting *types.Meeting, version *int64) error {
+	if version != nil && *version != meeting.Version {
+		return fmt.Errorf("%w: edited version %d, current version %d", ErrStaleVersion, *version, meeting.Version)
+	}
+	return nil
+}
+
+// editable reports whether the pipeline is done with the meeting
+func editable(meeting *types.Meeting) bool {
+	switch types.MeetingStatus(meeting.Status) {
+	case types.MeetingStatusCompleted, types.MeetingStatusTranscriptOnly, types.MeetingStatusFailed,
+		types.MeetingStatusAwaitingTranscript, types.MeetingStatusDeferred:
+		return true
+	}
+	return false
+}
+
+// EditMeeting applies manual corrections to a meeting, rebuilds the transcript from its
+// segments and regenerates the vault note of completed meetings
+func (t *TranscriberService) EditMeeting(meetingId string, edit MeetingEdit) (*types.Meeting, error) {
+	t.editMu.Lock()
+	defer t.editMu.Unlock()
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return nil, err
+	}
+	if !editable(meeting) {
+		return nil, ErrMeetingBusy
+	}
+	if err := checkVersion(meeting, edit.Version); err != nil {
+		return nil, err
+	}
+
+	// Validate everything before changing anything
+	if edit.Title != nil && strings.TrimSpace(*edit.Title) == "" {
+		return nil, fmt.Errorf("title cannot be empty")
+	}
+	if edit.Metadata != nil {
+		for key := range *edit.Metadata {
+			if strings.TrimSpace(key) == "" {
+				return nil, fmt.Errorf("metadata keys cannot be empty")
+			}
+		}
+	}
+	for _, segmentEdit := range edit.Segments {
+		if segmentEdit.Index < 0 || segmentEdit.Index >= len(meeting.Segments) {
+			return nil, fmt.Errorf("segment index %d out of range, meeting has %d segments", segmentEdit.Index, len(meeting.Segments))
+		}
+	}
+
+	previousNote, _ := t.notePath(meeting)
+
+	// Requests may be reading the meeting meanwhile
+	t.mu.Lock()
+	if edit.Title != nil {
+		meeting.Title = strings.TrimSpace(*edit.Title)
+	}
+	if edit.Participants != nil {
+		meeting.Participants = *edit.Participants
+	}
+	if edit.Tags != nil {
+		meeting.Tags = cleanTags(*edit.Tags)
+	}
+	if edit.Metadata != nil {
+		meeting.Metadata = *edit.Metadata
+	}
+	if edit.Series != nil {
+		meeting.Series = strings.TrimSpace(*edit.Series)
+	}
+	for _, segmentEdit := range edit.Segments {
+		segment := &meeting.Segments[segmentEdit.Index]
+		if segmentEdit.Text != nil {
+			segment.Text = *segmentEdit.Text
+		}
+		if segmentEdit.Speaker != nil {
+			segment.Speaker = *segmentEdit.Speaker
+		}
+	}
+	if edit.Summary != nil {
+		replaceSummary(meeting, *edit.Summary)
+		meeting.SummaryReview = nil // The review was of the replaced summary
+	}
+
+	// The transcript header includes the title and participants, so rebuild it on any edit
+	if len(meeting.Segments) > 0 {
+		meeting.Transcript = FormatTranscript(meeting, meeting.Segments)
+	}
+	now := time.Now()
+	meeting.Edited = true
+	meeting.EditedAt = &now
+	t.mu.Unlock()
+
+	if len(edit.Segments) > 0 {
+		t.invalidateEmbeddings(meeting.Id)
+	}
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeEdited)
+	t.logger.Info("Meeting edited", "meetingId", meeting.Id, "segments", len(edit.Segments), "summary", edit.Summary != nil)
+
+	if meeting.Status == string(types.MeetingStatusCompleted) || meeting.Status == string(types.MeetingStatusTranscriptOnly) {
+		if err := t.resaveToVault(meeting, previousNote); err != nil {
+			return meeting, fmt.Errorf("meeting edited but failed to update vault note: %w", err)
+		}
+	}
+	return meeting, nil
+}
+
+// cleanTags trims tags and drops empty and duplicate ones
+func cleanTags(tags []string) []string {
+	cleaned := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		tag = strings.TrimSpace(tag)
+		if tag != "" && !containsString(cleaned, tag) {
+			cleaned = append(cleaned, tag)
+		}
+	}
+	return cleaned
+}
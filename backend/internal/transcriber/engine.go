@@ -0,0 +1,79 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/logger"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Engine transcribes an audio file into timestamped segments.
+// Streaming engines report the transcript so far through partial while they run.
+// Intermediate files go into workDir, which the caller creates and removes.
+type Engine interface {
+	Name() string
+	Transcribe(ctx context.Context, audioPath string, workDir string, partial func(text string)) ([]types.Segment, error)
+}
+
+// newEngine creates the transcription engine selected in the config
+func newEngine(cfg config.TranscriptionConfig, logger *logger.Logger) (Engine, error) {
+	switch cfg.Engine {
+	case "", config.TranscriptionEngineWhisper:
+		return newWhisperEngine(cfg, logger)
+	case config.TranscriptionEngineDeepgram:
+		if cfg.DeepgramKey == "" {
+			return nil, fmt.Errorf("deepgram engine requires transcription.deepgram_api_key")
+		}
+		return &deepgramEngine{apiKey: cfg.DeepgramKey, logger: logger}, nil
+	case config.TranscriptionEngineAssemblyAI:
+		if cfg.AssemblyAIKey == "" {
+			return nil, fmt.Errorf("assemblyai engine requires transcription.assemblyai_api_key")
+		}
+		return &assemblyAIEngine{apiKey: cfg.AssemblyAIKey, logger: logger}, nil
+	case config.TranscriptionEngineFake:
+		return &fakeEngine{}, nil
+	default:
+		return nil, fmt.Errorf("unknown transcription engine %q", cfg.Engine)
+	}
+}
+
+// whisperEngine transcribes recordings locally with the whisper CLI
+type whisperEngine struct {
+	logger        *logger.Logger
+	options       WhisperOptions
+	codeSwitching []string // Candidate languages per segment, nil when code-switching is disabled
+}
+
+// newWhisperEngine creates the whisper engine, checking that code-switching uses a multilingual model
+func newWhisperEngine(cfg config.TranscriptionConfig, logger *logger.Logger) (Engine, error) {
+	engine := &whisperEngine{
+		logger:  logger,
+		options: WhisperOptions{Model: cfg.WhisperModel, Language: cfg.Language},
+	}
+	if cfg.CodeSwitching.Enabled {
+		if strings.HasSuffix(cfg.WhisperModel, ".en") {
+			return nil, fmt.Errorf("code-switching requires a multilingual whisper model, %q is English-only", cfg.WhisperModel)
+		}
+		if len(cfg.CodeSwitching.Languages) < 2 {
+			return nil, fmt.Errorf("code-switching requires at least two transcription.code_switching.languages")
+		}
+		engine.options.Language = ""
+		engine.codeSwitching = cfg.CodeSwitching.Languages
+	}
+	return engine, nil
+}
+
+func (e *whisperEngine) Name() string {
+	return config.TranscriptionEngineWhisper
+}
+
+func (e *whisperEngine) Transcribe(ctx context.Context, audioPath string, workDir string, partial func(text string)) ([]types.Segment, error) {
+	transcriber := NewTranscriber(audioPath, workDir, e.options, e.logger)
+	if len(e.codeSwitching) > 0 {
+		return transcriber.TranscribeCodeSwitched(ctx, e.codeSwitching)
+	}
+	return transcriber.TranscribeAudio(ctx)
+}
@@ -0,0 +1,137 @@
+package transcriber
+
+import (
+	"archive/zip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// exportMeeting writes a zip with the track of each device, the mixed recording, an SRT
+// transcript and the meeting note, for post-production in a DAW or video editor. It runs
+// before the recording files are removed. Failures are logged and leave the meeting as is.
+func (t *TranscriberService) exportMeeting(meeting *types.Meeting) {
+	cfg := t.config.Audio.Export
+	if !cfg.Enabled {
+		return
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		t.logger.Error("Failed to create export directory", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	recordingName := strings.TrimSuffix(filepath.Base(t.recordingFileName(meeting)), ".wav")
+	exportPath := freePath(meeting, filepath.Join(cfg.Dir, recordingName+".zip"), meeting.ExportPath)
+
+	err := t.writeExport(meeting, exportPath)
+	if err == nil {
+		err = t.cipher.EncryptFile(exportPath)
+	}
+	if err != nil {
+		os.Remove(exportPath)
+		t.logger.Error("Failed to export meeting", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	meeting.ExportPath = exportPath
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeExported)
+	t.logger.Info("Exported meeting", "meetingId", meeting.Id, "file", exportPath)
+}
+
+// writeExport writes the export zip of a meeting
+func (t *TranscriberService) writeExport(meeting *types.Meeting, exportPath string) error {
+	file, err := os.Create(exportPath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	archive := zip.NewWriter(file)
+
+	// The audio is stored as is, deflating WAV files gains little
+	audio := append(slices.Clone(meeting.Tracks), types.AudioTrack{Source: "mixed", Path: meeting.Transcript_path})
+	for _, track := range audio {
+		name := track.Source + ".wav"
+		if err := addFile(archive, name, track.Path); err != nil {
+			return fmt.Errorf("failed to add %s: %w", name, err)
+		}
+	}
+
+	if err := addContent(archive, "transcript.srt", formatSRT(meeting.Segments)); err != nil {
+		return err
+	}
+	noteName, note := t.exportNote(meeting)
+	if err := addContent(archive, noteName+".md", note); err != nil {
+		return err
+	}
+
+	if err := archive.Close(); err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// exportNote returns the name and content of the meeting note, read from the vault, or the
+// summary and transcript when the note can't be read
+func (t *TranscriberService) exportNote(meeting *types.Meeting) (string, string) {
+	noteName, err := t.noteName(meeting)
+	if err != nil {
+		noteName = "meeting"
+	}
+	if notePath, err := osoperations.MeetingNotePath(meeting, noteName); err == nil {
+		if vaultDir, err := osoperations.VaultDir(); err == nil {
+			if data, err := os.ReadFile(filepath.Join(vaultDir, notePath)); err == nil {
+				return noteName, string(data)
+			}
+		}
+	}
+	if meeting.Summary == "" {
+		return noteName, meeting.Transcript
+	}
+	return noteName, meeting.Summary + "\n\n" + meeting.Transcript
+}
+
+// addFile stores a file in the zip without compression
+func addFile(archive *zip.Writer, name string, path string) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Store, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	return err
+}
+
+// addContent adds a compressed text file to the zip
+func addContent(archive *zip.Writer, name string, content string) error {
+	out, err := archive.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: time.Now()})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(out, content)
+	return err
+}
+
+// formatSRT renders the segments as SubRip subtitles, prefixed with the speaker when known
+func formatSRT(segments []types.Segment) string {
+	var b strings.Builder
+	for i, segment := range segments {
+		text := segment.Text
+		if segment.Speaker != "" {
+			text = segment.Speaker + ": " + text
+		}
+		fmt.Fprintf(&b, "%d\n%s --> %s\n%s\n\n", i+1, FormatTimestamp(segment.Start), FormatTimestamp(segment.End), text)
+	}
+	return b.String()
+}
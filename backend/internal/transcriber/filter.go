@@ -0,0 +1,252 @@
+package transcriber
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// MeetingFilter selects meetings for listing and batch operations
+type MeetingFilter struct {
+	Since       time.Time         // Only meetings created at or after this time
+	Until       time.Time         // Only meetings created before this time
+	Status      string            // Only meetings with this status
+	Tag         string            // Only meetings carrying this tag
+	Title       string            // Only meetings whose title contains this substring (case-insensitive)
+	Participant string            // Only meetings with a participant containing this substring (case-insensitive)
+	Owner       string            // Only meetings started by this user
+	Type        string            // Only meetings started with this preset
+	Metadata    map[string]string // Only meetings whose metadata has these exact key/value pairs
+	Archived    bool              // Also archived meetings, which are left out otherwise
+	Trashed     bool              // Only meetings in the trash, which are left out otherwise
+}
+
+// MetadataFilterPrefix marks filter keys that match a metadata entry, e.g. meta.customer_id:42
+const MetadataFilterPrefix = "meta."
+
+// ParseMeetingFilter parses a filter expression made of space or comma separated
+// key:value terms, e.g. "since:30d tag:standup title:weekly meta.customer_id:42". The since and until
+// terms accept a relative duration in days (30d), a Go duration (12h) or a date (2006-01-02);
+// an until date includes that day.
+func ParseMeetingFilter(expr string) (MeetingFilter, error) {
+	var filter MeetingFilter
+	terms := strings.FieldsFunc(expr, func(r rune) bool { return r == ' ' || r == ',' })
+	for _, term := range terms {
+		key, value, ok := strings.Cut(term, ":")
+		if !ok || value == "" {
+			return filter, fmt.Errorf("invalid filter term %q, expected key:value", term)
+		}
+
+		if err := filter.Set(key, value); err != nil {
+			return filter, err
+		}
+	}
+	return filter, nil
+}
+
+// Set assigns a single filter field by name
+func (f *MeetingFilter) Set(key, value string) error {
+	if metaKey, ok := strings.CutPrefix(key, MetadataFilterPrefix); ok && metaKey != "" {
+		if f.Metadata == nil {
+			f.Metadata = make(map[string]string)
+		}
+		f.Metadata[metaKey] = value
+		return nil
+	}
+
+	switch strings.ToLower(key) {
+	case "since", "from":
+		since, err := parseTimeBound(value)
+		if err != nil {
+			return err
+		}
+		f.Since = since
+	case "until", "to":
+		until, err := parseTimeBound(value)
+		if err != nil {
+			return err
+		}
+		// A date includes the whole day, so the bound is the start of the next one
+		if isDate(value) {
+			until = until.AddDate(0, 0, 1)
+		}
+		f.Until = until
+	case "status":
+		f.Status = value
+	case "tag":
+		f.Tag = value
+	case "title":
+		f.Title = value
+	case "participant":
+		f.Participant = value
+	case "owner":
+		f.Owner = value
+	case "type":
+		f.Type = value
+	case "archived", "trash":
+		include, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("invalid %s value %q, expected true or false", key, value)
+		}
+		if strings.EqualFold(key, "archived") {
+			f.Archived = include
+		} else {
+			f.Trashed = include
+		}
+	default:
+		return fmt.Errorf("unknown filter key %q", key)
+	}
+	return nil
+}
+
+// dateLayout is the layout of time bounds given as a date
+const dateLayout = "2006-01-02"
+
+// isDate reports whether a time bound is a date without a time
+func isDate(value string) bool {
+	_, err := time.Parse(dateLayout, value)
+	return err == nil
+}
+
+// parseTimeBound parses a relative duration in days (30d), a Go duration (12h),
+// a date (2006-01-02) or an RFC3339 timestamp
+func parseTimeBound(value string) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err == nil {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.Parse(dateLayout, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid time value %q", value)
+}
+
+// Matches reports whether the meeting satisfies the filter
+func (f MeetingFilter) Matches(meeting *types.Meeting) bool {
+	if (meeting.TrashedAt != nil) != f.Trashed {
+		return false
+	}
+	if meeting.ArchivedAt != nil && !f.Archived && !f.Trashed {
+		return false
+	}
+	if !f.Since.IsZero() && meeting.CreatedAt.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && !meeting.CreatedAt.Before(f.Until) {
+		return false
+	}
+	if f.Status != "" && !strings.EqualFold(meeting.Status, f.Status) {
+		return false
+	}
+	if f.Owner != "" && meeting.Owner != f.Owner {
+		return false
+	}
+	if f.Type != "" && meeting.Type != f.Type {
+		return false
+	}
+	if f.Title != "" && !containsFold(meeting.Title, f.Title) {
+		return false
+	}
+	if f.Tag != "" && !anyMatch(meeting.Tags, func(tag string) bool { return strings.EqualFold(tag, f.Tag) }) {
+		return false
+	}
+	if f.Participant != "" && !anyMatch(meeting.Participants, func(p string) bool { return containsFold(p, f.Participant) }) {
+		return false
+	}
+	for key, value := range f.Metadata {
+		if meeting.Metadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func containsFold(s, substr string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
+}
+
+func anyMatch(values []string, match func(string) bool) bool {
+	for _, value := range values {
+		if match(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListOptions controls filtering, sorting and pagination of the meetings list
+type ListOptions struct {
+	Filter MeetingFilter
+	Sort   string // Field to sort by, prefixed with "-" for descending order
+	Limit  int    // Maximum number of meetings to return, 0 returns all
+	Offset int
+}
+
+// sortFields maps sortable field names to their comparison functions
+var sortFields = map[string]func(a, b *types.Meeting) bool{
+	"created_at": func(a, b *types.Meeting) bool { return a.CreatedAt.Before(b.CreatedAt) },
+	"title":      func(a, b *types.Meeting) bool { return strings.ToLower(a.Title) < strings.ToLower(b.Title) },
+	"duration":   func(a, b *types.Meeting) bool { return a.Duration < b.Duration },
+	"status":     func(a, b *types.Meeting) bool { return a.Status < b.Status },
+}
+
+// ValidateSort checks that the sort expression refers to a sortable field
+func ValidateSort(sortExpr string) error {
+	if sortExpr == "" {
+		return nil
+	}
+	if _, ok := sortFields[strings.TrimPrefix(sortExpr, "-")]; !ok {
+		return fmt.Errorf("cannot sort by %q", sortExpr)
+	}
+	return nil
+}
+
+// ListMeetings returns a filtered, sorted page of meetings and the total number of matches.
+// Meetings are sorted newest first unless another order is requested.
+func (t *TranscriberService) ListMeetings(opts ListOptions) ([]*types.Meeting, int) {
+	var matches []*types.Meeting
+	for _, meeting := range t.GetAllMeetings() {
+		if opts.Filter.Matches(meeting) {
+			matches = append(matches, meeting)
+		}
+	}
+
+	sortExpr := opts.Sort
+	if sortExpr == "" {
+		sortExpr = "-created_at"
+	}
+	less, ok := sortFields[strings.TrimPrefix(sortExpr, "-")]
+	if !ok {
+		less = sortFields["created_at"]
+	}
+	descending := strings.HasPrefix(sortExpr, "-")
+	sort.SliceStable(matches, func(i, j int) bool {
+		if descending {
+			return less(matches[j], matches[i])
+		}
+		return less(matches[i], matches[j])
+	})
+
+	total := len(matches)
+	if opts.Offset >= total {
+		return []*types.Meeting{}, total
+	}
+	matches = matches[opts.Offset:]
+	if opts.Limit > 0 && opts.Limit < len(matches) {
+		matches = matches[:opts.Limit]
+	}
+
+	return matches, total
+}
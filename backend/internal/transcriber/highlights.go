@@ -0,0 +1,102 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/highlights"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// AddBookmark marks the current moment of a recording, so the highlight reel includes it
+func (t *TranscriberService) AddBookmark(meetingId string, note string) (types.Bookmark, error) {
+	meeting, _ := t.activeRecording()
+	if meeting == nil || meeting.Id != meetingId {
+		return types.Bookmark{}, fmt.Errorf("meeting %s is not being recorded", meetingId)
+	}
+
+	bookmark := types.Bookmark{
+		At:   time.Since(meeting.Start_time).Seconds(),
+		Note: strings.TrimSpace(note),
+	}
+	meeting.Bookmarks = append(meeting.Bookmarks, bookmark)
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeBookmarked)
+	t.logger.Info("Bookmarked recording", "meetingId", meeting.Id, "at", bookmark.At)
+	return bookmark, nil
+}
+
+// defaultMarkerLabel labels bookmarks made without a note in the transcript
+const defaultMarkerLabel = "Bookmark"
+
+// markSegments attaches the bookmarks of the meeting to the segments spoken when they were
+// made, or to the next segment when they fell in a pause, so the transcript shows them
+func markSegments(segments []types.Segment, bookmarks []types.Bookmark) {
+	if len(segments) == 0 {
+		return
+	}
+	for i := range segments {
+		segments[i].Markers = nil
+	}
+	for _, bookmark := range bookmarks {
+		label := bookmark.Note
+		if label == "" {
+			label = defaultMarkerLabel
+		}
+		i := sort.Search(len(segments), func(i int) bool { return segments[i].End > bookmark.At })
+		if i == len(segments) {
+			i--
+		}
+		segments[i].Markers = append(segments[i].Markers, label)
+	}
+}
+
+// createHighlights cuts the most information-dense minutes of the recording into a highlight
+// reel. It runs before the recording files are removed. Failures are logged and leave the
+// meeting as is.
+func (t *TranscriberService) createHighlights(ctx context.Context, meeting *types.Meeting) {
+	cfg := t.config.Audio.Highlights
+	if !cfg.Enabled {
+		return
+	}
+
+	clips := highlights.Select(meeting.Segments, meeting.Bookmarks, cfg.Minutes)
+	if len(clips) == 0 {
+		t.logger.Info("No highlights found in transcript", "meetingId", meeting.Id)
+		return
+	}
+	extension, err := audiocapture.CodecExtension(cfg.Codec)
+	if err != nil {
+		t.logger.Error("Invalid highlights codec", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		t.logger.Error("Failed to create highlights directory", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	recordingName := strings.TrimSuffix(filepath.Base(t.recordingFileName(meeting)), ".wav")
+	highlightsPath := freePath(meeting, filepath.Join(cfg.Dir, recordingName+"_highlights"+extension), meeting.HighlightsPath)
+
+	if err := t.transcoder.ExtractClips(ctx, meeting.Transcript_path, highlightsPath, cfg.Codec, clips); err != nil {
+		os.Remove(highlightsPath)
+		t.logger.Error("Failed to create highlights", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	if err := t.cipher.EncryptFile(highlightsPath); err != nil {
+		os.Remove(highlightsPath)
+		t.logger.Error("Failed to encrypt highlights", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	meeting.Highlights = clips
+	meeting.HighlightsPath = highlightsPath
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeHighlighted)
+	t.logger.Info("Created highlights", "meetingId", meeting.Id, "file", highlightsPath, "clips", len(clips))
+}
@@ -0,0 +1,225 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// interruptedStatuses are pipeline stages a server restart interrupts
+var interruptedStatuses = map[types.MeetingStatus]bool{
+	types.MeetingStatusRecording:         true,
+	types.MeetingStatusProcessing:        true,
+	types.MeetingStatusRecordingCreated:  true,
+	types.MeetingStatusTranscriptCreated: true,
+	types.MeetingStatusSummaryCreated:    true,
+}
+
+// recordEvent appends the changes made to the meeting since its last event to the event log
+// and bumps the version of the meeting when anything changed
+func (t *TranscriberService) recordEvent(meeting *types.Meeting, eventType string) {
+	// Snapshot the meeting under the lock, requests may be reading or changing it meanwhile
+	t.mu.RLock()
+	data, err := json.Marshal(meeting)
+	t.mu.RUnlock()
+	if err != nil {
+		t.logger.Error("Failed to encode meeting event", "error", err, "meetingId", meeting.Id, "type", eventType)
+		return
+	}
+	event, err := t.events.Record(meeting.Id, eventType, data)
+	if err != nil {
+		t.logger.Error("Failed to record meeting event", "error", err, "meetingId", meeting.Id, "type", eventType)
+		return
+	}
+	if event != nil {
+		t.mu.Lock()
+		stampVersion(meeting, event)
+		t.mu.Unlock()
+	}
+
+	if _, command := t.hookCommand(eventType); command != "" {
+		var snapshot types.Meeting
+		if err := json.Unmarshal(data, &snapshot); err != nil {
+			t.logger.Error("Failed to decode meeting for hook", "error", err, "meetingId", meeting.Id)
+			return
+		}
+		if event != nil {
+			stampVersion(&snapshot, event)
+		}
+		t.triggerHook(&snapshot, eventType)
+	}
+}
+
+// stampVersion sets the version and update time of a meeting from its latest event. Event
+// sequence numbers only increase, so versions do too.
+func stampVersion(meeting *types.Meeting, latest *events.Event) {
+	meeting.Version = latest.Seq
+	meeting.UpdatedAt = latest.Time
+}
+
+// restoreMeetings rebuilds the meetings from the event log. Meetings that were being recorded
+// or processed when the server stopped resume where they were interrupted; those that can't
+// are marked as failed.
+func (t *TranscriberService) restoreMeetings() error {
+	var interrupted []*types.Meeting
+	for _, meetingId := range t.events.Meetings() {
+		meeting, err := t.meetingFromEvents(meetingId)
+		if err != nil {
+			return err
+		}
+		t.setMeeting(meeting)
+
+		status := types.MeetingStatus(meeting.Status)
+		_, statErr := os.Stat(meeting.Transcript_path)
+		switch {
+		case interruptedStatuses[status]:
+			interrupted = append(interrupted, meeting)
+		case status == types.MeetingStatusDeferred && statErr != nil:
+			t.failMeeting(meeting, "the deferred recording was lost in a server restart", statErr)
+		case status == types.MeetingStatusDownloading:
+			t.failMeeting(meeting, "the download was interrupted by a server restart", nil)
+		}
+	}
+
+	// Orphaned files are cleaned up before the meetings resume and mix their tracks
+	t.unsealRecordings()
+	t.cleanRecordingsDir()
+	for _, meeting := range interrupted {
+		go t.resumeMeeting(meeting)
+	}
+
+	t.logger.Info("Restored meetings from event log", "meetings", len(t.meetings))
+	return nil
+}
+
+// meetingFromEvents derives the current state of a meeting from its events
+func (t *TranscriberService) meetingFromEvents(meetingId string) (*types.Meeting, error) {
+	data, err := t.events.State(meetingId)
+	if err != nil {
+		return nil, err
+	}
+
+	var meeting types.Meeting
+	if err := json.Unmarshal(data, &meeting); err != nil {
+		return nil, fmt.Errorf("failed to restore meeting %s: %w", meetingId, err)
+	}
+	latest, err := t.events.Latest(meetingId)
+	if err != nil {
+		return nil, err
+	}
+	stampVersion(&meeting, latest)
+	return &meeting, nil
+}
+
+// WaitForStatusChange waits until the status of a meeting differs from status, ctx is done or
+// the server shuts down, and returns the meeting. It wakes up on every recorded event instead
+// of polling.
+func (t *TranscriberService) WaitForStatusChange(ctx context.Context, meetingId string, status string) (*types.Meeting, error) {
+	for {
+		// Taken before the status is checked, so a change in between isn't missed
+		appended := t.events.Appended()
+		meeting, err := t.GetMeetingStatus(meetingId)
+		if err != nil || meeting.Status != status {
+			return meeting, err
+		}
+		select {
+		case <-appended:
+		case <-ctx.Done():
+			return meeting, nil
+		case <-t.ctx.Done():
+			return meeting, nil
+		}
+	}
+}
+
+// MeetingEvents returns the events of a meeting after the since sequence number
+func (t *TranscriberService) MeetingEvents(meetingId string, since int64, limit int) ([]events.Event, error) {
+	if _, err := t.GetMeetingStatus(meetingId); err != nil {
+		return nil, err
+	}
+	return t.events.Events(meetingId, since, limit), nil
+}
+
+// Events returns the events of all meetings after the since sequence number. With an owner,
+// only the events of the meetings of that user are returned.
+func (t *TranscriberService) Events(since int64, limit int, owner string) []events.Event {
+	if owner == "" {
+		return t.events.Events("", since, limit)
+	}
+	result := []events.Event{}
+	for _, event := range t.events.Events("", since, 0) {
+		if meeting, err := t.GetMeetingStatus(event.MeetingId); err != nil || meeting.Owner != owner {
+			continue
+		}
+		result = append(result, event)
+		if limit > 0 && len(result) == limit {
+			break
+		}
+	}
+	return result
+}
+
+// MeetingAt replays the events of a meeting up to seq and returns the event and the
+// meeting as it was right after it
+func (t *TranscriberService) MeetingAt(meetingId string, seq int64) (*events.Event, *types.Meeting, error) {
+	event, err := t.events.Event(meetingId, seq)
+	if err != nil {
+		return nil, nil, err
+	}
+	data, err := t.events.StateAt(meetingId, seq)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var meeting types.Meeting
+	if err := json.Unmarshal(data, &meeting); err != nil {
+		return nil, nil, err
+	}
+	stampVersion(&meeting, event)
+	return event, &meeting, nil
+}
+
+// UndoEdit reverts the most recent edit of a meeting that wasn't undone yet. The fields it
+// changed are restored to their previous values and the vault note is rewritten. With a
+// version, the undo is rejected when the meeting changed since that version.
+func (t *TranscriberService) UndoEdit(meetingId string, version *int64) (*types.Meeting, error) {
+	t.editMu.Lock()
+	defer t.editMu.Unlock()
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return nil, err
+	}
+	if !editable(meeting) {
+		return nil, ErrMeetingBusy
+	}
+	if err := checkVersion(meeting, version); err != nil {
+		return nil, err
+	}
+
+	event, err := t.events.Undo(meetingId)
+	if err != nil {
+		return nil, err
+	}
+	restored, err := t.meetingFromEvents(meetingId)
+	if err != nil {
+		return nil, err
+	}
+
+	previousNote, _ := t.notePath(meeting)
+	t.mu.Lock()
+	*meeting = *restored
+	t.mu.Unlock()
+	t.invalidateEmbeddings(meetingId)
+	t.logger.Info("Meeting edit undone", "meetingId", meetingId, "seq", event.Undoes)
+
+	if meeting.Status == string(types.MeetingStatusCompleted) || meeting.Status == string(types.MeetingStatusTranscriptOnly) {
+		if err := t.resaveToVault(meeting, previousNote); err != nil {
+			return meeting, fmt.Errorf("edit undone but failed to update vault note: %w", err)
+		}
+	}
+	return meeting, nil
+}
@@ -0,0 +1,137 @@
+package transcriber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/procs"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// hookQueueSize is the number of hooks that can wait for the one running
+const hookQueueSize = 64
+
+// hookOutputLength is how much of the output of a failed hook is logged
+const hookOutputLength = 2000
+
+// hookRun is a hook waiting to run, with the meeting as it was when it was triggered
+type hookRun struct {
+	name      string
+	meetingId string
+	command   string
+	meeting   []byte
+	env       []string
+}
+
+// hookCommand returns the name and command of the hook triggered by a meeting event, or
+// empty strings when there is none
+func (t *TranscriberService) hookCommand(eventType string) (string, string) {
+	cfg := t.config.Hooks
+	switch eventType {
+	case events.TypeStopped:
+		return "on_recording_stopped", cfg.OnRecordingStopped
+	case events.TypeTranscribed:
+		return "on_transcript_ready", cfg.OnTranscriptReady
+	case events.TypeSummarized:
+		return "on_summary_ready", cfg.OnSummaryReady
+	}
+	return "", ""
+}
+
+// triggerHook queues the hook of a meeting event, given a snapshot of the meeting no one else
+// changes. Hooks run one at a time in the order they were triggered, so a script sees a
+// meeting's transcript before its summary.
+func (t *TranscriberService) triggerHook(meeting *types.Meeting, eventType string) {
+	name, command := t.hookCommand(eventType)
+	if command == "" {
+		return
+	}
+	data, err := json.Marshal(meeting)
+	if err != nil {
+		t.logger.Error("Failed to encode meeting for hook", "error", err, "meetingId", meeting.Id, "hook", name)
+		return
+	}
+	run := hookRun{
+		name:      name,
+		meetingId: meeting.Id,
+		command:   command,
+		meeting:   data,
+		env: []string{
+			"TRANSCRIBER_HOOK=" + name,
+			"TRANSCRIBER_MEETING_ID=" + meeting.Id,
+			"TRANSCRIBER_MEETING_TITLE=" + meeting.Title,
+			"TRANSCRIBER_MEETING_STATUS=" + meeting.Status,
+			"TRANSCRIBER_MEETING_TYPE=" + meeting.Type,
+			"TRANSCRIBER_MEETING_TAGS=" + strings.Join(meeting.Tags, ","),
+			"TRANSCRIBER_RECORDING=" + meeting.Transcript_path,
+		},
+	}
+	t.hooksPending.Add(1)
+	select {
+	case t.hooks <- run:
+	default:
+		t.hooksPending.Done()
+		t.logger.Error("Too many hooks waiting, skipping hook", "meetingId", meeting.Id, "hook", name)
+	}
+}
+
+// runHooks runs the triggered hooks until the service shuts down
+func (t *TranscriberService) runHooks() {
+	for {
+		select {
+		case <-t.ctx.Done():
+			return
+		case run := <-t.hooks:
+			t.runHook(run)
+			t.hooksPending.Done()
+		}
+	}
+}
+
+// waitHooks waits for the triggered hooks to finish and reports whether they did before the
+// timeout
+func (t *TranscriberService) waitHooks(timeout time.Duration) bool {
+	done := make(chan struct{})
+	go func() {
+		t.hooksPending.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return true
+	case <-time.After(timeout):
+		return false
+	}
+}
+
+// runHook runs a hook with sh, giving it the meeting as JSON on stdin and its details in
+// TRANSCRIBER_* variables. Failing hooks are logged and don't affect the meeting.
+func (t *TranscriberService) runHook(run hookRun) {
+	ctx := t.ctx
+	if seconds := t.config.Hooks.TimeoutSeconds; seconds > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(seconds)*time.Second)
+		defer cancel()
+	}
+
+	cmd := exec.CommandContext(ctx, "sh", "-c", run.command)
+	cmd.Stdin = bytes.NewReader(run.meeting)
+	cmd.Env = append(os.Environ(), run.env...)
+	started := time.Now()
+	output, err := procs.CombinedOutput(cmd)
+	if err = stageError(ctx, err); err != nil {
+		if len(output) > hookOutputLength {
+			output = output[len(output)-hookOutputLength:]
+		}
+		t.logger.Error("Hook failed", "error", err, "meetingId", run.meetingId, "hook", run.name, "output", string(output))
+		return
+	}
+	t.logger.Info("Hook finished", "meetingId", run.meetingId, "hook", run.name, "duration", time.Since(started).Round(time.Millisecond))
+	t.logger.Debug("Hook output", "meetingId", run.meetingId, "hook", run.name, "output", string(output))
+}
@@ -0,0 +1,138 @@
+package transcriber
+
+import (
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/notify"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// hotSwapFollow is the hot-swap mode switching the mic to the default input, other modes
+// only warn
+const hotSwapFollow = "follow"
+
+// defaultHotSwapInterval is used when no check interval is configured
+const defaultHotSwapInterval = 5 * time.Second
+
+// micWatch is what the hot-swap watcher knows about the mic being recorded
+type micWatch struct {
+	device       string // Device the mic track is recorded from
+	defaultInput string // Default input device at the last check, empty when unknown
+	live         string // File the mic track was written to at the last check
+	size         int64  // Size of that file at the last check
+	growing      bool   // Whether the file grew since recording from the device, so ffmpeg captured audio
+}
+
+// watchDevices checks the mic of a recording for when it disappears, stops delivering
+// audio, or another device becomes the default input, as when AirPods connect mid-meeting
+// and ffmpeg keeps recording a dead device. In follow mode the mic track continues on the
+// default input; otherwise, or when switching fails, the meeting gets a warning and a
+// device_lost event, and a notification is shown.
+func (t *TranscriberService) watchDevices(meeting *types.Meeting, recorder audiocapture.Recorder, mic string) {
+	cfg := t.config.Audio.HotSwap
+	if !cfg.Enabled {
+		return
+	}
+	interval := time.Duration(cfg.CheckInterval) * time.Second
+	if interval <= 0 {
+		interval = defaultHotSwapInterval
+	}
+
+	watch := &micWatch{device: mic}
+	watch.defaultInput, _ = t.capturer.DefaultInputDevice()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if !t.isRecording(meeting) {
+			return
+		}
+		reason, target := t.checkMic(meeting, recorder, watch, interval)
+		if reason == "" {
+			continue
+		}
+
+		if cfg.Mode == hotSwapFollow && target != "" {
+			err := recorder.SwitchMic(t.ctx, target)
+			if err == nil {
+				t.micSwitched(meeting, watch, target, reason)
+				continue
+			}
+			t.logger.Error("Failed to switch microphone", "error", err, "meetingId", meeting.Id, "device", target)
+		}
+		t.micLost(meeting, fmt.Sprintf("microphone %s: %s", watch.device, reason))
+	}
+}
+
+// checkMic returns what is wrong with the mic, if anything, and the device to continue on
+func (t *TranscriberService) checkMic(meeting *types.Meeting, recorder audiocapture.Recorder, watch *micWatch, interval time.Duration) (string, string) {
+	current, err := t.capturer.DefaultInputDevice()
+	if err != nil {
+		t.logger.Debug("Failed to get default input device", "error", err, "meetingId", meeting.Id)
+	} else {
+		previous := watch.defaultInput
+		watch.defaultInput = current
+		if previous != "" && current != previous && current != watch.device {
+			return fmt.Sprintf("default input changed from %s to %s", previous, current), current
+		}
+	}
+
+	// Devices given by index can't be looked up by name
+	if _, err := strconv.Atoi(watch.device); err != nil {
+		if devices, err := t.capturer.ListAudioDevices(); err == nil && len(devices) > 0 && !slices.Contains(devices, watch.device) {
+			return "device disappeared", current
+		}
+	}
+
+	micPath := recorder.GetTracks()[0].Path
+	live, size, err := audiocapture.TrackProgress(micPath)
+	if err != nil {
+		return "", ""
+	}
+	stalled := live == watch.live && size == watch.size
+	watch.growing = watch.growing || (live == watch.live && size > watch.size)
+	watch.live, watch.size = live, size
+	if stalled && watch.growing {
+		return "stopped delivering audio", current
+	}
+	if level, err := audiocapture.TrackLevel(micPath, interval); err == nil && watch.growing && math.IsInf(level, -1) {
+		return "delivers only digital silence", current
+	}
+	return "", ""
+}
+
+// micSwitched records that the mic track continues on another device
+func (t *TranscriberService) micSwitched(meeting *types.Meeting, watch *micWatch, device string, reason string) {
+	warning := fmt.Sprintf("switched microphone from %s to %s: %s", watch.device, device, reason)
+	t.logger.Info("Switched microphone", "meetingId", meeting.Id, "from", watch.device, "to", device, "reason", reason)
+	*watch = micWatch{device: device, defaultInput: watch.defaultInput}
+
+	t.mu.Lock()
+	if len(meeting.Audio_devices) > 0 {
+		meeting.Audio_devices[0].Name = device
+	}
+	t.mu.Unlock()
+	if !t.addWarning(meeting, events.TypeDeviceSelected, warning) {
+		// Switching back and forth repeats a warning, the device is recorded all the same
+		t.recordEvent(meeting, events.TypeDeviceSelected)
+	}
+	if err := notify.Send("Microphone switched", warning); err != nil {
+		t.logger.Debug("Failed to send notification", "error", err)
+	}
+}
+
+// micLost warns that the mic may not be recording, once per problem
+func (t *TranscriberService) micLost(meeting *types.Meeting, warning string) {
+	if !t.addWarning(meeting, events.TypeDeviceLost, warning) {
+		return
+	}
+	t.logger.Info("Microphone problem while recording", "meetingId", meeting.Id, "warning", warning)
+	if err := notify.Send("Check your microphone", warning+" — your voice may not be recorded"); err != nil {
+		t.logger.Debug("Failed to send notification", "error", err)
+	}
+}
@@ -0,0 +1,182 @@
+package transcriber
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Import formats: SRT and WebVTT subtitles, the WebVTT transcripts Zoom and Teams export,
+// with the speaker as a "Name:" prefix or a <v Name> voice tag, and JSON segments or
+// transcripts of the services the webhook inbox accepts
+const (
+	ImportFormatSRT   = "srt"
+	ImportFormatVTT   = "vtt"
+	ImportFormatZoom  = "zoom"
+	ImportFormatTeams = "teams"
+	ImportFormatJSON  = "json"
+)
+
+// ErrInvalidImport is returned for transcripts that can't be imported
+var ErrInvalidImport = errors.New("invalid transcript")
+
+// voiceTag matches the WebVTT voice tags Teams puts around the text of a speaker
+var voiceTag = regexp.MustCompile(`^<v(?:\.[\w.-]+)?\s+([^>]+)>(.*?)(?:</v>)?$`)
+
+// speakerPrefix matches the "Name: text" lines of Zoom transcripts
+var speakerPrefix = regexp.MustCompile(`^([^:]{1,40}):\s+(.+)$`)
+
+// ImportOptions describes a meeting recorded elsewhere whose transcript is imported
+type ImportOptions struct {
+	Meeting  RecordingOptions // Title, participants, tags, metadata, owner, template, type and series
+	Format   string           // One of the import formats, empty detects it from the file
+	FileName string           // Name of the uploaded file, for the format and the default title
+	Date     time.Time        // When the meeting took place, zero uses now
+}
+
+// ImportTranscript creates a meeting from the transcript of a meeting recorded elsewhere and
+// queues the summarize and vault stages for it, so historical meetings end up in the vault
+// like recorded ones. Without participants, the speakers of the transcript are used.
+func (t *TranscriberService) ImportTranscript(opts ImportOptions, data []byte) (*types.Meeting, error) {
+	format := opts.Format
+	if format == "" {
+		format = detectImportFormat(opts.FileName, data)
+	}
+	segments, err := parseImport(format, data)
+	if err != nil {
+		return nil, err
+	}
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("%w: no segments found", ErrInvalidImport)
+	}
+
+	meetingOpts := opts.Meeting
+	if meetingOpts.Title == "" {
+		meetingOpts.Title = strings.TrimSuffix(filepath.Base(opts.FileName), filepath.Ext(opts.FileName))
+	}
+	if meetingOpts.Title == "" || meetingOpts.Title == "." {
+		meetingOpts.Title = "Imported Meeting"
+	}
+	if len(meetingOpts.Participants) == 0 {
+		meetingOpts.Participants = speakers(segments)
+	}
+	meetingOpts.Tags = cleanTags(meetingOpts.Tags)
+	vaultFolder, err := t.applyPreset(&meetingOpts)
+	if err != nil {
+		return nil, err
+	}
+	date := opts.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	meeting := &types.Meeting{
+		Id:            uuid.NewString(),
+		Title:         meetingOpts.Title,
+		CreatedAt:     date,
+		Start_time:    date,
+		Status:        string(types.MeetingStatusTranscriptCreated),
+		Participants:  meetingOpts.Participants,
+		Tags:          meetingOpts.Tags,
+		Metadata:      meetingOpts.Metadata,
+		Owner:         meetingOpts.Owner,
+		Template:      meetingOpts.Template,
+		Type:          meetingOpts.Type,
+		Series:        meetingOpts.Series,
+		VaultFolder:   vaultFolder,
+		Stages:        meetingOpts.Stages,
+		Duration:      int(segments[len(segments)-1].End),
+		Audio_devices: []types.AudioDevice{},
+		Segments:      segments,
+		ImportedFrom:  format,
+	}
+	meeting.Transcript = FormatTranscript(meeting, segments)
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeCreated)
+
+	t.logger.Info("Imported transcript", "meetingId", meeting.Id, "format", format, "segments", len(segments))
+	t.queue.Enqueue(meeting.Id, tagPriority(t.config.Processing.TagPriorities, meeting.Tags), func() {
+		t.summarizeAndPublish(t.ctx, meeting)
+	})
+	return meeting, nil
+}
+
+// detectImportFormat guesses the format of a transcript from its file name and content
+func detectImportFormat(fileName string, data []byte) string {
+	content := bytes.TrimSpace(bytes.TrimPrefix(data, []byte("\ufeff")))
+	switch {
+	case strings.EqualFold(filepath.Ext(fileName), ".json") || bytes.HasPrefix(content, []byte("{")):
+		return ImportFormatJSON
+	case strings.EqualFold(filepath.Ext(fileName), ".vtt") || bytes.HasPrefix(content, []byte("WEBVTT")):
+		return ImportFormatVTT
+	default:
+		return ImportFormatSRT
+	}
+}
+
+// parseImport parses a transcript in the format into segments
+func parseImport(format string, data []byte) ([]types.Segment, error) {
+	switch format {
+	case ImportFormatJSON:
+		var transcript externalTranscript
+		if err := json.Unmarshal(data, &transcript); err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		return transcript.segments(), nil
+	case ImportFormatSRT, ImportFormatVTT, ImportFormatZoom, ImportFormatTeams:
+		data = bytes.ReplaceAll(bytes.TrimPrefix(data, []byte("\ufeff")), []byte("\r\n"), []byte("\n"))
+		segments, err := parseCues(bytes.NewReader(data))
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidImport, err)
+		}
+		return cueSpeakers(segments, format), nil
+	default:
+		return nil, fmt.Errorf("%w: unknown format %q, use %s, %s, %s, %s or %s", ErrInvalidImport, format,
+			ImportFormatSRT, ImportFormatVTT, ImportFormatZoom, ImportFormatTeams, ImportFormatJSON)
+	}
+}
+
+// cueSpeakers moves the speakers of WebVTT cues from the text to the segments: voice tags,
+// and "Name:" prefixes for Zoom transcripts or WebVTT files where every cue has one
+func cueSpeakers(segments []types.Segment, format string) []types.Segment {
+	prefixed := 0
+	for i := range segments {
+		if match := voiceTag.FindStringSubmatch(segments[i].Text); match != nil {
+			segments[i].Speaker = strings.TrimSpace(match[1])
+			segments[i].Text = strings.TrimSpace(match[2])
+		}
+		if speakerPrefix.MatchString(segments[i].Text) {
+			prefixed++
+		}
+	}
+
+	if format == ImportFormatZoom || (format == ImportFormatVTT && prefixed == len(segments)) {
+		for i := range segments {
+			if match := speakerPrefix.FindStringSubmatch(segments[i].Text); match != nil && segments[i].Speaker == "" {
+				segments[i].Speaker = strings.TrimSpace(match[1])
+				segments[i].Text = strings.TrimSpace(match[2])
+			}
+		}
+	}
+	return segments
+}
+
+// speakers returns the speakers of the segments in the order they first speak
+func speakers(segments []types.Segment) []string {
+	var names []string
+	for _, segment := range segments {
+		if segment.Speaker != "" && !containsString(names, segment.Speaker) {
+			names = append(names, segment.Speaker)
+		}
+	}
+	return names
+}
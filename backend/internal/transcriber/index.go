@@ -0,0 +1,77 @@
+package transcriber
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// hasNote reports whether the meeting has been published to the vault
+func hasNote(meeting *types.Meeting) bool {
+	status := types.MeetingStatus(meeting.Status)
+	return status == types.MeetingStatusCompleted || status == types.MeetingStatusTranscriptOnly
+}
+
+// updateIndex rewrites the index note listing every published meeting. saved is the meeting
+// that was just written to the vault, which may not be marked as published yet.
+func (t *TranscriberService) updateIndex(saved *types.Meeting) {
+	cfg := t.config.Vault.Index
+	if !cfg.Enabled {
+		return
+	}
+
+	t.indexMu.Lock()
+	defer t.indexMu.Unlock()
+
+	meetings := []*types.Meeting{saved}
+	for _, meeting := range t.GetAllMeetings() {
+		if meeting.Id != saved.Id && hasNote(meeting) {
+			meetings = append(meetings, meeting)
+		}
+	}
+	sort.Slice(meetings, func(i, j int) bool {
+		return meetings[i].CreatedAt.After(meetings[j].CreatedAt)
+	})
+
+	names := t.noteNames(saved)
+	var b strings.Builder
+	b.WriteString("# Meetings\n\n")
+	b.WriteString("_Generated by the transcriber, changes are overwritten._\n")
+	month := ""
+	for _, meeting := range meetings {
+		if m := meeting.CreatedAt.Format("January 2006"); m != month {
+			month = m
+			b.WriteString(fmt.Sprintf("\n## %s\n\n", month))
+		}
+		b.WriteString("- " + indexEntry(meeting, names[meeting.Id]) + "\n")
+	}
+
+	if err := osoperations.WriteVaultNote(cfg.Path, b.String()); err != nil {
+		t.logger.Error("Failed to update meetings index", "error", err, "path", cfg.Path)
+	}
+}
+
+// indexEntry renders the index line of a meeting: date, link to its note, duration and tags
+func indexEntry(meeting *types.Meeting, noteName string) string {
+	title := strings.NewReplacer("[", "(", "]", ")", "|", "-").Replace(meeting.Title)
+	link := title
+	if noteName != "" {
+		if notePath, err := osoperations.MeetingNotePath(meeting, noteName); err == nil {
+			link = fmt.Sprintf("[[%s|%s]]", strings.TrimSuffix(filepath.ToSlash(notePath), ".md"), title)
+		}
+	}
+
+	entry := meeting.CreatedAt.Format("2006-01-02") + " " + link
+	if seconds := meetingDuration(meeting); seconds > 0 {
+		entry += fmt.Sprintf(" (%d min)", (seconds+30)/60)
+	}
+	for _, tag := range meeting.Tags {
+		// Obsidian tags can't contain spaces
+		entry += " #" + strings.ReplaceAll(tag, " ", "-")
+	}
+	return entry
+}
@@ -0,0 +1,89 @@
+package transcriber
+
+import (
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/meetingapps"
+	"github.com/martijnspitter/transcriber/internal/notify"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// meetingAppMetadataKey stores the meeting app whose call started a recording
+const meetingAppMetadataKey = "meeting_app"
+
+// MeetingApps returns the meeting app detector, or nil when detection is disabled
+func (t *TranscriberService) MeetingApps() *meetingapps.Detector {
+	return t.meetingApps
+}
+
+// watchMeetingApps checks for calls in meeting apps. A call starting while nothing is being
+// recorded shows a notification or, when enabled, starts a recording that is stopped when
+// the app leaves the call.
+func (t *TranscriberService) watchMeetingApps() {
+	cfg := t.config.MeetingApps
+	interval := time.Duration(cfg.Interval) * time.Second
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+
+	var recording *types.Meeting // Meeting started for a call
+	var recordingApp string
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for ; t.ctx.Err() == nil; t.nextTick(ticker) {
+		started, ended, err := t.meetingApps.Check()
+		if err != nil {
+			t.logger.Error("Failed to check meeting apps", "error", err)
+			continue
+		}
+
+		for _, app := range ended {
+			t.logger.Info("Call ended", "app", app)
+			if recording == nil || app != recordingApp {
+				continue
+			}
+			if t.isRecording(recording) {
+				t.logger.Info("Call ended, stopping recording", "meetingId", recording.Id, "app", app)
+				if err := t.StopMeeting(recording.Id); err != nil {
+					t.logger.Error("Failed to stop recording of call", "error", err, "meetingId", recording.Id)
+				}
+			}
+			recording = nil
+		}
+
+		for _, app := range started {
+			t.logger.Info("Call started", "app", app)
+			if t.recordingActive() {
+				continue
+			}
+
+			if !cfg.AutoStart {
+				if cfg.Notify {
+					if err := notify.Send("Call started in "+app, "Start a recording to transcribe this meeting"); err != nil {
+						t.logger.Error("Failed to notify about call", "error", err, "app", app)
+					}
+				}
+				continue
+			}
+			opts := RecordingOptions{
+				Metadata: map[string]string{meetingAppMetadataKey: app},
+			}
+			if t.config.Audio.AppCapture.MeetingApps {
+				if _, err := t.appTap(app); err != nil {
+					t.logger.Info("Can't record the audio of the app only, recording all system audio", "app", app, "error", err)
+				} else {
+					opts.App = app
+				}
+			}
+			meetingId, err := t.StartRecording(opts)
+			if err != nil {
+				t.logger.Error("Failed to start recording of call", "error", err, "app", app)
+				continue
+			}
+			recording, _ = t.GetMeetingStatus(meetingId)
+			recordingApp = app
+			t.logger.Info("Started recording of call", "meetingId", meetingId, "app", app)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Metadata keys linking a merged meeting and the meetings it was made of
+const (
+	mergedFromMetadataKey = "merged_from" // Comma separated IDs of the merged meetings, in order
+	mergedIntoMetadataKey = "merged_into" // ID of the meeting an archived meeting was merged into
+)
+
+// ErrInvalidMerge is returned for meetings that can't be merged
+var ErrInvalidMerge = errors.New("invalid merge")
+
+// MergeMeetings joins two meetings into a new one, e.g. a recording that was stopped by
+// accident and the one started for the rest of the meeting. The earlier meeting comes first:
+// the segments, bookmarks and notes of the later one are moved by the length of the earlier
+// recording, and the recordings are concatenated when both were kept. The merged meeting is
+// summarized and published like a new recording, and the originals are archived. With
+// versions, the merge is rejected when either meeting changed since. Meetings that are being
+// recorded or processed can't be merged.
+func (t *TranscriberService) MergeMeetings(firstId string, secondId string, firstVersion *int64, secondVersion *int64) (*types.Meeting, error) {
+	if firstId == secondId {
+		return nil, fmt.Errorf("%w: a meeting can't be merged with itself", ErrInvalidMerge)
+	}
+
+	// Both meetings are archived like an edit, so no edit, archive or other merge changes
+	// them between the checks and the archiving
+	t.editMu.Lock()
+	defer t.editMu.Unlock()
+	var originals []*types.Meeting
+	versions := []*int64{firstVersion, secondVersion}
+	for i, meetingId := range []string{firstId, secondId} {
+		meeting, err := t.GetMeetingStatus(meetingId)
+		if err != nil {
+			return nil, err
+		}
+		t.mu.RLock()
+		status, busy := meeting.Status, !editable(meeting)
+		t.mu.RUnlock()
+		if busy {
+			return nil, fmt.Errorf("%w: meeting %s is %s", ErrMeetingBusy, meetingId, status)
+		}
+		if err := checkVersion(meeting, versions[i]); err != nil {
+			return nil, err
+		}
+		if meeting.TrashedAt != nil {
+			return nil, fmt.Errorf("%w: %s", ErrInTrash, meetingId)
+		}
+		if len(meeting.Segments) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoTranscript, meetingId)
+		}
+		originals = append(originals, meeting)
+	}
+	sort.Slice(originals, func(i, j int) bool {
+		return originals[i].Start_time.Before(originals[j].Start_time)
+	})
+	first, second := originals[0], originals[1]
+
+	mergedId := uuid.NewString()
+	dir, err := t.artifacts.Create(mergedId, "merge")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create merge directory: %w", err)
+	}
+	var sources []string
+	var warnings []string
+	for _, meeting := range originals {
+		source, err := t.recordingSource(meeting, dir)
+		if err != nil {
+			t.artifacts.Release(dir)
+			return nil, fmt.Errorf("failed to read the recording of %s: %w", meeting.Id, err)
+		}
+		if source == "" {
+			warnings = append(warnings, fmt.Sprintf("the recording of %q wasn't kept, the merged meeting has no audio", meeting.Title))
+			continue
+		}
+		sources = append(sources, source)
+	}
+
+	// The later meeting starts where the earlier recording ends
+	offset := float64(first.Duration)
+	if len(sources) == len(originals) {
+		if duration, err := audiocapture.TrackDuration(sources[0]); err == nil {
+			offset = duration.Seconds()
+		}
+	}
+	offset = max(offset, first.Segments[len(first.Segments)-1].End)
+
+	merged := mergedMeeting(mergedId, first, second, offset)
+	merged.Warnings = append(merged.Warnings, warnings...)
+	if len(sources) == len(originals) {
+		merged.Transcript_path = freePath(merged, osoperations.CreateFilePath(t.recordDir, t.recordingFileName(merged)), "")
+	}
+	merged.Transcript = FormatTranscript(merged, merged.Segments)
+	t.setMeeting(merged)
+	t.recordEvent(merged, events.TypeCreated)
+
+	now := time.Now()
+	for _, meeting := range originals {
+		// Requests may be reading the meeting meanwhile
+		t.mu.Lock()
+		meeting.Metadata = maps.Clone(meeting.Metadata)
+		if meeting.Metadata == nil {
+			meeting.Metadata = make(map[string]string)
+		}
+		meeting.Metadata[mergedIntoMetadataKey] = merged.Id
+		if meeting.ArchivedAt == nil {
+			meeting.ArchivedAt = &now
+		}
+		t.mu.Unlock()
+		t.setMeeting(meeting)
+		t.recordEvent(meeting, events.TypeMeetingArchived)
+	}
+
+	t.logger.Info("Merged meetings", "meetingId", merged.Id, "first", first.Id, "second", second.Id, "offset", offset, "audio", merged.Transcript_path != "")
+	t.queue.Enqueue(merged.Id, tagPriority(t.config.Processing.TagPriorities, merged.Tags), func() {
+		defer t.artifacts.Release(dir)
+		defer t.removeRecording(merged)
+
+		if merged.Transcript_path != "" {
+			if err := t.transcoder.Concat(t.ctx, sources, merged.Transcript_path); err != nil {
+				t.logger.Error("Failed to concatenate recordings of merged meetings", "error", err, "meetingId", merged.Id)
+				merged.Warnings = append(merged.Warnings, "the recordings could not be concatenated, the merged meeting has no audio")
+				merged.Transcript_path = ""
+				t.setMeeting(merged)
+				t.recordEvent(merged, events.TypeWarning)
+			} else {
+				t.archiveRecording(t.ctx, merged)
+			}
+		}
+		t.summarizeAndPublish(t.ctx, merged)
+		if merged.Transcript_path != "" {
+			t.exportMeeting(merged)
+			t.createHighlights(t.ctx, merged)
+		}
+	})
+	return merged, nil
+}
+
+// mergedMeeting returns a transcribed meeting with the ID made of the first meeting followed,
+// offset seconds later, by the second. Its details are those of the first meeting, with the
+// participants, tags and metadata of both.
+func mergedMeeting(id string, first *types.Meeting, second *types.Meeting, offset float64) *types.Meeting {
+	metadata := maps.Clone(second.Metadata)
+	if metadata == nil {
+		metadata = make(map[string]string)
+	}
+	maps.Copy(metadata, first.Metadata)
+	delete(metadata, mergedIntoMetadataKey)
+	metadata[mergedFromMetadataKey] = strings.Join([]string{first.Id, second.Id}, ",")
+
+	merged := &types.Meeting{
+		Id:            id,
+		Title:         first.Title,
+		Series:        first.Series,
+		Type:          first.Type,
+		Status:        string(types.MeetingStatusTranscriptCreated),
+		CreatedAt:     first.CreatedAt,
+		Start_time:    first.Start_time,
+		Participants:  mergeUnique(first.Participants, second.Participants),
+		Owner:         first.Owner,
+		Tags:          mergeUnique(first.Tags, second.Tags),
+		Metadata:      metadata,
+		Template:      first.Template,
+		VaultFolder:   first.VaultFolder,
+		Stages:        first.Stages,
+		Duration:      int(offset) + second.Duration,
+		Audio_devices: first.Audio_devices,
+		Segments:      append([]types.Segment{}, first.Segments...),
+		Bookmarks:     append([]types.Bookmark{}, first.Bookmarks...),
+		Notes:         append([]types.Note{}, first.Notes...),
+		Warnings:      append(append([]string{}, first.Warnings...), second.Warnings...),
+	}
+	for _, segment := range second.Segments {
+		segment.Start += offset
+		segment.End += offset
+		merged.Segments = append(merged.Segments, segment)
+	}
+	for _, bookmark := range second.Bookmarks {
+		bookmark.At += offset
+		merged.Bookmarks = append(merged.Bookmarks, bookmark)
+	}
+	for _, note := range second.Notes {
+		note.At += offset
+		merged.Notes = append(merged.Notes, note)
+	}
+	return merged
+}
+
+// recordingSource returns a file with the recording of a meeting: the recording itself while
+// it is kept, or else its archive, decrypted into dir and downloaded when it was only kept in
+// object storage. It returns an empty path when neither is left.
+func (t *TranscriberService) recordingSource(meeting *types.Meeting, dir string) (string, error) {
+	if info, err := os.Stat(meeting.Transcript_path); err == nil && info.Size() > 0 {
+		return meeting.Transcript_path, nil
+	}
+	if meeting.ArchivePath == "" && meeting.ArchiveURL != "" && t.objects != nil {
+		return t.downloadArchive(t.ctx, meeting, dir)
+	}
+	if meeting.ArchivePath == "" {
+		return "", nil
+	}
+	data, err := t.ReadStoredFile(meeting.ArchivePath)
+	if os.IsNotExist(err) {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, meeting.Id+filepath.Ext(meeting.ArchivePath))
+	return path, os.WriteFile(path, data, 0600)
+}
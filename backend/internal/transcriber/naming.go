@@ -0,0 +1,257 @@
+package transcriber
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/frontmatter"
+	"github.com/martijnspitter/transcriber/internal/naming"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// saveToVault writes the meeting note under the name given by the note name template and
+// updates the meetings index
+func (t *TranscriberService) saveToVault(meeting *types.Meeting) error {
+	noteName, err := t.noteName(meeting)
+	if err != nil {
+		return err
+	}
+	if err := osoperations.SaveMeetingToVault(meeting, noteName, frontmatter.Render(meeting, t.config.Frontmatter, t.personLink), t.noteTranscript(meeting)); err != nil {
+		return err
+	}
+	t.updateIndex(meeting)
+	return nil
+}
+
+// resaveToVault rewrites the note of a changed meeting. When the change gave the note another
+// path than previousPath, where it was before, the old note is removed so the vault doesn't
+// keep a stale copy.
+func (t *TranscriberService) resaveToVault(meeting *types.Meeting, previousPath string) error {
+	if err := t.saveToVault(meeting); err != nil {
+		return err
+	}
+	notePath, err := t.notePath(meeting)
+	if err != nil || previousPath == "" || notePath == previousPath {
+		return nil
+	}
+	if err := osoperations.RemoveVaultNote(previousPath); err != nil {
+		t.logger.Error("Failed to remove previous vault note", "error", err, "meetingId", meeting.Id, "note", previousPath)
+		return nil
+	}
+	t.logger.Info("Moved vault note", "meetingId", meeting.Id, "from", previousPath, "to", notePath)
+	return nil
+}
+
+// noteName returns the name of the meeting note, see noteNames
+func (t *TranscriberService) noteName(meeting *types.Meeting) (string, error) {
+	if _, err := t.naming.NoteName(meeting); err != nil {
+		return "", err
+	}
+	return t.noteNames(meeting)[meeting.Id], nil
+}
+
+// notePath returns the path of the meeting note relative to the vault root
+func (t *TranscriberService) notePath(meeting *types.Meeting) (string, error) {
+	noteName, err := t.noteName(meeting)
+	if err != nil {
+		return "", err
+	}
+	return osoperations.MeetingNotePath(meeting, noteName)
+}
+
+// noteNames returns the note names of all meetings by ID, taking the given meetings instead
+// of the stored ones with the same ID. Names come from the note name template; when the
+// notes of several meetings would have the same path, the earliest meeting keeps the name
+// and the others get the start of their ID appended, so they don't overwrite each other.
+// Meetings whose name can't be rendered are left out.
+func (t *TranscriberService) noteNames(meetings ...*types.Meeting) map[string]string {
+	given := make(map[string]*types.Meeting, len(meetings))
+	for _, meeting := range meetings {
+		given[meeting.Id] = meeting
+	}
+
+	names := make(map[string]string)
+	byPath := make(map[string][]*types.Meeting)
+	add := func(meeting *types.Meeting) {
+		name, err := t.naming.NoteName(meeting)
+		if err != nil {
+			return
+		}
+		notePath, err := osoperations.MeetingNotePath(meeting, name)
+		if err != nil {
+			return
+		}
+		names[meeting.Id] = name
+		// Obsidian and the default macOS file system don't tell names apart by case
+		key := strings.ToLower(notePath)
+		byPath[key] = append(byPath[key], meeting)
+	}
+
+	t.mu.RLock()
+	for id, meeting := range t.meetings {
+		if replacement, ok := given[id]; ok {
+			meeting = replacement
+			delete(given, id)
+		}
+		add(meeting)
+	}
+	t.mu.RUnlock()
+	for _, meeting := range given {
+		add(meeting)
+	}
+
+	for _, sharing := range byPath {
+		if len(sharing) < 2 {
+			continue
+		}
+		sort.Slice(sharing, func(i, j int) bool {
+			if !sharing[i].CreatedAt.Equal(sharing[j].CreatedAt) {
+				return sharing[i].CreatedAt.Before(sharing[j].CreatedAt)
+			}
+			return sharing[i].Id < sharing[j].Id
+		})
+		for _, meeting := range sharing[1:] {
+			names[meeting.Id] += " " + shortId(meeting.Id)
+		}
+	}
+	return names
+}
+
+// shortId returns the first characters of a meeting ID, enough to tell meetings apart
+func shortId(id string) string {
+	if len(id) > 8 {
+		return id[:8]
+	}
+	return id
+}
+
+// recordingFileName returns the file name of a new recording, falling back to the default
+// name when the recording name template can't be rendered for the meeting
+func (t *TranscriberService) recordingFileName(meeting *types.Meeting) string {
+	name, err := t.naming.RecordingName(meeting)
+	if err != nil {
+		t.logger.Error("Failed to render recording name, using default", "error", err, "meetingId", meeting.Id)
+		return osoperations.FormatFileName("recording", meeting.CreatedAt, ".wav")
+	}
+	return name + ".wav"
+}
+
+// PathPreviewOptions describes a sample meeting and optionally the naming templates to try
+// instead of the configured ones
+type PathPreviewOptions struct {
+	Title        string
+	Type         string
+	Participants []string
+	Tags         []string
+	CreatedAt    time.Time
+	Naming       *config.NamingConfig // Fields left empty use the configured template
+}
+
+// PathPreview lists the names and paths generated for a sample meeting
+type PathPreview struct {
+	NoteName      string              `json:"note_name"`
+	NotePath      string              `json:"note_path"`  // Relative to the vault root
+	VaultPath     string              `json:"vault_path"` // Absolute path of the note
+	Wikilink      string              `json:"wikilink"`
+	RecordingName string              `json:"recording_name"`
+	RecordingPath string              `json:"recording_path"`
+	PersonNotes   []PersonNote        `json:"person_notes,omitempty"` // Notes of the participants, created for those not in the people directory
+	Templates     config.NamingConfig `json:"templates"`
+}
+
+// PersonNote is the note of a participant
+type PersonNote struct {
+	Name     string `json:"name"`
+	NotePath string `json:"note_path,omitempty"`
+	Known    bool   `json:"known"`           // Already in the people directory, so no note is created
+	Error    string `json:"error,omitempty"` // Why no note can be created for the name
+}
+
+// PreviewPaths renders the naming templates for a sample meeting without saving anything
+func (t *TranscriberService) PreviewPaths(opts PathPreviewOptions) (*PathPreview, error) {
+	templates := t.config.Naming
+	if opts.Naming != nil {
+		if opts.Naming.Note != "" {
+			templates.Note = opts.Naming.Note
+		}
+		if opts.Naming.Recording != "" {
+			templates.Recording = opts.Naming.Recording
+		}
+	}
+	names, err := naming.New(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.Title == "" {
+		opts.Title = "New Meeting"
+	}
+	if opts.CreatedAt.IsZero() {
+		opts.CreatedAt = time.Now()
+	}
+	meeting := &types.Meeting{
+		Id:           "00000000-0000-0000-0000-000000000000",
+		Title:        opts.Title,
+		Type:         opts.Type,
+		CreatedAt:    opts.CreatedAt,
+		Participants: opts.Participants,
+		Tags:         opts.Tags,
+	}
+	if opts.Type != "" {
+		preset, ok := t.config.Presets[opts.Type]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownPreset, opts.Type)
+		}
+		meeting.VaultFolder = preset.VaultFolder
+		meeting.Participants = mergeUnique(preset.Participants, meeting.Participants)
+		meeting.Tags = mergeUnique(preset.Tags, meeting.Tags)
+	}
+
+	noteName, err := names.NoteName(meeting)
+	if err != nil {
+		return nil, err
+	}
+	notePath, err := osoperations.MeetingNotePath(meeting, noteName)
+	if err != nil {
+		return nil, err
+	}
+	vaultDir, err := osoperations.VaultDir()
+	if err != nil {
+		return nil, err
+	}
+	recordingName, err := names.RecordingName(meeting)
+	if err != nil {
+		return nil, err
+	}
+
+	preview := &PathPreview{
+		NoteName:      noteName,
+		NotePath:      notePath,
+		VaultPath:     filepath.Join(vaultDir, notePath),
+		Wikilink:      "[[" + noteName + "]]",
+		RecordingName: recordingName + ".wav",
+		RecordingPath: osoperations.CreateFilePath(t.recordDir, recordingName+".wav"),
+		Templates:     templates,
+	}
+	for _, participant := range meeting.Participants {
+		if person, ok := t.people.Resolve(participant); ok {
+			preview.PersonNotes = append(preview.PersonNotes, PersonNote{Name: person.Name, NotePath: person.NotePath, Known: true})
+			continue
+		}
+		if t.config.People.CreateNotes {
+			note := PersonNote{Name: participant}
+			if notePath, err := t.personNotePath(participant); err != nil {
+				note.Error = err.Error()
+			} else {
+				note.NotePath = notePath
+			}
+			preview.PersonNotes = append(preview.PersonNotes, note)
+		}
+	}
+	return preview, nil
+}
@@ -0,0 +1,56 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrEmptyNote is returned when a note without text is added to a meeting
+var ErrEmptyNote = errors.New("note text cannot be empty")
+
+// AddNote appends a note, timestamped with the current moment of the recording, to the
+// meeting being recorded. The notes are given to the summarizer and listed in the note. With
+// a version, the note is rejected when the meeting changed since that version.
+func (t *TranscriberService) AddNote(meetingId string, text string, version *int64) (types.Note, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return types.Note{}, ErrEmptyNote
+	}
+	t.editMu.Lock()
+	defer t.editMu.Unlock()
+	meeting, _ := t.activeRecording()
+	if meeting == nil || meeting.Id != meetingId {
+		return types.Note{}, fmt.Errorf("meeting %s is not being recorded", meetingId)
+	}
+	if err := checkVersion(meeting, version); err != nil {
+		return types.Note{}, err
+	}
+
+	note := types.Note{
+		At:   time.Since(meeting.Start_time).Seconds(),
+		Text: text,
+	}
+	meeting.Notes = append(meeting.Notes, note)
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeNoteAdded)
+	t.logger.Info("Added note to recording", "meetingId", meeting.Id, "at", note.At)
+	return note, nil
+}
+
+// notesPrompt lists the notes taken while recording as context for the summary, or returns
+// nothing when none were taken
+func notesPrompt(meeting *types.Meeting) string {
+	if len(meeting.Notes) == 0 {
+		return ""
+	}
+	var lines []string
+	for _, note := range meeting.Notes {
+		lines = append(lines, fmt.Sprintf("- [%s] %s", FormatTimestamp(note.At), note.Text))
+	}
+	return "\n\nNotes I took during the meeting, at the time in the transcript. Use them as context and make sure the summary covers what they point out:\n" + strings.Join(lines, "\n")
+}
@@ -0,0 +1,122 @@
+package transcriber
+
+import (
+	"context"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// archiveContentTypes are the content types of archived recordings by codec
+var archiveContentTypes = map[string]string{
+	audiocapture.CodecOpus: "audio/ogg",
+	audiocapture.CodecAAC:  "audio/mp4",
+	audiocapture.CodecFLAC: "audio/flac",
+}
+
+// uploadMeeting uploads the archived recording and the transcript of a processed meeting to
+// object storage, when configured, and records their URLs. Files that are encrypted at rest
+// are uploaded encrypted. With delete_local the local archive is removed once it is uploaded,
+// unless a vault attachment links to it. Failures are logged and the meeting is kept as it is.
+func (t *TranscriberService) uploadMeeting(ctx context.Context, meeting *types.Meeting) {
+	if t.objects == nil || meeting.Transcript == "" {
+		return
+	}
+	cfg := t.config.Storage.ObjectStorage
+	metadata := map[string]string{
+		"meeting-id":  meeting.Id,
+		"recorded-at": meeting.CreatedAt.UTC().Format(time.RFC3339),
+		"encrypted":   strconv.FormatBool(t.cipher != nil),
+	}
+	if meeting.Type != "" {
+		metadata["meeting-type"] = meeting.Type
+	}
+	if cfg.RetentionDays > 0 {
+		metadata["retain-until"] = meeting.CreatedAt.AddDate(0, 0, cfg.RetentionDays).UTC().Format(time.DateOnly)
+	}
+
+	if meeting.ArchivePath != "" && meeting.ArchiveURL == "" {
+		contentType := archiveContentTypes[meeting.ArchiveCodec]
+		if t.cipher != nil || contentType == "" {
+			contentType = "application/octet-stream"
+		}
+		key := t.objects.Key(meeting.Id, meeting.CreatedAt, "recording"+filepath.Ext(meeting.ArchivePath))
+		url, err := t.objects.Put(ctx, key, meeting.ArchivePath, contentType, metadata)
+		if err != nil {
+			t.logger.Error("Failed to upload archived recording", "error", err, "meetingId", meeting.Id)
+			return
+		}
+		meeting.ArchiveURL = url
+	}
+
+	dir, err := t.artifacts.Create(meeting.Id, "upload")
+	if err != nil {
+		t.logger.Error("Failed to create upload directory", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	defer t.artifacts.Release(dir)
+	transcript, err := t.cipher.Seal([]byte(meeting.Transcript))
+	if err == nil {
+		err = os.WriteFile(filepath.Join(dir, "transcript.md"), transcript, 0600)
+	}
+	if err == nil {
+		contentType := "text/markdown; charset=utf-8"
+		if t.cipher != nil {
+			contentType = "application/octet-stream"
+		}
+		meeting.TranscriptURL, err = t.objects.Put(ctx, t.objects.Key(meeting.Id, meeting.CreatedAt, "transcript.md"), filepath.Join(dir, "transcript.md"), contentType, metadata)
+	}
+	if err != nil {
+		t.logger.Error("Failed to upload transcript", "error", err, "meetingId", meeting.Id)
+	}
+
+	if cfg.DeleteLocal && meeting.ArchiveURL != "" && meeting.ArchivePath != "" {
+		if t.config.Audio.VaultAttachment.Mode == config.AttachmentModeSymlink && t.cipher == nil {
+			t.logger.Info("Keeping the local archive, the vault attachment links to it", "meetingId", meeting.Id)
+		} else if err := os.Remove(meeting.ArchivePath); err != nil && !os.IsNotExist(err) {
+			t.logger.Error("Failed to remove uploaded archive", "error", err, "meetingId", meeting.Id)
+		} else {
+			meeting.ArchivePath = ""
+		}
+	}
+
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeArchived)
+	t.logger.Info("Uploaded meeting to object storage", "meetingId", meeting.Id, "recording", meeting.ArchiveURL, "transcript", meeting.TranscriptURL)
+}
+
+// downloadArchive downloads the archived recording of a meeting from object storage into dir
+// and returns its path, decrypted
+func (t *TranscriberService) downloadArchive(ctx context.Context, meeting *types.Meeting, dir string) (string, error) {
+	data, err := t.objects.Get(ctx, meeting.ArchiveURL)
+	if err != nil {
+		return "", err
+	}
+	if data, err = t.cipher.Open(data); err != nil {
+		return "", err
+	}
+	file := filepath.Join(dir, meeting.Id+path.Ext(meeting.ArchiveURL))
+	return file, os.WriteFile(file, data, 0600)
+}
+
+// removeUploads deletes the objects of a purged meeting from object storage
+func (t *TranscriberService) removeUploads(ctx context.Context, meeting *types.Meeting) {
+	if t.objects == nil {
+		return
+	}
+	for _, url := range []string{meeting.ArchiveURL, meeting.TranscriptURL} {
+		if url == "" {
+			continue
+		}
+		if err := t.objects.Delete(ctx, url); err != nil {
+			t.logger.Error("Failed to delete uploaded file of purged meeting", "error", err, "meetingId", meeting.Id, "url", url)
+		}
+	}
+}
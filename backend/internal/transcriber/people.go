@@ -0,0 +1,165 @@
+package transcriber
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/people"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// wikilinkPattern matches [[target]], [[target|display]] and [[target#heading]] links
+var wikilinkPattern = regexp.MustCompile(`\[\[([^\[\]|#]+)((?:[#|][^\[\]]*)?)\]\]`)
+
+// personNoteTemplate is the content of person notes created for new participants
+const personNoteTemplate = `---
+tags:
+  - person
+aliases: []
+---
+
+# %s
+`
+
+// People returns the directory of known people
+func (t *TranscriberService) People() *people.Store {
+	return t.people
+}
+
+// normalizeParticipants replaces participant names and speakers that are aliases of a known
+// person with the person's name, and rebuilds the transcript when anything changed
+func (t *TranscriberService) normalizeParticipants(meeting *types.Meeting) {
+	changed := false
+	canonical := func(name string) string {
+		if person, ok := t.people.Resolve(name); ok && person.Name != name {
+			changed = true
+			return person.Name
+		}
+		return name
+	}
+
+	participants := make([]string, 0, len(meeting.Participants))
+	for _, participant := range meeting.Participants {
+		participant = canonical(participant)
+		if !containsString(participants, participant) {
+			participants = append(participants, participant)
+		}
+	}
+	for i := range meeting.Segments {
+		meeting.Segments[i].Speaker = canonical(meeting.Segments[i].Speaker)
+	}
+	if !changed {
+		return
+	}
+
+	meeting.Participants = participants
+	if len(meeting.Segments) > 0 {
+		meeting.Transcript = FormatTranscript(meeting, meeting.Segments)
+	}
+	t.logger.Info("Normalized participant names", "meetingId", meeting.Id)
+}
+
+// createPersonNotes adds participants that aren't known yet to the people directory and
+// creates a vault note for them, when enabled
+func (t *TranscriberService) createPersonNotes(meeting *types.Meeting) {
+	if !t.config.People.CreateNotes {
+		return
+	}
+
+	for _, participant := range meeting.Participants {
+		if _, ok := t.people.Resolve(participant); ok {
+			continue
+		}
+		notePath, err := t.personNotePath(participant)
+		if err != nil {
+			t.logger.Error("Participant name can't be used for a person note", "error", err, "meetingId", meeting.Id)
+			continue
+		}
+
+		person, err := t.people.Save(people.Person{
+			Name:     participant,
+			NotePath: notePath,
+		})
+		if err != nil {
+			t.logger.Error("Failed to add participant to people directory", "error", err, "meetingId", meeting.Id, "name", participant)
+			continue
+		}
+
+		created, err := osoperations.CreateVaultNote(person.NotePath, fmt.Sprintf(personNoteTemplate, person.Name))
+		if err != nil {
+			t.logger.Error("Failed to create person note", "error", err, "meetingId", meeting.Id, "name", participant)
+			continue
+		}
+		t.logger.Info("Added new participant to people directory", "meetingId", meeting.Id, "name", participant, "noteCreated", created)
+	}
+}
+
+// personNotePath returns the path of the note created for a participant, relative to the
+// vault root, or an error when the name can't be used as a note name
+func (t *TranscriberService) personNotePath(name string) (string, error) {
+	if err := people.ValidateName(name); err != nil {
+		return "", err
+	}
+	notePath := filepath.Join(t.config.People.NotesFolder, name+".md")
+	if !filepath.IsLocal(notePath) {
+		return "", fmt.Errorf("person note %q must be a relative path inside the vault", notePath)
+	}
+	return notePath, nil
+}
+
+// peoplePrompt lists the known people mentioned in the meeting so the model spells and
+// links their names consistently, or returns nothing when none are mentioned
+func (t *TranscriberService) peoplePrompt(meeting *types.Meeting) string {
+	var lines []string
+	for _, person := range t.people.List() {
+		mentioned := false
+		for _, name := range append([]string{person.Name}, person.Aliases...) {
+			if containsString(meeting.Participants, name) || containsWord(meeting.Transcript, name) {
+				mentioned = true
+				break
+			}
+		}
+		if !mentioned {
+			continue
+		}
+
+		line := fmt.Sprintf("- %s: always write as %s", person.Name, person.Link())
+		if len(person.Aliases) > 0 {
+			line += fmt.Sprintf(" (also called %s)", strings.Join(person.Aliases, ", "))
+		}
+		lines = append(lines, line)
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	return "\n\nKnown people in this meeting. Use their full name and exact link, also when the transcript uses a nickname or misspelling:\n" + strings.Join(lines, "\n")
+}
+
+// personLink returns the wikilink to a participant, pointing at their person note when known
+func (t *TranscriberService) personLink(name string) string {
+	if person, ok := t.people.Resolve(name); ok {
+		return person.Link()
+	}
+	return "[[" + name + "]]"
+}
+
+// linkPeople rewrites wikilinks to aliases of known people into links to the person
+func (t *TranscriberService) linkPeople(summary string) string {
+	return wikilinkPattern.ReplaceAllStringFunc(summary, func(link string) string {
+		groups := wikilinkPattern.FindStringSubmatch(link)
+		person, ok := t.people.Resolve(groups[1])
+		if !ok {
+			return link
+		}
+		if strings.HasPrefix(groups[2], "|") {
+			// Keep a custom display text
+			target, _, _ := strings.Cut(person.LinkTarget(), "|")
+			return "[[" + target + groups[2] + "]]"
+		}
+		return person.Link()
+	})
+}
@@ -0,0 +1,156 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/artifacts"
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Errors of playing a recording
+var (
+	ErrNoRecording  = errors.New("meeting has no recording")
+	ErrInvalidCodec = errors.New("invalid codec")
+)
+
+// playbackTranscode is a transcode of a recording for playback, shared by the requests that
+// wait for it
+type playbackTranscode struct {
+	done chan struct{}
+	err  error
+}
+
+// PlaybackAudio returns the file to play the recording of a meeting from. Without a codec
+// that is the recording or its archive as stored, transcoded to the playback codec when it
+// is only kept in object storage. With a codec the recording is transcoded once and cached,
+// so players can seek in it with range requests. The transcode runs in the background and
+// goes on when ctx is done, so a later request finds it finished. The file may be encrypted,
+// read it with ReadStoredFile.
+func (t *TranscriberService) PlaybackAudio(ctx context.Context, meetingId string, codec string) (string, error) {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return "", err
+	}
+	if meeting.Status == string(types.MeetingStatusRecording) {
+		return "", fmt.Errorf("%w: meeting %s is being recorded", ErrMeetingBusy, meetingId)
+	}
+
+	if codec == "" {
+		for _, path := range []string{meeting.Transcript_path, meeting.ArchivePath} {
+			if info, err := os.Stat(path); path != "" && err == nil && info.Size() > 0 {
+				return path, nil
+			}
+		}
+		codec = t.config.Audio.Playback.Codec
+	}
+	extension, err := audiocapture.CodecExtension(codec)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidCodec, err)
+	}
+	if meeting.ArchiveCodec == codec && meeting.ArchivePath != "" {
+		if _, err := os.Stat(meeting.ArchivePath); err == nil {
+			return meeting.ArchivePath, nil
+		}
+	}
+
+	cached := filepath.Join(t.config.Audio.Playback.Dir, meeting.Id+"_"+codec+extension)
+	if _, err := os.Stat(cached); err == nil {
+		now := time.Now()
+		os.Chtimes(cached, now, now) // Keep played copies from being cleaned up
+		return cached, nil
+	}
+
+	// Requests for a recording that isn't cached yet wait for a single transcode of it
+	t.playbackMu.Lock()
+	job, running := t.playbackJobs[cached]
+	if !running {
+		job = &playbackTranscode{done: make(chan struct{})}
+		t.playbackJobs[cached] = job
+		go func() {
+			job.err = t.transcodePlayback(meeting, codec, extension, cached)
+			t.playbackMu.Lock()
+			delete(t.playbackJobs, cached)
+			t.playbackMu.Unlock()
+			close(job.done)
+		}()
+	}
+	t.playbackMu.Unlock()
+
+	select {
+	case <-job.done:
+		if job.err != nil {
+			return "", job.err
+		}
+		return cached, nil
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// transcodePlayback transcodes the recording of a meeting with the codec to the cached path
+func (t *TranscriberService) transcodePlayback(meeting *types.Meeting, codec string, extension string, cached string) error {
+	dir, err := t.artifacts.Create(meeting.Id, "playback")
+	if err != nil {
+		return err
+	}
+	defer t.artifacts.Release(dir)
+	source, err := t.recordingSource(meeting, dir)
+	if err != nil {
+		return fmt.Errorf("failed to read recording: %w", err)
+	}
+	if source == "" {
+		return fmt.Errorf("%w: %s", ErrNoRecording, meeting.Id)
+	}
+
+	transcoded := filepath.Join(dir, "playback"+extension)
+	if err := t.transcoder.Compress(t.ctx, source, transcoded, codec, ""); err != nil {
+		return err
+	}
+	if err := t.cipher.EncryptFile(transcoded); err != nil {
+		return fmt.Errorf("failed to encrypt transcoded recording: %w", err)
+	}
+	if err := os.MkdirAll(t.config.Audio.Playback.Dir, 0700); err != nil {
+		return fmt.Errorf("failed to create playback directory: %w", err)
+	}
+	if err := artifacts.Promote(transcoded, cached); err != nil {
+		return err
+	}
+	t.logger.Info("Transcoded recording for playback", "meetingId", meeting.Id, "codec", codec)
+	return nil
+}
+
+// removePlayback removes the transcoded copies of a meeting's recording
+func (t *TranscriberService) removePlayback(meetingId string) {
+	paths, _ := filepath.Glob(filepath.Join(t.config.Audio.Playback.Dir, meetingId+"_*"))
+	for _, path := range paths {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			t.logger.Error("Failed to remove transcoded recording", "error", err, "meetingId", meetingId, "file", path)
+		}
+	}
+}
+
+// removeStalePlayback removes the transcoded copies that weren't played for maxAge and
+// returns how many were removed
+func (t *TranscriberService) removeStalePlayback(maxAge time.Duration) int {
+	entries, err := os.ReadDir(t.config.Audio.Playback.Dir)
+	if err != nil {
+		return 0
+	}
+	removed := 0
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil || entry.IsDir() || time.Since(info.ModTime()) < maxAge {
+			continue
+		}
+		if os.Remove(filepath.Join(t.config.Audio.Playback.Dir, entry.Name())) == nil {
+			removed++
+		}
+	}
+	return removed
+}
@@ -0,0 +1,135 @@
+package transcriber
+
+import (
+	"container/heap"
+	"strings"
+	"sync"
+	"time"
+)
+
+// job is a unit of post-recording work processed by the job queue
+type job struct {
+	meetingId string
+	priority  int
+	sequence  uint64 // Insertion order, used as FIFO tie-breaker
+	run       func()
+}
+
+// jobHeap orders jobs by priority (highest first) and then by insertion order
+type jobHeap []*job
+
+func (h jobHeap) Len() int { return len(h) }
+
+func (h jobHeap) Less(i, j int) bool {
+	if h[i].priority != h[j].priority {
+		return h[i].priority > h[j].priority
+	}
+	return h[i].sequence < h[j].sequence
+}
+
+func (h jobHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *jobHeap) Push(x any) { *h = append(*h, x.(*job)) }
+
+func (h *jobHeap) Pop() any {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return item
+}
+
+// jobQueue runs jobs one at a time in priority order
+type jobQueue struct {
+	mu       sync.Mutex
+	cond     *sync.Cond
+	jobs     jobHeap
+	sequence uint64
+	busy     bool // A job is running
+	draining bool // No more jobs are started
+}
+
+func newJobQueue() *jobQueue {
+	q := &jobQueue{}
+	q.cond = sync.NewCond(&q.mu)
+	go q.work()
+	return q
+}
+
+// Enqueue adds a job to the queue
+func (q *jobQueue) Enqueue(meetingId string, priority int, run func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	q.sequence++
+	heap.Push(&q.jobs, &job{
+		meetingId: meetingId,
+		priority:  priority,
+		sequence:  q.sequence,
+		run:       run,
+	})
+	q.cond.Signal()
+}
+
+// Len returns the number of jobs waiting to be processed
+func (q *jobQueue) Len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.jobs)
+}
+
+// Drain stops starting jobs and waits up to the timeout for the running job to finish. It
+// reports whether the queue went idle; jobs still waiting are left unstarted.
+func (q *jobQueue) Drain(timeout time.Duration) bool {
+	q.mu.Lock()
+	q.draining = true
+	q.mu.Unlock()
+
+	deadline := time.Now().Add(timeout)
+	for {
+		q.mu.Lock()
+		busy := q.busy
+		q.mu.Unlock()
+		if !busy {
+			return true
+		}
+		if time.Now().After(deadline) {
+			return false
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// work processes jobs until the program exits or the queue is drained
+func (q *jobQueue) work() {
+	for {
+		q.mu.Lock()
+		for len(q.jobs) == 0 || q.draining {
+			q.cond.Wait()
+		}
+		next := heap.Pop(&q.jobs).(*job)
+		q.busy = true
+		q.mu.Unlock()
+
+		next.run()
+
+		q.mu.Lock()
+		q.busy = false
+		q.mu.Unlock()
+	}
+}
+
+// tagPriority returns the highest configured priority among the given tags.
+// Meetings without a prioritized tag get priority 0.
+func tagPriority(priorities map[string]int, tags []string) int {
+	priority, matched := 0, false
+	for _, tag := range tags {
+		for name, p := range priorities {
+			if strings.EqualFold(name, tag) && (!matched || p > priority) {
+				priority, matched = p, true
+			}
+		}
+	}
+	return priority
+}
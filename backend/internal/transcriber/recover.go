@@ -0,0 +1,206 @@
+package transcriber
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// resumeMeeting continues the pipeline of a meeting interrupted by a server restart from the
+// last stage it finished. Tracks left on disk are mixed again, so a recording is processed
+// with what was captured; the segment being written when the server stopped may be missing.
+// Meetings whose audio or transcript is gone are marked as failed.
+func (t *TranscriberService) resumeMeeting(meeting *types.Meeting) {
+	status := types.MeetingStatus(meeting.Status)
+	priority := tagPriority(t.config.Processing.TagPriorities, meeting.Tags)
+
+	if status == types.MeetingStatusTranscriptCreated || status == types.MeetingStatusSummaryCreated {
+		if len(meeting.Segments) == 0 {
+			t.failMeeting(meeting, fmt.Sprintf("interrupted by a server restart while %s and the transcript was lost", meeting.Status), nil)
+			return
+		}
+		t.logger.Info("Resuming interrupted meeting after transcription", "meetingId", meeting.Id, "status", meeting.Status)
+		t.queue.Enqueue(meeting.Id, priority, func() {
+			defer t.removeRecording(meeting)
+			t.summarizeAndPublish(t.ctx, meeting)
+			t.exportMeeting(meeting)
+			t.createHighlights(t.ctx, meeting)
+		})
+		return
+	}
+
+	if recording := recordingBase(meeting); audiocapture.HasTracks(recording) {
+		t.logger.Info("Recovering interrupted recording", "meetingId", meeting.Id, "file", meeting.Transcript_path)
+		err := t.transcoder.RecoverRecording(t.ctx, recording, t.meetingMixOptions(meeting))
+		if err == nil && recording != meeting.Transcript_path {
+			err = os.Rename(recording, meeting.Transcript_path)
+		}
+		if err != nil {
+			t.failMeeting(meeting, fmt.Sprintf("interrupted by a server restart while %s and the tracks could not be mixed", meeting.Status), err)
+			return
+		}
+		dropMixedTracks(meeting)
+	}
+	if _, err := os.Stat(meeting.Transcript_path); err != nil {
+		t.failMeeting(meeting, fmt.Sprintf("interrupted by a server restart while %s and no audio of the recording was left", meeting.Status), err)
+		return
+	}
+
+	if status == types.MeetingStatusRecording {
+		duration, err := audiocapture.TrackDuration(meeting.Transcript_path)
+		if err != nil {
+			t.failMeeting(meeting, "interrupted by a server restart while recording and the recording could not be recovered", err)
+			return
+		}
+		meeting.Duration = int(duration.Seconds())
+		t.setStatus(meeting, types.MeetingStatusProcessing)
+		t.addWarning(meeting, events.TypeStopped, fmt.Sprintf("recording was interrupted by a server restart, recovered %s", duration.Round(time.Second)))
+	} else {
+		t.setStatus(meeting, types.MeetingStatusProcessing)
+		t.addWarning(meeting, events.TypeWarning, "processing was interrupted by a server restart and started over")
+	}
+
+	t.logger.Info("Queueing interrupted meeting for processing", "meetingId", meeting.Id, "duration", meeting.Duration)
+	t.queue.Enqueue(meeting.Id, priority, func() {
+		t.processMeeting(t.ctx, meeting)
+	})
+}
+
+// cleanRecordingsDir removes what captures interrupted by a server restart left in the
+// recordings directory. Segments of tracks no meeting claims are joined into a single file
+// and kept, encrypted when encryption is enabled, so the audio isn't lost; leftover armed
+// captures and soundchecks are removed, unless a recording took them over.
+func (t *TranscriberService) cleanRecordingsDir() {
+	claimed := make(map[string]bool)
+	var bases []string
+	for _, meeting := range t.meetings {
+		if meeting.Transcript_path == "" {
+			continue
+		}
+		for _, recording := range []string{meeting.Transcript_path, recordingBase(meeting)} {
+			base := strings.TrimSuffix(recording, filepath.Ext(recording))
+			bases = append(bases, base)
+			claimed[base+"_mic.wav"] = true
+			claimed[base+"_system.wav"] = true
+			for i := range audiocapture.MaxExtraDevices {
+				claimed[base+"_"+audiocapture.ExtraTrackSource(i)+".wav"] = true
+			}
+		}
+		for _, track := range meeting.Tracks {
+			claimed[track.Path] = true
+		}
+	}
+
+	leftovers, _ := filepath.Glob(filepath.Join(t.recordDir, "armed_*"))
+	soundchecks, _ := filepath.Glob(filepath.Join(t.recordDir, "soundcheck_*"))
+	for _, path := range append(leftovers, soundchecks...) {
+		if claimed[path] || slices.ContainsFunc(bases, func(base string) bool { return strings.HasPrefix(path, base+"_") }) {
+			continue
+		}
+		if err := os.RemoveAll(path); err != nil {
+			t.logger.Error("Failed to remove leftover recording", "error", err, "file", path)
+		}
+	}
+
+	for _, track := range audiocapture.SegmentedTracks(t.recordDir) {
+		if claimed[track] {
+			continue
+		}
+		if err := t.transcoder.JoinSegments(t.ctx, track); err != nil {
+			t.logger.Error("Failed to join segments of orphaned track", "error", err, "file", track)
+			continue
+		}
+		if err := t.cipher.EncryptFile(track); err != nil {
+			t.logger.Error("Failed to encrypt orphaned track", "error", err, "file", track)
+		}
+		t.logger.Info("Joined segments of orphaned track", "file", track)
+	}
+}
+
+// recordingBase returns the path the tracks of a meeting's recording were written next to:
+// the recording itself, or the armed capture the recording took over, which keeps writing
+// its tracks under its own name
+func recordingBase(meeting *types.Meeting) string {
+	for _, track := range meeting.Tracks {
+		if base, ok := strings.CutSuffix(track.Path, "_"+track.Source+filepath.Ext(track.Path)); ok {
+			return base + filepath.Ext(meeting.Transcript_path)
+		}
+	}
+	return meeting.Transcript_path
+}
+
+// meetingMixOptions returns the options a meeting's recording was captured with, to mix its
+// tracks again after an interruption
+func (t *TranscriberService) meetingMixOptions(meeting *types.Meeting) audiocapture.MixOptions {
+	mixOptions := audiocapture.RecordedMixOptions(t.mixOptions(), meeting.AudioFilters, meeting.StereoSplit)
+	if len(meeting.Tracks) > 2 {
+		mixOptions.KeepTracks = true // The extra devices were archived as their own tracks
+	}
+	return mixOptions
+}
+
+// dropMixedTracks removes the tracks that were removed after mixing from a meeting, which
+// lists the tracks of an armed capture while it records
+func dropMixedTracks(meeting *types.Meeting) {
+	meeting.Tracks = slices.DeleteFunc(meeting.Tracks, func(track types.AudioTrack) bool {
+		_, err := os.Stat(track.Path)
+		return os.IsNotExist(err)
+	})
+	if len(meeting.Tracks) == 0 {
+		meeting.Tracks = nil
+	}
+}
+
+// sealRecordings encrypts what is left in the recordings directory on shutdown, the captures
+// and tracks of meetings that resume on the next start, so audio isn't kept in plaintext
+// while the server is stopped. It's called after the processes writing there were stopped.
+func (t *TranscriberService) sealRecordings() {
+	if t.cipher == nil {
+		return
+	}
+	t.walkRecordings(func(path string) {
+		if err := t.cipher.EncryptFile(path); err != nil {
+			t.logger.Error("Failed to encrypt leftover recording", "error", err, "file", path)
+		}
+	})
+}
+
+// unsealRecordings decrypts the recordings of meetings sealed on the last shutdown, so the
+// tools resuming them read plain audio. Orphaned tracks stay encrypted.
+func (t *TranscriberService) unsealRecordings() {
+	var recordings []string
+	for _, meeting := range t.meetings {
+		if meeting.Transcript_path != "" {
+			recordings = append(recordings, meeting.Transcript_path, recordingBase(meeting))
+		}
+	}
+	t.walkRecordings(func(path string) {
+		for _, recording := range recordings {
+			base := strings.TrimSuffix(recording, filepath.Ext(recording))
+			if path != recording && !strings.HasPrefix(path, base+"_") {
+				continue
+			}
+			if err := t.cipher.DecryptFile(path); err != nil {
+				t.logger.Error("Failed to decrypt leftover recording", "error", err, "file", path)
+			}
+			return
+		}
+	})
+}
+
+// walkRecordings calls fn for every file in the recordings directory
+func (t *TranscriberService) walkRecordings(fn func(path string)) {
+	filepath.WalkDir(t.recordDir, func(path string, entry os.DirEntry, err error) error {
+		if err == nil && entry.Type().IsRegular() {
+			fn(path)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,235 @@
+package transcriber
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// RenameResult reports what a participant rename changed
+type RenameResult struct {
+	From         string   `json:"from"`
+	To           string   `json:"to"`
+	Meetings     []string `json:"meetings"`               // IDs of the meetings that changed
+	Replacements int      `json:"replacements"`           // Occurrences replaced across all meetings
+	Skipped      []string `json:"skipped,omitempty"`      // Meetings that were still recording or processing
+	VaultErrors  []string `json:"vault_errors,omitempty"` // Meetings whose vault note couldn't be rewritten
+}
+
+// nameReplacer replaces a name where it names the participant: in [[wikilinks]] and in
+// speaker labels starting a line ("Jon: ..."). With freeText it replaces every whole-word
+// occurrence instead, also where the word means something else.
+type nameReplacer struct {
+	from     string
+	to       string
+	freeText bool
+	links    *regexp.Regexp
+	labels   *regexp.Regexp
+	count    int
+}
+
+func newNameReplacer(from, to string, freeText bool) *nameReplacer {
+	name := regexp.QuoteMeta(from)
+	return &nameReplacer{
+		from:     from,
+		to:       to,
+		freeText: freeText,
+		links:    regexp.MustCompile(`\[\[` + name + `(\]\]|\||#)`),
+		labels:   regexp.MustCompile(`(?m)^((?:\[[^\]\n]*\] ?)?\**)` + name + `(\**:)`),
+	}
+}
+
+func (r *nameReplacer) replace(text string) string {
+	if r.freeText {
+		return r.replaceWords(text)
+	}
+	text = r.links.ReplaceAllStringFunc(text, func(match string) string {
+		r.count++
+		return "[[" + r.to + match[len("[["+r.from):]
+	})
+	return r.labels.ReplaceAllStringFunc(text, func(match string) string {
+		r.count++
+		parts := r.labels.FindStringSubmatch(match)
+		return parts[1] + r.to + parts[2]
+	})
+}
+
+// replaceWords replaces the whole-word occurrences of the name
+func (r *nameReplacer) replaceWords(text string) string {
+	var b strings.Builder
+	for {
+		i := strings.Index(text, r.from)
+		if i < 0 {
+			b.WriteString(text)
+			return b.String()
+		}
+		end := i + len(r.from)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (i == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
+			b.WriteString(text[:i])
+			b.WriteString(r.to)
+			r.count++
+		} else {
+			b.WriteString(text[:end])
+		}
+		text = text[end:]
+	}
+}
+
+func isWordRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
+
+// RenameParticipant renames a participant in one meeting, or in all meetings when meetingId
+// is empty. Participants, speakers, speaker labels, wikilinks and vault notes are updated;
+// with freeText, every whole-word mention in the transcript and summary is too. A changed
+// summary keeps the previous one in its history. With a version, the rename of a single
+// meeting is rejected when it changed since that version.
+func (t *TranscriberService) RenameParticipant(meetingId string, from string, to string, freeText bool, version *int64) (*RenameResult, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if from == "" || to == "" {
+		return nil, fmt.Errorf("both the current and the new name are required")
+	}
+	if from == to {
+		return nil, fmt.Errorf("the new name is the same as the current name")
+	}
+
+	t.editMu.Lock()
+	defer t.editMu.Unlock()
+	var meetings []*types.Meeting
+	if meetingId != "" {
+		meeting, err := t.GetMeetingStatus(meetingId)
+		if err != nil {
+			return nil, err
+		}
+		if !editable(meeting) {
+			return nil, ErrMeetingBusy
+		}
+		if err := checkVersion(meeting, version); err != nil {
+			return nil, err
+		}
+		meetings = []*types.Meeting{meeting}
+	} else {
+		meetings = t.GetAllMeetings()
+	}
+
+	result := &RenameResult{From: from, To: to, Meetings: []string{}}
+	for _, meeting := range meetings {
+		if !editable(meeting) {
+			result.Skipped = append(result.Skipped, meeting.Id)
+			continue
+		}
+
+		previousNote, _ := t.notePath(meeting)
+		replacements := t.renameInMeeting(meeting, from, to, freeText)
+		if replacements == 0 {
+			continue
+		}
+		result.Meetings = append(result.Meetings, meeting.Id)
+		result.Replacements += replacements
+
+		if meeting.Status == string(types.MeetingStatusCompleted) || meeting.Status == string(types.MeetingStatusTranscriptOnly) {
+			if err := t.resaveToVault(meeting, previousNote); err != nil {
+				t.logger.Error("Failed to update vault note after rename", "error", err, "meetingId", meeting.Id)
+				result.VaultErrors = append(result.VaultErrors, meeting.Id)
+			}
+		}
+	}
+
+	t.logger.Info("Participant renamed", "from", from, "to", to, "meetings", len(result.Meetings), "replacements", result.Replacements)
+	return result, nil
+}
+
+// renameInMeeting applies the rename to a single meeting and returns the number of replacements
+func (t *TranscriberService) renameInMeeting(meeting *types.Meeting, from, to string, freeText bool) int {
+	replacer := newNameReplacer(from, to, freeText)
+
+	// Requests may be reading the meeting meanwhile
+	t.mu.Lock()
+	participants := make([]string, 0, len(meeting.Participants))
+	for _, participant := range meeting.Participants {
+		if participant == from {
+			replacer.count++
+			participant = to
+		}
+		if !containsString(participants, participant) {
+			participants = append(participants, participant)
+		}
+	}
+
+	segmentsChanged := false
+	for i := range meeting.Segments {
+		segment := &meeting.Segments[i]
+		before := replacer.count
+		if segment.Speaker == from {
+			replacer.count++
+			segment.Speaker = to
+		}
+		segment.Text = replacer.replace(segment.Text)
+		segmentsChanged = segmentsChanged || replacer.count > before
+	}
+
+	summary := replacer.replace(meeting.Summary)
+	if replacer.count == 0 {
+		t.mu.Unlock()
+		return 0
+	}
+
+	meeting.Participants = participants
+	if summary != meeting.Summary {
+		replaceSummary(meeting, summary)
+	}
+	if len(meeting.Segments) > 0 {
+		meeting.Transcript = FormatTranscript(meeting, meeting.Segments)
+	} else {
+		meeting.Transcript = replacer.replace(meeting.Transcript)
+	}
+	now := time.Now()
+	meeting.Edited = true
+	meeting.EditedAt = &now
+	t.mu.Unlock()
+
+	if segmentsChanged {
+		t.invalidateEmbeddings(meeting.Id)
+	}
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeEdited)
+	return replacer.count
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// containsWord reports whether text contains word as a whole word
+func containsWord(text, word string) bool {
+	if word == "" {
+		return false
+	}
+	for offset := 0; ; {
+		i := strings.Index(text[offset:], word)
+		if i < 0 {
+			return false
+		}
+		i += offset
+		end := i + len(word)
+		before, _ := utf8.DecodeLastRuneInString(text[:i])
+		after, _ := utf8.DecodeRuneInString(text[end:])
+		if (i == 0 || !isWordRune(before)) && (end == len(text) || !isWordRune(after)) {
+			return true
+		}
+		offset = end
+	}
+}
@@ -0,0 +1,315 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// resummarizePriority keeps batch jobs behind freshly recorded meetings
+const resummarizePriority = -1
+
+// ResummarizeBatch tracks the progress of a batch re-summarization
+type ResummarizeBatch struct {
+	mu         sync.Mutex
+	Id         string            `json:"id"`
+	Filter     string            `json:"filter"`
+	CreatedAt  time.Time         `json:"created_at"`
+	Total      int               `json:"total"`
+	Completed  int               `json:"completed"`
+	Failed     int               `json:"failed"`
+	MeetingIds []string          `json:"meeting_ids"`
+	Errors     map[string]string `json:"errors,omitempty"` // Meeting ID -> error message
+}
+
+// Snapshot returns a copy of the batch progress that is safe to serialize
+func (b *ResummarizeBatch) Snapshot() map[string]interface{} {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	errors := make(map[string]string, len(b.Errors))
+	for id, msg := range b.Errors {
+		errors[id] = msg
+	}
+
+	return map[string]interface{}{
+		"id":          b.Id,
+		"filter":      b.Filter,
+		"created_at":  b.CreatedAt,
+		"total":       b.Total,
+		"completed":   b.Completed,
+		"failed":      b.Failed,
+		"pending":     b.Total - b.Completed - b.Failed,
+		"done":        b.Completed+b.Failed >= b.Total,
+		"meeting_ids": b.MeetingIds,
+		"errors":      errors,
+	}
+}
+
+func (b *ResummarizeBatch) recordResult(meetingId string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		b.Failed++
+		b.Errors[meetingId] = err.Error()
+		return
+	}
+	b.Completed++
+}
+
+// Resummarize queues re-summarization with the current default template for all
+// transcribed meetings matching the filter expression
+func (t *TranscriberService) Resummarize(filterExpr string) (*ResummarizeBatch, error) {
+	filter, err := ParseMeetingFilter(filterExpr)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &ResummarizeBatch{
+		Id:        uuid.NewString(),
+		Filter:    filterExpr,
+		CreatedAt: time.Now(),
+		Errors:    make(map[string]string),
+	}
+
+	var matches []*types.Meeting
+	for _, meeting := range t.GetAllMeetings() {
+		// Only meetings that have a transcript and aren't being processed can be re-summarized
+		if !summarizable(meeting) {
+			continue
+		}
+		if filter.Matches(meeting) {
+			matches = append(matches, meeting)
+			batch.MeetingIds = append(batch.MeetingIds, meeting.Id)
+		}
+	}
+	batch.Total = len(matches)
+
+	t.mu.Lock()
+	t.batches[batch.Id] = batch
+	t.mu.Unlock()
+
+	t.logger.Info("Queueing batch re-summarization", "batchId", batch.Id, "filter", filterExpr, "meetings", batch.Total)
+	for _, meeting := range matches {
+		t.queue.Enqueue(meeting.Id, resummarizePriority, func() {
+			batch.recordResult(meeting.Id, t.resummarizeMeeting(t.ctx, meeting))
+		})
+	}
+
+	return batch, nil
+}
+
+// GetResummarizeBatch returns a batch re-summarization by its ID
+func (t *TranscriberService) GetResummarizeBatch(batchId string) (*ResummarizeBatch, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	batch, exists := t.batches[batchId]
+	if !exists {
+		return nil, fmt.Errorf("batch not found with ID: %s", batchId)
+	}
+	return batch, nil
+}
+
+// summarizable reports whether a meeting has a finished transcript that can be (re-)summarized,
+// including meetings saved transcript-only while Ollama was unavailable
+func summarizable(meeting *types.Meeting) bool {
+	if meeting.Transcript == "" {
+		return false
+	}
+	return meeting.Status == string(types.MeetingStatusCompleted) || meeting.Status == string(types.MeetingStatusTranscriptOnly)
+}
+
+// replaceSummary sets a new meeting summary, keeping the previous one in the history
+func replaceSummary(meeting *types.Meeting, summary string) {
+	if meeting.Summary != "" {
+		meeting.SummaryHistory = append(meeting.SummaryHistory, types.SummaryVersion{
+			Version:    len(meeting.SummaryHistory) + 1,
+			Summary:    meeting.Summary,
+			ReplacedAt: time.Now(),
+		})
+	}
+	meeting.Summary = summary
+}
+
+// resummarizeMeeting regenerates the summary of a meeting and re-saves its vault note
+func (t *TranscriberService) resummarizeMeeting(ctx context.Context, meeting *types.Meeting) error {
+	t.logger.Info("Re-summarizing meeting", "meetingId", meeting.Id)
+
+	summary, err := t.Summarize(ctx, meeting)
+	if err != nil {
+		t.logger.Error("Failed to re-summarize meeting", "error", err, "meetingId", meeting.Id)
+		return fmt.Errorf("failed to summarize transcription: %w", err)
+	}
+	replaceSummary(meeting, summary)
+	t.setStatus(meeting, types.MeetingStatusCompleted)
+	t.recordEvent(meeting, events.TypeSummarized)
+
+	if err := t.saveToVault(meeting); err != nil {
+		t.logger.Error("Failed to save re-summarized meeting to vault", "error", err, "meetingId", meeting.Id)
+		return fmt.Errorf("failed to save meeting to vault: %w", err)
+	}
+
+	return nil
+}
+
+// SummarizeOptions customizes the regeneration of a single meeting summary
+type SummarizeOptions struct {
+	Model        string // Ollama model, empty uses the configured model
+	Template     string // Named template to use instead of the meeting's template
+	SystemPrompt string // Replaces the template when set
+	Instructions string // Extra guidance appended to the template, e.g. "focus on the pricing discussion"
+}
+
+// Summary job states
+const (
+	SummaryJobQueued    = "queued"
+	SummaryJobRunning   = "running"
+	SummaryJobCompleted = "completed"
+	SummaryJobFailed    = "failed"
+)
+
+// SummaryJob tracks the regeneration of a meeting summary queued with QueueSummary
+type SummaryJob struct {
+	mu         sync.Mutex
+	Id         string
+	MeetingId  string
+	Status     string
+	Error      string
+	CreatedAt  time.Time
+	FinishedAt *time.Time
+}
+
+// Snapshot returns a copy of the job state that is safe to serialize
+func (j *SummaryJob) Snapshot() map[string]interface{} {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	snapshot := map[string]interface{}{
+		"id":         j.Id,
+		"meeting_id": j.MeetingId,
+		"status":     j.Status,
+		"created_at": j.CreatedAt,
+	}
+	if j.Error != "" {
+		snapshot["error"] = j.Error
+	}
+	if j.FinishedAt != nil {
+		snapshot["finished_at"] = j.FinishedAt
+	}
+	return snapshot
+}
+
+func (j *SummaryJob) setStatus(status string, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.Status = status
+	if err != nil {
+		j.Error = err.Error()
+	}
+	if status == SummaryJobCompleted || status == SummaryJobFailed {
+		now := time.Now()
+		j.FinishedAt = &now
+	}
+}
+
+// QueueSummary queues the regeneration of a meeting summary behind the meetings being
+// processed, returning the job to follow it with. The meeting and template are checked
+// before it is queued.
+func (t *TranscriberService) QueueSummary(meetingId string, opts SummarizeOptions) (*SummaryJob, error) {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return nil, err
+	}
+	if !summarizable(meeting) {
+		return nil, fmt.Errorf("meeting %s has no completed transcript to summarize", meetingId)
+	}
+	if opts.SystemPrompt == "" && opts.Template != "" {
+		if _, err := t.prompts.Get(opts.Template); err != nil {
+			return nil, err
+		}
+	}
+
+	job := &SummaryJob{
+		Id:        uuid.NewString(),
+		MeetingId: meetingId,
+		Status:    SummaryJobQueued,
+		CreatedAt: time.Now(),
+	}
+	t.mu.Lock()
+	t.summaryJobs[job.Id] = job
+	t.mu.Unlock()
+
+	t.queue.Enqueue(meetingId, tagPriority(t.config.Processing.TagPriorities, meeting.Tags), func() {
+		job.setStatus(SummaryJobRunning, nil)
+		if _, err := t.regenerateSummary(t.ctx, meetingId, opts); err != nil {
+			t.logger.Error("Failed to regenerate summary", "error", err, "meetingId", meetingId, "jobId", job.Id)
+			job.setStatus(SummaryJobFailed, err)
+			return
+		}
+		job.setStatus(SummaryJobCompleted, nil)
+	})
+	return job, nil
+}
+
+// GetSummaryJob returns a summary job of a meeting by its ID
+func (t *TranscriberService) GetSummaryJob(meetingId string, jobId string) (*SummaryJob, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	job, exists := t.summaryJobs[jobId]
+	if !exists || job.MeetingId != meetingId {
+		return nil, fmt.Errorf("summary job not found with ID: %s", jobId)
+	}
+	return job, nil
+}
+
+// regenerateSummary summarizes a completed meeting again with a custom prompt or model.
+// The previous summary is kept in the summary history and the vault note is re-saved.
+func (t *TranscriberService) regenerateSummary(ctx context.Context, meetingId string, opts SummarizeOptions) (*types.Meeting, error) {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return nil, err
+	}
+	if !summarizable(meeting) {
+		return nil, fmt.Errorf("meeting %s has no completed transcript to summarize", meetingId)
+	}
+
+	model := opts.Model
+	if model == "" {
+		model = t.config.Ollama.Model
+	}
+	systemPrompt := opts.SystemPrompt
+	if systemPrompt == "" && opts.Template != "" {
+		template, err := t.prompts.Get(opts.Template)
+		if err != nil {
+			return nil, err
+		}
+		systemPrompt = template.Prompt
+	}
+	if systemPrompt == "" {
+		systemPrompt = t.systemPrompt(meeting)
+	}
+	if opts.Instructions != "" {
+		systemPrompt += "\n\nAdditional instructions:\n" + opts.Instructions
+	}
+
+	t.logger.Info("Regenerating meeting summary", "meetingId", meetingId, "model", model, "template", opts.Template, "customPrompt", opts.SystemPrompt != "")
+	summary, err := t.reviewedSummary(ctx, meeting, model, systemPrompt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to summarize transcription: %w", err)
+	}
+	replaceSummary(meeting, summary)
+	t.setStatus(meeting, types.MeetingStatusCompleted)
+	t.recordEvent(meeting, events.TypeSummarized)
+
+	if err := t.saveToVault(meeting); err != nil {
+		return nil, fmt.Errorf("failed to save meeting to vault: %w", err)
+	}
+	return meeting, nil
+}
@@ -0,0 +1,141 @@
+package transcriber
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/ollama"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+const reviewSystemPrompt = `You review meeting summaries against the transcript they were written from. Check that:
+1. Every person named in the summary appears in the transcript or the participants.
+2. Every action item, decision and follow-up agreed in the transcript is in the summary.
+3. The summary follows the structure asked for in the instructions it was written with.
+Answer with a first line "SCORE: <0-100>", 100 for a summary without problems, followed by one line per problem starting with "- ". List nothing else.`
+
+// reviewScorePattern matches the score line of a review
+var reviewScorePattern = regexp.MustCompile(`(?i)score:\s*(\d{1,3})`)
+
+// reviewResult is the answer of one review of a summary
+type reviewResult struct {
+	score  int
+	issues []string
+}
+
+// reviewedSummary generates the meeting summary and, when reviews are enabled, has the model
+// check it against the transcript. Summaries scoring too low are written again with the
+// problems found until the retries run out, after which the best one is kept. The review of
+// the kept summary is set on the meeting; a review that fails leaves the summary as is.
+func (t *TranscriberService) reviewedSummary(ctx context.Context, meeting *types.Meeting, model string, systemPrompt string) (string, error) {
+	cfg := t.config.Ollama.Review
+	if !cfg.Enabled {
+		meeting.SummaryReview = nil
+		return t.summarizeWith(ctx, meeting, model, systemPrompt)
+	}
+	if meeting.Transcript == "" {
+		return "", fmt.Errorf("transcription cannot be empty")
+	}
+
+	msgs := t.summaryMessages(meeting, systemPrompt)
+	answer, summary, err := t.completeSummary(ctx, meeting, model, msgs)
+	if err != nil {
+		return "", err
+	}
+
+	var best *types.SummaryReview
+	bestSummary := summary
+	attempt := 1
+	for ; ; attempt++ {
+		result, err := t.reviewSummary(ctx, meeting, systemPrompt, summary)
+		if err != nil {
+			t.logger.Error("Failed to review summary, keeping it unreviewed", "error", err, "meetingId", meeting.Id, "attempt", attempt)
+			break
+		}
+		t.logger.Info("Reviewed summary", "meetingId", meeting.Id, "attempt", attempt, "score", result.score, "issues", len(result.issues))
+		if best == nil || result.score > best.Score {
+			best = &types.SummaryReview{Score: result.score, Issues: result.issues}
+			bestSummary = summary
+		}
+		if result.score >= cfg.MinScore || attempt > cfg.Retries {
+			break
+		}
+
+		msgs = append(msgs,
+			ollama.Message{Role: "assistant", Content: answer},
+			ollama.Message{Role: "user", Content: reviewFeedback(result.issues)},
+		)
+		answer, summary, err = t.completeSummary(ctx, meeting, model, msgs)
+		if err != nil {
+			t.logger.Error("Failed to rewrite summary after review, keeping the best one", "error", err, "meetingId", meeting.Id, "attempt", attempt+1)
+			break
+		}
+	}
+
+	if best != nil {
+		best.Passed = best.Score >= cfg.MinScore
+		best.Attempts = attempt
+		best.ReviewedAt = time.Now()
+	}
+	meeting.SummaryReview = best
+	return bestSummary, nil
+}
+
+// reviewSummary has the model score a summary against the transcript and list its problems
+func (t *TranscriberService) reviewSummary(ctx context.Context, meeting *types.Meeting, instructions string, summary string) (reviewResult, error) {
+	model := t.config.Ollama.Review.Model
+	if model == "" {
+		model = t.config.Ollama.Model
+	}
+	participants := "unknown"
+	if len(meeting.Participants) > 0 {
+		participants = strings.Join(meeting.Participants, ", ")
+	}
+
+	msgs := []ollama.Message{
+		{
+			Role:    "system",
+			Content: reviewSystemPrompt,
+		},
+		{
+			Role: "user",
+			Content: fmt.Sprintf("Instructions the summary was written with:\n\n%s\n\nParticipants: %s\n\nTranscript:\n\n%s\n\nSummary:\n\n%s",
+				instructions, participants, meeting.Transcript, summary),
+		},
+	}
+	res, err := t.chat(ctx, model, msgs)
+	if err != nil {
+		return reviewResult{}, fmt.Errorf("failed to talk to Ollama: %w", err)
+	}
+	return parseReview(res.Message.Content)
+}
+
+// parseReview reads the score and problems from the answer of a review
+func parseReview(answer string) (reviewResult, error) {
+	match := reviewScorePattern.FindStringSubmatch(answer)
+	if match == nil {
+		return reviewResult{}, fmt.Errorf("review has no score")
+	}
+	score, _ := strconv.Atoi(match[1])
+	result := reviewResult{score: min(score, 100)}
+	for _, line := range strings.Split(answer, "\n") {
+		line = strings.TrimSpace(line)
+		if issue, ok := strings.CutPrefix(line, "- "); ok && strings.TrimSpace(issue) != "" {
+			result.issues = append(result.issues, strings.TrimSpace(issue))
+		}
+	}
+	return result, nil
+}
+
+// reviewFeedback asks the model to write the summary again, fixing the problems of the review
+func reviewFeedback(issues []string) string {
+	if len(issues) == 0 {
+		return "A review found your summary incomplete or inaccurate. Write the summary again in the required format, sticking to what the transcript says."
+	}
+	return "A review of your summary found these problems:\n- " + strings.Join(issues, "\n- ") +
+		"\n\nWrite the summary again in the required format, fixing them and sticking to what the transcript says."
+}
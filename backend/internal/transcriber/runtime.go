@@ -0,0 +1,53 @@
+package transcriber
+
+import (
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// RuntimeStats is the state of the background work, to spot jobs piling up and leaked
+// child processes
+type RuntimeStats struct {
+	Meetings       int  `json:"meetings"`
+	Recording      bool `json:"recording"`
+	QueuedJobs     int  `json:"queued_jobs"`     // Jobs waiting for the one running to finish
+	ChildProcesses int  `json:"child_processes"` // Running ffmpeg, whisper and other children
+}
+
+// RuntimeStats returns the state of the background work
+func (t *TranscriberService) RuntimeStats() RuntimeStats {
+	t.mu.RLock()
+	meetings := len(t.meetings)
+	t.mu.RUnlock()
+	recording := t.recordingActive()
+	return RuntimeStats{
+		Meetings:       meetings,
+		Recording:      recording,
+		QueuedJobs:     t.queue.Len(),
+		ChildProcesses: procs.Running(),
+	}
+}
+
+// Failure is a meeting that failed to process
+type Failure struct {
+	MeetingId string    `json:"meeting_id"`
+	Error     string    `json:"error"`
+	Time      time.Time `json:"time"`
+	owner     string
+}
+
+// Owner returns the user who started the failed meeting
+func (f Failure) Owner() string {
+	return f.owner
+}
+
+// LastFailure returns the most recent meeting that failed since the service started
+func (t *TranscriberService) LastFailure() (Failure, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.lastFailure == nil {
+		return Failure{}, false
+	}
+	return *t.lastFailure, true
+}
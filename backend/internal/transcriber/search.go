@@ -0,0 +1,65 @@
+package transcriber
+
+import (
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// SearchResult is a meeting matching a keyword search with the segments that contain the query
+type SearchResult struct {
+	MeetingId string          `json:"meeting_id"`
+	Title     string          `json:"title"`
+	CreatedAt time.Time       `json:"created_at"`
+	Matches   []types.Segment `json:"matches"`
+}
+
+// maxSearchMatches caps the segments returned per meeting
+const maxSearchMatches = 5
+
+// SearchMeetings returns meetings whose title, summary or transcript segments contain the
+// query (case-insensitive), newest first. A limit of 0 returns all matches. With an owner,
+// only the meetings of that user are searched.
+func (t *TranscriberService) SearchMeetings(query string, limit int, owner string) []SearchResult {
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return []SearchResult{}
+	}
+
+	results := []SearchResult{}
+	for _, meeting := range t.GetAllMeetings() {
+		// Archived meetings are still found, those in the trash aren't
+		if meeting.TrashedAt != nil || (owner != "" && meeting.Owner != owner) {
+			continue
+		}
+		var matches []types.Segment
+		for _, segment := range meeting.Segments {
+			if containsFold(segment.Text, query) {
+				matches = append(matches, segment)
+			}
+		}
+		if len(matches) == 0 && !containsFold(meeting.Title, query) && !containsFold(meeting.Summary, query) {
+			continue
+		}
+		if len(matches) > maxSearchMatches {
+			matches = matches[:maxSearchMatches]
+		}
+
+		results = append(results, SearchResult{
+			MeetingId: meeting.Id,
+			Title:     meeting.Title,
+			CreatedAt: meeting.CreatedAt,
+			Matches:   matches,
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].CreatedAt.After(results[j].CreatedAt)
+	})
+	if limit > 0 && limit < len(results) {
+		results = results[:limit]
+	}
+	return results
+}
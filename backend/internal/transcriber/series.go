@@ -0,0 +1,102 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/devices"
+	"github.com/martijnspitter/transcriber/internal/frontmatter"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrSeriesNotFound is returned for series without meetings
+var ErrSeriesNotFound = errors.New("series not found")
+
+// Series is the history of a recurring meeting, oldest meeting first
+type Series struct {
+	Id       string          `json:"id"` // Series key shared by the meetings
+	Meetings []SeriesMeeting `json:"meetings"`
+}
+
+// SeriesMeeting is a meeting of a series with the action items of its summary
+type SeriesMeeting struct {
+	Id          string    `json:"id"`
+	Title       string    `json:"title"`
+	Status      string    `json:"status"`
+	CreatedAt   time.Time `json:"created_at"`
+	Duration    int       `json:"duration"`
+	ActionItems []string  `json:"action_items,omitempty"`
+}
+
+// seriesKey returns the series a meeting belongs to: its explicit series, or its title
+// without dates and times, as device preferences are remembered
+func seriesKey(meeting *types.Meeting) string {
+	return devices.SeriesKey(meeting.Series, meeting.Title)
+}
+
+// seriesMeetings returns the meetings of a series, oldest first. With an owner, only the
+// meetings of that user are returned.
+func (t *TranscriberService) seriesMeetings(key string, owner string) []*types.Meeting {
+	var meetings []*types.Meeting
+	for _, meeting := range t.GetAllMeetings() {
+		if seriesKey(meeting) == key && (owner == "" || meeting.Owner == owner) {
+			meetings = append(meetings, meeting)
+		}
+	}
+	sort.Slice(meetings, func(i, j int) bool { return meetings[i].CreatedAt.Before(meetings[j].CreatedAt) })
+	return meetings
+}
+
+// GetSeries returns the history of a series, given by its key or by a name or title that
+// reduces to it, e.g. "Weekly 1:1 with Anna". With an owner, only the meetings of that user
+// are included.
+func (t *TranscriberService) GetSeries(id string, owner string) (Series, error) {
+	key := devices.SeriesKey(id, "")
+	meetings := t.seriesMeetings(key, owner)
+	if len(meetings) == 0 {
+		return Series{}, fmt.Errorf("%w: %s", ErrSeriesNotFound, id)
+	}
+
+	series := Series{Id: key, Meetings: make([]SeriesMeeting, 0, len(meetings))}
+	for _, meeting := range meetings {
+		series.Meetings = append(series.Meetings, SeriesMeeting{
+			Id:          meeting.Id,
+			Title:       meeting.Title,
+			Status:      meeting.Status,
+			CreatedAt:   meeting.CreatedAt,
+			Duration:    meeting.Duration,
+			ActionItems: frontmatter.ActionItems(meeting.Summary),
+		})
+	}
+	return series, nil
+}
+
+// previousInSeries returns the latest summarized meeting of the series created before the
+// meeting, or nil when there is none
+func (t *TranscriberService) previousInSeries(meeting *types.Meeting) *types.Meeting {
+	var previous *types.Meeting
+	for _, other := range t.seriesMeetings(seriesKey(meeting), meeting.Owner) {
+		if other.Id != meeting.Id && other.Summary != "" && other.CreatedAt.Before(meeting.CreatedAt) {
+			previous = other
+		}
+	}
+	return previous
+}
+
+// seriesPrompt lists the action items of the previous meeting of the series so the summary
+// can note which were completed, or returns nothing when there are none
+func (t *TranscriberService) seriesPrompt(meeting *types.Meeting) string {
+	previous := t.previousInSeries(meeting)
+	if previous == nil {
+		return ""
+	}
+	items := frontmatter.ActionItems(previous.Summary)
+	if len(items) == 0 {
+		return ""
+	}
+	return fmt.Sprintf("\n\nThis meeting is part of a series. Action items of the previous meeting, %q on %s:\n- %s\nIn the summary, note which of these were completed, which are still open and which weren't discussed.",
+		previous.Title, previous.CreatedAt.Format("January 2, 2006"), strings.Join(items, "\n- "))
+}
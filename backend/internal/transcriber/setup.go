@@ -0,0 +1,331 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/ollama"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// Steps of the first-run setup, in order
+const (
+	SetupStepDevices       = "devices"
+	SetupStepTestRecording = "test_recording"
+	SetupStepModels        = "models"
+	SetupStepVault         = "vault"
+)
+
+var setupSteps = []struct{ name, description string }{
+	{SetupStepDevices, "Choose the microphone and the system audio device, e.g. BlackHole"},
+	{SetupStepTestRecording, "Record 5 seconds from both devices and check their levels"},
+	{SetupStepModels, "Pick the whisper and Ollama models, downloading them if needed"},
+	{SetupStepVault, "Set the path of the Obsidian vault meeting notes are saved to"},
+}
+
+// setupTestDuration is the length of the setup test recording
+const setupTestDuration = 5 * time.Second
+
+// whisperModels are the models the whisper CLI accepts
+var whisperModels = []string{"tiny", "tiny.en", "base", "base.en", "small", "small.en", "medium", "medium.en", "large", "turbo"}
+
+// Model download states
+const (
+	downloadRunning = "downloading"
+	downloadReady   = "ready"
+)
+
+// ErrInvalidSetupStep is returned for unknown steps and invalid step input
+var ErrInvalidSetupStep = errors.New("invalid setup step")
+
+// setupState tracks the model downloads started by the setup
+type setupState struct {
+	mu        sync.Mutex
+	downloads map[string]string // "whisper:<model>" or "ollama:<model>" -> state or error
+}
+
+// SetupStatus is the progress of the first-run setup
+type SetupStatus struct {
+	Completed bool              `json:"completed"`
+	Next      string            `json:"next,omitempty"` // First step that isn't done
+	Steps     []SetupStepStatus `json:"steps"`
+	Devices   []string          `json:"devices"`             // Audio devices to choose from
+	Downloads map[string]string `json:"downloads,omitempty"` // Model downloads and their state
+}
+
+// SetupStepStatus is a step of the setup
+type SetupStepStatus struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Done        bool   `json:"done"`
+}
+
+// SetupStepRequest completes a setup step; only the fields of the step are used
+type SetupStepRequest struct {
+	Step         string `json:"step"`
+	MicDevice    string `json:"mic_device,omitempty"`    // devices
+	SystemDevice string `json:"system_device,omitempty"` // devices
+	WhisperModel string `json:"whisper_model,omitempty"` // models
+	OllamaModel  string `json:"ollama_model,omitempty"`  // models
+	VaultPath    string `json:"vault_path,omitempty"`    // vault
+}
+
+// SetupStepResult is the outcome of a setup step
+type SetupStepResult struct {
+	Status     SetupStatus       `json:"status"`
+	Soundcheck *SoundcheckResult `json:"soundcheck,omitempty"` // Set by the test recording step
+}
+
+// SetupStatus returns the progress of the setup
+func (t *TranscriberService) SetupStatus() SetupStatus {
+	status := SetupStatus{Completed: true, Downloads: make(map[string]string)}
+	t.setup.mu.Lock()
+	for model, state := range t.setup.downloads {
+		status.Downloads[model] = state
+	}
+	for _, step := range setupSteps {
+		done := slices.Contains(t.config.Setup.CompletedSteps, step.name)
+		status.Steps = append(status.Steps, SetupStepStatus{Name: step.name, Description: step.description, Done: done})
+		if !done && status.Completed {
+			status.Completed = false
+			status.Next = step.name
+		}
+	}
+	t.setup.mu.Unlock()
+
+	if t.Capabilities().Recording {
+		status.Devices, _ = t.capturer.ListAudioDevices()
+	}
+	return status
+}
+
+// SetupStep completes a step of the setup and persists its choices in the config file
+func (t *TranscriberService) SetupStep(ctx context.Context, req SetupStepRequest) (*SetupStepResult, error) {
+	result := &SetupStepResult{}
+	var err error
+	switch req.Step {
+	case SetupStepDevices:
+		err = t.setupDevices(req.MicDevice, req.SystemDevice)
+	case SetupStepTestRecording:
+		result.Soundcheck, err = t.Soundcheck(ctx, SoundcheckOptions{Duration: setupTestDuration})
+		if err == nil {
+			err = t.saveSetup(SetupStepTestRecording, func(cfg *config.Config) {})
+		}
+	case SetupStepModels:
+		err = t.setupModels(req.WhisperModel, req.OllamaModel)
+	case SetupStepVault:
+		err = t.setupVault(req.VaultPath)
+	default:
+		err = fmt.Errorf("%w: unknown step %q", ErrInvalidSetupStep, req.Step)
+	}
+	if err != nil {
+		return nil, err
+	}
+	result.Status = t.SetupStatus()
+	return result, nil
+}
+
+// setupDevices sets the devices recorded by default
+func (t *TranscriberService) setupDevices(mic string, system string) error {
+	if mic == "" || system == "" {
+		return fmt.Errorf("%w: mic_device and system_device are required", ErrInvalidSetupStep)
+	}
+	selected := audiocapture.CaptureDevices{Mic: mic, System: system}
+	if err := t.matchDevices(&selected); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSetupStep, err)
+	}
+	mic, system = selected.Mic, selected.System
+	return t.saveSetup(SetupStepDevices, func(cfg *config.Config) {
+		cfg.Audio.MicDevice = mic
+		cfg.Audio.SystemDevice = system
+	})
+}
+
+// setupModels sets the whisper and Ollama models and downloads those that are missing in the
+// background. The step is done once both are available.
+func (t *TranscriberService) setupModels(whisperModel string, ollamaModel string) error {
+	if whisperModel == "" {
+		whisperModel = t.config.Transcription.WhisperModel
+	}
+	if ollamaModel == "" {
+		ollamaModel = t.config.Ollama.Model
+	}
+	if ollamaModel == "" {
+		ollamaModel = ollama.DefaultModel
+	}
+	if !slices.Contains(whisperModels, whisperModel) {
+		return fmt.Errorf("%w: unknown whisper model %q, use one of %s", ErrInvalidSetupStep, whisperModel, strings.Join(whisperModels, ", "))
+	}
+
+	if err := t.saveSetup("", func(cfg *config.Config) {
+		cfg.Transcription.WhisperModel = whisperModel
+		cfg.Ollama.Model = ollamaModel
+	}); err != nil {
+		return err
+	}
+	// The engine holds the whisper model, so it is created again
+	if engine, err := newEngine(t.config.Transcription, t.logger); err == nil {
+		t.engine = engine
+	}
+
+	go func() {
+		var failed bool
+		if t.config.Transcription.Engine == "" || t.config.Transcription.Engine == config.TranscriptionEngineWhisper {
+			failed = !t.download("whisper:"+whisperModel, func() error { return t.downloadWhisperModel(t.ctx, whisperModel) })
+		}
+		if !t.config.Ollama.Fake {
+			failed = !t.download("ollama:"+ollamaModel, func() error { return pullOllamaModel(t.ctx, ollamaModel) }) || failed
+		}
+		if failed {
+			return
+		}
+		if err := t.saveSetup(SetupStepModels, func(cfg *config.Config) {}); err != nil {
+			t.logger.Error("Failed to save setup progress", "error", err)
+		}
+	}()
+	return nil
+}
+
+// download runs a model download, tracking its state, and reports whether it succeeded
+func (t *TranscriberService) download(name string, run func() error) bool {
+	t.setup.mu.Lock()
+	t.setup.downloads[name] = downloadRunning
+	t.setup.mu.Unlock()
+
+	t.logger.Info("Downloading model", "model", name)
+	err := run()
+	state := downloadReady
+	if err != nil {
+		state = err.Error()
+		t.logger.Error("Failed to download model", "error", err, "model", name)
+	}
+
+	t.setup.mu.Lock()
+	t.setup.downloads[name] = state
+	t.setup.mu.Unlock()
+	return err == nil
+}
+
+// downloadWhisperModel makes whisper download a model by transcribing a second of silence,
+// unless the model is already in whisper's cache
+func (t *TranscriberService) downloadWhisperModel(ctx context.Context, model string) error {
+	if _, err := exec.LookPath("whisper"); err != nil {
+		return fmt.Errorf("whisper is not installed, run pip install openai-whisper")
+	}
+	if cached, err := whisperModelCached(model); err == nil && cached {
+		return nil
+	}
+
+	dir, err := t.artifacts.Create("", "whisper_download")
+	if err != nil {
+		return err
+	}
+	defer t.artifacts.Release(dir)
+	silence := filepath.Join(dir, "silence.wav")
+	if err := audiocapture.WriteSilence(silence, time.Second); err != nil {
+		return err
+	}
+	cmd := exec.CommandContext(ctx, "whisper", silence, "--model", model, "--language", "en", "--output_format", "txt", "--output_dir", dir)
+	if output, err := procs.CombinedOutput(cmd); err != nil {
+		return fmt.Errorf("whisper failed to download %s: %w\nOutput: %s", model, err, string(output))
+	}
+	return nil
+}
+
+// whisperModelCached reports whether whisper downloaded the model before
+func whisperModelCached(model string) (bool, error) {
+	cacheDir, err := whisperCacheDir()
+	if err != nil {
+		return false, err
+	}
+	file := map[string]string{"large": "large-v3", "turbo": "large-v3-turbo"}[model]
+	if file == "" {
+		file = model
+	}
+	_, err = os.Stat(filepath.Join(cacheDir, file+".pt"))
+	return err == nil, nil
+}
+
+// whisperCacheDir returns the directory whisper downloads its models to
+func whisperCacheDir() (string, error) {
+	cacheDir := os.Getenv("XDG_CACHE_HOME")
+	if cacheDir == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		cacheDir = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(cacheDir, "whisper"), nil
+}
+
+// pullOllamaModel pulls a model into Ollama unless it is already there
+func pullOllamaModel(ctx context.Context, model string) error {
+	models, err := ollama.Models()
+	if err != nil {
+		return fmt.Errorf("ollama is not running, install it from https://ollama.com and run ollama serve")
+	}
+	for _, name := range models {
+		if name == model || name == model+":latest" {
+			return nil
+		}
+	}
+	return ollama.Pull(ctx, model)
+}
+
+// setupVault sets the vault directory, creating it when it doesn't exist
+func (t *TranscriberService) setupVault(path string) error {
+	if strings.TrimSpace(path) == "" {
+		return fmt.Errorf("%w: vault_path is required", ErrInvalidSetupStep)
+	}
+	if err := osoperations.SetVaultDir(path); err != nil {
+		return err
+	}
+	vaultDir, err := osoperations.VaultDir()
+	if err != nil {
+		return err
+	}
+	if !filepath.IsAbs(vaultDir) {
+		osoperations.SetVaultDir(t.config.Vault.Path)
+		return fmt.Errorf("%w: vault_path must be an absolute path", ErrInvalidSetupStep)
+	}
+	if err := os.MkdirAll(vaultDir, 0755); err != nil {
+		osoperations.SetVaultDir(t.config.Vault.Path)
+		return fmt.Errorf("failed to create vault directory: %w", err)
+	}
+	return t.saveSetup(SetupStepVault, func(cfg *config.Config) {
+		cfg.Vault.Path = path
+	})
+}
+
+// saveSetup applies a change to the running config and the config file, and marks the step
+// as done unless it is empty
+func (t *TranscriberService) saveSetup(step string, change func(cfg *config.Config)) error {
+	apply := func(cfg *config.Config) {
+		change(cfg)
+		if step != "" && !slices.Contains(cfg.Setup.CompletedSteps, step) {
+			cfg.Setup.CompletedSteps = append(cfg.Setup.CompletedSteps, step)
+		}
+	}
+	if err := config.Update(apply); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	t.setup.mu.Lock()
+	apply(t.config)
+	t.setup.mu.Unlock()
+	if step != "" {
+		t.logger.Info("Completed setup step", "step", step)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package transcriber
+
+import (
+	"errors"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/procs"
+)
+
+// childExitTimeout is how long child processes get to exit after being interrupted
+const childExitTimeout = 5 * time.Second
+
+// ErrShuttingDown is returned for recordings and files started while the server shuts down
+var ErrShuttingDown = errors.New("server is shutting down")
+
+// nextTick waits for the next tick of a watcher, or until the service shuts down. Watchers
+// loop with `for ; t.ctx.Err() == nil; t.nextTick(ticker)`, so they exit on shutdown.
+func (t *TranscriberService) nextTick(ticker *time.Ticker) {
+	select {
+	case <-t.ctx.Done():
+	case <-ticker.C:
+	}
+}
+
+// Shutdown finishes or persists the work in flight. The recording in progress is stopped and
+// mixed, and the running job and triggered hooks get until the shutdown timeout to finish;
+// jobs that haven't started keep their status. The background work is then cancelled and child processes are
+// stopped. Meetings left unfinished keep their audio and resume on the next start.
+func (t *TranscriberService) Shutdown() {
+	timeout := time.Duration(t.config.Server.ShutdownTimeout) * time.Second
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	deadline := time.Now().Add(timeout)
+	t.stopping.Store(true)
+
+	if meeting, recorder := t.activeRecording(); meeting != nil {
+		t.logger.Info("Stopping recording for shutdown", "meetingId", meeting.Id)
+		if err := t.StopMeeting(meeting.Id); err != nil {
+			t.logger.Error("Failed to stop recording for shutdown", "error", err, "meetingId", meeting.Id)
+		} else if !recorder.Wait(time.Until(deadline)) {
+			t.logger.Info("Recording wasn't mixed before shutdown, it is mixed on the next start", "meetingId", meeting.Id)
+		}
+	}
+
+	if t.queue.Drain(time.Until(deadline)) {
+		t.logger.Info("Job queue drained for shutdown", "waiting", t.queue.Len())
+	} else {
+		t.logger.Info("Processing didn't finish before shutdown, the meeting resumes on the next start", "waiting", t.queue.Len())
+	}
+	if !t.waitHooks(time.Until(deadline)) {
+		t.logger.Info("Hooks didn't finish before shutdown and were stopped")
+	}
+
+	// Cancelling the background work stops the processes started under it, the others are
+	// stopped through the process registry
+	t.cancel()
+	if killed := procs.Stop(childExitTimeout); killed > 0 {
+		t.logger.Info("Killed child processes that didn't exit on shutdown", "processes", killed)
+	}
+	t.sealRecordings()
+}
@@ -0,0 +1,53 @@
+package transcriber
+
+import (
+	"fmt"
+	"time"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// silenceWarnings explains the likely cause of a silent track
+var silenceWarnings = map[string]string{
+	types.TrackSourceMic:    "microphone appears silent — is the right input device selected and is the microphone allowed?",
+	types.TrackSourceSystem: "system audio appears silent — is BlackHole configured as the output device?",
+}
+
+// watchSilence checks the capture tracks once the configured number of seconds has been
+// recorded and adds a warning to the meeting for every track that is near-silent
+func (t *TranscriberService) watchSilence(meeting *types.Meeting, tracks []types.AudioTrack) {
+	cfg := t.config.Audio.SilenceDetection
+	if !cfg.Enabled || cfg.After <= 0 {
+		return
+	}
+	window := time.Duration(cfg.After) * time.Second
+	time.Sleep(window)
+	if !t.isRecording(meeting) {
+		return // Stopped before enough audio was recorded to judge
+	}
+
+	var warnings []string
+	for _, track := range tracks {
+		level, err := audiocapture.TrackLevel(track.Path, window)
+		if err != nil {
+			t.logger.Error("Failed to measure track level", "error", err, "meetingId", meeting.Id, "track", track.Source)
+			continue
+		}
+		t.logger.Debug("Measured track level", "meetingId", meeting.Id, "track", track.Source, "dBFS", level)
+		if level < cfg.ThresholdDB {
+			warning, ok := silenceWarnings[track.Source]
+			if !ok {
+				warning = fmt.Sprintf("%s track appears silent", track.Source)
+			}
+			warnings = append(warnings, warning)
+		}
+	}
+	if len(warnings) == 0 {
+		return
+	}
+
+	t.addWarning(meeting, events.TypeWarning, warnings...)
+	t.logger.Info("Capture track appears silent", "meetingId", meeting.Id, "warnings", warnings)
+}
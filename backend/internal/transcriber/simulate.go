@@ -0,0 +1,56 @@
+package transcriber
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/events"
+	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrSimulationUnsupported is returned when meetings are simulated without the fake engine
+var ErrSimulationUnsupported = errors.New("simulated meetings require the fake transcription engine")
+
+// SimulateMeeting queues a meeting with an empty recording for processing, as if it had just
+// been stopped. It is used by the load test to drive the pipeline without capturing audio.
+func (t *TranscriberService) SimulateMeeting(opts RecordingOptions) (string, error) {
+	if t.engine.Name() != config.TranscriptionEngineFake {
+		return "", ErrSimulationUnsupported
+	}
+	if opts.Title == "" {
+		opts.Title = "Simulated Meeting"
+	}
+
+	meetingID := uuid.NewString()
+	path := osoperations.CreateFilePath(t.recordDir, meetingID+".wav")
+	if err := os.WriteFile(path, nil, 0644); err != nil {
+		return "", err
+	}
+
+	timestamp := time.Now()
+	meeting := &types.Meeting{
+		Id:              meetingID,
+		Title:           opts.Title,
+		CreatedAt:       timestamp,
+		Start_time:      timestamp,
+		Status:          string(types.MeetingStatusProcessing),
+		Participants:    opts.Participants,
+		Tags:            cleanTags(opts.Tags),
+		Metadata:        opts.Metadata,
+		Owner:           opts.Owner,
+		Template:        opts.Template,
+		Transcript_path: path,
+		Audio_devices:   []types.AudioDevice{},
+	}
+	t.setMeeting(meeting)
+	t.recordEvent(meeting, events.TypeCreated)
+
+	t.queue.Enqueue(meetingID, tagPriority(t.config.Processing.TagPriorities, meeting.Tags), func() {
+		t.processMeeting(t.ctx, meeting)
+	})
+	return meetingID, nil
+}
@@ -0,0 +1,138 @@
+package transcriber
+
+import (
+	"context"
+	"errors"
+	"math"
+	"os"
+	"time"
+
+	"github.com/google/uuid"
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/devices"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// Soundcheck timings
+const (
+	soundcheckDuration = 3 * time.Second
+	soundcheckTTL      = 5 * time.Minute // How long the playback file is kept
+)
+
+// silenceFloorDB is reported for tracks without any signal, which have a level of -Inf
+const silenceFloorDB = -120
+
+// ErrRecordingInProgress is returned when a recording or soundcheck is requested during a recording
+var ErrRecordingInProgress = errors.New("a meeting is being recorded")
+
+// ErrSoundcheckNotFound is returned for unknown or expired soundchecks
+var ErrSoundcheckNotFound = errors.New("soundcheck not found or expired")
+
+// SoundcheckOptions selects the devices to test, like the options of a recording
+type SoundcheckOptions struct {
+	Series       string
+	Title        string
+	MicDevice    string
+	SystemDevice string
+	Duration     time.Duration // Length of the test recording, 0 uses the default of a few seconds
+}
+
+// TrackLevel is the measured level of a capture track
+type TrackLevel struct {
+	Source  string  `json:"source"`
+	Device  string  `json:"device"`
+	LevelDB float64 `json:"level_db"` // RMS level in dBFS
+	Silent  bool    `json:"silent"`   // Below the silence detection threshold
+}
+
+// SoundcheckResult is a short test recording of the capture devices
+type SoundcheckResult struct {
+	Id        string       `json:"id"`
+	Levels    []TrackLevel `json:"levels"`
+	Warnings  []string     `json:"warnings,omitempty"`
+	ExpiresAt time.Time    `json:"expires_at"` // When the playback file is removed
+	mixPath   string
+}
+
+// Soundcheck records a few seconds from the devices a recording with the options would use,
+// measures the level of both tracks and keeps the mix for playback until it expires
+func (t *TranscriberService) Soundcheck(ctx context.Context, opts SoundcheckOptions) (*SoundcheckResult, error) {
+	if !t.Capabilities().Recording {
+		return nil, ErrFFmpegMissing
+	}
+	if t.recordingActive() {
+		return nil, ErrRecordingInProgress
+	}
+
+	if opts.Duration <= 0 {
+		opts.Duration = soundcheckDuration
+	}
+	captureDevices := t.resolveDevices(devices.SeriesKey(opts.Series, opts.Title), opts.MicDevice, opts.SystemDevice)
+	if err := t.matchDevices(&captureDevices); err != nil {
+		return nil, err
+	}
+	dir, err := t.artifacts.Create("", "soundcheck")
+	if err != nil {
+		return nil, err
+	}
+	check, err := t.capturer.RecordSoundcheck(ctx, dir, captureDevices, opts.Duration, t.mixOptions().Format)
+	if err != nil {
+		t.artifacts.Release(dir)
+		return nil, err
+	}
+	// Only the mix is kept for playback
+	defer os.Remove(check.MicPath)
+	defer os.Remove(check.SystemPath)
+
+	result := &SoundcheckResult{
+		Id:        uuid.NewString(),
+		ExpiresAt: time.Now().Add(soundcheckTTL),
+		mixPath:   check.MixPath,
+	}
+	for _, track := range []struct{ source, device, path string }{
+		{types.TrackSourceMic, captureDevices.Mic, check.MicPath},
+		{types.TrackSourceSystem, captureDevices.System, check.SystemPath},
+	} {
+		level, err := audiocapture.TrackLevel(track.path, opts.Duration)
+		if err != nil {
+			t.artifacts.Release(dir)
+			return nil, err
+		}
+		level = math.Max(level, silenceFloorDB)
+		silent := level < t.config.Audio.SilenceDetection.ThresholdDB
+		result.Levels = append(result.Levels, TrackLevel{
+			Source:  track.source,
+			Device:  track.device,
+			LevelDB: math.Round(level*10) / 10,
+			Silent:  silent,
+		})
+		if silent {
+			result.Warnings = append(result.Warnings, silenceWarnings[track.source])
+		}
+	}
+
+	t.mu.Lock()
+	t.soundchecks[result.Id] = result
+	t.mu.Unlock()
+	time.AfterFunc(soundcheckTTL, func() {
+		t.mu.Lock()
+		delete(t.soundchecks, result.Id)
+		t.mu.Unlock()
+		t.artifacts.Release(dir)
+	})
+
+	t.logger.Info("Recorded soundcheck", "id", result.Id, "mic", captureDevices.Mic, "system", captureDevices.System, "warnings", len(result.Warnings))
+	return result, nil
+}
+
+// SoundcheckAudio returns the path of the mixed soundcheck recording
+func (t *TranscriberService) SoundcheckAudio(id string) (string, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	result, ok := t.soundchecks[id]
+	if !ok {
+		return "", ErrSoundcheckNotFound
+	}
+	return result.mixPath, nil
+}
@@ -0,0 +1,172 @@
+package transcriber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/frontmatter"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrInvalidStages is returned for processing stages that can't be run in the given order
+var ErrInvalidStages = errors.New("invalid processing stages")
+
+// stageOrder lists every stage with the stages that must come before it when both run
+var stageOrder = map[string][]string{
+	config.StageTranscribe:     nil,
+	config.StageDiarize:        {config.StageTranscribe},
+	config.StageSummarize:      {config.StageTranscribe, config.StageDiarize},
+	config.StageExtractActions: {config.StageSummarize},
+	config.StageSaveVault:      {config.StageSummarize, config.StageExtractActions},
+	config.StageSaveNotion:     {config.StageSummarize, config.StageExtractActions},
+	config.StageSaveConfluence: {config.StageSummarize, config.StageExtractActions},
+	config.StageWebhook:        {config.StageTranscribe},
+}
+
+// validateStages checks that the stages are known, start with transcribe and come after the
+// stages they build on. Action items are taken from the summary, so extract-actions needs
+// summarize. Stages that send the meeting elsewhere need their destination configured.
+func validateStages(stages []string, cfg *config.Config) error {
+	if len(stages) == 0 || stages[0] != config.StageTranscribe {
+		return fmt.Errorf("%w: the first stage must be %s", ErrInvalidStages, config.StageTranscribe)
+	}
+	for i, stage := range stages {
+		before, ok := stageOrder[stage]
+		if !ok {
+			return fmt.Errorf("%w: unknown stage %q", ErrInvalidStages, stage)
+		}
+		if slices.Contains(stages[:i], stage) {
+			return fmt.Errorf("%w: %s is listed twice", ErrInvalidStages, stage)
+		}
+		for _, other := range before {
+			if slices.Contains(stages[i+1:], other) {
+				return fmt.Errorf("%w: %s must come before %s", ErrInvalidStages, other, stage)
+			}
+		}
+	}
+	if slices.Contains(stages, config.StageExtractActions) && !slices.Contains(stages, config.StageSummarize) {
+		return fmt.Errorf("%w: %s needs %s", ErrInvalidStages, config.StageExtractActions, config.StageSummarize)
+	}
+	if slices.Contains(stages, config.StageWebhook) && cfg.Processing.WebhookURL == "" {
+		return fmt.Errorf("%w: %s needs processing.webhook_url", ErrInvalidStages, config.StageWebhook)
+	}
+	if slices.Contains(stages, config.StageSaveNotion) && !cfg.Publish.Notion.Enabled() {
+		return fmt.Errorf("%w: %s needs publish.notion.database_id", ErrInvalidStages, config.StageSaveNotion)
+	}
+	if slices.Contains(stages, config.StageSaveConfluence) && !cfg.Publish.Confluence.Enabled() {
+		return fmt.Errorf("%w: %s needs publish.confluence.space_key", ErrInvalidStages, config.StageSaveConfluence)
+	}
+	return nil
+}
+
+// meetingStages returns the stages the meeting is processed with
+func (t *TranscriberService) meetingStages(meeting *types.Meeting) []string {
+	if len(meeting.Stages) > 0 {
+		return meeting.Stages
+	}
+	return t.config.Processing.Stages
+}
+
+// runsStage reports whether the meeting is processed with the stage
+func (t *TranscriberService) runsStage(meeting *types.Meeting, stage string) bool {
+	return slices.Contains(t.meetingStages(meeting), stage)
+}
+
+// usesOllama reports whether Ollama may be asked about the meeting: it must be reachable and
+// the meeting must be summarized
+func (t *TranscriberService) usesOllama(meeting *types.Meeting) bool {
+	return t.runsStage(meeting, config.StageSummarize) && t.Capabilities().Summarize
+}
+
+// diarize drops the speakers the engine found when the meeting isn't diarized
+func (t *TranscriberService) diarize(meeting *types.Meeting, segments []types.Segment) {
+	if t.runsStage(meeting, config.StageDiarize) {
+		return
+	}
+	for i := range segments {
+		segments[i].Speaker = ""
+	}
+}
+
+// webhookTimeout is how long the webhook receiver gets to accept a meeting
+const webhookTimeout = 30 * time.Second
+
+// postWebhook sends the processed meeting as JSON to the configured webhook URL
+func (t *TranscriberService) postWebhook(ctx context.Context, meeting *types.Meeting) error {
+	body, err := json.Marshal(meeting)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, webhookTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, t.config.Processing.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// publishPage publishes the meeting to the destination of the stage and records the URL of
+// the page. Failures are logged but don't fail the meeting, like webhook failures.
+func (t *TranscriberService) publishPage(ctx context.Context, meeting *types.Meeting, stage string) {
+	destination, ok := t.destinations[stage]
+	if !ok {
+		// The meeting was started before the destination was removed from the config
+		t.logger.Info("Skipping stage without a configured destination", "stage", stage, "meetingId", meeting.Id)
+		return
+	}
+	url, err := destination.Publish(ctx, meeting)
+	if err != nil {
+		t.logger.Error("Failed to publish meeting", "error", err, "destination", destination.Name(), "meetingId", meeting.Id)
+	}
+	if url == "" {
+		return
+	}
+	if meeting.PageURLs == nil {
+		meeting.PageURLs = make(map[string]string)
+	}
+	meeting.PageURLs[destination.Name()] = url
+	t.logger.Info("Published meeting", "destination", destination.Name(), "url", url, "meetingId", meeting.Id)
+}
+
+// extractActions stores the action items of the summary on the meeting
+func extractActions(meeting *types.Meeting) {
+	meeting.ActionItems = frontmatter.ActionItems(meeting.Summary)
+}
+
+// stageContext bounds a pipeline stage by its timeout in minutes; 0 leaves it unbounded
+func stageContext(ctx context.Context, minutes int) (context.Context, context.CancelFunc) {
+	if minutes <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, time.Duration(minutes)*time.Minute)
+}
+
+// stageError replaces the error of a stage that ran out of time or was cancelled, such as a
+// killed process, with the reason
+func stageError(ctx context.Context, err error) error {
+	if err == nil || ctx.Err() == nil {
+		return err
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("timed out: %w", ctx.Err())
+	}
+	return ctx.Err()
+}
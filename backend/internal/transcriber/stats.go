@@ -0,0 +1,37 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/martijnspitter/transcriber/internal/stats"
+)
+
+// ErrNoTranscript is returned for stats of meetings that weren't transcribed yet
+var ErrNoTranscript = errors.New("meeting has no transcript")
+
+// MeetingStats returns the talk time, pace, filler words and longest monologue of a meeting
+// computed from its transcript segments
+func (t *TranscriberService) MeetingStats(meetingId string) (stats.Stats, error) {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return stats.Stats{}, err
+	}
+	if len(meeting.Segments) == 0 {
+		return stats.Stats{}, fmt.Errorf("%w: %s", ErrNoTranscript, meetingId)
+	}
+	return stats.Compute(meeting.Segments, float64(meeting.Duration)), nil
+}
+
+// AggregateStats returns the stats of the transcribed meetings matching the filter together,
+// e.g. of the meetings in a date range
+func (t *TranscriberService) AggregateStats(filter MeetingFilter) stats.Aggregate {
+	aggregate := stats.Aggregate{Fillers: make(map[string]int)}
+	for _, meeting := range t.GetAllMeetings() {
+		if len(meeting.Segments) == 0 || !filter.Matches(meeting) {
+			continue
+		}
+		aggregate.Add(meeting.Id, stats.Compute(meeting.Segments, float64(meeting.Duration)))
+	}
+	return aggregate
+}
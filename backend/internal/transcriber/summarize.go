@@ -1,77 +1,98 @@
 package transcriber
 
 import (
+	"context"
 	"fmt"
 
+	"github.com/martijnspitter/transcriber/internal/frontmatter"
 	"github.com/martijnspitter/transcriber/internal/ollama"
+	"github.com/martijnspitter/transcriber/internal/prompts"
+	"github.com/martijnspitter/transcriber/internal/types"
 )
 
-func (t *TranscriberService) Summarize() (string, error) {
-	if t.meeting.Transcript == "" {
-		return "", fmt.Errorf("transcription cannot be empty")
-	}
-
-	// Comprehensive instructions with structured template
-	systemPrompt := `You are an assistant that summarizes meeting transcripts into a standardized markdown format. You do not have to wrap the output in markdown code blocks.
-
-Your summary MUST follow this exact structure, with all sections included even if empty:
-
----
-id: {{meeting_title from transcript}}
-tags:
-  - meeting-notes
-created: {{date from transcript}}
-type: #meeting
-updated: {{date from transcript}}
----
-
-# {{meeting_title from transcript}}
+// Summarize generates the meeting summary with the default model and the meeting's template,
+// reviewed when enabled
+func (t *TranscriberService) Summarize(ctx context.Context, meeting *types.Meeting) (string, error) {
+	return t.reviewedSummary(ctx, meeting, t.config.Ollama.Model, t.systemPrompt(meeting))
+}
 
-## Participants
-- [[{{participant1}}]]
-- [[{{participant2}}]]
-(include all participants mentioned in the transcript)
+// systemPrompt returns the prompt of the meeting's template, falling back to the
+// default template when it is unset or has been deleted since the meeting started
+func (t *TranscriberService) systemPrompt(meeting *types.Meeting) string {
+	name := meeting.Template
+	if name == "" {
+		name = prompts.DefaultTemplate
+	}
 
-## Summary
-(provide a concise summary of the entire meeting)
+	template, err := t.prompts.Get(name)
+	if err != nil && name != prompts.DefaultTemplate {
+		t.logger.Error("Meeting template unavailable, using default", "error", err, "meetingId", meeting.Id, "template", name)
+		template, err = t.prompts.Get(prompts.DefaultTemplate)
+	}
+	if err != nil {
+		t.logger.Error("Default template unavailable, using built-in", "error", err, "meetingId", meeting.Id)
+		return prompts.BuiltinDefault()
+	}
+	return template.Prompt
+}
 
-## Key Points
-- Key point 1
-- Key point 2
-(list all important points discussed)
+// Prompts returns the summarization template store
+func (t *TranscriberService) Prompts() *prompts.Store {
+	return t.prompts
+}
 
-## Decisions
-- Decision 1
-- Decision 2
-(list all decisions made during the meeting)
+// summarizeWith generates the meeting summary with a specific model and system prompt
+func (t *TranscriberService) summarizeWith(ctx context.Context, meeting *types.Meeting, model string, systemPrompt string) (string, error) {
+	if meeting.Transcript == "" {
+		return "", fmt.Errorf("transcription cannot be empty")
+	}
+	_, summary, err := t.completeSummary(ctx, meeting, model, t.summaryMessages(meeting, systemPrompt))
+	return summary, err
+}
 
-## Action Items
-- [[Person responsible]] will do task by deadline
-- [[Another person]] to follow up on X
-(list all action items with responsible persons in [[name]] format and deadlines if mentioned)
+// cites reports whether the summary of a meeting cites moments of its transcript
+func (t *TranscriberService) cites(meeting *types.Meeting) bool {
+	return t.config.Ollama.Citations && len(meeting.Segments) > 0
+}
 
-Important guidelines:
-1. ALL participant names MUST be formatted with double square brackets like [[Name]]
-2. Extract the meeting title and date from the transcript
-3. If certain sections have no content, include "None identified" rather than leaving blank
-4. Focus on extracting factual information only
-5. Maintain the exact structure provided - do not add or remove sections`
+// summaryMessages returns the chat asking the model for the summary of a meeting
+func (t *TranscriberService) summaryMessages(meeting *types.Meeting, systemPrompt string) []ollama.Message {
+	// With citations the transcript is given as lines starting with the second they were said
+	transcript := meeting.Transcript
+	if t.cites(meeting) {
+		systemPrompt += citationsPrompt
+		transcript = citationTranscript(meeting.Segments)
+	}
 
-	msgs := []ollama.Message{
+	return []ollama.Message{
 		{
 			Role:    "system",
-			Content: systemPrompt,
+			Content: systemPrompt + t.peoplePrompt(meeting) + t.seriesPrompt(meeting),
 		},
 		{
 			Role:    "user",
-			Content: fmt.Sprintf("Summarize the following meeting transcript into the required format: \n\n%s", t.meeting.Transcript),
+			Content: fmt.Sprintf("Summarize the following meeting transcript into the required format: \n\n%s", transcript) + notesPrompt(meeting),
 		},
 	}
+}
 
-	res, err := ollama.TalkToOllama(msgs)
+// completeSummary runs a summary chat. It returns the answer of the model, to continue the
+// chat with, and the summary made of it.
+func (t *TranscriberService) completeSummary(ctx context.Context, meeting *types.Meeting, model string, msgs []ollama.Message) (string, string, error) {
+	res, err := t.chat(ctx, model, msgs)
 	if err != nil {
-		return "", fmt.Errorf("failed to talk to Ollama: %w", err)
+		return "", "", fmt.Errorf("failed to talk to Ollama: %w", err)
+	}
+
+	summary := res.Message.Content
+	if t.cites(meeting) {
+		var removed int
+		summary, removed = checkCitations(summary, meeting.Segments)
+		if removed > 0 {
+			t.logger.Info("Removed citations of moments not in the transcript", "meetingId", meeting.Id, "citations", removed)
+		}
 	}
 
-	return res.Message.Content, nil
+	// The frontmatter is generated from the meeting when the note is saved
+	return res.Message.Content, t.linkPeople(frontmatter.Strip(summary)), nil
 }
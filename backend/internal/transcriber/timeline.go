@@ -0,0 +1,85 @@
+package transcriber
+
+import (
+	"sort"
+)
+
+// Kinds of timeline entries, in the order entries starting at the same offset are listed
+const (
+	TimelineChapter = "chapter"
+	TimelineSegment = "segment"
+	TimelineMarker  = "marker"
+	TimelineNote    = "note"
+)
+
+var timelineKindOrder = map[string]int{
+	TimelineChapter: 0,
+	TimelineSegment: 1,
+	TimelineMarker:  2,
+	TimelineNote:    3,
+}
+
+// TimelineEntry is a transcript segment, bookmark, chapter or note placed on the audio of
+// a meeting. Offsets are in seconds from the start of the recording; markers and notes are
+// moments and have no end.
+type TimelineEntry struct {
+	Kind     string  `json:"kind"`
+	Start    float64 `json:"start"`
+	End      float64 `json:"end,omitempty"`
+	Text     string  `json:"text"` // Segment text, marker label, chapter title or note
+	Speaker  string  `json:"speaker,omitempty"`
+	Source   string  `json:"source,omitempty"`
+	Language string  `json:"language,omitempty"`
+	Index    int     `json:"index"` // Position of the entry among the meeting's entries of its kind
+}
+
+// Timeline is a meeting as a single time-ordered list of entries, for players that show a
+// scrubber synchronized with the audio
+type Timeline struct {
+	Duration int             `json:"duration"` // Seconds of audio
+	Entries  []TimelineEntry `json:"entries"`
+}
+
+// MeetingTimeline returns the segments, bookmarks, chapters and notes of a meeting merged
+// into one list ordered by their offset in the audio
+func (t *TranscriberService) MeetingTimeline(meetingId string) (Timeline, error) {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return Timeline{}, err
+	}
+
+	entries := make([]TimelineEntry, 0, len(meeting.Segments)+len(meeting.Bookmarks)+len(meeting.Chapters)+len(meeting.Notes))
+	for i, chapter := range meeting.Chapters {
+		entries = append(entries, TimelineEntry{Kind: TimelineChapter, Start: chapter.Start, End: chapter.End, Text: chapter.Title, Index: i})
+	}
+	for i, segment := range meeting.Segments {
+		entries = append(entries, TimelineEntry{
+			Kind:     TimelineSegment,
+			Start:    segment.Start,
+			End:      segment.End,
+			Text:     segment.Text,
+			Speaker:  segment.Speaker,
+			Source:   segment.Source,
+			Language: segment.Language,
+			Index:    i,
+		})
+	}
+	for i, bookmark := range meeting.Bookmarks {
+		label := bookmark.Note
+		if label == "" {
+			label = defaultMarkerLabel
+		}
+		entries = append(entries, TimelineEntry{Kind: TimelineMarker, Start: bookmark.At, Text: label, Index: i})
+	}
+	for i, note := range meeting.Notes {
+		entries = append(entries, TimelineEntry{Kind: TimelineNote, Start: note.At, Text: note.Text, Index: i})
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		if entries[i].Start != entries[j].Start {
+			return entries[i].Start < entries[j].Start
+		}
+		return timelineKindOrder[entries[i].Kind] < timelineKindOrder[entries[j].Kind]
+	})
+	return Timeline{Duration: meeting.Duration, Entries: entries}, nil
+}
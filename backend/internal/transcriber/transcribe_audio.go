@@ -2,7 +2,10 @@ package transcriber
 
 import (
 	"bufio"
+	"context"
 	"fmt"
+	"io"
+	"math"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -11,56 +14,77 @@ import (
 
 	"github.com/martijnspitter/transcriber/internal/logger"
 	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/procs"
+	"github.com/martijnspitter/transcriber/internal/punctuation"
 	"github.com/martijnspitter/transcriber/internal/types"
 )
 
+// WhisperOptions selects the whisper model and spoken language
+type WhisperOptions struct {
+	Model    string // tiny, base, small, medium, large or turbo
+	Language string // Empty lets whisper detect the language
+}
+
 type Transcriber struct {
 	audioFilePath string
-	summary       string
+	workDir       string // Whisper writes its output here, every run into a directory of its own
+	options       WhisperOptions
 	logger        *logger.Logger
-	meeting       *types.Meeting
 }
 
-func NewTranscriber(audioFilePath string, logger *logger.Logger, meeting *types.Meeting) *Transcriber {
+func NewTranscriber(audioFilePath string, workDir string, options WhisperOptions, logger *logger.Logger) *Transcriber {
+	if options.Model == "" {
+		options.Model = "medium"
+	}
 	return &Transcriber{
 		audioFilePath: audioFilePath,
-		summary:       "",
+		workDir:       workDir,
+		options:       options,
 		logger:        logger,
-		meeting:       meeting,
 	}
 }
 
-func (s *Transcriber) TranscribeAudio() (string, error) {
-	// Check if meeting data is available
-	if s.meeting == nil {
-		return "", fmt.Errorf("meeting data not provided")
+// outputDir returns a new directory in the working directory for the output of a whisper run
+func (s *Transcriber) outputDir() (string, error) {
+	dir, err := os.MkdirTemp(s.workDir, "whisper_output")
+	if err != nil {
+		return "", fmt.Errorf("failed to create whisper output directory: %w", err)
+	}
+	return dir, nil
+}
+
+// whisperArgs returns the whisper CLI arguments to transcribe into the output directory,
+// forcing the language when one is given
+func (s *Transcriber) whisperArgs(outputDir string, format string, language string) []string {
+	args := []string{s.audioFilePath, "--model", s.options.Model}
+	if language != "" {
+		args = append(args, "--language", language)
 	}
-	s.logger.Info("Starting transcription using OpenAI Whisper")
+	return append(args,
+		"--output_dir", outputDir,
+		"--output_format", format,
+		"--verbose", "False")
+}
+
+// TranscribeAudio runs whisper on the audio file and returns the timestamped segments
+func (s *Transcriber) TranscribeAudio(ctx context.Context) ([]types.Segment, error) {
+	s.logger.Info("Starting transcription using OpenAI Whisper", "file", s.audioFilePath)
 
 	// Get just the filename without extension for output file naming
 	audioFileNameWithoutExt := osoperations.GetFileNameWithoutExtension(s.audioFilePath)
 
-	// Create a temporary output directory
-	tempDir, err := osoperations.CreateTempDirectory("whisper_output")
+	// Create the output directory, removed with the working directory
+	tempDir, err := s.outputDir()
 	if err != nil {
-		return "", fmt.Errorf("failed to create temp directory: %w", err)
-	}
-	defer osoperations.RemoveTempDirectory(tempDir) // Clean up temp dir when done
-
-	// Prepare the whisper command
-	// Adjust model size as needed: tiny, base, small, medium, large, turbo
-	modelSize := "medium"
-	cmd := exec.Command("whisper",
-		s.audioFilePath,
-		"--model", modelSize,
-		"--language", "en",
-		"--output_dir", tempDir,
-		"--output_format", "srt", // Use SRT format to get timestamps
-		"--verbose", "False")
+		return nil, err
+	}
+
+	// Prepare the whisper command, using SRT format to get timestamps
+	cmd := exec.CommandContext(ctx, "whisper", s.whisperArgs(tempDir, "srt", s.options.Language)...)
 
 	// Run the whisper command
 	s.logger.Info("Running Whisper command", "command", cmd.String())
-	output, err := cmd.CombinedOutput()
+	output, err := procs.CombinedOutput(cmd)
 	if err != nil {
 		s.logger.Error("Whisper transcription failed", err)
 		s.logger.Error("Command output", string(output))
@@ -73,7 +97,7 @@ func (s *Transcriber) TranscribeAudio() (string, error) {
 		}
 		s.logger.Info(fileList)
 
-		return "", fmt.Errorf("whisper transcription failed: %w\nOutput: %s", err, string(output))
+		return nil, fmt.Errorf("whisper transcription failed: %w\nOutput: %s", err, string(output))
 	}
 
 	// Whisper will save the txt file with the same base name as the input file
@@ -101,71 +125,110 @@ func (s *Transcriber) TranscribeAudio() (string, error) {
 				fileList += file.Name() + ", "
 			}
 			s.logger.Info(fileList)
-			return "", fmt.Errorf("no transcription file found in output directory")
+			return nil, fmt.Errorf("no transcription file found in output directory")
 		}
 	}
 
 	// Parse the SRT file to extract segments with timestamps
 	segments, err := parseSRTFile(expectedOutputFile)
 	if err != nil {
-		return "", fmt.Errorf("failed to parse SRT file: %w", err)
+		return nil, fmt.Errorf("failed to parse SRT file: %w", err)
+	}
+	s.logger.Info("Parsed segments from SRT file", "count", len(segments))
+
+	// Run the segment text through the punctuation pass, which only changes segments
+	// Whisper left unpunctuated. Segments are fed as one stream so sentences spanning
+	// segments keep their casing.
+	punctuator := punctuation.NewPunctuator()
+	for i := range segments {
+		segments[i].Text = punctuator.Feed(segments[i].Text, i == len(segments)-1)
+	}
+
+	s.logger.Info("Transcription completed")
+	return segments, nil
+}
+
+// FormatTranscript renders the markdown transcript of a meeting from its segments
+func FormatTranscript(meeting *types.Meeting, segments []types.Segment) string {
+	var transcript strings.Builder
+	transcript.WriteString(transcriptHeader(meeting))
+
+	// Add timestamps to each segment
+	for _, segment := range segments {
+		transcript.WriteString(formatLine(segment) + "\n")
 	}
-	s.logger.Info("Parsed %d segments from SRT file", len(segments))
 
-	// Create markdown header with meeting info
-	header := fmt.Sprintf("# %s\n\n", s.meeting.Title)
-	header += fmt.Sprintf("**Date:** %s\n\n", s.meeting.CreatedAt.Format("January 2, 2006"))
-	header += fmt.Sprintf("**Duration:** %d minutes %d seconds\n\n", s.meeting.Duration/60, s.meeting.Duration%60)
+	return transcript.String()
+}
+
+// transcriptHeader returns the markdown header with the meeting info, up to the transcript heading
+func transcriptHeader(meeting *types.Meeting) string {
+	header := fmt.Sprintf("# %s\n\n", meeting.Title)
+	header += fmt.Sprintf("**Date:** %s\n\n", meeting.CreatedAt.Format("January 2, 2006"))
+	header += fmt.Sprintf("**Duration:** %d minutes %d seconds\n\n", meeting.Duration/60, meeting.Duration%60)
 
-	if len(s.meeting.Participants) > 0 {
+	if len(meeting.Participants) > 0 {
 		header += "**Participants:**\n"
-		for _, participant := range s.meeting.Participants {
+		for _, participant := range meeting.Participants {
 			header += fmt.Sprintf("- %s\n", participant)
 		}
 		header += "\n"
 	}
 
 	header += "## Transcript\n\n"
+	return header
+}
 
-	// Generate transcript with the formatted segments
-	s.logger.Info("Generating transcript from segments")
-	var transcript strings.Builder
-	transcript.WriteString(header)
-
-	// Add timestamps to each segment
-	for _, segment := range segments {
-		transcript.WriteString(fmt.Sprintf("[%s --> %s] %s\n", segment.startTime, segment.endTime, segment.text))
+// formatLine formats a transcript segment as a timestamped line, followed by the bookmarks
+// made during it
+func formatLine(segment types.Segment) string {
+	line := fmt.Sprintf("[%s --> %s] %s", FormatTimestamp(segment.Start), FormatTimestamp(segment.End), segment.Text)
+	for _, marker := range segment.Markers {
+		line += " 🔖 " + marker
 	}
+	return line
+}
 
-	s.logger.Info("Adding summary to transcript")
-	s.summary = transcript.String()
-
-	s.logger.Info("Transcription completed")
-	return s.summary, nil
+// FormatTimestamp formats an offset in seconds as an SRT timestamp (00:00:00,000)
+func FormatTimestamp(seconds float64) string {
+	millis := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d,%03d", millis/3600000, millis/60000%60, millis/1000%60, millis%1000)
 }
 
-type Segment struct {
-	startTime string
-	endTime   string
-	text      string
+// parseTimestamp parses an SRT (00:00:00,000) or VTT (00:00:00.000) timestamp into seconds
+func parseTimestamp(value string) (float64, error) {
+	var hours, minutes, seconds, millis int
+	value = strings.Replace(value, ".", ",", 1)
+	if strings.Count(value, ":") == 1 {
+		value = "00:" + value // WebVTT leaves out the hours of short transcripts
+	}
+	if _, err := fmt.Sscanf(value, "%d:%d:%d,%d", &hours, &minutes, &seconds, &millis); err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q: %w", value, err)
+	}
+	return float64(hours*3600+minutes*60+seconds) + float64(millis)/1000, nil
 }
 
-func parseSRTFile(filePath string) ([]Segment, error) {
+func parseSRTFile(filePath string) ([]types.Segment, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
+	return parseCues(file)
+}
 
-	var segments []Segment
-	scanner := bufio.NewScanner(file)
+// parseCues parses the cues of an SRT or WebVTT transcript into segments
+func parseCues(r io.Reader) ([]types.Segment, error) {
+	var segments []types.Segment
+	scanner := bufio.NewScanner(r)
 
-	var currentSegment Segment
+	var currentSegment types.Segment
 	var isReadingText bool
 	var textLines []string
 
-	// Regular expression to match SRT timestamp line (e.g., "00:00:00,000 --> 00:00:05,000")
-	timestampRegex := regexp.MustCompile(`(\d{2}:\d{2}:\d{2},\d{3}) --> (\d{2}:\d{2}:\d{2},\d{3})`)
+	// Regular expression to match SRT timestamp line (e.g., "00:00:00,000 --> 00:00:05,000"),
+	// or a WebVTT one, where the hours are optional (e.g., "00:00.000 --> 00:05.000")
+	timestampRegex := regexp.MustCompile(`((?:\d+:)?\d{2}:\d{2}[,.]\d{3}) --> ((?:\d+:)?\d{2}:\d{2}[,.]\d{3})`)
 
 	for scanner.Scan() {
 		line := scanner.Text()
@@ -174,10 +237,18 @@ func parseSRTFile(filePath string) ([]Segment, error) {
 		matches := timestampRegex.FindStringSubmatch(line)
 		if len(matches) > 0 {
 			// Found timestamp line, start a new segment
+			start, err := parseTimestamp(matches[1])
+			if err != nil {
+				return nil, err
+			}
+			end, err := parseTimestamp(matches[2])
+			if err != nil {
+				return nil, err
+			}
 			isReadingText = true
-			currentSegment = Segment{
-				startTime: matches[1],
-				endTime:   matches[2],
+			currentSegment = types.Segment{
+				Start: start,
+				End:   end,
 			}
 			textLines = []string{}
 			continue
@@ -185,7 +256,7 @@ func parseSRTFile(filePath string) ([]Segment, error) {
 
 		// If line is empty and we were reading text, end of segment
 		if line == "" && isReadingText && len(textLines) > 0 {
-			currentSegment.text = strings.Join(textLines, " ")
+			currentSegment.Text = strings.Join(textLines, " ")
 			segments = append(segments, currentSegment)
 			isReadingText = false
 			continue
@@ -204,7 +275,7 @@ func parseSRTFile(filePath string) ([]Segment, error) {
 
 	// Add the last segment if there's text
 	if isReadingText && len(textLines) > 0 {
-		currentSegment.text = strings.Join(textLines, " ")
+		currentSegment.Text = strings.Join(textLines, " ")
 		segments = append(segments, currentSegment)
 	}
 
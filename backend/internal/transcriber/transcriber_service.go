@@ -1,204 +1,975 @@
 package transcriber
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"os"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/martijnspitter/transcriber/internal/artifacts"
 	"github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/calendar"
+	"github.com/martijnspitter/transcriber/internal/chapters"
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/crm"
+	"github.com/martijnspitter/transcriber/internal/devices"
+	"github.com/martijnspitter/transcriber/internal/encryption"
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/frontmatter"
 	"github.com/martijnspitter/transcriber/internal/logger"
+	"github.com/martijnspitter/transcriber/internal/meetingapps"
+	"github.com/martijnspitter/transcriber/internal/naming"
+	"github.com/martijnspitter/transcriber/internal/objectstore"
 	osoperations "github.com/martijnspitter/transcriber/internal/os_operations"
+	"github.com/martijnspitter/transcriber/internal/people"
+	"github.com/martijnspitter/transcriber/internal/prompts"
+	"github.com/martijnspitter/transcriber/internal/publish"
+	"github.com/martijnspitter/transcriber/internal/punctuation"
 	"github.com/martijnspitter/transcriber/internal/types"
+	"github.com/martijnspitter/transcriber/internal/update"
+	"github.com/martijnspitter/transcriber/internal/voicememos"
 )
 
 type TranscriberService struct {
-	meeting   *types.Meeting
-	logger    *logger.Logger
-	recorder  *audiocapture.CombinedAudio
-	meetings  map[string]*types.Meeting
-	recordDir string // Directory to store recordings
+	mu           sync.RWMutex
+	indexMu      sync.Mutex // Serializes writes of the meetings index note
+	recordMu     sync.Mutex // Serializes starting recordings, so only one is recorded at a time
+	config       *config.Config
+	meeting      *types.Meeting
+	logger       *logger.Logger
+	recorder     audiocapture.Recorder
+	capturer     audiocapture.Capturer   // Records from the audio devices, ffmpeg or the dev mode sample
+	transcoder   audiocapture.Transcoder // Converts recordings, ffmpeg or the dev mode sample
+	meetings     map[string]*types.Meeting
+	queue        *jobQueue
+	engine       Engine
+	crm          crm.Client                     // Nil when the CRM integration is disabled
+	objects      *objectstore.Client            // Nil when uploads to object storage are disabled
+	destinations map[string]publish.Destination // Notion and Confluence, by the stage that publishes to them
+	calendar     *calendar.Feed                 // Nil when no calendar is configured
+	meetingApps  *meetingapps.Detector          // Nil when meeting app detection is disabled
+	updates      *update.Checker                // Nil when update checks are disabled
+	voiceMemos   *voicememos.Library            // Voice Memos recordings that can be imported
+	prompts      *prompts.Store
+	events       *events.Log        // Append-only meeting history the meetings are restored from
+	cipher       *encryption.Cipher // Encrypts stored recordings and transcripts, nil when disabled
+	people       *people.Store
+	devices      *devices.Store     // Capture devices last used per meeting series
+	naming       *naming.Templates  // File and note name templates
+	transcripts  *chapters.Renderer // Renders the transcript section of meeting notes
+	capabilities *capabilityCache
+	batches      map[string]*ResummarizeBatch
+	summaryJobs  map[string]*SummaryJob
+	soundchecks  map[string]*SoundcheckResult // Test recordings kept for playback until they expire
+	armed        *ArmedRecording              // Capture started ahead of the next recording
+	setup        *setupState
+	embeddings   *embeddingIndex
+	recordDir    string // Directory to store recordings
+	artifacts    *artifacts.Manager
+	hooks        chan hookRun // Triggered hooks, run one at a time
+	hooksPending sync.WaitGroup
+	stopping     atomic.Bool                   // Set on shutdown, interrupted meetings are left to resume on the next start
+	lastFailure  *Failure                      // Most recent meeting that failed since the start
+	editMu       sync.Mutex                    // Held by changes to a meeting from the version check until they are recorded
+	playbackMu   sync.Mutex                    // Guards playbackJobs
+	playbackJobs map[string]*playbackTranscode // Running transcodes for playback, by cached path
+	storedFiles  storedFileCache               // Decrypted stored files, for range requests
+	// Background work such as recordings and processing runs under ctx, cancelled on shutdown
+	ctx    context.Context
+	cancel context.CancelFunc
 }
 
-func NewTranscriberService(logger *logger.Logger) *TranscriberService {
-	tempDir, err := osoperations.CreateTempDirectory("recording_output")
+func NewTranscriberService(cfg *config.Config, logger *logger.Logger) (*TranscriberService, error) {
+	if err := os.MkdirAll(cfg.Storage.RecordingsDir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create recordings directory: %w", err)
+	}
+
+	// Dev mode simulates the tools, so the server runs without ffmpeg and whisper
+	var capturer audiocapture.Capturer = audiocapture.FFmpeg{}
+	var transcoder audiocapture.Transcoder = audiocapture.FFmpeg{}
+	if cfg.DevMode {
+		logger.Info("Dev mode: recordings are copies of a sample and transcripts are generated")
+		capturer, transcoder = audiocapture.Sample{}, audiocapture.Sample{}
+		cfg.Transcription.Engine = config.TranscriptionEngineFake
+	}
+
+	engine, err := newEngine(cfg.Transcription, logger)
+	if err != nil {
+		logger.Error("Invalid transcription engine config, falling back to whisper", "error", err)
+		engine = &whisperEngine{logger: logger, options: WhisperOptions{Model: "medium", Language: "en"}}
+	}
+
+	promptStore, err := prompts.NewStore(cfg.Processing.TemplatesDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open prompt templates: %w", err)
+	}
+
+	cipher, err := encryption.Load(cfg.Storage.Encryption)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load encryption key: %w", err)
+	}
+
+	eventLog, err := events.Open(cfg.Storage.EventsFile, cipher)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open meeting event log: %w", err)
+	}
+	for _, skipped := range eventLog.Skipped() {
+		logger.Error("Skipped corrupt meeting event", "file", cfg.Storage.EventsFile, "event", skipped)
+	}
+
+	peopleStore, err := people.Open(cfg.People.File)
 	if err != nil {
-		logger.Error("Failed to create temp directory for recordings", "error", err)
-		return nil
+		return nil, fmt.Errorf("failed to open people directory: %w", err)
 	}
 
-	return &TranscriberService{
-		logger:    logger,
-		meetings:  make(map[string]*types.Meeting),
-		recordDir: tempDir,
+	if err := frontmatter.Validate(cfg.Frontmatter); err != nil {
+		return nil, fmt.Errorf("invalid frontmatter config: %w", err)
+	}
+
+	if err := validateAudioFormat(cfg.Audio); err != nil {
+		return nil, fmt.Errorf("invalid audio format config: %w", err)
+	}
+
+	if err := validateStages(cfg.Processing.Stages, cfg); err != nil {
+		return nil, fmt.Errorf("invalid processing stages config: %w", err)
+	}
+	for name, preset := range cfg.Presets {
+		if len(preset.Stages) == 0 {
+			continue
+		}
+		if err := validateStages(preset.Stages, cfg); err != nil {
+			return nil, fmt.Errorf("invalid processing stages of preset %s: %w", name, err)
+		}
+	}
+
+	deviceStore, err := devices.Open(cfg.Audio.DevicePreferencesFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open device preferences: %w", err)
+	}
+
+	namingTemplates, err := naming.New(cfg.Naming)
+	if err != nil {
+		return nil, fmt.Errorf("invalid naming config: %w", err)
+	}
+
+	transcriptRenderer, err := chapters.New(cfg.Vault.Transcript)
+	if err != nil {
+		return nil, fmt.Errorf("invalid vault transcript config: %w", err)
 	}
+
+	crmClient, err := crm.New(cfg.CRM)
+	if err != nil {
+		logger.Error("Invalid CRM config, CRM integration disabled", "error", err)
+	}
+
+	objectStore, err := objectstore.New(cfg.Storage.ObjectStorage)
+	if err != nil {
+		logger.Error("Invalid object storage config, uploads disabled", "error", err)
+	}
+
+	destinations, err := publish.New(cfg.Publish)
+	if err != nil {
+		return nil, fmt.Errorf("invalid publish config: %w", err)
+	}
+
+	artifactStore, err := artifacts.New(cfg.Storage.ArtifactsDir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open artifacts directory: %w", err)
+	}
+
+	if err := osoperations.SetVaultDir(cfg.Vault.Path); err != nil {
+		return nil, fmt.Errorf("invalid vault path: %w", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	t := &TranscriberService{
+		ctx:          ctx,
+		cancel:       cancel,
+		config:       cfg,
+		engine:       engine,
+		capturer:     capturer,
+		transcoder:   transcoder,
+		crm:          crmClient,
+		objects:      objectStore,
+		destinations: destinations,
+		prompts:      promptStore,
+		events:       eventLog,
+		cipher:       cipher,
+		people:       peopleStore,
+		devices:      deviceStore,
+		naming:       namingTemplates,
+		voiceMemos:   voicememos.New(cfg.VoiceMemos.Folder),
+		transcripts:  transcriptRenderer,
+		capabilities: &capabilityCache{},
+		logger:       logger,
+		meetings:     make(map[string]*types.Meeting),
+		queue:        newJobQueue(),
+		batches:      make(map[string]*ResummarizeBatch),
+		summaryJobs:  make(map[string]*SummaryJob),
+		playbackJobs: make(map[string]*playbackTranscode),
+		soundchecks:  make(map[string]*SoundcheckResult),
+		setup:        &setupState{downloads: make(map[string]string)},
+		embeddings:   &embeddingIndex{vectors: make(map[string]segmentEmbeddings)},
+		recordDir:    cfg.Storage.RecordingsDir,
+		artifacts:    artifactStore,
+		hooks:        make(chan hookRun, hookQueueSize),
+	}
+
+	t.killOrphans()
+	if err := t.restoreMeetings(); err != nil {
+		return nil, fmt.Errorf("failed to restore meetings from event log: %w", err)
+	}
+
+	go t.watchDeferred()
+	go t.runHooks()
+	go t.watchTempDirs()
+	if cfg.Storage.TrashDays > 0 {
+		go t.watchTrash()
+	}
+	if cfg.Calendar.URL != "" {
+		t.calendar = calendar.NewFeed(cfg.Calendar)
+		go t.watchCalendar()
+	}
+	if cfg.MeetingApps.Enabled {
+		t.meetingApps = meetingapps.NewDetector()
+		go t.watchMeetingApps()
+	}
+	if cfg.Watch.Folder != "" {
+		go t.watchFolder()
+	}
+	if cfg.Update.Enabled {
+		t.updates = update.NewChecker(cfg.Update)
+		go t.watchUpdates()
+	}
+
+	return t, nil
+}
+
+// RecordingOptions describes a meeting when its recording starts
+type RecordingOptions struct {
+	Title        string
+	Participants []string
+	Tags         []string
+	Metadata     map[string]string
+	Owner        string   // Authenticated user starting the recording
+	Template     string   // Summarization template, empty uses the preset or default template
+	Stages       []string // Processing stages, empty uses the preset or configured stages
+	Type         string   // Meeting preset providing the template, vault folder, participants and tags
+	Series       string   // Recurring meeting, empty groups meetings by title
+	MicDevice    string   // Capture devices, empty uses the devices last used for the series
+	SystemDevice string
+	App          string  // Records the audio of this app instead of the system device, e.g. Zoom
+	MicGain      float64 // Gains of the tracks in the mix, 0 uses the configured gain
+	SystemGain   float64
+	ExtraDevices []audiocapture.ExtraDevice // More input devices, each recorded as its own track
+
+	// Audio filters and mix mode overriding the configured ones when set
+	InputVolume    float64
+	NoiseReduction *bool
+	Loudnorm       *bool
+	MixMode        string
 }
 
-func (t *TranscriberService) StartRecording(title string, participants []string) (string, error) {
-	if title == "" {
-		title = "New Meeting"
+// ErrUnknownPreset is returned when a recording is started with an unknown meeting type
+var ErrUnknownPreset = errors.New("unknown meeting type")
+
+// applyPreset merges the preset of the meeting type into the options and checks the
+// template and stages, returning the vault folder of the preset
+func (t *TranscriberService) applyPreset(opts *RecordingOptions) (string, error) {
+	var vaultFolder string
+	if opts.Type != "" {
+		preset, ok := t.config.Presets[opts.Type]
+		if !ok {
+			return "", fmt.Errorf("%w: %s", ErrUnknownPreset, opts.Type)
+		}
+		if opts.Template == "" {
+			opts.Template = preset.Template
+		}
+		vaultFolder = preset.VaultFolder
+		opts.Participants = mergeUnique(preset.Participants, opts.Participants)
+		opts.Tags = mergeUnique(preset.Tags, opts.Tags)
+		if len(opts.Stages) == 0 {
+			opts.Stages = preset.Stages
+		}
+	}
+	if opts.Template != "" {
+		if _, err := t.prompts.Get(opts.Template); err != nil {
+			return "", err
+		}
+	}
+	if len(opts.Stages) > 0 {
+		if err := validateStages(opts.Stages, t.config); err != nil {
+			return "", err
+		}
+	}
+	return vaultFolder, nil
+}
+
+// StartRecording starts recording a meeting. Only one meeting is recorded at a time, a second
+// recording fails with ErrRecordingInProgress.
+func (t *TranscriberService) StartRecording(opts RecordingOptions) (string, error) {
+	t.recordMu.Lock()
+	defer t.recordMu.Unlock()
+	return t.startRecording(opts)
+}
+
+// startRecording starts a recording, with recordMu held
+func (t *TranscriberService) startRecording(opts RecordingOptions) (string, error) {
+	if opts.Owner == "" {
+		opts.Owner = t.autoOwner()
+	}
+	if t.stopping.Load() {
+		return "", ErrShuttingDown
+	}
+	if !t.Capabilities().Recording {
+		return "", ErrFFmpegMissing
+	}
+	if t.recordingActive() {
+		return "", ErrRecordingInProgress
+	}
+	if err := validateDevices(opts); err != nil {
+		return "", err
+	}
+	if err := validateMixMode(opts.MixMode, t.config.Audio.Format.Channels); err != nil {
+		return "", err
+	}
+	spaceWarning, err := t.checkRecordingSpace()
+	if err != nil {
+		return "", err
+	}
+	var appTap *audiocapture.AppTap
+	if opts.App != "" {
+		if appTap, err = t.appTap(opts.App); err != nil {
+			return "", err
+		}
+	}
+	t.applyCalendarEvent(&opts)
+	if opts.Title == "" {
+		opts.Title = "New Meeting"
+	}
+	opts.Tags = cleanTags(opts.Tags)
+
+	vaultFolder, err := t.applyPreset(&opts)
+	if err != nil {
+		return "", err
+	}
+	captureDevices, err := t.selectDevices(opts, appTap)
+	if err != nil {
+		return "", err
 	}
 	timestamp := time.Now()
-	meetingID := uuid.NewString()
-	t.meeting = &types.Meeting{
-		Id:            meetingID,
-		Title:         title,
+	meeting := &types.Meeting{
+		Id:            uuid.NewString(),
+		Title:         opts.Title,
 		CreatedAt:     timestamp,
 		Start_time:    timestamp,
 		Status:        string(types.MeetingStatusRecording),
-		Participants:  participants,
+		Participants:  opts.Participants,
+		Tags:          opts.Tags,
+		Metadata:      opts.Metadata,
+		Owner:         opts.Owner,
+		Template:      opts.Template,
+		Type:          opts.Type,
+		Series:        opts.Series,
+		VaultFolder:   vaultFolder,
+		Stages:        opts.Stages,
 		Audio_devices: []types.AudioDevice{}, // Initialize with empty slice instead of nil
 	}
-
-	// Store the meeting in the map for later retrieval
-	t.meetings[meetingID] = t.meeting
+	if spaceWarning != "" {
+		meeting.Warnings = append(meeting.Warnings, spaceWarning)
+		t.logger.Info("Recording with low disk space", "meetingId", meeting.Id, "warning", spaceWarning)
+	}
 
 	// Create output filepath
-	fileName := osoperations.FormatFileName("recording", t.meeting.CreatedAt, ".wav")
+	fileName := t.recordingFileName(meeting)
 	finalFilePath := osoperations.CreateFilePath(t.recordDir, fileName)
+	meeting.Transcript_path = finalFilePath // Recorded with the devices, so an interrupted recording can be recovered
 
 	// Create combined audio capture instance
-	audioCapture := audiocapture.NewCombinedAudio(finalFilePath)
+	mixOptions := t.recordingMixOptions(opts)
+	if len(captureDevices.Extra) > 0 && t.config.Audio.Archive.Codec != "" {
+		mixOptions.KeepTracks = true // Each device is archived as its own track
+	}
+	audioCapture := t.armedCapture(captureDevices, mixOptions)
+	armed := audioCapture != nil
+	if armed {
+		audioCapture.SetOutputPath(finalFilePath)
+	} else {
+		audioCapture = t.capturer.NewRecorder(finalFilePath, captureDevices, mixOptions)
+	}
+	if mixOptions.KeepTracks || armed {
+		// An armed capture keeps its own track names, they are listed so an interrupted
+		// recording can still be recovered
+		meeting.Tracks = audioCapture.GetTracks()
+	}
+	meeting.Audio_devices = []types.AudioDevice{
+		{Name: captureDevices.Mic, IsInput: true, Gain: captureDevices.MicGain},
+		{Name: captureDevices.System, IsSystem: true, Gain: captureDevices.SystemGain},
+	}
+	if appTap != nil {
+		meeting.Audio_devices[1].Name = appTap.App + " (app audio)"
+	}
+	for _, extra := range captureDevices.Extra {
+		meeting.Audio_devices = append(meeting.Audio_devices, types.AudioDevice{Name: extra.Device, IsInput: true, Gain: extra.Gain})
+	}
+	meeting.AudioFilters = audiocapture.AppliedFilters(captureDevices, mixOptions)
+	meeting.StereoSplit = mixOptions.StereoSplit
+
+	// The capture is started before it becomes the active recording, so a stop can't come
+	// before the start
+	if armed {
+		t.logger.Info("Recording with armed audio capture", "meetingId", meeting.Id, "title", meeting.Title)
+	} else {
+		t.logger.Info("Starting audio capture", "meetingId", meeting.Id, "title", meeting.Title)
+		if err := audioCapture.Start(t.ctx); err != nil {
+			return "", fmt.Errorf("failed to start audio capture: %w", err)
+		}
+	}
+
+	// The meeting and its recorder become the active recording together, so no one sees one
+	// without the other
+	t.mu.Lock()
+	if t.meeting != nil && t.meeting.Status == string(types.MeetingStatusRecording) {
+		t.mu.Unlock()
+		audioCapture.Cancel()
+		return "", ErrRecordingInProgress
+	}
+	t.meeting = meeting
 	t.recorder = audioCapture
+	t.meetings[meeting.Id] = meeting
+	t.mu.Unlock()
+	t.logger.Info("Audio capture started", "meetingId", meeting.Id, "file", finalFilePath)
 
-	go func() {
-		t.logger.Info("Starting audio capture", "meetingId", t.meeting.Id, "title", t.meeting.Title)
+	t.recordEvent(meeting, events.TypeCreated)
+	if spaceWarning != "" {
+		t.recordEvent(meeting, events.TypeWarning)
+	}
+	t.recordEvent(meeting, events.TypeDeviceSelected)
+	go t.watchSilence(meeting, audioCapture.GetTracks())
+	go t.watchAutoStop(meeting, audioCapture.GetTracks())
+	go t.watchCapture(meeting, audioCapture)
+	go t.watchDevices(meeting, audioCapture, captureDevices.Mic)
 
-		err := audioCapture.Start()
-		if err != nil {
-			t.logger.Error("Failed to start audio capture", err)
-			return
-		}
+	return meeting.Id, nil
+}
+
+// recordingActive reports whether a meeting is being recorded
+func (t *TranscriberService) recordingActive() bool {
+	meeting, _ := t.activeRecording()
+	return meeting != nil
+}
+
+// isRecording reports whether a meeting is still being recorded, read under the lock as a stop
+// changes its status
+func (t *TranscriberService) isRecording(meeting *types.Meeting) bool {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	return meeting.Status == string(types.MeetingStatusRecording)
+}
+
+// activeRecording returns the meeting being recorded and its recorder, read together under
+// the lock, or nil when nothing is being recorded
+func (t *TranscriberService) activeRecording() (*types.Meeting, audiocapture.Recorder) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.meeting == nil || t.meeting.Status != string(types.MeetingStatusRecording) {
+		return nil, nil
+	}
+	return t.meeting, t.recorder
+}
+
+// QuickStart starts a recording with opts unless a meeting is being recorded already, deciding
+// under the same lock as StartRecording so two quick starts can't both start one. It returns
+// the id of the meeting being recorded and whether this call started it.
+func (t *TranscriberService) QuickStart(opts RecordingOptions) (string, bool, error) {
+	t.recordMu.Lock()
+	defer t.recordMu.Unlock()
+	if meeting, _ := t.activeRecording(); meeting != nil {
+		return meeting.Id, false, nil
+	}
+	meetingId, err := t.startRecording(opts)
+	return meetingId, err == nil, err
+}
+
+// mixOptions returns how the tracks of a recording are mixed
+func (t *TranscriberService) mixOptions() audiocapture.MixOptions {
+	return audiocapture.MixOptions{
+		EchoCancellation: t.config.Audio.EchoCancellation.Enabled,
+		EchoFilterOrder:  t.config.Audio.EchoCancellation.FilterOrder,
+		EchoStepSize:     t.config.Audio.EchoCancellation.StepSize,
+		KeepTracks:       t.config.Transcription.DedupeTracks || t.config.Audio.Export.Enabled,
+		SegmentTime:      t.config.Audio.SegmentMinutes * 60,
+		InputVolume:      t.config.Audio.Filters.InputVolume,
+		NoiseReduction:   t.config.Audio.Filters.NoiseReduction.Enabled,
+		NoiseFloor:       t.config.Audio.Filters.NoiseReduction.NoiseFloor,
+		Loudnorm:         t.config.Audio.Filters.Loudnorm.Enabled,
+		LoudnessTarget:   t.config.Audio.Filters.Loudnorm.Target,
+		LoudnessTruePeak: t.config.Audio.Filters.Loudnorm.TruePeak,
+		LoudnessRange:    t.config.Audio.Filters.Loudnorm.Range,
+		StereoSplit:      t.config.Audio.MixMode == MixModeSplit,
+		Format:           audioFormat(t.config.Audio.Format),
+	}
+}
+
+// audioFormat returns the configured sample format of the tracks and the mix
+func audioFormat(cfg config.AudioFormatConfig) audiocapture.AudioFormat {
+	return audiocapture.AudioFormat{SampleRate: cfg.SampleRate, BitDepth: cfg.BitDepth, Channels: cfg.Channels}
+}
+
+// validateAudioFormat checks that the tracks can be recorded and mixed in the configured
+// format, and that the split mix mode has two channels to split the tracks over
+func validateAudioFormat(cfg config.AudioConfig) error {
+	format := audioFormat(cfg.Format)
+	if err := format.Validate(); err != nil {
+		return err
+	}
+	if cfg.MixMode == MixModeSplit && format.Channels != 2 {
+		return fmt.Errorf("mix mode %s needs 2 channels, the format has %d", MixModeSplit, format.Channels)
+	}
+	return nil
+}
+
+// recordingMixOptions returns how the tracks of a recording are mixed, with the audio
+// filters of the recording overriding the configured ones
+func (t *TranscriberService) recordingMixOptions(opts RecordingOptions) audiocapture.MixOptions {
+	mixOptions := t.mixOptions()
+	if opts.InputVolume > 0 {
+		mixOptions.InputVolume = opts.InputVolume
+	}
+	if opts.NoiseReduction != nil {
+		mixOptions.NoiseReduction = *opts.NoiseReduction
+	}
+	if opts.Loudnorm != nil {
+		mixOptions.Loudnorm = *opts.Loudnorm
+	}
+	if opts.MixMode != "" {
+		mixOptions.StereoSplit = opts.MixMode == MixModeSplit
+	}
+	return mixOptions
+}
 
-		t.logger.Info("Audio capture and merge completed successfully",
-			"meetingId", t.meeting.Id,
-			"file", finalFilePath,
-		)
+// Mix modes: blend mixes the tracks together, split puts the mic on the left channel and the
+// system audio on the right
+const (
+	MixModeBlend = "blend"
+	MixModeSplit = "split"
+)
 
-		t.meeting.Transcript_path = finalFilePath
-	}()
+// ErrUnknownMixMode is returned when a recording is started with an unknown mix mode
+var ErrUnknownMixMode = errors.New("unknown mix mode")
 
-	return t.meeting.Id, nil
+// validateMixMode checks the mix mode of a recording mixed to the number of channels, empty
+// uses the configured mode
+func validateMixMode(mode string, channels int) error {
+	if mode != "" && mode != MixModeBlend && mode != MixModeSplit {
+		return fmt.Errorf("%w %q, use %s or %s", ErrUnknownMixMode, mode, MixModeBlend, MixModeSplit)
+	}
+	if mode == MixModeSplit && channels == 1 {
+		return fmt.Errorf("%w %q: the audio format is mono", ErrUnknownMixMode, mode)
+	}
+	return nil
 }
 
+// mergeUnique appends the extra values to the defaults, skipping duplicates
+func mergeUnique(defaults []string, extra []string) []string {
+	merged := make([]string, 0, len(defaults)+len(extra))
+	for _, value := range append(append([]string{}, defaults...), extra...) {
+		if !slices.Contains(merged, value) {
+			merged = append(merged, value)
+		}
+	}
+	return merged
+}
+
+// ErrNotRecording is returned when stopping a meeting that isn't being recorded, including to
+// the losing caller when two stops race
+var ErrNotRecording = errors.New("meeting is not being recorded")
+
+// ErrAlreadyStopped is returned, with ErrNotRecording, when stopping a meeting that is no
+// longer being recorded, e.g. when a stop request is retried
+var ErrAlreadyStopped = errors.New("meeting already stopped")
+
 func (t *TranscriberService) StopMeeting(meetingId string) error {
 	// ===========================================================================
 	// Checks
 	// ===========================================================================
-	if t.meeting == nil || t.meeting.Id != meetingId {
-		return fmt.Errorf("no active meeting found with ID: %s", meetingId)
+	// The check and the switch to processing are done under one lock, so of two stops
+	// only one stops the recorder and queues the meeting
+	t.mu.Lock()
+	if t.meeting == nil || t.meeting.Id != meetingId || t.meeting.Status != string(types.MeetingStatusRecording) {
+		var status string
+		if meeting, exists := t.meetings[meetingId]; exists {
+			status = meeting.Status
+		}
+		t.mu.Unlock()
+		if status != "" && status != string(types.MeetingStatusRecording) {
+			return fmt.Errorf("%w: %w: meeting %s is %s", ErrNotRecording, ErrAlreadyStopped, meetingId, status)
+		}
+		return fmt.Errorf("%w: no active meeting found with ID: %s", ErrNotRecording, meetingId)
 	}
+
+	// ===========================================================================
+	// Update meeting
+	// ===========================================================================
+	// Store the meeting and recorder references for async processing
+	meeting := t.meeting
+	recorder := t.recorder
+
+	// Update status to indicate processing has begun
+	meeting.Status = string(types.MeetingStatusProcessing)
+	meeting.Duration = int(time.Since(meeting.Start_time).Seconds())
+	// Processing changes the meeting without the lock, so it stops being the active recording
+	t.meeting, t.recorder = nil, nil
+	t.mu.Unlock()
 	t.logger.Info("Stopping meeting", "meetingId", meetingId)
 
 	// ===========================================================================
 	// Stop the audio recorder
 	// ===========================================================================
-	if t.recorder != nil {
+	if recorder != nil {
 		t.logger.Debug("Stopping audio recorder", "meetingId", meetingId)
-		t.recorder.Stop()
+		recorder.Stop()
 	}
+	t.recordEvent(meeting, events.TypeStopped)
 
 	// ===========================================================================
-	// Update meetign
+	// Queue meeting for processing
 	// ===========================================================================
-	// Store the meeting reference for async processing
-	meeting := t.meeting
+	priority := tagPriority(t.config.Processing.TagPriorities, meeting.Tags)
+	t.logger.Info("Queueing meeting for processing", "meetingId", meetingId, "priority", priority)
+	t.queue.Enqueue(meetingId, priority, func() {
+		if !t.checkCapture(meeting, recorder) {
+			return
+		}
+		t.processMeeting(t.ctx, meeting)
+	})
 
-	// Update status to indicate processing has begun
-	meeting.Status = string(types.MeetingStatusProcessing)
-	meeting.Duration = int(time.Since(meeting.Start_time).Seconds())
+	// Return immediately after starting the processing
+	return nil
+}
 
-	// Update the meeting in the map
-	t.meetings[meetingId] = meeting
+// processMeeting runs the post-recording pipeline for a meeting
+func (t *TranscriberService) processMeeting(ctx context.Context, meeting *types.Meeting) {
+	// Check if the audio file exists
+	timeoutCounter := 0
+	for timeoutCounter < 10 {
+		if _, err := os.Stat(meeting.Transcript_path); err == nil {
+			t.setStatus(meeting, types.MeetingStatusRecordingCreated)
+			break
+		}
+		time.Sleep(1 * time.Second)
+		timeoutCounter++
+	}
+
+	if _, err := os.Stat(meeting.Transcript_path); os.IsNotExist(err) {
+		t.failMeeting(meeting, fmt.Sprintf("recording file not created: %s", meeting.Transcript_path), err)
+		return
+	}
 
 	// ===========================================================================
-	// Process meeting
+	// Archive a compressed copy of the recording
 	// ===========================================================================
-	go func() {
-		defer osoperations.RemoveTempDirectory(t.recordDir) // Clean up temp dir when done
+	t.archiveRecording(ctx, meeting)
 
-		// Check if the audio file exists
-		timeoutCounter := 0
-		for timeoutCounter < 10 {
-			time.Sleep(1 * time.Second)
-			if _, err := os.Stat(meeting.Transcript_path); err == nil {
-				meeting.Status = string(types.MeetingStatusRecordingCreated)
-				break
+	// ===========================================================================
+	// Compute the waveform for players
+	// ===========================================================================
+	t.createWaveform(meeting)
+
+	// ===========================================================================
+	// Hand off to an external transcription service
+	// ===========================================================================
+	if t.config.Transcription.Engine == config.TranscriptionEngineExternal {
+		// The recording is kept so it can be uploaded; the pipeline resumes when
+		// the transcript arrives through the webhook inbox
+		t.setStatus(meeting, types.MeetingStatusAwaitingTranscript)
+		t.recordEvent(meeting, events.TypeStatusChanged)
+		t.logger.Info("Waiting for external transcript", "meetingId", meeting.Id, "file", meeting.Transcript_path)
+		return
+	}
+
+	// ===========================================================================
+	// Defer processing while the transcription engine is unavailable
+	// ===========================================================================
+	if !t.Capabilities().Transcribe {
+		// The recording is kept and queued again once the engine is installed
+		t.setStatus(meeting, types.MeetingStatusDeferred)
+		t.recordEvent(meeting, events.TypeStatusChanged)
+		t.logger.Info("Transcription engine unavailable, deferring meeting", "meetingId", meeting.Id, "engine", t.engine.Name())
+		return
+	}
+	if err := t.checkTranscriptionSpace(meeting); err != nil {
+		// Queued again once there is enough free space
+		meeting.Warnings = append(meeting.Warnings, err.Error())
+		t.setStatus(meeting, types.MeetingStatusDeferred)
+		t.recordEvent(meeting, events.TypeStatusChanged)
+		t.logger.Info("Not enough disk space to transcribe, deferring meeting", "meetingId", meeting.Id, "error", err)
+		return
+	}
+
+	// ===========================================================================
+	// Transcribe meeting
+	// ===========================================================================
+	defer t.removeRecording(meeting) // Clean up the audio files when done
+
+	segments, err := t.transcribeMeeting(ctx, meeting)
+	if err != nil {
+		t.failMeeting(meeting, fmt.Sprintf("failed to transcribe audio: %v", err), err)
+		return
+	}
+	if len(segments) == 0 && len(meeting.Warnings) > 0 {
+		// Report the silent capture instead of publishing an empty transcript
+		t.failMeeting(meeting, fmt.Sprintf("no speech was transcribed: %s", strings.Join(meeting.Warnings, "; ")), nil)
+		return
+	}
+	t.diarize(meeting, segments)
+	markSegments(segments, meeting.Bookmarks)
+	meeting.Segments = segments
+	meeting.Transcript = FormatTranscript(meeting, segments)
+	t.setStatus(meeting, types.MeetingStatusTranscriptCreated)
+	t.recordEvent(meeting, events.TypeTranscribed)
+
+	t.summarizeAndPublish(ctx, meeting)
+	t.exportMeeting(meeting)
+	t.createHighlights(ctx, meeting)
+}
+
+// transcribeMeeting transcribes the meeting recording with the configured engine. When track
+// deduplication is enabled the mic and system tracks are transcribed separately and merged.
+func (t *TranscriberService) transcribeMeeting(ctx context.Context, meeting *types.Meeting) ([]types.Segment, error) {
+	t.logger.Info("Transcribing meeting", "meetingId", meeting.Id, "engine", t.engine.Name())
+	ctx, cancel := stageContext(ctx, t.config.Processing.TranscribeTimeout)
+	defer cancel()
+
+	workDir, err := t.artifacts.Create(meeting.Id, "transcribe")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create working directory: %w", err)
+	}
+	defer t.artifacts.Release(workDir)
+
+	if t.config.Transcription.DedupeTracks && len(meeting.Tracks) == 2 {
+		trackSegments := make(map[string][]types.Segment, len(meeting.Tracks))
+		for _, track := range meeting.Tracks {
+			segments, err := t.engine.Transcribe(ctx, track.Path, workDir, func(string) {})
+			if err != nil {
+				return nil, fmt.Errorf("failed to transcribe %s track: %w", track.Source, stageError(ctx, err))
 			}
-			timeoutCounter++
+			trackSegments[track.Source] = segments
 		}
 
-		if _, err := os.Stat(meeting.Transcript_path); os.IsNotExist(err) {
-			errorMsg := fmt.Sprintf("recording file not created: %s", meeting.Transcript_path)
-			t.logger.Error(errorMsg)
-			meeting.Status = string(types.MeetingStatusFailed)
-			meeting.Error = errorMsg
-			t.meetings[meetingId] = meeting
-			return
-		}
+		segments, dropped := mergeTrackSegments(trackSegments[types.TrackSourceMic], trackSegments[types.TrackSourceSystem])
+		t.logger.Info("Merged per-track transcripts", "meetingId", meeting.Id, "segments", len(segments), "duplicatesDropped", dropped)
+		return segments, nil
+	}
+
+	// Status requests read the partial transcript while the engine streams results
+	segments, err := t.engine.Transcribe(ctx, meeting.Transcript_path, workDir, func(text string) {
+		text = punctuation.Punctuate(text)
+		t.mu.Lock()
+		meeting.PartialTranscript = text
+		t.mu.Unlock()
+	})
+	t.mu.Lock()
+	meeting.PartialTranscript = ""
+	t.mu.Unlock()
+	return segments, stageError(ctx, err)
+}
+
+// summarizeAndPublish runs the stages after transcription for a transcribed meeting, in the
+// order they are listed
+func (t *TranscriberService) summarizeAndPublish(ctx context.Context, meeting *types.Meeting) {
+	ctx, cancel := stageContext(ctx, t.config.Processing.SummarizeTimeout)
+	defer cancel()
+
+	// ===========================================================================
+	// Normalize participant names against the people directory
+	// ===========================================================================
+	t.normalizeParticipants(meeting)
 
+	// ===========================================================================
+	// Detect the meeting type when none was given
+	// ===========================================================================
+	t.detectMeetingType(ctx, meeting)
+
+	// ===========================================================================
+	// Split long transcripts into chapters
+	// ===========================================================================
+	t.chapterTranscript(ctx, meeting)
+
+	// Notes and webhooks get the final status, so it is known before the stages run;
+	// while Ollama is unavailable, meetings to summarize are saved transcript-only
+	final := types.MeetingStatusCompleted
+	if t.runsStage(meeting, config.StageSummarize) && !t.Capabilities().Summarize {
+		final = types.MeetingStatusTranscriptOnly
+	}
+
+	for _, stage := range t.meetingStages(meeting) {
+		switch stage {
 		// ===========================================================================
-		// Transcribe meeting
+		// Summarize meeting
 		// ===========================================================================
-		transcriber := NewTranscriber(meeting.Transcript_path, t.logger, meeting)
-		transcription, err := transcriber.TranscribeAudio()
-		if err != nil {
-			errorMsg := fmt.Sprintf("failed to transcribe audio: %v", err)
-			t.logger.Error(errorMsg, "error", err)
-			meeting.Status = string(types.MeetingStatusFailed)
-			meeting.Error = errorMsg
-			t.meetings[meetingId] = meeting
-			return
-		}
-		meeting.Transcript = transcription
-		meeting.Status = string(types.MeetingStatusTranscriptCreated)
+		case config.StageSummarize:
+			if final == types.MeetingStatusTranscriptOnly {
+				continue
+			}
+			summary, err := t.summarizeForPipeline(ctx, meeting)
+			if err = stageError(ctx, err); err != nil {
+				t.failMeeting(meeting, fmt.Sprintf("failed to summarize transcription: %v", err), err)
+				return
+			}
+			meeting.Summary = summary
+			t.setStatus(meeting, types.MeetingStatusSummaryCreated)
 
 		// ===========================================================================
-		// Summarize meeting
+		// Extract the action items of the summary
 		// ===========================================================================
-		summary, err := t.Summarize()
-		if err != nil {
-			errorMsg := fmt.Sprintf("failed to summarize transcription: %v", err)
-			t.logger.Error(errorMsg, "error", err)
-			meeting.Status = string(types.MeetingStatusFailed)
-			meeting.Error = errorMsg
-			t.meetings[meetingId] = meeting
-			return
-		}
-		meeting.Summary = summary
-		meeting.Status = string(types.MeetingStatusSummaryCreated)
+		case config.StageExtractActions:
+			extractActions(meeting)
 
 		// ===========================================================================
-		// Save summary to vault
+		// Save the meeting to the vault, with its person notes and recording
 		// ===========================================================================
-		err = osoperations.SaveMeetingToVault(meeting)
-		if err != nil {
-			errorMsg := fmt.Sprintf("failed to save meeting to vault: %v", err)
-			t.logger.Error(errorMsg, "error", err)
-			meeting.Status = string(types.MeetingStatusFailed)
-			meeting.Error = errorMsg
-			t.meetings[meetingId] = meeting
-			return
+		case config.StageSaveVault:
+			t.createPersonNotes(meeting)
+			t.attachRecording(ctx, meeting)
+			note := *meeting
+			note.Status = string(final)
+			if err := t.saveToVault(&note); err != nil {
+				t.failMeeting(meeting, fmt.Sprintf("failed to save meeting to vault: %v", err), err)
+				return
+			}
+
+		// ===========================================================================
+		// Publish the meeting to Notion or Confluence
+		// ===========================================================================
+		case config.StageSaveNotion, config.StageSaveConfluence:
+			t.publishPage(ctx, meeting, stage)
+
+		// ===========================================================================
+		// Send the meeting to the webhook
+		// ===========================================================================
+		case config.StageWebhook:
+			// Webhook failures are logged but don't fail the meeting, like CRM failures
+			sent := *meeting
+			sent.Status = string(final)
+			if err := t.postWebhook(ctx, &sent); err != nil {
+				t.logger.Error("Failed to send meeting to webhook", "error", err, "meetingId", meeting.Id)
+			}
 		}
+	}
 
-		// Mark as completed if everything went well
-		meeting.Status = string(types.MeetingStatusCompleted)
-		t.meetings[meetingId] = meeting
-		t.logger.Info("Meeting processing completed successfully", "meetingId", meetingId)
-	}()
+	// ===========================================================================
+	// Attach summary to CRM contacts
+	// ===========================================================================
+	// CRM failures are logged but don't fail the meeting; the summary is already saved
+	if meeting.Summary != "" {
+		t.attachToCRM(ctx, meeting)
+	}
 
-	// Return immediately after starting the processing
-	return nil
+	t.setStatus(meeting, final)
+	switch {
+	case final == types.MeetingStatusTranscriptOnly:
+		t.recordEvent(meeting, events.TypeStatusChanged)
+		t.logger.Info("Ollama unavailable, saved meeting transcript-only", "meetingId", meeting.Id)
+	case meeting.Summary != "":
+		t.recordEvent(meeting, events.TypeSummarized)
+		t.logger.Info("Meeting processing completed successfully", "meetingId", meeting.Id)
+	default:
+		t.recordEvent(meeting, events.TypeStatusChanged)
+		t.logger.Info("Meeting processing completed successfully", "meetingId", meeting.Id, "stages", t.meetingStages(meeting))
+	}
+
+	// ===========================================================================
+	// Upload the archive and transcript to object storage
+	// ===========================================================================
+	t.uploadMeeting(ctx, meeting)
 }
 
-// GetMeetingStatus retrieves the status and details of a meeting by its ID
-func (t *TranscriberService) GetMeetingStatus(meetingId string) (*types.Meeting, error) {
-	// Check if the requested meeting is the current active meeting
-	if t.meeting != nil && t.meeting.Id == meetingId {
-		return t.meeting, nil
+// removeRecording deletes the audio files of a processed meeting
+func (t *TranscriberService) removeRecording(meeting *types.Meeting) {
+	if t.stopping.Load() && interruptedStatuses[types.MeetingStatus(meeting.Status)] {
+		return // Kept for the meeting to resume on the next start
+	}
+	paths := []string{meeting.Transcript_path}
+	for _, track := range meeting.Tracks {
+		paths = append(paths, track.Path)
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			t.logger.Error("Failed to remove recording", "error", err, "meetingId", meeting.Id, "file", path)
+		}
+	}
+}
+
+// failMeeting marks a meeting as failed with the given error message
+func (t *TranscriberService) failMeeting(meeting *types.Meeting, errorMsg string, err error) {
+	if t.stopping.Load() {
+		// Failures caused by the shutdown aren't recorded, so the meeting resumes on the next start
+		t.logger.Info("Meeting interrupted by shutdown, it resumes on the next start", "meetingId", meeting.Id, "status", meeting.Status)
+		return
+	}
+	t.logger.Error(errorMsg, "error", err, "meetingId", meeting.Id)
+	meeting.Error = errorMsg
+	t.setStatus(meeting, types.MeetingStatusFailed)
+	t.mu.Lock()
+	t.lastFailure = &Failure{MeetingId: meeting.Id, Error: errorMsg, Time: time.Now(), owner: meeting.Owner}
+	t.mu.Unlock()
+	t.recordEvent(meeting, events.TypeStatusChanged)
+}
+
+// setMeeting stores the meeting in the meetings map
+func (t *TranscriberService) setMeeting(meeting *types.Meeting) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.meetings[meeting.Id] = meeting
+}
+
+// setStatus changes the status of a meeting and stores it, under the lock as starts and stops
+// read the status to tell whether the meeting is being recorded
+func (t *TranscriberService) setStatus(meeting *types.Meeting, status types.MeetingStatus) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	meeting.Status = string(status)
+	t.meetings[meeting.Id] = meeting
+}
+
+// addWarning adds the warnings a meeting doesn't have yet under the lock, as watchers add them
+// while requests read and change the meeting, and records an event of eventType for them. It
+// reports whether any were added.
+func (t *TranscriberService) addWarning(meeting *types.Meeting, eventType string, warnings ...string) bool {
+	t.mu.Lock()
+	added := false
+	for _, warning := range warnings {
+		if !slices.Contains(meeting.Warnings, warning) {
+			meeting.Warnings = append(meeting.Warnings, warning)
+			added = true
+		}
 	}
+	if added {
+		t.meetings[meeting.Id] = meeting
+	}
+	t.mu.Unlock()
+
+	if added {
+		t.recordEvent(meeting, eventType)
+	}
+	return added
+}
 
-	// Check if the meeting exists in our meetings map
+// GetMeetingStatus retrieves the status and details of a meeting by its ID
+func (t *TranscriberService) GetMeetingStatus(meetingId string) (*types.Meeting, error) {
+	// The meeting being recorded is in the meetings map as well
+	t.mu.RLock()
+	defer t.mu.RUnlock()
 	if meeting, exists := t.meetings[meetingId]; exists {
 		return meeting, nil
 	}
@@ -206,8 +977,32 @@ func (t *TranscriberService) GetMeetingStatus(meetingId string) (*types.Meeting,
 	return nil, fmt.Errorf("meeting not found with ID: %s", meetingId)
 }
 
+// ActiveRecording returns a copy of the meeting being recorded, if any, taken under the lock
+// so its fields can be read while the recording changes. Its slices and maps are shared.
+func (t *TranscriberService) ActiveRecording() (*types.Meeting, bool) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.meeting == nil || t.meeting.Status != string(types.MeetingStatusRecording) {
+		return nil, false
+	}
+	recording := *t.meeting
+	return &recording, true
+}
+
+// Snapshot returns a copy of the meeting taken under the lock, for fields such as the
+// partial transcript that change while the meeting is processed. Its slices and maps are shared.
+func (t *TranscriberService) Snapshot(meeting *types.Meeting) *types.Meeting {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	snapshot := *meeting
+	return &snapshot
+}
+
 // GetAllMeetings returns all meetings (both active and completed)
 func (t *TranscriberService) GetAllMeetings() []*types.Meeting {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
 	meetings := make([]*types.Meeting, 0, len(t.meetings))
 
 	// Add all meetings from the map
@@ -0,0 +1,94 @@
+package transcriber
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+	"github.com/martijnspitter/transcriber/internal/logger"
+)
+
+// newTestService creates a service in dev mode, recording copies of the sample, with its
+// data in a temporary directory
+func newTestService(t *testing.T) *TranscriberService {
+	t.Helper()
+	dir := t.TempDir()
+	t.Setenv("HOME", dir)
+	t.Setenv("TRANSCRIBER_DATA_DIR", filepath.Join(dir, ".transcriber"))
+
+	cfg := config.Default()
+	cfg.DevMode = true
+	cfg.Vault.Path = filepath.Join(dir, "vault")
+	log, err := logger.New(config.LogConfig{Level: "error"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	service, err := NewTranscriberService(cfg, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(service.Shutdown)
+	return service
+}
+
+// TestConcurrentStartAndStop starts and stops recordings from many goroutines at once, run
+// with -race: only one start and one stop may win each round
+func TestConcurrentStartAndStop(t *testing.T) {
+	service := newTestService(t)
+	const goroutines = 8
+
+	for round := 0; round < 3; round++ {
+		var wg sync.WaitGroup
+		ids := make(chan string, goroutines)
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				// Titled per round, recordings of the same title in the same second share a file
+				id, err := service.StartRecording(RecordingOptions{Title: fmt.Sprintf("Standup %d", round)})
+				switch {
+				case err == nil:
+					ids <- id
+				case !errors.Is(err, ErrRecordingInProgress):
+					t.Errorf("unexpected start error: %v", err)
+				}
+				service.ActiveRecording()
+				service.GetAllMeetings()
+			}()
+		}
+		wg.Wait()
+		close(ids)
+		if len(ids) != 1 {
+			t.Fatalf("round %d: %d recordings started, want 1", round, len(ids))
+		}
+		id := <-ids
+
+		stopped := make(chan struct{}, goroutines)
+		for i := 0; i < goroutines; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := service.StopMeeting(id)
+				switch {
+				case err == nil:
+					stopped <- struct{}{}
+				case !errors.Is(err, ErrNotRecording):
+					t.Errorf("unexpected stop error: %v", err)
+				}
+				if _, err := service.GetMeetingStatus(id); err != nil {
+					t.Errorf("meeting %s is gone: %v", id, err)
+				}
+			}()
+		}
+		wg.Wait()
+		if len(stopped) != 1 {
+			t.Fatalf("round %d: recording stopped %d times, want 1", round, len(stopped))
+		}
+		if err := service.StopMeeting(id); !errors.Is(err, ErrAlreadyStopped) {
+			t.Errorf("round %d: stopping again returned %v, want ErrAlreadyStopped", round, err)
+		}
+	}
+}
@@ -0,0 +1,158 @@
+package transcriber
+
+import (
+	"errors"
+	"os"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// trashCheckInterval is how often meetings that were in the trash for too long are purged
+const trashCheckInterval = time.Hour
+
+// ErrInTrash is returned when archiving a meeting that is in the trash
+var ErrInTrash = errors.New("meeting is in the trash")
+
+// ArchiveMeeting hides a meeting from the meetings list. It is still found by search and
+// listed with archived=true. With a version, it is rejected when the meeting changed since.
+func (t *TranscriberService) ArchiveMeeting(meetingId string, version *int64) (*types.Meeting, error) {
+	return t.moveMeeting(meetingId, version, func(meeting *types.Meeting) (string, error) {
+		if !editable(meeting) {
+			return "", ErrMeetingBusy
+		}
+		if meeting.TrashedAt != nil {
+			return "", ErrInTrash
+		}
+		if meeting.ArchivedAt != nil {
+			return "", nil
+		}
+		now := time.Now()
+		meeting.ArchivedAt = &now
+		return events.TypeMeetingArchived, nil
+	})
+}
+
+// TrashMeeting moves a meeting to the trash, where it is kept for storage.trash_days before
+// it is purged with its recordings. Its vault note is left alone. With a version, it is
+// rejected when the meeting changed since.
+func (t *TranscriberService) TrashMeeting(meetingId string, version *int64) (*types.Meeting, error) {
+	return t.moveMeeting(meetingId, version, func(meeting *types.Meeting) (string, error) {
+		if !editable(meeting) {
+			return "", ErrMeetingBusy
+		}
+		if meeting.TrashedAt != nil {
+			return "", nil
+		}
+		now := time.Now()
+		meeting.TrashedAt = &now
+		return events.TypeTrashed, nil
+	})
+}
+
+// RestoreMeeting takes a meeting out of the trash, or out of the archive when it isn't in
+// the trash. A meeting that was archived before it was trashed goes back to the archive. With
+// a version, it is rejected when the meeting changed since.
+func (t *TranscriberService) RestoreMeeting(meetingId string, version *int64) (*types.Meeting, error) {
+	return t.moveMeeting(meetingId, version, func(meeting *types.Meeting) (string, error) {
+		switch {
+		case meeting.TrashedAt != nil:
+			meeting.TrashedAt = nil
+		case meeting.ArchivedAt != nil:
+			meeting.ArchivedAt = nil
+		default:
+			return "", nil
+		}
+		return events.TypeRestored, nil
+	})
+}
+
+// moveMeeting moves a meeting in or out of the archive or trash with move, which returns the
+// event to record, or none when the meeting is already where it should go. Like an edit, it
+// holds editMu from the version check until the event is recorded, and changes the meeting
+// under the lock as requests may be reading it.
+func (t *TranscriberService) moveMeeting(meetingId string, version *int64, move func(meeting *types.Meeting) (string, error)) (*types.Meeting, error) {
+	t.editMu.Lock()
+	defer t.editMu.Unlock()
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkVersion(meeting, version); err != nil {
+		return nil, err
+	}
+
+	t.mu.Lock()
+	eventType, err := move(meeting)
+	t.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if eventType != "" {
+		t.setMeeting(meeting)
+		t.recordEvent(meeting, eventType)
+	}
+	return meeting, nil
+}
+
+// watchTrash purges the meetings that were in the trash for longer than storage.trash_days,
+// on startup and every trashCheckInterval
+func (t *TranscriberService) watchTrash() {
+	retention := time.Duration(t.config.Storage.TrashDays) * 24 * time.Hour
+	ticker := time.NewTicker(trashCheckInterval)
+	defer ticker.Stop()
+
+	for ; t.ctx.Err() == nil; t.nextTick(ticker) {
+		for _, meeting := range t.GetAllMeetings() {
+			t.purgeExpired(meeting, retention)
+		}
+	}
+}
+
+// purgeExpired purges a meeting that was in the trash for longer than retention. It holds
+// editMu, so a meeting restored meanwhile is kept.
+func (t *TranscriberService) purgeExpired(meeting *types.Meeting, retention time.Duration) {
+	t.editMu.Lock()
+	defer t.editMu.Unlock()
+	if meeting.TrashedAt != nil && time.Since(*meeting.TrashedAt) > retention {
+		t.purgeMeeting(meeting)
+	}
+}
+
+// purgeMeeting deletes a meeting for good: its events, recordings, archive, export and
+// highlight reel
+func (t *TranscriberService) purgeMeeting(meeting *types.Meeting) {
+	if err := t.events.Remove(meeting.Id); err != nil && !errors.Is(err, events.ErrNotFound) {
+		t.logger.Error("Failed to purge meeting", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	t.mu.Lock()
+	delete(t.meetings, meeting.Id)
+	t.mu.Unlock()
+	t.embeddings.mu.Lock()
+	delete(t.embeddings.vectors, meeting.Id)
+	t.embeddings.mu.Unlock()
+
+	t.removeUploads(t.ctx, meeting)
+	t.removePlayback(meeting.Id)
+	if err := t.artifacts.Remove(meeting.Id); err != nil {
+		t.logger.Error("Failed to remove working directories of purged meeting", "error", err, "meetingId", meeting.Id)
+	}
+
+	paths := []string{meeting.Transcript_path, meeting.ArchivePath, meeting.ExportPath, meeting.HighlightsPath, t.waveformPath(meeting.Id)}
+	for _, tracks := range [][]types.AudioTrack{meeting.Tracks, meeting.ArchiveTracks} {
+		for _, track := range tracks {
+			paths = append(paths, track.Path)
+		}
+	}
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			t.logger.Error("Failed to remove file of purged meeting", "error", err, "meetingId", meeting.Id, "file", path)
+		}
+	}
+	t.logger.Info("Purged meeting from the trash", "meetingId", meeting.Id, "trashedAt", meeting.TrashedAt)
+}
@@ -0,0 +1,48 @@
+package transcriber
+
+import (
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/notify"
+	"github.com/martijnspitter/transcriber/internal/update"
+)
+
+// Updates returns the update checker, or nil when update checks are disabled
+func (t *TranscriberService) Updates() *update.Checker {
+	return t.updates
+}
+
+// watchUpdates checks for a newer release every configured interval and notifies once per
+// release found, until shutdown
+func (t *TranscriberService) watchUpdates() {
+	cfg := t.config.Update
+	interval := time.Duration(cfg.CheckHours) * time.Hour
+	if interval <= 0 {
+		interval = 24 * time.Hour
+	}
+
+	notified := ""
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		status, err := t.updates.Check()
+		switch {
+		case err != nil:
+			t.logger.Error("Failed to check for updates", "error", err)
+		case status.Available && status.Latest != notified:
+			t.logger.Info("Update available", "current", status.Current, "latest", status.Latest, "url", status.URL)
+			notified = status.Latest
+			if cfg.Notify {
+				if err := notify.Send("Transcriber "+status.Latest+" is available", "Run transcriber self-update to install it"); err != nil {
+					t.logger.Error("Failed to notify about update", "error", err)
+				}
+			}
+		}
+
+		select {
+		case <-t.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
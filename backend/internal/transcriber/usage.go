@@ -0,0 +1,65 @@
+package transcriber
+
+import (
+	"os"
+
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// StorageUsage is the disk and transcript storage consumed by a user's meetings
+type StorageUsage struct {
+	Meetings        int   `json:"meetings"`
+	AudioBytes      int64 `json:"audio_bytes"`      // Recordings, archived recordings and source tracks still on disk
+	TranscriptBytes int64 `json:"transcript_bytes"` // Transcripts, segments and summaries
+	TotalBytes      int64 `json:"total_bytes"`
+}
+
+// StorageUsage returns the storage consumed by the meetings of the owner. Without
+// authentication meetings have no owner and the empty owner covers all of them.
+func (t *TranscriberService) StorageUsage(owner string) StorageUsage {
+	var usage StorageUsage
+	for _, meeting := range t.GetAllMeetings() {
+		if meeting.Owner != owner {
+			continue
+		}
+		usage.Meetings++
+		usage.AudioBytes += meetingAudioBytes(meeting)
+		usage.TranscriptBytes += meetingTranscriptBytes(meeting)
+	}
+	usage.TotalBytes = usage.AudioBytes + usage.TranscriptBytes
+	return usage
+}
+
+// meetingAudioBytes sums the size of the meeting's audio files that still exist
+func meetingAudioBytes(meeting *types.Meeting) int64 {
+	paths := []string{meeting.Transcript_path, meeting.ArchivePath, meeting.ExportPath, meeting.HighlightsPath}
+	for _, track := range meeting.Tracks {
+		paths = append(paths, track.Path)
+	}
+
+	var total int64
+	for _, path := range paths {
+		if path == "" {
+			continue
+		}
+		if info, err := os.Stat(path); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// meetingTranscriptBytes estimates the text stored for a meeting
+func meetingTranscriptBytes(meeting *types.Meeting) int64 {
+	total := int64(len(meeting.Transcript) + len(meeting.Summary))
+	for _, segment := range meeting.Segments {
+		total += int64(len(segment.Text))
+	}
+	for _, version := range meeting.SummaryHistory {
+		total += int64(len(version.Summary))
+	}
+	for _, variant := range meeting.SummaryVariants {
+		total += int64(len(variant.Summary))
+	}
+	return total
+}
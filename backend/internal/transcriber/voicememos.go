@@ -0,0 +1,82 @@
+package transcriber
+
+import (
+	"fmt"
+	"maps"
+
+	"github.com/martijnspitter/transcriber/internal/voicememos"
+)
+
+// voiceMemoMetadataKey stores the Voice Memos recording a meeting was imported from
+const voiceMemoMetadataKey = "voice_memo"
+
+// VoiceMemo is a Voice Memos recording and the meeting it was imported as, if any
+type VoiceMemo struct {
+	voicememos.Memo
+	MeetingId string `json:"meeting_id,omitempty"`
+}
+
+// VoiceMemos lists the Voice Memos recordings, newest first: only those that weren't
+// imported yet, or all of them
+func (t *TranscriberService) VoiceMemos(all bool) ([]VoiceMemo, error) {
+	memos, err := t.voiceMemos.List()
+	if err != nil {
+		return nil, err
+	}
+	imported := make(map[string]string) // Memo -> meeting
+	for _, meeting := range t.GetAllMeetings() {
+		if memo := meeting.Metadata[voiceMemoMetadataKey]; memo != "" {
+			imported[memo] = meeting.Id
+		}
+	}
+
+	list := []VoiceMemo{}
+	for _, memo := range memos {
+		meetingId := imported[memo.Id]
+		if meetingId == "" || all {
+			list = append(list, VoiceMemo{Memo: memo, MeetingId: meetingId})
+		}
+	}
+	return list, nil
+}
+
+// ImportVoiceMemos queues Voice Memos recordings for the full pipeline, each as a meeting
+// dated when it was recorded and titled after the memo unless opts has a title. The
+// recordings are copied, so Voice Memos keeps them. It returns the IDs of the queued meetings.
+func (t *TranscriberService) ImportVoiceMemos(opts RecordingOptions, ids []string) ([]string, error) {
+	memos, err := t.voiceMemos.List()
+	if err != nil {
+		return nil, err
+	}
+	byId := make(map[string]voicememos.Memo, len(memos))
+	for _, memo := range memos {
+		byId[memo.Id] = memo
+	}
+	// Every memo is checked before any is queued
+	for _, id := range ids {
+		if _, ok := byId[id]; !ok {
+			return nil, fmt.Errorf("%w: %s", voicememos.ErrNotFound, id)
+		}
+	}
+
+	var meetingIds []string
+	for _, id := range ids {
+		memo := byId[id]
+		memoOpts := opts
+		if memoOpts.Title == "" {
+			memoOpts.Title = memo.Title
+		}
+		memoOpts.Metadata = maps.Clone(opts.Metadata)
+		if memoOpts.Metadata == nil {
+			memoOpts.Metadata = make(map[string]string)
+		}
+		memoOpts.Metadata[voiceMemoMetadataKey] = memo.Id
+
+		meetingId, err := t.processFile(memoOpts, memo.Path, true, memo.RecordedAt)
+		if err != nil {
+			return meetingIds, err
+		}
+		meetingIds = append(meetingIds, meetingId)
+	}
+	return meetingIds, nil
+}
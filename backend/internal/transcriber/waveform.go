@@ -0,0 +1,79 @@
+package transcriber
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+
+	audiocapture "github.com/martijnspitter/transcriber/internal/audio_capture"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+// ErrNoWaveform is returned for meetings whose waveform wasn't computed
+var ErrNoWaveform = errors.New("meeting has no waveform")
+
+// Waveform is the level of a recording over time, for drawing it in a player. Each level
+// covers an equal stretch of the recording, Duration divided by the number of levels.
+type Waveform struct {
+	Duration float64   `json:"duration"` // Seconds
+	Levels   []float64 `json:"levels"`   // RMS levels from 0 for silence to 1 for full scale
+}
+
+// waveformPath returns the file the waveform of a meeting is stored in
+func (t *TranscriberService) waveformPath(meetingId string) string {
+	return filepath.Join(t.config.Audio.Waveform.Dir, meetingId+".json")
+}
+
+// createWaveform computes the waveform of the mixed recording, when enabled. It's kept after
+// the recording is removed. Failures are logged and the meeting is processed anyway.
+func (t *TranscriberService) createWaveform(meeting *types.Meeting) {
+	cfg := t.config.Audio.Waveform
+	if !cfg.Enabled {
+		return
+	}
+	levels, duration, err := audiocapture.Waveform(meeting.Transcript_path, cfg.Buckets)
+	if err != nil {
+		t.logger.Error("Failed to compute waveform", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	for i, level := range levels {
+		levels[i] = math.Round(level*10000) / 10000
+	}
+
+	data, err := json.Marshal(Waveform{Duration: math.Round(duration*1000) / 1000, Levels: levels})
+	if err != nil {
+		t.logger.Error("Failed to encode waveform", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	if err := os.MkdirAll(cfg.Dir, 0700); err != nil {
+		t.logger.Error("Failed to create waveform directory", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	if err := os.WriteFile(t.waveformPath(meeting.Id), data, 0600); err != nil {
+		t.logger.Error("Failed to write waveform", "error", err, "meetingId", meeting.Id)
+		return
+	}
+	t.logger.Info("Computed waveform", "meetingId", meeting.Id, "levels", len(levels))
+}
+
+// MeetingWaveform returns the waveform of a meeting's recording
+func (t *TranscriberService) MeetingWaveform(meetingId string) (Waveform, error) {
+	if _, err := t.GetMeetingStatus(meetingId); err != nil {
+		return Waveform{}, err
+	}
+	data, err := os.ReadFile(t.waveformPath(meetingId))
+	if os.IsNotExist(err) {
+		return Waveform{}, fmt.Errorf("%w: %s", ErrNoWaveform, meetingId)
+	}
+	if err != nil {
+		return Waveform{}, err
+	}
+	var waveform Waveform
+	if err := json.Unmarshal(data, &waveform); err != nil {
+		return Waveform{}, fmt.Errorf("failed to read waveform: %w", err)
+	}
+	return waveform, nil
+}
@@ -0,0 +1,176 @@
+package transcriber
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/events"
+	"github.com/martijnspitter/transcriber/internal/types"
+)
+
+const assemblyAITranscriptURL = "https://api.assemblyai.com/v2/transcript/"
+
+// externalTranscript covers the callback payloads of the supported services:
+// Deepgram pre-recorded callbacks, AssemblyAI transcripts (or their webhook
+// notification) and a generic segments list
+type externalTranscript struct {
+	// Generic payload
+	Segments []types.Segment `json:"segments"`
+	Text     string          `json:"text"`
+
+	// Deepgram
+	Results *struct {
+		Utterances []struct {
+			Start      float64 `json:"start"`
+			End        float64 `json:"end"`
+			Transcript string  `json:"transcript"`
+			Speaker    *int    `json:"speaker"`
+		} `json:"utterances"`
+		Channels []struct {
+			Alternatives []struct {
+				Transcript string `json:"transcript"`
+			} `json:"alternatives"`
+		} `json:"channels"`
+	} `json:"results"`
+
+	// AssemblyAI
+	TranscriptId string `json:"transcript_id"`
+	Status       string `json:"status"`
+	Utterances   []struct {
+		Start   int64  `json:"start"` // Milliseconds
+		End     int64  `json:"end"`
+		Text    string `json:"text"`
+		Speaker string `json:"speaker"`
+	} `json:"utterances"`
+	AudioDuration float64 `json:"audio_duration"`
+}
+
+// segments converts the payload into transcript segments
+func (e *externalTranscript) segments() []types.Segment {
+	if len(e.Segments) > 0 {
+		return e.Segments
+	}
+
+	var segments []types.Segment
+	if e.Results != nil {
+		for _, utterance := range e.Results.Utterances {
+			segment := types.Segment{Start: utterance.Start, End: utterance.End, Text: utterance.Transcript}
+			if utterance.Speaker != nil {
+				segment.Speaker = fmt.Sprintf("Speaker %d", *utterance.Speaker)
+			}
+			segments = append(segments, segment)
+		}
+		if len(segments) == 0 && len(e.Results.Channels) > 0 && len(e.Results.Channels[0].Alternatives) > 0 {
+			segments = append(segments, types.Segment{Text: e.Results.Channels[0].Alternatives[0].Transcript})
+		}
+		return segments
+	}
+
+	for _, utterance := range e.Utterances {
+		segments = append(segments, types.Segment{
+			Start:   float64(utterance.Start) / 1000,
+			End:     float64(utterance.End) / 1000,
+			Text:    utterance.Text,
+			Speaker: utterance.Speaker,
+		})
+	}
+	if len(segments) == 0 && e.Text != "" {
+		segments = append(segments, types.Segment{End: e.AudioDuration, Text: e.Text})
+	}
+	return segments
+}
+
+// ParseExternalTranscript extracts segments from a transcript callback payload. AssemblyAI
+// webhooks only announce a finished transcript, which is then fetched with the configured API key.
+func (t *TranscriberService) ParseExternalTranscript(ctx context.Context, payload []byte) ([]types.Segment, error) {
+	var transcript externalTranscript
+	if err := json.Unmarshal(payload, &transcript); err != nil {
+		return nil, fmt.Errorf("invalid transcript payload: %w", err)
+	}
+
+	if transcript.Status == "error" {
+		return nil, fmt.Errorf("external transcription failed")
+	}
+
+	segments := transcript.segments()
+	if len(segments) == 0 && transcript.TranscriptId != "" {
+		fetched, err := t.fetchAssemblyAITranscript(ctx, transcript.TranscriptId)
+		if err != nil {
+			return nil, err
+		}
+		segments = fetched.segments()
+	}
+
+	if len(segments) == 0 {
+		return nil, fmt.Errorf("transcript payload contains no segments")
+	}
+	return segments, nil
+}
+
+// fetchAssemblyAITranscript downloads a completed AssemblyAI transcript
+func (t *TranscriberService) fetchAssemblyAITranscript(ctx context.Context, transcriptId string) (*externalTranscript, error) {
+	if t.config.Transcription.AssemblyAIKey == "" {
+		return nil, fmt.Errorf("AssemblyAI API key not configured, cannot fetch transcript %s", transcriptId)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, assemblyAITranscriptURL+transcriptId, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", t.config.Transcription.AssemblyAIKey)
+
+	client := http.Client{Timeout: 30 * time.Second}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch AssemblyAI transcript: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch AssemblyAI transcript: status %d", resp.StatusCode)
+	}
+
+	var transcript externalTranscript
+	if err := json.NewDecoder(resp.Body).Decode(&transcript); err != nil {
+		return nil, fmt.Errorf("failed to decode AssemblyAI transcript: %w", err)
+	}
+	return &transcript, nil
+}
+
+// AcceptExternalTranscript stores a transcript delivered by an external service and
+// queues the summarize and vault stages for the meeting
+func (t *TranscriberService) AcceptExternalTranscript(meetingId string, segments []types.Segment) error {
+	meeting, err := t.GetMeetingStatus(meetingId)
+	if err != nil {
+		return err
+	}
+
+	switch types.MeetingStatus(meeting.Status) {
+	case types.MeetingStatusAwaitingTranscript, types.MeetingStatusFailed, types.MeetingStatusCompleted:
+	default:
+		return fmt.Errorf("meeting %s cannot accept a transcript while %s", meetingId, meeting.Status)
+	}
+
+	for i := range segments {
+		segments[i].Text = strings.TrimSpace(segments[i].Text)
+	}
+
+	t.diarize(meeting, segments)
+	markSegments(segments, meeting.Bookmarks)
+	meeting.Segments = segments
+	meeting.Transcript = FormatTranscript(meeting, segments)
+	meeting.Error = ""
+	t.setStatus(meeting, types.MeetingStatusTranscriptCreated)
+	t.recordEvent(meeting, events.TypeTranscribed)
+
+	t.logger.Info("Received external transcript", "meetingId", meetingId, "segments", len(segments))
+	t.queue.Enqueue(meetingId, tagPriority(t.config.Processing.TagPriorities, meeting.Tags), func() {
+		t.summarizeAndPublish(t.ctx, meeting)
+	})
+
+	return nil
+}
@@ -5,36 +5,162 @@ import "time"
 type MeetingStatus string
 
 const (
-	MeetingStatusRecording         MeetingStatus = "recording"
-	MeetingStatusProcessing        MeetingStatus = "processing"
-	MeetingStatusRecordingCreated  MeetingStatus = "recording_created"
-	MeetingStatusTranscriptCreated MeetingStatus = "transcript_created"
-	MeetingStatusSummaryCreated    MeetingStatus = "summary_created"
-	MeetingStatusCompleted         MeetingStatus = "completed"
-	MeetingStatusFailed            MeetingStatus = "failed"
+	MeetingStatusRecording          MeetingStatus = "recording"
+	MeetingStatusDownloading        MeetingStatus = "downloading" // Downloading a recording from a URL
+	MeetingStatusProcessing         MeetingStatus = "processing"
+	MeetingStatusRecordingCreated   MeetingStatus = "recording_created"
+	MeetingStatusAwaitingTranscript MeetingStatus = "awaiting_transcript"
+	MeetingStatusDeferred           MeetingStatus = "deferred" // Recorded, waiting for a transcription engine to become available
+	MeetingStatusTranscriptCreated  MeetingStatus = "transcript_created"
+	MeetingStatusSummaryCreated     MeetingStatus = "summary_created"
+	MeetingStatusCompleted          MeetingStatus = "completed"
+	MeetingStatusTranscriptOnly     MeetingStatus = "transcript_only" // Completed without a summary because Ollama was unavailable
+	MeetingStatusFailed             MeetingStatus = "failed"
 )
 
 type Meeting struct {
-	Id              string        `json:"id"`
-	Title           string        `json:"title"`
-	Status          string        `json:"status"`
-	CreatedAt       time.Time     `json:"created_at"`
-	Start_time      time.Time     `json:"start_time"`
-	Participants    []string      `json:"participants"`
-	Transcript_path string        `json:"transcript_path"`
-	Duration        int           `json:"duration"` // in seconds
-	Audio_devices   []AudioDevice `json:"audio_devices"`
-	Transcript      string        `json:"transcript,omitempty"` // Optional, can be empty if not transcribed
-	Summary         string        `json:"summary,omitempty"`    // Optional, can be empty if not summarized
-	Error           string        `json:"error,omitempty"`      // Error message if processing failed
+	Id                string            `json:"id"`
+	Title             string            `json:"title"`
+	Series            string            `json:"series,omitempty"`        // Recurring meeting the recording belongs to
+	Type              string            `json:"type,omitempty"`          // Meeting preset the recording was started with
+	TypeDetected      bool              `json:"type_detected,omitempty"` // Set when the type was classified after recording
+	Status            string            `json:"status"`
+	CreatedAt         time.Time         `json:"created_at"`
+	Start_time        time.Time         `json:"start_time"`
+	Participants      []string          `json:"participants"`
+	Owner             string            `json:"owner,omitempty"` // Authenticated user who started the recording
+	Tags              []string          `json:"tags,omitempty"`
+	Metadata          map[string]string `json:"metadata,omitempty"`     // Integrator-defined keys, e.g. a Zoom meeting ID or CRM link
+	Template          string            `json:"template,omitempty"`     // Summarization template, empty uses the default
+	VaultFolder       string            `json:"vault_folder,omitempty"` // Vault folder of the meeting note, empty uses "meetings"
+	Stages            []string          `json:"stages,omitempty"`       // Processing stages, empty uses the configured stages
+	Transcript_path   string            `json:"transcript_path"`
+	AudioAttachment   string            `json:"audio_attachment,omitempty"` // Recording embedded in the note, relative to the vault root
+	ArchivePath       string            `json:"archive_path,omitempty"`     // Compressed recording kept after processing
+	ArchiveCodec      string            `json:"archive_codec,omitempty"`
+	ArchiveTracks     []AudioTrack      `json:"archive_tracks,omitempty"`  // Compressed track of each device, for recordings of more than two devices
+	ArchiveURL        string            `json:"archive_url,omitempty"`     // Archived recording in object storage
+	TranscriptURL     string            `json:"transcript_url,omitempty"`  // Transcript in object storage
+	PageURLs          map[string]string `json:"page_urls,omitempty"`       // Pages published to Notion and Confluence, by destination
+	ExportPath        string            `json:"export_path,omitempty"`     // Zip with the tracks, mix, SRT and note
+	HighlightsPath    string            `json:"highlights_path,omitempty"` // Short reel of the most informative minutes
+	Duration          int               `json:"duration"`                  // in seconds
+	Audio_devices     []AudioDevice     `json:"audio_devices"`
+	Tracks            []AudioTrack      `json:"tracks,omitempty"`             // Individual source tracks, when kept after mixing
+	AudioFilters      []AudioFilter     `json:"audio_filters,omitempty"`      // Filters the recording was captured and mixed with, in order
+	StereoSplit       bool              `json:"stereo_split,omitempty"`       // The recording has the mic on the left channel and the system audio on the right
+	ImportedFrom      string            `json:"imported_from,omitempty"`      // Format of the transcript the meeting was imported from, without a recording
+	Transcript        string            `json:"transcript,omitempty"`         // Optional, can be empty if not transcribed
+	Segments          []Segment         `json:"segments,omitempty"`           // Timestamped transcript segments
+	Chapters          []Chapter         `json:"chapters,omitempty"`           // Topics of long transcripts, in order
+	Bookmarks         []Bookmark        `json:"bookmarks,omitempty"`          // Moments marked while recording
+	Notes             []Note            `json:"notes,omitempty"`              // Notes taken while recording
+	Highlights        []Highlight       `json:"highlights,omitempty"`         // Clips of the highlight reel, in order
+	PartialTranscript string            `json:"partial_transcript,omitempty"` // Transcript so far while a streaming engine runs
+	Summary           string            `json:"summary,omitempty"`            // Optional, can be empty if not summarized
+	ActionItems       []string          `json:"action_items,omitempty"`       // Action items of the summary, from the extract-actions stage
+	Error             string            `json:"error,omitempty"`              // Error message if processing failed
+	Warnings          []string          `json:"warnings,omitempty"`           // Problems noticed while recording, e.g. a silent track
+	SummaryVariants   []SummaryVariant  `json:"summary_variants,omitempty"`   // Outputs of the A/B testing harness
+	SummaryHistory    []SummaryVersion  `json:"summary_history,omitempty"`    // Previous summaries, oldest first
+	SummaryReview     *SummaryReview    `json:"summary_review,omitempty"`     // Check of the summary against the transcript, when enabled
+	Edited            bool              `json:"edited"`                       // Set once the meeting has been edited by hand
+	EditedAt          *time.Time        `json:"edited_at,omitempty"`
+	ArchivedAt        *time.Time        `json:"archived_at,omitempty"` // Hidden from the meetings list, still found by search
+	TrashedAt         *time.Time        `json:"trashed_at,omitempty"`  // In the trash, purged after storage.trash_days
+	Version           int64             `json:"version"`               // Increases with every change, for optimistic concurrency
+	UpdatedAt         time.Time         `json:"updated_at"`            // When the meeting last changed
 }
 
-type AudioDevice struct {
-	ID        uint32 `json:"id"`
+// Bookmark marks a moment of the recording, e.g. a decision worth keeping
+type Bookmark struct {
+	At   float64 `json:"at"` // Seconds from the start of the recording
+	Note string  `json:"note,omitempty"`
+}
+
+// Note is free text written down while recording, given to the summarizer as context
+type Note struct {
+	At   float64 `json:"at"` // Seconds from the start of the recording
+	Text string  `json:"text"`
+}
+
+// Highlight is a clip of the recording selected for the highlight reel
+type Highlight struct {
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`
+	Score float64 `json:"score"`
+}
+
+// Chapter is a stretch of the transcript about one topic
+type Chapter struct {
+	Title string  `json:"title"`
+	Start float64 `json:"start"` // Seconds
+	End   float64 `json:"end"`
+}
+
+type SummaryVariant struct {
 	Name      string `json:"name"`
-	Channels  int    `json:"channels"`
-	IsInput   bool   `json:"is_input"`
-	IsOutput  bool   `json:"is_output"`
-	IsSystem  bool   `json:"is_system"`
-	IsDefault bool   `json:"is_default"`
+	Model     string `json:"model"`
+	Summary   string `json:"summary"`
+	Error     string `json:"error,omitempty"`
+	Preferred bool   `json:"preferred"` // Set when the user gave this variant a thumbs-up
+}
+
+// SummaryReview is the outcome of checking a summary against its transcript for invented
+// names, missing action items and a broken template structure
+type SummaryReview struct {
+	Score      int       `json:"score"`            // Out of 100
+	Passed     bool      `json:"passed"`           // The score reached the minimum, otherwise the best summary was kept
+	Attempts   int       `json:"attempts"`         // Summaries written, the first one included
+	Issues     []string  `json:"issues,omitempty"` // Problems found in the kept summary
+	ReviewedAt time.Time `json:"reviewed_at"`
+}
+
+// SummaryVersion is a summary that was replaced by a regenerated one
+type SummaryVersion struct {
+	Version    int       `json:"version"`
+	Summary    string    `json:"summary"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// Segment is a timestamped piece of the transcript, offsets are in seconds from the start of the recording
+type Segment struct {
+	Start    float64  `json:"start"`
+	End      float64  `json:"end"`
+	Text     string   `json:"text"`
+	Speaker  string   `json:"speaker,omitempty"`
+	Source   string   `json:"source,omitempty"`   // Track the segment was transcribed from, when transcribed per track
+	Language string   `json:"language,omitempty"` // Language the segment was transcribed in, when code-switching
+	Markers  []string `json:"markers,omitempty"`  // Labels of the bookmarks made while the segment was spoken
+}
+
+const (
+	TrackSourceMic    = "mic"
+	TrackSourceSystem = "system"
+)
+
+// AudioFilterMix is the track of an AudioFilter applied to the mix of the tracks
+const AudioFilterMix = "mix"
+
+// AudioFilter is an ffmpeg filter applied to a track of a recording, or to its mix
+type AudioFilter struct {
+	Track  string `json:"track"`  // Source of the track, or AudioFilterMix
+	Filter string `json:"filter"` // The filter with its options, e.g. afftdn=nf=-50
+}
+
+// AudioTrack is a single source recording of a meeting
+type AudioTrack struct {
+	Source string `json:"source"`
+	Path   string `json:"path"`
+}
+
+type AudioDevice struct {
+	ID        uint32  `json:"id"`
+	Name      string  `json:"name"`
+	Channels  int     `json:"channels"`
+	IsInput   bool    `json:"is_input"`
+	IsOutput  bool    `json:"is_output"`
+	IsSystem  bool    `json:"is_system"`
+	IsDefault bool    `json:"is_default"`
+	Gain      float64 `json:"gain,omitempty"` // Gain of the track in the mix
 }
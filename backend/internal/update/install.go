@@ -0,0 +1,213 @@
+package update
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// archAliases are the names release builds use for an architecture
+var archAliases = map[string][]string{
+	"amd64": {"amd64", "x86_64"},
+	"arm64": {"arm64", "aarch64"},
+}
+
+// Asset returns the build of the release for this platform, e.g. transcriber_darwin_arm64.tar.gz
+func (r *Release) Asset() (*Asset, error) {
+	arches := archAliases[runtime.GOARCH]
+	if arches == nil {
+		arches = []string{runtime.GOARCH}
+	}
+	for i, asset := range r.Assets {
+		name := strings.ToLower(asset.Name)
+		if !strings.Contains(name, runtime.GOOS) || strings.Contains(name, "checksum") {
+			continue
+		}
+		for _, arch := range arches {
+			if strings.Contains(name, arch) {
+				return &r.Assets[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("release %s has no build for %s/%s", r.Tag, runtime.GOOS, runtime.GOARCH)
+}
+
+// PublicKey is the base64 Ed25519 key release checksums are signed with, set at build time
+// with -ldflags "-X github.com/martijnspitter/transcriber/internal/update.PublicKey=..."
+var PublicKey = ""
+
+// Install downloads the build of the release for this platform and replaces the running
+// binary with it. The download is verified against the checksums file of the release, whose
+// signature is verified against the pinned public key; releases without either aren't
+// installed. It returns the path of the replaced binary.
+func (c *Checker) Install(release *Release) (string, error) {
+	publicKey, err := c.publicKey()
+	if err != nil {
+		return "", err
+	}
+	asset, err := release.Asset()
+	if err != nil {
+		return "", err
+	}
+	executable, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	if executable, err = filepath.EvalSymlinks(executable); err != nil {
+		return "", err
+	}
+
+	// Download next to the binary so it can be renamed over it
+	download, err := os.CreateTemp(filepath.Dir(executable), ".transcriber-download-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create download file: %w", err)
+	}
+	defer os.Remove(download.Name())
+	defer download.Close()
+	hash := sha256.New()
+	if err := c.fetch(asset.URL, io.MultiWriter(download, hash)); err != nil {
+		return "", err
+	}
+	if err := c.verify(release, publicKey, asset.Name, hex.EncodeToString(hash.Sum(nil))); err != nil {
+		return "", err
+	}
+	if _, err := download.Seek(0, io.SeekStart); err != nil {
+		return "", err
+	}
+
+	binary, err := os.CreateTemp(filepath.Dir(executable), ".transcriber-new-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create binary file: %w", err)
+	}
+	defer os.Remove(binary.Name())
+	defer binary.Close()
+	name := strings.ToLower(asset.Name)
+	if strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tgz") {
+		err = extractBinary(download, binary)
+	} else {
+		_, err = io.Copy(binary, download)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to unpack %s: %w", asset.Name, err)
+	}
+	if err := binary.Close(); err != nil {
+		return "", err
+	}
+	if err := os.Chmod(binary.Name(), 0755); err != nil {
+		return "", err
+	}
+	if err := os.Rename(binary.Name(), executable); err != nil {
+		return "", fmt.Errorf("failed to replace %s: %w", executable, err)
+	}
+	return executable, nil
+}
+
+// fetch downloads a URL into the writer
+func (c *Checker) fetch(url string, w io.Writer) error {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return fmt.Errorf("failed to download %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	_, err = io.Copy(w, resp.Body)
+	return err
+}
+
+// publicKey returns the key release checksums must be signed with: update.public_key, or
+// else the one built in
+func (c *Checker) publicKey() (ed25519.PublicKey, error) {
+	encoded := c.cfg.PublicKey
+	if encoded == "" {
+		encoded = PublicKey
+	}
+	if encoded == "" {
+		return nil, fmt.Errorf("no release signing key is pinned, set update.public_key to install updates")
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(encoded))
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("release signing key must be a base64 Ed25519 public key")
+	}
+	return key, nil
+}
+
+// verify checks the signature of the checksums file of the release, a base64 Ed25519
+// signature in <checksums file>.sig, and compares the checksum of a downloaded asset with it
+func (c *Checker) verify(release *Release, publicKey ed25519.PublicKey, assetName string, checksum string) error {
+	var checksums, signature *Asset
+	for i, asset := range release.Assets {
+		name := strings.ToLower(asset.Name)
+		switch {
+		case strings.Contains(name, "checksums") && strings.HasSuffix(name, ".sig"):
+			signature = &release.Assets[i]
+		case strings.Contains(name, "checksums"):
+			checksums = &release.Assets[i]
+		}
+	}
+	if checksums == nil {
+		return fmt.Errorf("release %s has no checksums file", release.Tag)
+	}
+	if signature == nil {
+		return fmt.Errorf("release %s has no signature of %s", release.Tag, checksums.Name)
+	}
+
+	var data, sig strings.Builder
+	if err := c.fetch(checksums.URL, &data); err != nil {
+		return err
+	}
+	if err := c.fetch(signature.URL, &sig); err != nil {
+		return err
+	}
+	decoded, err := base64.StdEncoding.DecodeString(strings.TrimSpace(sig.String()))
+	if err != nil || !ed25519.Verify(publicKey, []byte(data.String()), decoded) {
+		return fmt.Errorf("signature of %s doesn't match the release signing key", checksums.Name)
+	}
+	scanner := bufio.NewScanner(strings.NewReader(data.String()))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == assetName {
+			if !strings.EqualFold(fields[0], checksum) {
+				return fmt.Errorf("checksum mismatch for %s", assetName)
+			}
+			return nil
+		}
+	}
+	return fmt.Errorf("%s is missing from %s", assetName, checksums.Name)
+}
+
+// extractBinary writes the transcriber binary in a gzipped tarball to the writer
+func extractBinary(r io.Reader, w io.Writer) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+	archive := tar.NewReader(gz)
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return fmt.Errorf("no transcriber binary in archive")
+		}
+		if err != nil {
+			return err
+		}
+		if header.Typeflag == tar.TypeReg && strings.HasPrefix(filepath.Base(header.Name), "transcriber") {
+			_, err := io.Copy(w, archive)
+			return err
+		}
+	}
+}
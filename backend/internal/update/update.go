@@ -0,0 +1,213 @@
+// Package update checks GitHub releases for newer versions of the transcriber and replaces
+// the running binary with a release build
+package update
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/martijnspitter/transcriber/internal/config"
+)
+
+// Version of the running binary, set at build time with
+// -ldflags "-X github.com/martijnspitter/transcriber/internal/update.Version=v1.2.3"
+var Version = "dev"
+
+// Release channels
+const (
+	ChannelStable     = "stable"     // Releases only
+	ChannelPrerelease = "prerelease" // Releases and prereleases
+)
+
+// Release is a published GitHub release
+type Release struct {
+	Tag         string    `json:"tag_name"`
+	Name        string    `json:"name"`
+	URL         string    `json:"html_url"`
+	Draft       bool      `json:"draft"`
+	Prerelease  bool      `json:"prerelease"`
+	PublishedAt time.Time `json:"published_at"`
+	Assets      []Asset   `json:"assets"`
+}
+
+// Asset is a file attached to a release
+type Asset struct {
+	Name string `json:"name"`
+	URL  string `json:"browser_download_url"`
+}
+
+// Status is the outcome of the last check
+type Status struct {
+	Current   string    `json:"current"`
+	Channel   string    `json:"channel"`
+	Latest    string    `json:"latest,omitempty"`
+	Available bool      `json:"available"`
+	URL       string    `json:"url,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// Checker looks up the latest release of the configured channel
+type Checker struct {
+	mu     sync.RWMutex
+	cfg    config.UpdateConfig
+	client *http.Client
+	status Status
+}
+
+// NewChecker returns a checker for the configured repository and channel
+func NewChecker(cfg config.UpdateConfig) *Checker {
+	return &Checker{
+		cfg:    cfg,
+		client: &http.Client{Timeout: 30 * time.Second},
+		status: Status{Current: Version, Channel: cfg.Channel},
+	}
+}
+
+// Check fetches the latest release and reports whether it is newer than the running version
+func (c *Checker) Check() (Status, error) {
+	release, err := c.Latest()
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.CheckedAt = time.Now()
+	if err != nil {
+		c.status.Error = err.Error()
+		return c.status, err
+	}
+	c.status.Error = ""
+	c.status.Latest = release.Tag
+	c.status.URL = release.URL
+	c.status.Available = Newer(release.Tag, Version)
+	return c.status, nil
+}
+
+// Status returns the outcome of the last check
+func (c *Checker) Status() Status {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.status
+}
+
+// Latest returns the newest release of the channel
+func (c *Checker) Latest() (*Release, error) {
+	url := fmt.Sprintf("https://api.github.com/repos/%s/releases?per_page=30", c.cfg.Repository)
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch releases: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch releases: %s", resp.Status)
+	}
+
+	var releases []Release
+	if err := json.NewDecoder(resp.Body).Decode(&releases); err != nil {
+		return nil, fmt.Errorf("failed to decode releases: %w", err)
+	}
+	var latest *Release
+	for i, release := range releases {
+		if release.Draft || (release.Prerelease && c.cfg.Channel != ChannelPrerelease) {
+			continue
+		}
+		if latest == nil || Newer(release.Tag, latest.Tag) {
+			latest = &releases[i]
+		}
+	}
+	if latest == nil {
+		return nil, fmt.Errorf("no %s release found for %s", c.cfg.Channel, c.cfg.Repository)
+	}
+	return latest, nil
+}
+
+// Newer reports whether version a is newer than b. Versions are semantic versions with an
+// optional v prefix; a version that doesn't parse, such as a development build, is older
+// than any release.
+func Newer(a, b string) bool {
+	va, okA := parseVersion(a)
+	vb, okB := parseVersion(b)
+	switch {
+	case !okA:
+		return false
+	case !okB:
+		return true
+	}
+	for i := range va.numbers {
+		if va.numbers[i] != vb.numbers[i] {
+			return va.numbers[i] > vb.numbers[i]
+		}
+	}
+	return comparePrerelease(va.prerelease, vb.prerelease) > 0
+}
+
+// version is a parsed semantic version
+type version struct {
+	numbers    [3]int
+	prerelease string
+}
+
+// parseVersion parses a version like v1.2.3 or 1.2.3-rc.1, ignoring build metadata
+func parseVersion(value string) (version, bool) {
+	value = strings.TrimPrefix(strings.TrimSpace(value), "v")
+	value, _, _ = strings.Cut(value, "+")
+	core, prerelease, _ := strings.Cut(value, "-")
+	parts := strings.Split(core, ".")
+	if len(parts) == 0 || len(parts) > 3 {
+		return version{}, false
+	}
+	v := version{prerelease: prerelease}
+	for i, part := range parts {
+		n, err := strconv.Atoi(part)
+		if err != nil || n < 0 {
+			return version{}, false
+		}
+		v.numbers[i] = n
+	}
+	return v, true
+}
+
+// comparePrerelease orders prerelease identifiers, a release ranking above its prereleases
+func comparePrerelease(a, b string) int {
+	switch {
+	case a == b:
+		return 0
+	case a == "":
+		return 1
+	case b == "":
+		return -1
+	}
+	partsA, partsB := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		if partsA[i] == partsB[i] {
+			continue
+		}
+		na, errA := strconv.Atoi(partsA[i])
+		nb, errB := strconv.Atoi(partsB[i])
+		switch {
+		case errA == nil && errB == nil:
+			if na > nb {
+				return 1
+			}
+			return -1
+		case errA == nil:
+			return -1 // Numeric identifiers rank below alphanumeric ones
+		case errB == nil:
+			return 1
+		case partsA[i] > partsB[i]:
+			return 1
+		default:
+			return -1
+		}
+	}
+	return len(partsA) - len(partsB)
+}
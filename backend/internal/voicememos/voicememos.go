@@ -0,0 +1,197 @@
+// Package voicememos lists the recordings of Apple Voice Memos, from the app's storage
+// location or a folder of exported memos
+package voicememos
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+)
+
+// databaseName is the database the Voice Memos app keeps the titles and dates of its
+// recordings in, next to the recordings
+const databaseName = "CloudRecordings.db"
+
+// extensions are the file extensions of memos
+var extensions = []string{".m4a", ".qta"}
+
+// coreDataEpoch is the zero of the timestamps in the Voice Memos database
+var coreDataEpoch = time.Date(2001, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// recordedName matches the date and time Voice Memos names its recordings after, e.g.
+// "20240312 093015-1A2B3C4D.m4a"
+var recordedName = regexp.MustCompile(`^(\d{8} \d{6})`)
+
+// ErrNotFound is returned for memos that aren't in the library
+var ErrNotFound = errors.New("voice memo not found")
+
+// Memo is a Voice Memos recording
+type Memo struct {
+	Id         string    `json:"id"` // File name, unique in the library
+	Title      string    `json:"title"`
+	RecordedAt time.Time `json:"recorded_at"`
+	Duration   float64   `json:"duration,omitempty"` // Seconds, when the library has a database
+	Size       int64     `json:"size"`
+	Path       string    `json:"-"`
+}
+
+// Library is a folder of Voice Memos recordings
+type Library struct {
+	dir string
+}
+
+// DefaultDirs are the storage locations of the Voice Memos app, on macOS 14 and later and on
+// earlier versions
+func DefaultDirs() []string {
+	home, _ := os.UserHomeDir()
+	return []string{
+		filepath.Join(home, "Library", "Group Containers", "group.com.apple.VoiceMemos.shared", "Recordings"),
+		filepath.Join(home, "Library", "Application Support", "com.apple.voicememos", "Recordings"),
+	}
+}
+
+// New returns the library in the folder, or in the storage location of the Voice Memos app
+// when folder is empty
+func New(folder string) *Library {
+	if folder != "" {
+		return &Library{dir: folder}
+	}
+	dirs := DefaultDirs()
+	for _, dir := range dirs {
+		if _, err := os.Stat(dir); err == nil {
+			return &Library{dir: dir}
+		}
+	}
+	return &Library{dir: dirs[0]}
+}
+
+// Dir returns the folder of the library
+func (l *Library) Dir() string {
+	return l.dir
+}
+
+// List returns the memos in the library, newest first. Titles and dates come from the Voice
+// Memos database when there is one; otherwise memos are dated after their file name or when
+// the file was last modified, and titled after the file name unless it is a date.
+func (l *Library) List() ([]Memo, error) {
+	entries, err := os.ReadDir(l.dir)
+	if os.IsPermission(err) {
+		return nil, fmt.Errorf("no access to %s, grant the transcriber Full Disk Access in System Settings: %w", l.dir, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	records := l.records()
+
+	var memos []Memo
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !isMemo(name) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		memo := Memo{
+			Id:         name,
+			Title:      strings.TrimSuffix(name, filepath.Ext(name)),
+			RecordedAt: info.ModTime(),
+			Size:       info.Size(),
+			Path:       filepath.Join(l.dir, name),
+		}
+		if match := recordedName.FindStringSubmatch(name); match != nil {
+			if recordedAt, err := time.ParseInLocation("20060102 150405", match[1], time.Local); err == nil {
+				memo.RecordedAt = recordedAt
+				memo.Title = "Voice Memo " + recordedAt.Format("2006-01-02 15:04")
+			}
+		}
+		if record, ok := records[name]; ok {
+			if record.Title != "" {
+				memo.Title = record.Title
+			}
+			if record.Date > 0 {
+				memo.RecordedAt = coreDataEpoch.Add(time.Duration(record.Date * float64(time.Second))).Local()
+			}
+			memo.Duration = record.Duration
+		}
+		memos = append(memos, memo)
+	}
+
+	sort.Slice(memos, func(i, j int) bool {
+		return memos[i].RecordedAt.After(memos[j].RecordedAt)
+	})
+	return memos, nil
+}
+
+// record is a recording in the Voice Memos database
+type record struct {
+	Path     string
+	Title    string
+	Date     float64 // Seconds since the Core Data epoch
+	Duration float64
+}
+
+// records reads the recordings in the Voice Memos database with the sqlite3 command that
+// ships with macOS, by file name. Folders without a database, such as exported memos, or
+// databases that can't be read have none.
+func (l *Library) records() map[string]record {
+	records := make(map[string]record)
+	database := filepath.Join(l.dir, databaseName)
+	if _, err := os.Stat(database); err != nil {
+		return records
+	}
+	output, err := exec.Command("sqlite3", "-readonly", "-json", database,
+		"SELECT ZPATH AS path, ZENCRYPTEDTITLE AS title, ZDATE AS date, ZDURATION AS duration FROM ZCLOUDRECORDING").Output()
+	if err != nil {
+		return records
+	}
+
+	var rows []struct {
+		Path     *string  `json:"path"`
+		Title    *string  `json:"title"`
+		Date     *float64 `json:"date"`
+		Duration *float64 `json:"duration"`
+	}
+	if err := json.Unmarshal(output, &rows); err != nil {
+		return records
+	}
+	for _, row := range rows {
+		if row.Path == nil {
+			continue
+		}
+		r := record{Path: filepath.Base(*row.Path)}
+		if row.Title != nil {
+			r.Title = strings.TrimSpace(*row.Title)
+		}
+		if row.Date != nil {
+			r.Date = *row.Date
+		}
+		if row.Duration != nil {
+			r.Duration = *row.Duration
+		}
+		records[r.Path] = r
+	}
+	return records
+}
+
+// isMemo reports whether a file is a memo recording
+func isMemo(name string) bool {
+	if strings.HasPrefix(name, ".") {
+		return false
+	}
+	ext := strings.ToLower(filepath.Ext(name))
+	for _, extension := range extensions {
+		if ext == extension {
+			return true
+		}
+	}
+	return false
+}
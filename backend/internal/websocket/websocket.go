@@ -0,0 +1,264 @@
+package websocket
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// Message opcodes as defined in RFC 6455
+const (
+	OpContinuation = 0x0
+	OpText         = 0x1
+	OpBinary       = 0x2
+	OpClose        = 0x8
+	OpPing         = 0x9
+	OpPong         = 0xA
+)
+
+// maxMessageSize guards against unbounded frames from the server
+const maxMessageSize = 16 << 20
+
+// acceptGUID is appended to the handshake key to compute Sec-WebSocket-Accept
+const acceptGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// ErrClosed is returned when reading from a connection the server closed
+var ErrClosed = errors.New("websocket: connection closed")
+
+// Conn is a minimal client-side WebSocket connection
+type Conn struct {
+	conn    net.Conn
+	reader  *bufio.Reader
+	writeMu sync.Mutex
+}
+
+// Dial opens a WebSocket connection to a ws:// or wss:// URL with extra handshake headers.
+// The context bounds connecting only.
+func Dial(ctx context.Context, rawURL string, header http.Header) (*Conn, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid websocket url: %w", err)
+	}
+
+	host := u.Host
+	var conn net.Conn
+	dialer := &net.Dialer{Timeout: 15 * time.Second}
+	switch u.Scheme {
+	case "wss":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "443")
+		}
+		conn, err = (&tls.Dialer{NetDialer: dialer, Config: &tls.Config{ServerName: u.Hostname()}}).DialContext(ctx, "tcp", host)
+	case "ws":
+		if u.Port() == "" {
+			host = net.JoinHostPort(u.Hostname(), "80")
+		}
+		conn, err = dialer.DialContext(ctx, "tcp", host)
+	default:
+		return nil, fmt.Errorf("unsupported websocket scheme %q", u.Scheme)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", u.Host, err)
+	}
+
+	keyBytes := make([]byte, 16)
+	if _, err := rand.Read(keyBytes); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	key := base64.StdEncoding.EncodeToString(keyBytes)
+
+	req := &http.Request{
+		Method:     http.MethodGet,
+		URL:        u,
+		Host:       u.Host,
+		Header:     http.Header{},
+		Proto:      "HTTP/1.1",
+		ProtoMajor: 1,
+		ProtoMinor: 1,
+	}
+	for name, values := range header {
+		req.Header[name] = values
+	}
+	req.Header.Set("Upgrade", "websocket")
+	req.Header.Set("Connection", "Upgrade")
+	req.Header.Set("Sec-WebSocket-Key", key)
+	req.Header.Set("Sec-WebSocket-Version", "13")
+
+	conn.SetDeadline(time.Now().Add(15 * time.Second))
+	if err := req.Write(conn); err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to send handshake: %w", err)
+	}
+
+	reader := bufio.NewReader(conn)
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to read handshake response: %w", err)
+	}
+	if resp.StatusCode != http.StatusSwitchingProtocols {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		resp.Body.Close()
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake failed: %s %s", resp.Status, string(body))
+	}
+	if resp.Header.Get("Sec-WebSocket-Accept") != acceptKey(key) {
+		conn.Close()
+		return nil, fmt.Errorf("websocket handshake failed: invalid accept key")
+	}
+	conn.SetDeadline(time.Time{})
+
+	return &Conn{conn: conn, reader: reader}, nil
+}
+
+func acceptKey(key string) string {
+	hash := sha1.Sum([]byte(key + acceptGUID))
+	return base64.StdEncoding.EncodeToString(hash[:])
+}
+
+// WriteMessage sends a single unfragmented message. Client frames are always masked.
+func (c *Conn) WriteMessage(opcode int, payload []byte) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	header := []byte{0x80 | byte(opcode)}
+	length := len(payload)
+	switch {
+	case length < 126:
+		header = append(header, 0x80|byte(length))
+	case length <= 0xFFFF:
+		header = append(header, 0x80|126, 0, 0)
+		binary.BigEndian.PutUint16(header[2:], uint16(length))
+	default:
+		header = append(header, 0x80|127, 0, 0, 0, 0, 0, 0, 0, 0)
+		binary.BigEndian.PutUint64(header[2:], uint64(length))
+	}
+
+	mask := make([]byte, 4)
+	if _, err := rand.Read(mask); err != nil {
+		return err
+	}
+	header = append(header, mask...)
+
+	masked := make([]byte, length)
+	for i := range payload {
+		masked[i] = payload[i] ^ mask[i%4]
+	}
+
+	if _, err := c.conn.Write(append(header, masked...)); err != nil {
+		return fmt.Errorf("failed to write websocket frame: %w", err)
+	}
+	return nil
+}
+
+// ReadMessage reads the next text or binary message, answering pings and
+// reassembling fragmented messages along the way
+func (c *Conn) ReadMessage() (int, []byte, error) {
+	var messageType int
+	var message []byte
+
+	for {
+		fin, opcode, payload, err := c.readFrame()
+		if err != nil {
+			return 0, nil, err
+		}
+
+		switch opcode {
+		case OpPing:
+			if err := c.WriteMessage(OpPong, payload); err != nil {
+				return 0, nil, err
+			}
+			continue
+		case OpPong:
+			continue
+		case OpClose:
+			// Echo the close frame to complete the closing handshake
+			c.WriteMessage(OpClose, payload)
+			return 0, nil, ErrClosed
+		case OpText, OpBinary:
+			messageType = opcode
+			message = payload
+		case OpContinuation:
+			message = append(message, payload...)
+		default:
+			return 0, nil, fmt.Errorf("websocket: unknown opcode %d", opcode)
+		}
+
+		if len(message) > maxMessageSize {
+			return 0, nil, fmt.Errorf("websocket: message exceeds %d bytes", maxMessageSize)
+		}
+		if fin {
+			return messageType, message, nil
+		}
+	}
+}
+
+// readFrame reads a single frame from the connection
+func (c *Conn) readFrame() (bool, int, []byte, error) {
+	header := make([]byte, 2)
+	if _, err := io.ReadFull(c.reader, header); err != nil {
+		return false, 0, nil, err
+	}
+
+	fin := header[0]&0x80 != 0
+	opcode := int(header[0] & 0x0F)
+	masked := header[1]&0x80 != 0
+	length := uint64(header[1] & 0x7F)
+
+	switch length {
+	case 126:
+		ext := make([]byte, 2)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext))
+	case 127:
+		ext := make([]byte, 8)
+		if _, err := io.ReadFull(c.reader, ext); err != nil {
+			return false, 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext)
+	}
+	if length > maxMessageSize {
+		return false, 0, nil, fmt.Errorf("websocket: frame exceeds %d bytes", maxMessageSize)
+	}
+
+	var mask []byte
+	if masked {
+		mask = make([]byte, 4)
+		if _, err := io.ReadFull(c.reader, mask); err != nil {
+			return false, 0, nil, err
+		}
+	}
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, payload); err != nil {
+		return false, 0, nil, err
+	}
+	if masked {
+		for i := range payload {
+			payload[i] ^= mask[i%4]
+		}
+	}
+
+	return fin, opcode, payload, nil
+}
+
+// Close sends a normal closure frame and closes the underlying connection
+func (c *Conn) Close() error {
+	c.WriteMessage(OpClose, []byte{0x03, 0xE8}) // 1000: normal closure
+	return c.conn.Close()
+}
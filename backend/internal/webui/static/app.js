@@ -0,0 +1,286 @@
+'use strict';
+
+const API = '/api/v1';
+const PAGE_SIZE = 50;
+const KEY_STORAGE = 'transcriber-api-key';
+
+// Statuses of meetings the pipeline is still working on, followed until they change
+const ACTIVE_STATUSES = ['recording', 'downloading', 'processing', 'recording_created', 'transcript_created', 'summary_created'];
+
+const $ = (id) => document.getElementById(id);
+
+const state = {
+  offset: 0,
+  selected: null, // ID of the meeting shown
+  recording: null, // { id, title, startedAt } of the meeting being recorded
+  timer: null,
+};
+
+// el creates an element with properties and children; text is always set as text, never as HTML
+function el(tag, props = {}, ...children) {
+  const node = Object.assign(document.createElement(tag), props);
+  for (const child of children) {
+    if (child !== null && child !== undefined) {
+      node.append(child);
+    }
+  }
+  return node;
+}
+
+function idempotencyKey() {
+  if (window.crypto && crypto.randomUUID) {
+    return crypto.randomUUID();
+  }
+  return `${Date.now()}-${Math.random().toString(16).slice(2)}`;
+}
+
+async function api(path, options = {}) {
+  const headers = { ...(options.headers || {}) };
+  const key = localStorage.getItem(KEY_STORAGE);
+  if (key) {
+    headers['X-API-Key'] = key;
+  }
+  if (options.body) {
+    headers['Content-Type'] = 'application/json';
+  }
+  const response = await fetch(API + path, { ...options, headers, credentials: 'same-origin' });
+  if (response.status === 401) {
+    showLogin();
+    throw new Error('Authentication required');
+  }
+  const body = await response.json().catch(() => ({}));
+  if (!response.ok) {
+    throw new Error(body.error || response.statusText);
+  }
+  return body;
+}
+
+function showError(error) {
+  $('error').textContent = error ? error.message || String(error) : '';
+  $('error').hidden = !error;
+}
+
+function showLogin() {
+  $('login').hidden = false;
+  $('app').hidden = true;
+}
+
+function formatOffset(seconds) {
+  const total = Math.floor(seconds);
+  const minutes = Math.floor(total / 60);
+  const hours = Math.floor(minutes / 60);
+  const pad = (n) => String(n).padStart(2, '0');
+  return hours > 0 ? `${hours}:${pad(minutes % 60)}:${pad(total % 60)}` : `${minutes}:${pad(total % 60)}`;
+}
+
+function formatDate(value) {
+  return new Date(value).toLocaleString([], { dateStyle: 'medium', timeStyle: 'short' });
+}
+
+// Recording
+
+function showRecording() {
+  const recording = state.recording;
+  $('start-form').hidden = recording !== null;
+  $('recording').hidden = recording === null;
+  clearInterval(state.timer);
+  if (!recording) {
+    return;
+  }
+  $('recording-title').textContent = recording.title;
+  const tick = () => {
+    $('recording-time').textContent = formatOffset((Date.now() - recording.startedAt) / 1000);
+  };
+  tick();
+  state.timer = setInterval(tick, 1000);
+}
+
+async function startRecording(event) {
+  event.preventDefault();
+  const title = $('title').value.trim();
+  $('start').disabled = true;
+  showError(null);
+  try {
+    const response = await api('/recordings', {
+      method: 'POST',
+      headers: { 'Idempotency-Key': idempotencyKey() },
+      body: JSON.stringify({ title }),
+    });
+    state.recording = { id: response.meeting_id, title, startedAt: Date.now() };
+    $('title').value = '';
+    showRecording();
+    await loadMeetings(true);
+    selectMeeting(response.meeting_id);
+  } catch (error) {
+    showError(error);
+  } finally {
+    $('start').disabled = false;
+  }
+}
+
+async function stopRecording() {
+  const recording = state.recording;
+  $('stop').disabled = true;
+  showError(null);
+  try {
+    await api(`/recordings/${encodeURIComponent(recording.id)}/stop`, {
+      method: 'POST',
+      headers: { 'Idempotency-Key': idempotencyKey() },
+    });
+    state.recording = null;
+    showRecording();
+    await loadMeetings(true);
+    selectMeeting(recording.id);
+  } catch (error) {
+    showError(error);
+  } finally {
+    $('stop').disabled = false;
+  }
+}
+
+// restoreRecording picks up a recording started before the page was loaded
+async function restoreRecording() {
+  const response = await api('/meetings?status=recording&limit=1');
+  const meeting = response.meetings[0];
+  if (meeting) {
+    state.recording = { id: meeting.id, title: meeting.title, startedAt: new Date(meeting.created_at).getTime() };
+  }
+  showRecording();
+}
+
+// Meetings
+
+function meetingItem(meeting) {
+  const item = el('li', { className: meeting.id === state.selected ? 'selected' : '' },
+    el('div', {}, meeting.title || 'Untitled', el('span', { className: 'status', textContent: meeting.status })),
+    el('time', { textContent: formatDate(meeting.created_at) }));
+  item.dataset.id = meeting.id;
+  item.addEventListener('click', () => selectMeeting(meeting.id));
+  return item;
+}
+
+async function loadMeetings(reset) {
+  if (reset) {
+    state.offset = 0;
+  }
+  const response = await api(`/meetings?limit=${PAGE_SIZE}&offset=${state.offset}`);
+  const items = response.meetings.map(meetingItem);
+  if (reset) {
+    $('meeting-list').replaceChildren(...items);
+  } else {
+    $('meeting-list').append(...items);
+  }
+  state.offset += response.meetings.length;
+  $('more').hidden = response.next_offset === undefined;
+}
+
+function updateListItem(meeting) {
+  const item = document.querySelector(`#meeting-list li[data-id="${CSS.escape(meeting.id)}"]`);
+  if (item) {
+    item.replaceWith(meetingItem(meeting));
+  }
+}
+
+function renderMeeting(meeting) {
+  const details = [formatDate(meeting.created_at), meeting.status];
+  if (meeting.duration > 0) {
+    details.splice(1, 0, formatOffset(meeting.duration));
+  }
+  if (meeting.participants && meeting.participants.length > 0) {
+    details.push(meeting.participants.join(', '));
+  }
+
+  const children = [
+    el('h2', { textContent: meeting.title || 'Untitled' }),
+    el('p', { className: 'meta', textContent: details.join(' · ') }),
+  ];
+  if (meeting.error) {
+    children.push(el('p', { className: 'error', textContent: meeting.error }));
+  }
+  if (meeting.summary) {
+    children.push(el('h3', { textContent: 'Summary' }), el('div', { className: 'summary', textContent: meeting.summary }));
+  }
+  if (meeting.segments && meeting.segments.length > 0) {
+    children.push(el('h3', { textContent: 'Transcript' }));
+    for (const segment of meeting.segments) {
+      children.push(el('div', { className: 'segment' },
+        el('time', { textContent: formatOffset(segment.start) }),
+        el('p', {}, segment.speaker ? el('span', { className: 'speaker', textContent: `${segment.speaker}:` }) : null, segment.text)));
+    }
+  } else if (meeting.partial_transcript) {
+    children.push(el('h3', { textContent: 'Transcript so far' }), el('p', { textContent: meeting.partial_transcript }));
+  } else if (ACTIVE_STATUSES.includes(meeting.status)) {
+    children.push(el('p', { className: 'placeholder', textContent: 'The transcript appears here once the meeting is processed.' }));
+  }
+  $('meeting').replaceChildren(...children);
+}
+
+// selectMeeting shows a meeting and follows its status with long polling until the pipeline
+// is done with it or another meeting is selected
+async function selectMeeting(id) {
+  state.selected = id;
+  for (const item of document.querySelectorAll('#meeting-list li')) {
+    item.classList.toggle('selected', item.dataset.id === id);
+  }
+
+  let status = '';
+  while (state.selected === id) {
+    let meeting;
+    try {
+      const query = status ? `?wait=30s&status=${encodeURIComponent(status)}` : '';
+      meeting = await api(`/meetings/${encodeURIComponent(id)}${query}`);
+    } catch (error) {
+      if (state.selected === id) {
+        $('meeting').replaceChildren(el('p', { className: 'error', textContent: error.message }));
+      }
+      return;
+    }
+    if (state.selected !== id) {
+      return;
+    }
+    if (meeting.status !== status) {
+      renderMeeting(meeting);
+      updateListItem(meeting);
+      if (status === 'recording' && state.recording && state.recording.id === id) {
+        // Stopped elsewhere, e.g. by the auto-stop or another client
+        state.recording = null;
+        showRecording();
+      }
+    }
+    status = meeting.status;
+    if (!ACTIVE_STATUSES.includes(status)) {
+      return;
+    }
+  }
+}
+
+// Startup
+
+async function start() {
+  $('login').hidden = true;
+  try {
+    const session = await api('/auth/session');
+    $('connection').textContent = session.user ? `Signed in as ${session.user.username}` : '';
+    $('app').hidden = false;
+    await Promise.all([restoreRecording(), loadMeetings(true)]);
+    if (state.recording) {
+      selectMeeting(state.recording.id);
+    }
+  } catch (error) {
+    if ($('login').hidden) {
+      $('connection').textContent = `Cannot reach the server: ${error.message}`;
+    }
+  }
+}
+
+$('login-form').addEventListener('submit', (event) => {
+  event.preventDefault();
+  localStorage.setItem(KEY_STORAGE, $('api-key').value.trim());
+  $('api-key').value = '';
+  start();
+});
+$('start-form').addEventListener('submit', startRecording);
+$('stop').addEventListener('click', stopRecording);
+$('more').addEventListener('click', () => loadMeetings(false).catch(showError));
+
+start();
@@ -0,0 +1,20 @@
+// Package webui holds the bundled web UI, a single page to record meetings and read their
+// transcripts in a browser without the desktop app
+package webui
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed static
+var static embed.FS
+
+// Files returns the files of the web UI, with index.html at the root
+func Files() fs.FS {
+	files, err := fs.Sub(static, "static")
+	if err != nil {
+		panic(err) // The directory is embedded, so this can't happen
+	}
+	return files
+}
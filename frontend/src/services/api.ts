@@ -21,6 +21,18 @@ export interface Meeting {
   error?: string;
 }
 
+// Meetings are listed without their transcript and summary; fetch a meeting for its details
+export interface MeetingListItem {
+  id: string;
+  title: string;
+  status: Meeting['status'];
+  duration: number;
+  created_at: string;
+  tags?: string[];
+  archived_at?: string;
+  trashed_at?: string;
+}
+
 export interface AudioDevice {
   id: number;
   name: string;
@@ -55,7 +67,7 @@ export interface StopMeetingResponse {
 
 export interface MeetingsResponse {
   status: string;
-  meetings: Meeting[];
+  meetings: MeetingListItem[];
 }
 
 /**
@@ -131,7 +143,7 @@ export async function getMeeting(meetingId: string): Promise<Meeting> {
 /**
  * Get all meetings
  */
-export async function getAllMeetings(): Promise<Meeting[]> {
+export async function getAllMeetings(): Promise<MeetingListItem[]> {
   try {
     const response = await fetch(`${API_BASE_URL}/api/meetings`);
 